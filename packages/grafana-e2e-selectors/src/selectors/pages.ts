@@ -1037,6 +1037,15 @@ export const versionedPages = {
         toggleAccessButton: {
           '11.3.0': 'data-testid share externally pause or resume access button',
         },
+        rotateAccessTokenButton: {
+          '13.3.0': 'data-testid share externally rotate access token button',
+        },
+        expiryDateTimePicker: {
+          '13.3.0': 'data-testid share externally expiry date time picker',
+        },
+        rateLimitInput: {
+          '13.3.0': 'data-testid share externally rate limit input',
+        },
       },
     },
     ShareSnapshot: {
@@ -1,9 +1,9 @@
 import { lastValueFrom, of } from 'rxjs';
 
-import { type DataQueryRequest, getDefaultTimeRange } from '@grafana/data';
+import { type DataQueryRequest, getDefaultTimeRange, type TypedVariableModel } from '@grafana/data';
 
 import { config } from '../config';
-import { type BackendSrv, type BackendSrvRequest, type FetchResponse } from '../services';
+import { type BackendSrv, type BackendSrvRequest, type FetchResponse, setTemplateSrv, type TemplateSrv } from '../services';
 
 import { publicDashboardQueryHandler } from './publicDashboardQueryHandler';
 
@@ -68,4 +68,17 @@ describe('publicDashboardQueryHandler', () => {
     expect(response.data).toEqual([]);
     expect(fetchMock).not.toHaveBeenCalled();
   });
+
+  it('sends the selected values of custom variables', async () => {
+    const variables = [
+      { type: 'custom', name: 'env', current: { text: 'prod', value: 'prod' } },
+      { type: 'custom', name: 'region', current: { text: ['eu', 'us'], value: ['eu', 'us'] } },
+      { type: 'textbox', name: 'text', current: { text: 'free', value: 'free' } },
+    ] as unknown as TypedVariableModel[];
+    setTemplateSrv({ getVariables: () => variables } as unknown as TemplateSrv);
+
+    await lastValueFrom(publicDashboardQueryHandler(makeRequest({ panelId: 42 })));
+
+    expect(fetchMock.mock.calls[0][0].data.variables).toEqual({ env: ['prod'], region: ['eu', 'us'] });
+  });
 });
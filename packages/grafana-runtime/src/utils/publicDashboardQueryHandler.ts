@@ -4,6 +4,7 @@ import { type DataQuery, type DataQueryRequest, type DataQueryResponse } from '@
 
 import { config } from '../config';
 import { getBackendSrv } from '../services/backendSrv';
+import { getTemplateSrv } from '../services/templateSrv';
 
 import { type BackendDataSourceResponse, toDataQueryResponse } from './queryResponse';
 
@@ -34,6 +35,7 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
       to: toRange.valueOf().toString(),
       timezone: request.timezone,
     },
+    variables: getVariableValues(),
   };
 
   return getBackendSrv()
@@ -52,3 +54,22 @@ export function publicDashboardQueryHandler(request: DataQueryRequest<DataQuery>
       })
     );
 }
+
+/**
+ * Returns the selected values of the variables viewers can change. The server only accepts values of the variables
+ * defined in the dashboard, which are all custom variables in a public dashboard.
+ */
+function getVariableValues(): Record<string, string[]> {
+  const values: Record<string, string[]> = {};
+  for (const variable of getTemplateSrv()?.getVariables() ?? []) {
+    if (variable.type !== 'custom') {
+      continue;
+    }
+    const value = variable.current?.value;
+    if (value == null) {
+      continue;
+    }
+    values[variable.name] = Array.isArray(value) ? value : [value];
+  }
+  return values;
+}
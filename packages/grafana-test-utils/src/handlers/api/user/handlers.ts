@@ -28,11 +28,19 @@ export const getSignedInUserTeamListHandler = (teams: Array<{ uid: string; name:
     return HttpResponse.json(teams);
   });
 
+export const getUserTOTPStatusHandler = (
+  status = { available: false, enabled: false, enforced: false, recoveryCodesRemaining: 0 }
+) =>
+  http.get('/api/user/totp', async () => {
+    return HttpResponse.json(status);
+  });
+
 const handlers = [
   getPreferencesHandler(),
   updatePreferencesHandler(),
   patchPreferencesHandler(),
   getSignedInUserTeamListHandler(),
+  getUserTOTPStatusHandler(),
 ];
 
 export default handlers;
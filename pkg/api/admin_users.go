@@ -258,6 +258,12 @@ func (hs *HTTPServer) AdminDeleteUser(c *contextmodel.ReqContext) response.Respo
 		}
 		return nil
 	})
+	g.Go(func() error {
+		if err := hs.totpService.DeleteByUser(ctx, cmd.UserID); err != nil {
+			return err
+		}
+		return nil
+	})
 	if err := g.Wait(); err != nil {
 		return response.Error(http.StatusInternalServerError, "Failed to delete user", err)
 	}
@@ -49,6 +49,7 @@ func (hs *HTTPServer) GetAnnotations(c *contextmodel.ReqContext) response.Respon
 		Tags:         c.QueryStrings("tags"),
 		Type:         c.Query("type"),
 		MatchAny:     c.QueryBool("matchAny"),
+		Text:         c.Query("text"),
 		SignedInUser: c.SignedInUser,
 	}
 	if query.Limit == 0 {
@@ -601,7 +602,7 @@ type DeleteAnnotationByIDParams struct {
 
 // swagger:parameters getAnnotations
 type GetAnnotationsParams struct {
-	// Find annotations created after specific epoch datetime in milliseconds.
+	// Find annotations created after specific epoch datetime in milliseconds. When both from and to are set, region annotations that overlap the time range are returned.
 	// in:query
 	// required:false
 	From int64 `json:"from"`
@@ -660,6 +661,10 @@ type GetAnnotationsParams struct {
 	// in:query
 	// required:false
 	MatchAny bool `json:"matchAny"`
+	// Find annotations whose text contains all the words, ignoring case. Quote a phrase to match it as a whole.
+	// in:query
+	// required:false
+	Text string `json:"text"`
 }
 
 // swagger:parameters getAnnotationTags
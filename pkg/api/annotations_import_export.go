@@ -0,0 +1,323 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+)
+
+const (
+	// The idempotency key of an imported annotation is stored with the annotation, so that it is removed with it.
+	// Longer keys are stored as their hash.
+	maxAnnotationImportKeyLength = 190
+
+	annotationExportPageSize    = 1000
+	maxAnnotationImportLineSize = 1024 * 1024
+	maxAnnotationImportErrors   = 100
+)
+
+// swagger:route GET /annotations/export annotations exportAnnotations
+//
+// Export Annotations.
+//
+// Streams the annotations of the organization, or of a dashboard, as newline-delimited JSON. Each line can be imported with the Import Annotations operation.
+// Alert annotations are not exported.
+//
+// Produces:
+// - application/x-ndjson
+//
+// Responses:
+// 200: exportAnnotationsResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) ExportAnnotations(c *contextmodel.ReqContext) response.Response {
+	ctx := c.Req.Context()
+	query := &annotations.ItemQuery{
+		OrgID:        c.GetOrgID(),
+		DashboardUID: c.Query("dashboardUID"),
+		From:         c.QueryInt64("from"),
+		To:           c.QueryInt64("to"),
+		Type:         "annotation",
+		Limit:        annotationExportPageSize,
+		SignedInUser: c.SignedInUser,
+	}
+
+	items, err := hs.annotationsRepo.Find(ctx, query)
+	if err != nil {
+		return response.Error(http.StatusInternalServerError, "Failed to export annotations", err)
+	}
+
+	c.Resp.Header().Set("Content-Type", "application/x-ndjson")
+	c.Resp.Header().Set("Content-Disposition", `attachment; filename="annotations.ndjson"`)
+	c.Resp.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(c.Resp)
+	for len(items) > 0 {
+		for _, item := range items {
+			key := fmt.Sprintf("%s/annotations/%d", strings.TrimSuffix(hs.Cfg.AppURL, "/"), item.ID)
+			if item.ImportKey != nil {
+				// Keep the key of imported annotations, so that they are not duplicated when the export is imported into the original instance.
+				key = *item.ImportKey
+			}
+			line := dtos.AnnotationExportItem{
+				IdempotencyKey: key,
+				PanelId:        item.PanelID,
+				Time:           item.Time,
+				TimeEnd:        item.TimeEnd,
+				Text:           item.Text,
+				Tags:           item.Tags,
+				Data:           item.Data,
+			}
+			if item.DashboardUID != nil {
+				line.DashboardUID = *item.DashboardUID
+			}
+			if err := enc.Encode(line); err != nil {
+				hs.log.Warn("Failed to write annotation export", "error", err)
+				return nil
+			}
+		}
+
+		query.Offset += query.Limit
+		if items, err = hs.annotationsRepo.Find(ctx, query); err != nil {
+			// The response has already started, so the export can only be cut short.
+			hs.log.Error("Failed to export annotations", "error", err)
+			return nil
+		}
+	}
+	return nil
+}
+
+// swagger:route POST /annotations/import annotations importAnnotations
+//
+// Import Annotations.
+//
+// Creates the annotations of a newline-delimited JSON body in the format of the Export Annotations operation.
+// Annotations whose idempotency key was already imported are skipped, so the same export can be imported again without creating duplicates.
+// Annotations without an idempotency key are identified by their content.
+// Each line is imported on its own: the lines that cannot be imported are reported in the response and don't stop the import.
+//
+// Consumes:
+// - application/x-ndjson
+//
+// Responses:
+// 200: importAnnotationsResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) ImportAnnotations(c *contextmodel.ReqContext) response.Response {
+	dashboardUIDs := make(map[string]string)
+	for _, mapping := range c.QueryStrings("mapDashboard") {
+		source, target, ok := strings.Cut(mapping, ":")
+		if !ok || source == "" {
+			return response.Error(http.StatusBadRequest, fmt.Sprintf("Invalid dashboard mapping %q, expected <source UID>:<target UID>", mapping), nil)
+		}
+		dashboardUIDs[source] = target
+	}
+
+	importer := &annotationImporter{
+		hs:            hs,
+		c:             c,
+		dashboardUIDs: dashboardUIDs,
+		dashboards:    make(map[string]annotationImportDashboard),
+	}
+
+	result := dtos.AnnotationImportResult{}
+	addError := func(line int, err error) {
+		result.Failed++
+		if len(result.Errors) < maxAnnotationImportErrors {
+			result.Errors = append(result.Errors, dtos.AnnotationImportError{Line: line, Message: err.Error()})
+		}
+	}
+
+	scanner := bufio.NewScanner(c.Req.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAnnotationImportLineSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		imported, err := importer.importLine(raw)
+		switch {
+		case err != nil:
+			addError(line, err)
+		case imported:
+			result.Imported++
+		default:
+			result.Skipped++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		addError(line+1, fmt.Errorf("failed to read annotation: %w", err))
+	}
+
+	return response.JSON(http.StatusOK, result)
+}
+
+type annotationImportDashboard struct {
+	id  int64
+	err error
+}
+
+// annotationImporter imports the lines of an annotation import request.
+type annotationImporter struct {
+	hs            *HTTPServer
+	c             *contextmodel.ReqContext
+	dashboardUIDs map[string]string
+	// dashboards caches the resolved dashboards by UID, and whether the user can annotate them.
+	dashboards map[string]annotationImportDashboard
+}
+
+// importLine creates the annotation of a line, and returns false if its idempotency key was already imported.
+func (i *annotationImporter) importLine(raw []byte) (bool, error) {
+	ctx := i.c.Req.Context()
+
+	var item dtos.AnnotationExportItem
+	if err := json.Unmarshal(raw, &item); err != nil {
+		return false, fmt.Errorf("invalid annotation: %w", err)
+	}
+	if item.Text == "" {
+		return false, errors.New("text field should not be empty")
+	}
+
+	key := annotationImportKey(item)
+
+	if target, ok := i.dashboardUIDs[item.DashboardUID]; ok {
+		item.DashboardUID = target
+	}
+	dashboardID, err := i.dashboardID(item.DashboardUID)
+	if err != nil {
+		return false, err
+	}
+
+	userID, _ := identity.UserIdentifier(i.c.GetID())
+	annotation := annotations.Item{
+		OrgID:        i.c.GetOrgID(),
+		UserID:       userID,
+		DashboardID:  dashboardID,
+		DashboardUID: item.DashboardUID,
+		PanelID:      item.PanelId,
+		Epoch:        item.Time,
+		EpochEnd:     item.TimeEnd,
+		Text:         item.Text,
+		Data:         item.Data,
+		Tags:         item.Tags,
+		ImportKey:    &key,
+	}
+	if err := i.hs.annotationsRepo.Save(ctx, &annotation); err != nil {
+		if errors.Is(err, annotations.ErrImportKeyExists) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to save annotation: %w", err)
+	}
+	return true, nil
+}
+
+// dashboardID returns the ID of the dashboard the annotation is imported into, or an error if the user can't annotate it.
+func (i *annotationImporter) dashboardID(uid string) (int64, error) {
+	if cached, ok := i.dashboards[uid]; ok {
+		return cached.id, cached.err
+	}
+
+	dashboard := annotationImportDashboard{}
+	if uid != "" {
+		query := dashboards.GetDashboardQuery{OrgID: i.c.GetOrgID(), UID: uid}
+		result, err := i.hs.DashboardService.GetDashboard(i.c.Req.Context(), &query)
+		if err != nil {
+			dashboard.err = fmt.Errorf("dashboard %s not found", uid)
+		} else {
+			dashboard.id = result.ID
+		}
+	}
+	if dashboard.err == nil {
+		canSave, err := i.hs.canCreateAnnotation(i.c, uid)
+		switch {
+		case err != nil:
+			dashboard.err = fmt.Errorf("failed to check annotation permissions: %w", err)
+		case !canSave:
+			dashboard.err = errors.New("access denied to save the annotation")
+		}
+	}
+
+	i.dashboards[uid] = dashboard
+	return dashboard.id, dashboard.err
+}
+
+// annotationImportKey returns the key the annotation is stored with. Annotations without an idempotency key are identified
+// by their content, before the dashboard is mapped.
+func annotationImportKey(item dtos.AnnotationExportItem) string {
+	if item.IdempotencyKey == "" {
+		content, _ := json.Marshal(dtos.AnnotationExportItem{
+			DashboardUID: item.DashboardUID,
+			PanelId:      item.PanelId,
+			Time:         item.Time,
+			TimeEnd:      item.TimeEnd,
+			Text:         item.Text,
+			Tags:         item.Tags,
+		})
+		hash := sha256.Sum256(content)
+		return "content:" + hex.EncodeToString(hash[:])
+	}
+	if len(item.IdempotencyKey) > maxAnnotationImportKeyLength {
+		hash := sha256.Sum256([]byte(item.IdempotencyKey))
+		return "sha256:" + hex.EncodeToString(hash[:])
+	}
+	return item.IdempotencyKey
+}
+
+// swagger:parameters exportAnnotations
+type ExportAnnotationsParams struct {
+	// Only export the annotations of the dashboard.
+	// in:query
+	// required:false
+	DashboardUID string `json:"dashboardUID"`
+	// Only export the annotations that overlap the time range, in epoch milliseconds.
+	// in:query
+	// required:false
+	From int64 `json:"from"`
+	// in:query
+	// required:false
+	To int64 `json:"to"`
+}
+
+// swagger:parameters importAnnotations
+type ImportAnnotationsParams struct {
+	// Newline-delimited JSON annotations in the format of the export.
+	// in:body
+	// required:true
+	Body string `json:"body"`
+	// Imports the annotations of a source dashboard into another dashboard, in the format <source UID>:<target UID>.
+	// in:query
+	// required:false
+	// type: array
+	// collectionFormat: multi
+	MapDashboard []string `json:"mapDashboard"`
+}
+
+// swagger:response exportAnnotationsResponse
+type ExportAnnotationsResponse struct {
+	// Newline-delimited JSON annotations.
+	// in: body
+	Body string `json:"body"`
+}
+
+// swagger:response importAnnotationsResponse
+type ImportAnnotationsResponse struct {
+	// in: body
+	Body dtos.AnnotationImportResult `json:"body"`
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/acimpl"
+	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/annotations/annotationstest"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestAPI_ImportAnnotations(t *testing.T) {
+	repo := annotationstest.NewFakeAnnotationsRepo()
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.annotationsRepo = repo
+		hs.Features = featuremgmt.WithFeatures()
+		dashService := &dashboards.FakeDashboardService{}
+		dashService.On("GetDashboard", mock.Anything, mock.MatchedBy(func(q *dashboards.GetDashboardQuery) bool {
+			return q.UID == "staging-dash"
+		})).Return(&dashboards.Dashboard{ID: 7, UID: "staging-dash"}, nil)
+		dashService.On("GetDashboard", mock.Anything, mock.Anything).Return(nil, dashboards.ErrDashboardNotFound)
+		hs.DashboardService = dashService
+		hs.AccessControl = acimpl.ProvideAccessControl(featuremgmt.WithFeatures())
+	})
+	permissions := []accesscontrol.Permission{
+		{Action: accesscontrol.ActionAnnotationsCreate, Scope: dashboards.ScopeDashboardsAll},
+		{Action: accesscontrol.ActionAnnotationsCreate, Scope: accesscontrol.ScopeAnnotationsTypeOrganization},
+	}
+
+	body := strings.Join([]string{
+		`{"idempotencyKey":"k1","dashboardUID":"prod-dash","panelId":2,"time":1000,"timeEnd":2000,"text":"deploy of service X","tags":["deploy"]}`,
+		`{"time":3000,"text":"organization annotation"}`,
+		`not json`,
+		``,
+		`{"idempotencyKey":"k1","dashboardUID":"prod-dash","time":1000,"text":"deploy of service X"}`,
+		`{"dashboardUID":"unknown","time":1000,"text":"lost annotation"}`,
+	}, "\n")
+	importAnnotations := func(t *testing.T, path string) (*http.Response, dtos.AnnotationImportResult) {
+		t.Helper()
+		req := webtest.RequestWithSignedInUser(server.NewRequest(http.MethodPost, path, strings.NewReader(body)), authedUserWithPermissions(1, 1, permissions))
+		res, err := server.Send(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, res.Body.Close()) })
+		var result dtos.AnnotationImportResult
+		if res.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&result))
+		}
+		return res, result
+	}
+
+	t.Run("imports the annotations and reports the failed lines", func(t *testing.T) {
+		res, result := importAnnotations(t, "/api/annotations/import?mapDashboard=prod-dash:staging-dash")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 2, result.Imported)
+		assert.Equal(t, 1, result.Skipped)
+		assert.Equal(t, 2, result.Failed)
+		require.Len(t, result.Errors, 2)
+		assert.Equal(t, 3, result.Errors[0].Line)
+		assert.Equal(t, 6, result.Errors[1].Line)
+		assert.Contains(t, result.Errors[1].Message, "dashboard unknown not found")
+
+		items := repo.Items()
+		require.Len(t, items, 2)
+		assert.Equal(t, "staging-dash", items[1].DashboardUID)
+		assert.Equal(t, int64(7), items[1].DashboardID) // nolint: staticcheck
+		assert.Equal(t, int64(2), items[1].PanelID)
+		assert.Equal(t, int64(2000), items[1].EpochEnd)
+		assert.Equal(t, []string{"deploy"}, items[1].Tags)
+		assert.Equal(t, "organization annotation", items[2].Text)
+	})
+
+	t.Run("does not duplicate annotations imported again", func(t *testing.T) {
+		res, result := importAnnotations(t, "/api/annotations/import?mapDashboard=prod-dash:staging-dash")
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, 0, result.Imported)
+		assert.Equal(t, 3, result.Skipped)
+		assert.Equal(t, 2, result.Failed)
+		assert.Len(t, repo.Items(), 2)
+	})
+
+	t.Run("rejects invalid dashboard mappings", func(t *testing.T) {
+		res, _ := importAnnotations(t, "/api/annotations/import?mapDashboard=prod-dash")
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+}
+
+func TestAPI_ExportAnnotations(t *testing.T) {
+	repo := &annotations.FakeAnnotationsRepo{}
+	dashboardUID := "dash"
+	importKey := "original-key"
+	repo.On("Find", mock.Anything, mock.MatchedBy(func(q *annotations.ItemQuery) bool {
+		return q.Offset == 0 && q.Type == "annotation" && q.DashboardUID == dashboardUID && q.Limit == annotationExportPageSize
+	})).Return([]*annotations.ItemDTO{
+		{ID: 1, DashboardUID: &dashboardUID, PanelID: 2, Time: 1000, TimeEnd: 2000, Text: "deploy", Tags: []string{"deploy"}},
+		{ID: 2, DashboardUID: &dashboardUID, Time: 3000, TimeEnd: 3000, Text: "imported", ImportKey: &importKey},
+	}, nil)
+	repo.On("Find", mock.Anything, mock.Anything).Return([]*annotations.ItemDTO{}, nil)
+
+	server := SetupAPITestServer(t, func(hs *HTTPServer) {
+		hs.Cfg = setting.NewCfg()
+		hs.Cfg.AppURL = "https://grafana.example.com/"
+		hs.annotationsRepo = repo
+		hs.Features = featuremgmt.WithFeatures()
+		hs.AccessControl = acimpl.ProvideAccessControl(featuremgmt.WithFeatures())
+	})
+
+	permissions := []accesscontrol.Permission{{Action: accesscontrol.ActionAnnotationsRead, Scope: accesscontrol.ScopeAnnotationsAll}}
+	req := webtest.RequestWithSignedInUser(server.NewRequest(http.MethodGet, "/api/annotations/export?dashboardUID=dash", nil), authedUserWithPermissions(1, 1, permissions))
+	res, err := server.Send(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, res.Body.Close()) })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+
+	var lines []dtos.AnnotationExportItem
+	scanner := bufio.NewScanner(res.Body)
+	for scanner.Scan() {
+		var line dtos.AnnotationExportItem
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []dtos.AnnotationExportItem{
+		{IdempotencyKey: "https://grafana.example.com/annotations/1", DashboardUID: "dash", PanelId: 2, Time: 1000, TimeEnd: 2000, Text: "deploy", Tags: []string{"deploy"}},
+		{IdempotencyKey: "original-key", DashboardUID: "dash", Time: 3000, TimeEnd: 3000, Text: "imported"},
+	}, lines)
+}
@@ -117,6 +117,73 @@ func (hs *HTTPServer) CreateDashboardSnapshot(c *contextmodel.ReqContext) {
 	dashboardsnapshots.CreateDashboardSnapshot(c, cfg, cmd, hs.dashboardsnapshotsService)
 }
 
+// swagger:route POST /dashboards/uid/{uid}/snapshots dashboards snapshots renderDashboardSnapshot
+//
+// Create a snapshot of a dashboard by running its queries on the server.
+//
+// The queries of the dashboard panels run with the permissions of the caller, for the given time range and template
+// variable values, and their results are stored as a snapshot. Unlike `POST /snapshots`, it doesn't need the dashboard
+// to be rendered by a browser.
+//
+// Responses:
+// 200: createDashboardSnapshotResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) RenderDashboardSnapshot(c *contextmodel.ReqContext) {
+	if !hs.Cfg.SnapshotEnabled {
+		c.JsonApiErr(http.StatusForbidden, "Dashboard Snapshots are disabled", nil)
+		return
+	}
+
+	cmd := dashboardsnapshots.RenderDashboardSnapshotCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		c.JsonApiErr(http.StatusBadRequest, "bad request data", err)
+		return
+	}
+
+	dash, err := hs.DashboardService.GetDashboard(c.Req.Context(), &dashboards.GetDashboardQuery{UID: web.Params(c.Req)[":uid"], OrgID: c.GetOrgID()})
+	if err != nil {
+		if errors.Is(err, dashboards.ErrDashboardNotFound) {
+			c.JsonApiErr(http.StatusNotFound, "Dashboard not found", err)
+			return
+		}
+		c.JsonApiErr(http.StatusInternalServerError, "Failed to get dashboard", err)
+		return
+	}
+
+	svcs := dashboardsnapshots.RenderServices{
+		QueryData:       hs.queryDataService,
+		LibraryElements: hs.LibraryElementService,
+		DataSources:     hs.DataSourcesService,
+	}
+	rendered, err := dashboardsnapshots.RenderDashboard(c.Req.Context(), svcs, c.SignedInUser, dash.Data, cmd)
+	if err != nil {
+		hs.handleQueryMetricsError(err).WriteTo(c)
+		return
+	}
+
+	createCmd := dashboardsnapshots.CreateDashboardSnapshotCommand{}
+	createCmd.Name = cmd.Name
+	if createCmd.Name == "" {
+		createCmd.Name = dash.Title
+	}
+	createCmd.Expires = cmd.Expires
+	createCmd.External = cmd.External
+	createCmd.Dashboard = rendered
+
+	cfg := snapshot.SnapshotSharingOptions{
+		SnapshotsEnabled:      hs.Cfg.SnapshotEnabled,
+		ExternalEnabled:       hs.Cfg.ExternalEnabled,
+		ExternalSnapshotName:  hs.Cfg.ExternalSnapshotName,
+		ExternalSnapshotURL:   hs.Cfg.ExternalSnapshotUrl,
+		ExternalSnapshotToken: hs.Cfg.ExternalSnapshotToken,
+	}
+	dashboardsnapshots.CreateDashboardSnapshot(c, cfg, createCmd, hs.dashboardsnapshotsService)
+}
+
 // GET /api/snapshots/:key
 // swagger:route GET /snapshots/{key} dashboards snapshots getDashboardSnapshot
 //
@@ -339,6 +406,16 @@ type CreateSnapshotParams struct {
 	Body dashboardsnapshots.CreateDashboardSnapshotCommand `json:"body"`
 }
 
+// swagger:parameters renderDashboardSnapshot
+type RenderDashboardSnapshotParams struct {
+	// in:path
+	// required:true
+	UID string `json:"uid"`
+	// in:body
+	// required:true
+	Body dashboardsnapshots.RenderDashboardSnapshotCommand `json:"body"`
+}
+
 // swagger:parameters searchDashboardSnapshots
 type GetSnapshotsParams struct {
 	// Search Query
@@ -252,7 +252,7 @@ func newTestLive(t *testing.T) *live.GrafanaLive {
 		nil, nil, nil, nil,
 		&usagestats.UsageStatsMock{T: t},
 		featuremgmt.WithFeatures(),
-		&dashboards.FakeDashboardService{}, nil)
+		&dashboards.FakeDashboardService{}, nil, nil)
 
 	require.NoError(t, err)
 	return gLive
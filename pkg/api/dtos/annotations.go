@@ -38,3 +38,29 @@ type MassDeleteAnnotationsCmd struct {
 	AnnotationId int64  `json:"annotationId"`
 	DashboardUID string `json:"dashboardUID,omitempty"`
 }
+
+// AnnotationExportItem is a line of the NDJSON annotation export, which is also the format of the import.
+type AnnotationExportItem struct {
+	// IdempotencyKey identifies the annotation across Grafana instances. An annotation is imported only once per key.
+	IdempotencyKey string           `json:"idempotencyKey,omitempty"`
+	DashboardUID   string           `json:"dashboardUID,omitempty"`
+	PanelId        int64            `json:"panelId,omitempty"`
+	Time           int64            `json:"time"`
+	TimeEnd        int64            `json:"timeEnd,omitempty"`
+	Text           string           `json:"text"`
+	Tags           []string         `json:"tags,omitempty"`
+	Data           *simplejson.Json `json:"data,omitempty"`
+}
+
+type AnnotationImportResult struct {
+	Imported int `json:"imported"`
+	// Skipped is the number of annotations whose idempotency key was already imported.
+	Skipped int                     `json:"skipped"`
+	Failed  int                     `json:"failed"`
+	Errors  []AnnotationImportError `json:"errors,omitempty"`
+}
+
+type AnnotationImportError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
@@ -114,6 +114,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/tag"
 	"github.com/grafana/grafana/pkg/services/team"
 	tempUser "github.com/grafana/grafana/pkg/services/temp_user"
+	"github.com/grafana/grafana/pkg/services/totp"
 	"github.com/grafana/grafana/pkg/services/updatemanager"
 	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/services/validations"
@@ -233,6 +234,7 @@ type HTTPServer struct {
 	dsEndpointRedirects             *prometheus.CounterVec
 	dsConnectionClient              datasource.ConnectionClient
 	publicDashboardsService         publicdashboards.Service
+	totpService                     totp.Service
 }
 
 type TLSCerts struct {
@@ -287,6 +289,7 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 	starApi *starApi.API, promRegister prometheus.Registerer, anonService anonymous.Service,
 	clientConfigProvider grafanaapiserver.DirectRestConfigProvider, clientGenerator resource.ClientGenerator,
 	userVerifier user.Verifier, pluginPreinstall pluginchecker.Preinstall, publicDashboardsService publicdashboards.Service,
+	totpService totp.Service,
 ) (*HTTPServer, error) {
 	web.Env = cfg.Env
 	m := web.New()
@@ -395,6 +398,7 @@ func ProvideHTTPServer(opts ServerOptions, cfg *setting.Cfg, routeRegister routi
 		anonService:                  anonService,
 		userVerifier:                 userVerifier,
 		publicDashboardsService:      publicDashboardsService,
+		totpService:                  totpService,
 		htmlHandlerRequestsDuration: metricutil.NewHistogramVec(prometheus.HistogramOpts{
 			Namespace: "grafana",
 			Name:      "html_handler_requests_duration_seconds",
@@ -253,7 +253,8 @@ func (hs *HTTPServer) LoginPost(c *contextmodel.ReqContext) response.Response {
 	// Cap the request body up-front so any downstream consumer inherits the limit.
 	c.Req.Body = http.MaxBytesReader(c.Resp, c.Req.Body, maxPreAuthFormBodySize)
 
-	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientForm, &authn.Request{HTTPRequest: c.Req})
+	req := &authn.Request{HTTPRequest: c.Req}
+	identity, err := hs.authnService.Login(c.Req.Context(), authn.ClientForm, req)
 	if err != nil {
 		tokenErr := &auth.CreateTokenErr{}
 		if errors.As(err, &tokenErr) {
@@ -263,7 +264,7 @@ func (hs *HTTPServer) LoginPost(c *contextmodel.ReqContext) response.Response {
 	}
 
 	metrics.MApiLoginPost.Inc()
-	return authn.HandleLoginResponse(c.Req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo, hs.Features)
+	return authn.HandleLoginResponse(req, c.Resp, hs.Cfg, identity, hs.ValidateRedirectTo, hs.Features)
 }
 
 func (hs *HTTPServer) loginUserWithUser(user *user.User, c *contextmodel.ReqContext) error {
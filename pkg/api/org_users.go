@@ -695,6 +695,9 @@ func (hs *HTTPServer) removeOrgUserHelper(ctx context.Context, cmd *org.RemoveOr
 		if err := hs.accesscontrolService.DeleteUserPermissions(ctx, accesscontrol.GlobalOrgID, cmd.UserID); err != nil {
 			hs.log.Warn("failed to delete permissions for user", "userID", cmd.UserID, "orgID", accesscontrol.GlobalOrgID, "err", err)
 		}
+		if err := hs.totpService.DeleteByUser(ctx, cmd.UserID); err != nil {
+			hs.log.Warn("failed to delete two-factor authentication of user", "userID", cmd.UserID, "err", err)
+		}
 		return response.Success("User deleted")
 	}
 
@@ -0,0 +1,297 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	claims "github.com/grafana/authlib/types"
+	"github.com/grafana/grafana/pkg/api/response"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+// swagger:route GET /user/totp signed_in_user getUserTOTPStatus
+//
+// Get the two-factor authentication status of the actual User.
+//
+// Responses:
+// 200: getUserTOTPStatusResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) GetUserTOTPStatus(c *contextmodel.ReqContext) response.Response {
+	userID, errResponse := hs.totpUserID(c)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	status, err := hs.totpService.GetStatus(c.Req.Context(), userID)
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get two-factor authentication status", err)
+	}
+
+	return response.JSON(http.StatusOK, status)
+}
+
+// swagger:route POST /user/totp/enroll signed_in_user enrollUserTOTP
+//
+// Start setting up two-factor authentication for the actual User.
+//
+// Generates a new secret to add to an authenticator app. Two-factor authentication is only enabled once a code generated with the secret is sent to `/user/totp/activate`.
+//
+// Responses:
+// 200: enrollUserTOTPResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) EnrollUserTOTP(c *contextmodel.ReqContext) response.Response {
+	userID, errResponse := hs.totpUserID(c)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	enrollment, err := hs.totpService.Enroll(c.Req.Context(), userID, c.GetLogin())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to set up two-factor authentication", err)
+	}
+
+	return response.JSON(http.StatusOK, enrollment)
+}
+
+// swagger:route POST /user/totp/activate signed_in_user activateUserTOTP
+//
+// Enable two-factor authentication for the actual User.
+//
+// Enables two-factor authentication if the code was generated with the enrolled secret, and returns the recovery codes of the user. The recovery codes are only returned once.
+//
+// Responses:
+// 200: userTOTPRecoveryCodesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) ActivateUserTOTP(c *contextmodel.ReqContext) response.Response {
+	cmd := totp.CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	userID, errResponse := hs.totpUserID(c)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	recoveryCodes, err := hs.totpService.Activate(c.Req.Context(), userID, cmd.Code)
+	if err != nil {
+		return totpCodeErrorResponse(err, "Failed to enable two-factor authentication")
+	}
+
+	return response.JSON(http.StatusOK, recoveryCodes)
+}
+
+// swagger:route POST /user/totp/disable signed_in_user disableUserTOTP
+//
+// Disable two-factor authentication for the actual User.
+//
+// Requires a code generated by the authenticator app or a recovery code. Administrators can't disable two-factor authentication when it's enforced for them.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) DisableUserTOTP(c *contextmodel.ReqContext) response.Response {
+	cmd := totp.CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	userID, errResponse := hs.totpUserID(c)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	if err := hs.totpService.Disable(c.Req.Context(), userID, cmd.Code); err != nil {
+		return totpCodeErrorResponse(err, "Failed to disable two-factor authentication")
+	}
+
+	return response.Success("Two-factor authentication disabled")
+}
+
+// swagger:route POST /user/totp/recovery-codes signed_in_user regenerateUserTOTPRecoveryCodes
+//
+// Regenerate the recovery codes of the actual User.
+//
+// Replaces the recovery codes of the user, the previous codes can no longer be used. Requires a code generated by the authenticator app or a recovery code.
+//
+// Responses:
+// 200: userTOTPRecoveryCodesResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) RegenerateUserTOTPRecoveryCodes(c *contextmodel.ReqContext) response.Response {
+	cmd := totp.CodeCommand{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+	userID, errResponse := hs.totpUserID(c)
+	if errResponse != nil {
+		return errResponse
+	}
+
+	recoveryCodes, err := hs.totpService.RegenerateRecoveryCodes(c.Req.Context(), userID, cmd.Code)
+	if err != nil {
+		return totpCodeErrorResponse(err, "Failed to regenerate recovery codes")
+	}
+
+	return response.JSON(http.StatusOK, recoveryCodes)
+}
+
+// swagger:route DELETE /admin/users/{user_id}/totp admin_users adminResetUserTOTP
+//
+// Reset the two-factor authentication of a user.
+//
+// Disables two-factor authentication for a user that lost access to their authenticator app and recovery codes. When two-factor authentication is enforced, the user has to set it up again on their next login.
+// If you are running Grafana Enterprise and have Fine-grained access control enabled, you need to have a permission with action `users.password:write` and scope `global.users:*`.
+//
+// Security:
+// - basic:
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 500: internalServerError
+func (hs *HTTPServer) AdminResetUserTOTP(c *contextmodel.ReqContext) response.Response {
+	userID, err := strconv.ParseInt(web.Params(c.Req)[":id"], 10, 64)
+	if err != nil {
+		return response.Error(http.StatusBadRequest, "id is invalid", err)
+	}
+
+	if err := hs.totpService.Reset(c.Req.Context(), userID); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to reset two-factor authentication", err)
+	}
+
+	return response.Success("Two-factor authentication reset")
+}
+
+// swagger:route GET /org/totp org getCurrentOrgTOTPEnforcement
+//
+// Get the two-factor authentication enforcement of the current Organization.
+//
+// Responses:
+// 200: getCurrentOrgTOTPEnforcementResponse
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) GetCurrentOrgTOTPEnforcement(c *contextmodel.ReqContext) response.Response {
+	enforced, err := hs.totpService.GetOrgEnforcement(c.Req.Context(), c.GetOrgID())
+	if err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to get two-factor authentication enforcement", err)
+	}
+
+	return response.JSON(http.StatusOK, totp.OrgEnforcement{Enforced: enforced})
+}
+
+// swagger:route PUT /org/totp org updateCurrentOrgTOTPEnforcement
+//
+// Update the two-factor authentication enforcement of the current Organization.
+//
+// When enforced, the members of the organization that log in with their password have to set up two-factor authentication, and can't disable it.
+//
+// Responses:
+// 200: okResponse
+// 400: badRequestError
+// 401: unauthorisedError
+// 403: forbiddenError
+// 404: notFoundError
+// 500: internalServerError
+func (hs *HTTPServer) UpdateCurrentOrgTOTPEnforcement(c *contextmodel.ReqContext) response.Response {
+	cmd := totp.OrgEnforcement{}
+	if err := web.Bind(c.Req, &cmd); err != nil {
+		return response.Error(http.StatusBadRequest, "bad request data", err)
+	}
+
+	if err := hs.totpService.SetOrgEnforcement(c.Req.Context(), c.GetOrgID(), cmd.Enforced); err != nil {
+		return response.ErrOrFallback(http.StatusInternalServerError, "Failed to update two-factor authentication enforcement", err)
+	}
+
+	return response.Success("Two-factor authentication enforcement updated")
+}
+
+// totpUserID returns the id of the signed in user, two-factor authentication is only available to users
+func (hs *HTTPServer) totpUserID(c *contextmodel.ReqContext) (int64, response.Response) {
+	if !c.IsIdentityType(claims.TypeUser) {
+		return 0, response.Error(http.StatusForbidden, "entity not allowed to use two-factor authentication", nil)
+	}
+
+	userID, err := c.GetInternalID()
+	if err != nil {
+		return 0, response.Error(http.StatusInternalServerError, "failed to parse user id", err)
+	}
+
+	return userID, nil
+}
+
+// totpCodeErrorResponse returns a bad request for invalid codes, unauthorized responses are reserved for
+// unauthenticated requests
+func totpCodeErrorResponse(err error, message string) response.Response {
+	if errors.Is(err, totp.ErrInvalidCode) {
+		return response.Error(http.StatusBadRequest, "Invalid verification code", err)
+	}
+	return response.ErrOrFallback(http.StatusInternalServerError, message, err)
+}
+
+// swagger:parameters activateUserTOTP disableUserTOTP regenerateUserTOTPRecoveryCodes
+type UserTOTPCodeParams struct {
+	// in:body
+	// required:true
+	Body totp.CodeCommand `json:"body"`
+}
+
+// swagger:parameters adminResetUserTOTP
+type AdminResetUserTOTPParams struct {
+	// in:path
+	// required:true
+	UserID int64 `json:"user_id"`
+}
+
+// swagger:parameters updateCurrentOrgTOTPEnforcement
+type UpdateCurrentOrgTOTPEnforcementParams struct {
+	// in:body
+	// required:true
+	Body totp.OrgEnforcement `json:"body"`
+}
+
+// swagger:response getCurrentOrgTOTPEnforcementResponse
+type GetCurrentOrgTOTPEnforcementResponse struct {
+	// in:body
+	Body totp.OrgEnforcement `json:"body"`
+}
+
+// swagger:response getUserTOTPStatusResponse
+type GetUserTOTPStatusResponse struct {
+	// in:body
+	Body totp.Status `json:"body"`
+}
+
+// swagger:response enrollUserTOTPResponse
+type EnrollUserTOTPResponse struct {
+	// in:body
+	Body totp.Enrollment `json:"body"`
+}
+
+// swagger:response userTOTPRecoveryCodesResponse
+type UserTOTPRecoveryCodesResponse struct {
+	// in:body
+	Body totp.RecoveryCodes `json:"body"`
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/totp/totptest"
+	"github.com/grafana/grafana/pkg/web/webtest"
+)
+
+func TestHTTPServer_UserTOTP(t *testing.T) {
+	t.Run("should return the status of the signed in user", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.totpService = &totptest.FakeService{ExpectedStatus: &totp.Status{Available: true, Enabled: true, RecoveryCodesRemaining: 3}}
+		})
+
+		res, err := server.Send(webtest.RequestWithSignedInUser(server.NewGetRequest("/api/user/totp"), userWithPermissions(1, nil)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var status totp.Status
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&status))
+		assert.Equal(t, totp.Status{Available: true, Enabled: true, RecoveryCodesRemaining: 3}, status)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("should return the recovery codes when activated", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.totpService = &totptest.FakeService{ExpectedRecoveryCodes: &totp.RecoveryCodes{Codes: []string{"abcde-fghij"}}}
+		})
+
+		req := server.NewPostRequest("/api/user/totp/activate", strings.NewReader(`{"code": "123456"}`))
+		res, err := server.Send(webtest.RequestWithSignedInUser(req, userWithPermissions(1, nil)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var recoveryCodes totp.RecoveryCodes
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&recoveryCodes))
+		assert.Equal(t, []string{"abcde-fghij"}, recoveryCodes.Codes)
+		require.NoError(t, res.Body.Close())
+	})
+
+	t.Run("should return bad request for an invalid code", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.totpService = &totptest.FakeService{ExpectedErr: totp.ErrInvalidCode.Errorf("invalid code")}
+		})
+
+		req := server.NewPostRequest("/api/user/totp/disable", strings.NewReader(`{"code": "123456"}`))
+		res, err := server.Send(webtest.RequestWithSignedInUser(req, userWithPermissions(1, nil)))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, "Invalid verification code", body["message"])
+		require.NoError(t, res.Body.Close())
+	})
+}
+
+func TestHTTPServer_OrgTOTPEnforcement(t *testing.T) {
+	tests := []struct {
+		name         string
+		permissions  []accesscontrol.Permission
+		expectedCode int
+	}{
+		{
+			name:         "org admins can enforce two-factor authentication",
+			permissions:  []accesscontrol.Permission{{Action: accesscontrol.ActionOrgsWrite}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "other users can't enforce two-factor authentication",
+			permissions:  []accesscontrol.Permission{{Action: accesscontrol.ActionOrgsRead}},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := SetupAPITestServer(t, func(hs *HTTPServer) {
+				hs.totpService = &totptest.FakeService{}
+			})
+
+			req := server.NewRequest(http.MethodPut, "/api/org/totp", strings.NewReader(`{"enforced": true}`))
+			res, err := server.SendJSON(webtest.RequestWithSignedInUser(req, userWithPermissions(1, tt.permissions)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, res.StatusCode)
+			require.NoError(t, res.Body.Close())
+		})
+	}
+
+	t.Run("should return the enforcement of the current org", func(t *testing.T) {
+		server := SetupAPITestServer(t, func(hs *HTTPServer) {
+			hs.totpService = &totptest.FakeService{ExpectedOrgEnforcement: true}
+		})
+
+		req := server.NewGetRequest("/api/org/totp")
+		res, err := server.Send(webtest.RequestWithSignedInUser(req, userWithPermissions(1, []accesscontrol.Permission{{Action: accesscontrol.ActionOrgsRead}})))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var enforcement totp.OrgEnforcement
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&enforcement))
+		assert.True(t, enforcement.Enforced)
+		require.NoError(t, res.Body.Close())
+	})
+}
@@ -0,0 +1,159 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/expr/metrics"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const (
+	defaultAnomalySensitivity = 3
+	defaultAnomalySeasons     = 1
+)
+
+// AnomalyCommand is an expression command that detects anomalies in time series
+// with statistical methods, without the need of the Grafana ML plugin.
+type AnomalyCommand struct {
+	VarToDetect string
+	Options     mathexp.AnomalyOptions
+	refID       string
+}
+
+// NewAnomalyCommand creates a new AnomalyCommand.
+func NewAnomalyCommand(refID, varToDetect string, opts mathexp.AnomalyOptions) (*AnomalyCommand, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &AnomalyCommand{
+		VarToDetect: varToDetect,
+		Options:     opts,
+		refID:       refID,
+	}, nil
+}
+
+// UnmarshalAnomalyCommand creates an AnomalyCommand from Grafana's frontend query.
+func UnmarshalAnomalyCommand(rn *rawNode) (*AnomalyCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, errors.New("no expression ID is specified to detect anomalies in. Must be a reference to an existing query or expression")
+	}
+	varToDetect, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expression ID is expected to be a string, got %T", rawVar)
+	}
+	varToDetect = strings.TrimPrefix(varToDetect, "$")
+
+	opts := mathexp.AnomalyOptions{
+		Seasons:     defaultAnomalySeasons,
+		Sensitivity: defaultAnomalySensitivity,
+		Output:      mathexp.AnomalyOutputFlag,
+	}
+
+	rawAlgorithm, ok := rn.Query["algorithm"]
+	if !ok {
+		return nil, errors.New("no anomaly detection algorithm specified")
+	}
+	algorithm, ok := rawAlgorithm.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected algorithm to be a string, got %T", rawAlgorithm)
+	}
+	opts.Algorithm = mathexp.AnomalyAlgorithm(strings.ToLower(algorithm))
+
+	rawWindow, ok := rn.Query["window"]
+	if !ok {
+		return nil, errors.New("no time duration specified for the window in anomaly command")
+	}
+	window, ok := rawWindow.(string)
+	if !ok {
+		return nil, fmt.Errorf("anomaly window is expected to be a string, got %T", rawWindow)
+	}
+	var err error
+	opts.Window, err = gtime.ParseDuration(window)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to parse anomaly "window" duration field %q: %w`, window, err)
+	}
+
+	if rawPeriod, ok := rn.Query["period"]; ok && rawPeriod != nil {
+		period, ok := rawPeriod.(string)
+		if !ok {
+			return nil, fmt.Errorf("anomaly period is expected to be a string, got %T", rawPeriod)
+		}
+		opts.Period, err = gtime.ParseDuration(period)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to parse anomaly "period" duration field %q: %w`, period, err)
+		}
+	}
+
+	if rawSeasons, ok := rn.Query["seasons"]; ok && rawSeasons != nil {
+		seasons, ok := rawSeasons.(float64)
+		if !ok || seasons != float64(int(seasons)) {
+			return nil, fmt.Errorf("expected seasons to be an integer, got %v", rawSeasons)
+		}
+		opts.Seasons = int(seasons)
+	}
+
+	if rawSensitivity, ok := rn.Query["sensitivity"]; ok && rawSensitivity != nil {
+		sensitivity, ok := rawSensitivity.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected sensitivity to be a number, got %T", rawSensitivity)
+		}
+		opts.Sensitivity = sensitivity
+	}
+
+	if rawOutput, ok := rn.Query["output"]; ok && rawOutput != nil && rawOutput != "" {
+		output, ok := rawOutput.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected output to be a string, got %T", rawOutput)
+		}
+		opts.Output = mathexp.AnomalyOutput(output)
+	}
+
+	return NewAnomalyCommand(rn.RefID, varToDetect, opts)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (ac *AnomalyCommand) NeedsVars() []string {
+	return []string{ac.VarToDetect}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (ac *AnomalyCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.Vars, tracer tracing.Tracer, _ *metrics.ExprMetrics) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteAnomaly")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("algorithm", string(ac.Options.Algorithm)))
+
+	newRes := mathexp.Results{}
+	for _, val := range vars[ac.VarToDetect].Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			series, err := mathexp.DetectAnomalies(ac.refID, v, ac.Options)
+			if err != nil {
+				return newRes, err
+			}
+			for _, s := range series {
+				newRes.Values = append(newRes.Values, s)
+			}
+		case mathexp.NoData:
+			newRes.Values = append(newRes.Values, v.New())
+		default:
+			return newRes, fmt.Errorf("can only detect anomalies in type series, got type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+func (ac *AnomalyCommand) Type() string {
+	return TypeAnomaly.String()
+}
@@ -0,0 +1,118 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestUnmarshalAnomalyCommand(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		isError  bool
+		expected mathexp.AnomalyOptions
+	}{
+		{
+			name:  "zscore with defaults",
+			query: `{ "expression": "$A", "algorithm": "zscore", "window": "1h" }`,
+			expected: mathexp.AnomalyOptions{
+				Algorithm:   mathexp.AnomalyZScore,
+				Window:      time.Hour,
+				Seasons:     1,
+				Sensitivity: 3,
+				Output:      mathexp.AnomalyOutputFlag,
+			},
+		},
+		{
+			name:  "seasonal band",
+			query: `{ "expression": "$A", "algorithm": "seasonal", "window": "10m", "period": "1d", "seasons": 7, "sensitivity": 2.5, "output": "band" }`,
+			expected: mathexp.AnomalyOptions{
+				Algorithm:   mathexp.AnomalySeasonal,
+				Window:      10 * time.Minute,
+				Period:      24 * time.Hour,
+				Seasons:     7,
+				Sensitivity: 2.5,
+				Output:      mathexp.AnomalyOutputBand,
+			},
+		},
+		{
+			name:    "error when window is missing",
+			query:   `{ "expression": "$A", "algorithm": "mad" }`,
+			isError: true,
+		},
+		{
+			name:    "error when seasonal has no period",
+			query:   `{ "expression": "$A", "algorithm": "seasonal", "window": "10m" }`,
+			isError: true,
+		},
+		{
+			name:    "error when sensitivity is not positive",
+			query:   `{ "expression": "$A", "algorithm": "zscore", "window": "10m", "sensitivity": 0 }`,
+			isError: true,
+		},
+		{
+			name:    "error when output is unknown",
+			query:   `{ "expression": "$A", "algorithm": "zscore", "window": "10m", "output": "score" }`,
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qmap = make(map[string]any)
+			require.NoError(t, json.Unmarshal([]byte(test.query), &qmap))
+
+			cmd, err := UnmarshalAnomalyCommand(&rawNode{
+				RefID: "B",
+				Query: qmap,
+			})
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "A", cmd.VarToDetect)
+			require.Equal(t, test.expected, cmd.Options)
+		})
+	}
+}
+
+func TestAnomalyCommand_Execute(t *testing.T) {
+	cmd, err := NewAnomalyCommand("B", "A", mathexp.AnomalyOptions{
+		Algorithm:   mathexp.AnomalyZScore,
+		Window:      time.Hour,
+		Sensitivity: 3,
+		Output:      mathexp.AnomalyOutputBand,
+	})
+	require.NoError(t, err)
+
+	series := mathexp.NewSeries("A", data.Labels{"host": "a"}, 0)
+	for i, v := range []float64{1, 2, 3} {
+		series.AppendPoint(time.Unix(int64(i*60), 0), new(v))
+	}
+
+	t.Run("should return a lower and an upper series", func(t *testing.T) {
+		res, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{series}},
+		}, tracing.InitializeTracerForTest(), nil)
+		require.NoError(t, err)
+		require.Len(t, res.Values, 2)
+		require.Equal(t, data.Labels{"host": "a", mathexp.AnomalyBandLabel: "lower"}, res.Values[0].GetLabels())
+		require.Equal(t, data.Labels{"host": "a", mathexp.AnomalyBandLabel: "upper"}, res.Values[1].GetLabels())
+	})
+
+	t.Run("should return error for numbers", func(t *testing.T) {
+		_, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{mathexp.NewNumber("A", nil)}},
+		}, tracing.InitializeTracerForTest(), nil)
+		require.Error(t, err)
+	})
+}
@@ -91,11 +91,13 @@ type ReduceCommand struct {
 	VarToReduce  string
 	refID        string
 	seriesMapper mathexp.ReduceMapper
+	reducerOpts  []mathexp.ReducerOption
 }
 
 // NewReduceCommand creates a new ReduceCMD.
-func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce string, mapper mathexp.ReduceMapper) (*ReduceCommand, error) {
-	_, err := mathexp.GetReduceFunc(reducer)
+// Arguments of parametrized reducers, such as percentile, are passed as opts.
+func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce string, mapper mathexp.ReduceMapper, opts ...mathexp.ReducerOption) (*ReduceCommand, error) {
+	_, err := mathexp.GetReduceFunc(reducer, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -105,6 +107,7 @@ func NewReduceCommand(refID string, reducer mathexp.ReducerID, varToReduce strin
 		VarToReduce:  varToReduce,
 		refID:        refID,
 		seriesMapper: mapper,
+		reducerOpts:  opts,
 	}, nil
 }
 
@@ -130,6 +133,11 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 	}
 	redFunc := mathexp.ReducerID(strings.ToLower(redString))
 
+	reducerOpts, err := unmarshalReducerOptions(rn)
+	if err != nil {
+		return nil, err
+	}
+
 	var mapper mathexp.ReduceMapper = nil
 	settings, ok := rn.Query["settings"]
 	if ok {
@@ -159,7 +167,20 @@ func UnmarshalReduceCommand(rn *rawNode) (*ReduceCommand, error) {
 			return nil, fmt.Errorf("field settings must be an object, got %T for refId %v", s, rn.RefID)
 		}
 	}
-	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper)
+	return NewReduceCommand(rn.RefID, redFunc, varToReduce, mapper, reducerOpts...)
+}
+
+// unmarshalReducerOptions reads the arguments of parametrized reducers from the query.
+func unmarshalReducerOptions(rn *rawNode) ([]mathexp.ReducerOption, error) {
+	var opts []mathexp.ReducerOption
+	if rawPercentile, ok := rn.Query["percentile"]; ok && rawPercentile != nil {
+		percentile, ok := rawPercentile.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected percentile to be a number, got %T", rawPercentile)
+		}
+		opts = append(opts, mathexp.WithPercentile(percentile))
+	}
+	return opts, nil
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -180,7 +201,7 @@ func (gr *ReduceCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.
 	for i, val := range vars[gr.VarToReduce].Values {
 		switch v := val.(type) {
 		case mathexp.Series:
-			num, err := v.Reduce(gr.refID, gr.Reducer, gr.seriesMapper)
+			num, err := v.Reduce(gr.refID, gr.Reducer, gr.seriesMapper, gr.reducerOpts...)
 			if err != nil {
 				return newRes, err
 			}
@@ -219,26 +240,35 @@ func (gr *ReduceCommand) Type() string {
 type ResampleCommand struct {
 	Window        time.Duration
 	VarToResample string
-	Downsampler   mathexp.ReducerID
+	Downsampler   mathexp.Downsampler
 	Upsampler     mathexp.Upsampler
 	TimeRange     TimeRange
+	// AlignLocation is the time zone in which the points are aligned to multiples of the window.
+	// If nil, the points start at the beginning of the time range.
+	AlignLocation *time.Location
 	refID         string
+	// downsamplerOpts are the arguments of a parametrized downsampler, such as percentile.
+	downsamplerOpts []mathexp.ReducerOption
 }
 
 // NewResampleCommand creates a new ResampleCMD.
-func NewResampleCommand(refID, rawWindow, varToResample string, downsampler mathexp.ReducerID, upsampler mathexp.Upsampler, tr TimeRange) (*ResampleCommand, error) {
-	// TODO: validate reducer here, before execution
+// Arguments of parametrized downsamplers, such as percentile, are passed as opts.
+func NewResampleCommand(refID, rawWindow, varToResample string, downsampler mathexp.Downsampler, upsampler mathexp.Upsampler, tr TimeRange, opts ...mathexp.ReducerOption) (*ResampleCommand, error) {
 	window, err := gtime.ParseDuration(rawWindow)
 	if err != nil {
 		return nil, fmt.Errorf(`failed to parse resample "window" duration field %q: %w`, window, err)
 	}
+	if err := downsampler.Validate(opts...); err != nil {
+		return nil, fmt.Errorf("invalid resample downsampler: %w", err)
+	}
 	return &ResampleCommand{
-		Window:        window,
-		VarToResample: varToResample,
-		Downsampler:   downsampler,
-		Upsampler:     upsampler,
-		TimeRange:     tr,
-		refID:         refID,
+		Window:          window,
+		VarToResample:   varToResample,
+		Downsampler:     downsampler,
+		Upsampler:       upsampler,
+		TimeRange:       tr,
+		refID:           refID,
+		downsamplerOpts: opts,
 	}, nil
 }
 
@@ -285,11 +315,44 @@ func UnmarshalResampleCommand(rn *rawNode) (*ResampleCommand, error) {
 		return nil, fmt.Errorf("expected resample downsampler to be a string, got type %T", upsampler)
 	}
 
-	return NewResampleCommand(rn.RefID, window,
+	downsamplerOpts, err := unmarshalReducerOptions(rn)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, err := NewResampleCommand(rn.RefID, window,
 		varToResample,
-		mathexp.ReducerID(downsampler),
+		mathexp.Downsampler(downsampler),
 		mathexp.Upsampler(upsampler),
-		rn.TimeRange)
+		rn.TimeRange,
+		downsamplerOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if rawAlign, ok := rn.Query["align"]; ok {
+		align, ok := rawAlign.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected resample align to be a boolean, got type %T", rawAlign)
+		}
+		if align {
+			cmd.AlignLocation = time.UTC
+			if rawTimezone, ok := rn.Query["timezone"]; ok {
+				timezone, ok := rawTimezone.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected resample timezone to be a string, got type %T", rawTimezone)
+				}
+				if timezone != "" {
+					loc, err := time.LoadLocation(timezone)
+					if err != nil {
+						return nil, fmt.Errorf("invalid resample timezone %q: %w", timezone, err)
+					}
+					cmd.AlignLocation = loc
+				}
+			}
+		}
+	}
+	return cmd, nil
 }
 
 // NeedsVars returns the variable names (refIds) that are dependencies
@@ -305,13 +368,16 @@ func (gr *ResampleCommand) Execute(ctx context.Context, now time.Time, vars math
 	defer span.End()
 	newRes := mathexp.Results{}
 	timeRange := gr.TimeRange.AbsoluteTime(now)
+	if gr.AlignLocation != nil {
+		timeRange.From = mathexp.AlignTime(timeRange.From, gr.Window, gr.AlignLocation)
+	}
 	for _, val := range vars[gr.VarToResample].Values {
 		if val == nil {
 			continue
 		}
 		switch v := val.(type) {
 		case mathexp.Series:
-			num, err := v.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, timeRange.From, timeRange.To)
+			num, err := v.Resample(gr.refID, gr.Window, gr.Downsampler, gr.Upsampler, timeRange.From, timeRange.To, gr.downsamplerOpts...)
 			if err != nil {
 				return newRes, err
 			}
@@ -330,6 +396,105 @@ func (gr *ResampleCommand) Type() string {
 	return TypeResample.String()
 }
 
+// AggregateCommand is an expression command that groups numbers or series by labels
+// and aggregates each group, such as a sum by host.
+type AggregateCommand struct {
+	Aggregator     mathexp.Aggregator
+	VarToAggregate string
+	// By are the names of the labels to group by. If empty, all values are in a single group.
+	By []string
+	// K is the number of values to keep per group for the topk and bottomk aggregators.
+	K     int
+	refID string
+}
+
+// NewAggregateCommand creates a new AggregateCommand.
+func NewAggregateCommand(refID string, aggregator mathexp.Aggregator, varToAggregate string, by []string, k int) (*AggregateCommand, error) {
+	if err := mathexp.ValidateAggregator(aggregator, k); err != nil {
+		return nil, err
+	}
+	return &AggregateCommand{
+		Aggregator:     aggregator,
+		VarToAggregate: varToAggregate,
+		By:             by,
+		K:              k,
+		refID:          refID,
+	}, nil
+}
+
+// UnmarshalAggregateCommand creates an AggregateCommand from Grafana's frontend query.
+func UnmarshalAggregateCommand(rn *rawNode) (*AggregateCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, errors.New("no expression ID is specified to aggregate. Must be a reference to an existing query or expression")
+	}
+	varToAggregate, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expression ID is expected to be a string, got %T", rawVar)
+	}
+	varToAggregate = strings.TrimPrefix(varToAggregate, "$")
+
+	rawAggregator, ok := rn.Query["aggregator"]
+	if !ok {
+		return nil, errors.New("no aggregator specified")
+	}
+	aggString, ok := rawAggregator.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected aggregator to be a string, got %T", rawAggregator)
+	}
+	aggregator := mathexp.Aggregator(strings.ToLower(aggString))
+
+	var by []string
+	if rawBy, ok := rn.Query["by"]; ok && rawBy != nil {
+		labels, ok := rawBy.([]any)
+		if !ok {
+			return nil, fmt.Errorf("expected by to be a list of label names, got %T", rawBy)
+		}
+		for _, l := range labels {
+			name, ok := l.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected label name to be a string, got %T", l)
+			}
+			by = append(by, name)
+		}
+	}
+
+	var k int
+	if rawK, ok := rn.Query["k"]; ok && rawK != nil {
+		kf, ok := rawK.(float64)
+		if !ok || kf != float64(int(kf)) {
+			return nil, fmt.Errorf("expected k to be an integer, got %v", rawK)
+		}
+		k = int(kf)
+	}
+	return NewAggregateCommand(rn.RefID, aggregator, varToAggregate, by, k)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (ga *AggregateCommand) NeedsVars() []string {
+	return []string{ga.VarToAggregate}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute.
+func (ga *AggregateCommand) Execute(ctx context.Context, _ time.Time, vars mathexp.Vars, tracer tracing.Tracer, _ *metrics.ExprMetrics) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteAggregate")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("aggregator", string(ga.Aggregator)))
+
+	vals, err := mathexp.Aggregate(ga.refID, vars[ga.VarToAggregate].Values, ga.Aggregator, ga.By, ga.K)
+	if err != nil {
+		return mathexp.Results{}, err
+	}
+	return mathexp.Results{Values: vals}, nil
+}
+
+func (ga *AggregateCommand) Type() string {
+	return TypeAggregate.String()
+}
+
 // CommandType is the type of the expression command.
 type CommandType int
 
@@ -348,6 +513,12 @@ const (
 	TypeThreshold
 	// TypeSQL is the CMDType for running SQL expressions
 	TypeSQL
+	// TypeAggregate is the CMDType for aggregating values grouped by labels.
+	TypeAggregate
+	// TypeAnomaly is the CMDType for detecting anomalies in time series.
+	TypeAnomaly
+	// TypeForecast is the CMDType for projecting time series into the future.
+	TypeForecast
 )
 
 func (gt CommandType) String() string {
@@ -364,6 +535,12 @@ func (gt CommandType) String() string {
 		return "threshold"
 	case TypeSQL:
 		return "sql"
+	case TypeAggregate:
+		return "aggregate"
+	case TypeAnomaly:
+		return "anomaly"
+	case TypeForecast:
+		return "forecast"
 	default:
 		return "unknown"
 	}
@@ -384,6 +561,12 @@ func ParseCommandType(s string) (CommandType, error) {
 		return TypeThreshold, nil
 	case "sql":
 		return TypeSQL, nil
+	case "aggregate":
+		return TypeAggregate, nil
+	case "anomaly":
+		return TypeAnomaly, nil
+	case "forecast":
+		return TypeForecast, nil
 	default:
 		return TypeUnknown, fmt.Errorf("'%v' is not a recognized expression type", s)
 	}
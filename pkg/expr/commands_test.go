@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"slices"
 	"testing"
 	"time"
 
@@ -99,6 +100,68 @@ func Test_UnmarshalReduceCommand_Settings(t *testing.T) {
 	}
 }
 
+func Test_UnmarshalReduceCommand_Percentile(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		isError  bool
+		expected *float64
+	}{
+		{
+			name:     "percentile reducer with percentile",
+			query:    `{ "expression" : "$A", "reducer": "percentile", "percentile": 95 }`,
+			expected: new(95.0),
+		},
+		{
+			name:    "error when percentile is missing",
+			query:   `{ "expression" : "$A", "reducer": "percentile" }`,
+			isError: true,
+		},
+		{
+			name:    "error when percentile is not a number",
+			query:   `{ "expression" : "$A", "reducer": "percentile", "percentile": "95" }`,
+			isError: true,
+		},
+		{
+			name:    "error when percentile is out of range",
+			query:   `{ "expression" : "$A", "reducer": "percentile", "percentile": -1 }`,
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qmap = make(map[string]any)
+			require.NoError(t, json.Unmarshal([]byte(test.query), &qmap))
+
+			cmd, err := UnmarshalReduceCommand(&rawNode{
+				RefID: "B",
+				Query: qmap,
+			})
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			vars := mathexp.Vars{
+				"A": mathexp.Results{Values: mathexp.Values{
+					mathexp.NewSeries("A", nil, 0),
+				}},
+			}
+			series := vars["A"].Values[0].(mathexp.Series)
+			for i := range 101 {
+				series.AppendPoint(time.Unix(int64(i), 0), new(float64(i)))
+			}
+
+			res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest(), nil)
+			require.NoError(t, err)
+			require.Len(t, res.Values, 1)
+			require.Equal(t, test.expected, res.Values[0].(mathexp.Number).GetFloat64Value())
+		})
+	}
+}
+
 func TestReduceExecute(t *testing.T) {
 	varToReduce := util.GenerateShortUID()
 
@@ -211,7 +274,9 @@ func TestReduceExecute(t *testing.T) {
 }
 
 func randomReduceFunc() mathexp.ReducerID {
-	res := mathexp.GetSupportedReduceFuncs()
+	res := slices.DeleteFunc(mathexp.GetSupportedReduceFuncs(), func(r mathexp.ReducerID) bool {
+		return r == mathexp.ReducerPercentile // requires a percentile argument
+	})
 	return res[rand.Intn(len(res))]
 }
 
@@ -273,3 +338,181 @@ func TestResampleCommand_Execute(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestResampleCommand_Align(t *testing.T) {
+	unmarshal := func(t *testing.T, q string) (*ResampleCommand, error) {
+		t.Helper()
+		var qmap = make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(q), &qmap))
+		return UnmarshalResampleCommand(&rawNode{
+			RefID:     "B",
+			Query:     qmap,
+			TimeRange: AbsoluteTimeRange{From: time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC), To: time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC)},
+		})
+	}
+
+	t.Run("should start at the beginning of the time range when not aligned", func(t *testing.T) {
+		cmd, err := unmarshal(t, `{ "expression": "$A", "window": "1h", "downsampler": "mean", "upsampler": "fillna" }`)
+		require.NoError(t, err)
+		require.Nil(t, cmd.AlignLocation)
+	})
+
+	t.Run("should align to hour starts", func(t *testing.T) {
+		cmd, err := unmarshal(t, `{ "expression": "$A", "window": "1h", "downsampler": "mean", "upsampler": "fillna", "align": true, "timezone": "Asia/Kolkata" }`)
+		require.NoError(t, err)
+		require.Equal(t, "Asia/Kolkata", cmd.AlignLocation.String())
+
+		result, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{mathexp.NewSeries("A", nil, 0)}},
+		}, tracing.InitializeTracerForTest(), nil)
+		require.NoError(t, err)
+		require.Len(t, result.Values, 1)
+		series := result.Values[0].(mathexp.Series)
+		// Asia/Kolkata is UTC+05:30, so local hour starts are at half past the hour in UTC.
+		require.Equal(t, time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC), series.GetTime(0).UTC())
+		require.Equal(t, time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC), series.GetTime(series.Len()-1).UTC())
+	})
+
+	t.Run("should return error when time zone is unknown", func(t *testing.T) {
+		_, err := unmarshal(t, `{ "expression": "$A", "window": "1h", "downsampler": "mean", "upsampler": "fillna", "align": true, "timezone": "Mars/Olympus" }`)
+		require.Error(t, err)
+	})
+}
+
+func TestResampleCommand_Percentile(t *testing.T) {
+	unmarshal := func(t *testing.T, q string) (*ResampleCommand, error) {
+		t.Helper()
+		var qmap = make(map[string]any)
+		require.NoError(t, json.Unmarshal([]byte(q), &qmap))
+		return UnmarshalResampleCommand(&rawNode{
+			RefID:     "B",
+			Query:     qmap,
+			TimeRange: AbsoluteTimeRange{From: time.Unix(100, 0), To: time.Unix(200, 0)},
+		})
+	}
+
+	t.Run("should downsample with the percentile", func(t *testing.T) {
+		cmd, err := unmarshal(t, `{ "expression": "$A", "window": "100s", "downsampler": "percentile", "percentile": 90, "upsampler": "fillna" }`)
+		require.NoError(t, err)
+
+		series := mathexp.NewSeries("A", nil, 0)
+		for i := 101; i <= 200; i++ {
+			series.AppendPoint(time.Unix(int64(i), 0), new(float64(i-100)))
+		}
+		result, err := cmd.Execute(context.Background(), time.Now(), mathexp.Vars{
+			"A": mathexp.Results{Values: mathexp.Values{series}},
+		}, tracing.InitializeTracerForTest(), nil)
+		require.NoError(t, err)
+		require.Len(t, result.Values, 1)
+		_, v := result.Values[0].(mathexp.Series).GetPoint(1)
+		require.InDelta(t, 90.1, *v, 1e-9)
+	})
+
+	t.Run("should return error when the percentile is not a number", func(t *testing.T) {
+		_, err := unmarshal(t, `{ "expression": "$A", "window": "100s", "downsampler": "percentile", "percentile": "90", "upsampler": "fillna" }`)
+		require.Error(t, err)
+	})
+
+	t.Run("should return error when the percentile is missing", func(t *testing.T) {
+		_, err := unmarshal(t, `{ "expression": "$A", "window": "100s", "downsampler": "percentile", "upsampler": "fillna" }`)
+		require.Error(t, err)
+	})
+
+	t.Run("should return error when the downsampler is not a reducer", func(t *testing.T) {
+		_, err := unmarshal(t, `{ "expression": "$A", "window": "100s", "downsampler": "avg", "upsampler": "fillna" }`)
+		require.ErrorContains(t, err, "invalid resample downsampler")
+	})
+}
+
+func Test_UnmarshalAggregateCommand(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		isError  bool
+		expected *AggregateCommand
+	}{
+		{
+			name:  "sum by labels",
+			query: `{ "expression" : "$A", "aggregator": "sum", "by": ["host", "region"] }`,
+			expected: &AggregateCommand{
+				Aggregator:     mathexp.AggregatorSum,
+				VarToAggregate: "A",
+				By:             []string{"host", "region"},
+				refID:          "B",
+			},
+		},
+		{
+			name:  "topk with k",
+			query: `{ "expression" : "A", "aggregator": "topk", "k": 3 }`,
+			expected: &AggregateCommand{
+				Aggregator:     mathexp.AggregatorTopK,
+				VarToAggregate: "A",
+				K:              3,
+				refID:          "B",
+			},
+		},
+		{
+			name:    "error when aggregator is not supported",
+			query:   `{ "expression" : "$A", "aggregator": "median" }`,
+			isError: true,
+		},
+		{
+			name:    "error when k is missing for topk",
+			query:   `{ "expression" : "$A", "aggregator": "topk" }`,
+			isError: true,
+		},
+		{
+			name:    "error when k is not an integer",
+			query:   `{ "expression" : "$A", "aggregator": "bottomk", "k": 1.5 }`,
+			isError: true,
+		},
+		{
+			name:    "error when by is not a list",
+			query:   `{ "expression" : "$A", "aggregator": "sum", "by": "host" }`,
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qmap = make(map[string]any)
+			require.NoError(t, json.Unmarshal([]byte(test.query), &qmap))
+
+			cmd, err := UnmarshalAggregateCommand(&rawNode{
+				RefID: "B",
+				Query: qmap,
+			})
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, cmd)
+		})
+	}
+}
+
+func TestAggregateExecute(t *testing.T) {
+	makeNumber := func(refID string, labels data.Labels, f float64) mathexp.Number {
+		n := mathexp.NewNumber(refID, labels)
+		n.SetValue(&f)
+		return n
+	}
+
+	cmd, err := NewAggregateCommand("B", mathexp.AggregatorMax, "A", []string{"region"}, 0)
+	require.NoError(t, err)
+
+	vars := mathexp.Vars{
+		"A": mathexp.Results{Values: mathexp.Values{
+			makeNumber("A", data.Labels{"host": "a", "region": "eu"}, 1),
+			makeNumber("A", data.Labels{"host": "b", "region": "eu"}, 3),
+			makeNumber("A", data.Labels{"host": "c", "region": "us"}, 2),
+		}},
+	}
+	res, err := cmd.Execute(context.Background(), time.Now(), vars, tracing.InitializeTracerForTest(), nil)
+	require.NoError(t, err)
+	require.Equal(t, mathexp.Values{
+		makeNumber("B", data.Labels{"region": "eu"}, 3),
+		makeNumber("B", data.Labels{"region": "us"}, 2),
+	}, res.Values)
+}
@@ -0,0 +1,167 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/expr/metrics"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+const defaultForecastSmoothingFactor = 0.5
+
+// ForecastCommand is an expression command that projects each time series into the future,
+// and returns the projected value or the time until a threshold is crossed as a number.
+type ForecastCommand struct {
+	VarToForecast string
+	Options       mathexp.ForecastOptions
+	refID         string
+}
+
+// NewForecastCommand creates a new ForecastCommand.
+func NewForecastCommand(refID, varToForecast string, opts mathexp.ForecastOptions) (*ForecastCommand, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &ForecastCommand{
+		VarToForecast: varToForecast,
+		Options:       opts,
+		refID:         refID,
+	}, nil
+}
+
+// UnmarshalForecastCommand creates a ForecastCommand from Grafana's frontend query.
+func UnmarshalForecastCommand(rn *rawNode) (*ForecastCommand, error) {
+	rawVar, ok := rn.Query["expression"]
+	if !ok {
+		return nil, errors.New("no expression ID is specified to forecast. Must be a reference to an existing query or expression")
+	}
+	varToForecast, ok := rawVar.(string)
+	if !ok {
+		return nil, fmt.Errorf("expression ID is expected to be a string, got %T", rawVar)
+	}
+	varToForecast = strings.TrimPrefix(varToForecast, "$")
+
+	opts := mathexp.ForecastOptions{
+		Output: mathexp.ForecastOutputValue,
+		Alpha:  defaultForecastSmoothingFactor,
+		Beta:   defaultForecastSmoothingFactor,
+		Gamma:  defaultForecastSmoothingFactor,
+	}
+
+	rawMethod, ok := rn.Query["method"]
+	if !ok {
+		return nil, errors.New("no forecast method specified")
+	}
+	method, ok := rawMethod.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected method to be a string, got %T", rawMethod)
+	}
+	opts.Method = mathexp.ForecastMethod(strings.ToLower(method))
+
+	rawHorizon, ok := rn.Query["horizon"]
+	if !ok {
+		return nil, errors.New("no time duration specified for the horizon in forecast command")
+	}
+	horizon, ok := rawHorizon.(string)
+	if !ok {
+		return nil, fmt.Errorf("forecast horizon is expected to be a string, got %T", rawHorizon)
+	}
+	var err error
+	opts.Horizon, err = gtime.ParseDuration(horizon)
+	if err != nil {
+		return nil, fmt.Errorf(`failed to parse forecast "horizon" duration field %q: %w`, horizon, err)
+	}
+
+	if rawOutput, ok := rn.Query["output"]; ok && rawOutput != nil && rawOutput != "" {
+		output, ok := rawOutput.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected output to be a string, got %T", rawOutput)
+		}
+		opts.Output = mathexp.ForecastOutput(output)
+	}
+
+	if opts.Output == mathexp.ForecastOutputTimeToThreshold {
+		rawThreshold, ok := rn.Query["threshold"]
+		if !ok || rawThreshold == nil {
+			return nil, errors.New("threshold must be specified when output is 'time_to_threshold'")
+		}
+		threshold, ok := rawThreshold.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected threshold to be a number, got %T", rawThreshold)
+		}
+		opts.Threshold = threshold
+	}
+
+	if rawPeriod, ok := rn.Query["period"]; ok && rawPeriod != nil {
+		period, ok := rawPeriod.(string)
+		if !ok {
+			return nil, fmt.Errorf("forecast period is expected to be a string, got %T", rawPeriod)
+		}
+		opts.Period, err = gtime.ParseDuration(period)
+		if err != nil {
+			return nil, fmt.Errorf(`failed to parse forecast "period" duration field %q: %w`, period, err)
+		}
+	}
+
+	for field, factor := range map[string]*float64{
+		"smoothing": &opts.Alpha,
+		"trend":     &opts.Beta,
+		"seasonal":  &opts.Gamma,
+	} {
+		raw, ok := rn.Query[field]
+		if !ok || raw == nil {
+			continue
+		}
+		f, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected %s to be a number, got %T", field, raw)
+		}
+		*factor = f
+	}
+
+	return NewForecastCommand(rn.RefID, varToForecast, opts)
+}
+
+// NeedsVars returns the variable names (refIds) that are dependencies
+// to execute the command and allows the command to fulfill the Command interface.
+func (fc *ForecastCommand) NeedsVars() []string {
+	return []string{fc.VarToForecast}
+}
+
+// Execute runs the command and returns the results or an error if the command
+// failed to execute. The series are projected from now.
+func (fc *ForecastCommand) Execute(ctx context.Context, now time.Time, vars mathexp.Vars, tracer tracing.Tracer, _ *metrics.ExprMetrics) (mathexp.Results, error) {
+	_, span := tracer.Start(ctx, "SSE.ExecuteForecast")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("method", string(fc.Options.Method)))
+
+	newRes := mathexp.Results{}
+	for _, val := range vars[fc.VarToForecast].Values {
+		switch v := val.(type) {
+		case mathexp.Series:
+			num, err := v.Forecast(fc.refID, now, fc.Options)
+			if err != nil {
+				return newRes, err
+			}
+			newRes.Values = append(newRes.Values, num)
+		case mathexp.NoData:
+			newRes.Values = append(newRes.Values, v.New())
+		default:
+			return newRes, fmt.Errorf("can only forecast type series, got type %v", val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+func (fc *ForecastCommand) Type() string {
+	return TypeForecast.String()
+}
@@ -0,0 +1,121 @@
+package expr
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestUnmarshalForecastCommand(t *testing.T) {
+	var tests = []struct {
+		name     string
+		query    string
+		isError  bool
+		expected mathexp.ForecastOptions
+	}{
+		{
+			name:  "linear with defaults",
+			query: `{ "expression": "$A", "method": "linear", "horizon": "4h" }`,
+			expected: mathexp.ForecastOptions{
+				Method:  mathexp.ForecastLinear,
+				Horizon: 4 * time.Hour,
+				Output:  mathexp.ForecastOutputValue,
+				Alpha:   0.5,
+				Beta:    0.5,
+				Gamma:   0.5,
+			},
+		},
+		{
+			name:  "holt_winters time to threshold",
+			query: `{ "expression": "$A", "method": "holt_winters", "horizon": "7d", "output": "time_to_threshold", "threshold": 90, "period": "1d", "smoothing": 0.3, "trend": 0.1, "seasonal": 0.2 }`,
+			expected: mathexp.ForecastOptions{
+				Method:    mathexp.ForecastHoltWinters,
+				Horizon:   7 * 24 * time.Hour,
+				Output:    mathexp.ForecastOutputTimeToThreshold,
+				Threshold: 90,
+				Period:    24 * time.Hour,
+				Alpha:     0.3,
+				Beta:      0.1,
+				Gamma:     0.2,
+			},
+		},
+		{
+			name:    "error when horizon is missing",
+			query:   `{ "expression": "$A", "method": "linear" }`,
+			isError: true,
+		},
+		{
+			name:    "error when threshold is missing",
+			query:   `{ "expression": "$A", "method": "linear", "horizon": "4h", "output": "time_to_threshold" }`,
+			isError: true,
+		},
+		{
+			name:    "error when holt_winters has no period",
+			query:   `{ "expression": "$A", "method": "holt_winters", "horizon": "4h" }`,
+			isError: true,
+		},
+		{
+			name:    "error when method is unknown",
+			query:   `{ "expression": "$A", "method": "arima", "horizon": "4h" }`,
+			isError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var qmap = make(map[string]any)
+			require.NoError(t, json.Unmarshal([]byte(test.query), &qmap))
+
+			cmd, err := UnmarshalForecastCommand(&rawNode{
+				RefID: "B",
+				Query: qmap,
+			})
+			if test.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "A", cmd.VarToForecast)
+			require.Equal(t, test.expected, cmd.Options)
+		})
+	}
+}
+
+func TestForecastCommand_Execute(t *testing.T) {
+	cmd, err := NewForecastCommand("B", "A", mathexp.ForecastOptions{
+		Method:    mathexp.ForecastLinear,
+		Horizon:   time.Hour,
+		Output:    mathexp.ForecastOutputTimeToThreshold,
+		Threshold: 100,
+	})
+	require.NoError(t, err)
+
+	// grows by 1 per minute and is at 40 now
+	now := time.Unix(3600, 0)
+	series := mathexp.NewSeries("A", nil, 0)
+	for i := range 5 {
+		series.AppendPoint(now.Add(time.Duration(i-4)*time.Minute), new(float64(36+i)))
+	}
+
+	res, err := cmd.Execute(context.Background(), now, mathexp.Vars{
+		"A": mathexp.Results{Values: mathexp.Values{series, mathexp.NewNoData()}},
+	}, tracing.InitializeTracerForTest(), nil)
+	require.NoError(t, err)
+	require.Len(t, res.Values, 2)
+	require.InDelta(t, 3600, *res.Values[0].(mathexp.Number).GetFloat64Value(), 1e-6)
+	require.Equal(t, mathexp.NewNoData(), res.Values[1])
+
+	cmd.Options.Threshold = 1000
+	res, err = cmd.Execute(context.Background(), now, mathexp.Vars{
+		"A": mathexp.Results{Values: mathexp.Values{series}},
+	}, tracing.InitializeTracerForTest(), nil)
+	require.NoError(t, err)
+	require.True(t, math.IsInf(*res.Values[0].(mathexp.Number).GetFloat64Value(), 1))
+}
@@ -0,0 +1,293 @@
+package mathexp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// The aggregation function
+// +enum
+type Aggregator string
+
+const (
+	AggregatorSum   Aggregator = "sum"
+	AggregatorAvg   Aggregator = "avg"
+	AggregatorMin   Aggregator = "min"
+	AggregatorMax   Aggregator = "max"
+	AggregatorCount Aggregator = "count"
+	// Keep the k largest values of each group
+	AggregatorTopK Aggregator = "topk"
+	// Keep the k smallest values of each group
+	AggregatorBottomK Aggregator = "bottomk"
+)
+
+// GetSupportedAggregators returns collection of supported aggregator names
+func GetSupportedAggregators() []Aggregator {
+	return []Aggregator{
+		AggregatorSum, AggregatorAvg, AggregatorMin, AggregatorMax, AggregatorCount, AggregatorTopK, AggregatorBottomK,
+	}
+}
+
+// IsRanking returns true if the aggregator selects values instead of combining them.
+func (a Aggregator) IsRanking() bool {
+	return a == AggregatorTopK || a == AggregatorBottomK
+}
+
+// ValidateAggregator returns an error if the aggregator is not supported
+// or if k is not valid for a topk or bottomk aggregator.
+func ValidateAggregator(aggregator Aggregator, k int) error {
+	if !slices.Contains(GetSupportedAggregators(), aggregator) {
+		return fmt.Errorf("aggregator %q is not supported", aggregator)
+	}
+	if aggregator.IsRanking() && k < 1 {
+		return fmt.Errorf("%s requires k to be greater than 0, got %d", aggregator, k)
+	}
+	return nil
+}
+
+// Aggregate groups vals by the values of the labels in by and aggregates each group.
+// All values must be numbers or all values must be series, NoData values are ignored.
+// If there are no other values the result is NoData.
+//
+// sum, avg, min, max and count return one value per group that only has the grouping labels.
+// Series are aligned by timestamp and null points are skipped.
+// A point is null if none of the series in the group has a value at that time.
+//
+// topk and bottomk return the k largest or smallest values of each group with their original labels.
+// For series the ranking is done per timestamp, points that do not rank within k are set to null
+// and series that never rank within k are dropped.
+func Aggregate(refID string, vals Values, aggregator Aggregator, by []string, k int) (Values, error) {
+	if err := ValidateAggregator(aggregator, k); err != nil {
+		return nil, err
+	}
+
+	var numbers []Number
+	var series []Series
+	for _, val := range vals {
+		switch v := val.(type) {
+		case Number:
+			numbers = append(numbers, v)
+		case Series:
+			series = append(series, v)
+		case NoData:
+			continue
+		default:
+			return nil, fmt.Errorf("can only aggregate numbers or series, got type %v", val.Type())
+		}
+	}
+	if len(numbers) > 0 && len(series) > 0 {
+		return nil, fmt.Errorf("can not aggregate numbers and series together")
+	}
+	if len(numbers) == 0 && len(series) == 0 {
+		return Values{NewNoData()}, nil
+	}
+
+	newVals := Values{}
+	if len(numbers) > 0 {
+		for _, g := range groupByLabels(numbers, by) {
+			if aggregator.IsRanking() {
+				newVals = append(newVals, rankNumbers(refID, g.values, aggregator, k)...)
+				continue
+			}
+			fs := make([]float64, 0, len(g.values))
+			for _, n := range g.values {
+				if f := n.GetFloat64Value(); f != nil {
+					fs = append(fs, *f)
+				}
+			}
+			number := NewNumber(refID, g.labels)
+			number.SetValue(combine(aggregator, fs))
+			newVals = append(newVals, number)
+		}
+		return newVals, nil
+	}
+
+	for _, g := range groupByLabels(series, by) {
+		if aggregator.IsRanking() {
+			newVals = append(newVals, rankSeries(refID, g.values, aggregator, k)...)
+			continue
+		}
+		times, points := alignSeries(g.values)
+		newSeries := NewSeries(refID, g.labels, len(times))
+		for i, t := range times {
+			newSeries.SetPoint(i, t, combine(aggregator, points[t.UnixNano()]))
+		}
+		newVals = append(newVals, newSeries)
+	}
+	return newVals, nil
+}
+
+// labelGroup is a set of values that have the same values for the grouping labels.
+type labelGroup[V Value] struct {
+	labels data.Labels
+	values []V
+}
+
+// groupByLabels splits vals into groups by the values of the labels in by.
+// Values that do not have a label are grouped together with values where the label is empty.
+// The groups are in the order in which they first appear in vals.
+func groupByLabels[V Value](vals []V, by []string) []*labelGroup[V] {
+	var groups []*labelGroup[V]
+	byKey := map[string]*labelGroup[V]{}
+	for _, v := range vals {
+		var ls data.Labels
+		for _, name := range by {
+			lv, ok := v.GetLabels()[name]
+			if !ok || lv == "" {
+				continue
+			}
+			if ls == nil {
+				ls = data.Labels{}
+			}
+			ls[name] = lv
+		}
+		key := ls.String()
+		g, ok := byKey[key]
+		if !ok {
+			g = &labelGroup[V]{labels: ls}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.values = append(g.values, v)
+	}
+	return groups
+}
+
+// combine returns the result of a non-ranking aggregator over fs or nil if fs is empty.
+func combine(aggregator Aggregator, fs []float64) *float64 {
+	if len(fs) == 0 {
+		return nil
+	}
+	var f float64
+	switch aggregator {
+	case AggregatorSum, AggregatorAvg:
+		for _, v := range fs {
+			f += v
+		}
+		if aggregator == AggregatorAvg {
+			f /= float64(len(fs))
+		}
+	case AggregatorMin:
+		f = slices.Min(fs)
+	case AggregatorMax:
+		f = slices.Max(fs)
+	case AggregatorCount:
+		f = float64(len(fs))
+	}
+	return &f
+}
+
+// ranked returns the indices of the k largest (topk) or smallest (bottomk) values of fs.
+// Ties keep the order of fs.
+func ranked(aggregator Aggregator, fs []float64, k int) []int {
+	idx := make([]int, len(fs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		if aggregator == AggregatorTopK {
+			return fs[idx[i]] > fs[idx[j]]
+		}
+		return fs[idx[i]] < fs[idx[j]]
+	})
+	return idx[:min(k, len(idx))]
+}
+
+func rankNumbers(refID string, numbers []Number, aggregator Aggregator, k int) Values {
+	var candidates []Number
+	var fs []float64
+	for _, n := range numbers {
+		if f := n.GetFloat64Value(); f != nil {
+			candidates = append(candidates, n)
+			fs = append(fs, *f)
+		}
+	}
+	newVals := Values{}
+	for _, i := range ranked(aggregator, fs, k) {
+		var ls data.Labels
+		if candidates[i].GetLabels() != nil {
+			ls = candidates[i].GetLabels().Copy()
+		}
+		number := NewNumber(refID, ls)
+		number.SetValue(new(fs[i]))
+		newVals = append(newVals, number)
+	}
+	return newVals
+}
+
+func rankSeries(refID string, series []Series, aggregator Aggregator, k int) Values {
+	// keep[i] has the timestamps at which series i ranks within k
+	keep := make([]map[int64]bool, len(series))
+	for i := range keep {
+		keep[i] = map[int64]bool{}
+	}
+	// candidates and values of the series that have a value at each timestamp
+	candidates := map[int64][]int{}
+	values := map[int64][]float64{}
+	for i, s := range series {
+		for j := range s.Len() {
+			t, f := s.GetPoint(j)
+			if f == nil {
+				continue
+			}
+			ts := t.UnixNano()
+			candidates[ts] = append(candidates[ts], i)
+			values[ts] = append(values[ts], *f)
+		}
+	}
+	for ts, fs := range values {
+		for _, j := range ranked(aggregator, fs, k) {
+			keep[candidates[ts][j]][ts] = true
+		}
+	}
+
+	newVals := Values{}
+	for i, s := range series {
+		if len(keep[i]) == 0 {
+			continue
+		}
+		var ls data.Labels
+		if s.GetLabels() != nil {
+			ls = s.GetLabels().Copy()
+		}
+		newSeries := NewSeries(refID, ls, s.Len())
+		for j := range s.Len() {
+			t, f := s.GetPoint(j)
+			if !keep[i][t.UnixNano()] {
+				f = nil
+			}
+			newSeries.SetPoint(j, t, f)
+		}
+		newSeries.SortByTime(false)
+		newVals = append(newVals, newSeries)
+	}
+	return newVals
+}
+
+// alignSeries returns the sorted union of the timestamps of series
+// and the non-null values of all series at each timestamp.
+func alignSeries(series []Series) ([]time.Time, map[int64][]float64) {
+	var times []time.Time
+	points := map[int64][]float64{}
+	for _, s := range series {
+		for i := range s.Len() {
+			t, f := s.GetPoint(i)
+			fs, seen := points[t.UnixNano()]
+			if !seen {
+				times = append(times, t)
+			}
+			if f != nil {
+				fs = append(fs, *f)
+			}
+			points[t.UnixNano()] = fs
+		}
+	}
+	slices.SortFunc(times, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	return times, points
+}
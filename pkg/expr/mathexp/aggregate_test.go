@@ -0,0 +1,189 @@
+package mathexp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregate(t *testing.T) {
+	numbers := Values{
+		makeNumber("A", data.Labels{"host": "a", "region": "eu"}, new(1.0)),
+		makeNumber("A", data.Labels{"host": "b", "region": "eu"}, new(4.0)),
+		makeNumber("A", data.Labels{"host": "c", "region": "us"}, new(2.0)),
+		makeNumber("A", data.Labels{"host": "d", "region": "us"}, nil),
+		makeNumber("A", data.Labels{"host": "e"}, new(8.0)),
+	}
+	series := Values{
+		makeSeries("A", data.Labels{"host": "a", "region": "eu"},
+			tp{time.Unix(5, 0), new(1.0)},
+			tp{time.Unix(10, 0), new(3.0)},
+		),
+		makeSeries("A", data.Labels{"host": "b", "region": "eu"},
+			tp{time.Unix(10, 0), new(2.0)},
+			tp{time.Unix(15, 0), nil},
+			tp{time.Unix(0, 0), new(5.0)},
+		),
+		makeSeries("A", data.Labels{"host": "c", "region": "us"},
+			tp{time.Unix(5, 0), new(7.0)},
+		),
+	}
+
+	var tests = []struct {
+		name       string
+		vals       Values
+		aggregator Aggregator
+		by         []string
+		k          int
+		errIs      require.ErrorAssertionFunc
+		expected   Values
+	}{
+		{
+			name:       "sum of numbers by label",
+			vals:       numbers,
+			aggregator: AggregatorSum,
+			by:         []string{"region"},
+			errIs:      require.NoError,
+			expected: Values{
+				makeNumber("B", data.Labels{"region": "eu"}, new(5.0)),
+				makeNumber("B", data.Labels{"region": "us"}, new(2.0)),
+				makeNumber("B", nil, new(8.0)),
+			},
+		},
+		{
+			name:       "count of numbers without labels skips null values",
+			vals:       numbers,
+			aggregator: AggregatorCount,
+			errIs:      require.NoError,
+			expected: Values{
+				makeNumber("B", nil, new(4.0)),
+			},
+		},
+		{
+			name:       "avg of numbers",
+			vals:       numbers,
+			aggregator: AggregatorAvg,
+			by:         []string{"region"},
+			errIs:      require.NoError,
+			expected: Values{
+				makeNumber("B", data.Labels{"region": "eu"}, new(2.5)),
+				makeNumber("B", data.Labels{"region": "us"}, new(2.0)),
+				makeNumber("B", nil, new(8.0)),
+			},
+		},
+		{
+			name:       "topk of numbers keeps the original labels",
+			vals:       numbers,
+			aggregator: AggregatorTopK,
+			by:         []string{"region"},
+			k:          1,
+			errIs:      require.NoError,
+			expected: Values{
+				makeNumber("B", data.Labels{"host": "b", "region": "eu"}, new(4.0)),
+				makeNumber("B", data.Labels{"host": "c", "region": "us"}, new(2.0)),
+				makeNumber("B", data.Labels{"host": "e"}, new(8.0)),
+			},
+		},
+		{
+			name:       "bottomk of numbers",
+			vals:       numbers,
+			aggregator: AggregatorBottomK,
+			k:          2,
+			errIs:      require.NoError,
+			expected: Values{
+				makeNumber("B", data.Labels{"host": "a", "region": "eu"}, new(1.0)),
+				makeNumber("B", data.Labels{"host": "c", "region": "us"}, new(2.0)),
+			},
+		},
+		{
+			name:       "max of series aligns by timestamp",
+			vals:       series,
+			aggregator: AggregatorMax,
+			by:         []string{"region"},
+			errIs:      require.NoError,
+			expected: Values{
+				makeSeries("B", data.Labels{"region": "eu"},
+					tp{time.Unix(0, 0), new(5.0)},
+					tp{time.Unix(5, 0), new(1.0)},
+					tp{time.Unix(10, 0), new(3.0)},
+					tp{time.Unix(15, 0), nil},
+				),
+				makeSeries("B", data.Labels{"region": "us"},
+					tp{time.Unix(5, 0), new(7.0)},
+				),
+			},
+		},
+		{
+			name:       "sum of series",
+			vals:       series,
+			aggregator: AggregatorSum,
+			errIs:      require.NoError,
+			expected: Values{
+				makeSeries("B", nil,
+					tp{time.Unix(0, 0), new(5.0)},
+					tp{time.Unix(5, 0), new(8.0)},
+					tp{time.Unix(10, 0), new(5.0)},
+					tp{time.Unix(15, 0), nil},
+				),
+			},
+		},
+		{
+			name:       "topk of series ranks per timestamp",
+			vals:       series,
+			aggregator: AggregatorTopK,
+			k:          1,
+			errIs:      require.NoError,
+			expected: Values{
+				makeSeries("B", data.Labels{"host": "a", "region": "eu"},
+					tp{time.Unix(5, 0), nil},
+					tp{time.Unix(10, 0), new(3.0)},
+				),
+				makeSeries("B", data.Labels{"host": "b", "region": "eu"},
+					tp{time.Unix(0, 0), new(5.0)},
+					tp{time.Unix(10, 0), nil},
+					tp{time.Unix(15, 0), nil},
+				),
+				makeSeries("B", data.Labels{"host": "c", "region": "us"},
+					tp{time.Unix(5, 0), new(7.0)},
+				),
+			},
+		},
+		{
+			name:       "no data is returned if there are no values",
+			vals:       Values{NewNoData()},
+			aggregator: AggregatorSum,
+			errIs:      require.NoError,
+			expected:   Values{NewNoData()},
+		},
+		{
+			name:       "numbers and series can not be mixed",
+			vals:       Values{numbers[0], series[0]},
+			aggregator: AggregatorSum,
+			errIs:      require.Error,
+		},
+		{
+			name:       "unknown aggregator",
+			vals:       numbers,
+			aggregator: Aggregator("stddev"),
+			errIs:      require.Error,
+		},
+		{
+			name:       "topk requires k",
+			vals:       numbers,
+			aggregator: AggregatorTopK,
+			errIs:      require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Aggregate("B", tt.vals, tt.aggregator, tt.by, tt.k)
+			tt.errIs(t, err)
+			if err == nil {
+				require.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package mathexp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// The anomaly detection algorithm
+// +enum
+type AnomalyAlgorithm string
+
+const (
+	// Number of standard deviations from the mean of the preceding window
+	AnomalyZScore AnomalyAlgorithm = "zscore"
+	// Number of median absolute deviations from the median of the preceding window
+	AnomalyMAD AnomalyAlgorithm = "mad"
+	// Number of standard deviations from the mean of the same time in previous periods
+	AnomalySeasonal AnomalyAlgorithm = "seasonal"
+)
+
+// The output of anomaly detection
+// +enum
+type AnomalyOutput string
+
+const (
+	// A series that is 1 where the value is anomalous and 0 otherwise
+	AnomalyOutputFlag AnomalyOutput = "anomaly"
+	// A lower and an upper series that contain the expected values
+	AnomalyOutputBand AnomalyOutput = "band"
+)
+
+// AnomalyBandLabel is the label added to the series of a band to tell the lower and the upper bound apart.
+const AnomalyBandLabel = "anomaly_band"
+
+// madScale scales the median absolute deviation so that it estimates the standard deviation of normally distributed values.
+const madScale = 1.4826
+
+// AnomalyOptions configure the detection of anomalies in a series.
+type AnomalyOptions struct {
+	Algorithm AnomalyAlgorithm
+	// Window is the duration of the preceding window for zscore and mad.
+	// For seasonal it is the tolerance around the same time in previous periods.
+	Window time.Duration
+	// Period is the length of a season. Only used by seasonal.
+	Period time.Duration
+	// Seasons is the number of previous periods to compare with. Only used by seasonal.
+	Seasons int
+	// Sensitivity is the number of deviations after which a value is anomalous.
+	Sensitivity float64
+	Output      AnomalyOutput
+}
+
+// Validate returns an error if the options can not be used to detect anomalies.
+func (o AnomalyOptions) Validate() error {
+	switch o.Algorithm {
+	case AnomalyZScore, AnomalyMAD:
+	case AnomalySeasonal:
+		if o.Period <= 0 {
+			return errors.New("seasonal anomaly detection requires a period greater than 0")
+		}
+		if o.Seasons < 1 {
+			return fmt.Errorf("seasonal anomaly detection requires at least one season, got %d", o.Seasons)
+		}
+	default:
+		return fmt.Errorf("anomaly detection algorithm %q is not supported", o.Algorithm)
+	}
+	if o.Window <= 0 {
+		return errors.New("anomaly detection requires a window greater than 0")
+	}
+	if o.Sensitivity <= 0 || math.IsNaN(o.Sensitivity) {
+		return fmt.Errorf("anomaly detection sensitivity must be greater than 0, got %v", o.Sensitivity)
+	}
+	if o.Output != AnomalyOutputFlag && o.Output != AnomalyOutputBand {
+		return fmt.Errorf("anomaly detection output %q is not supported", o.Output)
+	}
+	return nil
+}
+
+// DetectAnomalies compares each point of the series with a baseline of reference points.
+// zscore and mad use the points in the window that precedes the point. seasonal uses the points
+// within half the window around the same time in each of the previous seasons.
+//
+// With the anomaly output the result is a single series that is 1 if the point deviates from the
+// baseline by more than sensitivity deviations, and 0 otherwise. With the band output the result is
+// a lower and an upper series labeled with AnomalyBandLabel.
+// A point is null if the value is null or if there are less than two reference points.
+func DetectAnomalies(refID string, s Series, opts AnomalyOptions) ([]Series, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	points := make([]windowPoint, s.Len())
+	for i := range s.Len() {
+		t, f := s.GetPoint(i)
+		points[i] = windowPoint{t: t, f: f}
+	}
+	slices.SortStableFunc(points, func(a, b windowPoint) int {
+		return a.t.Compare(b.t)
+	})
+
+	var flags, lower, upper Series
+	if opts.Output == AnomalyOutputBand {
+		lower = NewSeries(refID, bandLabels(s.GetLabels(), "lower"), len(points))
+		upper = NewSeries(refID, bandLabels(s.GetLabels(), "upper"), len(points))
+	} else {
+		var ls data.Labels
+		if s.GetLabels() != nil {
+			ls = s.GetLabels().Copy()
+		}
+		flags = NewSeries(refID, ls, len(points))
+	}
+
+	for i, p := range points {
+		var ref []float64
+		if opts.Algorithm == AnomalySeasonal {
+			for n := 1; n <= opts.Seasons; n++ {
+				at := p.t.Add(-time.Duration(n) * opts.Period)
+				ref = appendValues(ref, points, at.Add(-opts.Window/2), at.Add(opts.Window/2))
+			}
+		} else {
+			ref = appendValues(ref, points[:i], p.t.Add(-opts.Window), p.t)
+		}
+
+		var center, spread *float64
+		if len(ref) >= 2 {
+			if opts.Algorithm == AnomalyMAD {
+				center, spread = medianAbsoluteDeviation(ref)
+			} else {
+				center, spread = meanStdDev(ref)
+			}
+		}
+
+		if opts.Output == AnomalyOutputBand {
+			var lo, hi *float64
+			if center != nil {
+				lo = new(*center - opts.Sensitivity**spread)
+				hi = new(*center + opts.Sensitivity**spread)
+			}
+			lower.SetPoint(i, p.t, lo)
+			upper.SetPoint(i, p.t, hi)
+			continue
+		}
+
+		var flag *float64
+		if center != nil && p.f != nil && !math.IsNaN(*p.f) {
+			flag = new(0.0)
+			deviation := math.Abs(*p.f - *center)
+			if (*spread == 0 && deviation > 0) || (*spread > 0 && deviation/(*spread) > opts.Sensitivity) {
+				flag = new(1.0)
+			}
+		}
+		flags.SetPoint(i, p.t, flag)
+	}
+
+	if opts.Output == AnomalyOutputBand {
+		return []Series{lower, upper}, nil
+	}
+	return []Series{flags}, nil
+}
+
+// appendValues appends the non-null values of the time sorted points in the range (from, to) to values.
+func appendValues(values []float64, points []windowPoint, from, to time.Time) []float64 {
+	start := sort.Search(len(points), func(i int) bool {
+		return points[i].t.After(from)
+	})
+	for _, p := range points[start:] {
+		if !p.t.Before(to) {
+			break
+		}
+		if p.f != nil && !math.IsNaN(*p.f) {
+			values = append(values, *p.f)
+		}
+	}
+	return values
+}
+
+func meanStdDev(values []float64) (*float64, *float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return new(mean), new(math.Sqrt(squares / float64(len(values))))
+}
+
+func medianAbsoluteDeviation(values []float64) (*float64, *float64) {
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return new(median), new(madScale * medianOf(deviations))
+}
+
+// medianOf returns the median of values, values are sorted in place.
+func medianOf(values []float64) float64 {
+	slices.Sort(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+func bandLabels(ls data.Labels, bound string) data.Labels {
+	newLabels := data.Labels{}
+	if ls != nil {
+		newLabels = ls.Copy()
+	}
+	newLabels[AnomalyBandLabel] = bound
+	return newLabels
+}
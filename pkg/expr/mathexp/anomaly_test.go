@@ -0,0 +1,141 @@
+package mathexp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectAnomalies(t *testing.T) {
+	minutes := func(values ...float64) Series {
+		s := NewSeries("A", data.Labels{"host": "a"}, len(values))
+		for i, v := range values {
+			s.SetPoint(i, time.Unix(int64(i*60), 0), new(v))
+		}
+		return s
+	}
+	flags := func(step time.Duration, values ...*float64) Series {
+		s := NewSeries("B", data.Labels{"host": "a"}, len(values))
+		for i, v := range values {
+			s.SetPoint(i, time.Unix(0, 0).Add(time.Duration(i)*step), v)
+		}
+		return s
+	}
+
+	var tests = []struct {
+		name     string
+		series   Series
+		opts     AnomalyOptions
+		errIs    require.ErrorAssertionFunc
+		expected []Series
+	}{
+		{
+			name:   "zscore flags values that deviate from the preceding window",
+			series: minutes(10, 12, 10, 12, 10, 50),
+			opts: AnomalyOptions{
+				Algorithm:   AnomalyZScore,
+				Window:      5 * time.Minute,
+				Sensitivity: 3,
+				Output:      AnomalyOutputFlag,
+			},
+			errIs: require.NoError,
+			expected: []Series{
+				flags(time.Minute, nil, nil, new(0.0), new(0.0), new(0.0), new(1.0)),
+			},
+		},
+		{
+			name:   "mad is not skewed by previous anomalies",
+			series: minutes(10, 12, 11, 10, 100, 11),
+			opts: AnomalyOptions{
+				Algorithm:   AnomalyMAD,
+				Window:      10 * time.Minute,
+				Sensitivity: 3,
+				Output:      AnomalyOutputFlag,
+			},
+			errIs: require.NoError,
+			expected: []Series{
+				flags(time.Minute, nil, nil, new(0.0), new(0.0), new(1.0), new(0.0)),
+			},
+		},
+		{
+			name: "seasonal compares with the same time in previous periods",
+			series: func() Series {
+				s := NewSeries("A", data.Labels{"host": "a"}, 0)
+				for i, v := range []float64{10, 12, 11, 30} {
+					s.AppendPoint(time.Unix(0, 0).Add(time.Duration(i)*time.Hour), new(v))
+				}
+				return s
+			}(),
+			opts: AnomalyOptions{
+				Algorithm:   AnomalySeasonal,
+				Window:      10 * time.Minute,
+				Period:      time.Hour,
+				Seasons:     2,
+				Sensitivity: 3,
+				Output:      AnomalyOutputFlag,
+			},
+			errIs: require.NoError,
+			expected: []Series{
+				flags(time.Hour, nil, nil, new(0.0), new(1.0)),
+			},
+		},
+		{
+			name:   "band contains the expected values",
+			series: minutes(10, 12, 100),
+			opts: AnomalyOptions{
+				Algorithm:   AnomalyZScore,
+				Window:      5 * time.Minute,
+				Sensitivity: 2,
+				Output:      AnomalyOutputBand,
+			},
+			errIs: require.NoError,
+			expected: []Series{
+				makeSeries("B", data.Labels{"host": "a", AnomalyBandLabel: "lower"},
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(60, 0), nil},
+					tp{time.Unix(120, 0), new(9.0)},
+				),
+				makeSeries("B", data.Labels{"host": "a", AnomalyBandLabel: "upper"},
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(60, 0), nil},
+					tp{time.Unix(120, 0), new(13.0)},
+				),
+			},
+		},
+		{
+			name:   "seasonal requires a period",
+			series: minutes(1, 2, 3),
+			opts: AnomalyOptions{
+				Algorithm:   AnomalySeasonal,
+				Window:      time.Minute,
+				Seasons:     1,
+				Sensitivity: 3,
+				Output:      AnomalyOutputFlag,
+			},
+			errIs: require.Error,
+		},
+		{
+			name:   "unknown algorithm",
+			series: minutes(1, 2, 3),
+			opts: AnomalyOptions{
+				Algorithm:   "prophet",
+				Window:      time.Minute,
+				Sensitivity: 3,
+				Output:      AnomalyOutputFlag,
+			},
+			errIs: require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := DetectAnomalies("B", tt.series, tt.opts)
+			tt.errIs(t, err)
+			if err == nil {
+				require.Equal(t, tt.expected, res)
+			}
+		})
+	}
+}
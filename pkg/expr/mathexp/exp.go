@@ -757,6 +757,8 @@ func (e *State) walkFunc(node *parse.FuncNode) (Results, error) {
 			v = e.Vars[t.Name]
 		case *parse.ScalarNode:
 			v = NewScalarResults(e.RefID, &t.Float64)
+		case *parse.DurationNode:
+			v = t.Duration
 		case *parse.FuncNode:
 			v, err = e.walkFunc(t)
 		case *parse.UnaryNode:
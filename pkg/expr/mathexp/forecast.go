@@ -0,0 +1,280 @@
+package mathexp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// The forecasting method
+// +enum
+type ForecastMethod string
+
+const (
+	// Least squares linear regression, like predict_linear in Prometheus
+	ForecastLinear ForecastMethod = "linear"
+	// Double exponential smoothing of the level and the trend
+	ForecastHolt ForecastMethod = "holt"
+	// Triple exponential smoothing of the level, the trend and an additive season
+	ForecastHoltWinters ForecastMethod = "holt_winters"
+)
+
+// The output of a forecast
+// +enum
+type ForecastOutput string
+
+const (
+	// The projected value at the end of the horizon
+	ForecastOutputValue ForecastOutput = "value"
+	// The number of seconds until the projected value crosses the threshold, 0 if it already has, +Inf if it does not within the horizon
+	ForecastOutputTimeToThreshold ForecastOutput = "time_to_threshold"
+)
+
+// maxForecastSteps limits the number of points that are projected to find the time to a threshold.
+const maxForecastSteps = 10000
+
+// ForecastOptions configure the projection of a series into the future.
+type ForecastOptions struct {
+	Method ForecastMethod
+	// Horizon is how far into the future the series is projected.
+	// For the time_to_threshold output it is the longest time that is searched.
+	Horizon   time.Duration
+	Output    ForecastOutput
+	Threshold float64
+	// Period is the length of a season. Only used by holt_winters.
+	Period time.Duration
+	// Alpha, Beta and Gamma are the smoothing factors of the level, the trend and the season,
+	// between 0 and 1. Only used by holt and holt_winters.
+	Alpha float64
+	Beta  float64
+	Gamma float64
+}
+
+// Validate returns an error if the options can not be used to forecast.
+func (o ForecastOptions) Validate() error {
+	switch o.Method {
+	case ForecastLinear:
+	case ForecastHoltWinters:
+		if o.Period <= 0 {
+			return errors.New("holt_winters forecast requires a period greater than 0")
+		}
+		if err := validateSmoothingFactor("seasonal", o.Gamma); err != nil {
+			return err
+		}
+		fallthrough
+	case ForecastHolt:
+		if err := validateSmoothingFactor("smoothing", o.Alpha); err != nil {
+			return err
+		}
+		if err := validateSmoothingFactor("trend", o.Beta); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("forecast method %q is not supported", o.Method)
+	}
+	if o.Horizon <= 0 {
+		return errors.New("forecast requires a horizon greater than 0")
+	}
+	if o.Output != ForecastOutputValue && o.Output != ForecastOutputTimeToThreshold {
+		return fmt.Errorf("forecast output %q is not supported", o.Output)
+	}
+	return nil
+}
+
+func validateSmoothingFactor(name string, f float64) error {
+	if !(f > 0 && f < 1) {
+		return fmt.Errorf("%s factor must be between 0 and 1, got %v", name, f)
+	}
+	return nil
+}
+
+// Forecast fits a model to the non-null values of the series and projects it from now into the future.
+// holt and holt_winters assume that the points are evenly spaced.
+// The result is null if there are not enough points to fit the model: two for linear and holt, and
+// two full periods for holt_winters.
+func (s Series) Forecast(refID string, now time.Time, opts ForecastOptions) (Number, error) {
+	var l data.Labels
+	if s.GetLabels() != nil {
+		l = s.GetLabels().Copy()
+	}
+	number := NewNumber(refID, l)
+	if err := opts.Validate(); err != nil {
+		return number, err
+	}
+
+	var times []time.Time
+	var values []float64
+	sorted := NewSeries(refID, nil, s.Len())
+	for i := range s.Len() {
+		t, f := s.GetPoint(i)
+		sorted.SetPoint(i, t, f)
+	}
+	sorted.SortByTime(false)
+	for i := range sorted.Len() {
+		t, f := sorted.GetPoint(i)
+		if f == nil || math.IsNaN(*f) {
+			continue
+		}
+		times = append(times, t)
+		values = append(values, *f)
+	}
+	if len(times) < 2 {
+		return number, nil
+	}
+	step := times[len(times)-1].Sub(times[0]) / time.Duration(len(times)-1)
+	if step <= 0 {
+		return number, nil
+	}
+
+	var model func(t time.Time) float64
+	switch opts.Method {
+	case ForecastLinear:
+		model = fitLinear(times, values)
+	case ForecastHolt:
+		model = fitHolt(times, values, step, opts.Alpha, opts.Beta)
+	case ForecastHoltWinters:
+		model = fitHoltWinters(times, values, step, opts.Period, opts.Alpha, opts.Beta, opts.Gamma)
+	}
+	if model == nil {
+		return number, nil
+	}
+
+	if opts.Output == ForecastOutputValue {
+		number.SetValue(new(model(now.Add(opts.Horizon))))
+		return number, nil
+	}
+	number.SetValue(new(timeToThreshold(model, now, opts.Horizon, step, opts.Threshold)))
+	return number, nil
+}
+
+// fitLinear returns the least squares regression line of the values over time.
+func fitLinear(times []time.Time, values []float64) func(t time.Time) float64 {
+	// x is relative to the first point to keep the precision
+	origin := times[0]
+	n := float64(len(times))
+	var sumX, sumY, sumXY, sumXX float64
+	for i, t := range times {
+		x := t.Sub(origin).Seconds()
+		sumX += x
+		sumY += values[i]
+		sumXY += x * values[i]
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return nil
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	intercept := (sumY - slope*sumX) / n
+	return func(t time.Time) float64 {
+		return intercept + slope*t.Sub(origin).Seconds()
+	}
+}
+
+// fitHolt returns the projection of double exponential smoothing of the values.
+func fitHolt(times []time.Time, values []float64, step time.Duration, alpha, beta float64) func(t time.Time) float64 {
+	level, trend := values[0], values[1]-values[0]
+	for _, v := range values[1:] {
+		prevLevel := level
+		level = alpha*v + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+	}
+	last := times[len(times)-1]
+	return func(t time.Time) float64 {
+		h := float64(t.Sub(last)) / float64(step)
+		return level + h*trend
+	}
+}
+
+// fitHoltWinters returns the projection of triple exponential smoothing of the values with an additive season.
+// It requires at least two full periods of values.
+func fitHoltWinters(times []time.Time, values []float64, step, period time.Duration, alpha, beta, gamma float64) func(t time.Time) float64 {
+	m := int(math.Round(float64(period) / float64(step)))
+	if m < 2 || len(values) < 2*m {
+		return nil
+	}
+
+	var firstMean, secondMean float64
+	for i := range m {
+		firstMean += values[i]
+		secondMean += values[m+i]
+	}
+	firstMean /= float64(m)
+	secondMean /= float64(m)
+
+	level := firstMean
+	trend := (secondMean - firstMean) / float64(m)
+	season := make([]float64, len(values))
+	for i := range m {
+		season[i] = values[i] - firstMean
+	}
+	for i := m; i < len(values); i++ {
+		prevLevel := level
+		level = alpha*(values[i]-season[i-m]) + (1-alpha)*(level+trend)
+		trend = beta*(level-prevLevel) + (1-beta)*trend
+		season[i] = gamma*(values[i]-level) + (1-gamma)*season[i-m]
+	}
+
+	last := times[len(times)-1]
+	n := len(values)
+	return func(t time.Time) float64 {
+		h := float64(t.Sub(last)) / float64(step)
+		// the season of the closest step, taken from the last full period
+		offset := (m - 1 + int(math.Round(h))) % m
+		if offset < 0 {
+			offset += m
+		}
+		idx := n - m + offset
+		return level + h*trend + season[idx]
+	}
+}
+
+// timeToThreshold returns the number of seconds from now until the model crosses the threshold,
+// or +Inf if it does not cross the threshold within the horizon.
+// If the value is already past the threshold in the direction the model moves over the horizon,
+// for example a disk that is already full, it returns 0.
+func timeToThreshold(model func(t time.Time) float64, now time.Time, horizon, step time.Duration, threshold float64) float64 {
+	steps := maxForecastSteps
+	if f := math.Ceil(float64(horizon) / float64(step)); f <= maxForecastSteps {
+		steps = int(f)
+	} else {
+		step = horizon / maxForecastSteps
+	}
+
+	distance := func(d time.Duration) float64 {
+		return model(now.Add(d)) - threshold
+	}
+	prev := distance(0)
+	if prev == 0 {
+		return 0
+	}
+	if direction := sign(distance(horizon) - prev); direction != 0 && direction == sign(prev) {
+		return 0
+	}
+
+	for i := 1; i <= steps; i++ {
+		from, to := min(time.Duration(i-1)*step, horizon), min(time.Duration(i)*step, horizon)
+		next := distance(to)
+		if sign(next) != sign(prev) {
+			// linear interpolation within the step
+			d := from + time.Duration(float64(to-from)*prev/(prev-next))
+			return d.Seconds()
+		}
+		prev = next
+	}
+	return math.Inf(1)
+}
+
+func sign(f float64) int {
+	switch {
+	case f > 0:
+		return 1
+	case f < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,194 @@
+package mathexp
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesForecast(t *testing.T) {
+	minutes := func(values ...float64) Series {
+		s := NewSeries("A", data.Labels{"host": "a"}, len(values))
+		for i, v := range values {
+			s.SetPoint(i, time.Unix(int64(i*60), 0), new(v))
+		}
+		return s
+	}
+	linear := minutes(0, 60, 120)
+	seasonal := minutes(0, 10, 0, 10, 0, 10, 0, 10)
+
+	var tests = []struct {
+		name     string
+		series   Series
+		now      time.Time
+		opts     ForecastOptions
+		errIs    require.ErrorAssertionFunc
+		expected *float64
+	}{
+		{
+			name:   "linear projects the value at the end of the horizon",
+			series: linear,
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:  ForecastLinear,
+				Horizon: time.Minute,
+				Output:  ForecastOutputValue,
+			},
+			errIs:    require.NoError,
+			expected: new(180.0),
+		},
+		{
+			name:   "linear time to threshold",
+			series: linear,
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:    ForecastLinear,
+				Horizon:   time.Hour,
+				Output:    ForecastOutputTimeToThreshold,
+				Threshold: 300,
+			},
+			errIs:    require.NoError,
+			expected: new(180.0),
+		},
+		{
+			name:   "time to threshold is zero when the value has already crossed the threshold",
+			series: linear,
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:    ForecastLinear,
+				Horizon:   time.Hour,
+				Output:    ForecastOutputTimeToThreshold,
+				Threshold: 100,
+			},
+			errIs:    require.NoError,
+			expected: new(0.0),
+		},
+		{
+			name:   "time to threshold is zero when a falling value has already crossed the threshold",
+			series: minutes(120, 60, 0),
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:    ForecastLinear,
+				Horizon:   time.Hour,
+				Output:    ForecastOutputTimeToThreshold,
+				Threshold: 10,
+			},
+			errIs:    require.NoError,
+			expected: new(0.0),
+		},
+		{
+			name:   "time to threshold is infinite when the value does not move",
+			series: minutes(50, 50, 50),
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:    ForecastLinear,
+				Horizon:   time.Hour,
+				Output:    ForecastOutputTimeToThreshold,
+				Threshold: 100,
+			},
+			errIs:    require.NoError,
+			expected: new(math.Inf(1)),
+		},
+		{
+			name:   "time to threshold is infinite when the threshold is beyond the horizon",
+			series: linear,
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:    ForecastLinear,
+				Horizon:   time.Hour,
+				Output:    ForecastOutputTimeToThreshold,
+				Threshold: 10000,
+			},
+			errIs:    require.NoError,
+			expected: new(math.Inf(1)),
+		},
+		{
+			name:   "holt follows the trend",
+			series: minutes(0, 1, 2, 3),
+			now:    time.Unix(180, 0),
+			opts: ForecastOptions{
+				Method:  ForecastHolt,
+				Horizon: 2 * time.Minute,
+				Output:  ForecastOutputValue,
+				Alpha:   0.5,
+				Beta:    0.5,
+			},
+			errIs:    require.NoError,
+			expected: new(5.0),
+		},
+		{
+			name:   "holt_winters follows the season",
+			series: seasonal,
+			now:    time.Unix(420, 0),
+			opts: ForecastOptions{
+				Method:  ForecastHoltWinters,
+				Horizon: time.Minute,
+				Output:  ForecastOutputValue,
+				Period:  2 * time.Minute,
+				Alpha:   0.5,
+				Beta:    0.5,
+				Gamma:   0.5,
+			},
+			errIs:    require.NoError,
+			expected: new(0.0),
+		},
+		{
+			name:   "holt_winters is null without two full periods",
+			series: minutes(0, 10, 0),
+			now:    time.Unix(120, 0),
+			opts: ForecastOptions{
+				Method:  ForecastHoltWinters,
+				Horizon: time.Minute,
+				Output:  ForecastOutputValue,
+				Period:  2 * time.Minute,
+				Alpha:   0.5,
+				Beta:    0.5,
+				Gamma:   0.5,
+			},
+			errIs: require.NoError,
+		},
+		{
+			name:   "linear is null with a single point",
+			series: minutes(1),
+			now:    time.Unix(0, 0),
+			opts: ForecastOptions{
+				Method:  ForecastLinear,
+				Horizon: time.Minute,
+				Output:  ForecastOutputValue,
+			},
+			errIs: require.NoError,
+		},
+		{
+			name:   "smoothing factor must be between 0 and 1",
+			series: linear,
+			opts: ForecastOptions{
+				Method:  ForecastHolt,
+				Horizon: time.Minute,
+				Output:  ForecastOutputValue,
+				Alpha:   1.5,
+				Beta:    0.5,
+			},
+			errIs: require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.series.Forecast("B", tt.now, tt.opts)
+			tt.errIs(t, err)
+			if err != nil {
+				return
+			}
+			require.Equal(t, data.Labels{"host": "a"}, res.GetLabels())
+			if tt.expected == nil {
+				require.Nil(t, res.GetFloat64Value())
+				return
+			}
+			require.NotNil(t, res.GetFloat64Value())
+			require.InDelta(t, *tt.expected, *res.GetFloat64Value(), 1e-9)
+		})
+	}
+}
@@ -68,6 +68,31 @@ var builtins = map[string]parse.Func{
 		VariantReturn: true,
 		F:             floor,
 	},
+	"rate": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeDuration},
+		Return: parse.TypeSeriesSet,
+		F:      rate,
+	},
+	"delta": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeDuration},
+		Return: parse.TypeSeriesSet,
+		F:      delta,
+	},
+	"moving_avg": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeDuration},
+		Return: parse.TypeSeriesSet,
+		F:      movingAvg,
+	},
+	"cumsum": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet},
+		Return: parse.TypeSeriesSet,
+		F:      cumSum,
+	},
+	"shift": {
+		Args:   []parse.ReturnType{parse.TypeSeriesSet, parse.TypeDuration},
+		Return: parse.TypeSeriesSet,
+		F:      shift,
+	},
 }
 
 // abs returns the absolute value for each result in NumberSet, SeriesSet, or Scalar
@@ -56,8 +56,9 @@ const (
 	itemRightParen
 	itemString
 	itemFunc
-	itemVar // e.g. $A
-	itemPow // '**'
+	itemVar      // e.g. $A
+	itemPow      // '**'
+	itemDuration // e.g. 5m
 )
 
 const eof = -1
@@ -232,10 +233,19 @@ Loop:
 // isn't a perfect number scanner - for instance it accepts "." and "0x0.2"
 // and "089" - but when it's wrong the input is invalid and the parser (via
 // strconv) will notice.
+// A number directly followed by letters (e.g. "5m") is scanned as a duration,
+// the unit is validated by the parser.
 func lexNumber(l *lexer) stateFn {
 	if !l.scanNumber() {
 		return l.errorf("bad number syntax: %q", l.input[l.start:l.pos])
 	}
+	if r := l.peek(); unicode.IsLetter(r) {
+		for unicode.IsLetter(l.next()) {
+		}
+		l.backup()
+		l.emit(itemDuration)
+		return lexItem
+	}
 	l.emit(itemNumber)
 	return lexItem
 }
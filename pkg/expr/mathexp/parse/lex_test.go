@@ -35,6 +35,7 @@ var itemName = map[itemType]string{
 	itemRightParen: ")",
 	itemString:     "string",
 	itemFunc:       "func",
+	itemDuration:   "duration",
 }
 
 func (i itemType) String() string {
@@ -99,6 +100,23 @@ var lexTests = []lexTest{
 		{itemNumber, 0, "1.2e-4"},
 		tEOF,
 	}},
+	{"durations", "5m 1h 30s 1.5h 7d", []item{
+		{itemDuration, 0, "5m"},
+		{itemDuration, 0, "1h"},
+		{itemDuration, 0, "30s"},
+		{itemDuration, 0, "1.5h"},
+		{itemDuration, 0, "7d"},
+		tEOF,
+	}},
+	{"func with duration", "rate($A, 5m)", []item{
+		{itemFunc, 0, "rate"},
+		{itemLeftParen, 0, "("},
+		{itemVar, 0, "$A"},
+		{itemComma, 0, ","},
+		{itemDuration, 0, "5m"},
+		{itemRightParen, 0, ")"},
+		tEOF,
+	}},
 	{"curly brace var", "${My Var}", []item{
 		{itemVar, 0, "${My Var}"},
 		tEOF,
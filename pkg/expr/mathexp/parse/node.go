@@ -9,6 +9,9 @@ package parse
 import (
 	"fmt"
 	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 )
 
 // A Node is an element in the parse tree. The interface is trivial.
@@ -62,6 +65,8 @@ const (
 	NodeNumber
 	// NodeVar is variable: $A
 	NodeVar
+	// NodeDuration is a duration constant: 5m
+	NodeDuration
 )
 
 // String returns the string representation of the NodeType
@@ -79,6 +84,8 @@ func (t NodeType) String() string {
 		return "NodeNumber"
 	case NodeVar:
 		return "NodeVar"
+	case NodeDuration:
+		return "NodeDuration"
 	default:
 		return "NodeUnknown"
 	}
@@ -293,6 +300,45 @@ func (s *StringNode) Return() ReturnType {
 	return TypeString
 }
 
+// DurationNode holds a duration constant such as 5m or 1h.
+type DurationNode struct {
+	NodeType
+	Pos
+	Duration time.Duration // The parsed duration.
+	Text     string        // The original textual representation from the input.
+}
+
+func newDuration(pos Pos, text string) (*DurationNode, error) {
+	d, err := gtime.ParseDuration(text)
+	if err != nil {
+		return nil, fmt.Errorf("illegal duration syntax: %q", text)
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("duration must be positive: %q", text)
+	}
+	return &DurationNode{NodeType: NodeDuration, Pos: pos, Duration: d, Text: text}, nil
+}
+
+// String returns the string representation of the DurationNode so it fulfills the Node interface.
+func (d *DurationNode) String() string {
+	return d.Text
+}
+
+// StringAST returns the string representation of abstract syntax tree of the DurationNode so it fulfills the Node interface.
+func (d *DurationNode) StringAST() string {
+	return d.String()
+}
+
+// Check performs parse time checking on the DurationNode so it fulfills the Node interface.
+func (d *DurationNode) Check(*Tree) error {
+	return nil
+}
+
+// Return returns the result type of the DurationNode so it fulfills the Node interface.
+func (d *DurationNode) Return() ReturnType {
+	return TypeDuration
+}
+
 // BinaryNode holds two arguments and an operator.
 type BinaryNode struct {
 	NodeType
@@ -380,7 +426,7 @@ func Walk(n Node, f func(Node)) {
 		for _, a := range n.Args {
 			Walk(a, f)
 		}
-	case *ScalarNode, *StringNode:
+	case *ScalarNode, *StringNode, *DurationNode:
 		// Ignore since these node types have no sub nodes.
 	case *UnaryNode:
 		Walk(n.Arg, f)
@@ -407,6 +453,8 @@ const (
 	TypeNoData
 	// TypeTableData is a tabular data response.
 	TypeTableData
+	// TypeDuration is a single duration constant.
+	TypeDuration
 )
 
 // String returns a string representation of the ReturnType.
@@ -426,6 +474,8 @@ func (f ReturnType) String() string {
 		return "noData"
 	case TypeTableData:
 		return "tableData"
+	case TypeDuration:
+		return "duration"
 	default:
 		return "unknown"
 	}
@@ -177,7 +177,7 @@ E -> F {( "**" ) F}
 F -> v | "(" O ")" | "!" O | "-" O
 v -> number | func(..) | queryVar
 Func -> name "(" param {"," param} ")"
-param -> number | "string" | queryVar
+param -> number | "string" | duration | queryVar
 */
 
 // expr:
@@ -332,9 +332,23 @@ func (t *Tree) Func() (f *FuncNode) {
 				t.errorf("Unquoting error: %s", err)
 			}
 			f.append(newString(token.pos, token.val, s))
+		case itemDuration:
+			d, err := newDuration(token.pos, token.val)
+			if err != nil {
+				t.error(err)
+			}
+			f.append(d)
 		case itemRightParen:
 			return
 		}
+		// arguments are separated by commas
+		switch token = t.next(); token.typ {
+		case itemComma:
+		case itemRightParen:
+			return
+		default:
+			t.unexpected(token, "func")
+		}
 	}
 }
 
@@ -15,18 +15,42 @@ type ReducerFunc = func(fv *Float64Field) *float64
 type ReducerID string
 
 const (
-	ReducerSum    ReducerID = "sum"
-	ReducerMean   ReducerID = "mean"
-	ReducerMin    ReducerID = "min"
-	ReducerMax    ReducerID = "max"
-	ReducerCount  ReducerID = "count"
-	ReducerLast   ReducerID = "last"
-	ReducerMedian ReducerID = "median"
+	ReducerSum         ReducerID = "sum"
+	ReducerMean        ReducerID = "mean"
+	ReducerMin         ReducerID = "min"
+	ReducerMax         ReducerID = "max"
+	ReducerCount       ReducerID = "count"
+	ReducerLast        ReducerID = "last"
+	ReducerMedian      ReducerID = "median"
+	ReducerFirst       ReducerID = "first"
+	ReducerStdDev      ReducerID = "stddev"
+	ReducerVariance    ReducerID = "variance"
+	ReducerRange       ReducerID = "range"
+	ReducerDiff        ReducerID = "diff"
+	ReducerPercentDiff ReducerID = "percent_diff"
+	ReducerPercentile  ReducerID = "percentile"
 )
 
 // GetSupportedReduceFuncs returns collection of supported function names
 func GetSupportedReduceFuncs() []ReducerID {
-	return []ReducerID{ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian}
+	return []ReducerID{
+		ReducerSum, ReducerMean, ReducerMin, ReducerMax, ReducerCount, ReducerLast, ReducerMedian,
+		ReducerFirst, ReducerStdDev, ReducerVariance, ReducerRange, ReducerDiff, ReducerPercentDiff, ReducerPercentile,
+	}
+}
+
+// ReducerOption sets an argument of a parametrized reducer such as percentile.
+type ReducerOption func(*reducerArgs)
+
+type reducerArgs struct {
+	percentile *float64
+}
+
+// WithPercentile sets the percentile, between 0 and 100, calculated by the percentile reducer.
+func WithPercentile(p float64) ReducerOption {
+	return func(a *reducerArgs) {
+		a.percentile = &p
+	}
 }
 
 func Sum(fv *Float64Field) *float64 {
@@ -126,7 +150,117 @@ func Median(fv *Float64Field) *float64 {
 	}
 }
 
-func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
+func First(fv *Float64Field) *float64 {
+	var f float64
+	if fv.Len() == 0 {
+		f = math.NaN()
+		return &f
+	}
+	return fv.GetValue(0)
+}
+
+func Variance(fv *Float64Field) *float64 {
+	values, ok := strictValues(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var squares float64
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	variance := squares / float64(len(values))
+	return &variance
+}
+
+func StdDev(fv *Float64Field) *float64 {
+	f := math.Sqrt(*Variance(fv))
+	return &f
+}
+
+func Range(fv *Float64Field) *float64 {
+	f := *Max(fv) - *Min(fv)
+	return &f
+}
+
+// Diff returns the difference between the last and the first value.
+func Diff(fv *Float64Field) *float64 {
+	first, last, ok := firstAndLast(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	f := last - first
+	return &f
+}
+
+// PercentDiff returns the difference between the last and the first value
+// as a percentage of the first value.
+func PercentDiff(fv *Float64Field) *float64 {
+	first, last, ok := firstAndLast(fv)
+	if !ok {
+		nan := math.NaN()
+		return &nan
+	}
+	f := (last - first) / math.Abs(first) * 100
+	return &f
+}
+
+// Percentile returns a reduce function that calculates the p-th percentile, with p between 0 and 100.
+// Values between the closest ranks are linearly interpolated.
+func Percentile(p float64) ReducerFunc {
+	return func(fv *Float64Field) *float64 {
+		values, ok := strictValues(fv)
+		if !ok {
+			nan := math.NaN()
+			return &nan
+		}
+		sort.Float64s(values)
+		rank := p / 100 * float64(len(values)-1)
+		lower := int(math.Floor(rank))
+		upper := int(math.Ceil(rank))
+		f := values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+		return &f
+	}
+}
+
+// strictValues returns the values of the field, ok is false if the field is empty or has a null or NaN value.
+func strictValues(fv *Float64Field) ([]float64, bool) {
+	values := make([]float64, 0, fv.Len())
+	for i := 0; i < fv.Len(); i++ {
+		v := fv.GetValue(i)
+		if v == nil || math.IsNaN(*v) {
+			return nil, false
+		}
+		values = append(values, *v)
+	}
+	return values, len(values) > 0
+}
+
+// firstAndLast returns the first and the last values of the field, ok is false if the field is empty or either value is null.
+func firstAndLast(fv *Float64Field) (first float64, last float64, ok bool) {
+	if fv.Len() == 0 {
+		return 0, 0, false
+	}
+	f, l := fv.GetValue(0), fv.GetValue(fv.Len()-1)
+	if f == nil || l == nil {
+		return 0, 0, false
+	}
+	return *f, *l, true
+}
+
+// GetReduceFunc returns the reduce function for the given reducer.
+// Parametrized reducers take their arguments from opts, and return an error if an argument is missing or invalid.
+func GetReduceFunc(rFunc ReducerID, opts ...ReducerOption) (ReducerFunc, error) {
+	args := reducerArgs{}
+	for _, opt := range opts {
+		opt(&args)
+	}
 	switch rFunc {
 	case ReducerSum:
 		return Sum, nil
@@ -142,6 +276,27 @@ func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 		return Last, nil
 	case ReducerMedian:
 		return Median, nil
+	case ReducerFirst:
+		return First, nil
+	case ReducerStdDev:
+		return StdDev, nil
+	case ReducerVariance:
+		return Variance, nil
+	case ReducerRange:
+		return Range, nil
+	case ReducerDiff:
+		return Diff, nil
+	case ReducerPercentDiff:
+		return PercentDiff, nil
+	case ReducerPercentile:
+		if args.percentile == nil {
+			return nil, fmt.Errorf("reduction %v requires a percentile", rFunc)
+		}
+		p := *args.percentile
+		if math.IsNaN(p) || p < 0 || p > 100 {
+			return nil, fmt.Errorf("reduction %v requires a percentile between 0 and 100, got %v", rFunc, p)
+		}
+		return Percentile(p), nil
 	default:
 		return nil, fmt.Errorf("reduction %v not implemented", rFunc)
 	}
@@ -150,7 +305,7 @@ func GetReduceFunc(rFunc ReducerID) (ReducerFunc, error) {
 // Reduce turns the Series into a Number based on the given reduction function
 // if ReduceMapper is defined it applies it to the provided series and performs reduction of the resulting series.
 // Otherwise, the reduction operation is done against the original series.
-func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper) (Number, error) {
+func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper, opts ...ReducerOption) (Number, error) {
 	var l data.Labels
 	if s.GetLabels() != nil {
 		l = s.GetLabels().Copy()
@@ -163,7 +318,7 @@ func (s Series) Reduce(refID string, rFunc ReducerID, mapper ReduceMapper) (Numb
 	}
 	fVec := series.Frame.Fields[seriesTypeValIdx]
 	floatField := Float64Field(*fVec)
-	reduceFunc, err := GetReduceFunc(rFunc)
+	reduceFunc, err := GetReduceFunc(rFunc, opts...)
 	if err != nil {
 		return number, fmt.Errorf("invalid expression '%s': %w", refID, err)
 	}
@@ -299,6 +299,158 @@ func TestSeriesReduce(t *testing.T) {
 	}
 }
 
+var fourPointSeries = Vars{
+	"A": resultValuesNoErr(
+		makeSeries("temp", nil,
+			tp{time.Unix(5, 0), new(4.0)},
+			tp{time.Unix(10, 0), new(2.0)},
+			tp{time.Unix(15, 0), new(8.0)},
+			tp{time.Unix(20, 0), new(6.0)},
+		),
+	),
+}
+
+func TestSeriesReduceStatistics(t *testing.T) {
+	var tests = []struct {
+		name    string
+		red     ReducerID
+		opts    []ReducerOption
+		vars    Vars
+		errIs   require.ErrorAssertionFunc
+		results Results
+	}{
+		{
+			name:    "first",
+			red:     ReducerFirst,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(4.0))),
+		},
+		{
+			name:    "first empty series",
+			red:     ReducerFirst,
+			vars:    seriesEmpty,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, NaN)),
+		},
+		{
+			name:    "variance",
+			red:     ReducerVariance,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(5.0))),
+		},
+		{
+			name:    "stddev",
+			red:     ReducerStdDev,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(math.Sqrt(5)))),
+		},
+		{
+			name:    "stddev series with a nil value",
+			red:     ReducerStdDev,
+			vars:    seriesWithNil,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, NaN)),
+		},
+		{
+			name:    "range",
+			red:     ReducerRange,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(6.0))),
+		},
+		{
+			name:    "diff",
+			red:     ReducerDiff,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(2.0))),
+		},
+		{
+			name:    "diff series with a nil value",
+			red:     ReducerDiff,
+			vars:    seriesWithNil,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, NaN)),
+		},
+		{
+			name:    "percent_diff",
+			red:     ReducerPercentDiff,
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(50.0))),
+		},
+		{
+			name:    "percentile 50 is the median",
+			red:     ReducerPercentile,
+			opts:    []ReducerOption{WithPercentile(50)},
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(5.0))),
+		},
+		{
+			name:    "percentile 90 interpolates between values",
+			red:     ReducerPercentile,
+			opts:    []ReducerOption{WithPercentile(90)},
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(7.4))),
+		},
+		{
+			name:    "percentile 100 is the max",
+			red:     ReducerPercentile,
+			opts:    []ReducerOption{WithPercentile(100)},
+			vars:    fourPointSeries,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, new(8.0))),
+		},
+		{
+			name:    "percentile empty series",
+			red:     ReducerPercentile,
+			opts:    []ReducerOption{WithPercentile(95)},
+			vars:    seriesEmpty,
+			errIs:   require.NoError,
+			results: resultValuesNoErr(makeNumber("", nil, NaN)),
+		},
+		{
+			name:  "percentile without percentile will error",
+			red:   ReducerPercentile,
+			vars:  fourPointSeries,
+			errIs: require.Error,
+		},
+		{
+			name:  "percentile out of range will error",
+			red:   ReducerPercentile,
+			opts:  []ReducerOption{WithPercentile(101)},
+			vars:  fourPointSeries,
+			errIs: require.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Results{}
+			for _, series := range tt.vars["A"].Values {
+				ns, err := series.Value().(*Series).Reduce("", tt.red, nil, tt.opts...)
+				tt.errIs(t, err)
+				if err != nil {
+					return
+				}
+				results.Values = append(results.Values, ns)
+			}
+			opt := cmp.Comparer(func(x, y float64) bool {
+				return (math.IsNaN(x) && math.IsNaN(y)) || math.Abs(x-y) < 1e-9
+			})
+			options := append([]cmp.Option{opt}, data.FrameTestCompareOptions()...)
+			if diff := cmp.Diff(tt.results, results, options...); diff != "" {
+				t.Errorf("Result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 var seriesNonNumbers = Vars{
 	"A": resultValuesNoErr(
 		makeSeries("temp", nil,
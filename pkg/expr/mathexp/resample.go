@@ -21,10 +21,32 @@ const (
 	// Do not fill values (nill)
 	UpsamplerFillNA Upsampler = "fillna"
 
+	// Linear interpolation between the last seen and the next value
+	UpsamplerLinear Upsampler = "linear"
+
+	// Use the value that is the closest in time
+	UpsamplerNearest Upsampler = "nearest"
+
 	// Maximum size of new series length.
 	MaxNewSeriesLength int = 1_000_000
 )
 
+// The downsample function, any of the reducers or the time weighted mean
+type Downsampler string
+
+// Time weighted average of the values in the window
+const DownsamplerTimeWeightedMean Downsampler = "time_weighted_mean"
+
+// Validate returns an error if the downsampler is neither a supported reducer nor the time weighted mean,
+// or if a parametrized reducer is missing its arguments.
+func (d Downsampler) Validate(opts ...ReducerOption) error {
+	if d == DownsamplerTimeWeightedMean {
+		return nil
+	}
+	_, err := GetReduceFunc(ReducerID(d), opts...)
+	return err
+}
+
 type ErrNewSeriesLengthTooLong struct {
 	newSeriesLength int
 }
@@ -34,7 +56,8 @@ func (e ErrNewSeriesLengthTooLong) Error() string {
 }
 
 // Resample turns the Series into a Number based on the given reduction function
-func (s Series) Resample(refID string, interval time.Duration, downsampler ReducerID, upsampler Upsampler, from, to time.Time) (Series, error) {
+// Arguments of parametrized downsamplers, such as percentile, are passed as opts.
+func (s Series) Resample(refID string, interval time.Duration, downsampler Downsampler, upsampler Upsampler, from, to time.Time, opts ...ReducerOption) (Series, error) {
 	newSeriesLength := int(float64(to.Sub(from).Nanoseconds()) / float64(interval.Nanoseconds()))
 	if newSeriesLength <= 0 {
 		return s, fmt.Errorf("the series cannot be sampled further; the time range is shorter than the interval")
@@ -42,13 +65,23 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 	if newSeriesLength > MaxNewSeriesLength {
 		return s, ErrNewSeriesLengthTooLong{newSeriesLength: newSeriesLength}
 	}
+	// the reducer is only needed if the series is downsampled, so the error is returned only then.
+	var reduceFunc ReducerFunc
+	var reduceErr error
+	if downsampler != DownsamplerTimeWeightedMean {
+		reduceFunc, reduceErr = GetReduceFunc(ReducerID(downsampler), opts...)
+	}
 	resampled := NewSeries(refID, s.GetLabels(), newSeriesLength+1)
 	bookmark := 0
 	var lastSeen *float64
+	var lastSeenTime time.Time
 	idx := 0
 	t := from
 	for !t.After(to) && idx <= newSeriesLength {
+		// the value held at the start of the window, used by the time weighted mean
+		heldValue, heldSince := lastSeen, lastSeenTime
 		vals := make([]*float64, 0)
+		times := make([]time.Time, 0)
 		sIdx := bookmark
 		for sIdx != s.Len() {
 			st, v := s.GetPoint(sIdx)
@@ -58,7 +91,9 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 			bookmark++
 			sIdx++
 			lastSeen = v
+			lastSeenTime = st
 			vals = append(vals, v)
+			times = append(times, st)
 		}
 		var value *float64
 		if len(vals) == 0 { // upsampling
@@ -77,30 +112,46 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 				}
 			case UpsamplerFillNA:
 				value = nil
+			case UpsamplerLinear:
+				if lastSeen != nil && sIdx != s.Len() {
+					nextTime, next := s.GetPoint(sIdx)
+					if next != nil {
+						ratio := float64(t.Sub(lastSeenTime)) / float64(nextTime.Sub(lastSeenTime))
+						interpolated := *lastSeen + (*next-*lastSeen)*ratio
+						value = &interpolated
+					}
+				}
+			case UpsamplerNearest:
+				switch {
+				case sIdx == s.Len() && bookmark == 0: // no points at all
+					value = nil
+				case sIdx == s.Len():
+					value = lastSeen
+				case bookmark == 0: // no points before
+					_, value = s.GetPoint(sIdx)
+				default:
+					nextTime, next := s.GetPoint(sIdx)
+					if nextTime.Sub(t) < t.Sub(lastSeenTime) {
+						value = next
+					} else {
+						value = lastSeen
+					}
+				}
 			default:
 				return s, fmt.Errorf("upsampling %v not implemented", upsampler)
 			}
+		} else if downsampler == DownsamplerTimeWeightedMean {
+			// a single point is also weighted against the value held from the previous window
+			value = timeWeightedMean(t.Add(-interval), t, heldValue, heldSince, times, vals)
 		} else if len(vals) == 1 {
 			value = vals[0]
 		} else { // downsampling
+			if reduceErr != nil {
+				return s, fmt.Errorf("downsampling %v failed: %w", downsampler, reduceErr)
+			}
 			fVec := data.NewField("", s.GetLabels(), vals)
 			ff := Float64Field(*fVec)
-			var tmp *float64
-			switch downsampler {
-			case ReducerSum:
-				tmp = Sum(&ff)
-			case ReducerMean:
-				tmp = Avg(&ff)
-			case ReducerMin:
-				tmp = Min(&ff)
-			case ReducerMax:
-				tmp = Max(&ff)
-			case ReducerLast:
-				tmp = Last(&ff)
-			default:
-				return s, fmt.Errorf("downsampling %v not implemented", downsampler)
-			}
-			value = tmp
+			value = reduceFunc(&ff)
 		}
 		resampled.SetPoint(idx, t, value)
 		t = t.Add(interval)
@@ -108,3 +159,58 @@ func (s Series) Resample(refID string, interval time.Duration, downsampler Reduc
 	}
 	return resampled, nil
 }
+
+// timeWeightedMean returns the average of the values in the window (start, end], where each value is
+// weighted by the time until the next point or the end of the window.
+// The value held at the start of the window is weighted by the time until the first point.
+// Null values are not included in the average.
+func timeWeightedMean(start, end time.Time, held *float64, heldSince time.Time, times []time.Time, vals []*float64) *float64 {
+	var sum, total float64
+	add := func(v *float64, from, to time.Time) {
+		if from.Before(start) {
+			from = start
+		}
+		if v == nil || !to.After(from) {
+			return
+		}
+		d := float64(to.Sub(from))
+		sum += *v * d
+		total += d
+	}
+	add(held, heldSince, times[0])
+	for i := range vals {
+		next := end
+		if i+1 < len(vals) {
+			next = times[i+1]
+		}
+		add(vals[i], times[i], next)
+	}
+	if total == 0 {
+		// all points are at the end of the window, so none of them is held for any time
+		fVec := data.NewField("", nil, vals)
+		ff := Float64Field(*fVec)
+		return Avg(&ff)
+	}
+	f := sum / total
+	return &f
+}
+
+// AlignTime returns the first time at or after t that is a multiple of interval since the Unix epoch,
+// shifted by the UTC offset of loc at t. It is used to align the resampled points to wall-clock
+// boundaries, for example hour starts, that don't depend on the day for intervals that don't divide 24h.
+func AlignTime(t time.Time, interval time.Duration, loc *time.Location) time.Time {
+	if interval <= 0 {
+		return t
+	}
+	_, offset := t.In(loc).Zone()
+	shift := time.Duration(offset) * time.Second
+	sinceEpoch := time.Duration(t.UnixNano()) + shift
+	rem := sinceEpoch % interval
+	if rem < 0 {
+		rem += interval
+	}
+	if rem == 0 {
+		return t
+	}
+	return t.Add(interval - rem)
+}
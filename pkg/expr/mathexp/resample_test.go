@@ -14,7 +14,7 @@ func TestResampleSeries(t *testing.T) {
 	var tests = []struct {
 		name             string
 		interval         time.Duration
-		downsampler      ReducerID
+		downsampler      Downsampler
 		upsampler        Upsampler
 		timeRange        backend.TimeRange
 		seriesToResample Series
@@ -279,6 +279,134 @@ func TestResampleSeries(t *testing.T) {
 				time.Unix(9, 0), new(0.0),
 			}),
 		},
+		{
+			name:        "resample series: upsampling (mean / linear)",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "linear",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(2, 0), new(2.0),
+			}, tp{
+				time.Unix(7, 0), new(12.0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), nil,
+			}, tp{
+				time.Unix(2, 0), new(2.0),
+			}, tp{
+				time.Unix(4, 0), new(6.0),
+			}, tp{
+				time.Unix(6, 0), new(10.0),
+			}, tp{
+				time.Unix(8, 0), new(12.0),
+			}, tp{
+				time.Unix(10, 0), nil,
+			}),
+		},
+		{
+			name:        "resample series: upsampling (mean / nearest)",
+			interval:    time.Second * 2,
+			downsampler: "mean",
+			upsampler:   "nearest",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(11, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(2, 0), new(2.0),
+			}, tp{
+				time.Unix(7, 0), new(1.0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), new(2.0),
+			}, tp{
+				time.Unix(2, 0), new(2.0),
+			}, tp{
+				time.Unix(4, 0), new(2.0),
+			}, tp{
+				time.Unix(6, 0), new(1.0),
+			}, tp{
+				time.Unix(8, 0), new(1.0),
+			}, tp{
+				time.Unix(10, 0), new(1.0),
+			}),
+		},
+		{
+			name:        "resample series: downsampling (time_weighted_mean / fillna)",
+			interval:    time.Second * 10,
+			downsampler: "time_weighted_mean",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(20, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(0, 0), new(0.0),
+			}, tp{
+				time.Unix(2, 0), new(10.0),
+			}, tp{
+				time.Unix(4, 0), new(0.0),
+			}, tp{
+				time.Unix(12, 0), new(10.0),
+			}, tp{
+				time.Unix(20, 0), new(0.0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), new(0.0),
+			}, tp{
+				time.Unix(10, 0), new(2.0),
+			}, tp{
+				time.Unix(20, 0), new(8.0),
+			}),
+		},
+		{
+			name:        "resample series: downsampling a single point (time_weighted_mean / fillna)",
+			interval:    time.Second * 10,
+			downsampler: "time_weighted_mean",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(20, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(0, 0), new(0.0),
+			}, tp{
+				time.Unix(15, 0), new(10.0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), new(0.0),
+			}, tp{
+				time.Unix(10, 0), nil,
+			}, tp{
+				time.Unix(20, 0), new(5.0),
+			}),
+		},
+		{
+			name:        "resample series: downsampling (median / fillna)",
+			interval:    time.Second * 5,
+			downsampler: "median",
+			upsampler:   "fillna",
+			timeRange: backend.TimeRange{
+				From: time.Unix(0, 0),
+				To:   time.Unix(6, 0),
+			},
+			seriesToResample: makeSeries("", nil, tp{
+				time.Unix(1, 0), new(2.0),
+			}, tp{
+				time.Unix(2, 0), new(9.0),
+			}, tp{
+				time.Unix(3, 0), new(3.0),
+			}),
+			series: makeSeries("", nil, tp{
+				time.Unix(0, 0), nil,
+			}, tp{
+				time.Unix(5, 0), new(3.0),
+			}),
+		},
 		{
 			name:        "resample series: upsampling, result too big",
 			interval:    time.Microsecond,
@@ -312,3 +440,92 @@ func TestResampleSeries(t *testing.T) {
 		})
 	}
 }
+
+func TestAlignTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	var tests = []struct {
+		name     string
+		t        time.Time
+		interval time.Duration
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "aligns to the next hour start",
+			t:        time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC),
+			interval: time.Hour,
+			loc:      time.UTC,
+			expected: time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "already aligned time is unchanged",
+			t:        time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+			interval: 15 * time.Minute,
+			loc:      time.UTC,
+			expected: time.Date(2024, 3, 1, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "aligns to midnight in the time zone",
+			t:        time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			interval: 24 * time.Hour,
+			loc:      berlin,
+			expected: time.Date(2024, 3, 1, 23, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "aligns to 6 hour blocks in the time zone",
+			t:        time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+			interval: 6 * time.Hour,
+			loc:      berlin,
+			expected: time.Date(2024, 3, 1, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "aligns an interval that does not divide a day to the Unix epoch",
+			t:        time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC),
+			interval: 7 * time.Minute,
+			loc:      time.UTC,
+			expected: time.Date(2024, 3, 1, 10, 25, 0, 0, time.UTC),
+		},
+		{
+			name:     "does not restart an interval that does not divide a day at midnight",
+			t:        time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			interval: 7 * time.Minute,
+			loc:      time.UTC,
+			expected: time.Date(2024, 3, 2, 0, 4, 0, 0, time.UTC),
+		},
+		{
+			name:     "aligns 5 hour blocks to the Unix epoch",
+			t:        time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC),
+			interval: 5 * time.Hour,
+			loc:      time.UTC,
+			expected: time.Date(2024, 3, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "aligns 5 hour blocks in the time zone",
+			t:        time.Date(2024, 3, 1, 10, 20, 0, 0, time.UTC),
+			interval: 5 * time.Hour,
+			loc:      berlin,
+			expected: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned := AlignTime(tt.t, tt.interval, tt.loc)
+			require.True(t, tt.expected.Equal(aligned), "expected %s, got %s", tt.expected, aligned)
+		})
+	}
+}
+
+func TestDownsamplerValidate(t *testing.T) {
+	for _, reducer := range GetSupportedReduceFuncs() {
+		if reducer == ReducerPercentile {
+			continue
+		}
+		require.NoError(t, Downsampler(reducer).Validate(), reducer)
+	}
+	require.NoError(t, DownsamplerTimeWeightedMean.Validate())
+	require.NoError(t, Downsampler(ReducerPercentile).Validate(WithPercentile(90)))
+	require.Error(t, Downsampler(ReducerPercentile).Validate())
+	require.Error(t, Downsampler("avg").Validate())
+}
@@ -0,0 +1,176 @@
+package mathexp
+
+import (
+	"fmt"
+	"time"
+)
+
+// Window functions operate on each point of a series using the points that fall
+// into the window (t-window, t] that ends at that point. The input series are
+// copied and sorted by time, the input values are not modified.
+
+// rate returns the per-second average rate of increase of each series over the window.
+// Decreases of the value are treated as counter resets.
+// A point is null if the window contains less than two non-null values.
+func rate(e *State, varSet Results, window time.Duration) (Results, error) {
+	return perWindow(e, "rate", varSet, window, func(points []windowPoint) *float64 {
+		first, last := firstLastIdx(points)
+		if first == last {
+			return nil
+		}
+		var increase float64
+		prev := *points[first].f
+		for _, p := range points[first+1 : last+1] {
+			if p.f == nil {
+				continue
+			}
+			if *p.f < prev {
+				// counter reset: the counter restarted from zero
+				increase += *p.f
+			} else {
+				increase += *p.f - prev
+			}
+			prev = *p.f
+		}
+		elapsed := points[last].t.Sub(points[first].t).Seconds()
+		if elapsed <= 0 {
+			return nil
+		}
+		r := increase / elapsed
+		return &r
+	})
+}
+
+// delta returns the difference between the last and the first value of each series within the window.
+// A point is null if the window contains less than two non-null values.
+func delta(e *State, varSet Results, window time.Duration) (Results, error) {
+	return perWindow(e, "delta", varSet, window, func(points []windowPoint) *float64 {
+		first, last := firstLastIdx(points)
+		if first == last {
+			return nil
+		}
+		d := *points[last].f - *points[first].f
+		return &d
+	})
+}
+
+// movingAvg returns the average of the non-null values of each series within the window.
+// A point is null if the window contains only null values.
+func movingAvg(e *State, varSet Results, window time.Duration) (Results, error) {
+	return perWindow(e, "moving_avg", varSet, window, func(points []windowPoint) *float64 {
+		var sum float64
+		var count int
+		for _, p := range points {
+			if p.f == nil {
+				continue
+			}
+			sum += *p.f
+			count++
+		}
+		if count == 0 {
+			return nil
+		}
+		avg := sum / float64(count)
+		return &avg
+	})
+}
+
+// cumSum returns the running total of the non-null values of each series.
+// Null points stay null and are not added to the total.
+func cumSum(e *State, varSet Results) (Results, error) {
+	return perSeries(e, "cumsum", varSet, func(s Series) Series {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		var sum float64
+		for i := range s.Len() {
+			t, f := s.GetPoint(i)
+			if f == nil {
+				newSeries.SetPoint(i, t, nil)
+				continue
+			}
+			sum += *f
+			newSeries.SetPoint(i, t, new(sum))
+		}
+		return newSeries
+	})
+}
+
+// shift moves each point of the series forward in time by the given duration,
+// so the value at t is the value the input had at t-duration.
+func shift(e *State, varSet Results, d time.Duration) (Results, error) {
+	return perSeries(e, "shift", varSet, func(s Series) Series {
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		for i := range s.Len() {
+			t, f := s.GetPoint(i)
+			newSeries.SetPoint(i, t.Add(d), f)
+		}
+		return newSeries
+	})
+}
+
+// perWindow calls windowF for each point of each series in varSet with the points that are
+// within the window ending at that point, and the result is the value of that point.
+func perWindow(e *State, name string, varSet Results, window time.Duration, windowF func(points []windowPoint) *float64) (Results, error) {
+	return perSeries(e, name, varSet, func(s Series) Series {
+		points := make([]windowPoint, s.Len())
+		for i := range s.Len() {
+			t, f := s.GetPoint(i)
+			points[i] = windowPoint{t: t, f: f}
+		}
+
+		newSeries := NewSeries(e.RefID, s.GetLabels(), s.Len())
+		start := 0
+		for i, p := range points {
+			from := p.t.Add(-window)
+			for !points[start].t.After(from) {
+				start++
+			}
+			newSeries.SetPoint(i, p.t, windowF(points[start:i+1]))
+		}
+		return newSeries
+	})
+}
+
+// perSeries passes a time sorted copy of each series in varSet to seriesF.
+// NoData values are passed through, any other non series value is an error.
+func perSeries(e *State, name string, varSet Results, seriesF func(s Series) Series) (Results, error) {
+	newRes := Results{}
+	for _, val := range varSet.Values {
+		switch v := val.(type) {
+		case Series:
+			sorted := NewSeries(e.RefID, v.GetLabels(), v.Len())
+			for i := range v.Len() {
+				t, f := v.GetPoint(i)
+				sorted.SetPoint(i, t, f)
+			}
+			sorted.SortByTime(false)
+			newRes.Values = append(newRes.Values, seriesF(sorted))
+		case NoData:
+			newRes.Values = append(newRes.Values, NewNoData())
+		default:
+			return newRes, fmt.Errorf("%s() expects time series input but got %s", name, val.Type())
+		}
+	}
+	return newRes, nil
+}
+
+// firstLastIdx returns the indices of the first and the last non-null points.
+// Both are equal if there are less than two non-null points.
+func firstLastIdx(points []windowPoint) (int, int) {
+	first, last := -1, -1
+	for i, p := range points {
+		if p.f == nil {
+			continue
+		}
+		if first == -1 {
+			first = i
+		}
+		last = i
+	}
+	return first, last
+}
+
+// windowPoint is a single point of a series.
+type windowPoint struct {
+	t time.Time
+	f *float64
+}
@@ -0,0 +1,190 @@
+package mathexp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+)
+
+func TestWindowFuncs(t *testing.T) {
+	counter := Vars{
+		"A": resultValuesNoErr(
+			makeSeries("", nil,
+				tp{time.Unix(0, 0), new(0.0)},
+				tp{time.Unix(60, 0), new(60.0)},
+				tp{time.Unix(120, 0), new(180.0)},
+				tp{time.Unix(180, 0), new(30.0)},
+			),
+		),
+	}
+
+	var tests = []struct {
+		name      string
+		expr      string
+		vars      Vars
+		newErrIs  require.ErrorAssertionFunc
+		execErrIs require.ErrorAssertionFunc
+		results   Results
+	}{
+		{
+			name:      "rate handles counter resets",
+			expr:      "rate($A, 2m)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(60, 0), new(1.0)},
+					tp{time.Unix(120, 0), new(2.0)},
+					tp{time.Unix(180, 0), new(0.5)},
+				),
+			),
+		},
+		{
+			name:      "delta",
+			expr:      "delta($A, 2m)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), nil},
+					tp{time.Unix(60, 0), new(60.0)},
+					tp{time.Unix(120, 0), new(120.0)},
+					tp{time.Unix(180, 0), new(-150.0)},
+				),
+			),
+		},
+		{
+			name: "moving_avg skips null values",
+			expr: "moving_avg($A, 1m)",
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", nil,
+						tp{time.Unix(0, 0), new(2.0)},
+						tp{time.Unix(30, 0), new(4.0)},
+						tp{time.Unix(60, 0), nil},
+						tp{time.Unix(90, 0), nil},
+						tp{time.Unix(120, 0), nil},
+					),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(0, 0), new(2.0)},
+					tp{time.Unix(30, 0), new(3.0)},
+					tp{time.Unix(60, 0), new(4.0)},
+					tp{time.Unix(90, 0), nil},
+					tp{time.Unix(120, 0), nil},
+				),
+			),
+		},
+		{
+			name: "cumsum sorts by time",
+			expr: "cumsum($A)",
+			vars: Vars{
+				"A": resultValuesNoErr(
+					makeSeries("", nil,
+						tp{time.Unix(10, 0), new(2.0)},
+						tp{time.Unix(5, 0), new(1.0)},
+						tp{time.Unix(15, 0), nil},
+						tp{time.Unix(20, 0), new(3.0)},
+					),
+				),
+			},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(5, 0), new(1.0)},
+					tp{time.Unix(10, 0), new(3.0)},
+					tp{time.Unix(15, 0), nil},
+					tp{time.Unix(20, 0), new(6.0)},
+				),
+			),
+		},
+		{
+			name:      "shift moves points forward in time",
+			expr:      "shift($A, 1h)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(3600, 0), new(0.0)},
+					tp{time.Unix(3660, 0), new(60.0)},
+					tp{time.Unix(3720, 0), new(180.0)},
+					tp{time.Unix(3780, 0), new(30.0)},
+				),
+			),
+		},
+		{
+			name:      "window functions can be combined with binary operations",
+			expr:      "$A - shift($A, 1m)",
+			vars:      counter,
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results: resultValuesNoErr(
+				makeSeries("", nil,
+					tp{time.Unix(60, 0), new(60.0)},
+					tp{time.Unix(120, 0), new(120.0)},
+					tp{time.Unix(180, 0), new(-150.0)},
+				),
+			),
+		},
+		{
+			name:      "no data is passed through",
+			expr:      "rate($A, 5m)",
+			vars:      Vars{"A": resultValuesNoErr(NewNoData())},
+			newErrIs:  require.NoError,
+			execErrIs: require.NoError,
+			results:   resultValuesNoErr(NewNoData()),
+		},
+		{
+			name:      "number input is an error",
+			expr:      "rate($A, 5m)",
+			vars:      Vars{"A": resultValuesNoErr(makeNumber("", nil, new(1.0)))},
+			newErrIs:  require.NoError,
+			execErrIs: require.Error,
+		},
+		{
+			name:     "missing window is a parse error",
+			expr:     "rate($A)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "number as window is a parse error",
+			expr:     "rate($A, 5)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "invalid duration unit is a parse error",
+			expr:     "rate($A, 5x)",
+			newErrIs: require.Error,
+		},
+		{
+			name:     "duration outside of a function is a parse error",
+			expr:     "$A + 5m",
+			newErrIs: require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.expr)
+			tt.newErrIs(t, err)
+			if e != nil {
+				res, err := e.Execute("", tt.vars, tracing.InitializeTracerForTest())
+				tt.execErrIs(t, err)
+				if err == nil {
+					require.Equal(t, tt.results, res)
+				}
+			}
+		})
+	}
+}
@@ -21,6 +21,15 @@ const (
 	// Resample query results
 	QueryTypeResample QueryType = "resample"
 
+	// Aggregate query results grouped by labels
+	QueryTypeAggregate QueryType = "aggregate"
+
+	// Detect anomalies in query results
+	QueryTypeAnomaly QueryType = "anomaly"
+
+	// Project query results into the future
+	QueryTypeForecast QueryType = "forecast"
+
 	// Classic query
 	QueryTypeClassic QueryType = "classic_conditions"
 
@@ -43,6 +52,9 @@ type ReduceQuery struct {
 	// The reducer
 	Reducer mathexp.ReducerID `json:"reducer"`
 
+	// The percentile to calculate, between 0 and 100. Only valid when reducer is percentile
+	Percentile *float64 `json:"percentile,omitempty"`
+
 	// Reducer Options
 	Settings *ReduceSettings `json:"settings,omitempty"`
 }
@@ -55,11 +67,89 @@ type ResampleQuery struct {
 	// The time duration
 	Window string `json:"window" jsonschema:"minLength=1,example=1d,example=10m"`
 
-	// The downsample function
-	Downsampler mathexp.ReducerID `json:"downsampler"`
+	// The downsample function, any of the reducers or time_weighted_mean
+	Downsampler mathexp.Downsampler `json:"downsampler"`
+
+	// The percentile to calculate, between 0 and 100. Only valid when downsampler is percentile
+	Percentile *float64 `json:"percentile,omitempty"`
 
 	// The upsample function
 	Upsampler mathexp.Upsampler `json:"upsampler"`
+
+	// Align the points to multiples of the window since the Unix epoch in the time zone instead of the start of the time range
+	Align bool `json:"align,omitempty"`
+
+	// The time zone used to align the points, defaults to UTC
+	Timezone string `json:"timezone,omitempty" jsonschema:"example=Europe/Berlin"`
+}
+
+// QueryType = aggregate
+type AggregateQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The aggregation function
+	Aggregator mathexp.Aggregator `json:"aggregator"`
+
+	// The label names to group by. When empty, all values are aggregated into one
+	By []string `json:"by,omitempty"`
+
+	// The number of values to keep per group. Only valid when aggregator is topk or bottomk
+	K *int `json:"k,omitempty"`
+}
+
+// QueryType = anomaly
+type AnomalyQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The detection algorithm
+	Algorithm mathexp.AnomalyAlgorithm `json:"algorithm"`
+
+	// The preceding window for zscore and mad, or the tolerance around the same time in previous periods for seasonal
+	Window string `json:"window" jsonschema:"minLength=1,example=1h,example=10m"`
+
+	// The length of a season. Only valid when algorithm is seasonal
+	Period string `json:"period,omitempty" jsonschema:"example=1d,example=1w"`
+
+	// The number of previous seasons to compare with, defaults to 1. Only valid when algorithm is seasonal
+	Seasons *int `json:"seasons,omitempty"`
+
+	// The number of deviations after which a value is anomalous, defaults to 3
+	Sensitivity *float64 `json:"sensitivity,omitempty"`
+
+	// The output, defaults to anomaly
+	Output mathexp.AnomalyOutput `json:"output,omitempty"`
+}
+
+// QueryType = forecast
+type ForecastQuery struct {
+	// Reference to single query result
+	Expression string `json:"expression" jsonschema:"minLength=1,example=$A"`
+
+	// The forecasting method
+	Method mathexp.ForecastMethod `json:"method"`
+
+	// How far to project into the future. For time_to_threshold the longest time that is searched
+	Horizon string `json:"horizon" jsonschema:"minLength=1,example=4h,example=7d"`
+
+	// The output, defaults to value
+	Output mathexp.ForecastOutput `json:"output,omitempty"`
+
+	// The value to reach. Only valid when output is time_to_threshold
+	Threshold *float64 `json:"threshold,omitempty"`
+
+	// The length of a season. Only valid when method is holt_winters
+	Period string `json:"period,omitempty" jsonschema:"example=1d"`
+
+	// The smoothing factor of the level between 0 and 1, defaults to 0.5. Only valid when method is holt or holt_winters
+	Smoothing *float64 `json:"smoothing,omitempty"`
+
+	// The smoothing factor of the trend between 0 and 1, defaults to 0.5. Only valid when method is holt or holt_winters
+	Trend *float64 `json:"trend,omitempty"`
+
+	// The smoothing factor of the season between 0 and 1, defaults to 0.5. Only valid when method is holt_winters
+	Seasonal *float64 `json:"seasonal,omitempty"`
 }
 
 type ThresholdQuery struct {
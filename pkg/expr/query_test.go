@@ -24,6 +24,11 @@ func TestQueryTypeDefinitions(t *testing.T) {
 			Enums: []reflect.Type{
 				reflect.TypeFor[mathexp.ReducerID](),
 				reflect.TypeFor[mathexp.Upsampler](),
+				reflect.TypeFor[mathexp.Aggregator](),
+				reflect.TypeFor[mathexp.AnomalyAlgorithm](),
+				reflect.TypeFor[mathexp.AnomalyOutput](),
+				reflect.TypeFor[mathexp.ForecastMethod](),
+				reflect.TypeFor[mathexp.ForecastOutput](),
 				reflect.TypeFor[ReduceMode](),
 				reflect.TypeFor[ThresholdType](),
 				reflect.TypeFor[classic.ConditionOperatorType](),
@@ -71,11 +76,54 @@ func TestQueryTypeDefinitions(t *testing.T) {
 				SaveModel: data.AsUnstructured(ResampleQuery{
 					Expression:  "$A",
 					Window:      "1d",
-					Downsampler: mathexp.ReducerLast,
+					Downsampler: mathexp.Downsampler(mathexp.ReducerLast),
 					Upsampler:   mathexp.UpsamplerPad,
 				}),
 			},
 		},
+	}, {
+		Discriminators: data.NewDiscriminators("type", QueryTypeAggregate),
+		GoType:         reflect.TypeFor[*AggregateQuery](),
+		Examples: []data.QueryExample{
+			{
+				Name: "sum by host",
+				SaveModel: data.AsUnstructured(AggregateQuery{
+					Expression: "$A",
+					Aggregator: mathexp.AggregatorSum,
+					By:         []string{"host"},
+				}),
+			},
+		},
+	}, {
+		Discriminators: data.NewDiscriminators("type", QueryTypeAnomaly),
+		GoType:         reflect.TypeFor[*AnomalyQuery](),
+		Examples: []data.QueryExample{
+			{
+				Name: "compare with the same hour of the last 7 days",
+				SaveModel: data.AsUnstructured(AnomalyQuery{
+					Expression: "$A",
+					Algorithm:  mathexp.AnomalySeasonal,
+					Window:     "1h",
+					Period:     "1d",
+					Seasons:    new(7),
+				}),
+			},
+		},
+	}, {
+		Discriminators: data.NewDiscriminators("type", QueryTypeForecast),
+		GoType:         reflect.TypeFor[*ForecastQuery](),
+		Examples: []data.QueryExample{
+			{
+				Name: "seconds until the disk is full",
+				SaveModel: data.AsUnstructured(ForecastQuery{
+					Expression: "$A",
+					Method:     mathexp.ForecastLinear,
+					Horizon:    "1d",
+					Output:     mathexp.ForecastOutputTimeToThreshold,
+					Threshold:  new(100.0),
+				}),
+			},
+		},
 	}, {
 		Discriminators: data.NewDiscriminators("type", QueryTypeSQL),
 		GoType:         reflect.TypeFor[*SQLExpression](),
@@ -95,6 +95,18 @@ func ProvideService(cfg *setting.Cfg, pluginClient plugins.Client, pCtxProvider
 	}
 }
 
+// QueryDataMiddleware wraps the handler that executes the queries of data source nodes.
+type QueryDataMiddleware func(next backend.QueryDataHandler) backend.QueryDataHandler
+
+// WithQueryDataMiddleware returns a copy of the service that executes the queries of data source nodes through the middleware.
+// The queries are always sent to the plugin client, the query service client is not used.
+func (s *Service) WithQueryDataMiddleware(m QueryDataMiddleware) *Service {
+	c := *s
+	c.dataService = m(s.dataService)
+	c.qsDatasourceClientBuilder = dsquerierclient.NewNullQSDatasourceClientBuilder()
+	return &c
+}
+
 func (s *Service) isDisabled() bool {
 	if s.cfg == nil {
 		return true
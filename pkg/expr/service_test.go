@@ -153,6 +153,55 @@ func TestDSQueryError(t *testing.T) {
 	require.Equal(t, new(42.0), res.Responses["C"].Frames[0].Fields[0].At(0))
 }
 
+func TestWithQueryDataMiddleware(t *testing.T) {
+	queries := []Query{
+		{
+			RefID: "A",
+			DataSource: &datasources.DataSource{
+				OrgID: 1,
+				UID:   "test",
+				Type:  "test",
+			},
+			JSON: json.RawMessage(`{ "datasource": { "uid": "1" }, "intervalMs": 1000, "maxDataPoints": 1000 }`),
+		},
+		{
+			RefID:      "B",
+			DataSource: dataSourceModel(),
+			JSON:       json.RawMessage(`{ "datasource": { "uid": "__expr__", "type": "__expr__"}, "type": "math", "expression": "$A * 2" }`),
+		},
+	}
+
+	s, req := newMockQueryService(map[string]backend.DataResponse{
+		"A": {Error: fmt.Errorf("the data source must not be queried")},
+	}, queries)
+
+	var queried []string
+	s = s.WithQueryDataMiddleware(func(next backend.QueryDataHandler) backend.QueryDataHandler {
+		return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			resp := backend.NewQueryDataResponse()
+			for _, q := range req.Queries {
+				queried = append(queried, q.RefID)
+				resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{
+					data.NewFrame("",
+						data.NewField("time", nil, []time.Time{time.Unix(1, 0)}),
+						data.NewField("value", nil, []*float64{new(2.0)}),
+					),
+				}}
+			}
+			return resp, nil
+		})
+	})
+
+	pl, err := s.BuildPipeline(t.Context(), req)
+	require.NoError(t, err)
+
+	res, err := s.ExecutePipeline(context.Background(), time.Now(), pl)
+	require.NoError(t, err)
+	require.Equal(t, []string{"A"}, queried)
+	require.NoError(t, res.Responses["B"].Error)
+	require.Equal(t, new(4.0), res.Responses["B"].Frames[0].Fields[1].At(0))
+}
+
 func TestParseError(t *testing.T) {
 	resp := map[string]backend.DataResponse{}
 
@@ -11,6 +11,7 @@ import (
 var (
 	ErrTimerangeMissing     = errors.New("missing timerange")
 	ErrBaseTagLimitExceeded = errutil.BadRequest("annotations.tag-limit-exceeded", errutil.WithPublicMessage("Tags length exceeds the maximum allowed."))
+	ErrImportKeyExists      = errors.New("an annotation with the import key already exists")
 )
 
 //go:generate mockery --name Repository --structname FakeAnnotationsRepo --inpackage --filename annotations_repository_mock.go
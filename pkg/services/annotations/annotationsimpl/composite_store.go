@@ -49,7 +49,12 @@ func (c *CompositeStore) Get(ctx context.Context, query annotations.ItemQuery, a
 	close(itemCh)
 	res := make([]*annotations.ItemDTO, 0)
 	for items := range itemCh {
-		res = append(res, items...)
+		// Filter the items again so that the results of all stores follow the same text search and time range rules.
+		for _, item := range items {
+			if query.MatchesText(item) && query.OverlapsTimeRange(item) {
+				res = append(res, item)
+			}
+		}
 	}
 	sort.Sort(annotations.SortedItems(res))
 
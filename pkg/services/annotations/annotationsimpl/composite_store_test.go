@@ -98,6 +98,26 @@ func TestCompositeStore(t *testing.T) {
 		require.Equal(t, expected, items)
 	})
 
+	t.Run("should filter results from Get by text and time range", func(t *testing.T) {
+		r1 := newFakeReader(withItems([]*annotations.ItemDTO{
+			{Time: 5, TimeEnd: 15, Text: "Deploy of service X"},
+			{Time: 12, TimeEnd: 12, Text: "deploy of service Y"},
+		}))
+		r2 := newFakeReader(withItems([]*annotations.ItemDTO{
+			{Time: 1, TimeEnd: 2, Text: "deploy of service X"},
+			{Time: 11, Text: "Firing: service X"},
+		}))
+
+		store := &CompositeStore{
+			log.NewNopLogger(),
+			[]ReadStore{r1, r2},
+		}
+
+		items, err := store.Get(context.Background(), annotations.ItemQuery{From: 10, To: 20, Text: `"deploy of" x`}, nil)
+		require.NoError(t, err)
+		require.Equal(t, []*annotations.ItemDTO{{Time: 5, TimeEnd: 15, Text: "Deploy of service X"}}, items)
+	})
+
 	t.Run("should combine and sort results from GetTags", func(t *testing.T) {
 		tags1 := []*annotations.TagsDTO{
 			{Tag: "key1:val1"},
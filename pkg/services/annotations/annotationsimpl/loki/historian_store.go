@@ -137,7 +137,12 @@ func (r *LokiHistorianStore) Get(ctx context.Context, query annotations.ItemQuer
 			return make([]*annotations.ItemDTO, 0), ErrLokiStoreInternal.Errorf("failed to query loki: %w", err)
 		}
 		for _, stream := range res.Data.Result {
-			items = append(items, r.annotationsFromStream(stream, *accessResources)...)
+			for _, item := range r.annotationsFromStream(stream, *accessResources) {
+				// The text of the annotations is built from the entries, so it cannot be searched by Loki.
+				if query.MatchesText(item) {
+					items = append(items, item)
+				}
+			}
 		}
 	}
 	sort.Sort(annotations.SortedItems(items))
@@ -321,6 +321,35 @@ func TestIntegrationAlertStateHistoryStore(t *testing.T) {
 			}
 		})
 
+		t.Run("can search history by text", func(t *testing.T) {
+			fakeLokiClient.rangeQueryRes = []lokiclient.Stream{
+				historian.StatesToStream(ruleMetaFromRule(t, dashboardRules[dashboard1.UID][0]), transitions, map[string]string{}, log.NewNopLogger()),
+				historian.StatesToStream(ruleMetaFromRule(t, dashboardRules[dashboard1.UID][1]), transitions, map[string]string{}, log.NewNopLogger()),
+			}
+
+			query := annotations.ItemQuery{
+				OrgID:        1,
+				DashboardUID: dashboard1.UID,
+				From:         start.UnixMilli(),
+				To:           start.Add(time.Second * time.Duration(numTransitions+1)).UnixMilli(),
+				Text:         `"test rule 2"`,
+			}
+			res, err := store.Get(
+				context.Background(),
+				query,
+				&annotation_ac.AccessResources{
+					Dashboards: map[string]int64{
+						dashboard1.UID: dashboard1.ID,
+					},
+				},
+			)
+			require.NoError(t, err)
+			require.Len(t, res, numTransitions)
+			for _, item := range res {
+				require.Contains(t, item.Text, "Test Rule 2")
+			}
+		})
+
 		t.Run("should return nothing if query is for tags only", func(t *testing.T) {
 			fakeLokiClient.rangeQueryRes = []lokiclient.Stream{
 				historian.StatesToStream(ruleMetaFromRule(t, dashboardRules[dashboard1.UID][0]), transitions, map[string]string{}, log.NewNopLogger()),
@@ -108,6 +108,9 @@ func (r *xormRepositoryImpl) Add(ctx context.Context, item *annotations.Item) er
 
 	return r.db.WithDbSession(ctx, func(sess *db.Session) error {
 		if _, err := sess.Table("annotation").Insert(item); err != nil {
+			if item.ImportKey != nil && r.db.GetDialect().IsUniqueConstraintViolation(err) {
+				return annotations.ErrImportKeyExists
+			}
 			return err
 		}
 		return r.ensureTags(ctx, item.ID, item.Tags)
@@ -339,6 +342,7 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query annotations.ItemQuer
 				annotation.data,
 				annotation.created,
 				annotation.updated,
+				annotation.import_key,
 				usr.email,
 				usr.login,
 				usr.uid as user_uid,
@@ -405,6 +409,12 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query annotations.ItemQuer
 			sql.WriteString(` AND a.alert_id = 0`)
 		}
 
+		for _, term := range annotations.TextSearchTerms(query.Text) {
+			like, param := r.db.GetDialect().LikeOperator("a.text", true, escapeLikePattern(term), true)
+			sql.WriteString(` AND ` + like + ` ESCAPE '` + likeEscapeChar + `'`)
+			params = append(params, param)
+		}
+
 		if len(query.Tags) > 0 {
 			keyValueFilters := []string{}
 
@@ -443,8 +453,9 @@ func (r *xormRepositoryImpl) Get(ctx context.Context, query annotations.ItemQuer
 		}
 		params = append(params, acParams...)
 
-		// order of ORDER BY arguments match the order of a sql index for performance
-		orderBy := " ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC"
+		// order of ORDER BY arguments match the order of a sql index for performance, the ID makes the order stable
+		// so that the annotations with the same time are paginated by offset without being skipped or repeated
+		orderBy := " ORDER BY a.org_id, a.epoch_end DESC, a.epoch DESC, a.id DESC"
 		if query.Limit > 0 {
 			orderBy += r.db.GetDialect().LimitOffset(query.Limit, query.Offset)
 		}
@@ -810,3 +821,14 @@ type annotationTag struct {
 	AnnotationID int64 `xorm:"annotation_id"`
 	TagID        int64 `xorm:"tag_id"`
 }
+
+// likeEscapeChar escapes the wildcards in the patterns of LIKE. It's not a backslash, which needs to be written
+// differently in the string literals of MySQL and Postgres, and which is a literal with any other escape character.
+const likeEscapeChar = "!"
+
+var likePatternEscaper = strings.NewReplacer(likeEscapeChar, likeEscapeChar+likeEscapeChar, "%", likeEscapeChar+"%", "_", likeEscapeChar+"_")
+
+// escapeLikePattern escapes the wildcards in s, so that it matches itself in a LIKE with likeEscapeChar.
+func escapeLikePattern(s string) string {
+	return likePatternEscaper.Replace(s)
+}
@@ -247,6 +247,32 @@ func TestIntegrationAnnotations(t *testing.T) {
 			assert.Len(t, inserted, count)
 		})
 
+		t.Run("Rejects annotations with an existing import key", func(t *testing.T) {
+			key := "import-key"
+			imported := &annotations.Item{OrgID: 102, Epoch: 12, Text: "imported", ImportKey: &key}
+			require.NoError(t, store.Add(context.Background(), imported))
+
+			err := store.Add(context.Background(), &annotations.Item{OrgID: 102, Epoch: 12, Text: "duplicate", ImportKey: &key})
+			require.ErrorIs(t, err, annotations.ErrImportKeyExists)
+			// The key is unique per organization, and annotations without a key are not affected.
+			require.NoError(t, store.Add(context.Background(), &annotations.Item{OrgID: 103, Epoch: 12, Text: "other org", ImportKey: &key}))
+			require.NoError(t, store.Add(context.Background(), &annotations.Item{OrgID: 102, Epoch: 12, Text: "no key"}))
+			require.NoError(t, store.Add(context.Background(), &annotations.Item{OrgID: 102, Epoch: 12, Text: "no key"}))
+
+			query := annotations.ItemQuery{OrgID: 102, SignedInUser: testUser}
+			accRes := &annotation_ac.AccessResources{CanAccessOrgAnnotations: true}
+			items, err := store.Get(context.Background(), query, accRes)
+			require.NoError(t, err)
+			require.Len(t, items, 3)
+			for _, item := range items {
+				if item.ID == imported.ID {
+					require.Equal(t, &key, item.ImportKey)
+				} else {
+					require.Nil(t, item.ImportKey)
+				}
+			}
+		})
+
 		t.Run("Can query for annotation by id", func(t *testing.T) {
 			items, err := store.Get(context.Background(), annotations.ItemQuery{
 				OrgID:        1,
@@ -343,6 +369,79 @@ func TestIntegrationAnnotations(t *testing.T) {
 			assert.Len(t, items, 2)
 		})
 
+		t.Run("Should find annotations by text", func(t *testing.T) {
+			accRes := &annotation_ac.AccessResources{CanAccessOrgAnnotations: true}
+			query := annotations.ItemQuery{
+				OrgID:        1,
+				From:         1,
+				To:           25,
+				Text:         "DEPLO",
+				SignedInUser: testUser,
+			}
+			items, err := store.Get(context.Background(), query, accRes)
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, organizationAnnotation1.ID, items[0].ID)
+
+			query.Text = "deploy rollback"
+			items, err = store.Get(context.Background(), query, accRes)
+			require.NoError(t, err)
+			assert.Empty(t, items)
+		})
+
+		t.Run("Should find annotations by text with the wildcards of LIKE", func(t *testing.T) {
+			texts := []string{"cpu at 100%", "cpu at 1000", "disk_full", "diskXfull", `C:\temp!`, "C:temp"}
+			ids := make(map[string]int64, len(texts))
+			for _, text := range texts {
+				item := &annotations.Item{OrgID: 104, Epoch: 12, Text: text}
+				require.NoError(t, store.Add(context.Background(), item))
+				ids[text] = item.ID
+			}
+
+			accRes := &annotation_ac.AccessResources{CanAccessOrgAnnotations: true}
+			for _, text := range []string{"100%", "disk_full", `C:\temp!`} {
+				items, err := store.Get(context.Background(), annotations.ItemQuery{OrgID: 104, Text: text, SignedInUser: testUser}, accRes)
+				require.NoError(t, err)
+				require.Len(t, items, 1, text)
+				assert.Equal(t, ids[text], items[0].ID)
+			}
+		})
+
+		t.Run("Should paginate annotations with the same time by offset", func(t *testing.T) {
+			ids := make([]int64, 0, 3)
+			for range 3 {
+				item := &annotations.Item{OrgID: 105, Epoch: 12, EpochEnd: 12, Text: "same time"}
+				require.NoError(t, store.Add(context.Background(), item))
+				ids = append([]int64{item.ID}, ids...)
+			}
+
+			accRes := &annotation_ac.AccessResources{CanAccessOrgAnnotations: true}
+			paged := make([]int64, 0, len(ids))
+			for offset := range int64(len(ids)) {
+				items, err := store.Get(context.Background(), annotations.ItemQuery{OrgID: 105, Limit: 1, Offset: offset, SignedInUser: testUser}, accRes)
+				require.NoError(t, err)
+				require.Len(t, items, 1)
+				paged = append(paged, items[0].ID)
+			}
+			assert.Equal(t, ids, paged)
+		})
+
+		t.Run("Should find region annotations that overlap the time range", func(t *testing.T) {
+			accRes := &annotation_ac.AccessResources{
+				Dashboards: map[string]int64{dashboard2.UID: dashboard2.ID},
+			}
+			items, err := store.Get(context.Background(), annotations.ItemQuery{
+				OrgID:        1,
+				DashboardUID: dashboard2.UID,
+				From:         21,
+				To:           25,
+				SignedInUser: testUser,
+			}, accRes)
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, annotation2.ID, items[0].ID)
+		})
+
 		t.Run("Should find one when all key value tag filters does match", func(t *testing.T) {
 			accRes := &annotation_ac.AccessResources{
 				Dashboards: map[string]int64{dashboard.UID: 1},
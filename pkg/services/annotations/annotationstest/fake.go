@@ -39,6 +39,13 @@ func (repo *fakeAnnotationsRepo) Save(ctx context.Context, item *annotations.Ite
 	repo.mtx.Lock()
 	defer repo.mtx.Unlock()
 
+	if item.ImportKey != nil {
+		for _, a := range repo.annotations {
+			if a.OrgID == item.OrgID && a.ImportKey != nil && *a.ImportKey == *item.ImportKey {
+				return annotations.ErrImportKeyExists
+			}
+		}
+	}
 	if item.ID == 0 {
 		item.ID = int64(len(repo.annotations) + 1)
 	}
@@ -6,7 +6,9 @@ import (
 )
 
 type ItemQuery struct {
-	OrgID    int64  `json:"orgId"`
+	OrgID int64 `json:"orgId"`
+	// From and To select the annotations that overlap the time range when both are set, so regions that start
+	// before From or end after To are included.
 	From     int64  `json:"from"`
 	To       int64  `json:"to"`
 	UserID   int64  `json:"userId"`
@@ -21,6 +23,9 @@ type ItemQuery struct {
 	Tags         []string `json:"tags"`
 	Type         string   `json:"type"`
 	MatchAny     bool     `json:"matchAny"`
+	// Text selects the annotations whose text contains every term of the search, ignoring case.
+	// See TextSearchTerms for how the search is split into terms.
+	Text         string `json:"text"`
 	SignedInUser identity.Requester
 
 	Limit  int64 `json:"limit"`
@@ -102,6 +107,8 @@ type Item struct {
 	Updated      int64            `json:"updated"`
 	Tags         []string         `json:"tags"`
 	Data         *simplejson.Json `json:"data"`
+	// ImportKey is the idempotency key of an imported annotation. It is unique in the organization.
+	ImportKey *string `json:"-" xorm:"import_key"`
 
 	// needed until we remove it from db
 	Type  string
@@ -135,11 +142,12 @@ type ItemDTO struct {
 	Email        string           `json:"email,omitempty"`
 	AvatarURL    string           `json:"avatarUrl,omitempty" xorm:"avatar_url"`
 	Data         *simplejson.Json `json:"data,omitempty"`
+	ImportKey    *string          `json:"-" xorm:"import_key"`
 }
 
 type SortedItems []*ItemDTO
 
-// sort annotations in descending order by end time, then by start time
+// sort annotations in descending order by end time, then by start time, then by ID
 func (s SortedItems) Len() int {
 	return len(s)
 }
@@ -148,7 +156,10 @@ func (s SortedItems) Less(i, j int) bool {
 	if s[i].TimeEnd != s[j].TimeEnd {
 		return s[i].TimeEnd > s[j].TimeEnd
 	}
-	return s[i].Time > s[j].Time
+	if s[i].Time != s[j].Time {
+		return s[i].Time > s[j].Time
+	}
+	return s[i].ID > s[j].ID
 }
 
 func (s SortedItems) Swap(i, j int) {
@@ -0,0 +1,41 @@
+package annotations
+
+import (
+	"strings"
+)
+
+// TextSearchTerms splits a text search into the terms an annotation text must contain. Quoted phrases,
+// such as "deploy of service X", are a single term, and every other word is a term of its own.
+func TextSearchTerms(text string) []string {
+	var terms []string
+	for i, part := range strings.Split(text, `"`) {
+		if i%2 == 0 {
+			terms = append(terms, strings.Fields(part)...)
+			continue
+		}
+		if phrase := strings.TrimSpace(part); phrase != "" {
+			terms = append(terms, phrase)
+		}
+	}
+	return terms
+}
+
+// MatchesText returns true if the annotation matches the text search of the query.
+func (q *ItemQuery) MatchesText(item *ItemDTO) bool {
+	text := strings.ToLower(item.Text)
+	for _, term := range TextSearchTerms(q.Text) {
+		if !strings.Contains(text, strings.ToLower(term)) {
+			return false
+		}
+	}
+	return true
+}
+
+// OverlapsTimeRange returns true if any part of the annotation is in the time range of the query.
+func (q *ItemQuery) OverlapsTimeRange(item *ItemDTO) bool {
+	if q.From <= 0 || q.To <= 0 {
+		return true
+	}
+	end := max(item.TimeEnd, item.Time)
+	return item.Time <= q.To && end >= q.From
+}
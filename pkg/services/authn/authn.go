@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	claims "github.com/grafana/authlib/types"
@@ -37,6 +38,8 @@ const (
 	MetaKeyUsername            = "username"
 	MetaKeyAuthModule          = "authModule"
 	MetaKeyIsLogin             = "isLogin"
+	MetaKeyTOTPCode            = "totpCode"
+	MetaKeyTOTPRecoveryCodes   = "totpRecoveryCodes"
 	defaultRedirectToCookieKey = "redirect_to"
 )
 
@@ -262,9 +265,13 @@ func ClientWithPrefix(name string) string {
 type RedirectValidator func(url string) (string, error)
 
 // HandleLoginResponse is a utility function to perform common operations after a successful login and returns response.NormalResponse
-func HandleLoginResponse(r *http.Request, w http.ResponseWriter, cfg *setting.Cfg, identity *Identity, validator RedirectValidator, features featuremgmt.FeatureToggles) *response.NormalResponse {
+func HandleLoginResponse(r *Request, w http.ResponseWriter, cfg *setting.Cfg, identity *Identity, validator RedirectValidator, features featuremgmt.FeatureToggles) *response.NormalResponse {
 	result := map[string]any{"message": "Logged in"}
-	result["redirectUrl"] = handleLogin(r, w, cfg, identity, validator, features, "")
+	result["redirectUrl"] = handleLogin(r.HTTPRequest, w, cfg, identity, validator, features, "")
+	// the recovery codes of a user that set up two-factor authentication while logging in are only shown once
+	if codes := r.GetMeta(MetaKeyTOTPRecoveryCodes); codes != "" {
+		result["totpRecoveryCodes"] = strings.Split(codes, ",")
+	}
 	return response.JSON(http.StatusOK, result)
 }
 
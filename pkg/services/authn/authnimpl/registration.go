@@ -27,6 +27,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/rendering"
 	tempuser "github.com/grafana/grafana/pkg/services/temp_user"
+	"github.com/grafana/grafana/pkg/services/totp"
 	"github.com/grafana/grafana/pkg/services/user"
 )
 
@@ -44,6 +45,7 @@ func ProvideRegistration(
 	socialService social.Service, cache *remotecache.RemoteCache,
 	ldapService service.LDAP,
 	tracer tracing.Tracer, tempUserService tempuser.Service, notificationService notifications.Service,
+	totpService totp.Service,
 ) (Registration, error) {
 	logger := log.New("authn.registration")
 
@@ -74,7 +76,7 @@ func ProvideRegistration(
 
 	// if we have password clients configure check if basic auth or form auth is enabled
 	if len(passwordClients) > 0 {
-		passwordClient := clients.ProvidePassword(loginAttempts, tracer, passwordClients...)
+		passwordClient := clients.ProvidePassword(loginAttempts, totpService, tracer, passwordClients...)
 		if cfg.BasicAuthEnabled {
 			authnSvc.RegisterClient(clients.ProvideBasic(passwordClient))
 		}
@@ -23,6 +23,8 @@ type Form struct {
 type loginForm struct {
 	Username string `json:"user" binding:"Required"`
 	Password string `json:"password" binding:"Required"`
+	// TOTPCode is the second factor of users with two-factor authentication
+	TOTPCode string `json:"totpCode"`
 }
 
 func (c *Form) Name() string {
@@ -34,6 +36,9 @@ func (c *Form) Authenticate(ctx context.Context, r *authn.Request) (*authn.Ident
 	if err := web.Bind(r.HTTPRequest, &form); err != nil {
 		return nil, errBadForm.Errorf("failed to parse request: %w", err)
 	}
+	if form.TOTPCode != "" {
+		r.SetMeta(authn.MetaKeyTOTPCode, form.TOTPCode)
+	}
 	return c.client.AuthenticatePassword(ctx, r, form.Username, form.Password)
 }
 
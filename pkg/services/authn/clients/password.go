@@ -3,13 +3,16 @@ package clients
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/authn"
+	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/loginattempt"
+	"github.com/grafana/grafana/pkg/services/totp"
 	"github.com/grafana/grafana/pkg/web"
 )
 
@@ -21,12 +24,13 @@ var (
 
 var _ authn.PasswordClient = new(Password)
 
-func ProvidePassword(loginAttempts loginattempt.Service, tracer trace.Tracer, clients ...authn.PasswordClient) *Password {
-	return &Password{loginAttempts, clients, log.New("authn.password"), tracer}
+func ProvidePassword(loginAttempts loginattempt.Service, totpService totp.Service, tracer trace.Tracer, clients ...authn.PasswordClient) *Password {
+	return &Password{loginAttempts, totpService, clients, log.New("authn.password"), tracer}
 }
 
 type Password struct {
 	loginAttempts loginattempt.Service
+	totpService   totp.Service
 	clients       []authn.PasswordClient
 	log           log.Logger
 	tracer        trace.Tracer
@@ -71,6 +75,10 @@ func (c *Password) AuthenticatePassword(ctx context.Context, r *authn.Request, u
 			continue
 		}
 
+		if err := c.verifySecondFactor(ctx, r, username, identity); err != nil {
+			return nil, err
+		}
+
 		return identity, nil
 	}
 
@@ -81,3 +89,32 @@ func (c *Password) AuthenticatePassword(ctx context.Context, r *authn.Request, u
 
 	return nil, errPasswordAuthFailed.Errorf("failed to authenticate identity: %w", clientErrs)
 }
+
+// verifySecondFactor checks the second factor of users authenticated with their Grafana password, so that no session is
+// created without it. Invalid codes count as failed login attempts. The recovery codes of users setting up their
+// second factor while logging in are added to the request, for the login response.
+func (c *Password) verifySecondFactor(ctx context.Context, r *authn.Request, username string, identity *authn.Identity) error {
+	if identity.AuthenticatedBy != login.PasswordAuthModule {
+		return nil
+	}
+
+	userID, err := identity.GetInternalID()
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := c.totpService.VerifyLogin(ctx, userID, username, r.GetMeta(authn.MetaKeyTOTPCode))
+	if errors.Is(err, totp.ErrInvalidCode) {
+		if err := c.loginAttempts.Add(ctx, username, web.RemoteAddr(r.HTTPRequest)); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if recoveryCodes != nil {
+		r.SetMeta(authn.MetaKeyTOTPRecoveryCodes, strings.Join(recoveryCodes.Codes, ","))
+	}
+	return nil
+}
@@ -7,13 +7,17 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	claims "github.com/grafana/authlib/types"
 
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/services/authn"
 	"github.com/grafana/grafana/pkg/services/authn/authntest"
+	"github.com/grafana/grafana/pkg/services/login"
 	"github.com/grafana/grafana/pkg/services/loginattempt/loginattempttest"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/totp/totptest"
 )
 
 func TestPassword_AuthenticatePassword(t *testing.T) {
@@ -22,6 +26,7 @@ func TestPassword_AuthenticatePassword(t *testing.T) {
 		username         string
 		password         string
 		blockLogin       bool
+		totpErr          error
 		clients          []authn.PasswordClient
 		expectedErr      error
 		expectedIdentity *authn.Identity
@@ -62,11 +67,27 @@ func TestPassword_AuthenticatePassword(t *testing.T) {
 			clients:     []authn.PasswordClient{authntest.FakePasswordClient{ExpectedErr: errIdentityNotFound}, authntest.FakePasswordClient{ExpectedErr: errIdentityNotFound}},
 			expectedErr: errPasswordAuthFailed,
 		},
+		{
+			desc:        "should fail when the second factor is required",
+			username:    "test",
+			password:    "test",
+			totpErr:     totp.ErrRequired,
+			clients:     []authn.PasswordClient{authntest.FakePasswordClient{ExpectedIdentity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule}}},
+			expectedErr: totp.ErrRequired,
+		},
+		{
+			desc:             "should not check the second factor of users authenticated by other password clients",
+			username:         "test",
+			password:         "test",
+			totpErr:          totp.ErrRequired,
+			clients:          []authn.PasswordClient{authntest.FakePasswordClient{ExpectedIdentity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.LDAPAuthModule}}},
+			expectedIdentity: &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.LDAPAuthModule},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			c := ProvidePassword(loginattempttest.FakeLoginAttemptService{ExpectedValid: !tt.blockLogin}, tracing.InitializeTracerForTest(), tt.clients...)
+			c := ProvidePassword(loginattempttest.FakeLoginAttemptService{ExpectedValid: !tt.blockLogin}, &totptest.FakeService{ExpectedErr: tt.totpErr}, tracing.InitializeTracerForTest(), tt.clients...)
 			r := &authn.Request{
 				OrgID: 12345,
 				HTTPRequest: &http.Request{
@@ -93,3 +114,35 @@ func TestPassword_AuthenticatePassword(t *testing.T) {
 		})
 	}
 }
+
+func TestPassword_AuthenticatePassword_SecondFactor(t *testing.T) {
+	identity := &authn.Identity{ID: "1", Type: claims.TypeUser, AuthenticatedBy: login.PasswordAuthModule}
+
+	t.Run("should count invalid codes as failed login attempts", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: true}
+		c := ProvidePassword(loginAttempts, &totptest.FakeService{ExpectedErr: totp.ErrInvalidCode}, tracing.InitializeTracerForTest(), authntest.FakePasswordClient{ExpectedIdentity: identity})
+
+		_, err := c.AuthenticatePassword(context.Background(), &authn.Request{HTTPRequest: &http.Request{}}, "test", "test")
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+		assert.True(t, loginAttempts.AddCalled)
+	})
+
+	t.Run("should add the recovery codes of users enrolled on login to the request", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: true}
+		c := ProvidePassword(loginAttempts, &totptest.FakeService{ExpectedRecoveryCodes: &totp.RecoveryCodes{Codes: []string{"abcde-fghij", "klmno-pqrst"}}}, tracing.InitializeTracerForTest(), authntest.FakePasswordClient{ExpectedIdentity: identity})
+
+		r := &authn.Request{HTTPRequest: &http.Request{}}
+		_, err := c.AuthenticatePassword(context.Background(), r, "test", "test")
+		require.NoError(t, err)
+		assert.Equal(t, "abcde-fghij,klmno-pqrst", r.GetMeta(authn.MetaKeyTOTPRecoveryCodes))
+	})
+
+	t.Run("should not count missing codes as failed login attempts", func(t *testing.T) {
+		loginAttempts := &loginattempttest.MockLoginAttemptService{ExpectedValid: true}
+		c := ProvidePassword(loginAttempts, &totptest.FakeService{ExpectedErr: totp.ErrRequired}, tracing.InitializeTracerForTest(), authntest.FakePasswordClient{ExpectedIdentity: identity})
+
+		_, err := c.AuthenticatePassword(context.Background(), &authn.Request{HTTPRequest: &http.Request{}}, "test", "test")
+		require.ErrorIs(t, err, totp.ErrRequired)
+		assert.False(t, loginAttempts.AddCalled)
+	})
+}
@@ -0,0 +1,144 @@
+package caching
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/services/contexthandler"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
+)
+
+// userIdentityHeaders are forwarded to the data source when it authenticates as the signed in user.
+// The responses of such requests depend on the user, so they are never cached.
+var userIdentityHeaders = []string{"Authorization", "X-Id-Token", "Cookie"}
+
+// forwardedUserHeaders tell the data source who the signed in user is, when ID forwarding or send_user_header
+// is enabled. The responses of such requests may depend on the user, so they are cached per user.
+var forwardedUserHeaders = []string{"X-Grafana-Id", proxyutil.UserHeaderName}
+
+// queryCacheKey contains everything the response of a query request depends on.
+type queryCacheKey struct {
+	OrgID             int64                `json:"orgId"`
+	User              string               `json:"user,omitempty"`
+	DatasourceUpdated time.Time            `json:"datasourceUpdated"`
+	Queries           []queryCacheKeyQuery `json:"queries"`
+}
+
+type queryCacheKeyQuery struct {
+	RefID         string          `json:"refId"`
+	QueryType     string          `json:"queryType"`
+	MaxDataPoints int64           `json:"maxDataPoints"`
+	Interval      time.Duration   `json:"interval"`
+	From          time.Time       `json:"from"`
+	To            time.Time       `json:"to"`
+	JSON          json.RawMessage `json:"json"`
+}
+
+func newQueryCacheKey(req *backend.QueryDataRequest, now time.Time, ttl time.Duration) queryCacheKey {
+	user, _ := queryCacheUser(req)
+	key := queryCacheKey{
+		OrgID:             req.PluginContext.OrgID,
+		User:              user,
+		DatasourceUpdated: req.PluginContext.DataSourceInstanceSettings.Updated,
+		Queries:           make([]queryCacheKeyQuery, 0, len(req.Queries)),
+	}
+	for _, q := range req.Queries {
+		timeRange := normalizeTimeRange(q.TimeRange, now, ttl)
+		key.Queries = append(key.Queries, queryCacheKeyQuery{
+			RefID:         q.RefID,
+			QueryType:     q.QueryType,
+			MaxDataPoints: q.MaxDataPoints,
+			Interval:      q.Interval,
+			From:          timeRange.From.UTC(),
+			To:            timeRange.To.UTC(),
+			JSON:          q.JSON,
+		})
+	}
+	return key
+}
+
+// normalizeTimeRange rounds a time range that ends close to now, such as "now-6h to now", down to the TTL.
+// The absolute time of a relative range changes on every refresh, so without rounding the requests sent
+// within the same TTL would never share a cache entry. Other time ranges are returned as they are.
+func normalizeTimeRange(tr backend.TimeRange, now time.Time, ttl time.Duration) backend.TimeRange {
+	if now.Sub(tr.To).Abs() > ttl {
+		return tr
+	}
+	return backend.TimeRange{
+		From: tr.From.Truncate(ttl),
+		To:   tr.To.Truncate(ttl),
+	}
+}
+
+// datasourceCacheTTL returns how long the responses of the data source are cached. The data source can
+// override the default TTL with queryCachingTTL in its JSON data, in milliseconds. A negative TTL
+// disables query caching for the data source.
+func datasourceCacheTTL(settings *backend.DataSourceInstanceSettings, defaultTTL time.Duration) time.Duration {
+	var jsonData struct {
+		QueryCachingTTL int64 `json:"queryCachingTTL"`
+	}
+	if len(settings.JSONData) == 0 || json.Unmarshal(settings.JSONData, &jsonData) != nil || jsonData.QueryCachingTTL == 0 {
+		return defaultTTL
+	}
+	return time.Duration(jsonData.QueryCachingTTL) * time.Millisecond
+}
+
+// shouldBypassQueryCache returns true if the request asks to skip the cache with the X-Cache-Skip header,
+// or if the data source is queried with the identity of the signed in user.
+func shouldBypassQueryCache(ctx context.Context, req *backend.QueryDataRequest) bool {
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.SkipQueryCache {
+		return true
+	}
+
+	var jsonData struct {
+		OAuthPassThru bool `json:"oauthPassThru"`
+	}
+	settings := req.PluginContext.DataSourceInstanceSettings
+	if len(settings.JSONData) > 0 && json.Unmarshal(settings.JSONData, &jsonData) == nil && jsonData.OAuthPassThru {
+		return true
+	}
+	for _, header := range userIdentityHeaders {
+		if req.GetHTTPHeader(header) != "" {
+			return true
+		}
+	}
+	_, ok := queryCacheUser(req)
+	return !ok
+}
+
+// queryCacheUser returns the login of the user whose identity is forwarded to the data source, which is part
+// of the cache keys. It returns false if the identity is forwarded but the user is unknown.
+func queryCacheUser(req *backend.QueryDataRequest) (string, bool) {
+	forwarded := false
+	for _, header := range forwardedUserHeaders {
+		if req.GetHTTPHeader(header) != "" {
+			forwarded = true
+		}
+	}
+	if !forwarded {
+		return "", true
+	}
+	if login := req.GetHTTPHeader(proxyutil.UserHeaderName); login != "" {
+		return login, true
+	}
+	if req.PluginContext.User != nil && req.PluginContext.User.Login != "" {
+		return req.PluginContext.User.Login, true
+	}
+	return "", false
+}
+
+// isCacheableQueryResponse returns false if any query failed, so that errors are retried on the next request.
+func isCacheableQueryResponse(resp *backend.QueryDataResponse) bool {
+	if resp == nil {
+		return false
+	}
+	for _, r := range resp.Responses {
+		if r.Error != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -17,7 +17,9 @@ import (
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/services/contexthandler"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -27,6 +29,7 @@ const (
 	XCacheHeader               = "X-Cache"
 	StatusHit      CacheStatus = "HIT"
 	StatusMiss     CacheStatus = "MISS"
+	StatusPartial  CacheStatus = "PARTIAL"
 	StatusBypass   CacheStatus = "BYPASS"
 	StatusError    CacheStatus = "ERROR"
 	StatusDisabled CacheStatus = "DISABLED"
@@ -36,6 +39,7 @@ const (
 var ShouldCacheQuery = awsds.ShouldCacheQuery
 
 type CacheQueryResponseFn func(context.Context, *backend.QueryDataResponse)
+type MergePartialQueryResponseFn func(context.Context, *backend.QueryDataResponse) *backend.QueryDataResponse
 type CacheResourceResponseFn func(context.Context, *backend.CallResourceResponse)
 
 type CachedQueryDataResponse struct {
@@ -44,6 +48,12 @@ type CachedQueryDataResponse struct {
 	// A function that should be used to cache a QueryDataResponse for a given query.
 	// It can be set to nil by the method implementation (if there is an error, for example), so it should be checked before being called.
 	UpdateCacheFn CacheQueryResponseFn
+	// The request for the part of the data that is not cached, or nil if the whole request has to be queried.
+	// It is set together with MergePartialResponseFn when part of the data is cached.
+	PartialRequest *backend.QueryDataRequest
+	// A function that merges the response to PartialRequest with the cached data into the response to the original request.
+	// UpdateCacheFn should be called with the merged response.
+	MergePartialResponseFn MergePartialQueryResponseFn
 }
 
 type CachedResourceDataResponse struct {
@@ -55,8 +65,23 @@ type CachedResourceDataResponse struct {
 	UpdateCacheFn CacheResourceResponseFn
 }
 
-func ProvideCachingService() *OSSCachingService {
-	return &OSSCachingService{}
+func ProvideCachingService(cfg *setting.Cfg) *OSSCachingService {
+	s := &OSSCachingService{
+		cfg:    cfg.QueryCaching,
+		logger: log.New("caching_service"),
+		now:    time.Now,
+	}
+	if !cfg.QueryCaching.Enabled {
+		return s
+	}
+
+	storage, err := newCacheStorage(cfg.QueryCaching)
+	if err != nil {
+		s.logger.Error("Failed to create query cache storage, query caching is disabled", "backend", cfg.QueryCaching.Backend, "error", err)
+		return s
+	}
+	s.storage = storage
+	return s
 }
 
 type CachingService interface {
@@ -69,12 +94,94 @@ type CachingService interface {
 	HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse, CacheStatus)
 }
 
-// Implementation of interface - does nothing
+// Implementation of interface - caches the query responses in memory or in Redis if query caching is enabled.
+// Resource requests are not cached.
 type OSSCachingService struct {
+	cfg     setting.QueryCachingSettings
+	storage cacheStorage
+	logger  log.Logger
+	now     func() time.Time
 }
 
 func (s *OSSCachingService) HandleQueryRequest(ctx context.Context, req *backend.QueryDataRequest) (bool, CachedQueryDataResponse, CacheStatus) {
-	return false, CachedQueryDataResponse{}, ""
+	if s.storage == nil || req == nil {
+		return false, CachedQueryDataResponse{}, ""
+	}
+	settings := req.PluginContext.DataSourceInstanceSettings
+	if settings == nil || shouldBypassQueryCache(ctx, req) {
+		return false, CachedQueryDataResponse{}, StatusBypass
+	}
+	ttl := datasourceCacheTTL(settings, s.cfg.TTL)
+	if ttl <= 0 {
+		return false, CachedQueryDataResponse{}, StatusDisabled
+	}
+
+	logger := s.logger.FromContext(ctx).New("datasource_uid", settings.UID)
+	key, err := GetKey("query", settings.UID, newQueryCacheKey(req, s.now(), ttl))
+	if err != nil {
+		logger.Error("Failed to create query cache key", "error", err)
+		return false, CachedQueryDataResponse{}, StatusError
+	}
+
+	value, found, err := s.storage.Get(ctx, key)
+	if err != nil {
+		logger.Error("Failed to read query response from cache", "error", err)
+		return false, CachedQueryDataResponse{}, StatusError
+	}
+	if found {
+		var resp backend.QueryDataResponse
+		if err := json.Unmarshal(value, &resp); err == nil {
+			return true, CachedQueryDataResponse{Response: &resp}, StatusHit
+		}
+		logger.Warn("Failed to decode cached query response", "error", err)
+	}
+
+	cr := CachedQueryDataResponse{}
+	status := StatusMiss
+	ranges := make(map[string]backend.TimeRange, len(req.Queries))
+	for _, q := range req.Queries {
+		ranges[q.RefID] = q.TimeRange
+	}
+	if s.cfg.TimeBucketSize > 0 {
+		if partial := s.findCachedTimeBuckets(ctx, logger, req); partial != nil {
+			if partial.request == nil {
+				return true, CachedQueryDataResponse{Response: partial.merge(nil)}, StatusHit
+			}
+			cr.PartialRequest = partial.request
+			cr.MergePartialResponseFn = func(_ context.Context, resp *backend.QueryDataResponse) *backend.QueryDataResponse {
+				return partial.merge(resp)
+			}
+			// Only the queried data is written to the time buckets
+			ranges = partial.missing
+			status = StatusPartial
+		}
+	}
+
+	cr.UpdateCacheFn = func(ctx context.Context, resp *backend.QueryDataResponse) {
+		s.cacheQueryResponse(ctx, logger, key, ttl, resp)
+		if s.cfg.TimeBucketSize > 0 {
+			s.cacheTimeBuckets(ctx, logger, req, ranges, resp, ttl)
+		}
+	}
+	return false, cr, status
+}
+
+func (s *OSSCachingService) cacheQueryResponse(ctx context.Context, logger log.Logger, key string, ttl time.Duration, resp *backend.QueryDataResponse) {
+	if !isCacheableQueryResponse(resp) {
+		return
+	}
+	value, err := json.Marshal(resp)
+	if err != nil {
+		logger.Error("Failed to encode query response", "error", err)
+		return
+	}
+	if s.cfg.MaxValueSize > 0 && len(value) > s.cfg.MaxValueSize {
+		logger.Debug("Query response is too large to be cached", "size", len(value), "max_size", s.cfg.MaxValueSize)
+		return
+	}
+	if err := s.storage.Set(ctx, key, value, ttl); err != nil {
+		logger.Error("Failed to write query response to cache", "error", err)
+	}
 }
 
 func (s *OSSCachingService) HandleResourceRequest(ctx context.Context, req *backend.CallResourceRequest) (bool, CachedResourceDataResponse, CacheStatus) {
@@ -174,10 +281,10 @@ func ProvideCachingServiceClient(cachingService CachingService, features feature
 }
 
 // WithQueryDataCaching calls `f` and caches the returned value if `req` has not been cached already.
-// Returns the cached value otherwise.
-func (c *CachingServiceClient) WithQueryDataCaching(ctx context.Context, req *backend.QueryDataRequest, f func() (*backend.QueryDataResponse, error)) (*backend.QueryDataResponse, error) {
+// Returns the cached value otherwise. If part of the data is cached, `f` is called with a request for the missing part only.
+func (c *CachingServiceClient) WithQueryDataCaching(ctx context.Context, req *backend.QueryDataRequest, f func(*backend.QueryDataRequest) (*backend.QueryDataResponse, error)) (*backend.QueryDataResponse, error) {
 	if c == nil || req == nil {
-		return f()
+		return f(req)
 	}
 
 	reqCtx := contexthandler.FromContext(ctx)
@@ -205,35 +312,53 @@ func (c *CachingServiceClient) WithQueryDataCaching(ctx context.Context, req *ba
 		return cr.Response, nil
 	}
 
-	// Cache miss; do the actual queries
-	resp, err := f()
-	// Update the query cache with the result for this metrics request
-	if err == nil && cr.UpdateCacheFn != nil {
-		// If AWS async caching is not enabled, use the old code path
-		//nolint:staticcheck // not yet migrated to OpenFeature
-		if c.features == nil || !c.features.IsEnabled(ctx, featuremgmt.FlagAwsAsyncQueryCaching) {
-			cr.UpdateCacheFn(ctx, resp)
-		} else if reqCtx != nil {
-			// time how long shouldCacheQuery takes
-			startShouldCacheQuery := time.Now()
-			shouldCache := ShouldCacheQuery(resp)
-			ShouldCacheQueryHistogram.With(prometheus.Labels{
-				"datasource_type": req.PluginContext.DataSourceInstanceSettings.Type,
-				"cache":           string(status),
-				"shouldCache":     strconv.FormatBool(shouldCache),
-				"query_type":      getQueryType(reqCtx),
-			}).Observe(time.Since(startShouldCacheQuery).Seconds())
-
-			// If AWS async caching is enabled and resp is for a running async query, don't cache it
-			if shouldCache {
-				cr.UpdateCacheFn(ctx, resp)
-			}
+	// Partial hit; only query the missing data and merge it with the cached data
+	if cr.PartialRequest != nil && cr.MergePartialResponseFn != nil {
+		resp, err := f(cr.PartialRequest)
+		if err != nil {
+			return resp, err
 		}
+		resp = cr.MergePartialResponseFn(ctx, resp)
+		c.updateQueryCache(ctx, req, reqCtx, cr, status, resp)
+		return resp, nil
+	}
+
+	// Cache miss; do the actual queries
+	resp, err := f(req)
+	if err == nil {
+		c.updateQueryCache(ctx, req, reqCtx, cr, status, resp)
 	}
 
 	return resp, err
 }
 
+// updateQueryCache updates the query cache with the result for this metrics request
+func (c *CachingServiceClient) updateQueryCache(ctx context.Context, req *backend.QueryDataRequest, reqCtx *contextmodel.ReqContext, cr CachedQueryDataResponse, status CacheStatus, resp *backend.QueryDataResponse) {
+	if cr.UpdateCacheFn == nil {
+		return
+	}
+	// If AWS async caching is not enabled, use the old code path
+	//nolint:staticcheck // not yet migrated to OpenFeature
+	if c.features == nil || !c.features.IsEnabled(ctx, featuremgmt.FlagAwsAsyncQueryCaching) {
+		cr.UpdateCacheFn(ctx, resp)
+	} else if reqCtx != nil {
+		// time how long shouldCacheQuery takes
+		startShouldCacheQuery := time.Now()
+		shouldCache := ShouldCacheQuery(resp)
+		ShouldCacheQueryHistogram.With(prometheus.Labels{
+			"datasource_type": req.PluginContext.DataSourceInstanceSettings.Type,
+			"cache":           string(status),
+			"shouldCache":     strconv.FormatBool(shouldCache),
+			"query_type":      getQueryType(reqCtx),
+		}).Observe(time.Since(startShouldCacheQuery).Seconds())
+
+		// If AWS async caching is enabled and resp is for a running async query, don't cache it
+		if shouldCache {
+			cr.UpdateCacheFn(ctx, resp)
+		}
+	}
+}
+
 // WithCallResourceCaching calls `f` and caches the returned value if `req` has not been cached already.
 // Returns the cached value otherwise.
 func (c *CachingServiceClient) WithCallResourceCaching(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender, f func(backend.CallResourceResponseSender) error) error {
@@ -5,10 +5,14 @@ import (
 	"errors"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/require"
 )
@@ -18,7 +22,7 @@ func TestWithQueryDataCaching(t *testing.T) {
 		var s *CachingServiceClient
 		req := backend.QueryDataRequest{}
 		fakeResponse := &backend.QueryDataResponse{}
-		response, err := s.WithQueryDataCaching(t.Context(), &req, func() (*backend.QueryDataResponse, error) {
+		response, err := s.WithQueryDataCaching(t.Context(), &req, func(*backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 			return fakeResponse, nil
 		})
 		require.NoError(t, err)
@@ -39,7 +43,7 @@ func TestWithQueryDataCaching(t *testing.T) {
 		}
 		ctx := context.WithValue(t.Context(), ctxkey.Key{}, reqCtx)
 		fakeResponse := &backend.QueryDataResponse{}
-		response, err := client.WithQueryDataCaching(ctx, &req, func() (*backend.QueryDataResponse, error) {
+		response, err := client.WithQueryDataCaching(ctx, &req, func(*backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 			return fakeResponse, nil
 		})
 		require.NoError(t, err)
@@ -56,12 +60,41 @@ func TestWithQueryDataCaching(t *testing.T) {
 
 		fakeResponse := &backend.QueryDataResponse{}
 		// Using the default test context, no request context.
-		response, err := client.WithQueryDataCaching(t.Context(), &req, func() (*backend.QueryDataResponse, error) {
+		response, err := client.WithQueryDataCaching(t.Context(), &req, func(*backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 			return fakeResponse, nil
 		})
 		require.NoError(t, err)
 		require.Equal(t, fakeResponse, response)
 	})
+
+	t.Run("only the missing part is queried on a partial hit", func(t *testing.T) {
+		partialReq := &backend.QueryDataRequest{}
+		partialResponse := &backend.QueryDataResponse{}
+		mergedResponse := &backend.QueryDataResponse{}
+		var cached *backend.QueryDataResponse
+
+		fakeCachingService := NewFakeOSSCachingService()
+		fakeCachingService.ReturnStatus = StatusPartial
+		fakeCachingService.ReturnQueryResponse = CachedQueryDataResponse{
+			PartialRequest: partialReq,
+			MergePartialResponseFn: func(_ context.Context, resp *backend.QueryDataResponse) *backend.QueryDataResponse {
+				require.Same(t, partialResponse, resp)
+				return mergedResponse
+			},
+			UpdateCacheFn: func(_ context.Context, resp *backend.QueryDataResponse) {
+				cached = resp
+			},
+		}
+		client := ProvideCachingServiceClient(fakeCachingService, nil)
+
+		response, err := client.WithQueryDataCaching(t.Context(), &backend.QueryDataRequest{}, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			require.Same(t, partialReq, req)
+			return partialResponse, nil
+		})
+		require.NoError(t, err)
+		require.Same(t, mergedResponse, response)
+		require.Same(t, mergedResponse, cached)
+	})
 }
 
 func TestWithCallResourceCaching(t *testing.T) {
@@ -128,3 +161,310 @@ func TestGetDatasourceType(t *testing.T) {
 		},
 	}))
 }
+
+func TestOSSCachingService_HandleQueryRequest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	createService := func(t *testing.T) *OSSCachingService {
+		t.Helper()
+		cfg := setting.NewCfg()
+		cfg.QueryCaching = setting.QueryCachingSettings{
+			Enabled:  true,
+			Backend:  setting.QueryCachingBackendMemory,
+			TTL:      time.Minute,
+			MaxItems: 10,
+		}
+		s := ProvideCachingService(cfg)
+		s.now = func() time.Time { return now }
+		s.storage.(*memoryStorage).now = s.now
+		return s
+	}
+	createRequest := func(jsonData string, from, to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID: 1,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					UID:      "ds",
+					JSONData: []byte(jsonData),
+				},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      []byte(`{"expr":"up"}`),
+			}},
+		}
+	}
+	response := &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": {Frames: data.Frames{data.NewFrame("A", data.NewField("value", nil, []float64{1}))}},
+	}}
+
+	t.Run("does nothing when query caching is disabled", func(t *testing.T) {
+		s := ProvideCachingService(setting.NewCfg())
+		hit, cr, status := s.HandleQueryRequest(t.Context(), createRequest("{}", now.Add(-time.Hour), now))
+		require.False(t, hit)
+		require.Nil(t, cr.UpdateCacheFn)
+		require.Empty(t, status)
+	})
+
+	t.Run("returns the cached response of a relative time range", func(t *testing.T) {
+		s := createService(t)
+
+		hit, cr, status := s.HandleQueryRequest(t.Context(), createRequest("{}", now.Add(-time.Hour), now))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, status)
+		require.NotNil(t, cr.UpdateCacheFn)
+		cr.UpdateCacheFn(t.Context(), response)
+
+		// A refresh a few seconds later falls into the same TTL window.
+		later := now.Add(10 * time.Second)
+		hit, cr, status = s.HandleQueryRequest(t.Context(), createRequest("{}", later.Add(-time.Hour), later))
+		require.True(t, hit)
+		require.Equal(t, StatusHit, status)
+		require.Len(t, cr.Response.Responses, 1)
+		require.Equal(t, response.Responses["A"].Frames[0].Name, cr.Response.Responses["A"].Frames[0].Name)
+
+		// The next TTL window queries the data source again.
+		later = now.Add(time.Minute)
+		s.now = func() time.Time { return later }
+		hit, _, status = s.HandleQueryRequest(t.Context(), createRequest("{}", later.Add(-time.Hour), later))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, status)
+	})
+
+	t.Run("does not cache failed queries", func(t *testing.T) {
+		s := createService(t)
+		req := createRequest("{}", now.Add(-time.Hour), now)
+
+		_, cr, _ := s.HandleQueryRequest(t.Context(), req)
+		cr.UpdateCacheFn(t.Context(), &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Error: errors.New("failed")},
+		}})
+
+		hit, _, status := s.HandleQueryRequest(t.Context(), req)
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, status)
+	})
+
+	t.Run("uses the TTL of the data source", func(t *testing.T) {
+		s := createService(t)
+
+		_, _, status := s.HandleQueryRequest(t.Context(), createRequest(`{"queryCachingTTL":-1}`, now.Add(-time.Hour), now))
+		require.Equal(t, StatusDisabled, status)
+
+		req := createRequest(`{"queryCachingTTL":300000}`, now.Add(-time.Hour), now)
+		_, cr, _ := s.HandleQueryRequest(t.Context(), req)
+		cr.UpdateCacheFn(t.Context(), response)
+		later := now.Add(2 * time.Minute)
+		s.now = func() time.Time { return later }
+		hit, _, status := s.HandleQueryRequest(t.Context(), req)
+		require.True(t, hit)
+		require.Equal(t, StatusHit, status)
+	})
+
+	t.Run("bypasses the cache", func(t *testing.T) {
+		s := createService(t)
+
+		_, _, status := s.HandleQueryRequest(t.Context(), createRequest(`{"oauthPassThru":true}`, now.Add(-time.Hour), now))
+		require.Equal(t, StatusBypass, status)
+
+		req := createRequest("{}", now.Add(-time.Hour), now)
+		req.SetHTTPHeader("Authorization", "Bearer token")
+		_, _, status = s.HandleQueryRequest(t.Context(), req)
+		require.Equal(t, StatusBypass, status)
+
+		reqCtx := &contextmodel.ReqContext{SkipQueryCache: true}
+		ctx := context.WithValue(t.Context(), ctxkey.Key{}, reqCtx)
+		_, _, status = s.HandleQueryRequest(ctx, createRequest("{}", now.Add(-time.Hour), now))
+		require.Equal(t, StatusBypass, status)
+
+		// the ID of an unknown user is forwarded
+		req = createRequest("{}", now.Add(-time.Hour), now)
+		req.SetHTTPHeader("X-Grafana-Id", "token")
+		_, _, status = s.HandleQueryRequest(t.Context(), req)
+		require.Equal(t, StatusBypass, status)
+	})
+
+	t.Run("caches the responses per user when the user is forwarded to the data source", func(t *testing.T) {
+		s := createService(t)
+		requestAs := func(login string) *backend.QueryDataRequest {
+			req := createRequest("{}", now.Add(-time.Hour), now)
+			req.PluginContext.User = &backend.User{Login: login}
+			req.SetHTTPHeader("X-Grafana-Id", "token-"+login)
+			return req
+		}
+
+		_, cr, status := s.HandleQueryRequest(t.Context(), requestAs("alice"))
+		require.Equal(t, StatusMiss, status)
+		cr.UpdateCacheFn(t.Context(), response)
+
+		hit, _, _ := s.HandleQueryRequest(t.Context(), requestAs("alice"))
+		require.True(t, hit)
+		hit, _, status = s.HandleQueryRequest(t.Context(), requestAs("bob"))
+		require.False(t, hit)
+		require.Equal(t, StatusMiss, status)
+	})
+}
+
+func TestOSSCachingService_TimeBuckets(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	cfg := setting.NewCfg()
+	cfg.QueryCaching = setting.QueryCachingSettings{
+		Enabled:        true,
+		Backend:        setting.QueryCachingBackendMemory,
+		TTL:            time.Minute,
+		MaxItems:       1000,
+		TimeBucketSize: 10 * time.Minute,
+		TimeBucketTTL:  time.Hour,
+	}
+	s := ProvideCachingService(cfg)
+	s.now = func() time.Time { return now }
+	s.storage.(*memoryStorage).now = func() time.Time { return now }
+
+	createRequest := func(from, to time.Time) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:                      1,
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "ds"},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: from, To: to},
+				JSON:      []byte(`{"expr":"up"}`),
+			}},
+		}
+	}
+	// query returns a sample at every minute of the time range of the queries.
+	query := func(req *backend.QueryDataRequest) *backend.QueryDataResponse {
+		resp := backend.NewQueryDataResponse()
+		for _, q := range req.Queries {
+			frame := data.NewFrame("up",
+				data.NewField("time", nil, []time.Time{}),
+				data.NewField("value", data.Labels{"job": "grafana"}, []float64{}),
+			)
+			for ts := q.TimeRange.From.Truncate(time.Minute); !ts.After(q.TimeRange.To); ts = ts.Add(time.Minute) {
+				if !ts.Before(q.TimeRange.From) {
+					frame.AppendRow(ts, float64(ts.Unix()))
+				}
+			}
+			resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+		}
+		return resp
+	}
+	samples := func(t *testing.T, resp *backend.QueryDataResponse) []int64 {
+		t.Helper()
+		require.Len(t, resp.Responses["A"].Frames, 1)
+		frame := resp.Responses["A"].Frames[0]
+		result := make([]int64, 0, frame.Rows())
+		for i := range frame.Rows() {
+			ts := frame.Fields[0].At(i).(time.Time)
+			require.Equal(t, float64(ts.Unix()), frame.Fields[1].At(i))
+			result = append(result, ts.Unix())
+		}
+		return result
+	}
+
+	req := createRequest(now.Add(-2*time.Hour), now)
+	hit, cr, status := s.HandleQueryRequest(t.Context(), req)
+	require.False(t, hit)
+	require.Equal(t, StatusMiss, status)
+	require.Nil(t, cr.PartialRequest)
+	cr.UpdateCacheFn(t.Context(), query(req))
+
+	t.Run("queries only the tail of a time range that moved forward", func(t *testing.T) {
+		later := now.Add(5 * time.Minute)
+		s.now = func() time.Time { return later }
+		req := createRequest(later.Add(-2*time.Hour), later)
+
+		hit, cr, status := s.HandleQueryRequest(t.Context(), req)
+		require.False(t, hit)
+		require.Equal(t, StatusPartial, status)
+		require.NotNil(t, cr.PartialRequest)
+		// The bucket with the data newer than the TTL at the time of caching is queried again.
+		require.Equal(t, backend.TimeRange{
+			From: time.Date(2024, 5, 1, 11, 50, 0, 0, time.UTC),
+			To:   later,
+		}, cr.PartialRequest.Queries[0].TimeRange)
+
+		merged := cr.MergePartialResponseFn(t.Context(), query(cr.PartialRequest))
+		require.Equal(t, samples(t, query(req)), samples(t, merged))
+		require.Equal(t, data.Labels{"job": "grafana"}, merged.Responses["A"].Frames[0].Fields[1].Labels)
+		cr.UpdateCacheFn(t.Context(), merged)
+	})
+
+	t.Run("queries only the head of a time range that moved backward", func(t *testing.T) {
+		req := createRequest(now.Add(-3*time.Hour), now.Add(-time.Hour))
+
+		hit, cr, status := s.HandleQueryRequest(t.Context(), req)
+		require.False(t, hit)
+		require.Equal(t, StatusPartial, status)
+		// The first cached bucket doesn't cover the start of its time range.
+		require.Equal(t, backend.TimeRange{
+			From: now.Add(-3 * time.Hour),
+			To:   time.Date(2024, 5, 1, 10, 10, 0, 0, time.UTC),
+		}, cr.PartialRequest.Queries[0].TimeRange)
+
+		merged := cr.MergePartialResponseFn(t.Context(), query(cr.PartialRequest))
+		require.Equal(t, samples(t, query(req)), samples(t, merged))
+	})
+
+	t.Run("returns a time range covered by the buckets from the cache", func(t *testing.T) {
+		req := createRequest(now.Add(-90*time.Minute), now.Add(-30*time.Minute))
+
+		hit, cr, status := s.HandleQueryRequest(t.Context(), req)
+		require.True(t, hit)
+		require.Equal(t, StatusHit, status)
+		require.Equal(t, samples(t, query(req)), samples(t, cr.Response))
+	})
+
+	t.Run("returns the errors of the partial request", func(t *testing.T) {
+		later := now.Add(20 * time.Minute)
+		s.now = func() time.Time { return later }
+		req := createRequest(later.Add(-2*time.Hour), later)
+
+		_, cr, status := s.HandleQueryRequest(t.Context(), req)
+		require.Equal(t, StatusPartial, status)
+		merged := cr.MergePartialResponseFn(t.Context(), &backend.QueryDataResponse{Responses: backend.Responses{
+			"A": {Error: errors.New("failed")},
+		}})
+		require.EqualError(t, merged.Responses["A"].Error, "failed")
+	})
+}
+
+func TestNormalizeTimeRange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+
+	relative := normalizeTimeRange(backend.TimeRange{From: now.Add(-time.Hour), To: now}, now, time.Minute)
+	require.Equal(t, backend.TimeRange{
+		From: time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}, relative)
+
+	absolute := backend.TimeRange{From: now.Add(-48 * time.Hour), To: now.Add(-24*time.Hour + 15*time.Second)}
+	require.Equal(t, absolute, normalizeTimeRange(absolute, now, time.Minute))
+}
+
+func TestRedisStorage(t *testing.T) {
+	mr := miniredis.RunT(t)
+	s, err := newRedisStorage("redis://"+mr.Addr(), "prefix:")
+	require.NoError(t, err)
+
+	_, found, err := s.Get(t.Context(), "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, s.Set(t.Context(), "key", []byte("value"), time.Minute))
+	require.True(t, mr.Exists("prefix:key"))
+	value, found, err := s.Get(t.Context(), "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("value"), value)
+
+	mr.FastForward(time.Minute)
+	_, found, err = s.Get(t.Context(), "key")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, err = newRedisStorage("redis://user:secret@%", "")
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret")
+}
@@ -0,0 +1,102 @@
+package caching
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+// cacheStorage stores encoded responses by cache key.
+type cacheStorage interface {
+	// Get returns the value stored for key, and false if there is no value or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key, replacing any existing value. The value expires after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+func newCacheStorage(cfg setting.QueryCachingSettings) (cacheStorage, error) {
+	switch cfg.Backend {
+	case setting.QueryCachingBackendMemory, "":
+		return newMemoryStorage(cfg.MaxItems)
+	case setting.QueryCachingBackendRedis:
+		return newRedisStorage(cfg.RedisURL, cfg.RedisPrefix)
+	default:
+		return nil, fmt.Errorf("unknown query caching backend %q", cfg.Backend)
+	}
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// memoryStorage keeps the most recently used values in memory. Each value has its own expiry because
+// data sources can set different TTLs.
+type memoryStorage struct {
+	cache *lru.Cache[string, memoryEntry]
+	now   func() time.Time
+}
+
+func newMemoryStorage(maxItems int) (*memoryStorage, error) {
+	cache, err := lru.New[string, memoryEntry](maxItems)
+	if err != nil {
+		return nil, err
+	}
+	return &memoryStorage{cache: cache, now: time.Now}, nil
+}
+
+func (s *memoryStorage) Get(_ context.Context, key string) ([]byte, bool, error) {
+	entry, ok := s.cache.Get(key)
+	if !ok {
+		return nil, false, nil
+	}
+	if !s.now().Before(entry.expires) {
+		s.cache.Remove(key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (s *memoryStorage) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	s.cache.Add(key, memoryEntry{value: value, expires: s.now().Add(ttl)})
+	return nil
+}
+
+// redisStorage shares the cached values between Grafana instances. Expiry is handled by Redis.
+type redisStorage struct {
+	client *redis.Client
+	prefix string
+}
+
+func newRedisStorage(url string, prefix string) (*redisStorage, error) {
+	if url == "" {
+		return nil, errors.New("redis_url must be set to use the redis query caching backend")
+	}
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		// Parse errors can quote the URL, including the credentials, so never propagate them.
+		return nil, errors.New("invalid redis connection URL in redis_url")
+	}
+	return &redisStorage{client: redis.NewClient(options), prefix: prefix}, nil
+}
+
+func (s *redisStorage) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *redisStorage) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
@@ -0,0 +1,385 @@
+package caching
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+)
+
+// maxTimeBucketsPerQuery limits the number of cache lookups for a single query. Queries with a longer
+// time range are cached only as a whole.
+const maxTimeBucketsPerQuery = 200
+
+// timeBucket is the cached data of a time series query in one time bucket.
+type timeBucket struct {
+	// From and To are the part of the bucket covered by Frames. The bucket doesn't cover the data
+	// that was newer than the TTL when it was cached, because it can still change.
+	From   time.Time   `json:"from"`
+	To     time.Time   `json:"to"`
+	Frames data.Frames `json:"frames"`
+}
+
+// timeBucketKey identifies a query regardless of its time range, and a time bucket.
+type timeBucketKey struct {
+	OrgID             int64           `json:"orgId"`
+	User              string          `json:"user,omitempty"`
+	DatasourceUpdated time.Time       `json:"datasourceUpdated"`
+	RefID             string          `json:"refId"`
+	QueryType         string          `json:"queryType"`
+	MaxDataPoints     int64           `json:"maxDataPoints"`
+	Interval          time.Duration   `json:"interval"`
+	JSON              json.RawMessage `json:"json"`
+	Start             time.Time       `json:"start"`
+}
+
+// cachedQueryPart is the part of the response of a query that is cached in time buckets.
+type cachedQueryPart struct {
+	refID  string
+	frames data.Frames
+	// complete is true if the whole time range of the query is cached.
+	complete bool
+	// boundary separates the cached data from the data that is queried. The data before it is queried
+	// if freshFirst is true, and the data from it onwards otherwise.
+	boundary   time.Time
+	freshFirst bool
+}
+
+// partialQueryResponse is the part of the response of a request that is cached in time buckets.
+type partialQueryResponse struct {
+	// request queries the time ranges that are not cached. It is nil if all the queries are cached.
+	request *backend.QueryDataRequest
+	// missing contains the time range that request queries for each query.
+	missing map[string]backend.TimeRange
+	parts   []cachedQueryPart
+}
+
+// merge stitches the frames of the response to the partial request with the cached frames.
+func (p *partialQueryResponse) merge(resp *backend.QueryDataResponse) *backend.QueryDataResponse {
+	result := backend.NewQueryDataResponse()
+	for _, part := range p.parts {
+		if part.complete {
+			result.Responses[part.refID] = backend.DataResponse{Frames: part.frames}
+			continue
+		}
+
+		var fresh backend.DataResponse
+		if resp != nil {
+			fresh = resp.Responses[part.refID]
+		}
+		if fresh.Error != nil {
+			result.Responses[part.refID] = fresh
+			continue
+		}
+		freshFrames := filterFrames(fresh.Frames, func(t time.Time) bool {
+			return t.Before(part.boundary) == part.freshFirst
+		})
+		frames := concatFrames(part.frames, freshFrames)
+		if part.freshFirst {
+			frames = concatFrames(freshFrames, part.frames)
+		}
+		result.Responses[part.refID] = backend.DataResponse{Frames: frames, Status: fresh.Status}
+	}
+	return result
+}
+
+// findCachedTimeBuckets returns the part of the response of the request that is cached in time buckets, or nil
+// if no query has cached data. Only the cached data at the start or at the end of the time range of a query is used,
+// so that the data source is queried for a single time range: the tail of a time range that moved forward, such as
+// "last 6 hours" on the next refresh, or the head of a time range that moved backward.
+func (s *OSSCachingService) findCachedTimeBuckets(ctx context.Context, logger log.Logger, req *backend.QueryDataRequest) *partialQueryResponse {
+	partialReq := *req
+	partialReq.Queries = nil
+	p := &partialQueryResponse{missing: map[string]backend.TimeRange{}}
+
+	anyCached := false
+	for _, q := range req.Queries {
+		part, missing, cached := s.findCachedQueryPart(ctx, logger, req, q)
+		anyCached = anyCached || cached
+		p.parts = append(p.parts, part)
+		if part.complete {
+			continue
+		}
+		p.missing[q.RefID] = missing
+		q.TimeRange = missing
+		partialReq.Queries = append(partialReq.Queries, q)
+	}
+	if !anyCached {
+		return nil
+	}
+	if len(partialReq.Queries) > 0 {
+		p.request = &partialReq
+	}
+	return p
+}
+
+func (s *OSSCachingService) findCachedQueryPart(ctx context.Context, logger log.Logger, req *backend.QueryDataRequest, q backend.DataQuery) (cachedQueryPart, backend.TimeRange, bool) {
+	part := cachedQueryPart{refID: q.RefID}
+	starts := timeBucketStarts(q.TimeRange, s.cfg.TimeBucketSize)
+	if len(starts) == 0 || len(starts) > maxTimeBucketsPerQuery {
+		return part, q.TimeRange, false
+	}
+
+	n := len(starts)
+	buckets := make([]*timeBucket, n)
+	for i, start := range starts {
+		buckets[i] = s.getTimeBucket(ctx, logger, req, q, start)
+	}
+	covered := func(i int) bool {
+		from, to := s.bucketRange(q.TimeRange, starts[i])
+		return buckets[i] != nil && !buckets[i].From.After(from) && !buckets[i].To.Before(to)
+	}
+	cachedFrames := func(first, last int) data.Frames {
+		parts := make([]data.Frames, 0, last-first)
+		for i := first; i < last; i++ {
+			from, to := s.bucketRange(q.TimeRange, starts[i])
+			end := starts[i].Add(s.cfg.TimeBucketSize)
+			parts = append(parts, filterFrames(buckets[i].Frames, func(t time.Time) bool {
+				return !t.Before(from) && !t.After(to) && t.Before(end)
+			}))
+		}
+		return concatFrames(parts...)
+	}
+
+	prefix := 0
+	for prefix < n && covered(prefix) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < n-prefix && covered(n-1-suffix) {
+		suffix++
+	}
+
+	switch {
+	case prefix == n:
+		part.frames = cachedFrames(0, n)
+		part.complete = true
+		return part, backend.TimeRange{}, true
+	case prefix > 0 && prefix >= suffix:
+		part.frames = cachedFrames(0, prefix)
+		part.boundary = starts[prefix]
+		return part, backend.TimeRange{From: starts[prefix], To: q.TimeRange.To}, true
+	case suffix > 0:
+		part.frames = cachedFrames(n-suffix, n)
+		part.boundary = starts[n-suffix]
+		part.freshFirst = true
+		return part, backend.TimeRange{From: q.TimeRange.From, To: starts[n-suffix]}, true
+	default:
+		return part, q.TimeRange, false
+	}
+}
+
+func (s *OSSCachingService) getTimeBucket(ctx context.Context, logger log.Logger, req *backend.QueryDataRequest, q backend.DataQuery, start time.Time) *timeBucket {
+	key, err := GetKey("query_bucket", req.PluginContext.DataSourceInstanceSettings.UID, newTimeBucketKey(req, q, start))
+	if err != nil {
+		logger.Error("Failed to create time bucket cache key", "error", err)
+		return nil
+	}
+	value, found, err := s.storage.Get(ctx, key)
+	if err != nil {
+		logger.Error("Failed to read time bucket from cache", "error", err)
+		return nil
+	}
+	if !found {
+		return nil
+	}
+	var bucket timeBucket
+	if err := json.Unmarshal(value, &bucket); err != nil {
+		logger.Warn("Failed to decode cached time bucket", "error", err)
+		return nil
+	}
+	return &bucket
+}
+
+// cacheTimeBuckets caches the frames of the time series queries in time buckets. Only the given time range of each
+// query is cached, so that the data that was read from the cache doesn't extend its own expiry.
+func (s *OSSCachingService) cacheTimeBuckets(ctx context.Context, logger log.Logger, req *backend.QueryDataRequest, ranges map[string]backend.TimeRange, resp *backend.QueryDataResponse, ttl time.Duration) {
+	if resp == nil {
+		return
+	}
+	freshUntil := s.now().Add(-ttl)
+	for _, q := range req.Queries {
+		tr, ok := ranges[q.RefID]
+		if !ok {
+			continue
+		}
+		r, ok := resp.Responses[q.RefID]
+		if !ok || r.Error != nil || !isTimeSeries(r.Frames) {
+			continue
+		}
+		starts := timeBucketStarts(tr, s.cfg.TimeBucketSize)
+		if len(starts) > maxTimeBucketsPerQuery {
+			continue
+		}
+		for _, start := range starts {
+			end := start.Add(s.cfg.TimeBucketSize)
+			from, to := s.bucketRange(tr, start)
+			if to.After(freshUntil) {
+				to = freshUntil
+			}
+			if from.After(to) {
+				continue
+			}
+			s.setTimeBucket(ctx, logger, req, q, start, timeBucket{
+				From: from,
+				To:   to,
+				Frames: filterFrames(r.Frames, func(t time.Time) bool {
+					return !t.Before(from) && !t.After(to) && t.Before(end)
+				}),
+			})
+		}
+	}
+}
+
+func (s *OSSCachingService) setTimeBucket(ctx context.Context, logger log.Logger, req *backend.QueryDataRequest, q backend.DataQuery, start time.Time, bucket timeBucket) {
+	key, err := GetKey("query_bucket", req.PluginContext.DataSourceInstanceSettings.UID, newTimeBucketKey(req, q, start))
+	if err != nil {
+		logger.Error("Failed to create time bucket cache key", "error", err)
+		return
+	}
+	value, err := json.Marshal(bucket)
+	if err != nil {
+		logger.Error("Failed to encode time bucket", "error", err)
+		return
+	}
+	if s.cfg.MaxValueSize > 0 && len(value) > s.cfg.MaxValueSize {
+		return
+	}
+	if err := s.storage.Set(ctx, key, value, s.cfg.TimeBucketTTL); err != nil {
+		logger.Error("Failed to write time bucket to cache", "error", err)
+	}
+}
+
+// bucketRange returns the part of the time range that is in the bucket that starts at start.
+func (s *OSSCachingService) bucketRange(tr backend.TimeRange, start time.Time) (time.Time, time.Time) {
+	from, to := start, start.Add(s.cfg.TimeBucketSize)
+	if tr.From.After(from) {
+		from = tr.From
+	}
+	if tr.To.Before(to) {
+		to = tr.To
+	}
+	return from, to
+}
+
+func newTimeBucketKey(req *backend.QueryDataRequest, q backend.DataQuery, start time.Time) timeBucketKey {
+	user, _ := queryCacheUser(req)
+	return timeBucketKey{
+		OrgID:             req.PluginContext.OrgID,
+		User:              user,
+		DatasourceUpdated: req.PluginContext.DataSourceInstanceSettings.Updated,
+		RefID:             q.RefID,
+		QueryType:         q.QueryType,
+		MaxDataPoints:     q.MaxDataPoints,
+		Interval:          q.Interval,
+		JSON:              q.JSON,
+		Start:             start.UTC(),
+	}
+}
+
+// timeBucketStarts returns the start of the buckets the time range overlaps. A bucket includes its start and
+// excludes its end, so a time range that ends at the start of a bucket overlaps it.
+func timeBucketStarts(tr backend.TimeRange, size time.Duration) []time.Time {
+	if size <= 0 || tr.To.Before(tr.From) {
+		return nil
+	}
+	var starts []time.Time
+	for start := tr.From.Truncate(size); !start.After(tr.To); start = start.Add(size) {
+		if len(starts) > maxTimeBucketsPerQuery {
+			break
+		}
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+// isTimeSeries returns true if all the frames have a time field.
+func isTimeSeries(frames data.Frames) bool {
+	for _, f := range frames {
+		if timeFieldIndex(f) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func timeFieldIndex(f *data.Frame) int {
+	for i, field := range f.Fields {
+		if field.Type() == data.FieldTypeTime || field.Type() == data.FieldTypeNullableTime {
+			return i
+		}
+	}
+	return -1
+}
+
+// filterFrames returns copies of the frames with the rows whose time matches keep. Frames without a time field are
+// returned as they are.
+func filterFrames(frames data.Frames, keep func(time.Time) bool) data.Frames {
+	result := make(data.Frames, 0, len(frames))
+	for _, f := range frames {
+		idx := timeFieldIndex(f)
+		if idx < 0 {
+			result = append(result, f)
+			continue
+		}
+		filtered := f.EmptyCopy()
+		for i := range f.Rows() {
+			var t time.Time
+			switch v := f.Fields[idx].At(i).(type) {
+			case time.Time:
+				t = v
+			case *time.Time:
+				if v == nil {
+					continue
+				}
+				t = *v
+			}
+			if keep(t) {
+				filtered.AppendRow(f.RowCopy(i)...)
+			}
+		}
+		result = append(result, filtered)
+	}
+	return result
+}
+
+// concatFrames appends the rows of the frames of each part to the frames of the previous parts with the same
+// name and fields. The parts must be in time order.
+func concatFrames(parts ...data.Frames) data.Frames {
+	var result data.Frames
+	index := map[string]*data.Frame{}
+	for _, frames := range parts {
+		for _, f := range frames {
+			signature := frameSignature(f)
+			existing, ok := index[signature]
+			if !ok {
+				existing = f.EmptyCopy()
+				index[signature] = existing
+				result = append(result, existing)
+			}
+			for i, field := range f.Fields {
+				existing.Fields[i].AppendAll(field)
+			}
+		}
+	}
+	return result
+}
+
+func frameSignature(f *data.Frame) string {
+	var sb strings.Builder
+	sb.WriteString(f.Name)
+	for _, field := range f.Fields {
+		sb.WriteString("\x00")
+		sb.WriteString(field.Name)
+		sb.WriteString("\x00")
+		sb.WriteString(field.Type().String())
+		sb.WriteString("\x00")
+		sb.WriteString(field.Labels.String())
+	}
+	return sb.String()
+}
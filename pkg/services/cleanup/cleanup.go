@@ -33,6 +33,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/image"
 	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/publicdashboards"
 	"github.com/grafana/grafana/pkg/services/queryhistory"
 	"github.com/grafana/grafana/pkg/services/shorturls"
 	"github.com/grafana/grafana/pkg/services/team"
@@ -64,13 +65,14 @@ type CleanUpService struct {
 	orgService                org.Service
 	teamService               team.Service
 	dataSourceService         datasources.DataSourceService
+	publicDashboardStore      publicdashboards.Store
 	dynamicClientFactory      func(*rest.Config) (dynamic.Interface, error)
 }
 
 func ProvideService(cfg *setting.Cfg, Features featuremgmt.FeatureToggles, serverLockService *serverlock.ServerLockService,
 	shortURLService shorturls.Service, sqlstore db.DB, queryHistoryService queryhistory.Service,
 	dashboardVersionService dashver.Service, dashSnapSvc dashboardsnapshots.Service, deleteExpiredImageService *image.DeleteExpiredService,
-	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, service AlertRuleService, clientConfigProvider grafanaapiserver.RestConfigProvider, orgService org.Service, teamService team.Service, dataSourceService datasources.DataSourceService) *CleanUpService {
+	tempUserService tempuser.Service, tracer tracing.Tracer, annotationCleaner annotations.Cleaner, service AlertRuleService, clientConfigProvider grafanaapiserver.RestConfigProvider, orgService org.Service, teamService team.Service, dataSourceService datasources.DataSourceService, publicDashboardStore publicdashboards.Store) *CleanUpService {
 	s := &CleanUpService{
 		Cfg:                       cfg,
 		Features:                  Features,
@@ -90,6 +92,7 @@ func ProvideService(cfg *setting.Cfg, Features featuremgmt.FeatureToggles, serve
 		orgService:                orgService,
 		teamService:               teamService,
 		dataSourceService:         dataSourceService,
+		publicDashboardStore:      publicDashboardStore,
 		dynamicClientFactory: func(c *rest.Config) (dynamic.Interface, error) {
 			return dynamic.NewForConfig(c)
 		},
@@ -137,6 +140,7 @@ func (srv *CleanUpService) clean(ctx context.Context) {
 		{"delete stale query history", srv.deleteStaleQueryHistory},
 		{"expire old email verifications", srv.expireOldVerifications},
 		{"cleanup stale LBAC rules", srv.cleanupStaleLBACRules},
+		{"disable expired public dashboards", srv.disableExpiredPublicDashboards},
 	}
 
 	if srv.Cfg.ShortLinkExpiration > 0 {
@@ -255,6 +259,16 @@ func (srv *CleanUpService) deleteExpiredSnapshots(ctx context.Context) {
 	}
 }
 
+func (srv *CleanUpService) disableExpiredPublicDashboards(ctx context.Context) {
+	logger := srv.log.FromContext(ctx)
+	affected, err := srv.publicDashboardStore.DisableExpired(ctx, time.Now())
+	if err != nil {
+		logger.Error("Failed to disable expired public dashboards", "error", err.Error())
+	} else {
+		logger.Debug("Disabled expired public dashboards", "rows affected", affected)
+	}
+}
+
 func (srv *CleanUpService) deleteKubernetesExpiredSnapshots(ctx context.Context) {
 	logger := srv.log.FromContext(ctx)
 	logger.Debug("Starting deleting expired Kubernetes snapshots")
@@ -0,0 +1,177 @@
+// Package dashboardvariables interpolates dashboard template variables in queries on the server, the same way the
+// frontend does before sending queries.
+package dashboardvariables
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// AllValue is the value of the "All" option of a variable
+const AllValue = "$__all"
+
+// datasources using the SQL string format for multi-value variables by default
+var sqlDatasourceTypes = []string{"mysql", "grafana-mysql-datasource", "postgres", "grafana-postgresql-datasource", "mssql", "grafana-mssql-datasource"}
+
+// datasources using the regex format for multi-value variables by default
+var regexDatasourceTypes = []string{"prometheus", "loki"}
+
+// same as the variable reference regex of the frontend: $var, [[var:format]] and ${var.fieldPath:format}
+var variableReferenceRegexp = regexp.MustCompile(`\$(\w+)|\[\[(\w+?)(?::(\w+))?\]\]|\$\{(\w+)(?:\.([^:^\}]+))?(?::([^\}]+))?\}`)
+
+// Variable is a template variable with the values to interpolate queries with
+type Variable struct {
+	Name       string
+	Multi      bool
+	IncludeAll bool
+	// AllValue is the custom value used when All is selected, the values are used otherwise
+	AllValue string
+	Values   []string
+	// All is true when the All option is selected
+	All bool
+}
+
+// Interpolate replaces the references to the variables in the string values of a query. The datasource and refId
+// of the query are never interpolated.
+func Interpolate(query *simplejson.Json, variables map[string]*Variable) {
+	if len(variables) == 0 {
+		return
+	}
+
+	dsType := query.Get("datasource").Get("type").MustString()
+	model, err := query.Map()
+	if err != nil {
+		return
+	}
+
+	for key, value := range model {
+		if key == "datasource" || key == "refId" {
+			continue
+		}
+		model[key] = interpolateValue(value, variables, dsType)
+	}
+}
+
+func interpolateValue(value any, variables map[string]*Variable, dsType string) any {
+	switch v := value.(type) {
+	case string:
+		return interpolateString(v, variables, dsType)
+	case map[string]any:
+		for key, nested := range v {
+			v[key] = interpolateValue(nested, variables, dsType)
+		}
+		return v
+	case []any:
+		for i, nested := range v {
+			v[i] = interpolateValue(nested, variables, dsType)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func interpolateString(s string, variables map[string]*Variable, dsType string) string {
+	if !strings.ContainsAny(s, "$[") {
+		return s
+	}
+
+	return variableReferenceRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		groups := variableReferenceRegexp.FindStringSubmatch(match)
+		name := groups[1] + groups[2] + groups[4]
+		format := groups[3] + groups[6]
+
+		// field paths are only used by data links, unknown variables are left as they are
+		v, ok := variables[name]
+		if !ok || groups[5] != "" {
+			return match
+		}
+
+		return FormatValues(v, format, dsType)
+	})
+}
+
+// FormatValues formats the values of a variable like the frontend does, using the default format of the datasource
+// when none is given
+func FormatValues(v *Variable, format string, dsType string) string {
+	if v.All && v.AllValue != "" {
+		return v.AllValue
+	}
+
+	values := v.Values
+	if format == "" {
+		switch {
+		case !v.Multi && !v.IncludeAll:
+			format = "raw"
+		case slices.Contains(regexDatasourceTypes, dsType):
+			format = "regex"
+		case slices.Contains(sqlDatasourceTypes, dsType):
+			format = "sqlstring"
+		default:
+			format = "glob"
+		}
+	}
+
+	switch format {
+	case "csv", "raw":
+		return strings.Join(values, ",")
+	case "pipe":
+		return strings.Join(values, "|")
+	case "text":
+		return strings.Join(values, " + ")
+	case "regex":
+		escaped := make([]string, len(values))
+		for i, value := range values {
+			escaped[i] = regexp.QuoteMeta(value)
+		}
+		if len(escaped) == 1 {
+			return escaped[0]
+		}
+		return "(" + strings.Join(escaped, "|") + ")"
+	case "glob":
+		if len(values) == 1 {
+			return values[0]
+		}
+		return "{" + strings.Join(values, ",") + "}"
+	case "json":
+		var b []byte
+		if len(values) == 1 {
+			b, _ = json.Marshal(values[0])
+		} else {
+			b, _ = json.Marshal(values)
+		}
+		return string(b)
+	case "singlequote":
+		return quoteValues(values, "'", `\'`)
+	case "doublequote":
+		return quoteValues(values, `"`, `\"`)
+	case "sqlstring":
+		return quoteValues(values, "'", "''")
+	case "queryparam":
+		params := make([]string, len(values))
+		for i, value := range values {
+			params[i] = "var-" + url.QueryEscape(v.Name) + "=" + url.QueryEscape(value)
+		}
+		return strings.Join(params, "&")
+	case "percentencode":
+		if len(values) == 1 {
+			return url.QueryEscape(values[0])
+		}
+		return url.QueryEscape("{" + strings.Join(values, ",") + "}")
+	default:
+		return strings.Join(values, ",")
+	}
+}
+
+func quoteValues(values []string, quote string, escapedQuote string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote + strings.ReplaceAll(value, quote, escapedQuote) + quote
+	}
+	return strings.Join(quoted, ",")
+}
@@ -0,0 +1,55 @@
+package dashboardvariables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestInterpolate(t *testing.T) {
+	variables := map[string]*Variable{
+		"env":    {Name: "env", Values: []string{"prod"}},
+		"region": {Name: "region", Multi: true, IncludeAll: true, Values: []string{"eu", "us"}},
+	}
+
+	testCases := []struct {
+		name     string
+		dsType   string
+		query    string
+		expected string
+	}{
+		{name: "single value", dsType: "prometheus", query: `up{env="$env"}`, expected: `up{env="prod"}`},
+		{name: "multiple values for prometheus", dsType: "prometheus", query: `up{region=~"${region}"}`, expected: `up{region=~"(eu|us)"}`},
+		{name: "multiple values for sql", dsType: "grafana-postgresql-datasource", query: `WHERE region IN ($region)`, expected: `WHERE region IN ('eu','us')`},
+		{name: "multiple values for other datasources", dsType: "graphite", query: `app.[[region]].cpu`, expected: `app.{eu,us}.cpu`},
+		{name: "format", dsType: "prometheus", query: `${region:csv} ${region:pipe} ${region:json}`, expected: `eu,us eu|us ["eu","us"]`},
+		{name: "unknown variables", dsType: "prometheus", query: `rate(up[$__rate_interval]) $other`, expected: `rate(up[$__rate_interval]) $other`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query := simplejson.NewFromAny(map[string]any{
+				"refId":      "A",
+				"datasource": map[string]any{"type": tc.dsType, "uid": "$env"},
+				"expr":       tc.query,
+			})
+
+			Interpolate(query, variables)
+
+			assert.Equal(t, tc.expected, query.Get("expr").MustString())
+			assert.Equal(t, "$env", query.Get("datasource").Get("uid").MustString())
+		})
+	}
+
+	t.Run("uses the all value", func(t *testing.T) {
+		query := simplejson.NewFromAny(map[string]any{
+			"datasource": map[string]any{"type": "prometheus"},
+			"nested":     map[string]any{"filters": []any{`region=~"$region"`}},
+		})
+
+		Interpolate(query, map[string]*Variable{"region": {Name: "region", Multi: true, IncludeAll: true, AllValue: ".*", Values: []string{"eu"}, All: true}})
+
+		assert.Equal(t, `region=~".*"`, query.Get("nested").Get("filters").GetIndex(0).MustString())
+	})
+}
@@ -0,0 +1,14 @@
+package dashboardvariables
+
+import (
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+// StringValues returns the values of a variable value, like the value of its current selection or of its options,
+// which is either a string or a list of strings
+func StringValues(value *simplejson.Json) []string {
+	if s, err := value.String(); err == nil {
+		return []string{s}
+	}
+	return value.MustStringArray()
+}
@@ -0,0 +1,15 @@
+package dashboardvariables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/grafana/grafana/pkg/components/simplejson"
+)
+
+func TestStringValues(t *testing.T) {
+	assert.Equal(t, []string{"prod"}, StringValues(simplejson.NewFromAny("prod")))
+	assert.Equal(t, []string{"eu", "us"}, StringValues(simplejson.NewFromAny([]any{"eu", "us"})))
+	assert.Empty(t, StringValues(simplejson.New().Get("missing")))
+}
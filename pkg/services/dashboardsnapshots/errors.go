@@ -7,3 +7,5 @@ import (
 var ErrBaseNotFound = errutil.NotFound("dashboardsnapshots.not-found", errutil.WithPublicMessage("Snapshot not found"))
 
 var ErrDashboardSnapshotAlreadyExists = errutil.Conflict("dashboardsnapshots.keyAlreadyExists", errutil.WithPublicMessage("Snapshot key already exists"))
+
+var ErrRenderInvalidTimeRange = errutil.BadRequest("dashboardsnapshots.renderInvalidTimeRange", errutil.WithPublicMessage("Invalid time range"))
@@ -75,6 +75,36 @@ type CreateDashboardSnapshotCommand struct {
 	DashboardEncrypted []byte `json:"-"`
 }
 
+// swagger:model
+type RenderDashboardSnapshotCommand struct {
+	// Snapshot name
+	// required:false
+	Name string `json:"name"`
+
+	// When the snapshot should expire in seconds. Default is never to expire.
+	// required:false
+	// default:0
+	Expires int64 `json:"expires"`
+
+	// Save the snapshot on an external server rather than locally.
+	// required:false
+	// default: false
+	External bool `json:"external"`
+
+	// Start of the time range of the snapshot, absolute in epoch milliseconds or relative like `now-6h`. Defaults to the
+	// time range of the dashboard.
+	// required:false
+	From string `json:"from"`
+
+	// End of the time range of the snapshot. Defaults to the time range of the dashboard.
+	// required:false
+	To string `json:"to"`
+
+	// Values of the template variables by variable name. Variables without values use their current value.
+	// required:false
+	Variables map[string][]string `json:"variables"`
+}
+
 type DeleteDashboardSnapshotCommand struct {
 	DeleteKey string `json:"-"`
 }
@@ -0,0 +1,630 @@
+package dashboardsnapshots
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	common "github.com/grafana/grafana/pkg/apimachinery/apis/common/v0alpha1"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/dashboards/dashboardvariables"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/libraryelements"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/query"
+)
+
+// defaultMaxDataPoints is used for panels without max data points, the frontend uses the width of the panel instead
+const defaultMaxDataPoints = 1000
+
+// dashboardDatasourceUID is the uid of the Dashboard datasource, which reuses the results of another panel
+const dashboardDatasourceUID = "-- Dashboard --"
+
+var rlog = log.New("dashboardsnapshots.render")
+
+// RenderServices are the services used to render a dashboard to a snapshot
+type RenderServices struct {
+	QueryData       query.Service
+	LibraryElements libraryelements.Service
+	DataSources     datasources.DataSourceService
+}
+
+// RenderDashboard runs the queries of every panel of a dashboard with the permissions of the user, and returns the
+// dashboard with the results embedded in the panels, the same way the frontend creates snapshots. Dashboards using the
+// v2 schema are returned in the v2 schema.
+func RenderDashboard(ctx context.Context, svcs RenderServices, user identity.Requester, dashboard *simplejson.Json, cmd RenderDashboardSnapshotCommand) (*common.Unstructured, error) {
+	_, v2 := dashboard.CheckGet("elements")
+
+	timeSettings := dashboard.Get("time")
+	if v2 {
+		timeSettings = dashboard.Get("timeSettings")
+	}
+	from := cmd.From
+	if from == "" {
+		from = timeSettings.Get("from").MustString("now-6h")
+	}
+	to := cmd.To
+	if to == "" {
+		to = timeSettings.Get("to").MustString("now")
+	}
+	timeRange := gtime.NewTimeRange(from, to)
+	fromTime, err := timeRange.ParseFrom()
+	if err != nil {
+		return nil, ErrRenderInvalidTimeRange.Errorf("invalid from %q: %w", from, err)
+	}
+	toTime, err := timeRange.ParseTo()
+	if err != nil {
+		return nil, ErrRenderInvalidTimeRange.Errorf("invalid to %q: %w", to, err)
+	}
+	if !fromTime.Before(toTime) {
+		return nil, ErrRenderInvalidTimeRange.Errorf("from %s is not before to %s", fromTime, toTime)
+	}
+
+	r := &dashboardRenderer{
+		svcs:    svcs,
+		user:    user,
+		from:    fromTime,
+		to:      toTime,
+		results: make(map[int64]panelResult),
+	}
+	if v2 {
+		var variables []*simplejson.Json
+		for _, obj := range dashboard.Get("variables").MustArray() {
+			variables = append(variables, simplejson.NewFromAny(obj).Get("spec"))
+		}
+		r.variables = snapshotVariables(variables, cmd.Variables, "never")
+		if err := r.renderElements(ctx, dashboard.Get("elements").MustMap()); err != nil {
+			return nil, err
+		}
+	} else {
+		var variables []*simplejson.Json
+		for _, obj := range dashboard.Get("templating").Get("list").MustArray() {
+			variables = append(variables, simplejson.NewFromAny(obj))
+		}
+		r.variables = snapshotVariables(variables, cmd.Variables, 0)
+		if err := r.renderPanels(ctx, dashboard.Get("panels").MustArray()); err != nil {
+			return nil, err
+		}
+	}
+	// the panels using the Dashboard datasource are rendered once the results of all the panels are known
+	for _, resolve := range r.pending {
+		resolve()
+	}
+
+	if v2 {
+		dashboard.Get("timeSettings").Set("from", formatSnapshotTime(fromTime))
+		dashboard.Get("timeSettings").Set("to", formatSnapshotTime(toTime))
+		dashboard.Set("annotations", snapshotAnnotationsV2(dashboard))
+	} else {
+		dashboard.Set("time", map[string]any{"from": formatSnapshotTime(fromTime), "to": formatSnapshotTime(toTime)})
+		dashboard.Get("annotations").Set("list", snapshotAnnotations(dashboard))
+	}
+	dashboard.Set("links", []any{})
+
+	b, err := dashboard.Encode()
+	if err != nil {
+		return nil, err
+	}
+	result := &common.Unstructured{}
+	if err := result.UnmarshalJSON(b); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+type dashboardRenderer struct {
+	svcs      RenderServices
+	user      identity.Requester
+	from      time.Time
+	to        time.Time
+	variables map[string]*dashboardvariables.Variable
+
+	// the datasources of the org, loaded to find the default datasource
+	datasources []*datasources.DataSource
+	// the results of the panels by id, reused by the panels using the Dashboard datasource
+	results map[int64]panelResult
+	pending []func()
+}
+
+type panelResult struct {
+	frames          data.Frames
+	transformations []any
+}
+
+// renderPanels replaces the queries of the panels with the snapshot of their results
+func (r *dashboardRenderer) renderPanels(ctx context.Context, panels []any) error {
+	for _, obj := range panels {
+		panel := simplejson.NewFromAny(obj)
+
+		if uid := panel.Get("libraryPanel").Get("uid").MustString(); uid != "" {
+			libraryPanel, err := r.libraryPanel(ctx, uid)
+			if err != nil {
+				return err
+			}
+			if libraryPanel == nil {
+				continue
+			}
+			// the library panel replaces the panel, except for its position in the dashboard
+			for key := range panel.MustMap() {
+				if key != "id" && key != "gridPos" {
+					panel.Del(key)
+				}
+			}
+			for key, value := range libraryPanel.MustMap() {
+				if key != "id" && key != "gridPos" && key != "libraryPanel" {
+					panel.Set(key, value)
+				}
+			}
+		}
+
+		if _, ok := panel.CheckGet("links"); ok {
+			panel.Set("links", []any{})
+		}
+
+		// the panels of collapsed rows are nested in the row
+		if panel.Get("type").MustString() == "row" {
+			if err := r.renderPanels(ctx, panel.Get("panels").MustArray()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, ok := panel.CheckGet("targets"); !ok {
+			continue
+		}
+
+		transformations, _ := panel.Get("transformations").Array()
+		err := r.renderPanel(ctx, panel.Get("id").MustInt64(), panel, transformations, func(frames data.Frames, transformations []any) {
+			datasource := map[string]any{"type": "datasource", "uid": "grafana"}
+			panel.Set("datasource", datasource)
+			panel.Set("targets", []any{
+				map[string]any{
+					"refId":      "A",
+					"datasource": datasource,
+					"queryType":  "snapshot",
+					"snapshot":   frames,
+				},
+			})
+			if transformations != nil {
+				panel.Set("transformations", transformations)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderElements replaces the queries of the panels of a v2 dashboard with the snapshot of their results. Library
+// panels are replaced with the panels they reference.
+func (r *dashboardRenderer) renderElements(ctx context.Context, elements map[string]any) error {
+	for name, obj := range elements {
+		element := simplejson.NewFromAny(obj)
+		spec := element.Get("spec")
+
+		// panel is the panel in the v1 schema, used to run the queries
+		var panel *simplejson.Json
+		switch element.Get("kind").MustString() {
+		case "LibraryPanel":
+			libraryPanel, err := r.libraryPanel(ctx, spec.Get("libraryPanel").Get("uid").MustString())
+			if err != nil {
+				return err
+			}
+			if libraryPanel == nil {
+				continue
+			}
+			element = libraryPanelElement(spec, libraryPanel)
+			elements[name] = element.Interface()
+			spec = element.Get("spec")
+			panel = libraryPanel
+		case "Panel":
+			if _, ok := spec.CheckGet("links"); ok {
+				spec.Set("links", []any{})
+			}
+			var targets []any
+			for _, query := range spec.Get("data").Get("spec").Get("queries").MustArray() {
+				targets = append(targets, panelQueryTarget(simplejson.NewFromAny(query).Get("spec")))
+			}
+			panel = simplejson.NewFromAny(map[string]any{
+				"title":         spec.Get("title").Interface(),
+				"maxDataPoints": spec.Get("data").Get("spec").Get("queryOptions").Get("maxDataPoints").Interface(),
+				"targets":       targets,
+			})
+		default:
+			continue
+		}
+
+		if len(panel.Get("targets").MustArray()) == 0 {
+			continue
+		}
+
+		queryGroup := spec.Get("data").Get("spec")
+		transformations, _ := queryGroup.Get("transformations").Array()
+		err := r.renderPanel(ctx, spec.Get("id").MustInt64(), panel, transformations, func(frames data.Frames, transformations []any) {
+			queryGroup.Set("queries", []any{
+				map[string]any{
+					"kind": "PanelQuery",
+					"spec": map[string]any{
+						"refId":  "A",
+						"hidden": false,
+						"query": map[string]any{
+							"kind":       "DataQuery",
+							"group":      "grafana",
+							"version":    "v0",
+							"datasource": map[string]any{"name": "grafana"},
+							"spec": map[string]any{
+								"queryType": "snapshot",
+								"snapshot":  frames,
+							},
+						},
+					},
+				},
+			})
+			if transformations != nil {
+				queryGroup.Set("transformations", transformations)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderPanel runs the queries of a panel and sets its results with set. Panels using the Dashboard datasource reuse
+// the results and, when requested, the transformations of their source panel, which are set once all the panels are
+// rendered.
+func (r *dashboardRenderer) renderPanel(ctx context.Context, id int64, panel *simplejson.Json, transformations []any, set func(frames data.Frames, transformations []any)) error {
+	if sourceID, withTransforms, ok := dashboardDatasourceQuery(panel); ok {
+		r.pending = append(r.pending, func() {
+			source := r.results[sourceID]
+			frames := source.frames
+			if frames == nil {
+				frames = data.Frames{}
+			}
+			if withTransforms && len(source.transformations) > 0 {
+				transformations = append(slices.Clone(source.transformations), transformations...)
+			}
+			set(frames, transformations)
+		})
+		return nil
+	}
+
+	frames, err := r.queryPanel(ctx, panel)
+	if err != nil {
+		return err
+	}
+	r.results[id] = panelResult{frames: frames, transformations: transformations}
+	set(frames, transformations)
+
+	return nil
+}
+
+// libraryPanel returns the model of a library panel, or nil when it no longer exists
+func (r *dashboardRenderer) libraryPanel(ctx context.Context, uid string) (*simplejson.Json, error) {
+	element, err := r.svcs.LibraryElements.GetElement(ctx, r.user, model.GetLibraryElementCommand{UID: uid})
+	if err != nil {
+		if errors.Is(err, model.ErrLibraryElementNotFound) {
+			rlog.Warn("Library panel not found, leaving it without data in the snapshot", "uid", uid)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return simplejson.NewJson(element.Model)
+}
+
+// queryPanel runs the queries of a panel. Queries failing are logged and left out of the snapshot, while errors of the
+// request, like missing datasource permissions, fail the snapshot.
+func (r *dashboardRenderer) queryPanel(ctx context.Context, panel *simplejson.Json) (data.Frames, error) {
+	maxDataPoints := panel.Get("maxDataPoints").MustInt64(defaultMaxDataPoints)
+	if maxDataPoints <= 0 {
+		maxDataPoints = defaultMaxDataPoints
+	}
+	intervalMs := max(r.to.Sub(r.from).Milliseconds()/maxDataPoints, 1)
+
+	targets := panel.Get("targets").MustArray()
+	hasExpression := slices.ContainsFunc(targets, func(obj any) bool {
+		return expr.NodeTypeFromDatasourceUID(datasourceUID(simplejson.NewFromAny(obj))) == expr.TypeCMDNode
+	})
+
+	var queries []*simplejson.Json
+	for _, obj := range targets {
+		query := simplejson.NewFromAny(obj)
+
+		// hidden queries are only needed by expressions, which remove their results
+		if !hasExpression && query.Get("hide").MustBool() {
+			continue
+		}
+
+		if datasourceUID(query) == "" && query.Get("datasourceId").MustInt64() == 0 {
+			if query.Get("datasource").Get("type").MustString() == "" {
+				query.Set("datasource", panel.Get("datasource").Interface())
+			}
+			// like in the frontend, queries without datasource use the default datasource
+			if datasourceUID(query) == "" {
+				datasource, err := r.defaultDatasource(ctx, query.Get("datasource").Get("type").MustString())
+				if err != nil {
+					return nil, err
+				}
+				query.Set("datasource", datasource)
+			}
+		}
+		dashboardvariables.Interpolate(query, r.variables)
+		query.Set("intervalMs", intervalMs)
+		query.Set("maxDataPoints", maxDataPoints)
+
+		queries = append(queries, query)
+	}
+
+	frames := data.Frames{}
+	if len(queries) == 0 {
+		return frames, nil
+	}
+
+	res, err := r.svcs.QueryData.QueryData(ctx, r.user, false, dtos.MetricRequest{
+		From:    strconv.FormatInt(r.from.UnixMilli(), 10),
+		To:      strconv.FormatInt(r.to.UnixMilli(), 10),
+		Queries: queries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range queries {
+		refID := query.Get("refId").MustString()
+		resp, ok := res.Responses[refID]
+		if !ok {
+			continue
+		}
+		if resp.Error != nil {
+			rlog.Warn("Query failed, leaving its results out of the snapshot", "panel", panel.Get("title").MustString(), "refId", refID, "error", resp.Error)
+			continue
+		}
+		for _, frame := range resp.Frames {
+			frame.RefID = refID
+			frames = append(frames, frame)
+		}
+	}
+
+	return frames, nil
+}
+
+// defaultDatasource returns the reference to the default datasource of the org, or to the first datasource of the
+// type when the default datasource is of another type. Like in the frontend, the Grafana datasource is the default
+// when the org has none.
+func (r *dashboardRenderer) defaultDatasource(ctx context.Context, dsType string) (map[string]any, error) {
+	if r.datasources == nil {
+		dataSources, err := r.svcs.DataSources.GetDataSources(ctx, &datasources.GetDataSourcesQuery{OrgID: r.user.GetOrgID()})
+		if err != nil {
+			return nil, err
+		}
+		r.datasources = dataSources
+	}
+
+	var found *datasources.DataSource
+	for _, ds := range r.datasources {
+		if dsType != "" && ds.Type != dsType {
+			continue
+		}
+		if ds.IsDefault {
+			found = ds
+			break
+		}
+		if found == nil && dsType != "" {
+			found = ds
+		}
+	}
+	if found == nil {
+		return map[string]any{"type": "datasource", "uid": "grafana"}, nil
+	}
+
+	return map[string]any{"type": found.Type, "uid": found.UID}, nil
+}
+
+// dashboardDatasourceQuery returns the id of the panel whose results are reused by a panel using the Dashboard
+// datasource, and whether its transformations are reused as well
+func dashboardDatasourceQuery(panel *simplejson.Json) (int64, bool, bool) {
+	for _, obj := range panel.Get("targets").MustArray() {
+		query := simplejson.NewFromAny(obj)
+		uid := datasourceUID(query)
+		if uid == "" {
+			uid = datasourceUID(panel)
+		}
+		if uid == dashboardDatasourceUID {
+			return query.Get("panelId").MustInt64(), query.Get("withTransforms").MustBool(), true
+		}
+	}
+	return 0, false, false
+}
+
+// panelQueryTarget returns a v2 panel query as a query of the v1 schema
+func panelQueryTarget(spec *simplejson.Json) map[string]any {
+	query := spec.Get("query")
+	target := make(map[string]any)
+	for key, value := range query.Get("spec").MustMap() {
+		target[key] = value
+	}
+	target["refId"] = spec.Get("refId").MustString("A")
+	target["hide"] = spec.Get("hidden").MustBool()
+
+	if query.Get("kind").MustString() == "DataQuery" {
+		datasource := map[string]any{"type": query.Get("group").MustString()}
+		if uid := query.Get("datasource").Get("name").MustString(); uid != "" {
+			datasource["uid"] = uid
+		}
+		target["datasource"] = datasource
+	} else if _, ok := spec.CheckGet("datasource"); ok {
+		// v2alpha1 references the datasource in the panel query, and the kind of the query is the datasource type
+		target["datasource"] = spec.Get("datasource").Interface()
+	} else {
+		target["datasource"] = map[string]any{"type": query.Get("kind").MustString()}
+	}
+
+	return target
+}
+
+// libraryPanelElement returns the v2 panel element of the model of a library panel, without queries
+func libraryPanelElement(spec *simplejson.Json, libraryPanel *simplejson.Json) *simplejson.Json {
+	transformations := []any{}
+	for _, obj := range libraryPanel.Get("transformations").MustArray() {
+		transformation := simplejson.NewFromAny(obj)
+		transformationSpec := make(map[string]any)
+		for _, key := range []string{"disabled", "filter", "topic", "options"} {
+			if value, ok := transformation.CheckGet(key); ok {
+				transformationSpec[key] = value.Interface()
+			}
+		}
+		transformations = append(transformations, map[string]any{
+			"kind":  "Transformation",
+			"group": transformation.Get("id").MustString(),
+			"spec":  transformationSpec,
+		})
+	}
+
+	fieldConfig := libraryPanel.Get("fieldConfig").MustMap(map[string]any{"defaults": map[string]any{}, "overrides": []any{}})
+	return simplejson.NewFromAny(map[string]any{
+		"kind": "Panel",
+		"spec": map[string]any{
+			"id":          spec.Get("id").Interface(),
+			"title":       spec.Get("title").MustString(libraryPanel.Get("title").MustString()),
+			"description": libraryPanel.Get("description").MustString(),
+			"links":       []any{},
+			"transparent": libraryPanel.Get("transparent").MustBool(),
+			"data": map[string]any{
+				"kind": "QueryGroup",
+				"spec": map[string]any{
+					"queries":         []any{},
+					"transformations": transformations,
+					"queryOptions":    map[string]any{},
+				},
+			},
+			"vizConfig": map[string]any{
+				"kind":    "VizConfig",
+				"group":   libraryPanel.Get("type").MustString(),
+				"version": libraryPanel.Get("pluginVersion").MustString(),
+				"spec": map[string]any{
+					"options":     libraryPanel.Get("options").MustMap(map[string]any{}),
+					"fieldConfig": fieldConfig,
+				},
+			},
+		},
+	})
+}
+
+func datasourceUID(query *simplejson.Json) string {
+	if uid := query.Get("datasource").Get("uid").MustString(); uid != "" {
+		return uid
+	}
+	// before 8.3 special types could be sent as datasource (expr)
+	return query.Get("datasource").MustString()
+}
+
+// snapshotVariables returns the values to interpolate the queries with, and saves them as the only option of the
+// variables of the snapshot like the frontend does
+func snapshotVariables(models []*simplejson.Json, requested map[string][]string, noRefresh any) map[string]*dashboardvariables.Variable {
+	variables := make(map[string]*dashboardvariables.Variable)
+	for _, variable := range models {
+		v := &dashboardvariables.Variable{
+			Name:       variable.Get("name").MustString(),
+			Multi:      variable.Get("multi").MustBool(),
+			IncludeAll: variable.Get("includeAll").MustBool(),
+			AllValue:   variable.Get("allValue").MustString(),
+		}
+
+		values, ok := requested[v.Name]
+		if !ok || len(values) == 0 {
+			values = dashboardvariables.StringValues(variable.Get("current").Get("value"))
+		}
+
+		current := map[string]any{"text": values, "value": values}
+		if slices.Contains(values, dashboardvariables.AllValue) {
+			// All uses the options saved in the dashboard
+			v.All = true
+			values = nil
+			for _, option := range variable.Get("options").MustArray() {
+				for _, value := range dashboardvariables.StringValues(simplejson.NewFromAny(option).Get("value")) {
+					if value != dashboardvariables.AllValue {
+						values = append(values, value)
+					}
+				}
+			}
+			current = map[string]any{"text": "All", "value": dashboardvariables.AllValue}
+		}
+		v.Values = values
+		variables[v.Name] = v
+
+		variable.Set("current", current)
+		variable.Set("options", []any{current})
+		if _, ok := variable.CheckGet("query"); ok {
+			variable.Set("query", "")
+		}
+		if _, ok := variable.CheckGet("refresh"); ok {
+			variable.Set("refresh", noRefresh)
+		}
+	}
+
+	return variables
+}
+
+// snapshotAnnotations returns the enabled annotations of a dashboard without their queries
+func snapshotAnnotations(dashboard *simplejson.Json) []any {
+	annotations := []any{}
+	for _, obj := range dashboard.Get("annotations").Get("list").MustArray() {
+		annotation := simplejson.NewFromAny(obj)
+		if !annotation.Get("enable").MustBool() {
+			continue
+		}
+		annotations = append(annotations, map[string]any{
+			"name":         annotation.Get("name").Interface(),
+			"enable":       true,
+			"iconColor":    annotation.Get("iconColor").Interface(),
+			"type":         annotation.Get("type").Interface(),
+			"builtIn":      annotation.Get("builtIn").Interface(),
+			"hide":         annotation.Get("hide").Interface(),
+			"snapshotData": []any{},
+		})
+	}
+	return annotations
+}
+
+// snapshotAnnotationsV2 returns the enabled annotations of a v2 dashboard like the frontend does
+func snapshotAnnotationsV2(dashboard *simplejson.Json) []any {
+	annotations := []any{}
+	for _, obj := range dashboard.Get("annotations").MustArray() {
+		spec := simplejson.NewFromAny(obj).Get("spec")
+		if !spec.Get("enable").MustBool() {
+			continue
+		}
+		annotations = append(annotations, map[string]any{
+			"kind": "AnnotationQuery",
+			"spec": map[string]any{
+				"name":      spec.Get("name").Interface(),
+				"enable":    true,
+				"iconColor": spec.Get("iconColor").Interface(),
+				"builtIn":   spec.Get("builtIn").Interface(),
+				"hide":      spec.Get("hide").Interface(),
+				"query":     spec.Get("query").Interface(),
+			},
+		})
+	}
+	return annotations
+}
+
+// formatSnapshotTime formats times like the ISO strings of the frontend
+func formatSnapshotTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
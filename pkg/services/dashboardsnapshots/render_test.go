@@ -0,0 +1,373 @@
+package dashboardsnapshots
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	datasourcesfake "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	libraryelementsfake "github.com/grafana/grafana/pkg/services/libraryelements/fake"
+	"github.com/grafana/grafana/pkg/services/libraryelements/model"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+const renderTestDashboard = `
+{
+  "uid": "dash",
+  "title": "Dashboard",
+  "time": {"from": "1700000000000", "to": "1700003600000"},
+  "links": [{"title": "link"}],
+  "annotations": {"list": [
+    {"name": "Annotations & Alerts", "enable": true, "builtIn": 1, "type": "dashboard", "target": {"limit": 100}},
+    {"name": "Disabled", "enable": false}
+  ]},
+  "templating": {"list": [
+    {"name": "env", "type": "custom", "query": "prod,dev", "current": {"text": "prod", "value": "prod"}, "options": [{"text": "prod", "value": "prod"}, {"text": "dev", "value": "dev"}]},
+    {"name": "region", "type": "custom", "multi": true, "includeAll": true, "query": "eu,us", "current": {"text": "All", "value": ["$__all"]}, "options": [{"text": "All", "value": "$__all"}, {"text": "eu", "value": "eu"}, {"text": "us", "value": "us"}]}
+  ]},
+  "panels": [
+    {
+      "id": 1,
+      "type": "timeseries",
+      "title": "Panel",
+      "datasource": {"type": "prometheus", "uid": "prom"},
+      "links": [{"title": "link"}],
+      "targets": [
+        {"refId": "A", "expr": "up{env=\"$env\", region=~\"$region\"}"},
+        {"refId": "B", "expr": "hidden", "hide": true}
+      ]
+    },
+    {"id": 2, "type": "text", "title": "Text"},
+    {
+      "id": 3,
+      "type": "row",
+      "collapsed": true,
+      "panels": [
+        {"id": 4, "type": "stat", "title": "Nested", "targets": [{"refId": "A", "datasource": {"type": "loki", "uid": "loki"}, "expr": "{env=\"$env\"}"}]}
+      ]
+    }
+  ]
+}`
+
+const renderTestDashboardV2 = `
+{
+  "title": "Dashboard",
+  "timeSettings": {"from": "1700000000000", "to": "1700003600000", "timezone": "utc"},
+  "links": [{"title": "link"}],
+  "annotations": [
+    {"kind": "AnnotationQuery", "spec": {"name": "Annotations & Alerts", "enable": true, "hide": true, "iconColor": "blue", "builtIn": true, "query": {"kind": "DataQuery", "group": "grafana", "version": "v0", "spec": {"limit": 100}}}},
+    {"kind": "AnnotationQuery", "spec": {"name": "Disabled", "enable": false, "hide": false, "iconColor": "red", "query": {"kind": "DataQuery", "group": "loki", "version": "v0", "spec": {}}}}
+  ],
+  "variables": [
+    {"kind": "CustomVariable", "spec": {"name": "env", "query": "prod,dev", "current": {"text": "prod", "value": "prod"}, "options": [{"text": "prod", "value": "prod"}, {"text": "dev", "value": "dev"}]}}
+  ],
+  "elements": {
+    "panel-1": {"kind": "Panel", "spec": {
+      "id": 1,
+      "title": "Panel",
+      "links": [{"title": "link", "url": "http://localhost"}],
+      "data": {"kind": "QueryGroup", "spec": {
+        "queries": [
+          {"kind": "PanelQuery", "spec": {"refId": "A", "hidden": false, "query": {"kind": "DataQuery", "group": "prometheus", "version": "v0", "datasource": {"name": "prom"}, "spec": {"expr": "up{env=\"$env\"}"}}}},
+          {"kind": "PanelQuery", "spec": {"refId": "B", "hidden": true, "query": {"kind": "DataQuery", "group": "prometheus", "version": "v0", "datasource": {"name": "prom"}, "spec": {"expr": "hidden"}}}}
+        ],
+        "transformations": [],
+        "queryOptions": {}
+      }},
+      "vizConfig": {"kind": "VizConfig", "group": "timeseries", "version": "", "spec": {"options": {}, "fieldConfig": {"defaults": {}, "overrides": []}}}
+    }},
+    "panel-2": {"kind": "LibraryPanel", "spec": {"id": 2, "title": "Library", "libraryPanel": {"uid": "lib", "name": "Library"}}}
+  },
+  "layout": {"kind": "GridLayout", "spec": {"items": []}}
+}`
+
+const renderTestLibraryPanel = `
+{
+  "type": "stat",
+  "title": "Library",
+  "datasource": {"type": "prometheus", "uid": "prom"},
+  "targets": [{"refId": "A", "expr": "library"}],
+  "transformations": [{"id": "reduce", "options": {}}],
+  "options": {"colorMode": "value"}
+}`
+
+func newRenderTestDashboard(t *testing.T) *simplejson.Json {
+	t.Helper()
+
+	dashboard, err := simplejson.NewJson([]byte(renderTestDashboard))
+	require.NoError(t, err)
+
+	return dashboard
+}
+
+func newRenderTestServices(t *testing.T, qds query.Service) RenderServices {
+	t.Helper()
+
+	libraryElements := &libraryelementsfake.LibraryElementService{}
+	_, err := libraryElements.CreateElement(context.Background(), &user.SignedInUser{OrgID: 1}, model.CreateLibraryElementCommand{
+		UID:   "lib",
+		Name:  "Library",
+		Kind:  int64(model.PanelElement),
+		Model: []byte(renderTestLibraryPanel),
+	})
+	require.NoError(t, err)
+
+	return RenderServices{
+		QueryData:       qds,
+		LibraryElements: libraryElements,
+		DataSources: &datasourcesfake.FakeDataSourceService{DataSources: []*datasources.DataSource{
+			{OrgID: 1, UID: "loki", Type: "loki"},
+			{OrgID: 1, UID: "default", Type: "prometheus", IsDefault: true},
+			{OrgID: 2, UID: "other", Type: "prometheus", IsDefault: true},
+		}},
+	}
+}
+
+// renderTestResult converts the result of RenderDashboard back to JSON
+func renderTestResult(t *testing.T, result any) *simplejson.Json {
+	t.Helper()
+
+	b, err := json.Marshal(result)
+	require.NoError(t, err)
+	rendered, err := simplejson.NewJson(b)
+	require.NoError(t, err)
+
+	return rendered
+}
+
+func frameResponse(name string) *backend.QueryDataResponse {
+	return &backend.QueryDataResponse{Responses: backend.Responses{
+		"A": backend.DataResponse{Frames: data.Frames{data.NewFrame(name, data.NewField("value", nil, []float64{1}))}},
+	}}
+}
+
+func TestRenderDashboard(t *testing.T) {
+	signedInUser := &user.SignedInUser{UserID: 1, OrgID: 1}
+
+	t.Run("embeds the results of the panel queries", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("expr").MustString() == `up{env="dev", region=~"(eu|us)"}` &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "prom" &&
+				req.From == "1700000000000" && req.To == "1700003600000"
+		})).Return(&backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.DataResponse{Frames: data.Frames{data.NewFrame("up", data.NewField("value", nil, []float64{1}))}},
+		}}, nil).Once()
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("expr").MustString() == `{env="dev"}`
+		})).Return(&backend.QueryDataResponse{Responses: backend.Responses{
+			"A": backend.DataResponse{Error: errors.New("query failed")},
+		}}, nil).Once()
+
+		result, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, newRenderTestDashboard(t), RenderDashboardSnapshotCommand{
+			Variables: map[string][]string{"env": {"dev"}},
+		})
+		require.NoError(t, err)
+		fakeQueryService.AssertExpectations(t)
+		rendered := renderTestResult(t, result)
+
+		assert.Equal(t, "2023-11-14T22:13:20.000Z", rendered.Get("time").Get("from").MustString())
+		assert.Empty(t, rendered.Get("links").MustArray())
+
+		panel := rendered.Get("panels").GetIndex(0)
+		assert.Equal(t, "grafana", panel.Get("datasource").Get("uid").MustString())
+		assert.Empty(t, panel.Get("links").MustArray())
+		require.Len(t, panel.Get("targets").MustArray(), 1)
+		target := panel.Get("targets").GetIndex(0)
+		assert.Equal(t, "snapshot", target.Get("queryType").MustString())
+		require.Len(t, target.Get("snapshot").MustArray(), 1)
+		assert.Equal(t, "up", target.Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+
+		_, ok := rendered.Get("panels").GetIndex(1).CheckGet("targets")
+		assert.False(t, ok)
+
+		nested := rendered.Get("panels").GetIndex(2).Get("panels").GetIndex(0).Get("targets").GetIndex(0)
+		assert.Equal(t, "snapshot", nested.Get("queryType").MustString())
+		assert.Empty(t, nested.Get("snapshot").MustArray())
+
+		env := rendered.Get("templating").Get("list").GetIndex(0)
+		assert.Equal(t, []string{"dev"}, env.Get("current").Get("value").MustStringArray())
+		assert.Len(t, env.Get("options").MustArray(), 1)
+		assert.Equal(t, "", env.Get("query").MustString())
+
+		annotations := rendered.Get("annotations").Get("list").MustArray()
+		require.Len(t, annotations, 1)
+		_, ok = rendered.Get("annotations").Get("list").GetIndex(0).CheckGet("target")
+		assert.False(t, ok)
+	})
+
+	t.Run("fails when the user can't query a datasource", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, datasources.ErrDataSourceAccessDenied)
+
+		_, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, newRenderTestDashboard(t), RenderDashboardSnapshotCommand{})
+		require.ErrorIs(t, err, datasources.ErrDataSourceAccessDenied)
+	})
+
+	t.Run("fails with an invalid time range", func(t *testing.T) {
+		_, err := RenderDashboard(context.Background(), newRenderTestServices(t, &query.FakeQueryService{}), signedInUser, newRenderTestDashboard(t), RenderDashboardSnapshotCommand{
+			From: "now",
+			To:   "now-1h",
+		})
+		require.ErrorIs(t, err, ErrRenderInvalidTimeRange)
+	})
+
+	t.Run("renders library panels", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("expr").MustString() == "library"
+		})).Return(frameResponse("library"), nil).Once()
+		dashboard := simplejson.NewFromAny(map[string]any{
+			"uid": "dash",
+			"panels": []any{
+				map[string]any{"id": 1, "title": "Old title", "gridPos": map[string]any{"x": 0, "y": 0, "w": 12, "h": 8}, "libraryPanel": map[string]any{"uid": "lib", "name": "Library"}},
+				map[string]any{"id": 2, "libraryPanel": map[string]any{"uid": "deleted", "name": "Deleted"}},
+			},
+		})
+
+		result, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, dashboard, RenderDashboardSnapshotCommand{})
+		require.NoError(t, err)
+		fakeQueryService.AssertExpectations(t)
+		rendered := renderTestResult(t, result)
+
+		panel := rendered.Get("panels").GetIndex(0)
+		assert.Equal(t, 1, panel.Get("id").MustInt())
+		assert.Equal(t, 12, panel.Get("gridPos").Get("w").MustInt())
+		assert.Equal(t, "stat", panel.Get("type").MustString())
+		assert.Equal(t, "Library", panel.Get("title").MustString())
+		_, ok := panel.CheckGet("libraryPanel")
+		assert.False(t, ok)
+		target := panel.Get("targets").GetIndex(0)
+		assert.Equal(t, "snapshot", target.Get("queryType").MustString())
+		assert.Equal(t, "library", target.Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+
+		deleted := rendered.Get("panels").GetIndex(1)
+		assert.Equal(t, "deleted", deleted.Get("libraryPanel").Get("uid").MustString())
+	})
+
+	t.Run("reuses the results of the source panel for the Dashboard datasource", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.Anything).Return(frameResponse("up"), nil).Once()
+		dashboardDatasource := map[string]any{"type": "datasource", "uid": "-- Dashboard --"}
+		dashboard := simplejson.NewFromAny(map[string]any{
+			"uid": "dash",
+			"panels": []any{
+				map[string]any{
+					"id":              2,
+					"datasource":      dashboardDatasource,
+					"targets":         []any{map[string]any{"refId": "A", "datasource": dashboardDatasource, "panelId": 1, "withTransforms": true}},
+					"transformations": []any{map[string]any{"id": "organize"}},
+				},
+				map[string]any{
+					"id":              1,
+					"datasource":      map[string]any{"type": "prometheus", "uid": "prom"},
+					"targets":         []any{map[string]any{"refId": "A", "expr": "up"}},
+					"transformations": []any{map[string]any{"id": "reduce"}},
+				},
+				map[string]any{
+					"id":      3,
+					"targets": []any{map[string]any{"refId": "A", "datasource": dashboardDatasource, "panelId": 1}},
+				},
+			},
+		})
+
+		result, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, dashboard, RenderDashboardSnapshotCommand{})
+		require.NoError(t, err)
+		fakeQueryService.AssertExpectations(t)
+		rendered := renderTestResult(t, result)
+
+		withTransforms := rendered.Get("panels").GetIndex(0)
+		assert.Equal(t, "grafana", withTransforms.Get("datasource").Get("uid").MustString())
+		assert.Equal(t, "up", withTransforms.Get("targets").GetIndex(0).Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+		assert.Equal(t, "reduce", withTransforms.Get("transformations").GetIndex(0).Get("id").MustString())
+		assert.Equal(t, "organize", withTransforms.Get("transformations").GetIndex(1).Get("id").MustString())
+
+		withoutTransforms := rendered.Get("panels").GetIndex(2)
+		assert.Equal(t, "up", withoutTransforms.Get("targets").GetIndex(0).Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+		_, ok := withoutTransforms.CheckGet("transformations")
+		assert.False(t, ok)
+	})
+
+	t.Run("uses the default datasource for queries without datasource", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 2 &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "default" &&
+				req.Queries[1].Get("datasource").Get("uid").MustString() == "loki"
+		})).Return(frameResponse("up"), nil).Once()
+		dashboard := simplejson.NewFromAny(map[string]any{
+			"uid": "dash",
+			"panels": []any{
+				map[string]any{"id": 1, "targets": []any{
+					map[string]any{"refId": "A", "expr": "up"},
+					map[string]any{"refId": "B", "datasource": map[string]any{"type": "loki"}, "expr": "{}"},
+				}},
+			},
+		})
+
+		_, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, dashboard, RenderDashboardSnapshotCommand{})
+		require.NoError(t, err)
+		fakeQueryService.AssertExpectations(t)
+	})
+
+	t.Run("renders v2 dashboards", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("expr").MustString() == `up{env="dev"}` &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "prom" &&
+				req.Queries[0].Get("datasource").Get("type").MustString() == "prometheus"
+		})).Return(frameResponse("up"), nil).Once()
+		fakeQueryService.On("QueryData", mock.Anything, signedInUser, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("expr").MustString() == "library"
+		})).Return(frameResponse("library"), nil).Once()
+		dashboard, err := simplejson.NewJson([]byte(renderTestDashboardV2))
+		require.NoError(t, err)
+
+		result, err := RenderDashboard(context.Background(), newRenderTestServices(t, fakeQueryService), signedInUser, dashboard, RenderDashboardSnapshotCommand{
+			Variables: map[string][]string{"env": {"dev"}},
+		})
+		require.NoError(t, err)
+		fakeQueryService.AssertExpectations(t)
+		rendered := renderTestResult(t, result)
+
+		assert.Equal(t, "2023-11-14T22:13:20.000Z", rendered.Get("timeSettings").Get("from").MustString())
+		assert.Equal(t, "utc", rendered.Get("timeSettings").Get("timezone").MustString())
+		assert.Empty(t, rendered.Get("links").MustArray())
+
+		panel := rendered.Get("elements").Get("panel-1").Get("spec")
+		assert.Empty(t, panel.Get("links").MustArray())
+		queries := panel.Get("data").Get("spec").Get("queries").MustArray()
+		require.Len(t, queries, 1)
+		query := simplejson.NewFromAny(queries[0]).Get("spec").Get("query")
+		assert.Equal(t, "grafana", query.Get("datasource").Get("name").MustString())
+		assert.Equal(t, "snapshot", query.Get("spec").Get("queryType").MustString())
+		assert.Equal(t, "up", query.Get("spec").Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+
+		library := rendered.Get("elements").Get("panel-2")
+		assert.Equal(t, "Panel", library.Get("kind").MustString())
+		assert.Equal(t, 2, library.Get("spec").Get("id").MustInt())
+		assert.Equal(t, "stat", library.Get("spec").Get("vizConfig").Get("group").MustString())
+		assert.Equal(t, "reduce", library.Get("spec").Get("data").Get("spec").Get("transformations").GetIndex(0).Get("group").MustString())
+		libraryQuery := library.Get("spec").Get("data").Get("spec").Get("queries").GetIndex(0).Get("spec").Get("query")
+		assert.Equal(t, "library", libraryQuery.Get("spec").Get("snapshot").GetIndex(0).Get("schema").Get("name").MustString())
+
+		env := rendered.Get("variables").GetIndex(0).Get("spec")
+		assert.Equal(t, []string{"dev"}, env.Get("current").Get("value").MustStringArray())
+		assert.Len(t, env.Get("options").MustArray(), 1)
+
+		annotations := rendered.Get("annotations").MustArray()
+		require.Len(t, annotations, 1)
+		assert.Equal(t, "Annotations & Alerts", rendered.Get("annotations").GetIndex(0).Get("spec").Get("name").MustString())
+	})
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
@@ -30,6 +31,7 @@ func ProvideService(dataSourceCache datasources.CacheService, datasourceReqValid
 	tracer tracing.Tracer,
 	secretsService secrets.Service, //nolint:staticcheck // SA1019: Legacy envelope encryption for single-tenant feature
 	features featuremgmt.FeatureToggles,
+	dsGuardian guardian.DatasourceGuardianProvider,
 ) *DataSourceProxyService {
 	return &DataSourceProxyService{
 		DataSourceCache:            dataSourceCache,
@@ -42,6 +44,7 @@ func ProvideService(dataSourceCache datasources.CacheService, datasourceReqValid
 		tracer:                     tracer,
 		secretsService:             secretsService,
 		features:                   features,
+		DatasourceGuardian:         dsGuardian,
 	}
 }
 
@@ -56,6 +59,9 @@ type DataSourceProxyService struct {
 	tracer                     tracing.Tracer
 	secretsService             secrets.Service //nolint:staticcheck // SA1019: Legacy envelope encryption for single-tenant feature
 	features                   featuremgmt.FeatureToggles
+	// DatasourceGuardian denies the proxy requests to the data sources restricted
+	// by query policies, as proxied requests can't be checked.
+	DatasourceGuardian guardian.DatasourceGuardianProvider
 }
 
 func (p *DataSourceProxyService) ProxyDataSourceRequest(c *contextmodel.ReqContext) {
@@ -117,6 +123,14 @@ func (p *DataSourceProxyService) proxyDatasourceRequest(c *contextmodel.ReqConte
 		return
 	}
 
+	if p.DatasourceGuardian != nil {
+		g := p.DatasourceGuardian.New(c.SignedInUser.GetOrgID(), c.SignedInUser, *ds)
+		if err := g.CheckDatasourceRequest(c.Req.Context(), ds); err != nil {
+			c.JsonApiErr(http.StatusForbidden, "Access denied by the data source query policies", err)
+			return
+		}
+	}
+
 	// find plugin
 	plugin, exists := p.pluginStore.Plugin(c.Req.Context(), ds.Type)
 	if !exists {
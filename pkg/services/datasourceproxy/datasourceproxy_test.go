@@ -7,13 +7,18 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/plugins"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/web"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -129,6 +134,49 @@ func TestDatasourceProxy_proxyDatasourceRequest(t *testing.T) {
 	}
 }
 
+func TestDatasourceProxy_queryPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+policies:
+  - name: team-a
+    teamIds: [2]
+    allowedMetrics: ['up']
+`), 0o600))
+	cfg := setting.NewCfg()
+	cfg.DataSourceQueryPoliciesFile = path
+	dsGuardian, err := guardian.ProvideGuardian(cfg)
+	require.NoError(t, err)
+
+	p := DataSourceProxyService{
+		DataSourceRequestValidator: &fakeDataSourceRequestValidator{},
+		pluginStore:                &pluginstore.FakePluginStore{},
+		DatasourceGuardian:         dsGuardian,
+	}
+	proxy := func(signedInUser *user.SignedInUser, ds *datasources.DataSource) int {
+		responseRecorder := httptest.NewRecorder()
+		c := &contextmodel.ReqContext{
+			Context: &web.Context{
+				Req:  &http.Request{URL: &url.URL{}},
+				Resp: web.NewResponseWriter("GET", responseRecorder),
+			},
+			SignedInUser: signedInUser,
+			Logger:       log.NewNopLogger(),
+		}
+		p.proxyDatasourceRequest(c, ds)
+		return responseRecorder.Result().StatusCode
+	}
+	prom := &datasources.DataSource{UID: "prom", OrgID: 1, Type: datasources.DS_PROMETHEUS, URL: "http://localhost:9090"}
+
+	t.Run("requests of the users the policies apply to are denied", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, proxy(&user.SignedInUser{OrgID: 1, TeamIDs: []int64{2}}, prom))
+	})
+
+	t.Run("requests of other users are proxied", func(t *testing.T) {
+		// The fake plugin store has no plugin, so a request that reaches the proxy gets a 404.
+		require.Equal(t, http.StatusNotFound, proxy(&user.SignedInUser{OrgID: 1, TeamIDs: []int64{3}}, prom))
+	})
+}
+
 type fakeDataSourceRequestValidator struct{}
 
 func (rv *fakeDataSourceRequestValidator) Validate(_ string, _ map[string]any, _ *http.Request) error {
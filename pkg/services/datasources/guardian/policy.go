@@ -0,0 +1,172 @@
+package guardian
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+// Actions a query policy takes on offending queries.
+const (
+	// PolicyActionDeny rejects offending queries.
+	PolicyActionDeny = "deny"
+	// PolicyActionRewrite enforces the label matchers of the policy on the
+	// queries. Queries that can't be rewritten are rejected.
+	PolicyActionRewrite = "rewrite"
+)
+
+// QueryPolicies is the content of the query policies file.
+type QueryPolicies struct {
+	Policies []QueryPolicy `yaml:"policies"`
+}
+
+// QueryPolicy restricts what the matching users can query from the matching data sources.
+type QueryPolicy struct {
+	Name string `yaml:"name"`
+	// OrgID limits the policy to an organization, 0 matches all organizations.
+	OrgID int64 `yaml:"orgId"`
+	// DatasourceUIDs and DatasourceTypes select the data sources of the policy.
+	// When both are empty, the PromQL rules apply to the Prometheus data sources,
+	// and the allowed tables to the MySQL, PostgreSQL and SQL Server data sources.
+	DatasourceUIDs  []string `yaml:"datasourceUids"`
+	DatasourceTypes []string `yaml:"datasourceTypes"`
+	// TeamIDs select the users of the policy. The policy matches all users when empty.
+	TeamIDs []int64 `yaml:"teamIds"`
+	// Action is deny (default) or rewrite.
+	Action string `yaml:"action"`
+	// LabelMatchers must be present on every selector of PromQL queries, e.g. tenant="a".
+	LabelMatchers []string `yaml:"labelMatchers"`
+	// AllowedMetrics are regular expressions of the metric names PromQL queries can select.
+	AllowedMetrics []string `yaml:"allowedMetrics"`
+	// AllowedTables are the tables SQL queries can read from. Tables without
+	// schema are in the default schema of the data source.
+	AllowedTables []string `yaml:"allowedTables"`
+
+	labelMatchers  []*labels.Matcher
+	allowedMetrics []*regexp.Regexp
+}
+
+// LoadQueryPolicies reads and validates a query policies file.
+func LoadQueryPolicies(path string) ([]QueryPolicy, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query policies file: %w", err)
+	}
+	var file QueryPolicies
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse query policies file: %w", err)
+	}
+	for i := range file.Policies {
+		if err := file.Policies[i].compile(); err != nil {
+			return nil, fmt.Errorf("invalid query policy %q: %w", file.Policies[i].Name, err)
+		}
+	}
+	return file.Policies, nil
+}
+
+func (p *QueryPolicy) compile() error {
+	if p.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	switch p.Action {
+	case "":
+		p.Action = PolicyActionDeny
+	case PolicyActionDeny, PolicyActionRewrite:
+	default:
+		return fmt.Errorf("unknown action %q", p.Action)
+	}
+	p.labelMatchers = make([]*labels.Matcher, 0, len(p.LabelMatchers))
+	for _, m := range p.LabelMatchers {
+		matchers, err := parseLabelMatchers(m)
+		if err != nil {
+			return fmt.Errorf("invalid label matcher %q: %w", m, err)
+		}
+		p.labelMatchers = append(p.labelMatchers, matchers...)
+	}
+	p.allowedMetrics = make([]*regexp.Regexp, 0, len(p.AllowedMetrics))
+	for _, m := range p.AllowedMetrics {
+		re, err := regexp.Compile("^(?:" + m + ")$")
+		if err != nil {
+			return fmt.Errorf("invalid metric pattern %q: %w", m, err)
+		}
+		p.allowedMetrics = append(p.allowedMetrics, re)
+	}
+	return nil
+}
+
+// appliesTo returns true if the policy matches the organization and the teams of the user.
+func (p *QueryPolicy) appliesTo(orgID int64, user identity.Requester) bool {
+	if p.OrgID != 0 && p.OrgID != orgID {
+		return false
+	}
+	if len(p.TeamIDs) == 0 {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	for _, teamID := range user.GetTeams() {
+		if slices.Contains(p.TeamIDs, teamID) {
+			return true
+		}
+	}
+	return false
+}
+
+// prometheusTypes are the data source types PromQL rules apply to.
+var prometheusTypes = []string{
+	datasources.DS_PROMETHEUS,
+	datasources.DS_AMAZON_PROMETHEUS,
+	datasources.DS_AZURE_PROMETHEUS,
+}
+
+func (p *QueryPolicy) hasPromQLRules() bool {
+	return len(p.labelMatchers) > 0 || len(p.allowedMetrics) > 0
+}
+
+func (p *QueryPolicy) hasSQLRules() bool {
+	return len(p.AllowedTables) > 0
+}
+
+// selectsDatasource returns true if the data source filter of the policy selects the data source.
+func (p *QueryPolicy) selectsDatasource(ds *datasources.DataSource) bool {
+	return slices.Contains(p.DatasourceUIDs, ds.UID) || slices.Contains(p.DatasourceTypes, ds.Type)
+}
+
+// enforcesPromQL returns true if the PromQL rules of the policy apply to the
+// queries of the data source. Without data source filter, they only apply to
+// the Prometheus data sources.
+func (p *QueryPolicy) enforcesPromQL(ds *datasources.DataSource) bool {
+	if !p.hasPromQLRules() {
+		return false
+	}
+	if len(p.DatasourceUIDs) == 0 && len(p.DatasourceTypes) == 0 {
+		return slices.Contains(prometheusTypes, ds.Type)
+	}
+	return p.selectsDatasource(ds)
+}
+
+// enforcesSQL returns true if the allowed tables of the policy apply to the
+// queries of the data source. Without data source filter, they only apply to
+// the SQL data sources with a known dialect.
+func (p *QueryPolicy) enforcesSQL(ds *datasources.DataSource) bool {
+	if !p.hasSQLRules() {
+		return false
+	}
+	if len(p.DatasourceUIDs) == 0 && len(p.DatasourceTypes) == 0 {
+		return sqlDialectOf(ds.Type) != nil
+	}
+	return p.selectsDatasource(ds)
+}
+
+// restricts returns true if the policy restricts the queries of the data source.
+func (p *QueryPolicy) restricts(ds *datasources.DataSource) bool {
+	return p.enforcesPromQL(ds) || p.enforcesSQL(ds)
+}
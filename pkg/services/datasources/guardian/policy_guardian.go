@@ -0,0 +1,109 @@
+package guardian
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+var ErrQueryDenied = errutil.Forbidden("datasources.queryDenied").MustTemplate(
+	"query {{ .Public.RefId }} denied by policy {{ .Private.Policy }}: {{ .Private.Reason }}",
+	errutil.WithPublic("Query {{ .Public.RefId }} is not allowed by the data source query policies"),
+)
+
+var ErrRequestDenied = errutil.Forbidden("datasources.requestDenied").MustTemplate(
+	"request to data source {{ .Public.DatasourceUid }} denied by policy {{ .Private.Policy }}",
+	errutil.WithPublic("Only queries are allowed to data source {{ .Public.DatasourceUid }} by the data source query policies"),
+)
+
+var _ DatasourceGuardian = new(PolicyGuardian)
+
+// PolicyGuardian enforces the query policies matching a user. It allows the
+// same data sources as AllowGuardian, but checks every query: PromQL queries
+// (the expr field) of Prometheus data sources against the label matchers and
+// metric names of the policies, and SQL queries (the rawSql field) of SQL data
+// sources against their allowed tables. Offending queries are denied or, when
+// the policy allows it, rewritten. The other requests to the data sources the
+// policies restrict, like resource calls, can't be checked and are denied.
+// Denials and rewrites are written to the audit log.
+type PolicyGuardian struct {
+	AllowGuardian
+	orgID    int64
+	user     identity.Requester
+	policies []*QueryPolicy
+	audit    log.Logger
+}
+
+func (g *PolicyGuardian) CheckQuery(ctx context.Context, ds *datasources.DataSource, query *simplejson.Json) error {
+	refID := query.Get("refId").MustString("A")
+	for _, policy := range g.policies {
+		if expr, ok := query.CheckGet("expr"); ok && policy.enforcesPromQL(ds) {
+			original := expr.MustString()
+			rewritten, err := enforcePromQL(policy, original)
+			if err != nil {
+				return g.deny(ctx, ds, policy, refID, err)
+			}
+			if rewritten != original {
+				g.audit.FromContext(ctx).Info("Query rewritten by data source query policy", g.auditFields(ds, policy, refID, "original", original, "rewritten", rewritten)...)
+				query.Set("expr", rewritten)
+			}
+		}
+
+		if rawSQL, ok := query.CheckGet("rawSql"); ok && policy.enforcesSQL(ds) {
+			if err := enforceSQL(policy, ds, rawSQL.MustString()); err != nil {
+				return g.deny(ctx, ds, policy, refID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *PolicyGuardian) CheckDatasourceRequest(ctx context.Context, ds *datasources.DataSource) error {
+	for _, policy := range g.policies {
+		if !policy.restricts(ds) {
+			continue
+		}
+		g.audit.FromContext(ctx).Warn("Request denied by data source query policy", g.auditFields(ds, policy, "")...)
+		return ErrRequestDenied.Build(errutil.TemplateData{
+			Public: map[string]any{
+				"DatasourceUid": ds.UID,
+			},
+			Private: map[string]any{
+				"Policy": policy.Name,
+			},
+		})
+	}
+	return nil
+}
+
+func (g *PolicyGuardian) deny(ctx context.Context, ds *datasources.DataSource, policy *QueryPolicy, refID string, reason error) error {
+	g.audit.FromContext(ctx).Warn("Query denied by data source query policy", g.auditFields(ds, policy, refID, "reason", reason.Error())...)
+	return ErrQueryDenied.Build(errutil.TemplateData{
+		Public: map[string]any{
+			"RefId": refID,
+		},
+		Private: map[string]any{
+			"Policy": policy.Name,
+			"Reason": reason.Error(),
+		},
+	})
+}
+
+func (g *PolicyGuardian) auditFields(ds *datasources.DataSource, policy *QueryPolicy, refID string, extra ...any) []any {
+	fields := []any{
+		"orgId", g.orgID,
+		"datasourceUid", ds.UID,
+		"datasourceType", ds.Type,
+		"policy", policy.Name,
+		"action", policy.Action,
+		"refId", refID,
+	}
+	if g.user != nil {
+		fields = append(fields, "userId", g.user.GetID(), "login", g.user.GetLogin())
+	}
+	return append(fields, extra...)
+}
@@ -0,0 +1,256 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testPolicies = `
+policies:
+  - name: team-a-metrics
+    orgId: 1
+    datasourceUids: [prom]
+    teamIds: [2]
+    action: rewrite
+    labelMatchers: ['tenant="a"']
+  - name: team-a-allowed-metrics
+    datasourceTypes: [prometheus]
+    teamIds: [2]
+    allowedMetrics: ['node_.*', 'up']
+  - name: team-a-sql
+    datasourceTypes: [grafana-postgresql-datasource]
+    teamIds: [2]
+    allowedTables: [metrics_a, public.hosts]
+`
+
+func newTestProvider(t *testing.T, policies string) *OSSProvider {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(policies), 0o600))
+	cfg := setting.NewCfg()
+	cfg.DataSourceQueryPoliciesFile = path
+	p, err := ProvideGuardian(cfg)
+	require.NoError(t, err)
+	return p
+}
+
+func checkQuery(t *testing.T, p *OSSProvider, u *user.SignedInUser, ds *datasources.DataSource, query string) (*simplejson.Json, error) {
+	t.Helper()
+	q, err := simplejson.NewJson([]byte(query))
+	require.NoError(t, err)
+	return q, p.New(u.OrgID, u, *ds).CheckQuery(context.Background(), ds, q)
+}
+
+func TestPolicyGuardian_PromQL(t *testing.T) {
+	p := newTestProvider(t, testPolicies)
+	prom := &datasources.DataSource{UID: "prom", Type: "prometheus"}
+	teamA := &user.SignedInUser{OrgID: 1, Login: "a", TeamIDs: []int64{2}}
+
+	t.Run("users outside of the teams are not restricted", func(t *testing.T) {
+		_, ok := p.New(1, &user.SignedInUser{OrgID: 1, TeamIDs: []int64{3}}, *prom).(*AllowGuardian)
+		require.True(t, ok)
+	})
+
+	t.Run("label matchers are enforced on every selector", func(t *testing.T) {
+		q, err := checkQuery(t, p, teamA, prom, `{"refId": "A", "expr": "rate(node_cpu_seconds_total{tenant=\"b\"}[$__rate_interval]) / up"}`)
+		require.NoError(t, err)
+		require.Equal(t, `rate(node_cpu_seconds_total{tenant="a"}[$__rate_interval]) / up{tenant="a"}`, q.Get("expr").MustString())
+	})
+
+	t.Run("compliant queries are not rewritten", func(t *testing.T) {
+		q, err := checkQuery(t, p, teamA, prom, `{"refId": "A", "expr": "sum(up{tenant=\"a\"})"}`)
+		require.NoError(t, err)
+		require.Equal(t, `sum(up{tenant="a"})`, q.Get("expr").MustString())
+	})
+
+	t.Run("metrics that are not allowed are denied", func(t *testing.T) {
+		_, err := checkQuery(t, p, teamA, prom, `{"refId": "B", "expr": "secret_metric"}`)
+		require.ErrorIs(t, err, ErrQueryDenied)
+		var gfErr errutil.Error
+		require.True(t, errors.As(err, &gfErr))
+		require.Equal(t, "B", gfErr.PublicPayload["RefId"])
+	})
+
+	t.Run("queries that can't be parsed are denied", func(t *testing.T) {
+		_, err := checkQuery(t, p, teamA, prom, `{"refId": "A", "expr": "up{"}`)
+		require.ErrorIs(t, err, ErrQueryDenied)
+	})
+
+	t.Run("deny action", func(t *testing.T) {
+		p := newTestProvider(t, `
+policies:
+  - name: deny
+    labelMatchers: ['tenant="a"']
+`)
+		_, err := checkQuery(t, p, teamA, prom, `{"refId": "A", "expr": "up{tenant=~\".+\"}"}`)
+		require.ErrorIs(t, err, ErrQueryDenied)
+		_, err = checkQuery(t, p, teamA, prom, `{"refId": "A", "expr": "up{tenant=\"a\"}"}`)
+		require.NoError(t, err)
+	})
+}
+
+func TestPolicyGuardian_SQL(t *testing.T) {
+	p := newTestProvider(t, testPolicies)
+	postgres := &datasources.DataSource{UID: "pg", Type: datasources.DS_POSTGRES}
+	teamA := &user.SignedInUser{OrgID: 1, Login: "a", TeamIDs: []int64{2}}
+
+	for _, sql := range []string{
+		"SELECT $__timeGroupAlias(time, '1m'), avg(value) FROM metrics_a WHERE $__timeFilter(time) GROUP BY 1",
+		"SELECT m.value::double precision AS v, h.name FROM public.metrics_a m JOIN hosts h ON h.id = m.host_id",
+		"WITH recent AS (SELECT * FROM metrics_a) SELECT * FROM recent -- FROM secrets",
+		"SELECT 'FROM secrets' AS s FROM \"metrics_a\" /* /* nested */ FROM secrets */;",
+		"SELECT * FROM metrics_a WHERE id IN (SELECT id FROM public.hosts)",
+	} {
+		_, err := checkQuery(t, p, teamA, postgres, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+		require.NoError(t, err, sql)
+	}
+
+	for _, sql := range []string{
+		"SELECT * FROM secrets",
+		"SELECT * FROM metrics_a, other.hosts",
+		"SELECT * FROM other.metrics_a",
+		"SELECT * FROM (SELECT * FROM metrics_a) a, secrets",
+		"SELECT * FROM metrics_a a LEFT JOIN secrets s ON s.id = a.id",
+		"SELECT * FROM metrics_a WHERE id IN (SELECT id FROM secrets)",
+		"TABLE secrets",
+		"SELECT query_to_xml('SELECT * FROM secrets', true, false, '')",
+		"SELECT * FROM dblink('dbname=other', 'SELECT * FROM secrets') AS t(v text)",
+		"SELECT * FROM metrics_a; DELETE FROM metrics_a",
+		"SELECT 'a\\' FROM secrets --'",
+		"SELECT $$'$$ FROM secrets --'",
+		"SELECT value #x FROM secrets",
+		"WITH secrets AS (SELECT * FROM secrets) SELECT * FROM secrets",
+		"SELECT * FROM secrets, (WITH secrets AS (SELECT * FROM metrics_a) SELECT * FROM secrets) s",
+		"SELECT pg_read_file('/etc/passwd')",
+	} {
+		_, err := checkQuery(t, p, teamA, postgres, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+		require.ErrorIs(t, err, ErrQueryDenied, sql)
+	}
+}
+
+func TestPolicyGuardian_SQLDialects(t *testing.T) {
+	p := newTestProvider(t, `
+policies:
+  - name: sql
+    allowedTables: [metrics]
+`)
+	u := &user.SignedInUser{OrgID: 1}
+
+	t.Run("mysql", func(t *testing.T) {
+		mysql := &datasources.DataSource{UID: "mysql", Type: datasources.DS_MYSQL, Database: "grafana"}
+		for _, sql := range []string{
+			"SELECT `time`, value FROM metrics WHERE $__timeFilter(`time`) -- FROM secrets",
+			"SELECT \"FROM secrets\" FROM grafana.metrics # FROM secrets",
+			"SELECT 1 FROM dual",
+		} {
+			_, err := checkQuery(t, p, u, mysql, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+			require.NoError(t, err, sql)
+		}
+		for _, sql := range []string{
+			"SELECT * FROM other.metrics",
+			"SELECT value --x FROM secrets",
+			"SELECT * FROM metrics /*! , secrets */",
+			"SELECT 'a\\' FROM secrets",
+			"SELECT load_file('/etc/passwd')",
+			"SELECT @@hostname",
+			"SELECT * FROM metrics INTO OUTFILE '/tmp/metrics'",
+		} {
+			_, err := checkQuery(t, p, u, mysql, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+			require.ErrorIs(t, err, ErrQueryDenied, sql)
+		}
+	})
+
+	t.Run("mssql", func(t *testing.T) {
+		mssql := &datasources.DataSource{UID: "mssql", Type: datasources.DS_MSSQL}
+		for _, sql := range []string{
+			"SELECT TOP 10 [value] FROM [dbo].[metrics]",
+			"SELECT value FROM metrics WHERE $__timeFilter(time)",
+		} {
+			_, err := checkQuery(t, p, u, mssql, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+			require.NoError(t, err, sql)
+		}
+		for _, sql := range []string{
+			"SELECT * FROM [other].[metrics]",
+			"SELECT * FROM #secrets",
+			"SELECT * FROM OPENROWSET('SQLNCLI', 'Server=other;', 'SELECT * FROM secrets')",
+		} {
+			_, err := checkQuery(t, p, u, mssql, `{"refId": "A", "rawSql": `+string(mustJSON(t, sql))+`}`)
+			require.ErrorIs(t, err, ErrQueryDenied, sql)
+		}
+	})
+
+	t.Run("queries of other data sources are not checked", func(t *testing.T) {
+		loki := &datasources.DataSource{UID: "loki", Type: datasources.DS_LOKI}
+		_, err := checkQuery(t, p, u, loki, `{"refId": "A", "rawSql": "SELECT * FROM secrets"}`)
+		require.NoError(t, err)
+	})
+}
+
+func TestPolicyGuardian_DatasourceTypes(t *testing.T) {
+	p := newTestProvider(t, `
+policies:
+  - name: all-datasources
+    labelMatchers: ['tenant="a"']
+  - name: clickhouse
+    datasourceTypes: [grafana-clickhouse-datasource]
+    allowedTables: [metrics]
+`)
+	u := &user.SignedInUser{OrgID: 1}
+	ctx := context.Background()
+
+	t.Run("PromQL rules without data source filter only apply to Prometheus", func(t *testing.T) {
+		loki := &datasources.DataSource{UID: "loki", Type: datasources.DS_LOKI}
+		q, err := checkQuery(t, p, u, loki, `{"refId": "A", "expr": "{job=\"app\"} |= \"error\""}`)
+		require.NoError(t, err)
+		require.Equal(t, `{job="app"} |= "error"`, q.Get("expr").MustString())
+		require.NoError(t, p.New(1, u, *loki).CheckDatasourceRequest(ctx, loki))
+
+		prom := &datasources.DataSource{UID: "prom", Type: datasources.DS_PROMETHEUS}
+		_, err = checkQuery(t, p, u, prom, `{"refId": "A", "expr": "up{tenant=\"b\"}"}`)
+		require.ErrorIs(t, err, ErrQueryDenied)
+	})
+
+	t.Run("requests other than queries are denied for restricted data sources", func(t *testing.T) {
+		prom := &datasources.DataSource{UID: "prom", Type: datasources.DS_AMAZON_PROMETHEUS}
+		err := p.New(1, u, *prom).CheckDatasourceRequest(ctx, prom)
+		require.ErrorIs(t, err, ErrRequestDenied)
+	})
+
+	t.Run("SQL queries of selected data sources without dialect are denied", func(t *testing.T) {
+		clickhouse := &datasources.DataSource{UID: "ch", Type: "grafana-clickhouse-datasource"}
+		_, err := checkQuery(t, p, u, clickhouse, `{"refId": "A", "rawSql": "SELECT * FROM metrics"}`)
+		require.ErrorIs(t, err, ErrQueryDenied)
+	})
+}
+
+func mustJSON(t *testing.T, v string) []byte {
+	t.Helper()
+	b, err := simplejson.NewFromAny(v).MarshalJSON()
+	require.NoError(t, err)
+	return b
+}
+
+func TestLoadQueryPolicies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	for _, content := range []string{
+		"policies:\n  - labelMatchers: ['tenant=\"a\"']",
+		"policies:\n  - name: a\n    action: drop",
+		"policies:\n  - name: a\n    labelMatchers: ['tenant=']",
+		"policies:\n  - name: a\n    allowedMetrics: ['(']",
+	} {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadQueryPolicies(path)
+		require.Error(t, err, content)
+	}
+}
@@ -0,0 +1,139 @@
+package guardian
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// promQLMacros are the variables interpolated by the Prometheus data source
+// backend. They are replaced with placeholders of the same type while the query
+// is parsed, and restored in rewritten queries. Longer names come first so that
+// $__interval doesn't match the prefix of $__interval_ms.
+var promQLMacros = []struct {
+	name        string
+	placeholder string
+}{
+	{name: "__rate_interval_ms", placeholder: "314159001"},
+	{name: "__rate_interval", placeholder: "22d1h1m1s1ms"},
+	{name: "__interval_ms", placeholder: "314159002"},
+	{name: "__interval", placeholder: "22d1h1m1s2ms"},
+	{name: "__range_ms", placeholder: "314159003"},
+	{name: "__range_s", placeholder: "314159004"},
+	{name: "__range", placeholder: "22d1h1m1s3ms"},
+}
+
+func replacePromQLMacros(query string) string {
+	for _, m := range promQLMacros {
+		query = strings.ReplaceAll(query, "${"+m.name+"}", m.placeholder)
+		query = strings.ReplaceAll(query, "$"+m.name, m.placeholder)
+	}
+	return query
+}
+
+func restorePromQLMacros(query string) string {
+	for _, m := range promQLMacros {
+		query = strings.ReplaceAll(query, m.placeholder, "$"+m.name)
+	}
+	return query
+}
+
+func parsePromQL(query string) (parser.Expr, error) {
+	return parser.NewParser(parser.Options{}).ParseExpr(replacePromQLMacros(query))
+}
+
+// parseLabelMatchers parses label matchers like tenant="a",env=~"prod|staging".
+func parseLabelMatchers(matchers string) ([]*labels.Matcher, error) {
+	expr, err := parsePromQL("{" + matchers + "}")
+	if err != nil {
+		return nil, err
+	}
+	vs, ok := expr.(*parser.VectorSelector)
+	if !ok {
+		return nil, errors.New("not a label matcher")
+	}
+	return vs.LabelMatchers, nil
+}
+
+// enforcePromQL checks every selector of a PromQL query against the policy.
+// With the rewrite action, the label matchers of the policy replace the matchers
+// of the selectors on the same labels. It returns the query to run, or an error
+// with the reason the query is denied.
+func enforcePromQL(policy *QueryPolicy, query string) (string, error) {
+	if len(policy.labelMatchers) == 0 && len(policy.allowedMetrics) == 0 {
+		return query, nil
+	}
+	expr, err := parsePromQL(query)
+	if err != nil {
+		// Queries that can't be analyzed can't be allowed.
+		return "", fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	rewritten := false
+	var denied error
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+		if err := checkMetricName(policy, vs); err != nil {
+			denied = err
+			return err
+		}
+		for _, required := range policy.labelMatchers {
+			if hasLabelMatcher(vs.LabelMatchers, required) {
+				continue
+			}
+			if policy.Action != PolicyActionRewrite {
+				denied = fmt.Errorf("selector %s is missing the label matcher %s", vs, required)
+				return denied
+			}
+			vs.LabelMatchers = slices.DeleteFunc(vs.LabelMatchers, func(m *labels.Matcher) bool {
+				return m.Name == required.Name
+			})
+			vs.LabelMatchers = append(vs.LabelMatchers, required)
+			rewritten = true
+		}
+		return nil
+	})
+	if denied != nil {
+		return "", denied
+	}
+	if !rewritten {
+		return query, nil
+	}
+	return restorePromQLMacros(expr.String()), nil
+}
+
+func checkMetricName(policy *QueryPolicy, vs *parser.VectorSelector) error {
+	if len(policy.allowedMetrics) == 0 {
+		return nil
+	}
+	name := vs.Name
+	if name == "" {
+		for _, m := range vs.LabelMatchers {
+			if m.Name == labels.MetricName && m.Type == labels.MatchEqual {
+				name = m.Value
+			}
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("selector %s doesn't select a single metric name", vs)
+	}
+	for _, re := range policy.allowedMetrics {
+		if re.MatchString(name) {
+			return nil
+		}
+	}
+	return fmt.Errorf("metric %s is not allowed", name)
+}
+
+func hasLabelMatcher(matchers []*labels.Matcher, required *labels.Matcher) bool {
+	return slices.ContainsFunc(matchers, func(m *labels.Matcher) bool {
+		return m.Name == required.Name && m.Type == required.Type && m.Value == required.Value
+	})
+}
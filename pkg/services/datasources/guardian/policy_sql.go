@@ -0,0 +1,557 @@
+package guardian
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dolthub/vitess/go/vt/sqlparser"
+
+	"github.com/grafana/grafana/pkg/services/datasources"
+)
+
+// sqlMacroPrefix replaces the $__ prefix of the macros of the SQL data sources,
+// like $__timeFilter(time), while the query is parsed.
+const sqlMacroPrefix = "__grafana_macro_"
+
+// sqlDialect is the SQL dialect of a data source type. The queries of every
+// dialect are parsed with the MySQL grammar of the SQL expressions: the lexical
+// syntax of the other dialects (comments, string literals, quoted identifiers
+// and casts) is translated to MySQL first. Queries using syntax the grammar
+// doesn't support are denied.
+type sqlDialect struct {
+	name string
+	// defaultSchema returns the schema of the tables referenced without schema.
+	defaultSchema func(ds *datasources.DataSource) string
+	// mysql is true for the MySQL lexical syntax: # comments, -- comments
+	// followed by a space, and double-quoted strings.
+	mysql bool
+	// bracketIdentifiers is true when identifiers can be quoted with brackets.
+	bracketIdentifiers bool
+	// casts is true when values can be cast with ::type.
+	casts bool
+}
+
+var (
+	mysqlDialect = &sqlDialect{
+		name:  "mysql",
+		mysql: true,
+		defaultSchema: func(ds *datasources.DataSource) string {
+			if ds.Database == "" && ds.JsonData != nil {
+				return ds.JsonData.Get("database").MustString()
+			}
+			return ds.Database
+		},
+	}
+	postgresDialect = &sqlDialect{
+		name:          "postgres",
+		casts:         true,
+		defaultSchema: func(*datasources.DataSource) string { return "public" },
+	}
+	mssqlDialect = &sqlDialect{
+		name:               "mssql",
+		bracketIdentifiers: true,
+		defaultSchema:      func(*datasources.DataSource) string { return "dbo" },
+	}
+)
+
+// sqlDialectOf returns the dialect of a data source type, or nil if the queries
+// of the type can't be checked.
+func sqlDialectOf(dsType string) *sqlDialect {
+	switch dsType {
+	case datasources.DS_MYSQL:
+		return mysqlDialect
+	case datasources.DS_POSTGRES, "postgres":
+		return postgresDialect
+	case datasources.DS_MSSQL:
+		return mssqlDialect
+	}
+	return nil
+}
+
+// mssqlTop matches the TOP clause of SQL Server, which MySQL doesn't support.
+var mssqlTop = regexp.MustCompile(`(?i)\b(select(?:\s+distinct|\s+all)?)\s+top\s*(?:\(\s*\d+\s*\)|\d+)(?:\s+percent)?(?:\s+with\s+ties)?\b`)
+
+// sqlCastWords are the words of the multi-word type names of PostgreSQL casts.
+var sqlCastWords = []string{"precision", "varying", "with", "without", "time", "zone"}
+
+func isSQLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isSQLIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// quotedLength returns the length of the quoted string or identifier at the
+// start of sql. The quote is escaped by doubling it, and by a backslash when
+// backslash is true.
+func quotedLength(sql string, closing byte, backslash bool) (int, error) {
+	for i := 1; i < len(sql); i++ {
+		switch {
+		case backslash && sql[i] == '\\':
+			i++
+		case sql[i] == closing:
+			if i+1 < len(sql) && sql[i+1] == closing {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated quoted string")
+}
+
+// translate translates the lexical syntax of the dialect to MySQL. Comments are
+// removed, and the constructs whose meaning differs in MySQL are rejected.
+// nolint:gocyclo
+func (d *sqlDialect) translate(sql string) (string, error) {
+	var b strings.Builder
+	ended := false
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		if isSQLSpace(c) {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+
+		// Comments
+		switch {
+		case strings.HasPrefix(sql[i:], "--") && (!d.mysql || i+2 == len(sql) || isSQLSpace(sql[i+2])),
+			c == '#' && d.mysql:
+			if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(sql)
+			}
+			b.WriteByte(' ')
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			if d.mysql && strings.HasPrefix(sql[i:], "/*!") {
+				return "", errors.New("executable comments are not supported")
+			}
+			depth := 0
+			for {
+				switch {
+				case i >= len(sql):
+					return "", errors.New("unterminated comment")
+				case strings.HasPrefix(sql[i:], "/*") && (depth == 0 || !d.mysql):
+					depth++
+					i += 2
+				case strings.HasPrefix(sql[i:], "*/"):
+					depth--
+					i += 2
+				default:
+					i++
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			b.WriteByte(' ')
+			continue
+		}
+
+		if ended {
+			return "", errors.New("multiple statements are not supported")
+		}
+		switch {
+		case c == ';':
+			ended = true
+			i++
+		case c == '\'' || (c == '"' && d.mysql):
+			n, err := quotedLength(sql[i:], c, d.mysql)
+			if err != nil {
+				return "", err
+			}
+			literal := sql[i : i+n]
+			if strings.Contains(literal, `\`) {
+				if d.mysql {
+					// Backslashes are escapes unless NO_BACKSLASH_ESCAPES is set on the server.
+					return "", errors.New("backslashes in string literals are not supported")
+				}
+				literal = strings.ReplaceAll(literal, `\`, `\\`)
+			}
+			b.WriteString(literal)
+			i += n
+		case c == '"' || (c == '[' && d.bracketIdentifiers):
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			n, err := quotedLength(sql[i:], closing, false)
+			if err != nil {
+				return "", err
+			}
+			name := strings.ReplaceAll(sql[i+1:i+n-1], string([]byte{closing, closing}), string(closing))
+			b.WriteString("`" + strings.ReplaceAll(name, "`", "``") + "`")
+			i += n
+		case c == '`':
+			if !d.mysql {
+				return "", errors.New("unsupported character `")
+			}
+			n, err := quotedLength(sql[i:], c, false)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(sql[i : i+n])
+			i += n
+		case strings.HasPrefix(sql[i:], "--"):
+			// Not a comment in MySQL, as no space follows.
+			b.WriteString("- -")
+			i += 2
+		case strings.HasPrefix(sql[i:], "$__"):
+			b.WriteString(sqlMacroPrefix)
+			i += 3
+		case c == '$' || c == '#':
+			// Dollar-quoted strings, parameters and operators of other dialects.
+			return "", fmt.Errorf("unsupported character %c", c)
+		case strings.HasPrefix(sql[i:], "::") && d.casts:
+			n, err := castLength(sql[i:])
+			if err != nil {
+				return "", err
+			}
+			i += n
+		case isSQLIdentifierChar(c):
+			start := i
+			for i < len(sql) && isSQLIdentifierChar(sql[i]) {
+				i++
+			}
+			word := sql[start:i]
+			if !d.mysql && strings.EqualFold(word, "e") && i < len(sql) && sql[i] == '\'' {
+				return "", errors.New("escape string literals are not supported")
+			}
+			b.WriteString(word)
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	if d.bracketIdentifiers {
+		return mssqlTop.ReplaceAllString(b.String(), "$1"), nil
+	}
+	return b.String(), nil
+}
+
+// castLength returns the length of the PostgreSQL cast at the start of sql, like ::timestamp with time zone.
+func castLength(sql string) (int, error) {
+	i := 2
+	word := func() string {
+		for i < len(sql) && isSQLSpace(sql[i]) {
+			i++
+		}
+		start := i
+		for i < len(sql) && isSQLIdentifierChar(sql[i]) {
+			i++
+		}
+		return sql[start:i]
+	}
+
+	if word() == "" {
+		return 0, errors.New("unsupported cast")
+	}
+	if i < len(sql) && sql[i] == '.' {
+		i++
+		if word() == "" {
+			return 0, errors.New("unsupported cast")
+		}
+	}
+	for {
+		end := i
+		if w := word(); w == "" || !slices.Contains(sqlCastWords, strings.ToLower(w)) {
+			i = end
+			break
+		}
+	}
+	rest := strings.TrimLeft(sql[i:], " \t\r\n")
+	if strings.HasPrefix(rest, "(") {
+		end := strings.IndexByte(rest, ')')
+		if end < 0 || strings.Trim(rest[1:end], "0123456789, ") != "" {
+			return 0, errors.New("unsupported cast")
+		}
+		i = len(sql) - len(rest) + end + 1
+	}
+	for strings.HasPrefix(sql[i:], "[]") {
+		i += 2
+	}
+	return i, nil
+}
+
+// sqlTable is a table a SQL query reads from, in lower case.
+type sqlTable struct {
+	schema string
+	name   string
+}
+
+func (t sqlTable) String() string {
+	if t.schema == "" {
+		return t.name
+	}
+	return t.schema + "." + t.name
+}
+
+// tables parses a SQL query and returns the tables it reads from. References
+// to the common table expressions of the query are not returned. Queries that
+// aren't a single SELECT statement, or use syntax or functions that could read
+// other data, are rejected.
+func (d *sqlDialect) tables(sql string) ([]sqlTable, error) {
+	translated, err := d.translate(sql)
+	if err != nil {
+		return nil, err
+	}
+	stmt, err := sqlparser.Parse(translated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+
+	var with *sqlparser.With
+	switch s := stmt.(type) {
+	case *sqlparser.Select:
+		with = s.With
+	case *sqlparser.SetOp:
+		with = s.With
+	default:
+		return nil, errors.New("only SELECT statements are allowed")
+	}
+
+	w := &sqlTableWalker{}
+	tables, err := w.walk(nil, stmt)
+	if err != nil {
+		return nil, err
+	}
+	// The common table expressions are in the scope of the whole statement, and
+	// of the bodies of the expressions defined after them.
+	var cteNames []string
+	for i := 0; i < len(w.ctes); i++ {
+		cteTables, err := w.walk(w.ctes[i], w.ctes[i])
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, withoutCTEs(cteTables, cteNames)...)
+		cteNames = append(cteNames, strings.ToLower(w.ctes[i].As.String()))
+	}
+	if len(w.withs) > 1 || (len(w.withs) == 1 && w.withs[0] != with) {
+		return nil, errors.New("WITH clauses are only supported at the start of the query")
+	}
+	tables = withoutCTEs(tables, cteNames)
+
+	if d == mysqlDialect {
+		tables = slices.DeleteFunc(tables, func(t sqlTable) bool {
+			return t == sqlTable{name: "dual"}
+		})
+	}
+	return tables, nil
+}
+
+func withoutCTEs(tables []sqlTable, cteNames []string) []sqlTable {
+	return slices.DeleteFunc(tables, func(t sqlTable) bool {
+		return t.schema == "" && slices.Contains(cteNames, t.name)
+	})
+}
+
+// sqlTableWalker collects the tables of a statement, and checks its nodes
+// against an allow list.
+type sqlTableWalker struct {
+	// ctes are the common table expressions found, which are walked separately.
+	ctes  []*sqlparser.CommonTableExpr
+	withs []*sqlparser.With
+}
+
+// walk returns the tables the nodes read from. The common table expressions,
+// other than root, are not walked.
+func (w *sqlTableWalker) walk(root *sqlparser.CommonTableExpr, nodes ...sqlparser.SQLNode) ([]sqlTable, error) {
+	var tables []sqlTable
+	var visit func(node sqlparser.SQLNode) (bool, error)
+	visit = func(node sqlparser.SQLNode) (bool, error) {
+		switch v := node.(type) {
+		case *sqlparser.CommonTableExpr:
+			if v != root {
+				w.ctes = append(w.ctes, v)
+				return false, nil
+			}
+			return true, nil
+		case *sqlparser.With:
+			if v != nil {
+				w.withs = append(w.withs, v)
+			}
+			return true, nil
+		case sqlparser.TableName:
+			if v.Name.String() != "" {
+				tables = append(tables, sqlTable{
+					schema: strings.ToLower(v.Qualifier.String()),
+					name:   strings.ToLower(v.Name.String()),
+				})
+			}
+			return false, nil
+		case *sqlparser.ColName:
+			// Reject @variables and @@system variables.
+			if strings.HasPrefix(v.Name.String(), "@") || strings.HasPrefix(v.Qualifier.Name.String(), "@") {
+				return false, fmt.Errorf("variable %s is not allowed", v.Name.String())
+			}
+			// The qualifier of a column is a table alias, not a table.
+			return false, nil
+		case *sqlparser.StarExpr:
+			return false, nil
+		case *sqlparser.FuncExpr:
+			if !v.Qualifier.IsEmpty() || !allowedSQLFunction(v.Name.String()) {
+				return false, fmt.Errorf("function %s is not allowed", v.Name.String())
+			}
+			return true, nil
+		case *sqlparser.SetOp:
+			// SetOp.walkSubtree doesn't visit these fields.
+			if v.GetInto() != nil || (v.Lock != nil && v.Lock.Type != "") {
+				return false, errors.New("SELECT INTO and locking reads are not allowed")
+			}
+			if err := sqlparser.Walk(visit, v.OrderBy, v.With, v.Limit); err != nil {
+				return false, err
+			}
+			return true, nil
+		case *sqlparser.Select:
+			if v.Lock != nil && v.Lock.Type != "" {
+				return false, errors.New("locking reads are not allowed")
+			}
+			// Select.walkSubtree doesn't visit the window definitions.
+			if len(v.Window) > 0 {
+				if err := sqlparser.Walk(visit, v.Window); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		case *sqlparser.Into:
+			if v != nil {
+				return false, errors.New("SELECT INTO is not allowed")
+			}
+			return true, nil
+		}
+		if !allowedSQLNode(node) {
+			return false, fmt.Errorf("%T is not allowed", node)
+		}
+		return true, nil
+	}
+	if err := sqlparser.Walk(visit, nodes...); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// allowedSQLNode returns true for the nodes of read-only expressions, the
+// nodes handled by the walker excepted.
+// nolint:gocyclo
+func allowedSQLNode(node sqlparser.SQLNode) bool {
+	switch node.(type) {
+	case *sqlparser.AliasedExpr, *sqlparser.AliasedTableExpr,
+		*sqlparser.AndExpr, *sqlparser.OrExpr, *sqlparser.NotExpr,
+		*sqlparser.BinaryExpr, *sqlparser.UnaryExpr,
+		sqlparser.BoolVal, *sqlparser.NullVal,
+		*sqlparser.CaseExpr, *sqlparser.When,
+		*sqlparser.CharExpr,
+		sqlparser.ColIdent, sqlparser.Columns, sqlparser.ColumnType,
+		sqlparser.Comments,
+		*sqlparser.ComparisonExpr,
+		*sqlparser.ConvertExpr, *sqlparser.ConvertType,
+		*sqlparser.CollateExpr,
+		sqlparser.Exprs,
+		*sqlparser.ExtractFuncExpr,
+		*sqlparser.GroupConcatExpr,
+		sqlparser.GroupBy,
+		*sqlparser.Frame, *sqlparser.FrameExtent, *sqlparser.FrameBound,
+		*sqlparser.IndexHints,
+		*sqlparser.IntervalExpr,
+		*sqlparser.IsExpr,
+		*sqlparser.JoinTableExpr, sqlparser.JoinCondition,
+		sqlparser.SelectExprs, *sqlparser.ParenSelect,
+		*sqlparser.SQLVal,
+		*sqlparser.Limit,
+		*sqlparser.Order, sqlparser.OrderBy,
+		*sqlparser.Over,
+		sqlparser.Window, *sqlparser.WindowDef,
+		*sqlparser.ParenExpr,
+		*sqlparser.RangeCond,
+		*sqlparser.Subquery,
+		sqlparser.TableExprs, sqlparser.TableIdent,
+		*sqlparser.TimestampFuncExpr,
+		*sqlparser.TrimExpr,
+		sqlparser.ValTuple,
+		*sqlparser.Where:
+		return true
+	}
+	return false
+}
+
+// sqlFunctions are the functions SQL queries can call. They can't read other
+// tables or files, or run other queries, in any dialect.
+var sqlFunctions = []string{
+	// Conditional
+	"if", "coalesce", "ifnull", "isnull", "nullif", "least", "greatest", "iif",
+	// Aggregation
+	"sum", "avg", "count", "min", "max", "stddev", "std", "stddev_pop", "stddev_samp", "stdev", "stdevp",
+	"variance", "var_pop", "var_samp", "var", "varp", "group_concat", "string_agg", "bool_and", "bool_or",
+	// Window
+	"row_number", "rank", "dense_rank", "percent_rank", "cume_dist",
+	"first_value", "last_value", "nth_value", "ntile", "lead", "lag",
+	// Mathematical
+	"abs", "round", "floor", "ceiling", "ceil", "sqrt", "pow", "power", "mod", "log", "log2", "log10", "exp",
+	"sign", "ln", "trunc", "truncate", "sin", "cos", "tan", "cot", "asin", "acos", "atan", "atan2",
+	"degrees", "radians", "pi", "width_bucket",
+	// String
+	"concat", "concat_ws", "length", "len", "char_length", "character_length", "lower", "upper",
+	"substring", "substr", "left", "right", "ltrim", "rtrim", "btrim", "replace", "reverse",
+	"lpad", "rpad", "repeat", "replicate", "position", "strpos", "instr", "locate", "charindex",
+	"split_part", "initcap", "format", "to_char", "regexp_replace", "regexp_substr", "regexp_like",
+	// Date and time
+	"now", "current_timestamp", "getdate", "getutcdate", "sysdatetime", "utc_timestamp",
+	"date_trunc", "date_part", "datepart", "datename", "dateadd", "datediff", "date_bin",
+	"date_format", "date_add", "date_sub", "to_timestamp", "to_date", "unix_timestamp", "from_unixtime",
+	"year", "month", "day", "hour", "minute", "second", "week", "quarter", "dayofweek", "dayofmonth", "dayofyear",
+	"extract", "timestampdiff", "timestampadd", "make_interval", "age",
+	// Type conversion
+	"cast", "convert", "try_cast", "try_convert",
+}
+
+func allowedSQLFunction(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, strings.ToLower(sqlMacroPrefix)) || slices.Contains(sqlFunctions, name)
+}
+
+// enforceSQL checks that a SQL query only reads from the allowed tables of the
+// policy. SQL queries are never rewritten.
+func enforceSQL(policy *QueryPolicy, ds *datasources.DataSource, sql string) error {
+	dialect := sqlDialectOf(ds.Type)
+	if dialect == nil {
+		return fmt.Errorf("SQL queries of %s data sources can't be checked", ds.Type)
+	}
+	tables, err := dialect.tables(sql)
+	if err != nil {
+		return err
+	}
+	defaultSchema := strings.ToLower(dialect.defaultSchema(ds))
+	for _, table := range tables {
+		if !sqlTableAllowed(policy.AllowedTables, table, defaultSchema) {
+			return fmt.Errorf("table %s is not allowed", table)
+		}
+	}
+	return nil
+}
+
+// sqlTableAllowed returns true when the table is allowed. Tables without schema,
+// in the query or in the allowed tables, are in the default schema.
+func sqlTableAllowed(allowed []string, table sqlTable, defaultSchema string) bool {
+	if table.schema == "" {
+		table.schema = defaultSchema
+	}
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		schema, name := defaultSchema, a
+		if i := strings.LastIndex(a, "."); i >= 0 {
+			schema, name = a[:i], a[i+1:]
+		}
+		if table.schema == schema && table.name == name {
+			return true
+		}
+	}
+	return false
+}
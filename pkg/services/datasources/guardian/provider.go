@@ -1,8 +1,13 @@
 package guardian
 
 import (
+	"context"
+
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/setting"
 )
 
 type DatasourceGuardianProvider interface {
@@ -13,14 +18,42 @@ type DatasourceGuardian interface {
 	CanQuery(datasourceID int64) (bool, error)
 	FilterDatasourcesByReadPermissions([]*datasources.DataSource) ([]*datasources.DataSource, error)
 	FilterDatasourcesByQueryPermissions([]*datasources.DataSource) ([]*datasources.DataSource, error)
+	// CheckQuery returns an error when the query of the data source is not
+	// allowed. The query can be rewritten in place to comply with the policies.
+	CheckQuery(ctx context.Context, ds *datasources.DataSource, query *simplejson.Json) error
+	// CheckDatasourceRequest returns an error when the requests to the data
+	// source other than queries, like resource calls, are not allowed.
+	CheckDatasourceRequest(ctx context.Context, ds *datasources.DataSource) error
 }
 
-func ProvideGuardian() *OSSProvider {
-	return &OSSProvider{}
+func ProvideGuardian(cfg *setting.Cfg) (*OSSProvider, error) {
+	p := &OSSProvider{}
+	if cfg.DataSourceQueryPoliciesFile == "" {
+		return p, nil
+	}
+	policies, err := LoadQueryPolicies(cfg.DataSourceQueryPoliciesFile)
+	if err != nil {
+		return nil, err
+	}
+	p.policies = policies
+	p.audit = log.New("datasources.guardian.audit")
+	return p, nil
 }
 
-type OSSProvider struct{}
+type OSSProvider struct {
+	policies []QueryPolicy
+	audit    log.Logger
+}
 
 func (p *OSSProvider) New(orgID int64, user identity.Requester, dataSources ...datasources.DataSource) DatasourceGuardian {
-	return &AllowGuardian{}
+	var policies []*QueryPolicy
+	for i := range p.policies {
+		if p.policies[i].appliesTo(orgID, user) {
+			policies = append(policies, &p.policies[i])
+		}
+	}
+	if len(policies) == 0 {
+		return &AllowGuardian{}
+	}
+	return &PolicyGuardian{orgID: orgID, user: user, policies: policies, audit: p.audit}
 }
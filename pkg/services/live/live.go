@@ -73,7 +73,7 @@ func ProvideService(cfg *setting.Cfg, routeRegister routing.RouteRegister, plugC
 	pluginStore pluginstore.Store, pluginClient plugins.Client, dataSourceCache datasources.CacheService,
 	usageStatsService usagestats.Service, toggles featuremgmt.FeatureToggles,
 	dashboardService dashboards.DashboardAccessService,
-	configProvider apiserver.RestConfigProvider) (*GrafanaLive, error) {
+	configProvider apiserver.RestConfigProvider, history *managedstream.History) (*GrafanaLive, error) {
 	g := &GrafanaLive{
 		Cfg:                   cfg,
 		Features:              toggles,
@@ -87,6 +87,7 @@ func ProvideService(cfg *setting.Cfg, routeRegister routing.RouteRegister, plugC
 		},
 		usageStatsService: usageStatsService,
 		keyPrefix:         "gf_live",
+		outputWriters:     pipeline.NewOutputWriters(),
 	}
 
 	if cfg.LiveHAPrefix != "" {
@@ -157,16 +158,21 @@ func ProvideService(cfg *setting.Cfg, routeRegister routing.RouteRegister, plugC
 	}
 
 	if redisClient != nil {
+		if history.Enabled() {
+			history.UseRedis(redisClient, g.keyPrefix)
+		}
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewRedisFrameCache(redisClient, g.keyPrefix),
+			history,
 		)
 	} else {
 		managedStreamRunner = managedstream.NewRunner(
 			g.Publish,
 			channelLocalPublisher,
 			managedstream.NewMemoryFrameCache(),
+			history,
 		)
 	}
 
@@ -520,6 +526,8 @@ type GrafanaLive struct {
 	ManagedStreamRunner *managedstream.Runner
 	Pipeline            *pipeline.Pipeline
 	pipelineStorage     pipeline.Storage
+	// outputWriters are shared by the outputs of all pipeline rules, and closed on shutdown.
+	outputWriters *pipeline.OutputWriters
 
 	contextGetter    *liveplugin.ContextGetter
 	runStreamManager *runstream.Manager
@@ -555,6 +563,9 @@ func (g *GrafanaLive) getStreamPlugin(ctx context.Context, pluginID string) (bac
 }
 
 func (g *GrafanaLive) Run(ctx context.Context) error {
+	// Flush the messages buffered by the pipeline outputs on shutdown.
+	defer g.outputWriters.Close()
+
 	eGroup, eCtx := errgroup.WithContext(ctx)
 
 	eGroup.Go(func() error {
@@ -1258,6 +1269,19 @@ func (s *DryRunRuleStorage) ListChannelRules(_ context.Context, _ string) ([]pip
 	return s.ChannelRules, nil
 }
 
+// pipelineRuleBuilder returns a builder of the pipeline rules of the storage.
+func (g *GrafanaLive) pipelineRuleBuilder(storage pipeline.Storage) *pipeline.StorageRuleBuilder {
+	return &pipeline.StorageRuleBuilder{
+		Node:                 g.node,
+		ManagedStream:        g.ManagedStreamRunner,
+		FrameStorage:         pipeline.NewFrameStorage(),
+		AggregateStorage:     pipeline.NewAggregateStorage(),
+		OutputWriters:        g.outputWriters,
+		Storage:              storage,
+		ChannelHandlerGetter: g,
+	}
+}
+
 // HandlePipelineConvertTestHTTP ...
 func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext) response.Response {
 	body, err := io.ReadAll(c.Req.Body)
@@ -1272,13 +1296,7 @@ func (g *GrafanaLive) HandlePipelineConvertTestHTTP(c *contextmodel.ReqContext)
 	storage := &DryRunRuleStorage{
 		ChannelRules: req.ChannelRules,
 	}
-	builder := &pipeline.StorageRuleBuilder{
-		Node:                 g.node,
-		ManagedStream:        g.ManagedStreamRunner,
-		FrameStorage:         pipeline.NewFrameStorage(),
-		Storage:              storage,
-		ChannelHandlerGetter: g,
-	}
+	builder := g.pipelineRuleBuilder(storage)
 	channelRuleGetter := pipeline.NewCacheSegmentedTree(builder)
 	pipe, err := pipeline.New(channelRuleGetter)
 	if err != nil {
@@ -4,16 +4,20 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/centrifugal/centrifuge"
 	"github.com/go-jose/go-jose/v4"
 	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/grafana/pkg/api/routing"
@@ -21,6 +25,8 @@ import (
 	"github.com/grafana/grafana/pkg/infra/usagestats"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
 	"github.com/grafana/grafana/pkg/setting"
 	"github.com/grafana/grafana/pkg/tests/testsuite"
 	"github.com/grafana/grafana/pkg/util/testutil"
@@ -398,6 +404,55 @@ func Test_handleOnSubscribe_IDTokenExpiration(t *testing.T) {
 	})
 }
 
+type webhookRuleStorage struct {
+	DryRunRuleStorage
+	WriteConfigs []pipeline.WriteConfig
+}
+
+func (s *webhookRuleStorage) ListWriteConfigs(_ context.Context, _ string) ([]pipeline.WriteConfig, error) {
+	return s.WriteConfigs, nil
+}
+
+func Test_pipelineRuleBuilder_OutputWriters(t *testing.T) {
+	var received atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload pipeline.WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received.Add(int64(len(payload.Messages)))
+	}))
+	t.Cleanup(srv.Close)
+
+	g, err := setupLiveService(nil, t)
+	require.NoError(t, err)
+
+	storage := &webhookRuleStorage{
+		DryRunRuleStorage: DryRunRuleStorage{ChannelRules: []pipeline.ChannelRule{{
+			Pattern: "stream/test/webhook",
+			Settings: pipeline.ChannelRuleSettings{
+				FrameOutputters: []*pipeline.FrameOutputterConfig{{
+					Type:                pipeline.FrameOutputTypeWebhook,
+					WebhookOutputConfig: &pipeline.WebhookOutputConfig{UID: "webhook"},
+				}},
+			},
+		}}},
+		WriteConfigs: []pipeline.WriteConfig{{UID: "webhook", Settings: pipeline.WriteSettings{Endpoint: srv.URL}}},
+	}
+	builder := g.pipelineRuleBuilder(storage)
+	require.Same(t, builder.OutputWriters, g.pipelineRuleBuilder(storage).OutputWriters)
+
+	rules, err := builder.BuildRules(context.Background(), "default")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	_, err = rules[0].FrameOutputters[0].OutputFrame(context.Background(), pipeline.Vars{Channel: "stream/test/webhook"}, data.NewFrame("test"))
+	require.NoError(t, err)
+
+	// The message buffered by the webhook output is flushed when Live shuts down.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.ErrorIs(t, g.Run(ctx), context.Canceled)
+	require.Equal(t, int64(1), received.Load())
+}
+
 func setupLiveService(cfg *setting.Cfg, t *testing.T) (*GrafanaLive, error) {
 	if cfg == nil {
 		cfg = setting.NewCfg()
@@ -410,7 +465,8 @@ func setupLiveService(cfg *setting.Cfg, t *testing.T) (*GrafanaLive, error) {
 		&usagestats.UsageStatsMock{T: t},
 		featuremgmt.WithFeatures(),
 		&dashboards.FakeDashboardService{},
-		nil)
+		nil,
+		managedstream.ProvideHistory(cfg))
 }
 
 type dummyTransport struct {
@@ -0,0 +1,269 @@
+package managedstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// maxHistoryPoints limits the points kept per channel when the history is only limited by age.
+	maxHistoryPoints = 10000
+	// historyEvictInterval is the minimum interval between two evictions of the idle channels.
+	historyEvictInterval = time.Minute
+)
+
+// History keeps the last points pushed to managed stream channels, so that new
+// subscribers and queries get the recent context of a channel and not only its
+// last frame. The points are kept in memory, or in Redis when Live runs with the
+// Redis HA engine.
+type History struct {
+	maxPoints int
+	maxAge    time.Duration
+
+	mu    sync.RWMutex
+	store historyStore
+}
+
+// historyStore stores the rows of the channels history.
+type historyStore interface {
+	add(ctx context.Context, key string, frame *data.Frame) error
+	// get returns the last rows of a channel, at least maxRows rows when there
+	// are enough. Rows older than since may be returned.
+	get(ctx context.Context, key string, since time.Time, maxRows int) (*data.Frame, bool, error)
+}
+
+// ProvideHistory creates the managed streams History from the [live] settings.
+func ProvideHistory(cfg *setting.Cfg) *History {
+	return NewHistory(cfg.LiveHistoryMaxPoints, cfg.LiveHistoryMaxAge)
+}
+
+// NewHistory creates a History keeping the last maxPoints points per channel,
+// not older than maxAge. The History is disabled when both are 0.
+func NewHistory(maxPoints int, maxAge time.Duration) *History {
+	if maxPoints <= 0 && maxAge > 0 {
+		maxPoints = maxHistoryPoints
+	}
+	h := &History{maxPoints: maxPoints, maxAge: maxAge}
+	h.store = newMemoryHistoryStore(h.maxPoints, maxAge)
+	return h
+}
+
+// UseRedis stores the history in Redis, to share it between Grafana instances.
+func (h *History) UseRedis(redisClient *redis.Client, keyPrefix string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.store = newRedisHistoryStore(redisClient, keyPrefix, h.maxPoints, h.maxAge)
+}
+
+// Enabled returns true when the history is configured.
+func (h *History) Enabled() bool {
+	return h != nil && h.maxPoints > 0
+}
+
+func (h *History) getStore() historyStore {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.store
+}
+
+// Add appends the rows of a frame to the history of a channel. The history is
+// reset when the frame schema changes.
+func (h *History) Add(ctx context.Context, ns string, channel string, frame *data.Frame) error {
+	if !h.Enabled() {
+		return nil
+	}
+	if _, err := frame.RowLen(); err != nil {
+		return err
+	}
+	return h.getStore().add(ctx, orgchannel.PrependK8sNamespace(ns, channel), frame)
+}
+
+// Get returns the history of a channel as a single frame. Only the rows not
+// older than since are returned, a zero since returns the whole history.
+func (h *History) Get(ctx context.Context, ns string, channel string, since time.Time) (*data.Frame, bool, error) {
+	if !h.Enabled() {
+		return nil, false, nil
+	}
+	if h.maxAge > 0 {
+		if minTime := time.Now().Add(-h.maxAge); since.Before(minTime) {
+			since = minTime
+		}
+	}
+	frame, ok, err := h.getStore().get(ctx, orgchannel.PrependK8sNamespace(ns, channel), since, h.maxPoints)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	frame = h.trim(frame, since)
+	if frame.Rows() == 0 {
+		return nil, false, nil
+	}
+	return frame, true, nil
+}
+
+// trim drops the rows older than since and keeps the last maxPoints rows.
+func (h *History) trim(frame *data.Frame, since time.Time) *data.Frame {
+	rows := frame.Rows()
+	first := max(rows-h.maxPoints, 0)
+	if !since.IsZero() {
+		if timeIndex := historyTimeIndex(frame); timeIndex >= 0 {
+			for first < rows {
+				t, ok := frame.Fields[timeIndex].ConcreteAt(first)
+				if ok && !t.(time.Time).Before(since) {
+					break
+				}
+				first++
+			}
+		}
+	}
+	if first == 0 {
+		return frame
+	}
+	trimmed := frame.EmptyCopy()
+	for i := first; i < rows; i++ {
+		trimmed.AppendRow(frame.RowCopy(i)...)
+	}
+	return trimmed
+}
+
+func historyTimeIndex(frame *data.Frame) int {
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			return i
+		}
+	}
+	return -1
+}
+
+// historyRowTime returns the value of the time field of a row copy.
+func historyRowTime(v any) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		if t != nil {
+			return *t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func sameSchema(a, b *data.Frame) bool {
+	if a.Name != b.Name || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() || !a.Fields[i].Labels.Equals(b.Fields[i].Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// memoryHistoryStore keeps the last rows of each channel in memory. The rows
+// older than maxAge are dropped when rows are added, and the channels not
+// updated for a while are evicted.
+type memoryHistoryStore struct {
+	mu          sync.Mutex
+	capacity    int
+	maxAge      time.Duration
+	idleTimeout time.Duration
+	lastEvict   time.Time
+	channels    map[string]*historyBuffer
+}
+
+type historyBuffer struct {
+	// schema is an empty copy of the frames in the buffer.
+	schema    *data.Frame
+	timeIndex int
+	// rows are ordered from the oldest to the newest.
+	rows    [][]any
+	updated time.Time
+}
+
+func newMemoryHistoryStore(capacity int, maxAge time.Duration) *memoryHistoryStore {
+	// Once a channel is idle for maxAge all its rows are expired, without age
+	// limit channels are kept as long as in Redis.
+	idleTimeout := frameCacheTTL
+	if maxAge > 0 {
+		idleTimeout = maxAge
+	}
+	return &memoryHistoryStore{
+		capacity:    capacity,
+		maxAge:      maxAge,
+		idleTimeout: idleTimeout,
+		lastEvict:   time.Now(),
+		channels:    map[string]*historyBuffer{},
+	}
+}
+
+func (s *memoryHistoryStore) add(_ context.Context, key string, frame *data.Frame) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictIdle(now)
+
+	buffer, ok := s.channels[key]
+	if !ok || !sameSchema(buffer.schema, frame) {
+		buffer = &historyBuffer{schema: frame.EmptyCopy(), timeIndex: historyTimeIndex(frame)}
+		s.channels[key] = buffer
+	}
+	buffer.updated = now
+	for i := range frame.Rows() {
+		buffer.rows = append(buffer.rows, frame.RowCopy(i))
+	}
+	first := max(len(buffer.rows)-s.capacity, 0)
+	if s.maxAge > 0 && buffer.timeIndex >= 0 {
+		minTime := now.Add(-s.maxAge)
+		for first < len(buffer.rows) {
+			t, ok := historyRowTime(buffer.rows[first][buffer.timeIndex])
+			if ok && !t.Before(minTime) {
+				break
+			}
+			first++
+		}
+	}
+	if first > 0 {
+		// Copy the kept rows, so the dropped rows can be garbage collected.
+		buffer.rows = append(make([][]any, 0, len(buffer.rows)-first), buffer.rows[first:]...)
+	}
+	return nil
+}
+
+// evictIdle removes the channels not updated for idleTimeout, at most once per historyEvictInterval.
+func (s *memoryHistoryStore) evictIdle(now time.Time) {
+	if now.Sub(s.lastEvict) < historyEvictInterval {
+		return
+	}
+	s.lastEvict = now
+	for key, buffer := range s.channels {
+		if now.Sub(buffer.updated) >= s.idleTimeout {
+			delete(s.channels, key)
+		}
+	}
+}
+
+func (s *memoryHistoryStore) get(_ context.Context, key string, since time.Time, _ int) (*data.Frame, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	buffer, ok := s.channels[key]
+	if !ok {
+		return nil, false, nil
+	}
+	frame := buffer.schema.EmptyCopy()
+	for _, row := range buffer.rows {
+		if !since.IsZero() && buffer.timeIndex >= 0 {
+			if t, ok := historyRowTime(row[buffer.timeIndex]); ok && t.Before(since) {
+				continue
+			}
+		}
+		frame.AppendRow(row...)
+	}
+	return frame, true, nil
+}
@@ -0,0 +1,122 @@
+package managedstream
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/redis/go-redis/v9"
+)
+
+// historyReadChunkSize is the number of frames read from Redis at once.
+const historyReadChunkSize = 100
+
+// redisHistoryStore keeps the frames of each channel in a Redis list, so the
+// history is shared between Grafana instances.
+type redisHistoryStore struct {
+	redisClient *redis.Client
+	keyPrefix   string
+	maxFrames   int
+	ttl         time.Duration
+}
+
+func newRedisHistoryStore(redisClient *redis.Client, keyPrefix string, maxFrames int, maxAge time.Duration) *redisHistoryStore {
+	ttl := frameCacheTTL
+	if maxAge > 0 {
+		ttl = maxAge
+	}
+	return &redisHistoryStore{
+		redisClient: redisClient,
+		keyPrefix:   keyPrefix,
+		maxFrames:   maxFrames,
+		ttl:         ttl,
+	}
+}
+
+func (s *redisHistoryStore) add(ctx context.Context, key string, frame *data.Frame) error {
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return err
+	}
+	// Frames contain at least a row, so keeping maxFrames frames keeps enough rows.
+	key = s.getKey(key)
+	_, err = s.redisClient.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, frameJSON)
+		pipe.LTrim(ctx, key, int64(-s.maxFrames), -1)
+		pipe.Expire(ctx, key, s.ttl)
+		return nil
+	})
+	return err
+}
+
+// get reads the frames from the newest to the oldest in chunks, and stops once
+// maxRows rows are read, at a frame older than since, or at a schema change,
+// so that the whole list is not read on every subscription.
+func (s *redisHistoryStore) get(ctx context.Context, key string, since time.Time, maxRows int) (*data.Frame, bool, error) {
+	key = s.getKey(key)
+	var frames []*data.Frame
+	rows := 0
+	end := int64(-1)
+	for {
+		start := end - historyReadChunkSize + 1
+		result, err := s.redisClient.LRange(ctx, key, start, end).Result()
+		if err != nil {
+			return nil, false, err
+		}
+		for i := len(result) - 1; i >= 0; i-- {
+			frame := &data.Frame{}
+			if err := frame.UnmarshalJSON([]byte(result[i])); err != nil {
+				return nil, false, fmt.Errorf("error unmarshaling history frame: %w", err)
+			}
+			// Only the frames with the schema of the latest frame are kept.
+			if len(frames) > 0 && !sameSchema(frames[0], frame) {
+				return mergeHistoryFrames(frames)
+			}
+			// Frames are pushed in time order, the older frames are not needed.
+			if last, ok := lastFrameTime(frame); ok && last.Before(since) {
+				return mergeHistoryFrames(frames)
+			}
+			frames = append(frames, frame)
+			if rows += frame.Rows(); rows >= maxRows {
+				return mergeHistoryFrames(frames)
+			}
+		}
+		if len(result) < historyReadChunkSize {
+			return mergeHistoryFrames(frames)
+		}
+		end = start - 1
+	}
+}
+
+// mergeHistoryFrames merges frames ordered from the newest to the oldest in a single frame.
+func mergeHistoryFrames(frames []*data.Frame) (*data.Frame, bool, error) {
+	if len(frames) == 0 {
+		return nil, false, nil
+	}
+	history := frames[0].EmptyCopy()
+	for i := len(frames) - 1; i >= 0; i-- {
+		for row := range frames[i].Rows() {
+			history.AppendRow(frames[i].RowCopy(row)...)
+		}
+	}
+	return history, true, nil
+}
+
+// lastFrameTime returns the time of the last row of a frame.
+func lastFrameTime(frame *data.Frame) (time.Time, bool) {
+	timeIndex := historyTimeIndex(frame)
+	rows := frame.Rows()
+	if timeIndex < 0 || rows == 0 {
+		return time.Time{}, false
+	}
+	t, ok := frame.Fields[timeIndex].ConcreteAt(rows - 1)
+	if !ok {
+		return time.Time{}, false
+	}
+	return t.(time.Time), true
+}
+
+func (s *redisHistoryStore) getKey(channelID string) string {
+	return s.keyPrefix + ".managed_stream.history." + channelID
+}
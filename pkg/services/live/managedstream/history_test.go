@@ -0,0 +1,182 @@
+package managedstream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+func historyFrame(values ...float64) *data.Frame {
+	times := make([]time.Time, len(values))
+	for i := range values {
+		times[i] = time.Now()
+	}
+	return data.NewFrame("test",
+		data.NewField("time", nil, times),
+		data.NewField("value", nil, values),
+	)
+}
+
+func TestHistory_MaxPoints(t *testing.T) {
+	h := NewHistory(3, 0)
+	require.True(t, h.Enabled())
+
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", historyFrame(1, 2)))
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", historyFrame(3, 4)))
+
+	frame, ok, err := h.Get(context.Background(), "default", "stream/test/a", time.Time{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 3, frame.Rows())
+	require.Equal(t, 2.0, frame.Fields[1].At(0))
+	require.Equal(t, 4.0, frame.Fields[1].At(2))
+
+	// History is kept per namespace and channel.
+	_, ok, err = h.Get(context.Background(), "stacks-1", "stream/test/a", time.Time{})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// A schema change resets the history.
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Now()}),
+		data.NewField("other", nil, []float64{5}),
+	)))
+	frame, ok, err = h.Get(context.Background(), "default", "stream/test/a", time.Time{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, "other", frame.Fields[1].Name)
+}
+
+func TestHistory_MaxAge(t *testing.T) {
+	h := NewHistory(0, time.Minute)
+	require.True(t, h.Enabled())
+
+	old := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{time.Now().Add(-time.Hour)}),
+		data.NewField("value", nil, []float64{1}),
+	)
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", old))
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", historyFrame(2)))
+
+	frame, ok, err := h.Get(context.Background(), "default", "stream/test/a", time.Time{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, frame.Rows())
+	require.Equal(t, 2.0, frame.Fields[1].At(0))
+}
+
+func TestHistory_Disabled(t *testing.T) {
+	var h *History
+	require.False(t, h.Enabled())
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", historyFrame(1)))
+
+	h = NewHistory(0, 0)
+	require.False(t, h.Enabled())
+	_, ok, err := h.Get(context.Background(), "default", "stream/test/a", time.Time{})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestHistory_Since(t *testing.T) {
+	h := NewHistory(10, 0)
+	now := time.Now()
+	require.NoError(t, h.Add(context.Background(), "default", "stream/test/a", data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{now.Add(-time.Hour), now.Add(-time.Minute), now}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)))
+
+	frame, ok, err := h.Get(context.Background(), "default", "stream/test/a", now.Add(-10*time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, frame.Rows())
+	require.Equal(t, 2.0, frame.Fields[1].At(0))
+
+	_, ok, err = h.Get(context.Background(), "default", "stream/test/a", now.Add(time.Minute))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestMemoryHistoryStore(t *testing.T) {
+	t.Run("rows older than max age are dropped on add", func(t *testing.T) {
+		s := newMemoryHistoryStore(10, time.Minute)
+		require.NoError(t, s.add(context.Background(), "a", data.NewFrame("test",
+			data.NewField("time", nil, []*time.Time{new(time.Now().Add(-time.Hour)), new(time.Now())}),
+			data.NewField("value", nil, []float64{1, 2}),
+		)))
+		require.Len(t, s.channels["a"].rows, 1)
+		require.Equal(t, 2.0, s.channels["a"].rows[0][1])
+	})
+
+	t.Run("idle channels are evicted", func(t *testing.T) {
+		s := newMemoryHistoryStore(10, time.Minute)
+		require.NoError(t, s.add(context.Background(), "idle", historyFrame(1)))
+		require.NoError(t, s.add(context.Background(), "active", historyFrame(1)))
+		s.channels["idle"].updated = time.Now().Add(-2 * time.Minute)
+
+		// Evictions are rate limited.
+		require.NoError(t, s.add(context.Background(), "active", historyFrame(2)))
+		require.Contains(t, s.channels, "idle")
+
+		s.lastEvict = time.Now().Add(-historyEvictInterval)
+		require.NoError(t, s.add(context.Background(), "active", historyFrame(3)))
+		require.NotContains(t, s.channels, "idle")
+		require.Contains(t, s.channels, "active")
+	})
+}
+
+func TestHistory_Redis(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = redisClient.Close() })
+
+	h := NewHistory(1000, 0)
+	h.UseRedis(redisClient, "test")
+	ctx := context.Background()
+	now := time.Now()
+	old := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{now.Add(-2 * time.Hour)}),
+		data.NewField("value", nil, []float64{0}),
+	)
+	require.NoError(t, h.Add(ctx, "default", "stream/test/a", old))
+	for i := range 2 * historyReadChunkSize {
+		require.NoError(t, h.Add(ctx, "default", "stream/test/a", historyFrame(float64(i))))
+	}
+
+	t.Run("reads stop once enough rows are read", func(t *testing.T) {
+		h := NewHistory(3, time.Hour)
+		h.UseRedis(redisClient, "test")
+		before := mr.CommandCount()
+		frame, ok, err := h.Get(ctx, "default", "stream/test/a", time.Time{})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 3, frame.Rows())
+		require.Equal(t, float64(2*historyReadChunkSize-1), frame.Fields[1].At(2))
+		require.Equal(t, 1, mr.CommandCount()-before)
+	})
+
+	t.Run("reads stop at frames older than since", func(t *testing.T) {
+		frame, ok, err := h.Get(ctx, "default", "stream/test/a", now.Add(-time.Hour))
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 2*historyReadChunkSize, frame.Rows())
+	})
+
+	t.Run("a schema change resets the history", func(t *testing.T) {
+		require.NoError(t, h.Add(ctx, "default", "stream/test/a", data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{time.Now()}),
+			data.NewField("other", nil, []float64{5}),
+		)))
+		frame, ok, err := h.Get(ctx, "default", "stream/test/a", time.Time{})
+		require.NoError(t, err)
+		require.True(t, ok)
+		require.Equal(t, 1, frame.Rows())
+		require.Equal(t, "other", frame.Fields[1].Name)
+	})
+}
@@ -42,19 +42,21 @@ type Runner struct {
 	publisher      model.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	history        *History
 }
 
 type LocalPublisher interface {
 	PublishLocal(channel string, data []byte) error
 }
 
-// NewRunner creates new Runner.
-func NewRunner(publisher model.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache) *Runner {
+// NewRunner creates new Runner. The history is optional.
+func NewRunner(publisher model.ChannelPublisher, localPublisher LocalPublisher, frameCache FrameCache, history *History) *Runner {
 	return &Runner{
 		publisher:      publisher,
 		localPublisher: localPublisher,
 		streams:        map[string]map[string]*Stream{},
 		frameCache:     frameCache,
+		history:        history,
 	}
 }
 
@@ -127,7 +129,7 @@ func (r *Runner) GetOrCreateStream(ns string, scope string, stream string) (*Str
 	prefix := scope + "/" + stream
 	s, ok := r.streams[ns][prefix]
 	if !ok {
-		s = NewStream(ns, scope, stream, r.publisher, r.localPublisher, r.frameCache)
+		s = NewStream(ns, scope, stream, r.publisher, r.localPublisher, r.frameCache, r.history)
 		r.streams[ns][prefix] = s
 	}
 	return s, nil
@@ -141,6 +143,7 @@ type Stream struct {
 	publisher      model.ChannelPublisher
 	localPublisher LocalPublisher
 	frameCache     FrameCache
+	history        *History
 	rateMu         sync.RWMutex
 	rates          map[string][60]rateEntry
 }
@@ -158,7 +161,7 @@ type ManagedChannel struct {
 }
 
 // NewStream creates new NewStream.
-func NewStream(ns string, scope string, stream string, publisher model.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache) *Stream {
+func NewStream(ns string, scope string, stream string, publisher model.ChannelPublisher, localPublisher LocalPublisher, schemaUpdater FrameCache, history *History) *Stream {
 	return &Stream{
 		ns:             ns,
 		scope:          scope,
@@ -166,12 +169,14 @@ func NewStream(ns string, scope string, stream string, publisher model.ChannelPu
 		publisher:      publisher,
 		localPublisher: localPublisher,
 		frameCache:     schemaUpdater,
+		history:        history,
 		rates:          map[string][60]rateEntry{},
 	}
 }
 
 // Push sends frame to the stream and saves it for later retrieval by subscribers.
 // * Saves the entire frame to cache.
+// * Appends the frame rows to the channel history when it's enabled.
 // * If schema has been changed sends entire frame to channel, otherwise only data.
 func (s *Stream) Push(ctx context.Context, path string, frame *data.Frame) error {
 	jsonFrameCache, err := data.FrameToJSONCache(frame)
@@ -188,6 +193,10 @@ func (s *Stream) Push(ctx context.Context, path string, frame *data.Frame) error
 		return err
 	}
 
+	if err := s.history.Add(ctx, s.ns, channel, frame); err != nil {
+		logger.Error("Error adding frame to managed stream history", "channel", channel, "error", err)
+	}
+
 	// When the schema has not changed, just send the data.
 	include := data.IncludeDataOnly
 	if isUpdated {
@@ -243,6 +252,19 @@ func (s *Stream) GetHandlerForPath(_ string) (model.ChannelHandler, error) {
 
 func (s *Stream) OnSubscribe(ctx context.Context, u identity.Requester, e model.SubscribeEvent) (model.SubscribeReply, backend.SubscribeStreamStatus, error) {
 	reply := model.SubscribeReply{}
+	if s.history.Enabled() {
+		frame, ok, err := s.history.Get(ctx, u.GetNamespace(), e.Channel, time.Time{})
+		if err != nil {
+			logger.Error("Error getting managed stream history", "channel", e.Channel, "error", err)
+		} else if ok {
+			frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+			if err != nil {
+				return reply, 0, err
+			}
+			reply.Data = frameJSON
+			return reply, backend.SubscribeStreamStatusOK, nil
+		}
+	}
 	frameJSON, ok, err := s.frameCache.GetFrame(ctx, u.GetNamespace(), e.Channel)
 	if err != nil {
 		return reply, 0, err
@@ -20,13 +20,13 @@ func (p *testPublisher) publish(_ string, _ string, _ []byte) error {
 
 func TestNewManagedStream(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewStream("default", "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewStream("default", "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil)
 	require.NotNil(t, c)
 }
 
 func TestManagedStreamMinuteRate(t *testing.T) {
 	publisher := &testPublisher{t: t}
-	c := NewStream("default", "stream", "a", publisher.publish, nil, NewMemoryFrameCache())
+	c := NewStream("default", "stream", "a", publisher.publish, nil, NewMemoryFrameCache(), nil)
 	require.NotNil(t, c)
 
 	c.incRate("test1", time.Now().Unix())
@@ -49,7 +49,7 @@ func TestManagedStreamMinuteRate(t *testing.T) {
 func TestGetManagedStreams(t *testing.T) {
 	publisher := &testPublisher{t: t}
 	frameCache := NewMemoryFrameCache()
-	runner := NewRunner(publisher.publish, nil, frameCache)
+	runner := NewRunner(publisher.publish, nil, frameCache, nil)
 	s1, err := runner.GetOrCreateStream("default", "stream", "test1")
 	require.NoError(t, err)
 	s2, err := runner.GetOrCreateStream("default", "stream", "test2")
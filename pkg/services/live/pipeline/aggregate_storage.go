@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/services/live/orgchannel"
+)
+
+// AggregateStorage keeps the current window of each channel aggregated by
+// AggregateFrameProcessor in memory. The processors are recreated when the rules
+// are rebuilt, so the windows are kept outside them to complete windows longer
+// than the rebuild interval. Not usable in HA setup.
+type AggregateStorage struct {
+	mu      sync.Mutex
+	windows map[string]*aggregateWindow
+}
+
+type aggregateWindow struct {
+	start time.Time
+	rows  *data.Frame
+}
+
+func NewAggregateStorage() *AggregateStorage {
+	return &AggregateStorage{
+		windows: map[string]*aggregateWindow{},
+	}
+}
+
+// update calls fn with the current window of the channel for the window
+// duration, and keeps the window fn returns. Calls are serialized.
+func (s *AggregateStorage) update(ns string, channel string, window time.Duration, fn func(current *aggregateWindow) *aggregateWindow) {
+	key := orgchannel.PrependK8sNamespace(ns, channel) + "@" + window.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := fn(s.windows[key])
+	if current == nil {
+		delete(s.windows, key)
+		return
+	}
+	s.windows[key] = current
+}
@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	batchFlushInterval     = time.Second
+	defaultOutputBatchSize = 100
+	defaultOutputRetries   = 3
+	batchRetryBackoff      = 500 * time.Millisecond
+	// maxBatchBufferSize limits the messages kept in memory while an endpoint is
+	// unavailable, the oldest messages are dropped above it.
+	maxBatchBufferSize = 10000
+)
+
+// errPermanentOutput marks send errors that won't succeed on retry, the batch is dropped.
+var errPermanentOutput = errors.New("permanent output error")
+
+// partialOutputError is returned by send when only some messages of a batch
+// failed, only the failed messages are retried.
+type partialOutputError struct {
+	failed []outputMessage
+	err    error
+}
+
+func (e *partialOutputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *partialOutputError) Unwrap() error {
+	return e.err
+}
+
+// outputMessage is a frame encoded to JSON or raw data sent by the batching outputs.
+type outputMessage struct {
+	Channel string
+	Time    time.Time
+	// IsFrame is true when Payload is a JSON encoded frame, and false for raw channel data.
+	IsFrame bool
+	Payload []byte
+}
+
+// batchWriter buffers messages and sends them in batches, either every
+// batchFlushInterval or as soon as a batch is full. Failed batches are retried
+// with an exponential backoff, and put back in the buffer for the next flush
+// when all attempts failed. The flush goroutine is started by the first write,
+// and stopped by stop.
+type batchWriter struct {
+	mu      sync.Mutex
+	buffer  []outputMessage
+	started bool
+	stopped bool
+
+	batchSize    int
+	maxRetries   int
+	retryBackoff time.Duration
+	send         func([]outputMessage) error
+	flushSignal  chan struct{}
+	stopCh       chan struct{}
+	done         chan struct{}
+}
+
+func newBatchWriter(batchSize int, maxRetries int, send func([]outputMessage) error) *batchWriter {
+	if batchSize <= 0 {
+		batchSize = defaultOutputBatchSize
+	}
+	if maxRetries <= 0 {
+		maxRetries = defaultOutputRetries
+	}
+	return &batchWriter{
+		batchSize:    batchSize,
+		maxRetries:   maxRetries,
+		retryBackoff: batchRetryBackoff,
+		send:         send,
+		flushSignal:  make(chan struct{}, 1),
+		stopCh:       make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+func (w *batchWriter) write(m outputMessage) {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		logger.Debug("Output is closed, dropping message", "channel", m.Channel)
+		return
+	}
+	if !w.started {
+		w.started = true
+		go w.flushPeriodically()
+	}
+	if len(w.buffer) >= maxBatchBufferSize {
+		logger.Warn("Output buffer is full, dropping oldest message", "channel", w.buffer[0].Channel)
+		w.buffer = w.buffer[1:]
+	}
+	w.buffer = append(w.buffer, m)
+	full := len(w.buffer) >= w.batchSize
+	w.mu.Unlock()
+	if full {
+		select {
+		case w.flushSignal <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *batchWriter) flushPeriodically() {
+	defer close(w.done)
+	ticker := time.NewTicker(batchFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.flushSignal:
+		case <-w.stopCh:
+			w.flush()
+			w.mu.Lock()
+			unsent := len(w.buffer)
+			w.buffer = nil
+			w.mu.Unlock()
+			if unsent > 0 {
+				logger.Warn("Output closed, dropping unsent messages", "numMessages", unsent)
+			}
+			return
+		}
+		w.flush()
+	}
+}
+
+// stop rejects new messages, and waits until the buffered messages are flushed.
+func (w *batchWriter) stop() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.stopped = true
+	started := w.started
+	w.mu.Unlock()
+	if started {
+		close(w.stopCh)
+		<-w.done
+	}
+}
+
+// flush sends all buffered messages, and stops at the first batch that can't be sent.
+func (w *batchWriter) flush() {
+	for {
+		w.mu.Lock()
+		n := min(len(w.buffer), w.batchSize)
+		if n == 0 {
+			w.mu.Unlock()
+			return
+		}
+		batch := w.buffer[:n:n]
+		w.buffer = w.buffer[n:]
+		w.mu.Unlock()
+
+		unsent, err := w.sendWithRetry(batch)
+		if errors.Is(err, errPermanentOutput) {
+			logger.Error("Dropping output batch", "error", err, "numMessages", len(batch))
+			continue
+		}
+		if err != nil {
+			logger.Error("Error flush output batch", "error", err, "numMessages", len(unsent))
+			w.mu.Lock()
+			w.buffer = append(unsent, w.buffer...)
+			if len(w.buffer) > maxBatchBufferSize {
+				w.buffer = w.buffer[len(w.buffer)-maxBatchBufferSize:]
+			}
+			w.mu.Unlock()
+			return
+		}
+	}
+}
+
+// sendWithRetry returns the messages that could not be sent with the last error.
+func (w *batchWriter) sendWithRetry(batch []outputMessage) ([]outputMessage, error) {
+	var err error
+	for attempt := range w.maxRetries + 1 {
+		if attempt > 0 {
+			time.Sleep(w.retryBackoff << (attempt - 1))
+		}
+		if err = w.send(batch); err == nil || errors.Is(err, errPermanentOutput) {
+			return nil, err
+		}
+		var partial *partialOutputError
+		if errors.As(err, &partial) {
+			batch = partial.failed
+		}
+		logger.Debug("Error sending output batch", "error", err, "attempt", attempt+1, "numMessages", len(batch))
+	}
+	return batch, err
+}
+
+// outputResponseError checks the response code of a batching output request.
+// Client errors other than 429 won't succeed on retry, so they are reported as
+// errPermanentOutput.
+func outputResponseError(code int, name string) error {
+	if code >= http.StatusBadRequest && code < http.StatusInternalServerError && code != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: unexpected response code %d from %s", errPermanentOutput, code, name)
+	}
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected response code %d from %s", code, name)
+	}
+	return nil
+}
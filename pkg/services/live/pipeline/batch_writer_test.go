@@ -0,0 +1,146 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testOutputMessages(n int) []outputMessage {
+	messages := make([]outputMessage, 0, n)
+	for i := range n {
+		messages = append(messages, outputMessage{Channel: "stream/test/1", Time: time.Now(), Payload: fmt.Appendf(nil, "%d", i)})
+	}
+	return messages
+}
+
+// bufferMessages adds messages to the buffer without starting the flush goroutine.
+func bufferMessages(w *batchWriter, messages []outputMessage) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buffer = append(w.buffer, messages...)
+}
+
+func TestBatchWriter_flush(t *testing.T) {
+	var batches [][]outputMessage
+	w := newBatchWriter(2, 1, func(batch []outputMessage) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	bufferMessages(w, testOutputMessages(5))
+	w.flush()
+
+	require.Len(t, batches, 3)
+	require.Len(t, batches[0], 2)
+	require.Len(t, batches[1], 2)
+	require.Len(t, batches[2], 1)
+	require.Equal(t, []byte("4"), batches[2][0].Payload)
+	require.Empty(t, w.buffer)
+}
+
+func TestBatchWriter_retry(t *testing.T) {
+	attempts := 0
+	w := newBatchWriter(10, 2, func(batch []outputMessage) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	w.retryBackoff = time.Millisecond
+	bufferMessages(w, testOutputMessages(3))
+	w.flush()
+
+	require.Equal(t, 3, attempts)
+	require.Empty(t, w.buffer)
+}
+
+func TestBatchWriter_keepsFailedBatches(t *testing.T) {
+	attempts := 0
+	w := newBatchWriter(2, 1, func(batch []outputMessage) error {
+		attempts++
+		return errors.New("unavailable")
+	})
+	w.retryBackoff = time.Millisecond
+	bufferMessages(w, testOutputMessages(3))
+	w.flush()
+
+	// The flush stops at the first failed batch and keeps the messages in order.
+	require.Equal(t, 2, attempts)
+	require.Len(t, w.buffer, 3)
+	require.Equal(t, []byte("0"), w.buffer[0].Payload)
+	require.Equal(t, []byte("2"), w.buffer[2].Payload)
+}
+
+func TestBatchWriter_dropsPermanentErrors(t *testing.T) {
+	attempts := 0
+	w := newBatchWriter(2, 3, func(batch []outputMessage) error {
+		attempts++
+		return fmt.Errorf("%w: bad request", errPermanentOutput)
+	})
+	bufferMessages(w, testOutputMessages(3))
+	w.flush()
+
+	require.Equal(t, 2, attempts)
+	require.Empty(t, w.buffer)
+}
+
+func TestBatchWriter_retriesFailedMessages(t *testing.T) {
+	var batches [][]outputMessage
+	w := newBatchWriter(10, 2, func(batch []outputMessage) error {
+		batches = append(batches, batch)
+		if len(batches) == 1 {
+			return &partialOutputError{failed: batch[1:2], err: errors.New("record failed")}
+		}
+		return nil
+	})
+	w.retryBackoff = time.Millisecond
+	bufferMessages(w, testOutputMessages(3))
+	w.flush()
+
+	require.Len(t, batches, 2)
+	require.Len(t, batches[0], 3)
+	require.Equal(t, []outputMessage{batches[0][1]}, batches[1])
+	require.Empty(t, w.buffer)
+}
+
+func TestBatchWriter_keepsFailedMessages(t *testing.T) {
+	w := newBatchWriter(10, 1, func(batch []outputMessage) error {
+		return &partialOutputError{failed: batch[:1], err: errors.New("record failed")}
+	})
+	w.retryBackoff = time.Millisecond
+	bufferMessages(w, testOutputMessages(3))
+	w.flush()
+
+	require.Len(t, w.buffer, 1)
+	require.Equal(t, []byte("0"), w.buffer[0].Payload)
+}
+
+func TestBatchWriter_stop(t *testing.T) {
+	var mu sync.Mutex
+	var sent []outputMessage
+	w := newBatchWriter(10, 1, func(batch []outputMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, batch...)
+		return nil
+	})
+	// Stopping a writer that never received a message doesn't block.
+	newBatchWriter(10, 1, w.send).stop()
+
+	for _, m := range testOutputMessages(3) {
+		w.write(m)
+	}
+	w.stop()
+
+	// The buffered messages are flushed by stop, and later messages are dropped.
+	w.write(testOutputMessages(1)[0])
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, sent, 3)
+	require.Empty(t, w.buffer)
+}
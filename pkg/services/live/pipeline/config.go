@@ -50,11 +50,57 @@ type KeepFieldsFrameProcessorConfig struct {
 	FieldNames []string `json:"fieldNames"`
 }
 
+type RenameFieldsFrameProcessorConfig struct {
+	// Fields maps field names to their new names.
+	Fields map[string]string `json:"fields,omitempty"`
+	// Labels maps label keys to their new keys.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type AddLabelsFrameProcessorConfig struct {
+	Labels map[string]string `json:"labels"`
+	// FieldNames to add the labels to, all fields except time fields when empty.
+	FieldNames []string `json:"fieldNames,omitempty"`
+}
+
+type ComputeFieldFrameProcessorConfig struct {
+	FieldName string `json:"fieldName"`
+	// Expression computing the field value, with other fields referenced as $name or ${name}.
+	Expression string `json:"expression"`
+	Unit       string `json:"unit,omitempty"`
+}
+
+type FieldConversion struct {
+	FieldName string `json:"fieldName"`
+	// Type to convert the field to: number, string, boolean or time.
+	Type string `json:"type,omitempty"`
+	// Scale and Offset convert numeric values to another unit: value * scale + offset.
+	Scale  float64 `json:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty"`
+	Unit   string  `json:"unit,omitempty"`
+}
+
+type ConvertFieldsFrameProcessorConfig struct {
+	Conversions []FieldConversion `json:"conversions"`
+}
+
+type AggregateFrameProcessorConfig struct {
+	// Window is the duration of the aggregation windows, e.g. 10s.
+	Window string `json:"window"`
+	// Function is one of mean (default), min, max, sum, count or last.
+	Function string `json:"function,omitempty"`
+}
+
 type FrameProcessorConfig struct {
-	Type                      string                          `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
-	DropFieldsProcessorConfig *DropFieldsFrameProcessorConfig `json:"dropFields,omitempty"`
-	KeepFieldsProcessorConfig *KeepFieldsFrameProcessorConfig `json:"keepFields,omitempty"`
-	MultipleProcessorConfig   *MultipleFrameProcessorConfig   `json:"multiple,omitempty"`
+	Type                         string                             `json:"type" ts_type:"Omit<keyof FrameProcessorConfig, 'type'>"`
+	DropFieldsProcessorConfig    *DropFieldsFrameProcessorConfig    `json:"dropFields,omitempty"`
+	KeepFieldsProcessorConfig    *KeepFieldsFrameProcessorConfig    `json:"keepFields,omitempty"`
+	MultipleProcessorConfig      *MultipleFrameProcessorConfig      `json:"multiple,omitempty"`
+	RenameFieldsProcessorConfig  *RenameFieldsFrameProcessorConfig  `json:"renameFields,omitempty"`
+	AddLabelsProcessorConfig     *AddLabelsFrameProcessorConfig     `json:"addLabels,omitempty"`
+	ComputeFieldProcessorConfig  *ComputeFieldFrameProcessorConfig  `json:"computeField,omitempty"`
+	ConvertFieldsProcessorConfig *ConvertFieldsFrameProcessorConfig `json:"convertFields,omitempty"`
+	AggregateProcessorConfig     *AggregateFrameProcessorConfig     `json:"aggregate,omitempty"`
 }
 
 type MultipleFrameProcessorConfig struct {
@@ -79,6 +125,32 @@ type LokiOutputConfig struct {
 	UID string `json:"uid"`
 }
 
+// NatsOutputConfig configures an output publishing to NATS. The endpoint of
+// the write config is the URL of a NATS server (e.g. nats://localhost:4222).
+type NatsOutputConfig struct {
+	UID string `json:"uid"`
+	// Subject to publish to, defaults to the channel with dots as separator
+	// prefixed by grafana.live (e.g. grafana.live.stream.telegraf.cpu).
+	Subject string `json:"subject,omitempty"`
+}
+
+// KafkaOutputConfig configures an output producing to Kafka through a Kafka
+// REST Proxy. Brokers are not supported: the endpoint of the write config must
+// be the URL of a proxy implementing the v2 API (e.g. Confluent REST Proxy).
+type KafkaOutputConfig struct {
+	// UID of the write config with the Kafka REST Proxy endpoint.
+	UID        string `json:"uid"`
+	Topic      string `json:"topic"`
+	BatchSize  int    `json:"batchSize,omitempty"`
+	MaxRetries int    `json:"maxRetries,omitempty"`
+}
+
+type WebhookOutputConfig struct {
+	UID        string `json:"uid"`
+	BatchSize  int    `json:"batchSize,omitempty"`
+	MaxRetries int    `json:"maxRetries,omitempty"`
+}
+
 type MultipleSubscriberConfig struct {
 	Subscribers []SubscriberConfig `json:"subscribers"`
 }
@@ -97,6 +169,9 @@ type DataOutputterConfig struct {
 	Type                     string                    `json:"type" ts_type:"Omit<keyof DataOutputterConfig, 'type'>"`
 	RedirectDataOutputConfig *RedirectDataOutputConfig `json:"redirect,omitempty"`
 	LokiOutputConfig         *LokiOutputConfig         `json:"loki,omitempty"`
+	NatsOutputConfig         *NatsOutputConfig         `json:"nats,omitempty"`
+	KafkaOutputConfig        *KafkaOutputConfig        `json:"kafka,omitempty"`
+	WebhookOutputConfig      *WebhookOutputConfig      `json:"webhook,omitempty"`
 }
 
 type FrameOutputterConfig struct {
@@ -109,6 +184,9 @@ type FrameOutputterConfig struct {
 	RemoteWriteOutputConfig *RemoteWriteOutputConfig   `json:"remoteWrite,omitempty"`
 	LokiOutputConfig        *LokiOutputConfig          `json:"loki,omitempty"`
 	ChangeLogOutputConfig   *ChangeLogOutputConfig     `json:"changeLog,omitempty"`
+	NatsOutputConfig        *NatsOutputConfig          `json:"nats,omitempty"`
+	KafkaOutputConfig       *KafkaOutputConfig         `json:"kafka,omitempty"`
+	WebhookOutputConfig     *WebhookOutputConfig       `json:"webhook,omitempty"`
 }
 
 type MultipleFrameConditionCheckerConfig struct {
@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// KafkaDataOutput produces raw data to a Kafka topic in batches, see KafkaFrameOutput.
+type KafkaDataOutput struct {
+	kafkaWriter *kafkaWriter
+}
+
+func NewKafkaDataOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config KafkaOutputConfig) *KafkaDataOutput {
+	return &KafkaDataOutput{
+		kafkaWriter: getKafkaWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const DataOutputTypeKafka = "kafka"
+
+func (out *KafkaDataOutput) Type() string {
+	return DataOutputTypeKafka
+}
+
+func (out *KafkaDataOutput) OutputData(_ context.Context, vars Vars, data []byte) ([]*ChannelData, error) {
+	if out.kafkaWriter.endpoint == "" {
+		logger.Debug("Skip sending to Kafka: no url")
+		return nil, nil
+	}
+	// The data is sent after the call returns, so it is copied.
+	out.kafkaWriter.writer.write(outputMessage{
+		Channel: vars.Channel,
+		Time:    time.Now(),
+		Payload: bytes.Clone(data),
+	})
+	return nil, nil
+}
@@ -0,0 +1,30 @@
+package pipeline
+
+import (
+	"context"
+)
+
+// NatsDataOutput publishes raw data to a NATS subject.
+type NatsDataOutput struct {
+	natsWriter *natsWriter
+}
+
+func NewNatsDataOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config NatsOutputConfig) *NatsDataOutput {
+	return &NatsDataOutput{
+		natsWriter: getNatsWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const DataOutputTypeNats = "nats"
+
+func (out *NatsDataOutput) Type() string {
+	return DataOutputTypeNats
+}
+
+func (out *NatsDataOutput) OutputData(_ context.Context, vars Vars, data []byte) ([]*ChannelData, error) {
+	if out.natsWriter.endpoint == "" {
+		logger.Debug("Skip sending to NATS: no url")
+		return nil, nil
+	}
+	return nil, out.natsWriter.publish(vars.Channel, data)
+}
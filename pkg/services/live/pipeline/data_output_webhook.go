@@ -0,0 +1,38 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// WebhookDataOutput sends raw data to an HTTP endpoint in batches.
+type WebhookDataOutput struct {
+	webhookWriter *webhookWriter
+}
+
+func NewWebhookDataOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config WebhookOutputConfig) *WebhookDataOutput {
+	return &WebhookDataOutput{
+		webhookWriter: getWebhookWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const DataOutputTypeWebhook = "webhook"
+
+func (out *WebhookDataOutput) Type() string {
+	return DataOutputTypeWebhook
+}
+
+func (out *WebhookDataOutput) OutputData(_ context.Context, vars Vars, data []byte) ([]*ChannelData, error) {
+	if out.webhookWriter.endpoint == "" {
+		logger.Debug("Skip sending to webhook: no url")
+		return nil, nil
+	}
+	// The data is sent after the call returns, so it is copied.
+	out.webhookWriter.writer.write(outputMessage{
+		Channel: vars.Channel,
+		Time:    time.Now(),
+		Payload: bytes.Clone(data),
+	})
+	return nil, nil
+}
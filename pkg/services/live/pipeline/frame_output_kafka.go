@@ -0,0 +1,177 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// KafkaFrameOutput produces frames encoded to JSON to a Kafka topic in batches.
+// The output doesn't speak the Kafka protocol: the write config endpoint must be
+// the URL of a Kafka REST Proxy (v2 API, e.g. Confluent REST Proxy), and not the
+// address of a broker. The records are keyed by channel, and their values are
+// sent in the binary embedded format.
+type KafkaFrameOutput struct {
+	kafkaWriter *kafkaWriter
+}
+
+func NewKafkaFrameOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config KafkaOutputConfig) *KafkaFrameOutput {
+	return &KafkaFrameOutput{
+		kafkaWriter: getKafkaWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const FrameOutputTypeKafka = "kafka"
+
+func (out *KafkaFrameOutput) Type() string {
+	return FrameOutputTypeKafka
+}
+
+func (out *KafkaFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.kafkaWriter.endpoint == "" {
+		logger.Debug("Skip sending to Kafka: no url")
+		return nil, nil
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	out.kafkaWriter.writer.write(outputMessage{
+		Channel: vars.Channel,
+		Time:    time.Now(),
+		IsFrame: true,
+		Payload: frameJSON,
+	})
+	return nil, nil
+}
+
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is a record of the Kafka REST Proxy binary embedded format,
+// []byte values are base64 encoded by encoding/json as expected by the proxy.
+type kafkaRecord struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// kafkaRetriableErrorCode is the error code of the records the proxy failed to
+// produce with a retriable Kafka exception, other records failed permanently.
+const kafkaRetriableErrorCode = 2
+
+// kafkaProduceResponse contains an offset per record, in the order of the records of the request.
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+type kafkaWriter struct {
+	httpClient *http.Client
+	writer     *batchWriter
+
+	// Endpoint of the Kafka REST Proxy.
+	endpoint  string
+	basicAuth *BasicAuth
+	topic     string
+}
+
+// getKafkaWriter returns the writer shared by the Kafka outputs with the same configuration.
+func getKafkaWriter(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config KafkaOutputConfig) *kafkaWriter {
+	return getOutputWriter(writers, outputWriterKey(FrameOutputTypeKafka, endpoint, basicAuth, config), func() *kafkaWriter {
+		return newKafkaWriter(endpoint, basicAuth, config)
+	})
+}
+
+func newKafkaWriter(endpoint string, basicAuth *BasicAuth, config KafkaOutputConfig) *kafkaWriter {
+	w := &kafkaWriter{
+		endpoint:  endpoint,
+		basicAuth: basicAuth,
+		topic:     config.Topic,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+	w.writer = newBatchWriter(config.BatchSize, config.MaxRetries, w.send)
+	return w
+}
+
+func (w *kafkaWriter) close() {
+	w.writer.stop()
+}
+
+func (w *kafkaWriter) send(batch []outputMessage) error {
+	records := kafkaRecords{Records: make([]kafkaRecord, 0, len(batch))}
+	for _, m := range batch {
+		records.Records = append(records.Records, kafkaRecord{Key: []byte(m.Channel), Value: m.Payload})
+	}
+	body, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("error converting Kafka records to bytes: %v", err)
+	}
+	topicURL := strings.TrimSuffix(w.endpoint, "/") + "/topics/" + url.PathEscape(w.topic)
+	logger.Debug("Sending to Kafka REST Proxy", "url", topicURL, "numRecords", len(batch), "bodyLength", len(body))
+	req, err := http.NewRequest(http.MethodPost, topicURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error constructing Kafka produce request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if w.basicAuth != nil {
+		req.SetBasicAuth(w.basicAuth.User, w.basicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending to Kafka: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := outputResponseError(resp.StatusCode, "Kafka REST Proxy"); err != nil {
+		return err
+	}
+	// The proxy reports the records it failed to produce in the offsets of a successful response.
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("error decoding Kafka REST Proxy response: %w", err)
+	}
+	var failed []outputMessage
+	var lastErr error
+	numDropped := 0
+	for i, offset := range produced.Offsets {
+		if offset.ErrorCode == nil || i >= len(batch) {
+			continue
+		}
+		var msg string
+		if offset.Error != nil {
+			msg = *offset.Error
+		}
+		lastErr = fmt.Errorf("error producing Kafka record: %d %s", *offset.ErrorCode, msg)
+		if *offset.ErrorCode == kafkaRetriableErrorCode {
+			failed = append(failed, batch[i])
+		} else {
+			numDropped++
+		}
+	}
+	if len(failed) > 0 {
+		if numDropped > 0 {
+			logger.Error("Dropping Kafka records", "error", lastErr, "numRecords", numDropped)
+		}
+		// Only the records that failed with a retriable error are retried, the others were produced.
+		return &partialOutputError{failed: failed, err: lastErr}
+	}
+	if numDropped > 0 {
+		return fmt.Errorf("%w: %d records: %v", errPermanentOutput, numDropped, lastErr)
+	}
+	logger.Debug("Successfully sent to Kafka", "elapsed", time.Since(started))
+	return nil
+}
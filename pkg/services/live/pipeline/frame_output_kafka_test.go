@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaWriter_send(t *testing.T) {
+	var received kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/topics/live-events", r.URL.Path)
+		require.Equal(t, "application/vnd.kafka.binary.v2+json", r.Header.Get("Content-Type"))
+		user, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", password)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	w := newKafkaWriter(server.URL+"/", &BasicAuth{User: "user", Password: "secret"}, KafkaOutputConfig{Topic: "live-events"})
+
+	err := w.send([]outputMessage{{Channel: "stream/test/1", Payload: []byte("cpu value=1")}})
+	require.NoError(t, err)
+	require.Equal(t, []kafkaRecord{{Key: []byte("stream/test/1"), Value: []byte("cpu value=1")}}, received.Records)
+}
+
+func TestKafkaWriter_sendErrors(t *testing.T) {
+	var offsets string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"offsets":[` + offsets + `]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	w := newKafkaWriter(server.URL, nil, KafkaOutputConfig{Topic: "live-events"})
+	messages := testOutputMessages(3)
+
+	t.Run("records with a retriable error are returned", func(t *testing.T) {
+		offsets = `{"partition":0,"offset":1},{"error_code":2,"error":"not leader"},{"error_code":1,"error":"too large"}`
+		err := w.send(messages)
+		var partial *partialOutputError
+		require.ErrorAs(t, err, &partial)
+		require.Equal(t, []outputMessage{messages[1]}, partial.failed)
+		require.NotErrorIs(t, err, errPermanentOutput)
+	})
+
+	t.Run("records with a non-retriable error are dropped", func(t *testing.T) {
+		offsets = `{"partition":0,"offset":1},{"error_code":1,"error":"too large"},{"partition":0,"offset":2}`
+		err := w.send(messages)
+		require.ErrorIs(t, err, errPermanentOutput)
+		require.ErrorContains(t, err, "too large")
+	})
+}
+
+func TestKafkaWriter_retriesFailedRecords(t *testing.T) {
+	var mu sync.Mutex
+	var requests [][]kafkaRecord
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var records kafkaRecords
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&records))
+		mu.Lock()
+		requests = append(requests, records.Records)
+		first := len(requests) == 1
+		mu.Unlock()
+		if first {
+			_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":1},{"error_code":2,"error":"not leader"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"offsets":[{"partition":0,"offset":2}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	w := newKafkaWriter(server.URL, nil, KafkaOutputConfig{Topic: "live-events"})
+	w.writer.retryBackoff = time.Millisecond
+	messages := testOutputMessages(2)
+	bufferMessages(w.writer, messages)
+	w.writer.flush()
+
+	// The record produced by the first request is not produced again.
+	require.Len(t, requests, 2)
+	require.Len(t, requests[0], 2)
+	require.Equal(t, []kafkaRecord{{Key: []byte(messages[1].Channel), Value: messages[1].Payload}}, requests[1])
+	require.Empty(t, w.writer.buffer)
+}
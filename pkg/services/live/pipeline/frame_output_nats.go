@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/nats-io/nats.go"
+)
+
+// NatsFrameOutput publishes frames encoded to JSON to a NATS subject.
+type NatsFrameOutput struct {
+	natsWriter *natsWriter
+}
+
+func NewNatsFrameOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config NatsOutputConfig) *NatsFrameOutput {
+	return &NatsFrameOutput{
+		natsWriter: getNatsWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const FrameOutputTypeNats = "nats"
+
+func (out *NatsFrameOutput) Type() string {
+	return FrameOutputTypeNats
+}
+
+func (out *NatsFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.natsWriter.endpoint == "" {
+		logger.Debug("Skip sending to NATS: no url")
+		return nil, nil
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	return nil, out.natsWriter.publish(vars.Channel, frameJSON)
+}
+
+// natsWriter publishes to a NATS server. The connection is established on the
+// first publish, and NATS buffers the messages published while it reconnects.
+type natsWriter struct {
+	mu     sync.Mutex
+	conn   *nats.Conn
+	closed bool
+
+	// Endpoint is a NATS server URL, or a comma separated list of URLs of a cluster.
+	endpoint  string
+	basicAuth *BasicAuth
+	subject   string
+}
+
+// getNatsWriter returns the writer shared by the NATS outputs with the same configuration.
+func getNatsWriter(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config NatsOutputConfig) *natsWriter {
+	return getOutputWriter(writers, outputWriterKey(FrameOutputTypeNats, endpoint, basicAuth, config), func() *natsWriter {
+		return newNatsWriter(endpoint, basicAuth, config)
+	})
+}
+
+func newNatsWriter(endpoint string, basicAuth *BasicAuth, config NatsOutputConfig) *natsWriter {
+	return &natsWriter{
+		endpoint:  endpoint,
+		basicAuth: basicAuth,
+		subject:   config.Subject,
+	}
+}
+
+// natsSubject returns the configured subject, or one derived from the channel
+// when not set: stream/telegraf/cpu is published to grafana.live.stream.telegraf.cpu.
+func (w *natsWriter) natsSubject(channel string) string {
+	if w.subject != "" {
+		return w.subject
+	}
+	return "grafana.live." + strings.ReplaceAll(channel, "/", ".")
+}
+
+func (w *natsWriter) connection() (*nats.Conn, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil, errors.New("NATS output is closed")
+	}
+	if w.conn != nil {
+		return w.conn, nil
+	}
+	opts := []nats.Option{
+		nats.Name("grafana-live-pipeline"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("Disconnected from NATS", "error", err)
+			}
+		}),
+	}
+	if w.basicAuth != nil {
+		opts = append(opts, nats.UserInfo(w.basicAuth.User, w.basicAuth.Password))
+	}
+	conn, err := nats.Connect(w.endpoint, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to NATS: %w", err)
+	}
+	w.conn = conn
+	return conn, nil
+}
+
+func (w *natsWriter) publish(channel string, payload []byte) error {
+	conn, err := w.connection()
+	if err != nil {
+		return err
+	}
+	subject := w.natsSubject(channel)
+	if err := conn.Publish(subject, payload); err != nil {
+		return fmt.Errorf("error publishing to NATS subject %s: %w", subject, err)
+	}
+	return nil
+}
+
+// close drains the connection, so the messages buffered while reconnecting are flushed if possible.
+func (w *natsWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.conn == nil {
+		return
+	}
+	if err := w.conn.Drain(); err != nil {
+		w.conn.Close()
+	}
+	w.conn = nil
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestNatsServer(t *testing.T) string {
+	t.Helper()
+	server, err := natsserver.NewServer(&natsserver.Options{
+		Host: "127.0.0.1",
+		Port: natsserver.RANDOM_PORT,
+	})
+	require.NoError(t, err)
+	go server.Start()
+	t.Cleanup(server.Shutdown)
+	require.True(t, server.ReadyForConnections(5*time.Second))
+	return server.ClientURL()
+}
+
+func TestNatsWriter_publish(t *testing.T) {
+	url := startTestNatsServer(t)
+	sub, err := nats.Connect(url)
+	require.NoError(t, err)
+	t.Cleanup(sub.Close)
+	messages := make(chan *nats.Msg, 10)
+	_, err = sub.ChanSubscribe("grafana.live.>", messages)
+	require.NoError(t, err)
+	require.NoError(t, sub.Flush())
+
+	t.Run("subject is derived from the channel", func(t *testing.T) {
+		w := newNatsWriter(url, nil, NatsOutputConfig{})
+		t.Cleanup(w.close)
+		require.NoError(t, w.publish("stream/telegraf/cpu", []byte("cpu value=1")))
+
+		select {
+		case msg := <-messages:
+			require.Equal(t, "grafana.live.stream.telegraf.cpu", msg.Subject)
+			require.Equal(t, []byte("cpu value=1"), msg.Data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	})
+
+	t.Run("configured subject is used", func(t *testing.T) {
+		w := newNatsWriter(url, nil, NatsOutputConfig{Subject: "grafana.live.events"})
+		t.Cleanup(w.close)
+		require.NoError(t, w.publish("stream/telegraf/cpu", []byte("cpu value=2")))
+
+		select {
+		case msg := <-messages:
+			require.Equal(t, "grafana.live.events", msg.Subject)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	})
+
+	t.Run("close drains the connection and rejects later messages", func(t *testing.T) {
+		w := newNatsWriter(url, nil, NatsOutputConfig{})
+		require.NoError(t, w.publish("stream/test/1", []byte("1")))
+		conn := w.conn
+		w.close()
+
+		select {
+		case msg := <-messages:
+			require.Equal(t, []byte("1"), msg.Data)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+		require.Eventually(t, conn.IsClosed, 5*time.Second, 10*time.Millisecond)
+		require.Error(t, w.publish("stream/test/1", []byte("2")))
+	})
+}
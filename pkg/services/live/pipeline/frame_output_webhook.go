@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// WebhookFrameOutput sends frames encoded to JSON to an HTTP endpoint in batches.
+type WebhookFrameOutput struct {
+	webhookWriter *webhookWriter
+}
+
+func NewWebhookFrameOutput(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config WebhookOutputConfig) *WebhookFrameOutput {
+	return &WebhookFrameOutput{
+		webhookWriter: getWebhookWriter(writers, endpoint, basicAuth, config),
+	}
+}
+
+const FrameOutputTypeWebhook = "webhook"
+
+func (out *WebhookFrameOutput) Type() string {
+	return FrameOutputTypeWebhook
+}
+
+func (out *WebhookFrameOutput) OutputFrame(_ context.Context, vars Vars, frame *data.Frame) ([]*ChannelFrame, error) {
+	if out.webhookWriter.endpoint == "" {
+		logger.Debug("Skip sending to webhook: no url")
+		return nil, nil
+	}
+	frameJSON, err := data.FrameToJSON(frame, data.IncludeAll)
+	if err != nil {
+		return nil, err
+	}
+	out.webhookWriter.writer.write(outputMessage{
+		Channel: vars.Channel,
+		Time:    time.Now(),
+		IsFrame: true,
+		Payload: frameJSON,
+	})
+	return nil, nil
+}
+
+// WebhookPayload is the body of the requests sent to a webhook endpoint.
+type WebhookPayload struct {
+	Messages []WebhookMessage `json:"messages"`
+}
+
+// WebhookMessage contains either a frame or the raw data pushed to a channel.
+type WebhookMessage struct {
+	Channel string `json:"channel"`
+	// Time is the time the message was received in epoch milliseconds.
+	Time  int64           `json:"time"`
+	Frame json.RawMessage `json:"frame,omitempty"`
+	Data  string          `json:"data,omitempty"`
+}
+
+type webhookWriter struct {
+	httpClient *http.Client
+	writer     *batchWriter
+
+	// Endpoint to send the batches to.
+	endpoint  string
+	basicAuth *BasicAuth
+}
+
+// getWebhookWriter returns the writer shared by the webhook outputs with the same configuration.
+func getWebhookWriter(writers *OutputWriters, endpoint string, basicAuth *BasicAuth, config WebhookOutputConfig) *webhookWriter {
+	return getOutputWriter(writers, outputWriterKey(FrameOutputTypeWebhook, endpoint, basicAuth, config), func() *webhookWriter {
+		return newWebhookWriter(endpoint, basicAuth, config)
+	})
+}
+
+func newWebhookWriter(endpoint string, basicAuth *BasicAuth, config WebhookOutputConfig) *webhookWriter {
+	w := &webhookWriter{
+		endpoint:  endpoint,
+		basicAuth: basicAuth,
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+	w.writer = newBatchWriter(config.BatchSize, config.MaxRetries, w.send)
+	return w
+}
+
+func (w *webhookWriter) close() {
+	w.writer.stop()
+}
+
+func (w *webhookWriter) send(batch []outputMessage) error {
+	payload := WebhookPayload{Messages: make([]WebhookMessage, 0, len(batch))}
+	for _, m := range batch {
+		msg := WebhookMessage{Channel: m.Channel, Time: m.Time.UnixMilli()}
+		if m.IsFrame {
+			msg.Frame = m.Payload
+		} else {
+			msg.Data = string(m.Payload)
+		}
+		payload.Messages = append(payload.Messages, msg)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error converting webhook messages to bytes: %v", err)
+	}
+	logger.Debug("Sending to webhook endpoint", "url", w.endpoint, "numMessages", len(batch), "bodyLength", len(body))
+	req, err := http.NewRequest(http.MethodPost, w.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error constructing webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.basicAuth != nil {
+		req.SetBasicAuth(w.basicAuth.User, w.basicAuth.Password)
+	}
+
+	started := time.Now()
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending to webhook: %w", err)
+	}
+	_ = resp.Body.Close()
+	if err := outputResponseError(resp.StatusCode, "webhook"); err != nil {
+		return err
+	}
+	logger.Debug("Successfully sent to webhook", "elapsed", time.Since(started))
+	return nil
+}
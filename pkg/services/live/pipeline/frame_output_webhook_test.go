@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookWriter_send(t *testing.T) {
+	var received WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "user", user)
+		require.Equal(t, "secret", password)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	w := newWebhookWriter("", &BasicAuth{User: "user", Password: "secret"}, WebhookOutputConfig{})
+	w.endpoint = server.URL
+	now := time.UnixMilli(1700000000000)
+	err := w.send([]outputMessage{
+		{Channel: "stream/test/frame", Time: now, IsFrame: true, Payload: []byte(`{"schema":{}}`)},
+		{Channel: "stream/test/data", Time: now, Payload: []byte("cpu value=1")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, WebhookPayload{Messages: []WebhookMessage{
+		{Channel: "stream/test/frame", Time: 1700000000000, Frame: json.RawMessage(`{"schema":{}}`)},
+		{Channel: "stream/test/data", Time: 1700000000000, Data: "cpu value=1"},
+	}}, received)
+}
+
+func TestWebhookWriter_sendErrors(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusBadRequest)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	t.Cleanup(server.Close)
+
+	w := newWebhookWriter("", nil, WebhookOutputConfig{})
+	w.endpoint = server.URL
+	messages := testOutputMessages(1)
+
+	err := w.send(messages)
+	require.ErrorIs(t, err, errPermanentOutput)
+
+	status.Store(http.StatusServiceUnavailable)
+	err = w.send(messages)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, errPermanentOutput)
+}
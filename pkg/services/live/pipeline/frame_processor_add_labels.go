@@ -0,0 +1,44 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// AddLabelsFrameProcessor can add static labels to the fields of a data.Frame.
+type AddLabelsFrameProcessor struct {
+	config AddLabelsFrameProcessorConfig
+}
+
+func NewAddLabelsFrameProcessor(config AddLabelsFrameProcessorConfig) *AddLabelsFrameProcessor {
+	return &AddLabelsFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeAddLabels = "addLabels"
+
+func (p *AddLabelsFrameProcessor) Type() string {
+	return FrameProcessorTypeAddLabels
+}
+
+func (p *AddLabelsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	for _, field := range frame.Fields {
+		if len(p.config.FieldNames) > 0 {
+			if !stringInSlice(field.Name, p.config.FieldNames) {
+				continue
+			}
+		} else if field.Type().Time() {
+			// Labels are only added to the time field when selected explicitly.
+			continue
+		}
+		labels := make(data.Labels, len(field.Labels)+len(p.config.Labels))
+		for k, v := range field.Labels {
+			labels[k] = v
+		}
+		for k, v := range p.config.Labels {
+			labels[k] = v
+		}
+		field.Labels = labels
+	}
+	return frame, nil
+}
@@ -0,0 +1,180 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Functions AggregateFrameProcessor can aggregate the values of a window with.
+const (
+	AggregateFunctionMean  = "mean"
+	AggregateFunctionMin   = "min"
+	AggregateFunctionMax   = "max"
+	AggregateFunctionSum   = "sum"
+	AggregateFunctionCount = "count"
+	AggregateFunctionLast  = "last"
+)
+
+// AggregateFrameProcessor can downsample the frames of a channel by aggregating
+// the rows over time windows. The rows are kept in memory until a row of a later
+// window arrives, then a frame with a row per completed window is returned: the
+// window start as time, and the aggregate of the numeric fields. Other fields
+// keep the last value of the window. Frames that don't complete a window are
+// not processed any further. The current window of each channel is kept in the
+// AggregateStorage, so it survives the rebuild of the rules. Not usable in HA setup.
+type AggregateFrameProcessor struct {
+	storage *AggregateStorage
+	config  AggregateFrameProcessorConfig
+	window  time.Duration
+}
+
+func NewAggregateFrameProcessor(storage *AggregateStorage, config AggregateFrameProcessorConfig) (*AggregateFrameProcessor, error) {
+	window, err := time.ParseDuration(config.Window)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid window %q for %s", config.Window, FrameProcessorTypeAggregate)
+	}
+	switch config.Function {
+	case "":
+		config.Function = AggregateFunctionMean
+	case AggregateFunctionMean, AggregateFunctionMin, AggregateFunctionMax, AggregateFunctionSum, AggregateFunctionCount, AggregateFunctionLast:
+	default:
+		return nil, fmt.Errorf("unknown aggregate function %s", config.Function)
+	}
+	return &AggregateFrameProcessor{
+		storage: storage,
+		config:  config,
+		window:  window,
+	}, nil
+}
+
+const FrameProcessorTypeAggregate = "aggregate"
+
+func (p *AggregateFrameProcessor) Type() string {
+	return FrameProcessorTypeAggregate
+}
+
+func (p *AggregateFrameProcessor) ProcessFrame(_ context.Context, vars Vars, frame *data.Frame) (*data.Frame, error) {
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex < 0 {
+		return nil, fmt.Errorf("%s requires a time field", FrameProcessorTypeAggregate)
+	}
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var result *data.Frame
+	p.storage.update(vars.NS, vars.Channel, p.window, func(current *aggregateWindow) *aggregateWindow {
+		if current != nil && !sameFrameSchema(current.rows, frame) {
+			// The window can't contain rows of different shapes, the rows received so far are dropped.
+			current = nil
+		}
+		for i := range rowLen {
+			t, ok := frame.Fields[timeIndex].ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			start := t.(time.Time).Truncate(p.window)
+			if current != nil && start.After(current.start) {
+				row := p.aggregate(current, timeIndex)
+				if result == nil {
+					result = row
+				} else {
+					result.AppendRow(row.RowCopy(0)...)
+				}
+				current = nil
+			}
+			if current == nil {
+				current = &aggregateWindow{start: start, rows: frame.EmptyCopy()}
+			}
+			current.rows.AppendRow(frame.RowCopy(i)...)
+		}
+		return current
+	})
+	return result, nil
+}
+
+// aggregate returns a frame with the aggregated row of a window.
+func (p *AggregateFrameProcessor) aggregate(w *aggregateWindow, timeIndex int) *data.Frame {
+	fields := make([]*data.Field, 0, len(w.rows.Fields))
+	for i, field := range w.rows.Fields {
+		var aggregated *data.Field
+		switch {
+		case i == timeIndex:
+			aggregated = data.NewFieldFromFieldType(field.Type(), 1)
+			if field.Nullable() {
+				aggregated.Set(0, &w.start)
+			} else {
+				aggregated.Set(0, w.start)
+			}
+		case field.Type().Numeric():
+			aggregated = data.NewField("", nil, []*float64{p.aggregateValues(field)})
+		default:
+			aggregated = data.NewFieldFromFieldType(field.Type(), 1)
+			aggregated.Set(0, field.CopyAt(field.Len()-1))
+		}
+		aggregated.Name = field.Name
+		aggregated.Labels = field.Labels
+		aggregated.Config = field.Config
+		fields = append(fields, aggregated)
+	}
+	return data.NewFrame(w.rows.Name, fields...)
+}
+
+func (p *AggregateFrameProcessor) aggregateValues(field *data.Field) *float64 {
+	var count, sum float64
+	var last *float64
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for i := range field.Len() {
+		v, ok := fieldFloatAt(field, i)
+		if !ok {
+			continue
+		}
+		count++
+		sum += v
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+		last = &v
+	}
+	switch p.config.Function {
+	case AggregateFunctionCount:
+		return &count
+	case AggregateFunctionLast:
+		return last
+	}
+	if count == 0 {
+		return nil
+	}
+	switch p.config.Function {
+	case AggregateFunctionMin:
+		return &minValue
+	case AggregateFunctionMax:
+		return &maxValue
+	case AggregateFunctionSum:
+		return &sum
+	default:
+		return new(sum / count)
+	}
+}
+
+func sameFrameSchema(a, b *data.Frame) bool {
+	if len(a.Fields) != len(b.Fields) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i].Name != b.Fields[i].Name || a.Fields[i].Type() != b.Fields[i].Type() {
+			return false
+		}
+	}
+	return true
+}
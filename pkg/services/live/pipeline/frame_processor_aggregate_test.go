@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateFrameProcessor(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	frame := func(offset time.Duration, value float64, host string) *data.Frame {
+		return data.NewFrame("test",
+			data.NewField("time", nil, []time.Time{start.Add(offset)}),
+			data.NewField("value", nil, []float64{value}),
+			data.NewField("host", nil, []string{host}),
+		)
+	}
+	vars := Vars{NS: "default", Channel: "stream/test/1"}
+
+	p, err := NewAggregateFrameProcessor(NewAggregateStorage(), AggregateFrameProcessorConfig{Window: "10s"})
+	require.NoError(t, err)
+
+	for _, f := range []*data.Frame{frame(0, 1, "a"), frame(5*time.Second, 3, "b")} {
+		result, err := p.ProcessFrame(context.Background(), vars, f)
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+
+	// A row of a later window completes the current window.
+	result, err := p.ProcessFrame(context.Background(), vars, frame(25*time.Second, 10, "c"))
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	require.Equal(t, 1, result.Rows())
+	require.Equal(t, start, result.Fields[0].At(0))
+	require.Equal(t, new(2.0), result.Fields[1].At(0))
+	require.Equal(t, "b", result.Fields[2].At(0))
+
+	// Windows are kept per channel.
+	result, err = p.ProcessFrame(context.Background(), Vars{NS: "default", Channel: "stream/test/2"}, frame(40*time.Second, 1, "d"))
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	t.Run("windows survive the rebuild of the processor", func(t *testing.T) {
+		storage := NewAggregateStorage()
+		config := AggregateFrameProcessorConfig{Window: "1m", Function: AggregateFunctionSum}
+		p, err := NewAggregateFrameProcessor(storage, config)
+		require.NoError(t, err)
+		result, err := p.ProcessFrame(context.Background(), vars, frame(0, 1, "a"))
+		require.NoError(t, err)
+		require.Nil(t, result)
+
+		// The rules are rebuilt in the middle of the window.
+		p, err = NewAggregateFrameProcessor(storage, config)
+		require.NoError(t, err)
+		result, err = p.ProcessFrame(context.Background(), vars, frame(30*time.Second, 2, "b"))
+		require.NoError(t, err)
+		require.Nil(t, result)
+
+		p, err = NewAggregateFrameProcessor(storage, config)
+		require.NoError(t, err)
+		result, err = p.ProcessFrame(context.Background(), vars, frame(time.Minute, 5, "c"))
+		require.NoError(t, err)
+		require.NotNil(t, result)
+		require.Equal(t, start, result.Fields[0].At(0))
+		require.Equal(t, new(3.0), result.Fields[1].At(0))
+
+		// A processor with another window doesn't share the windows.
+		p, err = NewAggregateFrameProcessor(storage, AggregateFrameProcessorConfig{Window: "10s"})
+		require.NoError(t, err)
+		result, err = p.ProcessFrame(context.Background(), vars, frame(2*time.Minute, 5, "d"))
+		require.NoError(t, err)
+		require.Nil(t, result)
+	})
+
+	t.Run("aggregate functions", func(t *testing.T) {
+		for function, expected := range map[string]float64{
+			AggregateFunctionMin:   1,
+			AggregateFunctionMax:   3,
+			AggregateFunctionSum:   4,
+			AggregateFunctionCount: 2,
+			AggregateFunctionLast:  3,
+		} {
+			p, err := NewAggregateFrameProcessor(NewAggregateStorage(), AggregateFrameProcessorConfig{Window: "10s", Function: function})
+			require.NoError(t, err)
+			f := data.NewFrame("test",
+				data.NewField("time", nil, []time.Time{start, start.Add(time.Second), start.Add(10 * time.Second)}),
+				data.NewField("value", nil, []float64{1, 3, 5}),
+			)
+			result, err := p.ProcessFrame(context.Background(), vars, f)
+			require.NoError(t, err)
+			require.Equal(t, &expected, result.Fields[1].At(0), function)
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		_, err := NewAggregateFrameProcessor(NewAggregateStorage(), AggregateFrameProcessorConfig{Window: "10"})
+		require.Error(t, err)
+		_, err = NewAggregateFrameProcessor(NewAggregateStorage(), AggregateFrameProcessorConfig{Window: "10s", Function: "median"})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,187 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/expr/mathexp/parse"
+)
+
+// ComputeFieldFrameProcessor can add a field to a data.Frame with values computed
+// from the other fields of each row using a math expression, for example
+// `$temperature * 1.8 + 32` or `${bytes sent} / 1024`. The expression supports
+// the arithmetic, comparison and logical operators of server-side expressions,
+// and the abs, ceil, floor, log, round and sqrt functions. The value is null when
+// a referenced field is null or not a number.
+type ComputeFieldFrameProcessor struct {
+	config ComputeFieldFrameProcessorConfig
+	tree   *parse.Tree
+}
+
+var computeFieldFuncs = map[string]parse.Func{
+	"abs":   computeFieldFunc(math.Abs),
+	"ceil":  computeFieldFunc(math.Ceil),
+	"floor": computeFieldFunc(math.Floor),
+	"log":   computeFieldFunc(math.Log),
+	"round": computeFieldFunc(math.Round),
+	"sqrt":  computeFieldFunc(math.Sqrt),
+}
+
+func computeFieldFunc(f func(float64) float64) parse.Func {
+	return parse.Func{
+		Args:   []parse.ReturnType{parse.TypeVariantSet},
+		Return: parse.TypeScalar,
+		F:      f,
+	}
+}
+
+func NewComputeFieldFrameProcessor(config ComputeFieldFrameProcessorConfig) (*ComputeFieldFrameProcessor, error) {
+	if config.FieldName == "" {
+		return nil, fmt.Errorf("missing field name for %s", FrameProcessorTypeComputeField)
+	}
+	tree, err := parse.Parse(config.Expression, computeFieldFuncs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", config.Expression, err)
+	}
+	return &ComputeFieldFrameProcessor{config: config, tree: tree}, nil
+}
+
+const FrameProcessorTypeComputeField = "computeField"
+
+func (p *ComputeFieldFrameProcessor) Type() string {
+	return FrameProcessorTypeComputeField
+}
+
+func (p *ComputeFieldFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	fields := make(map[string]*data.Field, len(p.tree.VarNames))
+	for _, name := range p.tree.VarNames {
+		for _, field := range frame.Fields {
+			if field.Name == name {
+				fields[name] = field
+				break
+			}
+		}
+		if _, ok := fields[name]; !ok {
+			return nil, fmt.Errorf("field %s of expression %q not found", name, p.config.Expression)
+		}
+	}
+
+	rowLen, err := frame.RowLen()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*float64, rowLen)
+	for i := range rowLen {
+		value := func(name string) (float64, bool) {
+			return fieldFloatAt(fields[name], i)
+		}
+		if v, ok := evalComputeFieldNode(p.tree.Root, value); ok {
+			values[i] = &v
+		}
+	}
+
+	field := data.NewField(p.config.FieldName, nil, values)
+	if p.config.Unit != "" {
+		field.SetConfig(&data.FieldConfig{Unit: p.config.Unit})
+	}
+	for i, f := range frame.Fields {
+		if f.Name == p.config.FieldName {
+			frame.Fields[i] = field
+			return frame, nil
+		}
+	}
+	frame.Fields = append(frame.Fields, field)
+	return frame, nil
+}
+
+// fieldFloatAt returns the value of a field as a number, or false if the value
+// is null or not a number.
+func fieldFloatAt(field *data.Field, idx int) (float64, bool) {
+	v, err := field.NullableFloatAt(idx)
+	if err != nil || v == nil {
+		return 0, false
+	}
+	return *v, true
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// evalComputeFieldNode evaluates an expression node for a row, value returns
+// the value of the fields of the row.
+func evalComputeFieldNode(node parse.Node, value func(name string) (float64, bool)) (float64, bool) {
+	switch n := node.(type) {
+	case *parse.ScalarNode:
+		return n.Float64, true
+	case *parse.VarNode:
+		return value(n.Name)
+	case *parse.UnaryNode:
+		v, ok := evalComputeFieldNode(n.Arg, value)
+		if !ok {
+			return 0, false
+		}
+		switch n.OpStr {
+		case "-":
+			return -v, true
+		case "!":
+			return boolToFloat(v == 0), true
+		}
+	case *parse.FuncNode:
+		f, ok := n.F.F.(func(float64) float64)
+		if !ok || len(n.Args) != 1 {
+			return 0, false
+		}
+		v, ok := evalComputeFieldNode(n.Args[0], value)
+		if !ok {
+			return 0, false
+		}
+		return f(v), true
+	case *parse.BinaryNode:
+		a, ok := evalComputeFieldNode(n.Args[0], value)
+		if !ok {
+			return 0, false
+		}
+		b, ok := evalComputeFieldNode(n.Args[1], value)
+		if !ok {
+			return 0, false
+		}
+		switch n.OpStr {
+		case "+":
+			return a + b, true
+		case "-":
+			return a - b, true
+		case "*":
+			return a * b, true
+		case "/":
+			return a / b, true
+		case "%":
+			return math.Mod(a, b), true
+		case "**":
+			return math.Pow(a, b), true
+		case "==":
+			return boolToFloat(a == b), true
+		case "!=":
+			return boolToFloat(a != b), true
+		case ">":
+			return boolToFloat(a > b), true
+		case ">=":
+			return boolToFloat(a >= b), true
+		case "<":
+			return boolToFloat(a < b), true
+		case "<=":
+			return boolToFloat(a <= b), true
+		case "&&":
+			return boolToFloat(a != 0 && b != 0), true
+		case "||":
+			return boolToFloat(a != 0 || b != 0), true
+		}
+	}
+	return 0, false
+}
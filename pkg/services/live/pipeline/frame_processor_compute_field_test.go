@@ -0,0 +1,90 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeFieldFrameProcessor(t *testing.T) {
+	now := time.Now()
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []time.Time{now, now}),
+		data.NewField("temperature", nil, []*float64{new(20.0), nil}),
+		data.NewField("bytes sent", nil, []int64{2048, 4096}),
+	)
+
+	p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{
+		FieldName:  "temperature_f",
+		Expression: "round($temperature * 1.8 + 32) + ${bytes sent} / 1024 * 0",
+		Unit:       "fahrenheit",
+	})
+	require.NoError(t, err)
+	result, err := p.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+	require.Len(t, result.Fields, 4)
+
+	field := result.Fields[3]
+	require.Equal(t, "temperature_f", field.Name)
+	require.Equal(t, "fahrenheit", field.Config.Unit)
+	require.Equal(t, new(68.0), field.At(0))
+	require.Nil(t, field.At(1))
+
+	t.Run("replaces an existing field", func(t *testing.T) {
+		p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{
+			FieldName:  "temperature",
+			Expression: "$temperature > 10 && !($temperature > 30)",
+		})
+		require.NoError(t, err)
+		result, err := p.ProcessFrame(context.Background(), Vars{}, frame)
+		require.NoError(t, err)
+		require.Len(t, result.Fields, 4)
+		require.Equal(t, new(1.0), result.Fields[1].At(0))
+	})
+
+	t.Run("errors on unknown fields", func(t *testing.T) {
+		p, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{FieldName: "x", Expression: "$missing + 1"})
+		require.NoError(t, err)
+		_, err = p.ProcessFrame(context.Background(), Vars{}, frame)
+		require.Error(t, err)
+	})
+
+	t.Run("errors on invalid expressions", func(t *testing.T) {
+		_, err := NewComputeFieldFrameProcessor(ComputeFieldFrameProcessorConfig{FieldName: "x", Expression: "unknown($a)"})
+		require.Error(t, err)
+	})
+}
+
+func TestConvertFieldsFrameProcessor(t *testing.T) {
+	frame := data.NewFrame("test",
+		data.NewField("time", nil, []int64{1700000000000}),
+		data.NewField("celsius", nil, []float64{100}),
+		data.NewField("state", nil, []string{"true"}),
+		data.NewField("count", nil, []string{"not a number"}),
+	)
+	p, err := NewConvertFieldsFrameProcessor(ConvertFieldsFrameProcessorConfig{
+		Conversions: []FieldConversion{
+			{FieldName: "time", Type: ConvertFieldTypeTime},
+			{FieldName: "celsius", Scale: 1.8, Offset: 32, Unit: "fahrenheit"},
+			{FieldName: "state", Type: ConvertFieldTypeBoolean},
+			{FieldName: "count", Type: ConvertFieldTypeNumber},
+		},
+	})
+	require.NoError(t, err)
+	result, err := p.ProcessFrame(context.Background(), Vars{}, frame)
+	require.NoError(t, err)
+
+	require.Equal(t, new(time.UnixMilli(1700000000000)), result.Fields[0].At(0))
+	require.Equal(t, new(212.0), result.Fields[1].At(0))
+	require.Equal(t, "fahrenheit", result.Fields[1].Config.Unit)
+	require.Equal(t, new(true), result.Fields[2].At(0))
+	require.Nil(t, result.Fields[3].At(0))
+
+	_, err = NewConvertFieldsFrameProcessor(ConvertFieldsFrameProcessorConfig{
+		Conversions: []FieldConversion{{FieldName: "time", Type: "duration"}},
+	})
+	require.Error(t, err)
+}
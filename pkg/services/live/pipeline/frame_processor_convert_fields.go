@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// Field types a field can be converted to by ConvertFieldsFrameProcessor.
+const (
+	ConvertFieldTypeNumber  = "number"
+	ConvertFieldTypeString  = "string"
+	ConvertFieldTypeBoolean = "boolean"
+	ConvertFieldTypeTime    = "time"
+)
+
+// ConvertFieldsFrameProcessor can convert the type of fields of a data.Frame,
+// scale numeric values to another unit and set the unit of fields. Values that
+// can't be converted are null.
+type ConvertFieldsFrameProcessor struct {
+	config ConvertFieldsFrameProcessorConfig
+}
+
+func NewConvertFieldsFrameProcessor(config ConvertFieldsFrameProcessorConfig) (*ConvertFieldsFrameProcessor, error) {
+	for _, c := range config.Conversions {
+		switch c.Type {
+		case "", ConvertFieldTypeNumber, ConvertFieldTypeString, ConvertFieldTypeBoolean, ConvertFieldTypeTime:
+		default:
+			return nil, fmt.Errorf("unknown conversion type %s for field %s", c.Type, c.FieldName)
+		}
+	}
+	return &ConvertFieldsFrameProcessor{config: config}, nil
+}
+
+const FrameProcessorTypeConvertFields = "convertFields"
+
+func (p *ConvertFieldsFrameProcessor) Type() string {
+	return FrameProcessorTypeConvertFields
+}
+
+func (p *ConvertFieldsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	for _, c := range p.config.Conversions {
+		for i, field := range frame.Fields {
+			if field.Name != c.FieldName {
+				continue
+			}
+			converted := convertField(field, c)
+			if c.Unit != "" {
+				config := data.FieldConfig{}
+				if converted.Config != nil {
+					config = *converted.Config
+				}
+				config.Unit = c.Unit
+				converted.SetConfig(&config)
+			}
+			frame.Fields[i] = converted
+		}
+	}
+	return frame, nil
+}
+
+func convertField(field *data.Field, c FieldConversion) *data.Field {
+	fieldType := c.Type
+	if fieldType == "" {
+		if c.Scale == 0 && c.Offset == 0 {
+			return field
+		}
+		fieldType = ConvertFieldTypeNumber
+	}
+
+	var converted *data.Field
+	switch fieldType {
+	case ConvertFieldTypeNumber:
+		scale := c.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		values := make([]*float64, field.Len())
+		for i := range values {
+			if v, ok := fieldFloatAt(field, i); ok {
+				values[i] = new(v*scale + c.Offset)
+			}
+		}
+		converted = data.NewField(field.Name, field.Labels, values)
+	case ConvertFieldTypeString:
+		values := make([]*string, field.Len())
+		for i := range values {
+			if v, ok := field.ConcreteAt(i); ok {
+				values[i] = new(stringValue(v))
+			}
+		}
+		converted = data.NewField(field.Name, field.Labels, values)
+	case ConvertFieldTypeBoolean:
+		values := make([]*bool, field.Len())
+		for i := range values {
+			if v, ok := boolValue(field, i); ok {
+				values[i] = &v
+			}
+		}
+		converted = data.NewField(field.Name, field.Labels, values)
+	case ConvertFieldTypeTime:
+		values := make([]*time.Time, field.Len())
+		for i := range values {
+			if v, ok := timeValue(field, i); ok {
+				values[i] = &v
+			}
+		}
+		converted = data.NewField(field.Name, field.Labels, values)
+	}
+	converted.Config = field.Config
+	return converted
+}
+
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func boolValue(field *data.Field, idx int) (bool, bool) {
+	v, ok := field.ConcreteAt(idx)
+	if !ok {
+		return false, false
+	}
+	switch v := v.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		return b, err == nil
+	}
+	if f, ok := fieldFloatAt(field, idx); ok && field.Type().Numeric() {
+		return f != 0, true
+	}
+	return false, false
+}
+
+// timeValue converts numbers as epoch milliseconds, and strings in RFC 3339 format.
+func timeValue(field *data.Field, idx int) (time.Time, bool) {
+	v, ok := field.ConcreteAt(idx)
+	if !ok {
+		return time.Time{}, false
+	}
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return t, err == nil
+	}
+	if f, ok := fieldFloatAt(field, idx); ok && field.Type().Numeric() {
+		return time.UnixMilli(int64(f)), true
+	}
+	return time.Time{}, false
+}
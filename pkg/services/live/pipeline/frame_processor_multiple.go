@@ -26,6 +26,10 @@ func (p *MultipleFrameProcessor) ProcessFrame(ctx context.Context, vars Vars, fr
 			logger.Error("Error processing frame", "error", err)
 			return nil, err
 		}
+		if frame == nil {
+			// The frame was dropped, e.g. while an aggregate window is not complete.
+			return nil, nil
+		}
 	}
 	return frame, nil
 }
@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+)
+
+// RenameFieldsFrameProcessor can rename fields of a data.Frame and the label
+// keys of its fields.
+type RenameFieldsFrameProcessor struct {
+	config RenameFieldsFrameProcessorConfig
+}
+
+func NewRenameFieldsFrameProcessor(config RenameFieldsFrameProcessorConfig) *RenameFieldsFrameProcessor {
+	return &RenameFieldsFrameProcessor{config: config}
+}
+
+const FrameProcessorTypeRenameFields = "renameFields"
+
+func (p *RenameFieldsFrameProcessor) Type() string {
+	return FrameProcessorTypeRenameFields
+}
+
+func (p *RenameFieldsFrameProcessor) ProcessFrame(_ context.Context, _ Vars, frame *data.Frame) (*data.Frame, error) {
+	for _, field := range frame.Fields {
+		if name, ok := p.config.Fields[field.Name]; ok {
+			field.Name = name
+		}
+		if len(p.config.Labels) == 0 || len(field.Labels) == 0 {
+			continue
+		}
+		labels := make(data.Labels, len(field.Labels))
+		for k, v := range field.Labels {
+			if key, ok := p.config.Labels[k]; ok {
+				k = key
+			}
+			labels[k] = v
+		}
+		field.Labels = labels
+	}
+	return frame, nil
+}
@@ -0,0 +1,120 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+)
+
+// outputWriter is a connection or a batch writer of an output, closed once no rule uses it.
+type outputWriter interface {
+	close()
+}
+
+// OutputWriters keeps the writers of the NATS, Kafka and webhook outputs keyed by
+// their configuration. The rules are rebuilt periodically, so the outputs of the
+// new rules share the writers of the previous ones instead of opening new
+// connections, and the messages buffered by a writer are not lost on rebuild.
+// The writers not used by the rules of any namespace are closed at the end of
+// each build. A nil OutputWriters creates a writer per output.
+type OutputWriters struct {
+	// buildMu serializes the builds, so the writers used by a build are known when it ends.
+	buildMu sync.Mutex
+
+	mu      sync.Mutex
+	writers map[string]outputWriter
+	// used contains the keys of the writers used by the current rules of each namespace.
+	used map[string]map[string]struct{}
+	// building contains the keys of the writers used by the build in progress.
+	building map[string]struct{}
+}
+
+func NewOutputWriters() *OutputWriters {
+	return &OutputWriters{
+		writers: map[string]outputWriter{},
+		used:    map[string]map[string]struct{}{},
+	}
+}
+
+func (w *OutputWriters) beginBuild() {
+	if w == nil {
+		return
+	}
+	w.buildMu.Lock()
+	w.mu.Lock()
+	w.building = map[string]struct{}{}
+	w.mu.Unlock()
+}
+
+// endBuild replaces the writers used by the namespace with the writers used by
+// the build if it succeeded, and closes the writers no longer used.
+func (w *OutputWriters) endBuild(ns string, ok bool) {
+	if w == nil {
+		return
+	}
+	defer w.buildMu.Unlock()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if ok {
+		w.used[ns] = w.building
+	}
+	w.building = nil
+	for key, writer := range w.writers {
+		inUse := false
+		for _, keys := range w.used {
+			if _, inUse = keys[key]; inUse {
+				break
+			}
+		}
+		if !inUse {
+			delete(w.writers, key)
+			// Closing flushes the buffered messages, which may take a while.
+			go writer.close()
+		}
+	}
+}
+
+// Close closes all writers, and waits until their buffered messages are flushed.
+func (w *OutputWriters) Close() {
+	if w == nil {
+		return
+	}
+	w.buildMu.Lock()
+	defer w.buildMu.Unlock()
+	w.mu.Lock()
+	writers := w.writers
+	w.writers = map[string]outputWriter{}
+	w.used = map[string]map[string]struct{}{}
+	w.mu.Unlock()
+	var wg sync.WaitGroup
+	for _, writer := range writers {
+		wg.Go(writer.close)
+	}
+	wg.Wait()
+}
+
+// getOutputWriter returns the writer with the key, and creates it if there is none.
+func getOutputWriter[T outputWriter](w *OutputWriters, key string, create func() T) T {
+	if w == nil {
+		return create()
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.building != nil {
+		w.building[key] = struct{}{}
+	}
+	if writer, ok := w.writers[key].(T); ok {
+		return writer
+	}
+	writer := create()
+	w.writers[key] = writer
+	return writer
+}
+
+// outputWriterKey identifies the writer of an output type by its endpoint, credentials and configuration.
+func outputWriterKey(outputType string, endpoint string, basicAuth *BasicAuth, config any) string {
+	key := fmt.Sprintf("%s %s %+v", outputType, endpoint, config)
+	if basicAuth != nil {
+		key += " " + basicAuth.User + ":" + basicAuth.Password
+	}
+	return key
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testOutputWriter struct {
+	closed atomic.Bool
+}
+
+func (w *testOutputWriter) close() {
+	w.closed.Store(true)
+}
+
+func TestOutputWriters(t *testing.T) {
+	writers := NewOutputWriters()
+	get := func(key string) *testOutputWriter {
+		return getOutputWriter(writers, key, func() *testOutputWriter { return &testOutputWriter{} })
+	}
+
+	writers.beginBuild()
+	a := get("a")
+	b := get("b")
+	require.Same(t, a, get("a"))
+	writers.endBuild("ns1", true)
+
+	writers.beginBuild()
+	other := get("b")
+	writers.endBuild("ns2", true)
+	require.Same(t, b, other)
+
+	// The writers used by the previous rules are shared by the rebuilt ones.
+	writers.beginBuild()
+	require.Same(t, a, get("a"))
+	writers.endBuild("ns1", true)
+	require.False(t, a.closed.Load())
+
+	// A failed build keeps the writers of the previous rules, and closes the writers it created.
+	writers.beginBuild()
+	c := get("c")
+	writers.endBuild("ns1", false)
+	require.Eventually(t, c.closed.Load, time.Second, time.Millisecond)
+	require.False(t, a.closed.Load())
+
+	// The writers no longer used by any namespace are closed.
+	writers.beginBuild()
+	writers.endBuild("ns2", true)
+	require.Eventually(t, b.closed.Load, time.Second, time.Millisecond)
+	require.False(t, a.closed.Load())
+	require.NotSame(t, b, get("b"))
+}
+
+func TestOutputWriters_Close(t *testing.T) {
+	writers := NewOutputWriters()
+	writers.beginBuild()
+	a := getOutputWriter(writers, "a", func() *testOutputWriter { return &testOutputWriter{} })
+	writers.endBuild("ns", true)
+
+	writers.Close()
+	require.True(t, a.closed.Load())
+	require.NotSame(t, a, getOutputWriter(writers, "a", func() *testOutputWriter { return &testOutputWriter{} }))
+}
+
+func TestOutputWriters_nil(t *testing.T) {
+	var writers *OutputWriters
+	writers.beginBuild()
+	a := getOutputWriter(writers, "a", func() *testOutputWriter { return &testOutputWriter{} })
+	require.NotSame(t, a, getOutputWriter(writers, "a", func() *testOutputWriter { return &testOutputWriter{} }))
+	writers.endBuild("ns", true)
+	require.False(t, a.closed.Load())
+}
+
+func TestOutputWriterKey(t *testing.T) {
+	config := KafkaOutputConfig{UID: "kafka", Topic: "events"}
+	key := outputWriterKey(FrameOutputTypeKafka, "http://proxy", nil, config)
+	require.Equal(t, key, outputWriterKey(FrameOutputTypeKafka, "http://proxy", nil, config))
+	require.NotEqual(t, key, outputWriterKey(FrameOutputTypeKafka, "http://proxy", nil, KafkaOutputConfig{UID: "kafka", Topic: "other"}))
+	require.NotEqual(t, key, outputWriterKey(FrameOutputTypeKafka, "http://proxy", &BasicAuth{User: "user"}, config))
+}
@@ -48,6 +48,21 @@ var FrameOutputsRegistry = []EntityInfo{
 		Type:        FrameOutputTypeLoki,
 		Description: "output frame as JSON to Loki",
 	},
+	{
+		Type:        FrameOutputTypeNats,
+		Description: "publish frame as JSON to a NATS subject",
+		Example:     NatsOutputConfig{},
+	},
+	{
+		Type:        FrameOutputTypeKafka,
+		Description: "produce frame as JSON to a Kafka topic through a Kafka REST Proxy (v2 API), brokers are not supported",
+		Example:     KafkaOutputConfig{},
+	},
+	{
+		Type:        FrameOutputTypeWebhook,
+		Description: "send frames as JSON to an HTTP endpoint in batches",
+		Example:     WebhookOutputConfig{},
+	},
 }
 
 var ConvertersRegistry = []EntityInfo{
@@ -79,6 +94,37 @@ var FrameProcessorsRegistry = []EntityInfo{
 		Description: "list the fields that should be removed",
 		Example:     DropFieldsFrameProcessorConfig{},
 	},
+	{
+		Type:        FrameProcessorTypeRenameFields,
+		Description: "rename fields and label keys",
+		Example:     RenameFieldsFrameProcessorConfig{},
+	},
+	{
+		Type:        FrameProcessorTypeAddLabels,
+		Description: "add static labels to fields",
+		Example:     AddLabelsFrameProcessorConfig{},
+	},
+	{
+		Type:        FrameProcessorTypeComputeField,
+		Description: "add a field computed from other fields with a math expression",
+		Example: ComputeFieldFrameProcessorConfig{
+			FieldName:  "temperature_f",
+			Expression: "$temperature * 1.8 + 32",
+		},
+	},
+	{
+		Type:        FrameProcessorTypeConvertFields,
+		Description: "convert field types and units",
+		Example:     ConvertFieldsFrameProcessorConfig{},
+	},
+	{
+		Type:        FrameProcessorTypeAggregate,
+		Description: "downsample by aggregating rows over time windows",
+		Example: AggregateFrameProcessorConfig{
+			Window:   "10s",
+			Function: AggregateFunctionMean,
+		},
+	},
 }
 
 var DataOutputsRegistry = []EntityInfo{
@@ -94,4 +140,19 @@ var DataOutputsRegistry = []EntityInfo{
 		Type:        DataOutputTypeLoki,
 		Description: "output data to Loki as logs",
 	},
+	{
+		Type:        DataOutputTypeNats,
+		Description: "publish data to a NATS subject",
+		Example:     NatsOutputConfig{},
+	},
+	{
+		Type:        DataOutputTypeKafka,
+		Description: "produce data to a Kafka topic through a Kafka REST Proxy (v2 API), brokers are not supported",
+		Example:     KafkaOutputConfig{},
+	},
+	{
+		Type:        DataOutputTypeWebhook,
+		Description: "send data to an HTTP endpoint in batches",
+		Example:     WebhookOutputConfig{},
+	},
 }
@@ -14,6 +14,8 @@ type StorageRuleBuilder struct {
 	Node                 *centrifuge.Node
 	ManagedStream        *managedstream.Runner
 	FrameStorage         *FrameStorage
+	AggregateStorage     *AggregateStorage
+	OutputWriters        *OutputWriters
 	Storage              Storage
 	ChannelHandlerGetter ChannelHandlerGetter
 	SecretsService       secrets.Service //nolint:staticcheck // SA1019: Legacy envelope encryption for single-tenant feature
@@ -90,6 +92,43 @@ func (f *StorageRuleBuilder) extractFrameProcessor(config *FrameProcessorConfig)
 			return nil, missingConfiguration
 		}
 		return NewKeepFieldsFrameProcessor(*config.KeepFieldsProcessorConfig), nil
+	case FrameProcessorTypeRenameFields:
+		if config.RenameFieldsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewRenameFieldsFrameProcessor(*config.RenameFieldsProcessorConfig), nil
+	case FrameProcessorTypeAddLabels:
+		if config.AddLabelsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		return NewAddLabelsFrameProcessor(*config.AddLabelsProcessorConfig), nil
+	case FrameProcessorTypeComputeField:
+		if config.ComputeFieldProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		proc, err := NewComputeFieldFrameProcessor(*config.ComputeFieldProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return proc, nil
+	case FrameProcessorTypeConvertFields:
+		if config.ConvertFieldsProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		proc, err := NewConvertFieldsFrameProcessor(*config.ConvertFieldsProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return proc, nil
+	case FrameProcessorTypeAggregate:
+		if config.AggregateProcessorConfig == nil {
+			return nil, missingConfiguration
+		}
+		proc, err := NewAggregateFrameProcessor(f.AggregateStorage, *config.AggregateProcessorConfig)
+		if err != nil {
+			return nil, err
+		}
+		return proc, nil
 	case FrameProcessorTypeMultiple:
 		if config.MultipleProcessorConfig == nil {
 			return nil, missingConfiguration
@@ -249,6 +288,36 @@ func (f *StorageRuleBuilder) extractFrameOutputter(config *FrameOutputterConfig,
 			return nil, missingConfiguration
 		}
 		return NewChangeLogFrameOutput(f.FrameStorage, *config.ChangeLogOutputConfig), nil
+	case FrameOutputTypeNats:
+		if config.NatsOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.NatsOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewNatsFrameOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.NatsOutputConfig), nil
+	case FrameOutputTypeKafka:
+		if config.KafkaOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		if config.KafkaOutputConfig.Topic == "" {
+			return nil, fmt.Errorf("missing topic for %s", config.Type)
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.KafkaOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewKafkaFrameOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.KafkaOutputConfig), nil
+	case FrameOutputTypeWebhook:
+		if config.WebhookOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.WebhookOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewWebhookFrameOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.WebhookOutputConfig), nil
 	default:
 		return nil, fmt.Errorf("unknown output type: %s", config.Type)
 	}
@@ -281,6 +350,36 @@ func (f *StorageRuleBuilder) extractDataOutputter(config *DataOutputterConfig, w
 			writeConfig.Settings.Endpoint,
 			basicAuth,
 		), nil
+	case DataOutputTypeNats:
+		if config.NatsOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.NatsOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewNatsDataOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.NatsOutputConfig), nil
+	case DataOutputTypeKafka:
+		if config.KafkaOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		if config.KafkaOutputConfig.Topic == "" {
+			return nil, fmt.Errorf("missing topic for %s", config.Type)
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.KafkaOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewKafkaDataOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.KafkaOutputConfig), nil
+	case DataOutputTypeWebhook:
+		if config.WebhookOutputConfig == nil {
+			return nil, missingConfiguration
+		}
+		writeConfig, basicAuth, err := f.getWriteConfigWithAuth(config.WebhookOutputConfig.UID, writeConfigs)
+		if err != nil {
+			return nil, err
+		}
+		return NewWebhookDataOutput(f.OutputWriters, writeConfig.Settings.Endpoint, basicAuth, *config.WebhookOutputConfig), nil
 	case DataOutputTypeBuiltin:
 		return NewBuiltinDataOutput(f.ChannelHandlerGetter), nil
 	case DataOutputTypeLocalSubscribers:
@@ -299,7 +398,27 @@ func (f *StorageRuleBuilder) getWriteConfig(uid string, writeConfigs []WriteConf
 	return WriteConfig{}, false
 }
 
+// getWriteConfigWithAuth returns the write config of an output with its basic auth.
+func (f *StorageRuleBuilder) getWriteConfigWithAuth(uid string, writeConfigs []WriteConfig) (WriteConfig, *BasicAuth, error) {
+	writeConfig, ok := f.getWriteConfig(uid, writeConfigs)
+	if !ok {
+		return WriteConfig{}, nil, fmt.Errorf("unknown write config uid: %s", uid)
+	}
+	basicAuth, err := f.constructBasicAuth(writeConfig)
+	if err != nil {
+		return WriteConfig{}, nil, fmt.Errorf("error constructing basicAuth: %w", err)
+	}
+	return writeConfig, basicAuth, nil
+}
+
 func (f *StorageRuleBuilder) BuildRules(ctx context.Context, ns string) ([]*LiveChannelRule, error) {
+	f.OutputWriters.beginBuild()
+	rules, err := f.buildRules(ctx, ns)
+	f.OutputWriters.endBuild(ns, err == nil)
+	return rules, err
+}
+
+func (f *StorageRuleBuilder) buildRules(ctx context.Context, ns string) ([]*LiveChannelRule, error) {
 	channelRules, err := f.Storage.ListChannelRules(ctx, ns)
 	if err != nil {
 		return nil, err
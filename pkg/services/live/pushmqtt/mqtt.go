@@ -0,0 +1,237 @@
+package pushmqtt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/at-wat/mqtt-go"
+	liveDto "github.com/grafana/grafana-plugin-sdk-go/live"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/apiserver/endpoints/request"
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/pipeline"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+var (
+	logger = log.New("live.push_mqtt")
+)
+
+const (
+	mqttPingInterval     = 30 * time.Second
+	mqttTimeout          = 10 * time.Second
+	mqttReconnectWait    = time.Second
+	mqttMaxReconnectWait = time.Minute
+)
+
+func ProvideService(cfg *setting.Cfg, live *live.GrafanaLive) *Gateway {
+	return &Gateway{
+		Cfg:         cfg,
+		GrafanaLive: live,
+		ns:          request.GetNamespaceMapper(cfg)(cfg.LiveMQTTOrgID),
+		converter:   newConverter(cfg.LiveMQTTConverter),
+	}
+}
+
+// Gateway subscribes to the topics of an MQTT broker and translates the
+// received messages to Grafana Live publications.
+type Gateway struct {
+	Cfg         *setting.Cfg
+	GrafanaLive *live.GrafanaLive
+
+	ns        string
+	converter pipeline.Converter
+}
+
+func newConverter(converterType string) pipeline.Converter {
+	switch converterType {
+	case pipeline.ConverterTypeInfluxAuto:
+		return pipeline.NewAutoInfluxConverter(pipeline.AutoInfluxConverterConfig{FrameFormat: "labels_column"})
+	case pipeline.ConverterTypeJsonFrame:
+		return pipeline.NewJsonFrameConverter(pipeline.JsonFrameConverterConfig{})
+	default:
+		return pipeline.NewAutoJsonConverter(pipeline.AutoJsonConverterConfig{})
+	}
+}
+
+// IsDisabled returns true when no MQTT broker is configured.
+func (g *Gateway) IsDisabled() bool {
+	return g.Cfg.LiveMQTTBrokerURL == "" || g.Cfg.LiveMaxConnections == 0
+}
+
+// mqttClientID returns the configured client ID, or generates one unique per
+// instance: the broker disconnects a client when another one connects with the
+// same ID, so replicas can't share it.
+func mqttClientID(cfg *setting.Cfg) (string, error) {
+	if cfg.LiveMQTTClientID != "" {
+		return cfg.LiveMQTTClientID, nil
+	}
+	suffix, err := util.GetRandomString(8)
+	if err != nil {
+		return "", err
+	}
+	return "grafana-live-" + cfg.InstanceName + "-" + suffix, nil
+}
+
+// subscriber subscribes to MQTT topics.
+type subscriber interface {
+	Subscribe(ctx context.Context, subs ...mqtt.Subscription) ([]mqtt.Subscription, error)
+}
+
+// subscribeOnConnect subscribes to the topics each time the client connects.
+// The session is not kept by the broker between connections, so the
+// subscriptions are lost on reconnect.
+func (g *Gateway) subscribeOnConnect(ctx context.Context, client subscriber, connected <-chan struct{}) {
+	subscriptions := make([]mqtt.Subscription, 0, len(g.Cfg.LiveMQTTTopics))
+	for _, topic := range g.Cfg.LiveMQTTTopics {
+		subscriptions = append(subscriptions, mqtt.Subscription{Topic: topic, QoS: mqtt.QoS1})
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-connected:
+		}
+		if _, err := client.Subscribe(ctx, subscriptions...); err != nil {
+			logger.Error("Error subscribing to MQTT topics", "error", err, "topics", g.Cfg.LiveMQTTTopics)
+			continue
+		}
+		logger.Debug("Subscribed to MQTT topics", "topics", g.Cfg.LiveMQTTTopics)
+	}
+}
+
+// Run Gateway. The client reconnects to the broker until the context is done,
+// so broker outages don't stop Grafana.
+func (g *Gateway) Run(ctx context.Context) error {
+	logger.Info("Live MQTT Gateway initialization", "topics", g.Cfg.LiveMQTTTopics)
+
+	clientID, err := mqttClientID(g.Cfg)
+	if err != nil {
+		logger.Error("Error generating MQTT client ID", "error", err)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	// The connection state handler is called by the connection loop of the
+	// client, the topics are subscribed to by another goroutine.
+	connected := make(chan struct{}, 1)
+	onConnStateChange := mqtt.WithConnStateHandler(func(state mqtt.ConnState, err error) {
+		if state != mqtt.StateActive {
+			logger.Debug("MQTT connection state changed", "state", state, "error", err)
+			return
+		}
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+	})
+
+	client, err := mqtt.NewReconnectClient(
+		&mqtt.URLDialer{URL: g.Cfg.LiveMQTTBrokerURL, Options: []mqtt.DialOption{onConnStateChange}},
+		mqtt.WithPingInterval(mqttPingInterval),
+		mqtt.WithTimeout(mqttTimeout),
+		mqtt.WithReconnectWait(mqttReconnectWait, mqttMaxReconnectWait),
+	)
+	if err != nil {
+		logger.Error("Error creating MQTT client", "error", err)
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	client.Handle(mqtt.HandlerFunc(func(msg *mqtt.Message) {
+		g.handleMessage(ctx, msg.Topic, msg.Payload)
+	}))
+
+	connectOptions := []mqtt.ConnectOption{mqtt.WithCleanSession(true)}
+	if g.Cfg.LiveMQTTUsername != "" {
+		connectOptions = append(connectOptions, mqtt.WithUserNamePassword(g.Cfg.LiveMQTTUsername, g.Cfg.LiveMQTTPassword))
+	}
+	if _, err := client.Connect(ctx, clientID, connectOptions...); err != nil {
+		logger.Error("Error connecting to MQTT broker", "error", err)
+	}
+	logger.Info("Live MQTT client started", "clientID", clientID)
+
+	g.subscribeOnConnect(ctx, client, connected)
+	disconnectCtx, cancel := context.WithTimeout(context.Background(), mqttTimeout)
+	defer cancel()
+	if err := client.Disconnect(disconnectCtx); err != nil {
+		logger.Debug("Error disconnecting from MQTT broker", "error", err)
+	}
+	return ctx.Err()
+}
+
+// topicChannel maps an MQTT topic to the stream/<streamID>/<topic> Live channel.
+func topicChannel(streamID string, topic string) (string, error) {
+	channel := liveDto.Channel{Scope: liveDto.ScopeStream, Namespace: streamID, Path: strings.Trim(topic, "/")}
+	if _, err := liveDto.ParseChannel(channel.String()); err != nil {
+		return "", fmt.Errorf("topic %q can't be mapped to a Live channel: %w", topic, err)
+	}
+	return channel.String(), nil
+}
+
+func (g *Gateway) handleMessage(ctx context.Context, topic string, payload []byte) {
+	channelID, err := topicChannel(g.Cfg.LiveMQTTStreamID, topic)
+	if err != nil {
+		logger.Warn("Skipping MQTT message", "error", err)
+		return
+	}
+	logger.Debug("Live Push request",
+		"protocol", "mqtt",
+		"topic", topic,
+		"channel", channelID,
+		"bodyLength", len(payload),
+	)
+
+	if g.GrafanaLive.Pipeline != nil {
+		ruleFound, err := g.GrafanaLive.Pipeline.ProcessInput(ctx, g.ns, channelID, payload)
+		if err != nil {
+			logger.Error("Pipeline input processing error", "error", err, "channel", channelID)
+			return
+		}
+		if ruleFound {
+			return
+		}
+	}
+
+	if err := g.push(ctx, channelID, payload); err != nil {
+		logger.Error("Error pushing MQTT message", "error", err, "channel", channelID)
+	}
+}
+
+// push converts the payload of a channel without pipeline rule and pushes the
+// frames to the managed streams.
+func (g *Gateway) push(ctx context.Context, channelID string, payload []byte) error {
+	channel, err := liveDto.ParseChannel(channelID)
+	if err != nil {
+		return err
+	}
+	channelFrames, err := g.converter.Convert(ctx, pipeline.Vars{
+		NS:      g.ns,
+		Channel: channelID,
+		Scope:   channel.Scope,
+		Stream:  channel.Namespace,
+		Path:    channel.Path,
+	}, payload)
+	if err != nil {
+		return err
+	}
+	for _, cf := range channelFrames {
+		frameChannel := channel
+		if cf.Channel != "" {
+			frameChannel, err = liveDto.ParseChannel(cf.Channel)
+			if err != nil {
+				return err
+			}
+		}
+		stream, err := g.GrafanaLive.ManagedStreamRunner.GetOrCreateStream(g.ns, frameChannel.Scope, frameChannel.Namespace)
+		if err != nil {
+			return err
+		}
+		if err := stream.Push(ctx, frameChannel.Path, cf.Frame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
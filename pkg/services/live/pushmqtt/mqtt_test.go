@@ -0,0 +1,117 @@
+package pushmqtt
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/at-wat/mqtt-go"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/live"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestTopicChannel(t *testing.T) {
+	channel, err := topicChannel("mqtt", "sensors/room-1/temperature")
+	require.NoError(t, err)
+	require.Equal(t, "stream/mqtt/sensors/room-1/temperature", channel)
+
+	channel, err = topicChannel("mqtt", "/sensors/")
+	require.NoError(t, err)
+	require.Equal(t, "stream/mqtt/sensors", channel)
+
+	_, err = topicChannel("mqtt", "sensors/room 1")
+	require.Error(t, err)
+	_, err = topicChannel("mqtt", "")
+	require.Error(t, err)
+}
+
+type publication struct {
+	ns      string
+	channel string
+	data    []byte
+}
+
+func TestGateway_HandleMessage(t *testing.T) {
+	var publications []publication
+	publish := func(ns string, channel string, data []byte) error {
+		publications = append(publications, publication{ns: ns, channel: channel, data: data})
+		return nil
+	}
+
+	cfg := setting.NewCfg()
+	cfg.LiveMQTTStreamID = "mqtt"
+	cfg.LiveMQTTOrgID = 1
+	cfg.LiveMQTTConverter = "influxAuto"
+	g := ProvideService(cfg, &live.GrafanaLive{
+		ManagedStreamRunner: managedstream.NewRunner(publish, nil, managedstream.NewMemoryFrameCache(), nil),
+	})
+
+	g.handleMessage(context.Background(), "sensors/room1", []byte("cpu,host=a value=1 1700000000000000000"))
+	require.Len(t, publications, 1)
+	require.Equal(t, "default", publications[0].ns)
+	require.Equal(t, "stream/mqtt/sensors/room1/cpu", publications[0].channel)
+
+	// Invalid payloads and topics are skipped.
+	g.handleMessage(context.Background(), "sensors/room1", []byte("not line protocol"))
+	g.handleMessage(context.Background(), "sensors/room 1", []byte("cpu,host=a value=1 1700000000000000000"))
+	require.Len(t, publications, 1)
+}
+
+func TestMqttClientID(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.InstanceName = "grafana-0"
+	id, err := mqttClientID(cfg)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(id, "grafana-live-grafana-0-"))
+	other, err := mqttClientID(cfg)
+	require.NoError(t, err)
+	require.NotEqual(t, id, other, "replicas with the same instance name get different client IDs")
+
+	cfg.LiveMQTTClientID = "custom"
+	id, err = mqttClientID(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "custom", id)
+}
+
+type fakeSubscriber struct {
+	subscribed chan []mqtt.Subscription
+}
+
+func (s *fakeSubscriber) Subscribe(_ context.Context, subs ...mqtt.Subscription) ([]mqtt.Subscription, error) {
+	s.subscribed <- subs
+	return subs, nil
+}
+
+func TestGateway_SubscribeOnConnect(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.LiveMQTTTopics = []string{"sensors/#", "devices/+/status"}
+	g := ProvideService(cfg, &live.GrafanaLive{})
+	client := &fakeSubscriber{subscribed: make(chan []mqtt.Subscription)}
+	connected := make(chan struct{}, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.subscribeOnConnect(ctx, client, connected)
+	}()
+
+	// The topics are subscribed to on connect, and again on each reconnect.
+	expected := []mqtt.Subscription{{Topic: "sensors/#", QoS: mqtt.QoS1}, {Topic: "devices/+/status", QoS: mqtt.QoS1}}
+	for range 2 {
+		connected <- struct{}{}
+		select {
+		case subs := <-client.subscribed:
+			require.Equal(t, expected, subs)
+		case <-time.After(5 * time.Second):
+			t.Fatal("topics not subscribed to")
+		}
+	}
+
+	cancel()
+	<-done
+}
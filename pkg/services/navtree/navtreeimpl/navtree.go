@@ -543,7 +543,7 @@ func (s *ServiceImpl) buildAlertNavLinks(c *contextmodel.ReqContext) *navtree.Na
 		}
 	}
 
-	if s.cfg.UnifiedAlerting.StateHistory.QueriesServedByLoki() {
+	if s.cfg.UnifiedAlerting.StateHistory.QueriesNotScopedToRule() {
 		if hasAccess(ac.EvalAny(ac.EvalPermission(ac.ActionAlertingRuleRead))) {
 			alertChildNavs = append(alertChildNavs, &navtree.NavLink{
 				Text: "History",
@@ -387,6 +387,82 @@ func (srv *ConvertPrometheusSrv) RouteConvertPrometheusGetRuleGroup(c *contextmo
 	return convertPrometheusResponse(c, http.StatusOK, promGroup)
 }
 
+// RouteConvertPrometheusExportRules exports Grafana-managed alert rules to the Prometheus rule file format.
+// Unlike the other GET routes, it is not limited to the rules that were imported from a Prometheus-compatible source.
+// The rules that cannot be represented in Prometheus format are left out of the rule groups and listed with the reason.
+func (srv *ConvertPrometheusSrv) RouteConvertPrometheusExportRules(c *contextmodel.ReqContext) response.Response {
+	logger := srv.logger.FromContext(c.Req.Context())
+
+	result := apimodels.PrometheusRulesExport{
+		Namespaces: map[string][]apimodels.PrometheusRuleGroup{},
+	}
+
+	folderUIDs := c.QueryStrings("folderUid")
+	if len(folderUIDs) == 0 {
+		workingFolderUID := getWorkingFolderUID(c)
+		logger = logger.New("working_folder_uid", workingFolderUID)
+
+		folders, err := srv.ruleStore.GetNamespaceChildren(c.Req.Context(), workingFolderUID, c.GetOrgID(), c.SignedInUser)
+		if len(folders) == 0 || errors.Is(err, dashboards.ErrFolderNotFound) {
+			return convertPrometheusResponse(c, http.StatusOK, result)
+		}
+		if err != nil {
+			logger.Error("Failed to get folders", "error", err)
+			return errorToResponse(err)
+		}
+		for _, f := range folders {
+			folderUIDs = append(folderUIDs, f.UID)
+		}
+	}
+
+	filterOpts := &provisioning.FilterOptions{
+		NamespaceUIDs: folderUIDs,
+	}
+	if group := c.Query("group"); group != "" {
+		filterOpts.RuleGroups = []string{group}
+	}
+	groups, err := srv.alertRuleService.GetAlertGroupsWithFolderFullpath(c.Req.Context(), c.SignedInUser, filterOpts)
+	if err != nil {
+		logger.Error("Failed to get alert groups", "error", err)
+		return errorToResponse(err)
+	}
+
+	datasourceTypes := map[string]string{}
+	datasourceType := func(uid string) (string, error) {
+		if dsType, ok := datasourceTypes[uid]; ok {
+			return dsType, nil
+		}
+		ds, err := srv.datasourceCache.GetDatasourceByUID(c.Req.Context(), uid, c.SignedInUser, c.SkipDSCache)
+		if err != nil {
+			return "", err
+		}
+		datasourceTypes[uid] = ds.Type
+		return ds.Type, nil
+	}
+
+	for _, group := range groups {
+		namespace := namespaceFromFolderFullpath(group.FolderFullpath)
+		promGroup, skipped := prom.ExportRuleGroup(group.Title, group.Rules, datasourceType)
+		if len(promGroup.Rules) > 0 {
+			result.Namespaces[namespace] = append(result.Namespaces[namespace], promGroup)
+		}
+		for _, rule := range skipped {
+			result.Skipped = append(result.Skipped, apimodels.PrometheusExportSkippedRule{
+				UID:       rule.UID,
+				Title:     rule.Title,
+				Namespace: namespace,
+				Group:     group.Title,
+				Reason:    rule.Reason,
+			})
+		}
+	}
+	if len(result.Skipped) > 0 {
+		logger.Debug("Some rules cannot be exported to Prometheus format", "skipped", len(result.Skipped))
+	}
+
+	return convertPrometheusResponse(c, http.StatusOK, result)
+}
+
 // rejectManagedFolderChange returns a 409 response when external ruler sync is
 // configured for the org and folderUID is inside the sync-managed folder
 // subtree, so manual convert-API mutations (imports and deletes) can't collide
@@ -800,18 +876,7 @@ func grafanaNamespacesToPrometheus(groups []models.AlertRuleGroupWithFolderFullp
 	result := map[string][]apimodels.PrometheusRuleGroup{}
 
 	for _, group := range groups {
-		// Since the folder can be nested but mimirtool does not support nested paths,
-		// we need to use only the last folder in the full path.
-		// For example, if the current working folder is "general" and the full path is "grafana/some folder/general/production",
-		// we should use the "production" folder.
-
-		// SplitFullpath (not filepath.Base) is used because the full path escapes separators
-		// within titles and is OS-independent, whereas filepath.Base treats "\" as a separator
-		// on Windows and would not unescape titles that contain a slash.
-		folder := group.FolderFullpath
-		if titles := folderimpl.SplitFullpath(group.FolderFullpath); len(titles) > 0 {
-			folder = titles[len(titles)-1]
-		}
+		folder := namespaceFromFolderFullpath(group.FolderFullpath)
 
 		promGroup, err := grafanaRuleGroupToPrometheus(group.Title, group.Rules)
 		if err != nil {
@@ -823,6 +888,21 @@ func grafanaNamespacesToPrometheus(groups []models.AlertRuleGroupWithFolderFullp
 	return result, nil
 }
 
+// namespaceFromFolderFullpath returns the title of the last folder in the full path.
+// Since the folder can be nested but mimirtool does not support nested paths,
+// we need to use only the last folder in the full path.
+// For example, if the current working folder is "general" and the full path is "grafana/some folder/general/production",
+// we should use the "production" folder.
+func namespaceFromFolderFullpath(fullpath string) string {
+	// SplitFullpath (not filepath.Base) is used because the full path escapes separators
+	// within titles and is OS-independent, whereas filepath.Base treats "\" as a separator
+	// on Windows and would not unescape titles that contain a slash.
+	if titles := folderimpl.SplitFullpath(fullpath); len(titles) > 0 {
+		return titles[len(titles)-1]
+	}
+	return fullpath
+}
+
 func grafanaRuleGroupToPrometheus(group string, rules []models.AlertRule) (apimodels.PrometheusRuleGroup, error) {
 	if len(rules) == 0 {
 		return apimodels.PrometheusRuleGroup{}, nil
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/grafana/grafana-plugin-sdk-go/data"
 	prommodel "github.com/prometheus/common/model"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -1246,6 +1247,99 @@ func TestRouteConvertPrometheusGetRules(t *testing.T) {
 	})
 }
 
+func TestRouteConvertPrometheusExportRules(t *testing.T) {
+	createQuery := func(dsUID string) models.AlertQuery {
+		return models.AlertQuery{
+			RefID:             "A",
+			DatasourceUID:     dsUID,
+			RelativeTimeRange: models.RelativeTimeRange{From: models.Duration(10 * time.Minute)},
+			Model:             json.RawMessage(`{"expr":"up == 0","instant":true,"refId":"A"}`),
+		}
+	}
+
+	setup := func(t *testing.T) (*ConvertPrometheusSrv, *folder.Folder, *models.AlertRule, *models.AlertRule) {
+		t.Helper()
+		folderService := foldertest.NewFakeService()
+		srv, _, ruleStore := createConvertPrometheusSrv(t, withFolderService(folderService))
+
+		fldr := randFolder()
+		fldr.ParentUID = ""
+		folderService.AddFolder(fldr)
+		folderService.ExpectedFolders = []*folder.Folder{fldr}
+		ruleStore.Folders[1] = append(ruleStore.Folders[1], fldr)
+
+		groupKey := models.GenerateGroupKey(1)
+		groupKey.NamespaceUID = fldr.UID
+		gen := models.RuleGen.With(
+			models.RuleGen.WithGroupKey(groupKey),
+			models.RuleGen.WithIntervalSeconds(60),
+			models.RuleGen.WithIsPaused(false),
+			models.RuleGen.WithFor(0),
+			models.RuleGen.WithKeepFiringFor(0),
+			models.RuleGen.WithNoDataExecAs(models.NoData),
+			models.RuleGen.WithErrorExecAs(models.ErrorErrState),
+			models.RuleGen.WithLabels(data.Labels{"severity": "critical"}),
+			models.RuleGen.WithAnnotations(data.Labels{"summary": "instance is down"}),
+		)
+		exported := gen.With(
+			gen.WithTitle("InstanceDown"),
+			gen.WithGroupIndex(1),
+			gen.WithQuery(createQuery(existingDSUID)),
+		).GenerateRef()
+		skipped := gen.With(
+			gen.WithTitle("UnknownDatasource"),
+			gen.WithGroupIndex(2),
+			gen.WithQuery(createQuery("unknown")),
+		).GenerateRef()
+		ruleStore.PutRule(context.Background(), exported, skipped)
+
+		return srv, fldr, exported, skipped
+	}
+
+	t.Run("should export rules and list the rules that cannot be exported", func(t *testing.T) {
+		srv, fldr, exported, skipped := setup(t)
+		rc := createRequestCtx()
+		rc.Req.Header.Set("Accept", "application/json")
+
+		response := srv.RouteConvertPrometheusExportRules(rc)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		var result apimodels.PrometheusRulesExport
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Equal(t, map[string][]apimodels.PrometheusRuleGroup{
+			fldr.Title: {{
+				Name:     exported.RuleGroup,
+				Interval: prommodel.Duration(time.Minute),
+				Rules: []apimodels.PrometheusRule{{
+					Alert:       "InstanceDown",
+					Expr:        "(up == 0) != 0",
+					Labels:      map[string]string{"severity": "critical"},
+					Annotations: map[string]string{"summary": "instance is down"},
+				}},
+			}},
+		}, result.Namespaces)
+		require.Len(t, result.Skipped, 1)
+		require.Equal(t, skipped.UID, result.Skipped[0].UID)
+		require.Equal(t, fldr.Title, result.Skipped[0].Namespace)
+		require.Equal(t, skipped.RuleGroup, result.Skipped[0].Group)
+		require.NotEmpty(t, result.Skipped[0].Reason)
+	})
+
+	t.Run("should filter by folder and group", func(t *testing.T) {
+		srv, fldr, _, _ := setup(t)
+
+		rc := createRequestCtx()
+		rc.Req = httptest.NewRequest("GET", "http://localhost?folderUid="+fldr.UID+"&group=other", nil)
+		response := srv.RouteConvertPrometheusExportRules(rc)
+		require.Equal(t, http.StatusOK, response.Status())
+
+		var result apimodels.PrometheusRulesExport
+		require.NoError(t, yaml.Unmarshal(response.Body(), &result))
+		require.Empty(t, result.Namespaces)
+		require.Empty(t, result.Skipped)
+	})
+}
+
 func TestConvertPrometheusResponse(t *testing.T) {
 	testData := map[string][]apimodels.PrometheusRuleGroup{
 		"test": {
@@ -79,6 +79,7 @@ type AlertRuleService interface {
 	DeleteAlertRule(ctx context.Context, user identity.Requester, ruleUID string, manager utils.ManagerProperties) error
 	GetRuleGroup(ctx context.Context, user identity.Requester, folder, group string) (alerting_models.AlertRuleGroup, error)
 	ReplaceRuleGroup(ctx context.Context, user identity.Requester, group alerting_models.AlertRuleGroup, manager utils.ManagerProperties, message string) error
+	DryRunReplaceRuleGroup(ctx context.Context, user identity.Requester, group alerting_models.AlertRuleGroup, manager utils.ManagerProperties) (*store.GroupDelta, error)
 	DeleteRuleGroup(ctx context.Context, user identity.Requester, folder, group string, manager utils.ManagerProperties) error
 	DeleteRuleGroups(ctx context.Context, user identity.Requester, manager utils.ManagerProperties, opts *provisioning.FilterOptions) error
 	GetAlertRuleWithFolderFullpath(ctx context.Context, u identity.Requester, ruleUID string) (provisioning.AlertRuleWithFolderFullpath, error)
@@ -520,10 +521,24 @@ func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *contextmodel.ReqContext, a
 		ErrResp(http.StatusBadRequest, err, "")
 	}
 	manager := determineManagerProperties(c)
+	if c.QueryBool("dryRun") {
+		changes, err := srv.alertRules.DryRunReplaceRuleGroup(c.Req.Context(), c.SignedInUser, groupModel, manager)
+		if err != nil {
+			return replaceRuleGroupErrorResponse(err)
+		}
+		return response.JSON(http.StatusOK, changesToDryRunResponse(changes))
+	}
 	// TODO: https://github.com/grafana/grafana/issues/114197
 	// Support passing change messages.
 	changeMessage := ""
 	err = srv.alertRules.ReplaceRuleGroup(c.Req.Context(), c.SignedInUser, groupModel, manager, changeMessage)
+	if err != nil {
+		return replaceRuleGroupErrorResponse(err)
+	}
+	return response.JSON(http.StatusOK, ag)
+}
+
+func replaceRuleGroupErrorResponse(err error) response.Response {
 	if errors.Is(err, alerting_models.ErrAlertRuleFailedValidation) {
 		return ErrResp(http.StatusBadRequest, err, "")
 	}
@@ -533,10 +548,7 @@ func (srv *ProvisioningSrv) RoutePutAlertRuleGroup(c *contextmodel.ReqContext, a
 	if errors.Is(err, alerting_models.ErrQuotaReached) {
 		return ErrResp(http.StatusForbidden, err, "")
 	}
-	if err != nil {
-		return response.ErrOrFallback(http.StatusInternalServerError, "", err)
-	}
-	return response.JSON(http.StatusOK, ag)
+	return response.ErrOrFallback(http.StatusInternalServerError, "", err)
 }
 
 func (srv *ProvisioningSrv) RouteDeleteAlertRuleGroup(c *contextmodel.ReqContext, folderUID string, group string) response.Response {
@@ -724,6 +724,21 @@ func TestIntegrationProvisioningApi(t *testing.T) {
 			require.Equal(t, 403, response.Status())
 		})
 
+		t.Run("have reached the rule quota, PUT dry run returns 403", func(t *testing.T) {
+			env := createTestEnv(t, testConfig)
+			quotas := provisioning.MockQuotaChecker{}
+			quotas.EXPECT().LimitExceeded()
+			env.quotas = &quotas
+			sut := createProvisioningSrvSutFromEnv(t, &env)
+			group := createTestAlertRuleGroup(1)
+			rc := createTestRequestCtx()
+			rc.Req.Form.Set("dryRun", "true")
+
+			response := sut.RoutePutAlertRuleGroup(&rc, group, "folder-uid", group.Title)
+
+			require.Equal(t, 403, response.Status())
+		})
+
 		t.Run("are valid", func(t *testing.T) {
 			t.Run("PUT returns 200", func(t *testing.T) {
 				sut := createProvisioningSrvSut(t)
@@ -739,6 +754,23 @@ func TestIntegrationProvisioningApi(t *testing.T) {
 				require.Equal(t, group.Title, updated.Title)
 			})
 
+			t.Run("PUT dry run returns the changes", func(t *testing.T) {
+				sut := createProvisioningSrvSut(t)
+				rc := createTestRequestCtx()
+				rc.Req.Form.Set("dryRun", "true")
+				group := createTestAlertRuleGroup(1)
+
+				response := sut.RoutePutAlertRuleGroup(&rc, group, "folder-uid", group.Title)
+
+				require.Equal(t, 200, response.Status())
+				result := definitions.RuleGroupDryRunResponse{}
+				require.NoError(t, json.Unmarshal(response.Body(), &result))
+				require.Len(t, result.Created, len(group.Rules))
+				for _, created := range result.Created {
+					require.NotEmpty(t, created.UID)
+				}
+			})
+
 			t.Run("PUT with MissingSeriesEvalsToResolve updates the value", func(t *testing.T) {
 				sut := createProvisioningSrvSut(t)
 				rc := createTestRequestCtx()
@@ -30,6 +30,7 @@ import (
 	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/ngalert/notifier"
 	"github.com/grafana/grafana/pkg/services/ngalert/provisioning"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
 	"github.com/grafana/grafana/pkg/services/ngalert/store"
 	"github.com/grafana/grafana/pkg/services/quota"
 	"github.com/grafana/grafana/pkg/services/user"
@@ -64,6 +65,10 @@ type RulerSrv struct {
 	amConfigStore  AMConfigStore
 	amRefresher    AMRefresher
 	featureManager featuremgmt.FeatureToggles
+
+	// evaluator and stateManager are used to preview the state changes of rules in dry-run requests.
+	evaluator    eval.EvaluatorFactory
+	stateManager state.AlertInstanceManager
 }
 
 var (
@@ -441,6 +446,10 @@ func (srv RulerSrv) RoutePostNameRulesConfig(c *contextmodel.ReqContext, ruleGro
 		RuleGroup:    ruleGroupConfig.Name,
 	}
 
+	if c.QueryBool("dryRun") {
+		return srv.dryRunUpdateAlertRulesInGroup(c, groupKey, rules, c.QueryBool("evaluate"))
+	}
+
 	return srv.updateAlertRulesInGroup(c, groupKey, rules, deletePermanently)
 }
 
@@ -472,21 +481,10 @@ func (srv RulerSrv) checkGroupLimits(group apimodels.PostableRuleGroupConfig) er
 //
 //nolint:gocyclo
 func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals, deletePermanently bool) response.Response {
-	finalChanges, amConfig, err := srv.performUpdateAlertRules(c.Req.Context(), c, groupKey, rules, deletePermanently)
+	finalChanges, amConfig, err := srv.performUpdateAlertRules(c.Req.Context(), c, groupKey, rules, deletePermanently, false)
 
 	if err != nil {
-		if errors.As(err, &errutil.Error{}) {
-			return response.Err(err)
-		} else if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
-			return ErrResp(http.StatusNotFound, err, "failed to update rule group")
-		} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) || errors.Is(err, errProvisionedResource) {
-			return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
-		} else if errors.Is(err, ngmodels.ErrQuotaReached) {
-			return ErrResp(http.StatusForbidden, err, "")
-		} else if errors.Is(err, store.ErrOptimisticLock) {
-			return ErrResp(http.StatusConflict, err, "")
-		}
-		return ErrResp(http.StatusInternalServerError, err, "failed to update rule group")
+		return toUpdateRuleGroupErrorResponse(err)
 	}
 
 	if amConfig != nil {
@@ -500,7 +498,10 @@ func (srv RulerSrv) updateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey
 	return changesToResponse(finalChanges)
 }
 
-func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals, deletePermanently bool) (*store.GroupDelta, *ngmodels.AlertConfiguration, error) {
+// performUpdateAlertRules calculates and validates the changes in the group, and applies them to the database.
+// If dryRun is true, the changes go through the same path, including the quota check, but the transaction is rolled back
+// and no Alertmanager configuration is returned.
+func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals, deletePermanently bool, dryRun bool) (*store.GroupDelta, *ngmodels.AlertConfiguration, error) {
 	var finalChanges *store.GroupDelta
 	var dbConfig *ngmodels.AlertConfiguration
 	err := srv.xactManager.InTransaction(ctx, func(tranCtx context.Context) error {
@@ -560,6 +561,12 @@ func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel
 		}
 
 		finalChanges = store.UpdateCalculatedRuleFields(groupChanges)
+		for _, update := range finalChanges.Update {
+			if ngmodels.IsNoGroupRuleGroup(update.Existing.RuleGroup) && !ngmodels.IsNoGroupRuleGroup(update.New.RuleGroup) {
+				return fmt.Errorf("%w: cannot move rule out of this group", ngmodels.ErrAlertRuleFailedValidation)
+			}
+		}
+
 		logger.Debug("Updating database with the authorized changes", "add", len(finalChanges.New), "update", len(finalChanges.New), "delete", len(finalChanges.Delete))
 
 		// Delete first as this could prevent future unique constraint violations.
@@ -578,9 +585,6 @@ func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel
 			updates := make([]ngmodels.UpdateRule, 0, len(finalChanges.Update))
 			for _, update := range finalChanges.Update {
 				logger.Debug("Updating rule", "rule_uid", update.New.UID, "diff", update.Diff.String())
-				if ngmodels.IsNoGroupRuleGroup(update.Existing.RuleGroup) && !ngmodels.IsNoGroupRuleGroup(update.New.RuleGroup) {
-					return fmt.Errorf("%w: cannot move rule out of this group", ngmodels.ErrAlertRuleFailedValidation)
-				}
 				updates = append(updates, ngmodels.UpdateRule{
 					Existing: update.Existing,
 					New:      *update.New,
@@ -624,9 +628,17 @@ func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel
 				return ngmodels.ErrQuotaReached
 			}
 		}
+
+		if dryRun {
+			logger.Debug("Dry run, rolling back the changes", "add", len(finalChanges.New), "update", len(finalChanges.Update), "delete", len(finalChanges.Delete))
+			return store.ErrDryRunRollback
+		}
 		return nil
 	})
 
+	if dryRun && errors.Is(err, store.ErrDryRunRollback) {
+		return finalChanges, nil, nil
+	}
 	if err != nil {
 		return nil, nil, err
 	}
@@ -634,6 +646,21 @@ func (srv RulerSrv) performUpdateAlertRules(ctx context.Context, c *contextmodel
 	return finalChanges, dbConfig, nil
 }
 
+func toUpdateRuleGroupErrorResponse(err error) response.Response {
+	if errors.As(err, &errutil.Error{}) {
+		return response.Err(err)
+	} else if errors.Is(err, ngmodels.ErrAlertRuleNotFound) {
+		return ErrResp(http.StatusNotFound, err, "failed to update rule group")
+	} else if errors.Is(err, ngmodels.ErrAlertRuleFailedValidation) || errors.Is(err, errProvisionedResource) {
+		return ErrResp(http.StatusBadRequest, err, "failed to update rule group")
+	} else if errors.Is(err, ngmodels.ErrQuotaReached) {
+		return ErrResp(http.StatusForbidden, err, "")
+	} else if errors.Is(err, store.ErrOptimisticLock) {
+		return ErrResp(http.StatusConflict, err, "")
+	}
+	return ErrResp(http.StatusInternalServerError, err, "failed to update rule group")
+}
+
 func changesToResponse(finalChanges *store.GroupDelta) response.Response {
 	body := apimodels.UpdateRuleGroupResponse{
 		Message: "rule group updated successfully",
@@ -898,7 +925,7 @@ func (srv RulerSrv) RouteUpdateNamespaceRules(c *contextmodel.ReqContext, body a
 
 				rulesToUpdate = append(rulesToUpdate, &r)
 			}
-			_, _, err := srv.performUpdateAlertRules(ctx, c, groupKey, rulesToUpdate, false)
+			_, _, err := srv.performUpdateAlertRules(ctx, c, groupKey, rulesToUpdate, false, false)
 			if errors.Is(err, errProvisionedResource) {
 				continue
 			}
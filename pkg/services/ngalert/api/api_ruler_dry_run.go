@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/api/response"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	ngmodels "github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/util/cmputil"
+)
+
+// dryRunUpdateAlertRulesInGroup applies the changes in the rule group in the same way as updateAlertRulesInGroup, and then
+// rolls them back. If evaluate is true, the new and updated alerting rules are evaluated once, and the alert instances
+// whose state would change are added to the response.
+func (srv RulerSrv) dryRunUpdateAlertRulesInGroup(c *contextmodel.ReqContext, groupKey ngmodels.AlertRuleGroupKey, rules []*ngmodels.AlertRuleWithOptionals, evaluate bool) response.Response {
+	changes, _, err := srv.performUpdateAlertRules(c.Req.Context(), c, groupKey, rules, false, true)
+	if err != nil {
+		return toUpdateRuleGroupErrorResponse(err)
+	}
+
+	body := changesToDryRunResponse(changes)
+	if evaluate && !changes.IsEmpty() {
+		stateChanges, err := srv.evaluateRuleChanges(c.Req.Context(), c.SignedInUser, changes, timeNow())
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "failed to evaluate rules")
+		}
+		body.StateChanges = stateChanges
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+func changesToDryRunResponse(changes *store.GroupDelta) apimodels.RuleGroupDryRunResponse {
+	body := apimodels.RuleGroupDryRunResponse{
+		Message: "no changes detected in the rule group",
+	}
+	if changes.IsEmpty() {
+		return body
+	}
+	body.Message = "rule group changes are valid, nothing was saved"
+	for _, r := range changes.New {
+		body.Created = append(body.Created, apimodels.RuleChange{UID: r.UID, Title: r.Title})
+	}
+	for _, r := range changes.Update {
+		body.Updated = append(body.Updated, apimodels.RuleChange{
+			UID:   r.Existing.UID,
+			Title: r.New.Title,
+			Diff:  toRuleFieldChanges(r.Diff),
+		})
+	}
+	for _, r := range changes.Delete {
+		body.Deleted = append(body.Deleted, apimodels.RuleChange{UID: r.UID, Title: r.Title})
+	}
+	return body
+}
+
+func toRuleFieldChanges(diff cmputil.DiffReport) []apimodels.RuleFieldChange {
+	result := make([]apimodels.RuleFieldChange, 0, len(diff))
+	for _, d := range diff {
+		result = append(result, apimodels.RuleFieldChange{
+			Path: d.Path,
+			Old:  diffValue(d.Left),
+			New:  diffValue(d.Right),
+		})
+	}
+	return result
+}
+
+// diffValue returns the value of one side of a diff, or nil if the field does not exist on that side.
+func diffValue(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// evaluateRuleChanges evaluates the new alerting rules and the updated alerting rules whose definition changed, and
+// compares the results with the latest evaluation of the existing rules. Instances that are no longer returned by the
+// queries of an updated rule are reported as Normal, because the state manager resolves them on the next evaluation.
+func (srv RulerSrv) evaluateRuleChanges(ctx context.Context, user identity.Requester, changes *store.GroupDelta, now time.Time) ([]apimodels.RuleInstanceStateChange, error) {
+	var result []apimodels.RuleInstanceStateChange
+	for _, rule := range changes.New {
+		if rule.Type() != ngmodels.RuleTypeAlerting || rule.IsPaused {
+			continue
+		}
+		stateChanges, err := srv.evaluateRuleChange(ctx, user, rule, nil, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, stateChanges...)
+	}
+	for _, upd := range changes.Update {
+		if upd.New.Type() != ngmodels.RuleTypeAlerting || upd.New.IsPaused || !shouldValidate(upd) {
+			continue
+		}
+		stateChanges, err := srv.evaluateRuleChange(ctx, user, upd.New, upd.Existing, now)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, stateChanges...)
+	}
+	return result, nil
+}
+
+func (srv RulerSrv) evaluateRuleChange(ctx context.Context, user identity.Requester, rule *ngmodels.AlertRule, existing *ngmodels.AlertRule, now time.Time) ([]apimodels.RuleInstanceStateChange, error) {
+	evaluator, err := srv.evaluator.Create(eval.NewContext(ctx, user), rule.GetEvalCondition().WithSource("dry_run"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build evaluator for rule '%s': %w", rule.Title, err)
+	}
+	results, err := evaluator.Evaluate(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate rule '%s': %w", rule.Title, err)
+	}
+
+	type previousState struct {
+		labels data.Labels
+		state  eval.State
+	}
+	previous := map[data.Fingerprint]previousState{}
+	if existing != nil && !existing.IsPaused {
+		for _, s := range srv.stateManager.GetStatesForRuleUID(ctx, existing.OrgID, existing.UID) {
+			if s.LatestResult == nil {
+				continue
+			}
+			previous[s.ResultFingerprint] = previousState{labels: s.Labels, state: s.LatestResult.EvaluationState}
+		}
+	}
+
+	var stateChanges []apimodels.RuleInstanceStateChange
+	for _, r := range results {
+		fp := r.Instance.Fingerprint()
+		prev, ok := previous[fp]
+		delete(previous, fp)
+		if ok && prev.state == r.State {
+			continue
+		}
+		change := apimodels.RuleInstanceStateChange{
+			RuleUID:   rule.UID,
+			RuleTitle: rule.Title,
+			Labels:    r.Instance,
+			Current:   r.State.String(),
+		}
+		if ok {
+			change.Previous = prev.state.String()
+		}
+		stateChanges = append(stateChanges, change)
+	}
+	for _, prev := range previous {
+		if prev.state == eval.Normal {
+			continue
+		}
+		stateChanges = append(stateChanges, apimodels.RuleInstanceStateChange{
+			RuleUID:   rule.UID,
+			RuleTitle: rule.Title,
+			Labels:    prev.labels,
+			Previous:  prev.state.String(),
+			Current:   eval.Normal.String(),
+		})
+	}
+	return stateChanges, nil
+}
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	apimodels "github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval/eval_mocks"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/store"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+	"github.com/grafana/grafana/pkg/services/quota/quotatest"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+func TestChangesToDryRunResponse(t *testing.T) {
+	gen := models.RuleGen.With(models.RuleGen.WithOrgID(1), models.RuleGen.WithIsPaused(false))
+
+	t.Run("should return message if there are no changes", func(t *testing.T) {
+		body := changesToDryRunResponse(&store.GroupDelta{})
+		require.Equal(t, "no changes detected in the rule group", body.Message)
+		require.Empty(t, body.Created)
+		require.Empty(t, body.Updated)
+		require.Empty(t, body.Deleted)
+	})
+
+	t.Run("should return created, updated and deleted rules with field changes", func(t *testing.T) {
+		created := gen.GenerateRef()
+		deleted := gen.GenerateRef()
+		existing := gen.GenerateRef()
+		updated := existing.Copy()
+		updated.Title = "new title"
+		updated.IntervalSeconds = existing.IntervalSeconds + 10
+
+		body := changesToDryRunResponse(&store.GroupDelta{
+			New: []*models.AlertRule{created},
+			Update: []store.RuleDelta{{
+				Existing: existing,
+				New:      updated,
+				Diff:     existing.Diff(updated),
+			}},
+			Delete: []*models.AlertRule{deleted},
+		})
+
+		require.Equal(t, []apimodels.RuleChange{{UID: created.UID, Title: created.Title}}, body.Created)
+		require.Equal(t, []apimodels.RuleChange{{UID: deleted.UID, Title: deleted.Title}}, body.Deleted)
+		require.Len(t, body.Updated, 1)
+		require.Equal(t, existing.UID, body.Updated[0].UID)
+		require.Equal(t, "new title", body.Updated[0].Title)
+		require.ElementsMatch(t, []apimodels.RuleFieldChange{
+			{Path: "Title", Old: existing.Title, New: "new title"},
+			{Path: "IntervalSeconds", Old: existing.IntervalSeconds, New: updated.IntervalSeconds},
+		}, body.Updated[0].Diff)
+	})
+}
+
+func TestEvaluateRuleChanges(t *testing.T) {
+	orgID := int64(1)
+	gen := models.RuleGen.With(models.RuleGen.WithOrgID(orgID), models.RuleGen.WithIsPaused(false))
+	now := time.Now()
+	requester := &user.SignedInUser{OrgID: orgID}
+
+	createSrv := func(t *testing.T, results eval.Results) (*RulerSrv, *fakeAlertInstanceManager) {
+		t.Helper()
+		evaluator := &eval_mocks.ConditionEvaluatorMock{}
+		evaluator.EXPECT().Evaluate(mock.Anything, now).Return(results, nil)
+		aim := NewFakeAlertInstanceManager(t)
+		return &RulerSrv{
+			evaluator:    eval_mocks.NewEvaluatorFactory(evaluator),
+			stateManager: aim,
+		}, aim
+	}
+
+	t.Run("should return all instances of new rules", func(t *testing.T) {
+		srv, _ := createSrv(t, eval.Results{
+			{Instance: data.Labels{"instance": "a"}, State: eval.Alerting},
+			{Instance: data.Labels{"instance": "b"}, State: eval.Normal},
+		})
+		rule := gen.GenerateRef()
+
+		changes, err := srv.evaluateRuleChanges(context.Background(), requester, &store.GroupDelta{
+			New: []*models.AlertRule{rule},
+		}, now)
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.RuleInstanceStateChange{
+			{RuleUID: rule.UID, RuleTitle: rule.Title, Labels: map[string]string{"instance": "a"}, Current: "Alerting"},
+			{RuleUID: rule.UID, RuleTitle: rule.Title, Labels: map[string]string{"instance": "b"}, Current: "Normal"},
+		}, changes)
+	})
+
+	t.Run("should return instances of updated rules whose state changes", func(t *testing.T) {
+		srv, aim := createSrv(t, eval.Results{
+			{Instance: data.Labels{"instance": "a"}, State: eval.Alerting},
+			{Instance: data.Labels{"instance": "b"}, State: eval.Normal},
+		})
+		existing := gen.GenerateRef()
+		updated := existing.Copy()
+		updated.IntervalSeconds = existing.IntervalSeconds + 10
+
+		previousState := func(lbls data.Labels, s eval.State) *state.State {
+			return &state.State{
+				OrgID:             orgID,
+				AlertRuleUID:      existing.UID,
+				Labels:            lbls,
+				ResultFingerprint: lbls.Fingerprint(),
+				LatestResult:      &state.Evaluation{EvaluationState: s},
+			}
+		}
+		aim.states[orgID] = map[string][]*state.State{
+			existing.UID: {
+				previousState(data.Labels{"instance": "a"}, eval.Normal),
+				previousState(data.Labels{"instance": "b"}, eval.Normal),
+				previousState(data.Labels{"instance": "c"}, eval.Alerting),
+			},
+		}
+
+		changes, err := srv.evaluateRuleChanges(context.Background(), requester, &store.GroupDelta{
+			Update: []store.RuleDelta{{
+				Existing: existing,
+				New:      updated,
+				Diff:     existing.Diff(updated),
+			}},
+		}, now)
+		require.NoError(t, err)
+		require.Equal(t, []apimodels.RuleInstanceStateChange{
+			{RuleUID: existing.UID, RuleTitle: existing.Title, Labels: map[string]string{"instance": "a"}, Previous: "Normal", Current: "Alerting"},
+			{RuleUID: existing.UID, RuleTitle: existing.Title, Labels: map[string]string{"instance": "c"}, Previous: "Alerting", Current: "Normal"},
+		}, changes)
+	})
+
+	t.Run("should not evaluate recording rules and updates that do not change the rule definition", func(t *testing.T) {
+		srv := &RulerSrv{
+			evaluator:    eval_mocks.NewEvaluatorFactory(&eval_mocks.ConditionEvaluatorMock{}),
+			stateManager: NewFakeAlertInstanceManager(t),
+		}
+		recording := gen.With(gen.WithAllRecordingRules()).GenerateRef()
+		existing := gen.GenerateRef()
+		reordered := existing.Copy()
+		reordered.RuleGroupIndex = existing.RuleGroupIndex + 1
+
+		changes, err := srv.evaluateRuleChanges(context.Background(), requester, &store.GroupDelta{
+			New: []*models.AlertRule{recording},
+			Update: []store.RuleDelta{{
+				Existing: existing,
+				New:      reordered,
+				Diff:     existing.Diff(reordered),
+			}},
+		}, now)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+}
+
+// rollbackRecordingTransactionManager records the error returned by the work, which rolls back a real transaction.
+type rollbackRecordingTransactionManager struct {
+	err error
+}
+
+func (m *rollbackRecordingTransactionManager) InTransaction(ctx context.Context, work func(ctx context.Context) error) error {
+	m.err = work(ctx)
+	return m.err
+}
+
+func TestDryRunUpdateAlertRulesInGroup(t *testing.T) {
+	orgID := rand.Int63()
+	f := randFolder()
+	groupKey := models.GenerateGroupKey(orgID)
+	groupKey.NamespaceUID = f.UID
+	gen := models.RuleGen.With(models.RuleGen.WithGroupKey(groupKey), models.RuleGen.WithNoNotificationSettings())
+
+	setup := func(t *testing.T, limitReached bool) (*RulerSrv, *fakes.RuleStore, *rollbackRecordingTransactionManager) {
+		t.Helper()
+		ruleStore := fakes.NewRuleStore(t)
+		ruleStore.Folders[orgID] = append(ruleStore.Folders[orgID], f)
+		xact := &rollbackRecordingTransactionManager{}
+		srv := createService(ruleStore, nil)
+		srv.xactManager = xact
+		srv.QuotaService = quotatest.New(limitReached, nil)
+		return srv, ruleStore, xact
+	}
+
+	t.Run("should apply the changes and roll them back", func(t *testing.T) {
+		srv, ruleStore, xact := setup(t, false)
+		rules := gen.GenerateManyRef(2)
+		req := createRequestContextWithPerms(orgID, createPermissionsForRules(rules, orgID), nil)
+
+		response := srv.dryRunUpdateAlertRulesInGroup(req, groupKey, toRulesWithOptionals(rules), false)
+
+		require.Equal(t, http.StatusOK, response.Status())
+		result := apimodels.RuleGroupDryRunResponse{}
+		require.NoError(t, json.Unmarshal(response.Body(), &result))
+		require.Len(t, result.Created, len(rules))
+		for _, created := range result.Created {
+			require.NotEmpty(t, created.UID)
+		}
+		require.ErrorIs(t, xact.err, store.ErrDryRunRollback)
+		inserts := ruleStore.GetRecordedCommands(func(cmd any) (any, bool) {
+			_, ok := cmd.([]models.InsertRule)
+			return cmd, ok
+		})
+		require.Len(t, inserts, 1)
+	})
+
+	t.Run("should return 403 when the quota is reached", func(t *testing.T) {
+		srv, _, _ := setup(t, true)
+		rules := gen.GenerateManyRef(1)
+		req := createRequestContextWithPerms(orgID, createPermissionsForRules(rules, orgID), nil)
+
+		response := srv.dryRunUpdateAlertRulesInGroup(req, groupKey, toRulesWithOptionals(rules), false)
+
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+}
+
+func toRulesWithOptionals(rules []*models.AlertRule) []*models.AlertRuleWithOptionals {
+	result := make([]*models.AlertRuleWithOptionals, 0, len(rules))
+	for _, r := range rules {
+		result = append(result, &models.AlertRuleWithOptionals{AlertRule: *r})
+	}
+	return result
+}
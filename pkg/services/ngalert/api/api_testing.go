@@ -10,8 +10,10 @@ import (
 
 	"github.com/benbjohnson/clock"
 	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	prommodel "github.com/prometheus/common/model"
 
 	"github.com/grafana/alerting/models"
+	alertingNotify "github.com/grafana/alerting/notify"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 
 	"github.com/grafana/grafana-plugin-sdk-go/data"
@@ -20,6 +22,7 @@ import (
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	ac "github.com/grafana/grafana/pkg/services/accesscontrol"
 	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
@@ -39,6 +42,20 @@ type folderService interface {
 	GetNamespaceByUID(ctx context.Context, uid string, orgID int64, user identity.Requester) (*foldermodel.Folder, error)
 }
 
+type notificationsConfigProvider interface {
+	GetNotificationsConfiguration(ctx context.Context, org int64) (alertingNotify.NotificationsConfiguration, error)
+}
+
+// simulateNotificationsEval asserts read access to the notification policies and contact points,
+// which are revealed by the simulated notifications of a backtest.
+var simulateNotificationsEval = ac.EvalAny(
+	ac.EvalPermission(ac.ActionAlertingNotificationsRead),
+	ac.EvalAll(
+		ac.EvalPermission(ac.ActionAlertingRoutesRead),
+		ac.EvalPermission(ac.ActionAlertingReceiversRead, ngmodels.ScopeReceiversAll),
+	),
+)
+
 type TestingApiSrv struct {
 	*AlertingProxy
 	DatasourceCache datasources.CacheService
@@ -51,6 +68,8 @@ type TestingApiSrv struct {
 	appUrl          *url.URL
 	tracer          tracing.Tracer
 	folderService   folderService
+	notifications   notificationsConfigProvider
+	ac              ac.AccessControl
 }
 
 // RouteTestGrafanaRuleConfig returns a list of potential alerts for a given rule configuration. This is intended to be
@@ -261,3 +280,112 @@ func (srv TestingApiSrv) BacktestAlertRule(c *contextmodel.ReqContext, cmd apimo
 
 	return response.JSONStreaming(http.StatusOK, result)
 }
+
+func (srv TestingApiSrv) BacktestRuleGroup(c *contextmodel.ReqContext, cmd apimodels.BacktestGroupConfig) response.Response {
+	//nolint:staticcheck // not yet migrated to OpenFeature
+	if !srv.featureManager.IsEnabled(c.Req.Context(), featuremgmt.FlagAlertingBacktesting) {
+		return ErrResp(http.StatusNotFound, nil, "Backtesting API is not enabled")
+	}
+
+	if cmd.SimulateNotifications {
+		canSimulate, err := srv.ac.Evaluate(c.Req.Context(), c.SignedInUser, simulateNotificationsEval)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "Failed to check permissions")
+		}
+		if !canSimulate {
+			return ErrResp(http.StatusForbidden, errors.New("simulating notifications requires permission to read notification policies and contact points"), "")
+		}
+	}
+
+	rules, err := apivalidation.ValidateBacktestGroupConfig(c.GetOrgID(), cmd, apivalidation.RuleLimitsFromConfig(srv.cfg, srv.featureManager))
+	if err != nil {
+		return ErrResp(http.StatusBadRequest, err, "")
+	}
+
+	if err := srv.authz.AuthorizeDatasourceAccessForRuleGroup(c.Req.Context(), c.SignedInUser, rules); err != nil {
+		return errorToResponse(err)
+	}
+
+	// Fetch folder path for alert labels, fallback to "Backtesting" if not available
+	var folderTitle string
+	if cmd.NamespaceUID != "" {
+		f, err := srv.folderService.GetNamespaceByUID(c.Req.Context(), cmd.NamespaceUID, c.OrgID, c.SignedInUser)
+		if err != nil {
+			srv.log.FromContext(c.Req.Context()).Warn("Failed to fetch folder path for alert labels", "error", err)
+		} else {
+			folderTitle = f.Fullpath
+		}
+	}
+
+	result, err := srv.backtesting.TestGroup(c.Req.Context(), c.SignedInUser, rules, cmd.From, cmd.To, folderTitle)
+	if err != nil {
+		if errors.Is(err, backtesting.ErrInvalidInputData) {
+			return ErrResp(http.StatusBadRequest, err, "Failed to evaluate")
+		}
+		return ErrResp(http.StatusInternalServerError, err, "Failed to evaluate")
+	}
+
+	body := apimodels.BacktestGroupResult{
+		Rules:         make([]apimodels.BacktestRuleResult, 0, len(result.Rules)),
+		Notifications: make([]apimodels.BacktestNotification, 0, len(result.Notifications)),
+		Warnings:      result.Warnings,
+	}
+	for _, r := range result.Rules {
+		body.Rules = append(body.Rules, apimodels.BacktestRuleResult{
+			UID:    r.UID,
+			Title:  r.Title,
+			Type:   string(r.Type),
+			States: r.States,
+			Series: r.Series,
+		})
+	}
+	for _, n := range result.Notifications {
+		body.Notifications = append(body.Notifications, apimodels.BacktestNotification{
+			RuleUID:     n.RuleUID,
+			EvaluatedAt: n.EvaluatedAt,
+			Alert:       n.Alert,
+		})
+	}
+
+	if cmd.SimulateNotifications {
+		cfg, err := srv.notifications.GetNotificationsConfiguration(c.Req.Context(), c.GetOrgID())
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "Failed to get notification configuration")
+		}
+		simulated, err := backtesting.SimulateNotifications(cfg, result.Notifications, cmd.To)
+		if err != nil {
+			return ErrResp(http.StatusInternalServerError, err, "Failed to simulate notifications")
+		}
+		body.SimulatedNotifications = make([]apimodels.BacktestSimulatedNotification, 0, len(simulated))
+		for _, n := range simulated {
+			body.SimulatedNotifications = append(body.SimulatedNotifications, apimodels.BacktestSimulatedNotification{
+				Receiver:    n.Receiver,
+				GroupKey:    n.GroupKey,
+				GroupLabels: labelSetToMap(n.GroupLabels),
+				NotifiedAt:  n.NotifiedAt,
+				Firing:      labelSetsToMaps(n.Firing),
+				Resolved:    labelSetsToMaps(n.Resolved),
+			})
+		}
+	}
+	return response.JSON(http.StatusOK, body)
+}
+
+func labelSetToMap(lset prommodel.LabelSet) map[string]string {
+	result := make(map[string]string, len(lset))
+	for k, v := range lset {
+		result[string(k)] = string(v)
+	}
+	return result
+}
+
+func labelSetsToMaps(lsets []prommodel.LabelSet) []map[string]string {
+	if len(lsets) == 0 {
+		return nil
+	}
+	result := make([]map[string]string, 0, len(lsets))
+	for _, lset := range lsets {
+		result = append(result, labelSetToMap(lset))
+	}
+	return result
+}
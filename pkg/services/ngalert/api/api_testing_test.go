@@ -391,6 +391,50 @@ func TestRouteEvalQueries(t *testing.T) {
 	})
 }
 
+func TestBacktestRuleGroup(t *testing.T) {
+	rc := func(permissions map[string][]string) *contextmodel.ReqContext {
+		return &contextmodel.ReqContext{
+			Context: &web.Context{
+				Req: &http.Request{},
+			},
+			SignedInUser: &user.SignedInUser{
+				OrgID:       1,
+				Permissions: map[int64]map[string][]string{1: permissions},
+			},
+		}
+	}
+	features := featuremgmt.WithFeatures(featuremgmt.FlagAlertingBacktesting)
+	evaluator := eval_mocks.NewEvaluatorFactory(&eval_mocks.ConditionEvaluatorMock{})
+
+	t.Run("should return Forbidden when simulating notifications without access to the notification settings", func(t *testing.T) {
+		srv := createTestingApiSrv(t, nil, nil, evaluator, features, fakes2.NewRuleStore(t))
+
+		response := srv.BacktestRuleGroup(rc(map[string][]string{
+			ac.ActionAlertingRoutesRead: {},
+		}), definitions.BacktestGroupConfig{SimulateNotifications: true})
+
+		require.Equal(t, http.StatusForbidden, response.Status())
+	})
+
+	t.Run("should check access to the notification settings only when simulating notifications", func(t *testing.T) {
+		srv := createTestingApiSrv(t, nil, nil, evaluator, features, fakes2.NewRuleStore(t))
+
+		response := srv.BacktestRuleGroup(rc(map[string][]string{
+			ac.ActionAlertingRoutesRead: {},
+		}), definitions.BacktestGroupConfig{})
+		// the empty group is rejected by the validation
+		require.Equal(t, http.StatusBadRequest, response.Status())
+
+		for _, permissions := range []map[string][]string{
+			{ac.ActionAlertingNotificationsRead: {}},
+			{ac.ActionAlertingRoutesRead: {}, ac.ActionAlertingReceiversRead: {models.ScopeReceiversAll}},
+		} {
+			response := srv.BacktestRuleGroup(rc(permissions), definitions.BacktestGroupConfig{SimulateNotifications: true})
+			require.Equal(t, http.StatusBadRequest, response.Status())
+		}
+	})
+}
+
 func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mock, evaluator eval.EvaluatorFactory, featureManager featuremgmt.FeatureToggles, ruleStore RuleStore) *TestingApiSrv {
 	if ac == nil {
 		ac = acMock.New()
@@ -404,5 +448,6 @@ func createTestingApiSrv(t *testing.T, ds *fakes.FakeCacheService, ac *acMock.Mo
 		tracer:          tracing.InitializeTracerForTest(),
 		featureManager:  featureManager,
 		folderService:   ruleStore,
+		ac:              ac,
 	}
 }
@@ -84,7 +84,8 @@ func (api *API) authorize(method, path string) web.Handler {
 		// additional authorization is done in the request handler
 		eval = ac.EvalPermission(ac.ActionAlertingRuleRead)
 	// Grafana Rules Testing Paths
-	case http.MethodPost + "/api/v1/rule/backtest": // TODO (yuri) this should be protected by dedicated permission
+	case http.MethodPost + "/api/v1/rule/backtest", // TODO (yuri) this should be protected by dedicated permission
+		http.MethodPost + "/api/v1/rule/backtest/group":
 		// additional authorization is done in the request handler
 		eval = ac.EvalAll(
 			ac.EvalPermission(ac.ActionAlertingRuleRead),
@@ -130,7 +131,8 @@ func (api *API) authorize(method, path string) web.Handler {
 		)
 
 	case http.MethodGet + "/api/convert/prometheus/config/v1/rules",
-		http.MethodGet + "/api/convert/api/prom/rules":
+		http.MethodGet + "/api/convert/api/prom/rules",
+		http.MethodGet + "/api/convert/prometheus/export/rules":
 		eval = ac.EvalAll(
 			ac.EvalPermission(ac.ActionAlertingRuleRead),
 			ac.EvalPermission(folder.ActionFoldersRead),
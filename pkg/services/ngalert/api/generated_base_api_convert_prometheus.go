@@ -29,6 +29,7 @@ type ConvertPrometheusApi interface {
 	RouteConvertPrometheusDeleteAlertmanagerConfig(*contextmodel.ReqContext) response.Response
 	RouteConvertPrometheusDeleteNamespace(*contextmodel.ReqContext) response.Response
 	RouteConvertPrometheusDeleteRuleGroup(*contextmodel.ReqContext) response.Response
+	RouteConvertPrometheusExportRules(*contextmodel.ReqContext) response.Response
 	RouteConvertPrometheusGetAlertmanagerConfig(*contextmodel.ReqContext) response.Response
 	RouteConvertPrometheusGetNamespace(*contextmodel.ReqContext) response.Response
 	RouteConvertPrometheusGetRuleGroup(*contextmodel.ReqContext) response.Response
@@ -86,6 +87,9 @@ func (f *ConvertPrometheusApiHandler) RouteConvertPrometheusDeleteRuleGroup(ctx
 	groupParam := web.Params(ctx.Req)[":Group"]
 	return f.handleRouteConvertPrometheusDeleteRuleGroup(ctx, namespaceTitleParam, groupParam)
 }
+func (f *ConvertPrometheusApiHandler) RouteConvertPrometheusExportRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.handleRouteConvertPrometheusExportRules(ctx)
+}
 func (f *ConvertPrometheusApiHandler) RouteConvertPrometheusGetAlertmanagerConfig(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteConvertPrometheusGetAlertmanagerConfig(ctx)
 }
@@ -242,6 +246,18 @@ func (api *API) RegisterConvertPrometheusApiEndpoints(srv ConvertPrometheusApi,
 				m,
 			),
 		)
+		group.Get(
+			toMacaronPath("/api/convert/prometheus/export/rules"),
+			requestmeta.SetOwner(requestmeta.TeamAlerting),
+			requestmeta.SetSLOGroup(requestmeta.SLOGroupHighSlow),
+			api.authorize(http.MethodGet, "/api/convert/prometheus/export/rules"),
+			metrics.Instrument(
+				http.MethodGet,
+				"/api/convert/prometheus/export/rules",
+				api.Hooks.Wrap(srv.RouteConvertPrometheusExportRules),
+				m,
+			),
+		)
 		group.Get(
 			toMacaronPath("/api/convert/api/v1/alerts"),
 			requestmeta.SetOwner(requestmeta.TeamAlerting),
@@ -96,6 +96,10 @@ func (f *ConvertPrometheusApiHandler) handleRouteConvertPrometheusPostRuleGroups
 	return f.svc.RouteConvertPrometheusPostRuleGroups(ctx, promNamespaces)
 }
 
+func (f *ConvertPrometheusApiHandler) handleRouteConvertPrometheusExportRules(ctx *contextmodel.ReqContext) response.Response {
+	return f.svc.RouteConvertPrometheusExportRules(ctx)
+}
+
 // cortextool
 func (f *ConvertPrometheusApiHandler) handleRouteConvertPrometheusCortexGetRules(ctx *contextmodel.ReqContext) response.Response {
 	return f.handleRouteConvertPrometheusGetRules(ctx)
@@ -200,6 +200,20 @@ import (
 //       202: ConvertPrometheusResponse
 //       403: ForbiddenError
 
+// swagger:route GET /convert/prometheus/export/rules convert_prometheus stable RouteConvertPrometheusExportRules
+//
+// Exports Grafana-managed alert and recording rules to the Prometheus rule file format, grouped by namespace.
+// Only the rules that query a Prometheus-compatible data source with a single instant query, optionally
+// reduced and compared with a threshold, can be exported. The other rules are listed with the reason.
+//
+//     Produces:
+//     - application/yaml
+//     - application/json
+//
+//     Responses:
+//       200: PrometheusRulesExport
+//       403: ForbiddenError
+
 // Route for `mimirtool alertmanager load`
 // swagger:route POST /convert/api/v1/alerts convert_prometheus RouteConvertPrometheusPostAlertmanagerConfig
 //
@@ -279,6 +293,35 @@ type RouteConvertPrometheusPostRuleGroupParams struct {
 	Body PrometheusRuleGroup
 }
 
+// swagger:parameters RouteConvertPrometheusExportRules
+type RouteConvertPrometheusExportRulesParams struct {
+	// UIDs of the folders to export. If not set, all folders in the working folder are exported.
+	// in: query
+	// required: false
+	FolderUID []string `json:"folderUid"`
+	// Name of the rule group to export. If not set, all rule groups are exported.
+	// in: query
+	// required: false
+	Group string `json:"group"`
+	// in: header
+	WorkingFolderUID string `json:"x-grafana-alerting-folder-uid"`
+}
+
+// swagger:model
+type PrometheusRulesExport struct {
+	Namespaces map[string][]PrometheusRuleGroup `yaml:"namespaces" json:"namespaces"`
+	Skipped    []PrometheusExportSkippedRule    `yaml:"skipped,omitempty" json:"skipped,omitempty"`
+}
+
+// PrometheusExportSkippedRule is a rule that cannot be represented in the Prometheus rule file format.
+type PrometheusExportSkippedRule struct {
+	UID       string `yaml:"uid" json:"uid"`
+	Title     string `yaml:"title" json:"title"`
+	Namespace string `yaml:"namespace" json:"namespace"`
+	Group     string `yaml:"group" json:"group"`
+	Reason    string `yaml:"reason" json:"reason"`
+}
+
 // swagger:model
 type PrometheusNamespace struct {
 	// in: body
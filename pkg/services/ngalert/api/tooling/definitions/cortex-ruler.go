@@ -93,6 +93,7 @@ import (
 //     - application/yaml
 //
 //     Responses:
+//       200: RuleGroupDryRunResponse
 //       202: UpdateRuleGroupResponse
 //       403: ForbiddenError
 //
@@ -232,6 +233,16 @@ type NamespaceConfig struct {
 	Body PostableRuleGroupConfig
 }
 
+// swagger:parameters RoutePostNameGrafanaRulesConfig
+type PostNameGrafanaRulesConfigParams struct {
+	// If true, the changes are validated and returned but not saved
+	// in: query
+	DryRun bool `json:"dryRun"`
+	// If true, the new and updated alert rules are evaluated once, and the alert instances whose state would change are returned. Only used in dry run.
+	// in: query
+	Evaluate bool `json:"evaluate"`
+}
+
 // swagger:parameters RouteGetNamespaceRulesConfig RouteDeleteNamespaceRulesConfig RouteGetNamespaceGrafanaRulesConfig RouteDeleteNamespaceGrafanaRulesConfig
 type PathNamespaceConfig struct {
 	// The UID of the rule folder
@@ -722,6 +733,44 @@ type UpdateRuleGroupResponse struct {
 	Deleted []string `json:"deleted,omitempty"`
 }
 
+// swagger:model
+type RuleGroupDryRunResponse struct {
+	Message string       `json:"message"`
+	Created []RuleChange `json:"created,omitempty"`
+	Updated []RuleChange `json:"updated,omitempty"`
+	Deleted []RuleChange `json:"deleted,omitempty"`
+	// StateChanges contains the alert instances whose state would change. It is only set if the rules were evaluated.
+	StateChanges []RuleInstanceStateChange `json:"state_changes,omitempty"`
+}
+
+// RuleChange describes a rule that would be created, updated or deleted.
+type RuleChange struct {
+	// UID of a created rule is generated for the dry run only, unless it is set in the request.
+	UID   string `json:"uid,omitempty"`
+	Title string `json:"title"`
+	// Diff contains the changed fields of updated rules.
+	Diff []RuleFieldChange `json:"diff,omitempty"`
+}
+
+// RuleFieldChange describes a change of a single field of a rule.
+type RuleFieldChange struct {
+	Path string `json:"path"`
+	// Old is not set if the field is added.
+	Old any `json:"old,omitempty"`
+	// New is not set if the field is removed.
+	New any `json:"new,omitempty"`
+}
+
+// RuleInstanceStateChange describes an alert instance whose evaluation state would change.
+type RuleInstanceStateChange struct {
+	RuleUID   string            `json:"rule_uid,omitempty"`
+	RuleTitle string            `json:"rule_title"`
+	Labels    map[string]string `json:"labels"`
+	// Previous is the state of the latest evaluation of the existing rule. It is empty if the instance is new.
+	Previous string `json:"previous,omitempty"`
+	Current  string `json:"current"`
+}
+
 // swagger:parameters RouteUpdateNamespaceRules
 type UpdateNamespaceRulesParams struct {
 	// The UID of the rule folder
@@ -264,6 +264,13 @@ type AlertRuleGroupPayload struct {
 	Body AlertRuleGroup
 }
 
+// swagger:parameters RoutePutAlertRuleGroup
+type PutAlertRuleGroupParams struct {
+	// If true, the changes are validated and returned as a RuleGroupDryRunResponse, but not saved
+	// in: query
+	DryRun bool `json:"dryRun"`
+}
+
 // swagger:model
 type AlertRuleGroupMetadata struct {
 	Interval int64 `json:"interval"`
@@ -68,6 +68,19 @@ import (
 //     Responses:
 //       200: BacktestResult
 
+// swagger:route Post /v1/rule/backtest/group testing BacktestGroupConfig
+//
+// Test all rules of a rule group, including recording rules
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       200: BacktestGroupResult
+
 // swagger:parameters RouteTestReceiverConfig
 type TestReceiverRequest struct {
 	// in:body
@@ -240,3 +253,63 @@ type BacktestConfig struct {
 
 // swagger:model
 type BacktestResult data.Frame
+
+// swagger:parameters BacktestGroupConfig
+type BacktestGroupConfigRequest struct {
+	// in:body
+	Body BacktestGroupConfig
+}
+
+// swagger:model
+type BacktestGroupConfig struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	NamespaceUID string                  `json:"namespace_uid"`
+	Group        PostableRuleGroupConfig `json:"group"`
+
+	// SimulateNotifications replays the alerts through the notification policies, inhibition rules and time intervals
+	// of the organization, and returns the notifications that would have been sent. Nothing is sent to the contact points.
+	SimulateNotifications bool `json:"simulate_notifications,omitempty"`
+}
+
+// swagger:model
+type BacktestGroupResult struct {
+	// Rules contains the results of the rules in the order of evaluation.
+	// Recording rules are evaluated before the rules that query the metrics they write.
+	Rules []BacktestRuleResult `json:"rules"`
+	// Notifications are the alerts that would have been sent to the Alertmanager.
+	Notifications []BacktestNotification `json:"notifications"`
+	// SimulatedNotifications are the notifications that would have been sent to contact points.
+	// It is only set if the simulation of notifications is requested.
+	SimulatedNotifications []BacktestSimulatedNotification `json:"simulated_notifications,omitempty"`
+	Warnings               []string                        `json:"warnings,omitempty"`
+}
+
+type BacktestRuleResult struct {
+	UID   string `json:"uid"`
+	Title string `json:"title"`
+	// Type is either alerting or recording.
+	Type string `json:"type"`
+	// States contains the state transitions of an alerting rule.
+	States *data.Frame `json:"states,omitempty"`
+	// Series contains the series written by a recording rule.
+	Series data.Frames `json:"series,omitempty"`
+}
+
+type BacktestNotification struct {
+	RuleUID     string             `json:"rule_uid"`
+	EvaluatedAt time.Time          `json:"evaluated_at"`
+	Alert       amv2.PostableAlert `json:"alert"`
+}
+
+type BacktestSimulatedNotification struct {
+	Receiver    string            `json:"receiver"`
+	GroupKey    string            `json:"group_key"`
+	GroupLabels map[string]string `json:"group_labels"`
+	NotifiedAt  time.Time         `json:"notified_at"`
+	// Firing contains the labels of the firing alerts of the notification.
+	Firing []map[string]string `json:"firing,omitempty"`
+	// Resolved contains the labels of the resolved alerts of the notification.
+	Resolved []map[string]string `json:"resolved,omitempty"`
+}
@@ -435,3 +435,22 @@ func ValidateBacktestConfig(orgId int64, config apimodels.BacktestConfig, limits
 		},
 	}, config.RuleGroup, interval, orgId, config.NamespaceUID, limits)
 }
+
+func ValidateBacktestGroupConfig(orgId int64, config apimodels.BacktestGroupConfig, limits RuleLimits) ([]*ngmodels.AlertRule, error) {
+	if !config.From.Before(config.To) {
+		return nil, fmt.Errorf("invalid testing range: from %s must be before to %s", config.From, config.To)
+	}
+	if len(config.Group.Rules) == 0 {
+		return nil, errors.New("rule group must contain at least one rule")
+	}
+
+	rules, err := ValidateRuleGroup(&config.Group, orgId, config.NamespaceUID, limits)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*ngmodels.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, &rule.AlertRule)
+	}
+	return result, nil
+}
@@ -13,8 +13,10 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/eval"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -46,19 +48,25 @@ type stateManager interface {
 }
 
 type Engine struct {
-	evalFactory          eval.EvaluatorFactory
-	createStateManager   func() stateManager
-	disableGrafanaFolder bool
-	featureToggles       featuremgmt.FeatureToggles
-	minInterval          time.Duration
-	baseInterval         time.Duration
-	jitterStrategy       schedule.JitterStrategy
-	maxEvaluations       int
+	appURL                 *url.URL
+	evalFactory            eval.EvaluatorFactory
+	createGroupEvalFactory func(middleware expr.QueryDataMiddleware) eval.EvaluatorFactory
+	createStateManager     func() stateManager
+	disableGrafanaFolder   bool
+	featureToggles         featuremgmt.FeatureToggles
+	minInterval            time.Duration
+	baseInterval           time.Duration
+	jitterStrategy         schedule.JitterStrategy
+	maxEvaluations         int
 }
 
-func NewEngine(appUrl *url.URL, evalFactory eval.EvaluatorFactory, tracer tracing.Tracer, cfg setting.UnifiedAlertingSettings, toggles featuremgmt.FeatureToggles) *Engine {
+func NewEngine(appUrl *url.URL, evalFactory eval.EvaluatorFactory, tracer tracing.Tracer, cfg setting.UnifiedAlertingSettings, toggles featuremgmt.FeatureToggles, dsCache datasources.CacheService, expressionService *expr.Service) *Engine {
 	return &Engine{
+		appURL:      appUrl,
 		evalFactory: evalFactory,
+		createGroupEvalFactory: func(middleware expr.QueryDataMiddleware) eval.EvaluatorFactory {
+			return eval.NewEvaluatorFactory(cfg, dsCache, expressionService.WithQueryDataMiddleware(middleware))
+		},
 		createStateManager: func() stateManager {
 			cfg := state.ManagerCfg{
 				Metrics:       nil,
@@ -148,23 +156,7 @@ func (e *Engine) Test(ctx context.Context, user identity.Requester, rule *models
 
 	var builder *historian.QueryResultBuilder
 
-	ruleMeta := history_model.RuleMeta{
-		ID:           rule.ID,
-		OrgID:        rule.OrgID,
-		UID:          rule.UID,
-		Title:        rule.Title,
-		Group:        rule.RuleGroup,
-		NamespaceUID: rule.NamespaceUID,
-		// DashboardUID: "",
-		// PanelID:      0,
-		Condition: rule.Condition,
-	}
-	labels := map[string]string{
-		historian.OrgIDLabel:     fmt.Sprint(ruleMeta.OrgID),
-		historian.GroupLabel:     fmt.Sprint(ruleMeta.Group),
-		historian.FolderUIDLabel: fmt.Sprint(rule.NamespaceUID),
-	}
-	labelsBytes, err := json.Marshal(labels)
+	ruleMeta, labelsBytes, err := historyLabels(rule)
 	if err != nil {
 		return nil, err
 	}
@@ -210,6 +202,31 @@ func (e *Engine) Test(ctx context.Context, user identity.Requester, rule *models
 	return builder.ToFrame(), nil
 }
 
+// historyLabels returns the metadata of the rule and the labels of the state history entries of the rule.
+func historyLabels(rule *models.AlertRule) (history_model.RuleMeta, []byte, error) {
+	ruleMeta := history_model.RuleMeta{
+		ID:           rule.ID,
+		OrgID:        rule.OrgID,
+		UID:          rule.UID,
+		Title:        rule.Title,
+		Group:        rule.RuleGroup,
+		NamespaceUID: rule.NamespaceUID,
+		// DashboardUID: "",
+		// PanelID:      0,
+		Condition: rule.Condition,
+	}
+	labels := map[string]string{
+		historian.OrgIDLabel:     fmt.Sprint(ruleMeta.OrgID),
+		historian.GroupLabel:     fmt.Sprint(ruleMeta.Group),
+		historian.FolderUIDLabel: fmt.Sprint(rule.NamespaceUID),
+	}
+	labelsBytes, err := json.Marshal(labels)
+	if err != nil {
+		return history_model.RuleMeta{}, nil, err
+	}
+	return ruleMeta, labelsBytes, nil
+}
+
 func newBacktestingEvaluator(ctx context.Context, evalFactory eval.EvaluatorFactory, user identity.Requester, condition models.Condition, reader eval.AlertingResultsReader) (backtestingEvaluator, error) {
 	for _, q := range condition.Data {
 		if q.DatasourceUID == "__data__" || q.QueryType == "__data__" {
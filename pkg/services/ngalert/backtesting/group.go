@@ -0,0 +1,373 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
+	"github.com/grafana/grafana/pkg/util"
+)
+
+// GroupResult is the result of testing a rule group.
+type GroupResult struct {
+	// Rules contains the results of the rules in the order of evaluation.
+	Rules []RuleResult
+	// Notifications are the alerts that would have been sent to the Alertmanager, in the order they were sent.
+	Notifications []Notification
+	Warnings      []string
+}
+
+// RuleResult is the result of testing a rule of a group.
+type RuleResult struct {
+	UID   string
+	Title string
+	Type  models.RuleType
+	// States contains the state transitions of an alerting rule, in the same format as the result of Engine.Test.
+	States *data.Frame
+	// Series contains the series written by a recording rule.
+	Series data.Frames
+}
+
+// Notification is an alert that would have been sent to the Alertmanager.
+type Notification struct {
+	RuleUID     string
+	EvaluatedAt time.Time
+	Alert       amv2.PostableAlert
+}
+
+type groupRule struct {
+	rule      *models.AlertRule
+	evaluator eval.ConditionEvaluator
+	// the following fields are only set for alerting rules.
+	ruleMeta    history_model.RuleMeta
+	labels      []byte
+	extraLabels data.Labels
+	builder     *historian.QueryResultBuilder
+	// the following fields are only set for recording rules.
+	recorded *seriesStore
+	failures int
+	firstErr error
+}
+
+// TestGroup evaluates all rules of a group over the range [from, to) at the interval of the group.
+// Recording rules are evaluated before the rules that query the metrics they write. The recorded series are kept in memory,
+// and the queries that select a recorded metric from the target data source of the recording rule are answered from them.
+func (e *Engine) TestGroup(ctx context.Context, user identity.Requester, rules []*models.AlertRule, from, to time.Time, folderTitle string) (res *GroupResult, err error) {
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%w: rule group is empty", ErrInvalidInputData)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("%w: invalid interval [%d,%d]", ErrInvalidInputData, from.Unix(), to.Unix())
+	}
+
+	groupKey := rules[0].GetGroupKey()
+	logger := logger.FromContext(ctx).New("backtesting", util.GenerateShortUID(), "folder", groupKey.NamespaceUID, "group", groupKey.RuleGroup)
+
+	var warns []string
+	interval := rules[0].GetInterval()
+	if interval < e.minInterval {
+		logger.Warn("Interval adjusted to minimal interval", "originalInterval", interval, "adjustedInterval", e.minInterval)
+		interval = e.minInterval
+		warns = append(warns, fmt.Sprintf("Interval adjusted to minimal interval %ds", int64(interval.Seconds())))
+	}
+
+	copied := make([]*models.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.GetGroupKey() != groupKey {
+			return nil, fmt.Errorf("%w: all rules must belong to the same group", ErrInvalidInputData)
+		}
+		if rule.GetInterval() != rules[0].GetInterval() {
+			return nil, fmt.Errorf("%w: all rules of the group must have the same interval", ErrInvalidInputData)
+		}
+		for _, q := range rule.Data {
+			if q.DatasourceUID == "__data__" || q.QueryType == "__data__" {
+				return nil, fmt.Errorf("%w: data queries are not supported when testing a rule group", ErrInvalidInputData)
+			}
+		}
+		rule = rule.Copy()
+		rule.IntervalSeconds = int64(interval.Seconds())
+		if rule.UID == "" {
+			rule.UID = util.GenerateShortUID()
+		}
+		copied = append(copied, rule)
+	}
+
+	ordered, err := orderGroupRules(copied)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidInputData, err)
+	}
+	warns = append(warns, recordedMetricWarnings(ordered)...)
+
+	effectiveStrategy := e.jitterStrategy
+	if effectiveStrategy == schedule.JitterByRule {
+		logger.Warn("Jitter strategy is set to by-rule, but rules of a group are evaluated at the same time. Use jitter by group")
+		warns = append(warns, "Jitter strategy is set to by-rule, but rules of a group are evaluated at the same time when testing a rule group. Jitter by group is used instead. The results of testing will be different than real evaluations")
+		effectiveStrategy = schedule.JitterByGroup
+	}
+	if effectiveStrategy == schedule.JitterByGroup && (groupKey.RuleGroup == "" || groupKey.NamespaceUID == "") {
+		logger.Warn(fmt.Sprintf("Jitter strategy is set to %s, but rule group or namespace is not set. Ignore jitter", effectiveStrategy))
+		warns = append(warns, fmt.Sprintf("Jitter strategy is set to %s, but rule group or namespace is not set. Ignore jitter. The results of testing will be different than real evaluations", effectiveStrategy))
+		effectiveStrategy = schedule.JitterNever
+	}
+	jitterOffset := schedule.JitterOffsetInDuration(ordered[0], e.baseInterval, effectiveStrategy)
+	firstEval, err := getFirstEvaluationTime(from, ordered[0], e.baseInterval, jitterOffset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidInputData, err)
+	}
+
+	evaluations := calculateNumberOfEvaluations(firstEval, to, interval)
+	if e.maxEvaluations > 0 && evaluations > e.maxEvaluations {
+		logger.Warn("Evaluations adjusted to maximal number", "originalEvaluations", evaluations, "adjustedEvaluations", e.maxEvaluations)
+		warns = append(warns, fmt.Sprintf("Number of evaluations are adjusted to the limit of %d evaluations. Requested: %d", e.maxEvaluations, evaluations))
+		evaluations = e.maxEvaluations
+	}
+
+	start := time.Now()
+	defer func() {
+		if err == nil {
+			logger.Info("Rule group testing finished successfully", "duration", time.Since(start))
+		} else {
+			logger.Error("Rule group testing finished with error", "duration", time.Since(start), "error", err)
+		}
+	}()
+
+	store := newSeriesStore()
+	for _, rule := range ordered {
+		if rule.Type() == models.RuleTypeRecording {
+			store.register(rule.Record.TargetDatasourceUID, rule.Record.Metric)
+		}
+	}
+	evalFactory := e.createGroupEvalFactory(store.middleware)
+	stateMgr := e.createStateManager()
+
+	// Ensure fallback if empty string is passed
+	if folderTitle == "" {
+		folderTitle = "Backtesting"
+	}
+
+	group := make([]*groupRule, 0, len(ordered))
+	for _, rule := range ordered {
+		ruleCtx := models.WithRuleKey(ctx, rule.GetKey())
+		r := &groupRule{rule: rule}
+		if rule.Type() == models.RuleTypeRecording {
+			r.evaluator, err = evalFactory.Create(eval.NewContext(ruleCtx, user), rule.GetEvalCondition().WithSource("backtesting"))
+			r.recorded = newSeriesStore()
+		} else {
+			r.evaluator, err = evalFactory.Create(eval.NewContextWithPreviousResults(ruleCtx, user, &schedule.AlertingResultsFromRuleState{
+				Manager: stateMgr,
+				Rule:    rule,
+			}), rule.GetEvalCondition().WithSource("backtesting"))
+			if err == nil {
+				r.ruleMeta, r.labels, err = historyLabels(rule)
+			}
+			r.extraLabels = state.GetRuleExtraLabels(logger, rule, folderTitle, !e.disableGrafanaFolder, e.featureToggles)
+			r.builder = historian.NewQueryResultBuilder(evaluations)
+		}
+		if err != nil {
+			return nil, errors.Join(ErrInvalidInputData, fmt.Errorf("rule %q: %w", rule.Title, err))
+		}
+		group = append(group, r)
+	}
+
+	logger.Info("Start testing rule group", "from", from, "to", to, "interval", interval, "rules", len(group), "firstTick", firstEval, "evaluations", evaluations, "jitterOffset", jitterOffset, "jitterStrategy", effectiveStrategy)
+
+	res = &GroupResult{}
+	for idx, now := 0, firstEval; idx < evaluations; idx, now = idx+1, now.Add(interval) {
+		for _, r := range group {
+			ruleCtx := models.WithRuleKey(ctx, r.rule.GetKey())
+			if r.rule.Type() == models.RuleTypeRecording {
+				if err := r.record(ruleCtx, now, store); err != nil {
+					logger.Debug("Recording rule evaluation failed", "rule", r.rule.UID, "time", now, "error", err)
+					r.failures++
+					if r.firstErr == nil {
+						r.firstErr = err
+					}
+				}
+				continue
+			}
+
+			results, err := r.evaluator.Evaluate(ruleCtx, now)
+			if err != nil {
+				return nil, err
+			}
+			sender := func(_ context.Context, states state.StateTransitions) {
+				for _, s := range states {
+					res.Notifications = append(res.Notifications, Notification{
+						RuleUID:     r.rule.UID,
+						EvaluatedAt: now,
+						Alert:       *state.StateToPostableAlert(s, e.appURL),
+					})
+				}
+			}
+			states, err := stateMgr.ProcessEvalResults(ruleCtx, now, r.rule, results, r.extraLabels, sender)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range states {
+				if !historian.ShouldRecord(s) {
+					continue
+				}
+				if err := r.builder.AddRow(now, historian.StateTransitionToLokiEntry(r.ruleMeta, s), r.labels); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	res.Rules = make([]RuleResult, 0, len(group))
+	for _, r := range group {
+		result := RuleResult{
+			UID:   r.rule.UID,
+			Title: r.rule.Title,
+			Type:  r.rule.Type(),
+		}
+		if r.rule.Type() == models.RuleTypeRecording {
+			result.Series = r.recorded.frames(r.rule.Record.TargetDatasourceUID, r.rule.Record.Metric)
+			if r.failures > 0 {
+				warns = append(warns, fmt.Sprintf("Recording rule %q failed %d of %d evaluations. First error: %s", r.rule.Title, r.failures, evaluations, r.firstErr))
+			}
+		} else {
+			result.States = r.builder.ToFrame()
+		}
+		res.Rules = append(res.Rules, result)
+	}
+	res.Warnings = warns
+	return res, nil
+}
+
+// record evaluates the recording rule and writes the result to the series store.
+func (r *groupRule) record(ctx context.Context, now time.Time, store *seriesStore) error {
+	resp, err := r.evaluator.EvaluateRaw(ctx, now)
+	if err != nil {
+		return err
+	}
+	if err := eval.FindConditionError(resp, r.rule.Record.From); err != nil {
+		return err
+	}
+	target, ok := resp.Responses[r.rule.Record.From]
+	if !ok || eval.IsNoData(target) {
+		return nil
+	}
+	points, err := writer.PointsFromFrames(r.rule.Record.Metric, now, target.Frames, models.WithoutPrivateLabels(r.rule.Labels))
+	if err != nil {
+		return err
+	}
+	store.add(r.rule.Record.TargetDatasourceUID, points)
+	r.recorded.add(r.rule.Record.TargetDatasourceUID, points)
+	return nil
+}
+
+// orderGroupRules returns the rules in the order of evaluation. The rules are evaluated in the order of the group,
+// except that a rule that queries a metric written by a recording rule of the group is evaluated after the recording rule.
+func orderGroupRules(rules []*models.AlertRule) ([]*models.AlertRule, error) {
+	sorted := make(models.RulesGroup, len(rules))
+	copy(sorted, rules)
+	sorted.SortByGroupIndex()
+
+	dependencies := make([][]int, len(sorted))
+	for i, rule := range sorted {
+		for j, recording := range sorted {
+			if i != j && dependsOn(rule, recording) {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+
+	result := make([]*models.AlertRule, 0, len(sorted))
+	added := make([]bool, len(sorted))
+	for len(result) < len(sorted) {
+		next := -1
+		for i := range sorted {
+			if added[i] {
+				continue
+			}
+			ready := true
+			for _, j := range dependencies[i] {
+				if !added[j] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var titles []string
+			for i, rule := range sorted {
+				if !added[i] {
+					titles = append(titles, fmt.Sprintf("%q", rule.Title))
+				}
+			}
+			return nil, fmt.Errorf("rules %s depend on each other", strings.Join(titles, ", "))
+		}
+		added[next] = true
+		result = append(result, sorted[next])
+	}
+	return result, nil
+}
+
+// dependsOn returns true if any data source query of the rule references the metric written by the recording rule.
+func dependsOn(rule, recording *models.AlertRule) bool {
+	if recording.Type() != models.RuleTypeRecording {
+		return false
+	}
+	for _, q := range rule.Data {
+		if q.DatasourceUID != recording.Record.TargetDatasourceUID || expr.NodeTypeFromDatasourceUID(q.DatasourceUID) != expr.TypeDatasourceNode {
+			continue
+		}
+		if referencesMetric(queryExpr(q), recording.Record.Metric) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedMetricWarnings returns warnings for the queries that reference recorded metrics but can not be answered from the recorded series.
+func recordedMetricWarnings(rules []*models.AlertRule) []string {
+	var warns []string
+	for _, rule := range rules {
+		for _, recording := range rules {
+			if rule == recording || recording.Type() != models.RuleTypeRecording {
+				continue
+			}
+			for _, q := range rule.Data {
+				if q.DatasourceUID != recording.Record.TargetDatasourceUID || !referencesMetric(queryExpr(q), recording.Record.Metric) {
+					continue
+				}
+				if metric, _, err := parseMetricSelector(queryExpr(q)); err == nil && metric == recording.Record.Metric {
+					continue
+				}
+				warns = append(warns, fmt.Sprintf("Query %s of rule %q uses the metric %s written by the recording rule %q in an expression. Only queries that select the metric are answered from the recorded series, this query is sent to the data source", q.RefID, rule.Title, recording.Record.Metric, recording.Title))
+			}
+		}
+	}
+	return warns
+}
+
+func queryExpr(q models.AlertQuery) string {
+	model := struct {
+		Expr string `json:"expr"`
+	}{}
+	if err := json.Unmarshal(q.Model, &model); err != nil {
+		return ""
+	}
+	return model.Expr
+}
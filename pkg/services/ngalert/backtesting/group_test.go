@@ -0,0 +1,224 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/schedule"
+	"github.com/grafana/grafana/pkg/services/ngalert/state/historian"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestOrderGroupRules(t *testing.T) {
+	t.Run("recording rules are evaluated before the rules that depend on them", func(t *testing.T) {
+		alert := newGroupTestRule("alert", 1, "prom", `job:up{job="a"} > 0`, nil)
+		unrelated := newGroupTestRule("unrelated", 2, "prom", "up", nil)
+		recording := newGroupTestRule("recording", 3, "source", "sum(up) by (job)", &models.Record{Metric: "job:up", From: "A", TargetDatasourceUID: "prom"})
+
+		ordered, err := orderGroupRules([]*models.AlertRule{unrelated, recording, alert})
+		require.NoError(t, err)
+		require.Equal(t, []string{"unrelated", "recording", "alert"}, ruleUIDs(ordered))
+	})
+
+	t.Run("rules that query the metric in another data source do not depend on the recording rule", func(t *testing.T) {
+		alert := newGroupTestRule("alert", 1, "other", "job:up", nil)
+		recording := newGroupTestRule("recording", 2, "source", "sum(up) by (job)", &models.Record{Metric: "job:up", From: "A", TargetDatasourceUID: "prom"})
+
+		ordered, err := orderGroupRules([]*models.AlertRule{recording, alert})
+		require.NoError(t, err)
+		require.Equal(t, []string{"alert", "recording"}, ruleUIDs(ordered))
+	})
+
+	t.Run("fails if recording rules depend on each other", func(t *testing.T) {
+		first := newGroupTestRule("first", 1, "prom", "second:up", &models.Record{Metric: "first:up", From: "A", TargetDatasourceUID: "prom"})
+		second := newGroupTestRule("second", 2, "prom", "first:up", &models.Record{Metric: "second:up", From: "A", TargetDatasourceUID: "prom"})
+
+		_, err := orderGroupRules([]*models.AlertRule{first, second})
+		require.ErrorContains(t, err, "depend on each other")
+	})
+}
+
+func TestEngineTestGroup(t *testing.T) {
+	start := time.Unix(600, 0)
+	interval := 10 * time.Second
+
+	// the source data source returns 1 from start+30s, and 0 before
+	source := backend.QueryDataHandlerFunc(func(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		resp := backend.NewQueryDataResponse()
+		for _, q := range req.Queries {
+			value := 0.0
+			if !q.TimeRange.To.Before(start.Add(30 * time.Second)) {
+				value = 1
+			}
+			frame := data.NewFrame("",
+				data.NewField("Value", data.Labels{"job": "a"}, []float64{value}),
+			)
+			frame.SetMeta(&data.FrameMeta{
+				Type:        data.FrameTypeNumericMulti,
+				TypeVersion: data.FrameTypeVersion{0, 1},
+			})
+			resp.Responses[q.RefID] = backend.DataResponse{Frames: data.Frames{frame}}
+		}
+		return resp, nil
+	})
+
+	engine := NewEngine(&url.URL{}, nil, tracing.InitializeTracerForTest(), setting.UnifiedAlertingSettings{
+		MinInterval:  interval,
+		BaseInterval: interval,
+	}, featuremgmt.WithFeatures(), nil, nil)
+	engine.jitterStrategy = schedule.JitterNever
+	engine.createGroupEvalFactory = func(middleware expr.QueryDataMiddleware) eval.EvaluatorFactory {
+		return &fakeGroupEvaluatorFactory{handler: middleware(source)}
+	}
+
+	alert := newGroupTestRule("alert", 1, "prom", `job:up{job="a"}`, nil)
+	recording := newGroupTestRule("recording", 2, "source", "sum(up) by (job)", &models.Record{Metric: "job:up", From: "A", TargetDatasourceUID: "prom"})
+
+	res, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{alert, recording}, start, start.Add(6*interval), "")
+	require.NoError(t, err)
+	require.Len(t, res.Rules, 2)
+
+	require.Equal(t, "recording", res.Rules[0].UID)
+	require.Equal(t, models.RuleTypeRecording, res.Rules[0].Type)
+	require.Len(t, res.Rules[0].Series, 1)
+	require.Equal(t, data.Labels{"job": "a"}, res.Rules[0].Series[0].Fields[1].Labels)
+	require.Equal(t, 6, res.Rules[0].Series[0].Rows())
+
+	require.Equal(t, "alert", res.Rules[1].UID)
+	require.Equal(t, models.RuleTypeAlerting, res.Rules[1].Type)
+	states := res.Rules[1].States
+	var firing []time.Time
+	for i := range states.Rows() {
+		var entry historian.LokiEntry
+		require.NoError(t, json.Unmarshal(states.Fields[1].At(i).(json.RawMessage), &entry))
+		if entry.Current == eval.Alerting.String() {
+			firing = append(firing, states.Fields[0].At(i).(time.Time))
+		}
+	}
+	require.Len(t, firing, 1)
+	require.True(t, start.Add(30*time.Second).Equal(firing[0]))
+
+	require.Len(t, res.Notifications, 1)
+	require.Equal(t, "alert", res.Notifications[0].RuleUID)
+	require.Equal(t, start.Add(30*time.Second), res.Notifications[0].EvaluatedAt)
+	require.Equal(t, "a", res.Notifications[0].Alert.Labels["job"])
+
+	t.Run("fails if rules are in different groups", func(t *testing.T) {
+		other := newGroupTestRule("other", 3, "prom", "up", nil)
+		other.RuleGroup = "other"
+		_, err := engine.TestGroup(context.Background(), nil, []*models.AlertRule{alert, other}, start, start.Add(6*interval), "")
+		require.ErrorIs(t, err, ErrInvalidInputData)
+	})
+}
+
+func newGroupTestRule(uid string, idx int, datasourceUID, query string, record *models.Record) *models.AlertRule {
+	model, _ := json.Marshal(map[string]any{"expr": query, "instant": true})
+	rule := &models.AlertRule{
+		UID:             uid,
+		Title:           uid,
+		OrgID:           1,
+		NamespaceUID:    "folder",
+		RuleGroup:       "group",
+		RuleGroupIndex:  idx,
+		IntervalSeconds: 10,
+		Condition:       "A",
+		Data: []models.AlertQuery{
+			{
+				RefID:         "A",
+				DatasourceUID: datasourceUID,
+				Model:         model,
+			},
+		},
+		Record:       record,
+		NoDataState:  models.OK,
+		ExecErrState: models.ErrorErrState,
+	}
+	if record != nil {
+		rule.Condition = ""
+	}
+	return rule
+}
+
+func ruleUIDs(rules []*models.AlertRule) []string {
+	uids := make([]string, 0, len(rules))
+	for _, r := range rules {
+		uids = append(uids, r.UID)
+	}
+	return uids
+}
+
+type fakeGroupEvaluatorFactory struct {
+	handler backend.QueryDataHandler
+}
+
+func (f *fakeGroupEvaluatorFactory) Validate(_ eval.EvaluationContext, _ models.Condition) error {
+	return nil
+}
+
+func (f *fakeGroupEvaluatorFactory) Create(_ eval.EvaluationContext, condition models.Condition) (eval.ConditionEvaluator, error) {
+	return &fakeGroupEvaluator{handler: f.handler, condition: condition}, nil
+}
+
+// fakeGroupEvaluator sends the queries of the condition to the handler, and evaluates the last value of each series
+// of the condition as alerting if it is not 0.
+type fakeGroupEvaluator struct {
+	handler   backend.QueryDataHandler
+	condition models.Condition
+}
+
+func (f *fakeGroupEvaluator) EvaluateRaw(ctx context.Context, now time.Time) (*backend.QueryDataResponse, error) {
+	resp := backend.NewQueryDataResponse()
+	for _, q := range f.condition.Data {
+		r, err := f.handler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: q.DatasourceUID},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     q.RefID,
+				JSON:      q.Model,
+				TimeRange: backend.TimeRange{From: now.Add(-time.Minute), To: now},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp.Responses[q.RefID] = r.Responses[q.RefID]
+	}
+	return resp, nil
+}
+
+func (f *fakeGroupEvaluator) Evaluate(ctx context.Context, now time.Time) (eval.Results, error) {
+	resp, err := f.EvaluateRaw(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	var results eval.Results
+	for _, frame := range resp.Responses[f.condition.Condition].Frames {
+		value := frame.Fields[len(frame.Fields)-1]
+		state := eval.Normal
+		if value.Len() > 0 && value.At(value.Len()-1).(float64) != 0 {
+			state = eval.Alerting
+		}
+		results = append(results, eval.Result{
+			Instance:    value.Labels,
+			State:       state,
+			EvaluatedAt: now,
+		})
+	}
+	if len(results) == 0 {
+		results = append(results, eval.Result{State: eval.NoData, EvaluatedAt: now})
+	}
+	return results, nil
+}
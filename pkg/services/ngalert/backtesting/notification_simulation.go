@@ -0,0 +1,425 @@
+package backtesting
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	alertingNotify "github.com/grafana/alerting/notify"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// SimulatedNotification is a notification that the Alertmanager would have sent to a receiver.
+type SimulatedNotification struct {
+	Receiver string
+	// GroupKey is the key of the aggregation group of the alerts, in the same format as the group key of real notifications.
+	GroupKey    string
+	GroupLabels model.LabelSet
+	NotifiedAt  time.Time
+	Firing      []model.LabelSet
+	// Resolved is empty if no integration of the receiver sends resolved notifications.
+	Resolved []model.LabelSet
+}
+
+type simulatedAlert struct {
+	labels   model.LabelSet
+	fp       model.Fingerprint
+	startsAt time.Time
+	endsAt   time.Time
+}
+
+func (a *simulatedAlert) resolvedAt(t time.Time) bool {
+	return !a.endsAt.IsZero() && !a.endsAt.After(t)
+}
+
+// simulatedGroup is an aggregation group of the dispatcher of the Alertmanager.
+type simulatedGroup struct {
+	route   *dispatch.Route
+	key     string
+	labels  model.LabelSet
+	alerts  map[model.Fingerprint]*simulatedAlert
+	next    time.Time
+	flushed bool
+}
+
+// notificationLogEntry is the last notification sent to a receiver for an aggregation group.
+type notificationLogEntry struct {
+	firing     map[model.Fingerprint]struct{}
+	resolved   map[model.Fingerprint]struct{}
+	notifiedAt time.Time
+}
+
+type inhibitRule struct {
+	source labels.Matchers
+	target labels.Matchers
+	equal  []model.LabelName
+}
+
+type notificationSimulator struct {
+	route         *dispatch.Route
+	inhibitRules  []inhibitRule
+	timeIntervals map[string][]timeinterval.TimeInterval
+	sendResolved  map[string]bool
+
+	alerts map[model.Fingerprint]*simulatedAlert
+	groups map[string]*simulatedGroup
+	log    map[string]*notificationLogEntry
+
+	result []SimulatedNotification
+}
+
+// SimulateNotifications replays the notifications of a backtest through the routing tree, inhibition rules and time intervals
+// of the notification configuration, and returns the notifications that the Alertmanager would have sent up to the time to.
+// Alerts are grouped, delayed and repeated according to the notification policies. Nothing is sent to the receivers, and silences are not taken into account.
+func SimulateNotifications(cfg alertingNotify.NotificationsConfiguration, notifications []Notification, to time.Time) ([]SimulatedNotification, error) {
+	if cfg.RoutingTree == nil {
+		return nil, errors.New("notification configuration does not have a routing tree")
+	}
+	route := cfg.RoutingTree.AsAMRoute()
+	normalizeGroupBy(route)
+
+	s := &notificationSimulator{
+		route:         dispatch.NewRoute(route, nil),
+		inhibitRules:  make([]inhibitRule, 0, len(cfg.InhibitRules)),
+		timeIntervals: make(map[string][]timeinterval.TimeInterval, len(cfg.TimeIntervals)),
+		sendResolved:  map[string]bool{},
+		alerts:        map[model.Fingerprint]*simulatedAlert{},
+		groups:        map[string]*simulatedGroup{},
+		log:           map[string]*notificationLogEntry{},
+	}
+	for idx, r := range cfg.InhibitRules {
+		rule, err := newInhibitRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inhibition rule at index %d: %w", idx, err)
+		}
+		s.inhibitRules = append(s.inhibitRules, rule)
+	}
+	for _, ti := range cfg.TimeIntervals {
+		s.timeIntervals[ti.Name] = ti.TimeIntervals
+	}
+	for _, r := range cfg.Receivers {
+		for _, i := range r.Integrations {
+			if !i.DisableResolveMessage {
+				s.sendResolved[r.Name] = true
+			}
+		}
+	}
+
+	sorted := slices.Clone(notifications)
+	slices.SortStableFunc(sorted, func(a, b Notification) int {
+		return a.EvaluatedAt.Compare(b.EvaluatedAt)
+	})
+	for _, n := range sorted {
+		if n.EvaluatedAt.After(to) {
+			break
+		}
+		// groups that are due at the same time as the alerts are flushed after the alerts are received
+		s.flushUntil(n.EvaluatedAt, false)
+		s.receive(n.EvaluatedAt, n.Alert)
+	}
+	s.flushUntil(to, true)
+	return s.result, nil
+}
+
+// receive adds the alert to the aggregation groups of all routes that match the alert.
+func (s *notificationSimulator) receive(now time.Time, postable amv2.PostableAlert) {
+	lset := make(model.LabelSet, len(postable.Labels))
+	for k, v := range postable.Labels {
+		lset[model.LabelName(k)] = model.LabelValue(v)
+	}
+	alert := &simulatedAlert{
+		labels:   lset,
+		fp:       lset.Fingerprint(),
+		startsAt: time.Time(postable.StartsAt),
+		endsAt:   time.Time(postable.EndsAt),
+	}
+	if existing, ok := s.alerts[alert.fp]; ok {
+		// merge with the alert received before if they overlap, the same as the alert store of the Alertmanager does.
+		if existing.startsAt.Before(alert.startsAt) && !existing.resolvedAt(alert.startsAt) {
+			alert.startsAt = existing.startsAt
+		}
+		*existing = *alert
+		alert = existing
+	} else {
+		s.alerts[alert.fp] = alert
+	}
+
+	for _, route := range s.route.Match(lset) {
+		groupLabels := getGroupLabels(lset, route)
+		key := fmt.Sprintf("%s:%s", route.Key(), groupLabels)
+		group, ok := s.groups[key]
+		if !ok {
+			group = &simulatedGroup{
+				route:  route,
+				key:    key,
+				labels: groupLabels,
+				alerts: map[model.Fingerprint]*simulatedAlert{},
+				next:   now.Add(route.RouteOpts.GroupWait),
+			}
+			s.groups[key] = group
+		}
+		group.alerts[alert.fp] = alert
+		// alerts that started long enough ago are sent without waiting for the group wait to pass
+		if !group.flushed && alert.startsAt.Add(route.RouteOpts.GroupWait).Before(now) {
+			group.next = now
+		}
+	}
+}
+
+// flushUntil flushes the aggregation groups in the order they are due, until the groups are due after the time t.
+func (s *notificationSimulator) flushUntil(t time.Time, inclusive bool) {
+	for {
+		var next *simulatedGroup
+		for _, g := range s.groups {
+			if next == nil || g.next.Before(next.next) || g.next.Equal(next.next) && g.key < next.key {
+				next = g
+			}
+		}
+		if next == nil || next.next.After(t) || !inclusive && next.next.Equal(t) {
+			return
+		}
+		s.flush(next, next.next)
+	}
+}
+
+// flush sends the alerts of the aggregation group to the receiver of the route, unless nothing changed since the last notification
+// and the repeat interval has not passed yet.
+func (s *notificationSimulator) flush(group *simulatedGroup, now time.Time) {
+	opts := group.route.RouteOpts
+
+	var firing, resolved []*simulatedAlert
+	for _, fp := range slices.Sorted(maps.Keys(group.alerts)) {
+		alert := group.alerts[fp]
+		if s.inhibited(alert, now) {
+			continue
+		}
+		if alert.resolvedAt(now) {
+			resolved = append(resolved, alert)
+		} else {
+			firing = append(firing, alert)
+		}
+	}
+
+	if len(firing)+len(resolved) > 0 && !s.muted(group.route, now) {
+		logKey := group.key + "/" + opts.Receiver
+		entry := s.log[logKey]
+		sendResolved := s.sendResolved[opts.Receiver]
+		if needsUpdate(entry, firing, resolved, sendResolved, opts.RepeatInterval, now) {
+			s.log[logKey] = &notificationLogEntry{
+				firing:     fingerprints(firing),
+				resolved:   fingerprints(resolved),
+				notifiedAt: now,
+			}
+			if !sendResolved {
+				resolved = nil
+			}
+			if len(firing)+len(resolved) > 0 {
+				s.result = append(s.result, SimulatedNotification{
+					Receiver:    opts.Receiver,
+					GroupKey:    group.key,
+					GroupLabels: group.labels,
+					NotifiedAt:  now,
+					Firing:      labelSets(firing),
+					Resolved:    labelSets(resolved),
+				})
+			}
+		}
+	}
+
+	for fp, alert := range group.alerts {
+		if alert.resolvedAt(now) {
+			delete(group.alerts, fp)
+		}
+	}
+	if len(group.alerts) == 0 {
+		delete(s.groups, group.key)
+		return
+	}
+	interval := opts.GroupInterval
+	if interval <= 0 {
+		interval = dispatch.DefaultRouteOpts.GroupInterval
+	}
+	group.flushed = true
+	group.next = now.Add(interval)
+}
+
+// inhibited returns true if a firing alert matches the source matchers of an inhibition rule whose target matchers match the alert.
+func (s *notificationSimulator) inhibited(target *simulatedAlert, now time.Time) bool {
+	for _, r := range s.inhibitRules {
+		if !r.target.Matches(target.labels) {
+			continue
+		}
+		// an alert that matches both sides of the rule can not be inhibited by another alert that matches both sides.
+		twoSided := r.source.Matches(target.labels)
+		for _, source := range s.alerts {
+			if source.fp == target.fp || source.resolvedAt(now) || !r.source.Matches(source.labels) {
+				continue
+			}
+			if twoSided && r.target.Matches(source.labels) {
+				continue
+			}
+			if r.hasEqual(source.labels, target.labels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// muted returns true if the time is in a mute time interval of the route, or outside all active time intervals of the route.
+func (s *notificationSimulator) muted(route *dispatch.Route, now time.Time) bool {
+	for _, name := range route.RouteOpts.MuteTimeIntervals {
+		if s.inTimeInterval(name, now) {
+			return true
+		}
+	}
+	if len(route.RouteOpts.ActiveTimeIntervals) == 0 {
+		return false
+	}
+	for _, name := range route.RouteOpts.ActiveTimeIntervals {
+		if s.inTimeInterval(name, now) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *notificationSimulator) inTimeInterval(name string, now time.Time) bool {
+	for _, ti := range s.timeIntervals[name] {
+		if ti.ContainsTime(now.UTC()) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsUpdate returns true if the alerts must be sent to the receiver, the same as the deduplication stage of the notification pipeline.
+func needsUpdate(entry *notificationLogEntry, firing, resolved []*simulatedAlert, sendResolved bool, repeat time.Duration, now time.Time) bool {
+	if entry == nil {
+		return len(firing) > 0
+	}
+	if !isSubset(firing, entry.firing) {
+		return true
+	}
+	// all alerts are resolved, the notification is sent even if resolved notifications are disabled to clear the log.
+	if len(firing) == 0 {
+		return len(entry.firing) > 0
+	}
+	if sendResolved && !isSubset(resolved, entry.resolved) {
+		return true
+	}
+	return entry.notifiedAt.Before(now.Add(-repeat))
+}
+
+func isSubset(alerts []*simulatedAlert, set map[model.Fingerprint]struct{}) bool {
+	for _, a := range alerts {
+		if _, ok := set[a.fp]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func fingerprints(alerts []*simulatedAlert) map[model.Fingerprint]struct{} {
+	result := make(map[model.Fingerprint]struct{}, len(alerts))
+	for _, a := range alerts {
+		result[a.fp] = struct{}{}
+	}
+	return result
+}
+
+func labelSets(alerts []*simulatedAlert) []model.LabelSet {
+	if len(alerts) == 0 {
+		return nil
+	}
+	result := make([]model.LabelSet, 0, len(alerts))
+	for _, a := range alerts {
+		result = append(result, a.labels)
+	}
+	slices.SortFunc(result, func(a, b model.LabelSet) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return result
+}
+
+// getGroupLabels returns the labels of the alert that the route groups by.
+func getGroupLabels(lset model.LabelSet, route *dispatch.Route) model.LabelSet {
+	groupLabels := model.LabelSet{}
+	for ln, lv := range lset {
+		if _, ok := route.RouteOpts.GroupBy[ln]; ok || route.RouteOpts.GroupByAll {
+			groupLabels[ln] = lv
+		}
+	}
+	return groupLabels
+}
+
+// normalizeGroupBy sets the labels to group by from their string form if the routing tree was not validated.
+func normalizeGroupBy(route *config.Route) {
+	if len(route.GroupBy) == 0 && !route.GroupByAll {
+		for _, l := range route.GroupByStr {
+			if l == models.GroupByAll {
+				route.GroupByAll = true
+			} else {
+				route.GroupBy = append(route.GroupBy, model.LabelName(l))
+			}
+		}
+	}
+	for _, r := range route.Routes {
+		normalizeGroupBy(r)
+	}
+}
+
+func newInhibitRule(cr config.InhibitRule) (inhibitRule, error) {
+	source, err := inhibitMatchers(cr.SourceMatch, cr.SourceMatchRE, cr.SourceMatchers)
+	if err != nil {
+		return inhibitRule{}, err
+	}
+	target, err := inhibitMatchers(cr.TargetMatch, cr.TargetMatchRE, cr.TargetMatchers)
+	if err != nil {
+		return inhibitRule{}, err
+	}
+	rule := inhibitRule{source: source, target: target}
+	for _, ln := range cr.Equal {
+		rule.equal = append(rule.equal, model.LabelName(ln))
+	}
+	return rule, nil
+}
+
+func inhibitMatchers(match map[string]string, matchRE config.MatchRegexps, matchers config.Matchers) (labels.Matchers, error) {
+	result := make(labels.Matchers, 0, len(match)+len(matchRE)+len(matchers))
+	for ln, lv := range match {
+		m, err := labels.NewMatcher(labels.MatchEqual, ln, lv)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	for ln, lv := range matchRE {
+		m, err := labels.NewMatcher(labels.MatchRegexp, ln, lv.String())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+	}
+	return append(result, matchers...), nil
+}
+
+// hasEqual returns true if the source and target alerts have the same values for all labels of the equal list.
+func (r inhibitRule) hasEqual(source, target model.LabelSet) bool {
+	for _, ln := range r.equal {
+		if source[ln] != target[ln] {
+			return false
+		}
+	}
+	return true
+}
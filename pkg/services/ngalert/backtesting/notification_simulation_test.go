@@ -0,0 +1,181 @@
+package backtesting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	alertingModels "github.com/grafana/alerting/models"
+	alertingNotify "github.com/grafana/alerting/notify"
+	amv2 "github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/timeinterval"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/api/tooling/definitions"
+)
+
+func TestSimulateNotifications(t *testing.T) {
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time {
+		return start.Add(d)
+	}
+
+	newConfig := func(routes ...*definitions.Route) alertingNotify.NotificationsConfiguration {
+		return alertingNotify.NotificationsConfiguration{
+			RoutingTree: &definitions.Route{
+				Receiver:       "default",
+				GroupByStr:     []string{"alertname"},
+				GroupWait:      new(model.Duration(30 * time.Second)),
+				GroupInterval:  new(model.Duration(5 * time.Minute)),
+				RepeatInterval: new(model.Duration(time.Hour)),
+				Routes:         routes,
+			},
+			Receivers: []alertingModels.ReceiverConfig{
+				{Name: "default", Integrations: []*alertingModels.IntegrationConfig{{UID: "default"}}},
+				{Name: "team", Integrations: []*alertingModels.IntegrationConfig{{UID: "team"}}},
+			},
+		}
+	}
+
+	t.Run("alerts are grouped and sent after group wait and group interval", func(t *testing.T) {
+		cfg := newConfig(&definitions.Route{
+			Receiver:       "team",
+			ObjectMatchers: definitions.ObjectMatchers{{Type: labels.MatchEqual, Name: "team", Value: "a"}},
+		})
+		first := amv2.LabelSet{"alertname": "HighCPU", "team": "a", "instance": "1"}
+		second := amv2.LabelSet{"alertname": "HighCPU", "team": "a", "instance": "2"}
+
+		var notifications []Notification
+		for i := range 10 {
+			notifications = append(notifications, simulationNotification(at(time.Duration(i)*time.Minute), start, at(time.Duration(i+4)*time.Minute), first))
+		}
+		notifications = append(notifications, simulationNotification(at(10*time.Minute), start, at(10*time.Minute), first))
+		for i := 1; i < 15; i++ {
+			notifications = append(notifications, simulationNotification(at(time.Duration(i)*time.Minute), at(time.Minute), at(time.Duration(i+4)*time.Minute), second))
+		}
+
+		result, err := SimulateNotifications(cfg, notifications, at(15*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, result, 3)
+
+		for _, n := range result {
+			require.Equal(t, "team", n.Receiver)
+			require.Equal(t, `{}/{team="a"}:{alertname="HighCPU"}`, n.GroupKey)
+			require.Equal(t, model.LabelSet{"alertname": "HighCPU"}, n.GroupLabels)
+		}
+		require.Equal(t, at(30*time.Second), result[0].NotifiedAt)
+		require.Equal(t, []model.LabelSet{toLabelSet(first)}, result[0].Firing)
+		require.Empty(t, result[0].Resolved)
+
+		require.Equal(t, at(5*time.Minute+30*time.Second), result[1].NotifiedAt)
+		require.Equal(t, []model.LabelSet{toLabelSet(first), toLabelSet(second)}, result[1].Firing)
+
+		require.Equal(t, at(10*time.Minute+30*time.Second), result[2].NotifiedAt)
+		require.Equal(t, []model.LabelSet{toLabelSet(second)}, result[2].Firing)
+		require.Equal(t, []model.LabelSet{toLabelSet(first)}, result[2].Resolved)
+	})
+
+	t.Run("alerts are not sent again before the repeat interval", func(t *testing.T) {
+		cfg := newConfig()
+		alert := amv2.LabelSet{"alertname": "HighCPU"}
+		var notifications []Notification
+		for i := range 90 {
+			notifications = append(notifications, simulationNotification(at(time.Duration(i)*time.Minute), start, at(time.Duration(i+4)*time.Minute), alert))
+		}
+
+		result, err := SimulateNotifications(cfg, notifications, at(90*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, "default", result[0].Receiver)
+		require.Equal(t, at(30*time.Second), result[0].NotifiedAt)
+		require.Equal(t, at(time.Hour+5*time.Minute+30*time.Second), result[1].NotifiedAt)
+	})
+
+	t.Run("inhibited alerts are not sent", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.InhibitRules = []config.InhibitRule{{
+			SourceMatchers: config.Matchers{{Type: labels.MatchEqual, Name: "severity", Value: "critical"}},
+			TargetMatchers: config.Matchers{{Type: labels.MatchEqual, Name: "severity", Value: "warning"}},
+			Equal:          []string{"instance"},
+		}}
+		critical := amv2.LabelSet{"alertname": "Critical", "severity": "critical", "instance": "1"}
+		inhibited := amv2.LabelSet{"alertname": "Warning", "severity": "warning", "instance": "1"}
+		warning := amv2.LabelSet{"alertname": "Warning", "severity": "warning", "instance": "2"}
+		notifications := []Notification{
+			simulationNotification(start, start, at(4*time.Minute), critical),
+			simulationNotification(start, start, at(4*time.Minute), inhibited),
+			simulationNotification(start, start, at(4*time.Minute), warning),
+		}
+
+		result, err := SimulateNotifications(cfg, notifications, at(time.Minute))
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		require.Equal(t, `{}:{alertname="Critical"}`, result[0].GroupKey)
+		require.Equal(t, []model.LabelSet{toLabelSet(critical)}, result[0].Firing)
+		require.Equal(t, `{}:{alertname="Warning"}`, result[1].GroupKey)
+		require.Equal(t, []model.LabelSet{toLabelSet(warning)}, result[1].Firing)
+	})
+
+	t.Run("alerts are not sent during mute time intervals", func(t *testing.T) {
+		cfg := newConfig(&definitions.Route{
+			Receiver:          "team",
+			ObjectMatchers:    definitions.ObjectMatchers{{Type: labels.MatchEqual, Name: "team", Value: "a"}},
+			MuteTimeIntervals: []string{"maintenance"},
+		})
+		cfg.TimeIntervals = []alertingNotify.TimeInterval{{
+			Name: "maintenance",
+			TimeIntervals: []timeinterval.TimeInterval{{
+				Times: []timeinterval.TimeRange{{StartMinute: 10 * 60, EndMinute: 10*60 + 10}},
+			}},
+		}}
+		alert := amv2.LabelSet{"alertname": "HighCPU", "team": "a"}
+		var notifications []Notification
+		for i := range 15 {
+			notifications = append(notifications, simulationNotification(at(time.Duration(i)*time.Minute), start, at(time.Duration(i+4)*time.Minute), alert))
+		}
+
+		result, err := SimulateNotifications(cfg, notifications, at(15*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, "team", result[0].Receiver)
+		require.Equal(t, at(10*time.Minute+30*time.Second), result[0].NotifiedAt)
+	})
+
+	t.Run("resolved alerts are not sent if all integrations disable resolve messages", func(t *testing.T) {
+		cfg := newConfig()
+		cfg.Receivers[0].Integrations[0].DisableResolveMessage = true
+		alert := amv2.LabelSet{"alertname": "HighCPU"}
+		notifications := []Notification{
+			simulationNotification(start, start, at(4*time.Minute), alert),
+			simulationNotification(at(time.Minute), start, at(time.Minute), alert),
+		}
+
+		result, err := SimulateNotifications(cfg, notifications, at(10*time.Minute))
+		require.NoError(t, err)
+		require.Len(t, result, 1)
+		require.Equal(t, at(30*time.Second), result[0].NotifiedAt)
+	})
+}
+
+func simulationNotification(evaluatedAt, startsAt, endsAt time.Time, lbls amv2.LabelSet) Notification {
+	return Notification{
+		RuleUID:     "rule",
+		EvaluatedAt: evaluatedAt,
+		Alert: amv2.PostableAlert{
+			Alert:    amv2.Alert{Labels: lbls},
+			StartsAt: strfmt.DateTime(startsAt),
+			EndsAt:   strfmt.DateTime(endsAt),
+		},
+	}
+}
+
+func toLabelSet(lbls amv2.LabelSet) model.LabelSet {
+	result := make(model.LabelSet, len(lbls))
+	for k, v := range lbls {
+		result[model.LabelName(k)] = model.LabelValue(v)
+	}
+	return result
+}
@@ -0,0 +1,237 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
+)
+
+// instantQueryLookback is how far back an instant query looks for the latest sample of a series, the same as the default lookback delta of Prometheus.
+const instantQueryLookback = 5 * time.Minute
+
+var metricSelectorRegexp = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)\s*(\{.*\})?$`)
+
+type recordedMetric struct {
+	datasourceUID string
+	metric        string
+}
+
+type recordedSeries struct {
+	labels data.Labels
+	times  []time.Time
+	values []float64
+}
+
+// seriesStore keeps in memory the series written by recording rules during backtesting of a rule group.
+// It answers the queries that select recorded metrics from the target data source instead of the data source itself.
+type seriesStore struct {
+	mtx    sync.RWMutex
+	series map[recordedMetric]map[data.Fingerprint]*recordedSeries
+}
+
+func newSeriesStore() *seriesStore {
+	return &seriesStore{
+		series: map[recordedMetric]map[data.Fingerprint]*recordedSeries{},
+	}
+}
+
+// register makes the store responsible for the metric in the data source, even if nothing is recorded yet.
+func (s *seriesStore) register(datasourceUID, metric string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := recordedMetric{datasourceUID: datasourceUID, metric: metric}
+	if _, ok := s.series[key]; !ok {
+		s.series[key] = map[data.Fingerprint]*recordedSeries{}
+	}
+}
+
+// add appends the points recorded at the same time to the series of the metric in the data source.
+func (s *seriesStore) add(datasourceUID string, points []writer.Point) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for _, p := range points {
+		key := recordedMetric{datasourceUID: datasourceUID, metric: p.Name}
+		series, ok := s.series[key]
+		if !ok {
+			series = map[data.Fingerprint]*recordedSeries{}
+			s.series[key] = series
+		}
+		lbls := data.Labels(p.Labels)
+		fp := lbls.Fingerprint()
+		r, ok := series[fp]
+		if !ok {
+			r = &recordedSeries{labels: lbls.Copy()}
+			series[fp] = r
+		}
+		r.times = append(r.times, p.Metric.T)
+		r.values = append(r.values, p.Metric.V)
+	}
+}
+
+// frames returns the recorded series of the metric in the data source as data frames, one frame per series.
+func (s *seriesStore) frames(datasourceUID, metric string) data.Frames {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	series := s.series[recordedMetric{datasourceUID: datasourceUID, metric: metric}]
+	frames := make(data.Frames, 0, len(series))
+	for _, r := range series {
+		frames = append(frames, data.NewFrame(metric,
+			data.NewField("Time", nil, slices.Clone(r.times)),
+			data.NewField("Value", r.labels.Copy(), slices.Clone(r.values)),
+		))
+	}
+	slices.SortFunc(frames, func(a, b *data.Frame) int {
+		return strings.Compare(a.Fields[1].Labels.String(), b.Fields[1].Labels.String())
+	})
+	return frames
+}
+
+// isRecorded returns true if the metric in the data source is written by a recording rule of the group.
+func (s *seriesStore) isRecorded(datasourceUID, metric string) bool {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	_, ok := s.series[recordedMetric{datasourceUID: datasourceUID, metric: metric}]
+	return ok
+}
+
+// query returns the response to the query if it selects a recorded metric.
+// It returns false if the query must be sent to the data source.
+func (s *seriesStore) query(datasourceUID string, q backend.DataQuery) (backend.DataResponse, bool) {
+	model := struct {
+		Expr    string `json:"expr"`
+		Instant bool   `json:"instant"`
+		Range   bool   `json:"range"`
+	}{}
+	if err := json.Unmarshal(q.JSON, &model); err != nil {
+		return backend.DataResponse{}, false
+	}
+	metric, matchers, err := parseMetricSelector(model.Expr)
+	if err != nil || !s.isRecorded(datasourceUID, metric) {
+		return backend.DataResponse{}, false
+	}
+
+	from, to := q.TimeRange.From, q.TimeRange.To
+	instant := model.Instant && !model.Range
+	if instant {
+		from = to.Add(-instantQueryLookback)
+	}
+
+	var frames data.Frames
+	for _, f := range s.frames(datasourceUID, metric) {
+		if !matchLabels(matchers, f.Fields[1].Labels) {
+			continue
+		}
+		filtered := data.NewFrame(f.Name,
+			data.NewField("Time", nil, []time.Time{}),
+			data.NewField("Value", f.Fields[1].Labels, []float64{}),
+		)
+		for i := range f.Rows() {
+			t := f.Fields[0].At(i).(time.Time)
+			if t.Before(from) || t.After(to) {
+				continue
+			}
+			if instant && filtered.Rows() > 0 {
+				// only the latest sample is returned by instant queries
+				filtered.SetRow(0, t, f.Fields[1].At(i))
+				continue
+			}
+			filtered.AppendRow(t, f.Fields[1].At(i))
+		}
+		if filtered.Rows() == 0 {
+			continue
+		}
+		frames = append(frames, filtered)
+	}
+	return backend.DataResponse{Frames: frames}, true
+}
+
+// middleware returns a query data middleware that answers the queries of recorded metrics from the store,
+// and sends the other queries to the data source.
+func (s *seriesStore) middleware(next backend.QueryDataHandler) backend.QueryDataHandler {
+	return backend.QueryDataHandlerFunc(func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		if req.PluginContext.DataSourceInstanceSettings == nil {
+			return next.QueryData(ctx, req)
+		}
+		datasourceUID := req.PluginContext.DataSourceInstanceSettings.UID
+
+		resp := backend.NewQueryDataResponse()
+		remaining := make([]backend.DataQuery, 0, len(req.Queries))
+		for _, q := range req.Queries {
+			r, ok := s.query(datasourceUID, q)
+			if !ok {
+				remaining = append(remaining, q)
+				continue
+			}
+			resp.Responses[q.RefID] = r
+		}
+		if len(remaining) == 0 {
+			return resp, nil
+		}
+
+		dsReq := *req
+		dsReq.Queries = remaining
+		dsResp, err := next.QueryData(ctx, &dsReq)
+		if err != nil {
+			return nil, err
+		}
+		for refID, r := range dsResp.Responses {
+			resp.Responses[refID] = r
+		}
+		return resp, nil
+	})
+}
+
+// parseMetricSelector parses a PromQL query that only selects series of a metric, for example metric{label="value"}.
+func parseMetricSelector(query string) (string, labels.Matchers, error) {
+	m := metricSelectorRegexp.FindStringSubmatch(strings.TrimSpace(query))
+	if m == nil {
+		return "", nil, fmt.Errorf("query %q is not a metric selector", query)
+	}
+	if m[2] == "" || strings.TrimSpace(m[2][1:len(m[2])-1]) == "" {
+		return m[1], nil, nil
+	}
+	matchers, err := labels.ParseMatchers(m[2])
+	if err != nil {
+		return "", nil, fmt.Errorf("query %q is not a metric selector: %w", query, err)
+	}
+	return m[1], matchers, nil
+}
+
+// referencesMetric returns true if the PromQL query contains the metric name.
+func referencesMetric(query, metric string) bool {
+	isNameChar := func(b byte) bool {
+		return b == '_' || b == ':' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+	}
+	for i := 0; i < len(query); {
+		idx := strings.Index(query[i:], metric)
+		if idx < 0 {
+			return false
+		}
+		start, end := i+idx, i+idx+len(metric)
+		if (start == 0 || !isNameChar(query[start-1])) && (end == len(query) || !isNameChar(query[end])) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+func matchLabels(matchers labels.Matchers, lbls data.Labels) bool {
+	for _, m := range matchers {
+		if !m.Matches(lbls[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package backtesting
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/ngalert/writer"
+)
+
+func TestParseMetricSelector(t *testing.T) {
+	testCases := []struct {
+		query    string
+		metric   string
+		matchers int
+		error    bool
+	}{
+		{query: "job:up", metric: "job:up"},
+		{query: " job:up{} ", metric: "job:up"},
+		{query: `job:up{job="a"}`, metric: "job:up", matchers: 1},
+		{query: `job:up{job="a", instance=~"b.*"}`, metric: "job:up", matchers: 2},
+		{query: "sum(job:up)", error: true},
+		{query: "job:up > 0", error: true},
+		{query: "job:up[5m]", error: true},
+		{query: `{__name__="job:up"}`, error: true},
+		{query: `job:up{job="a"`, error: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			metric, matchers, err := parseMetricSelector(tc.query)
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.metric, metric)
+			require.Len(t, matchers, tc.matchers)
+		})
+	}
+}
+
+func TestReferencesMetric(t *testing.T) {
+	require.True(t, referencesMetric("job:up", "job:up"))
+	require.True(t, referencesMetric("sum(job:up) by (job)", "job:up"))
+	require.True(t, referencesMetric(`rate(other[5m]) / job:up{job="a"}`, "job:up"))
+	require.False(t, referencesMetric("job:up_total", "job:up"))
+	require.False(t, referencesMetric("instance_job:up", "job:up"))
+	require.False(t, referencesMetric("", "job:up"))
+}
+
+func TestSeriesStoreMiddleware(t *testing.T) {
+	start := time.Unix(0, 0)
+	store := newSeriesStore()
+	store.register("prom", "job:up")
+	for i := range 3 {
+		store.add("prom", []writer.Point{
+			{Name: "job:up", Labels: map[string]string{"job": "a"}, Metric: writer.Metric{T: start.Add(time.Duration(i) * time.Minute), V: float64(i)}},
+			{Name: "job:up", Labels: map[string]string{"job": "b"}, Metric: writer.Metric{T: start.Add(time.Duration(i) * time.Minute), V: float64(10 + i)}},
+		})
+	}
+
+	var forwarded []string
+	next := backend.QueryDataHandlerFunc(func(_ context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+		resp := backend.NewQueryDataResponse()
+		for _, q := range req.Queries {
+			forwarded = append(forwarded, q.RefID)
+			resp.Responses[q.RefID] = backend.DataResponse{}
+		}
+		return resp, nil
+	})
+	handler := store.middleware(next)
+
+	query := func(t *testing.T, dsUID string, queries ...backend.DataQuery) *backend.QueryDataResponse {
+		t.Helper()
+		forwarded = nil
+		resp, err := handler.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: dsUID},
+			},
+			Queries: queries,
+		})
+		require.NoError(t, err)
+		return resp
+	}
+	dataQuery := func(refID string, model any, from, to time.Time) backend.DataQuery {
+		b, err := json.Marshal(model)
+		require.NoError(t, err)
+		return backend.DataQuery{RefID: refID, JSON: b, TimeRange: backend.TimeRange{From: from, To: to}}
+	}
+
+	t.Run("range query returns the samples in the range", func(t *testing.T) {
+		resp := query(t, "prom", dataQuery("A", map[string]any{"expr": "job:up"}, start.Add(time.Minute), start.Add(2*time.Minute)))
+		require.Empty(t, forwarded)
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 2)
+		require.Equal(t, data.Labels{"job": "a"}, frames[0].Fields[1].Labels)
+		require.Equal(t, 2, frames[0].Rows())
+		require.Equal(t, 1.0, frames[0].Fields[1].At(0))
+		require.Equal(t, 2.0, frames[0].Fields[1].At(1))
+	})
+
+	t.Run("instant query returns the latest sample", func(t *testing.T) {
+		resp := query(t, "prom", dataQuery("A", map[string]any{"expr": `job:up{job="b"}`, "instant": true}, start, start.Add(90*time.Second)))
+		frames := resp.Responses["A"].Frames
+		require.Len(t, frames, 1)
+		require.Equal(t, data.Labels{"job": "b"}, frames[0].Fields[1].Labels)
+		require.Equal(t, 1, frames[0].Rows())
+		require.Equal(t, 11.0, frames[0].Fields[1].At(0))
+	})
+
+	t.Run("returns no data if nothing is recorded in the range", func(t *testing.T) {
+		resp := query(t, "prom", dataQuery("A", map[string]any{"expr": "job:up"}, start.Add(time.Hour), start.Add(2*time.Hour)))
+		require.Empty(t, forwarded)
+		require.Empty(t, resp.Responses["A"].Frames)
+	})
+
+	t.Run("other queries are sent to the data source", func(t *testing.T) {
+		resp := query(t, "prom",
+			dataQuery("A", map[string]any{"expr": "job:up"}, start, start.Add(time.Hour)),
+			dataQuery("B", map[string]any{"expr": "sum(job:up)"}, start, start.Add(time.Hour)),
+			dataQuery("C", map[string]any{"expr": "up"}, start, start.Add(time.Hour)),
+		)
+		require.Equal(t, []string{"B", "C"}, forwarded)
+		require.Len(t, resp.Responses, 3)
+		require.Len(t, resp.Responses["A"].Frames, 2)
+	})
+
+	t.Run("queries to other data sources are sent to the data source", func(t *testing.T) {
+		query(t, "other", dataQuery("A", map[string]any{"expr": "job:up"}, start, start.Add(time.Hour)))
+		require.Equal(t, []string{"A"}, forwarded)
+	})
+}
@@ -16,11 +16,15 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/infra/tracing"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	fakes "github.com/grafana/grafana/pkg/services/datasources/fakes"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/dsquerierclient"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
@@ -604,7 +608,7 @@ func TestValidate(t *testing.T) {
 				tracing.InitializeTracerForTest(),
 				dsquerierclient.NewNullQSDatasourceClientBuilder(),
 			)
-			validator := NewConditionValidator(cacheService, expressions, store)
+			validator := NewConditionValidator(cacheService, expressions, store, nil)
 			evalCtx := NewContext(context.Background(), u)
 
 			err := validator.Validate(evalCtx, condition)
@@ -617,6 +621,60 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+type fakeQueryPolicies struct {
+	rewrite bool
+	err     error
+}
+
+func (f *fakeQueryPolicies) New(orgID int64, user identity.Requester, dataSources ...datasources.DataSource) guardian.DatasourceGuardian {
+	return &fakePolicyGuardian{policies: f}
+}
+
+type fakePolicyGuardian struct {
+	guardian.AllowGuardian
+	policies *fakeQueryPolicies
+}
+
+func (g *fakePolicyGuardian) CheckQuery(ctx context.Context, ds *datasources.DataSource, query *simplejson.Json) error {
+	if g.policies.rewrite {
+		query.Set("expr", "rewritten")
+	}
+	return g.policies.err
+}
+
+func TestValidate_QueryPolicies(t *testing.T) {
+	dsQuery := models.GenerateAlertQuery()
+	ds := &datasources.DataSource{UID: dsQuery.DatasourceUID, Type: util.GenerateShortUID()}
+	cacheService := &fakes.FakeCacheService{DataSources: []*datasources.DataSource{ds}}
+	store := &pluginstore.FakePluginStore{PluginList: []pluginstore.Plugin{{JSONData: plugins.JSONData{ID: ds.Type, Backend: true}}}}
+	expressions := expr.ProvideService(
+		&setting.Cfg{ExpressionsEnabled: true},
+		nil,
+		nil,
+		featuremgmt.WithFeatures(),
+		nil,
+		tracing.InitializeTracerForTest(),
+		dsquerierclient.NewNullQSDatasourceClientBuilder(),
+	)
+	condition := models.Condition{Condition: dsQuery.RefID, Data: []models.AlertQuery{dsQuery}}
+	evalCtx := NewContext(context.Background(), &user.SignedInUser{OrgID: 1})
+
+	t.Run("compliant queries are valid", func(t *testing.T) {
+		validator := NewConditionValidator(cacheService, expressions, store, &fakeQueryPolicies{})
+		require.NoError(t, validator.Validate(evalCtx, condition))
+	})
+
+	t.Run("denied queries are invalid", func(t *testing.T) {
+		validator := NewConditionValidator(cacheService, expressions, store, &fakeQueryPolicies{err: guardian.ErrQueryDenied.Build(errutil.TemplateData{})})
+		require.ErrorIs(t, validator.Validate(evalCtx, condition), guardian.ErrQueryDenied)
+	})
+
+	t.Run("queries a policy would rewrite are invalid", func(t *testing.T) {
+		validator := NewConditionValidator(cacheService, expressions, store, &fakeQueryPolicies{rewrite: true})
+		require.ErrorContains(t, validator.Validate(evalCtx, condition), "doesn't comply with the data source query policies")
+	})
+}
+
 func TestCreate_HysteresisCommand(t *testing.T) {
 	type services struct {
 		cache        *fakes.FakeCacheService
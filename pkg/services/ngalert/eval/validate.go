@@ -1,11 +1,14 @@
 package eval
 
 import (
+	"bytes"
 	"fmt"
 
+	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/plugins"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/ngalert/models"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/pluginstore"
 )
@@ -14,13 +17,15 @@ type ConditionValidator struct {
 	dataSourceCache   datasources.CacheService
 	pluginsStore      pluginstore.Store
 	expressionService expressionBuilder
+	dsGuardian        guardian.DatasourceGuardianProvider
 }
 
-func NewConditionValidator(datasourceCache datasources.CacheService, expressionService *expr.Service, pluginsStore pluginstore.Store) *ConditionValidator {
+func NewConditionValidator(datasourceCache datasources.CacheService, expressionService *expr.Service, pluginsStore pluginstore.Store, dsGuardian guardian.DatasourceGuardianProvider) *ConditionValidator {
 	return &ConditionValidator{
 		dataSourceCache:   datasourceCache,
 		expressionService: expressionService,
 		pluginsStore:      pluginsStore,
+		dsGuardian:        dsGuardian,
 	}
 }
 
@@ -42,6 +47,9 @@ func (e *ConditionValidator) Validate(ctx EvaluationContext, condition models.Co
 			if !p.Backend {
 				return fmt.Errorf("datasource refID %s is not a backend datasource", query.RefID)
 			}
+			if err := e.checkQueryPolicies(ctx, query); err != nil {
+				return err
+			}
 		case expr.TypeMLNode:
 			_, found := e.pluginsStore.Plugin(ctx.Ctx, query.DataSource.Type)
 			if !found {
@@ -63,3 +71,36 @@ func (e *ConditionValidator) Validate(ctx EvaluationContext, condition models.Co
 	}
 	return models.ErrConditionNotExist(condition.Condition, refIDs)
 }
+
+// checkQueryPolicies checks the query against the data source query policies of
+// the user saving the rule. Rules are evaluated without the user, so the
+// policies can't rewrite the query then: the queries a policy would rewrite
+// are rejected too.
+func (e *ConditionValidator) checkQueryPolicies(ctx EvaluationContext, query expr.Query) error {
+	if e.dsGuardian == nil || ctx.User == nil {
+		return nil
+	}
+	model, err := simplejson.NewJson(query.JSON)
+	if err != nil {
+		return fmt.Errorf("failed to parse query %s: %w", query.RefID, err)
+	}
+	if model.Get("refId").MustString() == "" {
+		model.Set("refId", query.RefID)
+	}
+	original, err := model.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	g := e.dsGuardian.New(ctx.User.GetOrgID(), ctx.User, *query.DataSource)
+	if err := g.CheckQuery(ctx.Ctx, query.DataSource, model); err != nil {
+		return err
+	}
+	checked, err := model.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(original, checked) {
+		return fmt.Errorf("query %s doesn't comply with the data source query policies", query.RefID)
+	}
+	return nil
+}
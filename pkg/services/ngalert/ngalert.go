@@ -35,6 +35,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/datasourceproxy"
 	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/folder"
 	ac "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol"
@@ -102,6 +103,7 @@ func ProvideService(
 	userService user.Service,
 	orgService org.Service,
 	clientGenerator resource.ClientGenerator,
+	dsGuardian guardian.DatasourceGuardianProvider,
 ) (*AlertNG, error) {
 	ng := &AlertNG{
 		Cfg:                       cfg,
@@ -137,6 +139,7 @@ func ProvideService(
 		FolderResourcePermissions: folderResourcePermissions,
 		userService:               userService,
 		orgService:                orgService,
+		dsGuardian:                dsGuardian,
 	}
 
 	if ng.IsDisabled() {
@@ -172,6 +175,7 @@ type AlertNG struct {
 	RecordingWriter       schedule.RecordingWriter
 	schedule              schedule.ScheduleService
 	stateManager          *state.Manager
+	stateHistorian        Historian
 	folderService         folder.Service
 	dashboardService      dashboards.DashboardService
 	Api                   *api.API
@@ -199,6 +203,7 @@ type AlertNG struct {
 	pluginsStore    pluginstore.Store
 	tracer          tracing.Tracer
 	clientGenerator resource.ClientGenerator
+	dsGuardian      guardian.DatasourceGuardianProvider
 
 	evaluationCoordinator EvaluationCoordinator
 	schedCfg              schedule.SchedulerCfg
@@ -382,7 +387,7 @@ func (ng *AlertNG) init() error {
 	ng.AlertsRouter = alertsRouter
 
 	evalFactory := eval.NewEvaluatorFactory(ng.Cfg.UnifiedAlerting, ng.DataSourceCache, ng.ExpressionService)
-	conditionValidator := eval.NewConditionValidator(ng.DataSourceCache, ng.ExpressionService, ng.pluginsStore)
+	conditionValidator := eval.NewConditionValidator(ng.DataSourceCache, ng.ExpressionService, ng.pluginsStore, ng.dsGuardian)
 
 	recordingWriter, err := createRecordingWriter(ng.Cfg.UnifiedAlerting.RecordingRules, ng.httpClientProvider, ng.DataSourceService, ng.pluginContextProvider, clk, ng.Metrics.GetRemoteWriterMetrics())
 	if err != nil {
@@ -435,6 +440,7 @@ func (ng *AlertNG) init() error {
 	if err != nil {
 		return err
 	}
+	ng.stateHistorian = history
 
 	ng.InstanceStore = initInstanceStore(ng.store.SQLStore, ng.Log, ng.FeatureToggles)
 	ng.StartupInstanceReader = ng.InstanceStore
@@ -642,6 +648,7 @@ func (ng *AlertNG) init() error {
 		AlertsRouter:          alertsRouter,
 		ExternalRulerSync:     ng.externalRulerSyncer,
 		EvaluatorFactory:      evalFactory,
+		ExpressionService:     ng.ExpressionService,
 		ConditionValidator:    conditionValidator,
 		FeatureManager:        ng.FeatureToggles,
 		AppUrl:                appUrl,
@@ -771,6 +778,11 @@ func (ng *AlertNG) Run(ctx context.Context) error {
 			return ng.externalRulerSyncer.Run(subCtx)
 		})
 	}
+	if r, ok := ng.stateHistorian.(historian.Runner); ok {
+		children.Go(func() error {
+			return r.Run(subCtx)
+		})
+	}
 
 	if ng.Cfg.UnifiedAlerting.ExecuteAlerts {
 		children.Go(func() error {
@@ -877,6 +889,20 @@ func configureHistorianBackend(
 		return backend, nil
 	}
 
+	if backend == historian.BackendTypeParquet {
+		pcfg, err := historian.NewParquetConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parquet configuration: %w", err)
+		}
+		logCtx := log.WithContextualAttributes(ctx, []any{"backend", "parquet"})
+		parquetBackendLogger := log.New("ngalert.state.historian").FromContext(logCtx)
+		backend, err := historian.NewParquetBackend(parquetBackendLogger, pcfg, met, rs, ac)
+		if err != nil {
+			return nil, err
+		}
+		return backend, nil
+	}
+
 	if backend == historian.BackendTypePrometheus {
 		pcfg, err := historian.NewPrometheusConfig(cfg)
 		if err != nil {
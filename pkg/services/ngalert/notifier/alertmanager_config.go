@@ -182,6 +182,17 @@ func (moa *MultiOrgAlertmanager) GetAlertmanagerConfiguration(ctx context.Contex
 	return moa.gettableUserConfigFromAMConfigString(ctx, org, amConfig.AlertmanagerConfiguration, withAutogen)
 }
 
+// GetNotificationsConfiguration returns the latest alertmanager configuration for a given org in the form it is applied to the Alertmanager,
+// that is with managed routes, autogenerated routes and inhibition rules included.
+func (moa *MultiOrgAlertmanager) GetNotificationsConfiguration(ctx context.Context, org int64) (alertingNotify.NotificationsConfiguration, error) {
+	amConfig, err := moa.configStore.GetLatestAlertmanagerConfiguration(ctx, org)
+	if err != nil {
+		return alertingNotify.NotificationsConfiguration{}, fmt.Errorf("failed to get latest configuration: %w", err)
+	}
+
+	return moa.PrepareConfig(ctx, org, amConfig, IgnoreInvalidReceivers)
+}
+
 // ActivateHistoricalConfiguration will set the current alertmanager configuration to a previous value based on the provided
 // alert_configuration_history id.
 func (moa *MultiOrgAlertmanager) ActivateHistoricalConfiguration(ctx context.Context, orgId int64, id int64) error {
@@ -96,3 +96,27 @@ func ConvertRuleGroup(
 
 	return grafanaGroup, nil
 }
+
+// ExportRuleGroup converts one Grafana rule group into a Prometheus rule group. The rules that
+// cannot be represented in Prometheus format are left out of the group and returned with the reason.
+func ExportRuleGroup(group string, rules []models.AlertRule, datasourceType DatasourceTypeFunc) (apimodels.PrometheusRuleGroup, []SkippedRule) {
+	promGroup, skipped := GrafanaRulesToPrometheus(group, rules, datasourceType)
+
+	result := apimodels.PrometheusRuleGroup{
+		Name:     promGroup.Name,
+		Interval: promGroup.Interval,
+		Rules:    make([]apimodels.PrometheusRule, len(promGroup.Rules)),
+	}
+	for i, r := range promGroup.Rules {
+		result.Rules[i] = apimodels.PrometheusRule{
+			Alert:         r.Alert,
+			Expr:          r.Expr,
+			For:           r.For,
+			KeepFiringFor: r.KeepFiringFor,
+			Labels:        r.Labels,
+			Annotations:   r.Annotations,
+			Record:        r.Record,
+		}
+	}
+	return result, skipped
+}
@@ -0,0 +1,335 @@
+package prom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/expr/mathexp"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+// DatasourceTypeFunc returns the type of the data source with the given UID.
+type DatasourceTypeFunc func(uid string) (string, error)
+
+// SkippedRule is a Grafana rule that cannot be represented in Prometheus format.
+type SkippedRule struct {
+	UID    string
+	Title  string
+	Reason string
+}
+
+// reducersOfInstantVector contains the reducers that return the value of a series with a single sample.
+var reducersOfInstantVector = []mathexp.ReducerID{
+	mathexp.ReducerLast,
+	mathexp.ReducerFirst,
+	mathexp.ReducerMean,
+	mathexp.ReducerMedian,
+	mathexp.ReducerMin,
+	mathexp.ReducerMax,
+	mathexp.ReducerSum,
+}
+
+// GrafanaRulesToPrometheus converts the rules of a Grafana rule group into a Prometheus rule group.
+//
+// Rules that were imported from Prometheus are converted back to their original definition. Other rules can be
+// converted if their condition is an instant query to a Prometheus-compatible data source, a threshold of such a
+// query, or a threshold of a reduce expression of such a query. Recording rules can be converted if they record
+// an instant query to a Prometheus-compatible data source.
+//
+// The rules that cannot be represented in Prometheus format are not added to the group and are returned with the reason.
+func GrafanaRulesToPrometheus(group string, rules []models.AlertRule, datasourceType DatasourceTypeFunc) (PrometheusRuleGroup, []SkippedRule) {
+	promGroup := PrometheusRuleGroup{
+		Name:  group,
+		Rules: make([]PrometheusRule, 0, len(rules)),
+	}
+	if len(rules) > 0 {
+		promGroup.Interval = prommodel.Duration(time.Duration(rules[0].IntervalSeconds) * time.Second)
+	}
+
+	var skipped []SkippedRule
+	for _, rule := range rules {
+		promRule, err := grafanaRuleToPrometheus(rule, datasourceType)
+		if err != nil {
+			skipped = append(skipped, SkippedRule{UID: rule.UID, Title: rule.Title, Reason: err.Error()})
+			continue
+		}
+		promGroup.Rules = append(promGroup.Rules, promRule)
+	}
+	return promGroup, skipped
+}
+
+func grafanaRuleToPrometheus(rule models.AlertRule, datasourceType DatasourceTypeFunc) (PrometheusRule, error) {
+	if definition, err := rule.PrometheusRuleDefinition(); err == nil {
+		var promRule PrometheusRule
+		if err := yaml.Unmarshal([]byte(definition), &promRule); err != nil {
+			return PrometheusRule{}, fmt.Errorf("failed to parse the original Prometheus rule definition: %w", err)
+		}
+		return promRule, nil
+	}
+
+	if rule.IsPaused {
+		return PrometheusRule{}, errors.New("the rule is paused, and Prometheus rules cannot be paused")
+	}
+
+	queries := make(map[string]models.AlertQuery, len(rule.Data))
+	for _, q := range rule.Data {
+		queries[q.RefID] = q
+	}
+
+	if rule.Type() == models.RuleTypeRecording {
+		q, ok := queries[rule.Record.From]
+		if !ok {
+			return PrometheusRule{}, fmt.Errorf("the recorded query %s does not exist", rule.Record.From)
+		}
+		if isExpr, _ := q.IsExpression(); isExpr {
+			return PrometheusRule{}, fmt.Errorf("the recorded query %s is an expression, only data source queries can be recorded", q.RefID)
+		}
+		promQL, err := instantPromQLQuery(q, datasourceType)
+		if err != nil {
+			return PrometheusRule{}, err
+		}
+		return PrometheusRule{
+			Record: rule.Record.Metric,
+			Expr:   promQL,
+			Labels: exportLabels(rule.Labels),
+		}, nil
+	}
+
+	if rule.NoDataState == models.Alerting {
+		return PrometheusRule{}, errors.New("the rule fires when the query returns no data, which is not supported by Prometheus")
+	}
+	if rule.ExecErrState == models.AlertingErrState {
+		return PrometheusRule{}, errors.New("the rule fires when the query fails, which is not supported by Prometheus")
+	}
+	for _, tmpl := range slices.Concat(slices.Collect(maps.Values(rule.Labels)), slices.Collect(maps.Values(rule.Annotations))) {
+		if strings.Contains(tmpl, "$values") || strings.Contains(tmpl, ".Values") {
+			return PrometheusRule{}, errors.New("labels or annotations use $values, which is not available in Prometheus templates")
+		}
+	}
+
+	promQL, err := conditionToPromQL(rule.Condition, queries, datasourceType)
+	if err != nil {
+		return PrometheusRule{}, err
+	}
+
+	promRule := PrometheusRule{
+		Alert:       rule.Title,
+		Expr:        promQL,
+		Labels:      exportLabels(rule.Labels),
+		Annotations: rule.Annotations,
+	}
+	if rule.For > 0 {
+		promRule.For = new(prommodel.Duration(rule.For))
+	}
+	if rule.KeepFiringFor > 0 {
+		promRule.KeepFiringFor = new(prommodel.Duration(rule.KeepFiringFor))
+	}
+	return promRule, nil
+}
+
+// conditionToPromQL returns a PromQL expression that returns the series that are alerting according to the condition.
+func conditionToPromQL(refID string, queries map[string]models.AlertQuery, datasourceType DatasourceTypeFunc) (string, error) {
+	q, ok := queries[refID]
+	if !ok {
+		return "", fmt.Errorf("the condition %s does not exist", refID)
+	}
+
+	if isExpr, _ := q.IsExpression(); !isExpr {
+		promQL, err := instantPromQLQuery(q, datasourceType)
+		if err != nil {
+			return "", err
+		}
+		// A data source query used as the condition is alerting if its value is not 0.
+		return fmt.Sprintf("(%s) != 0", promQL), nil
+	}
+
+	exprType, err := q.GetExpressionType()
+	if err != nil {
+		return "", err
+	}
+	if exprType != string(expr.QueryTypeThreshold) {
+		return "", fmt.Errorf("the condition is a %s expression, only threshold expressions are supported", exprType)
+	}
+
+	var threshold expr.ThresholdQuery
+	if err := json.Unmarshal(q.Model, &threshold); err != nil {
+		return "", fmt.Errorf("failed to parse the threshold expression %s: %w", q.RefID, err)
+	}
+	if len(threshold.Conditions) != 1 {
+		return "", fmt.Errorf("the threshold expression %s has %d conditions, only one is supported", q.RefID, len(threshold.Conditions))
+	}
+	condition := threshold.Conditions[0]
+	if condition.UnloadEvaluator != nil {
+		return "", fmt.Errorf("the threshold expression %s has a recovery threshold, which is not supported by Prometheus", q.RefID)
+	}
+
+	input, anyResult, err := thresholdInputToPromQL(strings.TrimPrefix(threshold.Expression, "$"), queries, datasourceType)
+	if err != nil {
+		return "", err
+	}
+	if anyResult {
+		if condition.Evaluator.Type != expr.ThresholdIsAbove || len(condition.Evaluator.Params) == 0 || condition.Evaluator.Params[0] != 0 {
+			return "", fmt.Errorf("the threshold expression %s is not supported", q.RefID)
+		}
+		return input, nil
+	}
+	return thresholdToPromQL(input, condition.Evaluator)
+}
+
+// thresholdInputToPromQL returns the PromQL query that is the input of a threshold expression. The second return value is true
+// if the input is the math expression that the Prometheus converter creates to make any result of the query alerting.
+func thresholdInputToPromQL(refID string, queries map[string]models.AlertQuery, datasourceType DatasourceTypeFunc) (string, bool, error) {
+	q, ok := queries[refID]
+	if !ok {
+		return "", false, fmt.Errorf("the query %s does not exist", refID)
+	}
+	if isExpr, _ := q.IsExpression(); !isExpr {
+		promQL, err := instantPromQLQuery(q, datasourceType)
+		return promQL, false, err
+	}
+
+	exprType, err := q.GetExpressionType()
+	if err != nil {
+		return "", false, err
+	}
+	switch exprType {
+	case string(expr.QueryTypeReduce):
+		var reduce expr.ReduceQuery
+		if err := json.Unmarshal(q.Model, &reduce); err != nil {
+			return "", false, fmt.Errorf("failed to parse the reduce expression %s: %w", q.RefID, err)
+		}
+		if !slices.Contains(reducersOfInstantVector, reduce.Reducer) {
+			return "", false, fmt.Errorf("the reduce expression %s uses the reducer %s, which is not supported", q.RefID, reduce.Reducer)
+		}
+		if reduce.Settings != nil && reduce.Settings.Mode == expr.ReduceModeReplace {
+			return "", false, fmt.Errorf("the reduce expression %s replaces non-numeric values, which is not supported", q.RefID)
+		}
+		input, ok := queries[strings.TrimPrefix(reduce.Expression, "$")]
+		if !ok {
+			return "", false, fmt.Errorf("the query %s does not exist", reduce.Expression)
+		}
+		if isExpr, _ := input.IsExpression(); isExpr {
+			return "", false, fmt.Errorf("the reduce expression %s reduces another expression, which is not supported", q.RefID)
+		}
+		promQL, err := instantPromQLQuery(input, datasourceType)
+		return promQL, false, err
+	case string(expr.QueryTypeMath):
+		var math expr.MathQuery
+		if err := json.Unmarshal(q.Model, &math); err != nil {
+			return "", false, fmt.Errorf("failed to parse the math expression %s: %w", q.RefID, err)
+		}
+		for _, input := range queries {
+			if isExpr, _ := input.IsExpression(); isExpr {
+				continue
+			}
+			if math.Expression == fmt.Sprintf("is_number($%[1]s) || is_nan($%[1]s) || is_inf($%[1]s)", input.RefID) {
+				promQL, err := instantPromQLQuery(input, datasourceType)
+				return promQL, true, err
+			}
+		}
+		return "", false, fmt.Errorf("the math expression %s is not supported", q.RefID)
+	default:
+		return "", false, fmt.Errorf("the %s expression %s is not supported", exprType, q.RefID)
+	}
+}
+
+// instantPromQLQuery returns the PromQL expression of the query if it is an instant query to a Prometheus-compatible data source.
+func instantPromQLQuery(q models.AlertQuery, datasourceType DatasourceTypeFunc) (string, error) {
+	dsType, err := datasourceType(q.DatasourceUID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the data source of the query %s: %w", q.RefID, err)
+	}
+	if !isPrometheusCompatibleDatasourceType(dsType) {
+		return "", fmt.Errorf("the query %s uses a data source of type %s, only Prometheus-compatible data sources are supported", q.RefID, dsType)
+	}
+
+	var model struct {
+		Expr    string `json:"expr"`
+		Instant bool   `json:"instant"`
+		Range   bool   `json:"range"`
+	}
+	if err := json.Unmarshal(q.Model, &model); err != nil {
+		return "", fmt.Errorf("failed to parse the query %s: %w", q.RefID, err)
+	}
+	if model.Expr == "" {
+		return "", fmt.Errorf("the query %s is empty", q.RefID)
+	}
+	if !model.Instant || model.Range {
+		return "", fmt.Errorf("the query %s is a range query, only instant queries are supported", q.RefID)
+	}
+	if q.RelativeTimeRange.To != 0 {
+		return "", fmt.Errorf("the time range of the query %s does not end at the evaluation time", q.RefID)
+	}
+	return model.Expr, nil
+}
+
+func thresholdToPromQL(promQL string, evaluator expr.ConditionEvalJSON) (string, error) {
+	params := make([]string, 0, len(evaluator.Params))
+	for _, p := range evaluator.Params {
+		params = append(params, strconv.FormatFloat(p, 'f', -1, 64))
+	}
+
+	var operators []string
+	switch evaluator.Type {
+	case expr.ThresholdIsAbove:
+		operators = []string{">"}
+	case expr.ThresholdIsBelow:
+		operators = []string{"<"}
+	case expr.ThresholdIsEqual:
+		operators = []string{"=="}
+	case expr.ThresholdIsNotEqual:
+		operators = []string{"!="}
+	case expr.ThresholdIsGreaterThanEqual:
+		operators = []string{">="}
+	case expr.ThresholdIsLessThanEqual:
+		operators = []string{"<="}
+	case expr.ThresholdIsWithinRange:
+		operators = []string{">", "<"}
+	case expr.ThresholdIsWithinRangeIncluded:
+		operators = []string{">=", "<="}
+	case expr.ThresholdIsOutsideRange:
+		operators = []string{"<", ">"}
+	case expr.ThresholdIsOutsideRangeIncluded:
+		operators = []string{"<=", ">="}
+	default:
+		return "", fmt.Errorf("the threshold type %s is not supported", evaluator.Type)
+	}
+	if len(params) < len(operators) {
+		return "", fmt.Errorf("the threshold type %s requires %d parameters, got %d", evaluator.Type, len(operators), len(params))
+	}
+
+	switch evaluator.Type {
+	case expr.ThresholdIsWithinRange, expr.ThresholdIsWithinRangeIncluded:
+		// Comparison operators filter the series, so chaining them keeps the series that are within the range.
+		return fmt.Sprintf("(%s) %s %s %s %s", promQL, operators[0], params[0], operators[1], params[1]), nil
+	case expr.ThresholdIsOutsideRange, expr.ThresholdIsOutsideRangeIncluded:
+		return fmt.Sprintf("(%[1]s) %[2]s %[3]s or (%[1]s) %[4]s %[5]s", promQL, operators[0], params[0], operators[1], params[1]), nil
+	default:
+		return fmt.Sprintf("(%s) %s %s", promQL, operators[0], params[0]), nil
+	}
+}
+
+// exportLabels returns the labels of the rule without the labels that are internal to Grafana.
+func exportLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if _, ok := models.PrivateLabelsToFilter[k]; ok {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
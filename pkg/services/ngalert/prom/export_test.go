@@ -0,0 +1,216 @@
+package prom
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/expr"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+)
+
+func TestGrafanaRulesToPrometheus(t *testing.T) {
+	datasourceType := func(uid string) (string, error) {
+		switch uid {
+		case "prometheus":
+			return datasources.DS_PROMETHEUS, nil
+		case "loki":
+			return datasources.DS_LOKI, nil
+		default:
+			return "", errors.New("data source not found")
+		}
+	}
+
+	promQuery := func(t *testing.T, refID, dsUID, promQL string, instant bool) models.AlertQuery {
+		t.Helper()
+		q, err := createAlertQueryWithDefaults(dsUID, map[string]any{
+			"expr":    promQL,
+			"instant": instant,
+			"range":   !instant,
+			"refId":   refID,
+		}, refID, &models.RelativeTimeRange{From: models.Duration(10 * time.Minute)}, "")
+		require.NoError(t, err)
+		return q
+	}
+	expression := func(t *testing.T, refID string, model map[string]any) models.AlertQuery {
+		t.Helper()
+		model["refId"] = refID
+		q, err := createAlertQueryWithDefaults(expr.DatasourceUID, model, refID, nil, "")
+		require.NoError(t, err)
+		return q
+	}
+	reduce := func(t *testing.T, refID, input, reducer string) models.AlertQuery {
+		return expression(t, refID, map[string]any{"type": "reduce", "expression": input, "reducer": reducer})
+	}
+	threshold := func(t *testing.T, refID, input string, evaluator map[string]any) models.AlertQuery {
+		return expression(t, refID, map[string]any{
+			"type":       "threshold",
+			"expression": input,
+			"conditions": []any{map[string]any{"evaluator": evaluator}},
+		})
+	}
+	alertRule := func(title, condition string, queries ...models.AlertQuery) models.AlertRule {
+		return models.AlertRule{
+			UID:             title,
+			Title:           title,
+			Condition:       condition,
+			Data:            queries,
+			IntervalSeconds: 60,
+			NoDataState:     models.NoData,
+			ExecErrState:    models.ErrorErrState,
+			Labels:          map[string]string{"severity": "critical", models.AutogeneratedRouteLabel: "true"},
+			Annotations:     map[string]string{"summary": "{{ $labels.instance }} is down"},
+		}
+	}
+
+	t.Run("converts rules with a threshold", func(t *testing.T) {
+		rule := alertRule("up", "C",
+			promQuery(t, "A", "prometheus", "up", true),
+			reduce(t, "B", "A", "last"),
+			threshold(t, "C", "B", map[string]any{"type": "lt", "params": []float64{1}}),
+		)
+		rule.For = 5 * time.Minute
+
+		group, skipped := GrafanaRulesToPrometheus("group", []models.AlertRule{rule}, datasourceType)
+		require.Empty(t, skipped)
+		require.Equal(t, "group", group.Name)
+		require.Equal(t, prommodel.Duration(time.Minute), group.Interval)
+		require.Equal(t, []PrometheusRule{{
+			Alert:       "up",
+			Expr:        "(up) < 1",
+			For:         new(prommodel.Duration(5 * time.Minute)),
+			Labels:      map[string]string{"severity": "critical"},
+			Annotations: map[string]string{"summary": "{{ $labels.instance }} is down"},
+		}}, group.Rules)
+	})
+
+	t.Run("converts threshold types", func(t *testing.T) {
+		testCases := []struct {
+			evaluator map[string]any
+			expected  string
+		}{
+			{evaluator: map[string]any{"type": "gt", "params": []float64{0.5}}, expected: "(rate(errors[5m])) > 0.5"},
+			{evaluator: map[string]any{"type": "gte", "params": []float64{10}}, expected: "(rate(errors[5m])) >= 10"},
+			{evaluator: map[string]any{"type": "ne", "params": []float64{1}}, expected: "(rate(errors[5m])) != 1"},
+			{evaluator: map[string]any{"type": "within_range", "params": []float64{1, 5}}, expected: "(rate(errors[5m])) > 1 < 5"},
+			{evaluator: map[string]any{"type": "outside_range_included", "params": []float64{1, 5}}, expected: "(rate(errors[5m])) <= 1 or (rate(errors[5m])) >= 5"},
+		}
+		for _, tc := range testCases {
+			rule := alertRule("errors", "B",
+				promQuery(t, "A", "prometheus", "rate(errors[5m])", true),
+				threshold(t, "B", "$A", tc.evaluator),
+			)
+			group, skipped := GrafanaRulesToPrometheus("group", []models.AlertRule{rule}, datasourceType)
+			require.Empty(t, skipped)
+			require.Equal(t, tc.expected, group.Rules[0].Expr)
+		}
+	})
+
+	t.Run("converts recording rules", func(t *testing.T) {
+		rule := models.AlertRule{
+			UID:             "recording",
+			Title:           "recording",
+			Data:            []models.AlertQuery{promQuery(t, "A", "prometheus", "sum(up) by (job)", true)},
+			IntervalSeconds: 60,
+			Record:          &models.Record{Metric: "job:up:sum", From: "A", TargetDatasourceUID: "prometheus"},
+		}
+
+		group, skipped := GrafanaRulesToPrometheus("group", []models.AlertRule{rule}, datasourceType)
+		require.Empty(t, skipped)
+		require.Equal(t, []PrometheusRule{{Record: "job:up:sum", Expr: "sum(up) by (job)"}}, group.Rules)
+	})
+
+	t.Run("converts rules created by the Prometheus converter", func(t *testing.T) {
+		converter, err := NewConverter(Config{
+			DatasourceUID:              "prometheus",
+			DatasourceType:             datasources.DS_PROMETHEUS,
+			DefaultInterval:            time.Minute,
+			KeepOriginalRuleDefinition: new(false),
+		})
+		require.NoError(t, err)
+		grafanaGroup, err := converter.PrometheusRulesToGrafana(1, "folder", PrometheusRuleGroup{
+			Name:  "group",
+			Rules: []PrometheusRule{{Alert: "HighLatency", Expr: "latency > 1"}},
+		})
+		require.NoError(t, err)
+
+		group, skipped := GrafanaRulesToPrometheus("group", grafanaGroup.Rules, datasourceType)
+		require.Empty(t, skipped)
+		require.Len(t, group.Rules, 1)
+		require.Equal(t, "latency > 1", group.Rules[0].Expr)
+	})
+
+	t.Run("returns the original definition of imported rules", func(t *testing.T) {
+		rule := alertRule("imported", "A", expression(t, "A", map[string]any{"type": "math", "expression": "1"}))
+		rule.Metadata.PrometheusStyleRule = &models.PrometheusStyleRule{
+			OriginalRuleDefinition: "alert: imported\nexpr: vector(1)\n",
+		}
+
+		group, skipped := GrafanaRulesToPrometheus("group", []models.AlertRule{rule}, datasourceType)
+		require.Empty(t, skipped)
+		require.Equal(t, []PrometheusRule{{Alert: "imported", Expr: "vector(1)"}}, group.Rules)
+	})
+
+	t.Run("reports the rules that cannot be converted", func(t *testing.T) {
+		paused := alertRule("paused", "A", promQuery(t, "A", "prometheus", "up", true))
+		paused.IsPaused = true
+		noDataAlerting := alertRule("no data alerting", "A", promQuery(t, "A", "prometheus", "up", true))
+		noDataAlerting.NoDataState = models.Alerting
+		values := alertRule("values", "A", promQuery(t, "A", "prometheus", "up", true))
+		values.Annotations = map[string]string{"summary": "{{ $values.A }}"}
+
+		rules := []models.AlertRule{
+			alertRule("converted", "A", promQuery(t, "A", "prometheus", "up", true)),
+			alertRule("range query", "A", promQuery(t, "A", "prometheus", "up", false)),
+			alertRule("loki", "A", promQuery(t, "A", "loki", `count_over_time({job="a"}[5m])`, true)),
+			alertRule("unknown data source", "A", promQuery(t, "A", "unknown", "up", true)),
+			alertRule("math", "B",
+				promQuery(t, "A", "prometheus", "up", true),
+				expression(t, "B", map[string]any{"type": "math", "expression": "$A > 1"}),
+			),
+			alertRule("count", "C",
+				promQuery(t, "A", "prometheus", "up", true),
+				reduce(t, "B", "A", "count"),
+				threshold(t, "C", "B", map[string]any{"type": "gt", "params": []float64{1}}),
+			),
+			alertRule("hysteresis", "B",
+				promQuery(t, "A", "prometheus", "up", true),
+				expression(t, "B", map[string]any{
+					"type":       "threshold",
+					"expression": "A",
+					"conditions": []any{map[string]any{
+						"evaluator":       map[string]any{"type": "gt", "params": []float64{10}},
+						"unloadEvaluator": map[string]any{"type": "lt", "params": []float64{5}},
+					}},
+				}),
+			),
+			paused,
+			noDataAlerting,
+			values,
+		}
+
+		group, skipped := GrafanaRulesToPrometheus("group", rules, datasourceType)
+		require.Len(t, group.Rules, 1)
+		require.Equal(t, "converted", group.Rules[0].Alert)
+		require.Equal(t, "(up) != 0", group.Rules[0].Expr)
+
+		reasons := make(map[string]string, len(skipped))
+		for _, s := range skipped {
+			reasons[s.UID] = s.Reason
+		}
+		require.Len(t, reasons, len(rules)-1)
+		require.Contains(t, reasons["range query"], "only instant queries are supported")
+		require.Contains(t, reasons["loki"], "only Prometheus-compatible data sources are supported")
+		require.Contains(t, reasons["unknown data source"], "data source not found")
+		require.Contains(t, reasons["math"], "only threshold expressions are supported")
+		require.Contains(t, reasons["count"], "reducer count")
+		require.Contains(t, reasons["hysteresis"], "recovery threshold")
+		require.Contains(t, reasons["paused"], "paused")
+		require.Contains(t, reasons["no data alerting"], "no data")
+		require.Contains(t, reasons["values"], "$values")
+	})
+}
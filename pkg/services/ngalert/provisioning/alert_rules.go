@@ -692,17 +692,40 @@ func (service *AlertRuleService) UpdateRuleGroup(ctx context.Context, user ident
 }
 
 func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, user identity.Requester, group models.AlertRuleGroup, manager utils.ManagerProperties, versionMessage string) error {
+	_, err := service.replaceRuleGroup(ctx, user, group, manager, versionMessage, false)
+	return err
+}
+
+// DryRunReplaceRuleGroup replaces the rule group in the same way as ReplaceRuleGroup, including the validation and
+// the quota check, and then rolls back the transaction. It returns the changes that would be made to the group.
+func (service *AlertRuleService) DryRunReplaceRuleGroup(ctx context.Context, user identity.Requester, group models.AlertRuleGroup, manager utils.ManagerProperties) (*store.GroupDelta, error) {
+	var delta *store.GroupDelta
+	err := service.xact.InTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		delta, err = service.replaceRuleGroup(ctx, user, group, manager, "", true)
+		if err != nil {
+			return err
+		}
+		return store.ErrDryRunRollback
+	})
+	if errors.Is(err, store.ErrDryRunRollback) {
+		return delta, nil
+	}
+	return nil, err
+}
+
+func (service *AlertRuleService) replaceRuleGroup(ctx context.Context, user identity.Requester, group models.AlertRuleGroup, manager utils.ManagerProperties, versionMessage string, dryRun bool) (*store.GroupDelta, error) {
 	if err := models.ValidateRuleGroupInterval(group.Interval, service.baseIntervalSeconds); err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := service.ensureNamespace(ctx, user, user.GetOrgID(), group.FolderUID); err != nil {
-		return err
+		return nil, err
 	}
 
 	// If the rule group is reserved for no-group rules, we cannot have multiple rules in it.
 	if models.IsNoGroupRuleGroup(group.Title) && len(group.Rules) > 1 {
-		return fmt.Errorf("rule group %s is reserved for no-group rules and cannot be used for rule groups with multiple rules", group.Title)
+		return nil, fmt.Errorf("rule group %s is reserved for no-group rules and cannot be used for rule groups with multiple rules", group.Title)
 	}
 
 	for _, rule := range group.Rules {
@@ -711,28 +734,28 @@ func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, user iden
 			continue
 		}
 		if err := util.ValidateUID(rule.UID); err != nil {
-			return fmt.Errorf("%w: cannot create rule with UID %q: %w", models.ErrAlertRuleFailedValidation, rule.UID, err)
+			return nil, fmt.Errorf("%w: cannot create rule with UID %q: %w", models.ErrAlertRuleFailedValidation, rule.UID, err)
 		}
 	}
 
 	delta, err := service.calcDelta(ctx, user, group)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if delta.IsEmpty() {
-		return nil
+		return delta, nil
 	}
 
 	// check if the current user has permissions to all rules and can bypass the regular authorization validation.
 	can, err := service.authz.CanWriteAllRules(ctx, user)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if !can {
 		if err := service.authz.AuthorizeRuleGroupWrite(ctx, user, delta); err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -740,16 +763,24 @@ func (service *AlertRuleService) ReplaceRuleGroup(ctx context.Context, user iden
 	if len(newOrUpdatedNotificationSettings) > 0 {
 		validator, err := service.nsValidatorProvider.Validator(ctx, delta.GroupKey.OrgID)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		for _, s := range newOrUpdatedNotificationSettings {
 			if err := validator.Validate(s); err != nil {
-				return errors.Join(models.ErrAlertRuleFailedValidation, err)
+				return nil, errors.Join(models.ErrAlertRuleFailedValidation, err)
 			}
 		}
 	}
 
-	return service.persistDelta(ctx, user, delta, manager, versionMessage)
+	persisted := delta
+	if dryRun {
+		// the inserted rules are rolled back, so their generated IDs and UIDs are not returned
+		persisted = delta.CopyNew()
+	}
+	if err := service.persistDelta(ctx, user, persisted, manager, versionMessage); err != nil {
+		return nil, err
+	}
+	return delta, nil
 }
 
 func (service *AlertRuleService) ReplaceRuleGroups(ctx context.Context, user identity.Requester, groups []*models.AlertRuleGroup, manager utils.ManagerProperties, versionMessage string) error {
@@ -926,10 +957,14 @@ func (service *AlertRuleService) persistDelta(ctx context.Context, user identity
 			if err != nil {
 				return fmt.Errorf("failed to insert alert rules: %w", err)
 			}
-			for _, key := range uids {
+			for i, key := range uids {
 				if err := service.provenanceStore.SetManagerProperties(ctx, &models.AlertRule{UID: key.UID}, user.GetOrgID(), manager); err != nil {
 					return err
 				}
+				if i < len(delta.New) {
+					delta.New[i].ID = key.ID
+					delta.New[i].UID = key.UID
+				}
 			}
 			if err := service.checkLimitsTransactionCtx(ctx, user); err != nil {
 				return err
@@ -753,6 +753,33 @@ func TestIntegrationAlertRuleService(t *testing.T) {
 		require.ErrorIs(t, err, models.ErrQuotaReached)
 	})
 
+	t.Run("quota met causes group write dry run to be rejected", func(t *testing.T) {
+		ruleService := createAlertRuleService(t, nil)
+		checker := &MockQuotaChecker{}
+		checker.EXPECT().LimitExceeded()
+		ruleService.quotas = checker
+
+		group := createDummyGroup("quota-reached-dry-run", orgID)
+		_, err := ruleService.DryRunReplaceRuleGroup(context.Background(), u, group, models.ProvenanceToManagerProperties(models.ProvenanceAPI))
+
+		require.ErrorIs(t, err, models.ErrQuotaReached)
+	})
+
+	t.Run("group write dry run should return the changes and roll them back", func(t *testing.T) {
+		group := createDummyGroup("group-dry-run", orgID)
+		delta, err := ruleService.DryRunReplaceRuleGroup(context.Background(), u, group, models.ProvenanceToManagerProperties(models.ProvenanceAPI))
+		require.NoError(t, err)
+		require.Len(t, delta.New, len(group.Rules))
+		for _, rule := range delta.New {
+			// the IDs and UIDs generated by the rolled back insert are not returned
+			require.Zero(t, rule.ID)
+			require.Empty(t, rule.UID)
+		}
+
+		_, err = ruleService.GetRuleGroup(context.Background(), u, "my-namespace", "group-dry-run")
+		require.ErrorIs(t, err, models.ErrAlertRuleGroupNotFound)
+	})
+
 	t.Run("alert rules created without a group should be considered NoGroup rules", func(t *testing.T) {
 		rule := createNoGroupRule("test-no-group-rule", orgID, "my-namespace")
 		// This is the way legacy storage creates rules without a group
@@ -17,6 +17,7 @@ const (
 	BackendTypeAnnotations BackendType = "annotations"
 	BackendTypeLoki        BackendType = "loki"
 	BackendTypeMultiple    BackendType = "multiple"
+	BackendTypeParquet     BackendType = "parquet"
 	BackendTypePrometheus  BackendType = "prometheus"
 	BackendTypeNoop        BackendType = "noop"
 )
@@ -28,6 +29,7 @@ func ParseBackendType(s string) (BackendType, error) {
 		BackendTypeAnnotations: {},
 		BackendTypeLoki:        {},
 		BackendTypeMultiple:    {},
+		BackendTypeParquet:     {},
 		BackendTypePrometheus:  {},
 		BackendTypeNoop:        {},
 	}
@@ -566,13 +566,19 @@ func queryHasLogFilters(query models.HistoryQuery) bool {
 }
 
 func (h *RemoteLokiBackend) getFolderUIDsForFilter(ctx context.Context, query models.HistoryQuery) ([]string, error) {
-	bypass, err := h.ac.CanReadAllRules(ctx, query.SignedInUser)
+	return folderUIDsForFilter(ctx, query, h.ac, h.ruleStore, h.log)
+}
+
+// folderUIDsForFilter returns the UIDs of the folders the user can read state history in.
+// It returns no UIDs when the user can read all rules, and no filtering by folder is needed.
+func folderUIDsForFilter(ctx context.Context, query models.HistoryQuery, ac AccessControl, ruleStore RuleStore, logger log.Logger) ([]string, error) {
+	bypass, err := ac.CanReadAllRules(ctx, query.SignedInUser)
 	if err != nil {
 		return nil, err
 	}
 
 	if query.RuleUID != "" {
-		return h.getFolderUIDsForRuleFilter(ctx, query, bypass)
+		return folderUIDsForRuleFilter(ctx, query, bypass, ac, ruleStore, logger)
 	}
 
 	// If the query has no rule filter, we need to return all folder UIDs the user has access to.
@@ -583,14 +589,14 @@ func (h *RemoteLokiBackend) getFolderUIDsForFilter(ctx context.Context, query mo
 	}
 
 	// All folders the user has access to.
-	folders, err := h.ruleStore.GetUserVisibleNamespaces(ctx, query.OrgID, query.SignedInUser)
+	folders, err := ruleStore.GetUserVisibleNamespaces(ctx, query.OrgID, query.SignedInUser)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch folders that user can access: %w", err)
 	}
 	uids := make([]string, 0, len(folders))
 	// Keep only UIDs of folder in which user can read rules.
 	for _, f := range folders {
-		hasAccess, err := h.ac.HasAccessInFolder(ctx, query.SignedInUser, models.NewNamespace(f))
+		hasAccess, err := ac.HasAccessInFolder(ctx, query.SignedInUser, models.NewNamespace(f))
 		if err != nil {
 			return nil, err
 		}
@@ -606,15 +612,15 @@ func (h *RemoteLokiBackend) getFolderUIDsForFilter(ctx context.Context, query mo
 	return uids, nil
 }
 
-func (h *RemoteLokiBackend) getFolderUIDsForRuleFilter(ctx context.Context, query models.HistoryQuery, canReadAll bool) ([]string, error) {
-	rule, err := h.ruleStore.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{
+func folderUIDsForRuleFilter(ctx context.Context, query models.HistoryQuery, canReadAll bool, ac AccessControl, ruleStore RuleStore, logger log.Logger) ([]string, error) {
+	rule, err := ruleStore.GetAlertRuleByUID(ctx, &models.GetAlertRuleByUIDQuery{
 		UID:   query.RuleUID,
 		OrgID: query.OrgID,
 	})
 	if err != nil {
 		if canReadAll {
 			// When the user can read all rules, filtering by folder UID is purely an optimization, so we can ignore errors here.
-			h.log.FromContext(ctx).Debug("failed to fetch alert rule by UID", "err", err)
+			logger.FromContext(ctx).Debug("failed to fetch alert rule by UID", "err", err)
 			return nil, nil
 		}
 		return nil, fmt.Errorf("failed to fetch alert rule by UID: %w", err)
@@ -624,7 +630,7 @@ func (h *RemoteLokiBackend) getFolderUIDsForRuleFilter(ctx context.Context, quer
 	// Whether we should check historical folders they might still have access to is not 100% clear, but it seems more
 	// intuitive to deny access in this case.
 	if !canReadAll {
-		if err := h.ac.AuthorizeAccessInFolder(ctx, query.SignedInUser, rule); err != nil {
+		if err := ac.AuthorizeAccessInFolder(ctx, query.SignedInUser, rule); err != nil {
 			return nil, err
 		}
 	}
@@ -632,11 +638,11 @@ func (h *RemoteLokiBackend) getFolderUIDsForRuleFilter(ctx context.Context, quer
 	// We want to return folder UIDs when possible, as it's indexed in Loki and will help with query performance.
 	// However, by just returning the current folder UID the user can lose history when a rule is moved between folders.
 	// So, we attempt to get historical folder UIDs from the rule's history.
-	historicalFolders, err := h.ruleStore.GetAlertRuleVersionFolders(ctx, rule.OrgID, rule.GUID)
+	historicalFolders, err := ruleStore.GetAlertRuleVersionFolders(ctx, rule.OrgID, rule.GUID)
 	if err != nil {
 		// Including historical folders is an edge case enhancement, better to just log the error and continue
 		// with the current folder UID.
-		h.log.FromContext(ctx).Debug("failed to include historical folder UIDs for rule", "err", err)
+		logger.FromContext(ctx).Debug("failed to include historical folder UIDs for rule", "err", err)
 	}
 
 	accessibleFolders := make([]string, 0, len(historicalFolders)+1)
@@ -656,11 +662,11 @@ func (h *RemoteLokiBackend) getFolderUIDsForRuleFilter(ctx context.Context, quer
 			continue
 		}
 
-		hasAccess, err := h.ac.HasAccessInFolder(ctx, query.SignedInUser, models.NewNamespaceUID(folderUID))
+		hasAccess, err := ac.HasAccessInFolder(ctx, query.SignedInUser, models.NewNamespaceUID(folderUID))
 		if err != nil {
 			// Including historical folders is an edge case enhancement, better to just log the error and continue
 			// with the current folder UID.
-			h.log.FromContext(ctx).Debug("failed to check access to folder", "err", err, "folderUID", folderUID)
+			logger.FromContext(ctx).Debug("failed to check access to folder", "err", err, "folderUID", folderUID)
 			continue
 		}
 		if !hasAccess {
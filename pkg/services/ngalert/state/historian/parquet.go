@@ -0,0 +1,745 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	history_model "github.com/grafana/grafana/pkg/services/ngalert/state/historian/model"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/storage/unified/parquet"
+)
+
+const (
+	parquetFilePrefix = "state-history"
+	parquetFileExt    = ".parquet"
+	// parquetMaxBufferedEntries is the number of buffered state transitions that triggers
+	// a write before the flush interval has passed.
+	parquetMaxBufferedEntries = 10000
+	// parquetBufferLimit is the number of state transitions that are kept in memory while files can't be written.
+	// The oldest state transitions are dropped when there are more.
+	parquetBufferLimit = 10 * parquetMaxBufferedEntries
+	// parquetCompactionBlock is the time range of the state transitions that are merged into a single file.
+	parquetCompactionBlock = time.Hour
+)
+
+// Name of the columns in the Parquet files.
+const (
+	pqTime         = "time"
+	pqOrgID        = "org_id"
+	pqFolderUID    = "folder_uid"
+	pqRuleUID      = "rule_uid"
+	pqDashboardUID = "dashboard_uid"
+	pqPanelID      = "panel_id"
+	pqPrevious     = "previous"
+	pqCurrent      = "current"
+	pqLabels       = "labels"
+	pqLine         = "line"
+)
+
+// The columns of the Parquet files, in the order of parquetSchema.
+const (
+	pqTimeIdx = iota
+	pqOrgIDIdx
+	pqFolderUIDIdx
+	pqRuleUIDIdx
+	pqDashboardUIDIdx
+	pqPanelIDIdx
+	pqPreviousIdx
+	pqCurrentIdx
+	pqLabelsIdx
+	pqLineIdx
+)
+
+var parquetSchema = arrow.NewSchema([]arrow.Field{
+	{Name: pqTime, Type: arrow.PrimitiveTypes.Int64},
+	{Name: pqOrgID, Type: arrow.PrimitiveTypes.Int64},
+	{Name: pqFolderUID, Type: arrow.BinaryTypes.String},
+	{Name: pqRuleUID, Type: arrow.BinaryTypes.String},
+	{Name: pqDashboardUID, Type: arrow.BinaryTypes.String},
+	{Name: pqPanelID, Type: arrow.PrimitiveTypes.Int64},
+	{Name: pqPrevious, Type: arrow.BinaryTypes.String},
+	{Name: pqCurrent, Type: arrow.BinaryTypes.String},
+	{Name: pqLabels, Type: arrow.BinaryTypes.String},
+	{Name: pqLine, Type: arrow.BinaryTypes.String},
+}, nil)
+
+type ParquetConfig struct {
+	Path           string
+	Retention      time.Duration
+	FlushInterval  time.Duration
+	ExternalLabels map[string]string
+}
+
+func NewParquetConfig(cfg setting.UnifiedAlertingStateHistorySettings) (ParquetConfig, error) {
+	if cfg.ParquetPath == "" {
+		return ParquetConfig{}, errors.New("path must not be empty")
+	}
+
+	if cfg.ParquetRetention <= 0 {
+		return ParquetConfig{}, errors.New("retention must be greater than 0")
+	}
+
+	if cfg.ParquetFlushInterval <= 0 {
+		return ParquetConfig{}, errors.New("flush interval must be greater than 0")
+	}
+
+	return ParquetConfig{
+		Path:           cfg.ParquetPath,
+		Retention:      cfg.ParquetRetention,
+		FlushInterval:  cfg.ParquetFlushInterval,
+		ExternalLabels: cfg.ExternalLabels,
+	}, nil
+}
+
+// parquetEntry is a single state transition. The line and labels are stored in the same format as in Loki,
+// and the fields that queries filter on are stored in their own columns.
+type parquetEntry struct {
+	Time         time.Time
+	OrgID        int64
+	FolderUID    string
+	RuleUID      string
+	DashboardUID string
+	PanelID      int64
+	Previous     string
+	Current      string
+	Labels       string
+	Line         string
+}
+
+// ParquetBackend is a state.Historian that records state history to Parquet files in a local directory.
+// State transitions are buffered in memory and written to a new file every flush interval.
+// The files of each hour are merged into a single file, and files that only contain state transitions
+// older than the retention are deleted.
+type ParquetBackend struct {
+	cfg     ParquetConfig
+	clock   clock.Clock
+	metrics *metrics.Historian
+	log     log.Logger
+	ac      AccessControl
+	rules   RuleStore
+
+	// flushCh requests Run to write the buffer before the flush interval has passed.
+	flushCh chan struct{}
+	// flushMtx serializes writing and deleting files.
+	flushMtx sync.Mutex
+	// filesMtx is held for reading while queries list and read the files, and for writing while files are
+	// deleted, so that the files listed by a query are not deleted before they are read.
+	filesMtx sync.RWMutex
+	// mtx guards the buffered state transitions. The state transitions being written
+	// stay visible to queries until the file is complete.
+	mtx      sync.RWMutex
+	buffer   []parquetEntry
+	flushing []parquetEntry
+}
+
+func NewParquetBackend(logger log.Logger, cfg ParquetConfig, metrics *metrics.Historian, rules RuleStore, ac AccessControl) (*ParquetBackend, error) {
+	if err := os.MkdirAll(cfg.Path, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create state history directory: %w", err)
+	}
+	logger.Info("Initializing Parquet state history backend", "path", cfg.Path, "retention", cfg.Retention)
+
+	return &ParquetBackend{
+		cfg:     cfg,
+		clock:   clock.New(),
+		metrics: metrics,
+		log:     logger,
+		ac:      ac,
+		rules:   rules,
+		flushCh: make(chan struct{}, 1),
+	}, nil
+}
+
+// Run periodically writes the buffered state transitions to a new file, compacts the files and deletes the files
+// past the retention. It is the only writer of files, so a full buffer is also written by Run.
+// The remaining state transitions are written when the context is cancelled.
+func (h *ParquetBackend) Run(ctx context.Context) error {
+	ticker := h.clock.Ticker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := h.flush(); err != nil {
+				h.log.Error("Failed to write state history file", "error", err)
+			}
+			if err := h.deleteExpired(); err != nil {
+				h.log.Error("Failed to delete expired state history files", "error", err)
+			}
+			if err := h.compact(ctx); err != nil {
+				h.log.Error("Failed to compact state history files", "error", err)
+			}
+		case <-h.flushCh:
+			if err := h.flush(); err != nil {
+				h.log.Error("Failed to write state history file", "error", err)
+			}
+		case <-ctx.Done():
+			if err := h.flush(); err != nil {
+				h.log.Error("Failed to write state history file on shutdown", "error", err)
+			}
+			return nil
+		}
+	}
+}
+
+// Record buffers a number of state transitions for a given rule. They are written to a file
+// by Run, when the buffer is full or the flush interval has passed. Failed writes are reported
+// by the metrics, as the state transitions are written asynchronously.
+func (h *ParquetBackend) Record(ctx context.Context, rule history_model.RuleMeta, states []state.StateTransition) <-chan error {
+	logger := h.log.FromContext(ctx)
+	entries := statesToParquetEntries(rule, states, h.cfg.ExternalLabels, logger)
+
+	errCh := make(chan error, 1)
+	defer close(errCh)
+	if len(entries) == 0 {
+		return errCh
+	}
+
+	org := fmt.Sprint(rule.OrgID)
+	h.metrics.TransitionsTotal.WithLabelValues(org).Add(float64(len(entries)))
+
+	h.mtx.Lock()
+	h.buffer = append(h.buffer, entries...)
+	dropped := h.limitBuffer()
+	full := len(h.buffer) >= parquetMaxBufferedEntries
+	h.mtx.Unlock()
+
+	h.reportDropped(dropped)
+	if full {
+		select {
+		case h.flushCh <- struct{}{}:
+		default:
+			// a flush is already requested
+		}
+	}
+	return errCh
+}
+
+// limitBuffer drops the oldest buffered state transitions over parquetBufferLimit, so the buffer doesn't grow
+// while files can't be written. It must be called with mtx held.
+func (h *ParquetBackend) limitBuffer() []parquetEntry {
+	over := len(h.buffer) - parquetBufferLimit
+	if over <= 0 {
+		return nil
+	}
+	dropped := slices.Clone(h.buffer[:over])
+	h.buffer = slices.Delete(h.buffer, 0, over)
+	return dropped
+}
+
+func (h *ParquetBackend) reportDropped(dropped []parquetEntry) {
+	if len(dropped) == 0 {
+		return
+	}
+	h.log.Warn("State history buffer is full, dropping the oldest state transitions", "count", len(dropped))
+	counts := make(map[int64]int)
+	for _, e := range dropped {
+		counts[e.OrgID]++
+	}
+	for orgID, count := range counts {
+		h.metrics.TransitionsFailed.WithLabelValues(fmt.Sprint(orgID)).Add(float64(count))
+	}
+}
+
+// Query reads the state history from the files and the buffer, and formats the results into a dataframe
+// in the same format as the Loki backend.
+func (h *ParquetBackend) Query(ctx context.Context, query models.HistoryQuery) (*data.Frame, error) {
+	uids, err := folderUIDsForFilter(ctx, query, h.ac, h.rules, h.log)
+	if err != nil {
+		return nil, err
+	}
+	folders := make(map[string]struct{}, len(uids))
+	for _, uid := range uids {
+		folders[uid] = struct{}{}
+	}
+
+	now := h.clock.Now().UTC()
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		query.From = now.Add(-defaultQueryRange)
+	}
+
+	match := func(e parquetEntry) bool {
+		if e.Time.Before(query.From) || e.Time.After(query.To) {
+			return false
+		}
+		if len(folders) > 0 {
+			if _, ok := folders[e.FolderUID]; !ok {
+				return false
+			}
+		}
+		return matchParquetEntry(query, e)
+	}
+
+	var res []parquetEntry
+	// The buffer is read before the files are listed so that state transitions that are written in between are not missed.
+	// They may be read twice instead, and are removed by the deduplication below.
+	h.mtx.RLock()
+	for _, entries := range [][]parquetEntry{h.flushing, h.buffer} {
+		for _, e := range entries {
+			if match(e) {
+				res = append(res, e)
+			}
+		}
+	}
+	h.mtx.RUnlock()
+
+	res, err = h.queryFiles(ctx, query, match, res)
+	if err != nil {
+		return nil, err
+	}
+
+	res = sortParquetEntries(res)
+	// Like Loki, return the most recent state transitions when there are more than the limit.
+	if query.Limit > 0 && len(res) > query.Limit {
+		res = res[len(res)-query.Limit:]
+	}
+
+	queryResult := NewQueryResultBuilder(len(res))
+	for _, e := range res {
+		queryResult.AddRowRaw(e.Time, json.RawMessage(e.Line), json.RawMessage(e.Labels))
+	}
+	return queryResult.ToFrame(), nil
+}
+
+// queryFiles appends the state transitions of the files that match the query to res.
+// The most recent files are read first, and the remaining files are skipped once they can only contain
+// state transitions older than the most recent ones within the limit.
+func (h *ParquetBackend) queryFiles(ctx context.Context, query models.HistoryQuery, match func(parquetEntry) bool, res []parquetEntry) ([]parquetEntry, error) {
+	h.filesMtx.RLock()
+	defer h.filesMtx.RUnlock()
+
+	files, err := h.listFiles()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(files, func(a, b parquetFile) int {
+		return b.maxTime.Compare(a.maxTime)
+	})
+	for _, f := range files {
+		if f.maxTime.Before(query.From) || f.minTime.After(query.To) {
+			continue
+		}
+		if query.Limit > 0 && len(res) >= query.Limit {
+			res = sortParquetEntries(res)
+			if len(res) >= query.Limit && f.maxTime.Before(res[len(res)-query.Limit].Time) {
+				break
+			}
+		}
+		entries, err := readParquetFile(ctx, f.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read state history file %s: %w", filepath.Base(f.path), err)
+		}
+		for _, e := range entries {
+			if match(e) {
+				res = append(res, e)
+			}
+		}
+	}
+	return res, nil
+}
+
+// sortParquetEntries sorts the state transitions by time, and removes the duplicates of state transitions
+// that were read from both the buffer and a file, or from files that were being compacted.
+func sortParquetEntries(entries []parquetEntry) []parquetEntry {
+	slices.SortFunc(entries, func(a, b parquetEntry) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Labels, b.Labels); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Line, b.Line)
+	})
+	return slices.CompactFunc(entries, func(a, b parquetEntry) bool {
+		return a.Time.Equal(b.Time) && a.Labels == b.Labels && a.Line == b.Line
+	})
+}
+
+// matchParquetEntry returns true if the state transition matches the filters of the query,
+// with the same semantics as the Loki query built by BuildLogQuery.
+func matchParquetEntry(query models.HistoryQuery, e parquetEntry) bool {
+	if e.OrgID != query.OrgID {
+		return false
+	}
+	if query.RuleUID != "" && e.RuleUID != query.RuleUID {
+		return false
+	}
+	if query.DashboardUID != "" && e.DashboardUID != query.DashboardUID {
+		return false
+	}
+	if query.PanelID != 0 && e.PanelID != query.PanelID {
+		return false
+	}
+	if !strings.HasPrefix(e.Previous, query.Previous) || !strings.HasPrefix(e.Current, query.Current) {
+		return false
+	}
+	if len(query.Labels) == 0 {
+		return true
+	}
+	var entry LokiEntry
+	if err := json.Unmarshal([]byte(e.Line), &entry); err != nil {
+		return false
+	}
+	for _, m := range query.Labels {
+		if !m.Matches(entry.InstanceLabels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+func statesToParquetEntries(rule history_model.RuleMeta, states []state.StateTransition, externalLabels map[string]string, logger log.Logger) []parquetEntry {
+	stream := StatesToStream(rule, states, externalLabels, logger)
+	if len(stream.Values) == 0 {
+		return nil
+	}
+	labels, err := json.Marshal(stream.Stream)
+	if err != nil {
+		logger.Error("Failed to serialize state history labels", "error", err)
+		return nil
+	}
+
+	entries := make([]parquetEntry, 0, len(stream.Values))
+	for _, sample := range stream.Values {
+		var entry LokiEntry
+		if err := json.Unmarshal([]byte(sample.V), &entry); err != nil {
+			logger.Error("Failed to read history record for state, skipping", "error", err)
+			continue
+		}
+		entries = append(entries, parquetEntry{
+			Time:         sample.T,
+			OrgID:        rule.OrgID,
+			FolderUID:    rule.NamespaceUID,
+			RuleUID:      rule.UID,
+			DashboardUID: rule.DashboardUID,
+			PanelID:      rule.PanelID,
+			Previous:     entry.Previous,
+			Current:      entry.Current,
+			Labels:       string(labels),
+			Line:         sample.V,
+		})
+	}
+	return entries
+}
+
+// flush writes the buffered state transitions to a new file.
+// If the file cannot be written, the state transitions are kept in the buffer to be retried,
+// up to parquetBufferLimit.
+func (h *ParquetBackend) flush() error {
+	h.flushMtx.Lock()
+	defer h.flushMtx.Unlock()
+
+	h.mtx.Lock()
+	h.flushing, h.buffer = h.buffer, nil
+	entries := h.flushing
+	h.mtx.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	_, err := h.writeFile(entries)
+	h.reportWrite(entries, err)
+
+	var dropped []parquetEntry
+	h.mtx.Lock()
+	if err != nil {
+		h.buffer = append(entries, h.buffer...)
+		dropped = h.limitBuffer()
+	}
+	h.flushing = nil
+	h.mtx.Unlock()
+
+	h.reportDropped(dropped)
+	return err
+}
+
+// reportWrite counts a write of state transitions in the metrics of each org.
+func (h *ParquetBackend) reportWrite(entries []parquetEntry, err error) {
+	orgs := make(map[int64]struct{})
+	for _, e := range entries {
+		orgs[e.OrgID] = struct{}{}
+	}
+	for orgID := range orgs {
+		org := fmt.Sprint(orgID)
+		h.metrics.WritesTotal.WithLabelValues(org, "parquet").Inc()
+		if err != nil {
+			h.metrics.WritesFailed.WithLabelValues(org, "parquet").Inc()
+		}
+	}
+}
+
+// writeFile writes the state transitions to a new file named after the time range of the state transitions,
+// and returns its path. The file is written under a temporary name first so that queries never read incomplete files.
+func (h *ParquetBackend) writeFile(entries []parquetEntry) (string, error) {
+	minTime, maxTime := entries[0].Time, entries[0].Time
+	for _, e := range entries[1:] {
+		if e.Time.Before(minTime) {
+			minTime = e.Time
+		}
+		if e.Time.After(maxTime) {
+			maxTime = e.Time
+		}
+	}
+	name := fmt.Sprintf("%s-%d-%d-%d%s", parquetFilePrefix, minTime.UnixNano(), maxTime.UnixNano(), h.clock.Now().UnixNano(), parquetFileExt)
+	path := filepath.Join(h.cfg.Path, name)
+	tmp := path + ".tmp"
+
+	if err := writeParquetFile(tmp, entries); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	h.log.Debug("Done saving alert state history file", "file", name, "samples", len(entries))
+	return path, nil
+}
+
+// compact merges the files of each hour into a single file, so that queries open fewer files.
+// Hours are only compacted once no more state transitions are expected to be flushed for them.
+// The merged file is written before the files are deleted, queries remove the duplicates in between.
+func (h *ParquetBackend) compact(ctx context.Context) error {
+	h.flushMtx.Lock()
+	defer h.flushMtx.Unlock()
+
+	files, err := h.listFiles()
+	if err != nil {
+		return err
+	}
+	complete := h.clock.Now().Add(-2 * h.cfg.FlushInterval)
+	blocks := make(map[time.Time][]parquetFile)
+	for _, f := range files {
+		block := f.minTime.Truncate(parquetCompactionBlock)
+		if block.Add(parquetCompactionBlock).After(complete) {
+			continue
+		}
+		blocks[block] = append(blocks[block], f)
+	}
+
+	var errs []error
+	for _, block := range blocks {
+		if len(block) < 2 {
+			continue
+		}
+		if err := h.compactFiles(ctx, block); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *ParquetBackend) compactFiles(ctx context.Context, files []parquetFile) error {
+	var entries []parquetEntry
+	for _, f := range files {
+		fileEntries, err := readParquetFile(ctx, f.path)
+		if err != nil {
+			return fmt.Errorf("failed to read state history file %s: %w", filepath.Base(f.path), err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	entries = sortParquetEntries(entries)
+	if len(entries) == 0 {
+		return nil
+	}
+
+	path, err := h.writeFile(entries)
+	if err != nil {
+		return err
+	}
+	h.filesMtx.Lock()
+	defer h.filesMtx.Unlock()
+	var errs []error
+	for _, f := range files {
+		if f.path == path {
+			// the merged file replaced a file with the same name
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	h.log.Debug("Compacted state history files", "files", len(files), "samples", len(entries))
+	return errors.Join(errs...)
+}
+
+// deleteExpired deletes the files which only contain state transitions older than the retention.
+func (h *ParquetBackend) deleteExpired() error {
+	h.flushMtx.Lock()
+	defer h.flushMtx.Unlock()
+
+	files, err := h.listFiles()
+	if err != nil {
+		return err
+	}
+	cutoff := h.clock.Now().Add(-h.cfg.Retention)
+	h.filesMtx.Lock()
+	defer h.filesMtx.Unlock()
+	var errs []error
+	for _, f := range files {
+		if !f.maxTime.Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(f.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		h.log.Debug("Deleted expired state history file", "file", filepath.Base(f.path))
+	}
+	return errors.Join(errs...)
+}
+
+type parquetFile struct {
+	path    string
+	minTime time.Time
+	maxTime time.Time
+}
+
+// listFiles returns the complete state history files in the directory.
+func (h *ParquetBackend) listFiles() ([]parquetFile, error) {
+	dirEntries, err := os.ReadDir(h.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list state history files: %w", err)
+	}
+	files := make([]parquetFile, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		f, ok := parseParquetFileName(d.Name())
+		if d.IsDir() || !ok {
+			continue
+		}
+		f.path = filepath.Join(h.cfg.Path, d.Name())
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// parseParquetFileName returns the time range of the state transitions in a file named by writeFile.
+func parseParquetFileName(name string) (parquetFile, bool) {
+	rest, ok := strings.CutPrefix(name, parquetFilePrefix+"-")
+	if !ok {
+		return parquetFile{}, false
+	}
+	rest, ok = strings.CutSuffix(rest, parquetFileExt)
+	if !ok {
+		return parquetFile{}, false
+	}
+	parts := strings.Split(rest, "-")
+	if len(parts) != 3 {
+		return parquetFile{}, false
+	}
+	minTime, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return parquetFile{}, false
+	}
+	maxTime, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return parquetFile{}, false
+	}
+	return parquetFile{
+		minTime: time.Unix(0, minTime),
+		maxTime: time.Unix(0, maxTime),
+	}, true
+}
+
+func writeParquetFile(path string, entries []parquetEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// The writer closes the file.
+	writer, err := parquet.NewRecordWriter(f, parquetSchema)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	b := writer.Builder()
+	times := b.Field(pqTimeIdx).(*array.Int64Builder)
+	orgIDs := b.Field(pqOrgIDIdx).(*array.Int64Builder)
+	folderUIDs := b.Field(pqFolderUIDIdx).(*array.StringBuilder)
+	ruleUIDs := b.Field(pqRuleUIDIdx).(*array.StringBuilder)
+	dashboardUIDs := b.Field(pqDashboardUIDIdx).(*array.StringBuilder)
+	panelIDs := b.Field(pqPanelIDIdx).(*array.Int64Builder)
+	previous := b.Field(pqPreviousIdx).(*array.StringBuilder)
+	current := b.Field(pqCurrentIdx).(*array.StringBuilder)
+	labels := b.Field(pqLabelsIdx).(*array.StringBuilder)
+	lines := b.Field(pqLineIdx).(*array.StringBuilder)
+	for _, e := range entries {
+		times.Append(e.Time.UnixNano())
+		orgIDs.Append(e.OrgID)
+		folderUIDs.Append(e.FolderUID)
+		ruleUIDs.Append(e.RuleUID)
+		dashboardUIDs.Append(e.DashboardUID)
+		panelIDs.Append(e.PanelID)
+		previous.Append(e.Previous)
+		current.Append(e.Current)
+		labels.Append(e.Labels)
+		lines.Append(e.Line)
+	}
+
+	return writer.Close()
+}
+
+func readParquetFile(ctx context.Context, path string) ([]parquetEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	var entries []parquetEntry
+	err = parquet.ReadRecords(ctx, f, parquetSchema, func(rec arrow.RecordBatch) error {
+		int64s := func(i int) *array.Int64 {
+			return rec.Column(i).(*array.Int64)
+		}
+		strs := func(i int) *array.String {
+			return rec.Column(i).(*array.String)
+		}
+		times, orgIDs, panelIDs := int64s(pqTimeIdx), int64s(pqOrgIDIdx), int64s(pqPanelIDIdx)
+		folderUIDs, ruleUIDs, dashboardUIDs := strs(pqFolderUIDIdx), strs(pqRuleUIDIdx), strs(pqDashboardUIDIdx)
+		previous, current := strs(pqPreviousIdx), strs(pqCurrentIdx)
+		labels, lines := strs(pqLabelsIdx), strs(pqLineIdx)
+
+		for i := range int(rec.NumRows()) {
+			entries = append(entries, parquetEntry{
+				Time:         time.Unix(0, times.Value(i)),
+				OrgID:        orgIDs.Value(i),
+				FolderUID:    folderUIDs.Value(i),
+				RuleUID:      ruleUIDs.Value(i),
+				DashboardUID: dashboardUIDs.Value(i),
+				PanelID:      panelIDs.Value(i),
+				Previous:     previous.Value(i),
+				Current:      current.Value(i),
+				Labels:       labels.Value(i),
+				Line:         lines.Value(i),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,342 @@
+package historian
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/log"
+	acfakes "github.com/grafana/grafana/pkg/services/ngalert/accesscontrol/fakes"
+	"github.com/grafana/grafana/pkg/services/ngalert/eval"
+	"github.com/grafana/grafana/pkg/services/ngalert/metrics"
+	"github.com/grafana/grafana/pkg/services/ngalert/models"
+	"github.com/grafana/grafana/pkg/services/ngalert/state"
+	"github.com/grafana/grafana/pkg/services/ngalert/tests/fakes"
+)
+
+func TestParquetBackend(t *testing.T) {
+	start := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	transition := func(at time.Time, current eval.State, lbls data.Labels) state.StateTransition {
+		return state.StateTransition{
+			PreviousState: eval.Normal,
+			State: &state.State{
+				State:              current,
+				Labels:             lbls,
+				LastEvaluationTime: at,
+			},
+		}
+	}
+	query := func(t *testing.T, b *ParquetBackend, q models.HistoryQuery) []LokiEntry {
+		t.Helper()
+		q.OrgID = 1
+		q.SignedInUser = &identity.StaticRequester{}
+		frame, err := b.Query(context.Background(), q)
+		require.NoError(t, err)
+		entries := make([]LokiEntry, 0, frame.Rows())
+		for i := range frame.Rows() {
+			var entry LokiEntry
+			require.NoError(t, json.Unmarshal(frame.Fields[1].At(i).(json.RawMessage), &entry))
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	t.Run("state transitions can be queried before and after they are written", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+		rule := createTestRule()
+		states := []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+			transition(start.Add(time.Minute), eval.Alerting, data.Labels{"a": "c"}),
+		}
+		clk.Set(start.Add(time.Hour))
+
+		require.NoError(t, <-b.Record(context.Background(), rule, states))
+		require.Len(t, query(t, b, models.HistoryQuery{}), 2)
+
+		require.NoError(t, b.flush())
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		frame, err := b.Query(context.Background(), models.HistoryQuery{OrgID: 1, SignedInUser: &identity.StaticRequester{}})
+		require.NoError(t, err)
+		require.Equal(t, 2, frame.Rows())
+		require.Equal(t, start, frame.Fields[0].At(0).(time.Time).UTC())
+		var streamLabels map[string]string
+		require.NoError(t, json.Unmarshal(frame.Fields[2].At(0).(json.RawMessage), &streamLabels))
+		require.Equal(t, map[string]string{
+			StateHistoryLabelKey: StateHistoryLabelValue,
+			OrgIDLabel:           "1",
+			GroupLabel:           rule.Group,
+			FolderUIDLabel:       rule.NamespaceUID,
+			"externalLabelKey":   "externalLabelValue",
+		}, streamLabels)
+		var entry LokiEntry
+		require.NoError(t, json.Unmarshal(frame.Fields[1].At(0).(json.RawMessage), &entry))
+		require.Equal(t, rule.UID, entry.RuleUID)
+		require.Equal(t, map[string]string{"a": "b"}, entry.InstanceLabels)
+	})
+
+	t.Run("query filters state transitions", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+		rule := createTestRule()
+		other := createTestRule()
+		other.UID = "other-rule-uid"
+		clk.Set(start.Add(time.Hour))
+
+		require.NoError(t, <-b.Record(context.Background(), rule, []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+			transition(start.Add(time.Minute), eval.Pending, data.Labels{"a": "c"}),
+		}))
+		require.NoError(t, b.flush())
+		require.NoError(t, <-b.Record(context.Background(), other, []state.StateTransition{
+			transition(start.Add(2*time.Minute), eval.Alerting, data.Labels{"a": "b"}),
+		}))
+
+		res := query(t, b, models.HistoryQuery{RuleUID: rule.UID})
+		require.Len(t, res, 2)
+
+		res = query(t, b, models.HistoryQuery{Current: "Alerting"})
+		require.Len(t, res, 2)
+		require.Equal(t, rule.UID, res[0].RuleUID)
+		require.Equal(t, other.UID, res[1].RuleUID)
+
+		res = query(t, b, models.HistoryQuery{Labels: labels.Matchers{mustNewMatcher(t, labels.MatchEqual, "a", "c")}})
+		require.Len(t, res, 1)
+		require.Equal(t, "Pending", res[0].Current)
+
+		res = query(t, b, models.HistoryQuery{From: start.Add(30 * time.Second), To: start.Add(90 * time.Second)})
+		require.Len(t, res, 1)
+		require.Equal(t, "Pending", res[0].Current)
+	})
+
+	t.Run("limit returns the most recent state transitions", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+		clk.Set(start.Add(time.Hour))
+
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+			transition(start.Add(time.Minute), eval.Pending, data.Labels{"a": "c"}),
+		}))
+
+		res := query(t, b, models.HistoryQuery{Limit: 1})
+		require.Len(t, res, 1)
+		require.Equal(t, "Pending", res[0].Current)
+	})
+
+	t.Run("older files are not read once the limit is reached", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+		clk.Set(start.Add(time.Hour))
+
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+		}))
+		require.NoError(t, b.flush())
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		// the oldest file can't be read, so the query fails if it is read
+		require.NoError(t, os.WriteFile(filepath.Join(b.cfg.Path, files[0].Name()), []byte("corrupt"), 0o600))
+
+		clk.Add(time.Minute)
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start.Add(2*time.Minute), eval.Alerting, data.Labels{"a": "c"}),
+			transition(start.Add(3*time.Minute), eval.Pending, data.Labels{"a": "c"}),
+		}))
+		require.NoError(t, b.flush())
+
+		res := query(t, b, models.HistoryQuery{Limit: 2})
+		require.Len(t, res, 2)
+		require.Equal(t, "Alerting", res[0].Current)
+		require.Equal(t, "Pending", res[1].Current)
+
+		_, err = b.Query(context.Background(), models.HistoryQuery{OrgID: 1, SignedInUser: &identity.StaticRequester{}, Limit: 3})
+		require.Error(t, err)
+	})
+
+	t.Run("files are not deleted while a query reads them", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+		}))
+		require.NoError(t, b.flush())
+		clk.Add(2 * b.cfg.Retention)
+
+		b.filesMtx.RLock()
+		done := make(chan error, 1)
+		go func() {
+			done <- b.deleteExpired()
+		}()
+		select {
+		case <-done:
+			t.Fatal("files were deleted while a query was reading them")
+		case <-time.After(100 * time.Millisecond):
+		}
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		b.filesMtx.RUnlock()
+		require.NoError(t, <-done)
+		files, err = os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Empty(t, files)
+	})
+
+	t.Run("files past the retention are deleted", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+		}))
+		require.NoError(t, b.flush())
+		clk.Add(30 * time.Minute)
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(clk.Now(), eval.Alerting, data.Labels{"a": "b"}),
+		}))
+		require.NoError(t, b.flush())
+
+		clk.Add(b.cfg.Retention)
+		require.NoError(t, b.deleteExpired())
+
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	})
+
+	t.Run("state transitions are written on shutdown", func(t *testing.T) {
+		b, _ := createTestParquetBackend(t, start)
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start, eval.Alerting, data.Labels{"a": "b"}),
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, b.Run(ctx))
+
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+	})
+
+	t.Run("files of an hour are compacted once the hour is complete", func(t *testing.T) {
+		b, clk := createTestParquetBackend(t, start)
+		rule := createTestRule()
+		for i := range 3 {
+			require.NoError(t, <-b.Record(context.Background(), rule, []state.StateTransition{
+				transition(start.Add(time.Duration(i)*time.Minute), eval.Alerting, data.Labels{"a": fmt.Sprint(i)}),
+			}))
+			clk.Add(time.Minute)
+			require.NoError(t, b.flush())
+		}
+		files, err := os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+
+		// the hour is not complete yet
+		require.NoError(t, b.compact(context.Background()))
+		files, err = os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 3)
+
+		clk.Set(start.Add(time.Hour + 2*b.cfg.FlushInterval))
+		require.NoError(t, b.compact(context.Background()))
+		files, err = os.ReadDir(b.cfg.Path)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		res := query(t, b, models.HistoryQuery{From: start, To: start.Add(time.Hour)})
+		require.Len(t, res, 3)
+		for i, entry := range res {
+			require.Equal(t, map[string]string{"a": fmt.Sprint(i)}, entry.InstanceLabels)
+		}
+	})
+
+	t.Run("a full buffer requests a flush", func(t *testing.T) {
+		b, _ := createTestParquetBackend(t, start)
+		states := make([]state.StateTransition, 0, parquetMaxBufferedEntries)
+		for i := range parquetMaxBufferedEntries {
+			states = append(states, transition(start, eval.Alerting, data.Labels{"a": fmt.Sprint(i)}))
+		}
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), states))
+		require.Len(t, b.flushCh, 1)
+	})
+
+	t.Run("the oldest state transitions are dropped when the buffer is over the limit", func(t *testing.T) {
+		b, _ := createTestParquetBackend(t, start)
+		b.buffer = make([]parquetEntry, parquetBufferLimit)
+		for i := range b.buffer {
+			b.buffer[i] = parquetEntry{OrgID: 1, Time: start.Add(time.Duration(i) * time.Millisecond)}
+		}
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			transition(start.Add(time.Hour), eval.Alerting, data.Labels{"a": "b"}),
+		}))
+
+		require.Len(t, b.buffer, parquetBufferLimit)
+		require.Equal(t, start.Add(time.Millisecond), b.buffer[0].Time)
+		require.Equal(t, start.Add(time.Hour), b.buffer[len(b.buffer)-1].Time)
+		require.Equal(t, 1.0, testutil.ToFloat64(b.metrics.TransitionsFailed.WithLabelValues("1")))
+	})
+
+	t.Run("normal states are not recorded", func(t *testing.T) {
+		b, _ := createTestParquetBackend(t, start)
+		require.NoError(t, <-b.Record(context.Background(), createTestRule(), []state.StateTransition{
+			{PreviousState: eval.Normal, State: &state.State{State: eval.Normal, LastEvaluationTime: start}},
+		}))
+		require.Empty(t, b.buffer)
+	})
+}
+
+func TestParseParquetFileName(t *testing.T) {
+	f, ok := parseParquetFileName("state-history-1000-2000-3000.parquet")
+	require.True(t, ok)
+	require.Equal(t, time.Unix(0, 1000), f.minTime)
+	require.Equal(t, time.Unix(0, 2000), f.maxTime)
+
+	for _, name := range []string{
+		"state-history-1000-2000-3000.parquet.tmp",
+		"state-history-1000-2000.parquet",
+		"other-1000-2000-3000.parquet",
+		"state-history-a-2000-3000.parquet",
+	} {
+		_, ok := parseParquetFileName(name)
+		require.False(t, ok, name)
+	}
+}
+
+func createTestParquetBackend(t *testing.T, now time.Time) (*ParquetBackend, *clock.Mock) {
+	t.Helper()
+	cfg := ParquetConfig{
+		Path:           t.TempDir(),
+		Retention:      time.Hour,
+		FlushInterval:  time.Minute,
+		ExternalLabels: map[string]string{"externalLabelKey": "externalLabelValue"},
+	}
+	ac := &acfakes.FakeRuleService{
+		CanReadAllRulesFunc: func(context.Context, identity.Requester) (bool, error) {
+			return true, nil
+		},
+	}
+	logger := log.New("ngalert.state.historian", "backend", "parquet")
+	met := metrics.NewHistorianMetrics(prometheus.NewRegistry(), metrics.Subsystem)
+	b, err := NewParquetBackend(logger, cfg, met, fakes.NewRuleStore(t), ac)
+	require.NoError(t, err)
+
+	clk := clock.NewMock()
+	clk.Set(now)
+	b.clock = clk
+	return b, clk
+}
@@ -38,6 +38,8 @@ const (
 
 var (
 	ErrOptimisticLock = errors.New("version conflict while updating a record in the database with optimistic locking")
+	// ErrDryRunRollback is returned from the transaction of a dry run to roll back the applied changes.
+	ErrDryRunRollback = errors.New("dry run, rolling back changes")
 )
 
 // DeleteAlertRulesByUID is a handler for deleting an alert rule.
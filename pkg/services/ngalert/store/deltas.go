@@ -49,6 +49,17 @@ func (c *GroupDelta) IsEmpty() bool {
 	return len(c.Update)+len(c.New)+len(c.Delete) == 0
 }
 
+// CopyNew returns a copy of the delta with copies of the new rules, so that the IDs and UIDs
+// that are generated when the new rules are inserted are not written to this delta.
+func (c *GroupDelta) CopyNew() *GroupDelta {
+	result := *c
+	result.New = make([]*models.AlertRule, 0, len(c.New))
+	for _, rule := range c.New {
+		result.New = append(result.New, rule.Copy())
+	}
+	return &result
+}
+
 // NewOrUpdatedNotificationSettings returns a list of notification settings that are either new or updated in the group.
 func (c *GroupDelta) NewOrUpdatedNotificationSettings() []models.NotificationSettings {
 	settings := make([]models.NotificationSettings, 0)
@@ -856,3 +856,21 @@ func groupByUID(t *testing.T, list []*models.AlertRule) map[string]*models.Alert
 	}
 	return result
 }
+
+func TestGroupDeltaCopyNew(t *testing.T) {
+	gen := models.RuleGen
+	rules := gen.With(gen.WithUniqueID()).GenerateManyRef(2)
+	delta := &GroupDelta{
+		GroupKey: models.GenerateGroupKey(1),
+		New:      rules,
+	}
+
+	copied := delta.CopyNew()
+	require.Equal(t, delta.GroupKey, copied.GroupKey)
+	require.Equal(t, delta.New, copied.New)
+
+	copied.New[0].ID = -1
+	copied.New[0].UID = "generated"
+	require.NotEqual(t, int64(-1), delta.New[0].ID)
+	require.NotEqual(t, "generated", delta.New[0].UID)
+}
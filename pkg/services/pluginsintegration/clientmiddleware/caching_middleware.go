@@ -31,13 +31,14 @@ type CachingMiddleware struct {
 
 // QueryData receives a data request and attempts to access results already stored in the cache for that request.
 // If data is found, it will return it immediately. Otherwise, it will perform the queries as usual, then write the response to the cache.
+// If only part of the data is found, the queries are only performed for the missing part.
 // If the cache service is implemented, we capture the request duration as a metric. The service is expected to write any response headers.
 func (m *CachingMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 	reqCtx := contexthandler.FromContext(ctx)
 	if reqCtx == nil {
 		return m.BaseHandler.QueryData(ctx, req)
 	}
-	return m.cachingServiceClient.WithQueryDataCaching(ctx, req, func() (*backend.QueryDataResponse, error) {
+	return m.cachingServiceClient.WithQueryDataCaching(ctx, req, func(req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
 		return m.BaseHandler.QueryData(ctx, req)
 	})
 }
@@ -0,0 +1,176 @@
+package clientmiddleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
+)
+
+// NewQueryPolicyMiddleware creates a new backend.HandlerMiddleware that enforces
+// the data source query policies on every request to a data source, whichever
+// API, expression or alert rule it comes from. Queries can be denied or
+// rewritten, and the requests that can't be checked, like resource calls and
+// streams, are denied for the data sources the policies restrict.
+func NewQueryPolicyMiddleware(dsGuardian guardian.DatasourceGuardianProvider) backend.HandlerMiddleware {
+	return backend.HandlerMiddlewareFunc(func(next backend.Handler) backend.Handler {
+		return &QueryPolicyMiddleware{
+			BaseHandler: backend.NewBaseHandler(next),
+			dsGuardian:  dsGuardian,
+		}
+	})
+}
+
+type QueryPolicyMiddleware struct {
+	backend.BaseHandler
+	dsGuardian guardian.DatasourceGuardianProvider
+}
+
+// datasourceGuardian returns the guardian of the data source of the request, or nil if
+// the request isn't for a data source.
+func (m *QueryPolicyMiddleware) datasourceGuardian(ctx context.Context, pCtx backend.PluginContext) (guardian.DatasourceGuardian, *datasources.DataSource) {
+	settings := pCtx.DataSourceInstanceSettings
+	if m.dsGuardian == nil || settings == nil {
+		return nil, nil
+	}
+	ds := &datasources.DataSource{
+		ID:       settings.ID,
+		UID:      settings.UID,
+		OrgID:    pCtx.OrgID,
+		Name:     settings.Name,
+		Type:     pCtx.PluginID,
+		Database: settings.Database,
+	}
+	if len(settings.JSONData) > 0 {
+		if jsonData, err := simplejson.NewJson(settings.JSONData); err == nil {
+			ds.JsonData = jsonData
+		}
+	}
+	// Requests without user, like alert rule evaluations, are only subject to
+	// the policies without teams. Alert rules are checked against the policies
+	// of the user saving them.
+	user, _ := identity.GetRequester(ctx)
+	g := m.dsGuardian.New(pCtx.OrgID, user, *ds)
+	if _, ok := g.(*guardian.AllowGuardian); ok {
+		return nil, nil
+	}
+	return g, ds
+}
+
+func (m *QueryPolicyMiddleware) QueryData(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+	if req == nil {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+	g, ds := m.datasourceGuardian(ctx, req.PluginContext)
+	if g == nil {
+		return m.BaseHandler.QueryData(ctx, req)
+	}
+
+	denied := backend.Responses{}
+	allowed := make([]backend.DataQuery, 0, len(req.Queries))
+	for _, q := range req.Queries {
+		query, err := simplejson.NewJson(q.JSON)
+		if err != nil {
+			denied[q.RefID] = backend.ErrDataResponse(backend.StatusBadRequest, "failed to parse query")
+			continue
+		}
+		if query.Get("refId").MustString() == "" {
+			query.Set("refId", q.RefID)
+		}
+		if err := g.CheckQuery(ctx, ds, query); err != nil {
+			denied[q.RefID] = policyErrorResponse(err)
+			continue
+		}
+		if q.JSON, err = query.MarshalJSON(); err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, q)
+	}
+	// The allowed queries can have been rewritten.
+	r := *req
+	r.Queries = allowed
+	if len(denied) == 0 {
+		return m.BaseHandler.QueryData(ctx, &r)
+	}
+
+	rsp := &backend.QueryDataResponse{Responses: denied}
+	if len(allowed) > 0 {
+		allowedRsp, err := m.BaseHandler.QueryData(ctx, &r)
+		if err != nil {
+			return nil, err
+		}
+		if allowedRsp != nil {
+			for refID, dr := range allowedRsp.Responses {
+				rsp.Responses[refID] = dr
+			}
+		}
+	}
+	return rsp, nil
+}
+
+func (m *QueryPolicyMiddleware) CallResource(ctx context.Context, req *backend.CallResourceRequest, sender backend.CallResourceResponseSender) error {
+	if req == nil {
+		return m.BaseHandler.CallResource(ctx, req, sender)
+	}
+	if g, ds := m.datasourceGuardian(ctx, req.PluginContext); g != nil {
+		if err := g.CheckDatasourceRequest(ctx, ds); err != nil {
+			body, err := simplejson.NewFromAny(map[string]any{"message": publicMessage(err)}).MarshalJSON()
+			if err != nil {
+				return err
+			}
+			return sender.Send(&backend.CallResourceResponse{
+				Status:  http.StatusForbidden,
+				Headers: map[string][]string{"Content-Type": {"application/json"}},
+				Body:    body,
+			})
+		}
+	}
+	return m.BaseHandler.CallResource(ctx, req, sender)
+}
+
+func (m *QueryPolicyMiddleware) SubscribeStream(ctx context.Context, req *backend.SubscribeStreamRequest) (*backend.SubscribeStreamResponse, error) {
+	if req == nil {
+		return m.BaseHandler.SubscribeStream(ctx, req)
+	}
+	if g, ds := m.datasourceGuardian(ctx, req.PluginContext); g != nil {
+		if err := g.CheckDatasourceRequest(ctx, ds); err != nil {
+			return &backend.SubscribeStreamResponse{Status: backend.SubscribeStreamStatusPermissionDenied}, nil
+		}
+	}
+	return m.BaseHandler.SubscribeStream(ctx, req)
+}
+
+func (m *QueryPolicyMiddleware) PublishStream(ctx context.Context, req *backend.PublishStreamRequest) (*backend.PublishStreamResponse, error) {
+	if req == nil {
+		return m.BaseHandler.PublishStream(ctx, req)
+	}
+	if g, ds := m.datasourceGuardian(ctx, req.PluginContext); g != nil {
+		if err := g.CheckDatasourceRequest(ctx, ds); err != nil {
+			return &backend.PublishStreamResponse{Status: backend.PublishStreamStatusPermissionDenied}, nil
+		}
+	}
+	return m.BaseHandler.PublishStream(ctx, req)
+}
+
+func policyErrorResponse(err error) backend.DataResponse {
+	return backend.DataResponse{
+		Error:  errors.New(publicMessage(err)),
+		Status: backend.StatusForbidden,
+	}
+}
+
+// publicMessage returns the message of the error that can be shown to the user.
+func publicMessage(err error) string {
+	var gfErr errutil.Error
+	if errors.As(err, &gfErr) {
+		return gfErr.Public().Message
+	}
+	return "Access denied by the data source query policies"
+}
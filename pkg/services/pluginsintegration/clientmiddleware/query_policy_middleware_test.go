@@ -0,0 +1,120 @@
+package clientmiddleware
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/services/datasources"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+func TestQueryPolicyMiddleware(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+policies:
+  - name: team-a
+    teamIds: [2]
+    action: rewrite
+    labelMatchers: ['tenant="a"']
+    allowedMetrics: ['up']
+  - name: shared
+    datasourceUids: [shared]
+    allowedMetrics: ['up']
+`), 0o600))
+	cfg := setting.NewCfg()
+	cfg.DataSourceQueryPoliciesFile = path
+	dsGuardian, err := guardian.ProvideGuardian(cfg)
+	require.NoError(t, err)
+
+	prom := backend.PluginContext{
+		OrgID:                      1,
+		PluginID:                   datasources.DS_PROMETHEUS,
+		DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "prom"},
+	}
+	teamA := identity.WithRequester(context.Background(), &user.SignedInUser{OrgID: 1, TeamIDs: []int64{2}})
+	otherTeam := identity.WithRequester(context.Background(), &user.SignedInUser{OrgID: 1, TeamIDs: []int64{3}})
+
+	t.Run("QueryData rewrites and denies queries", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		rsp, err := cdt.MiddlewareHandler.QueryData(teamA, &backend.QueryDataRequest{
+			PluginContext: prom,
+			Queries: []backend.DataQuery{
+				{RefID: "A", JSON: []byte(`{"expr": "up"}`)},
+				{RefID: "B", JSON: []byte(`{"expr": "secret"}`)},
+			},
+		})
+		require.NoError(t, err)
+		require.Len(t, cdt.QueryDataReq.Queries, 1)
+		require.JSONEq(t, `{"refId": "A", "expr": "up{tenant=\"a\"}"}`, string(cdt.QueryDataReq.Queries[0].JSON))
+		require.Equal(t, backend.StatusForbidden, rsp.Responses["B"].Status)
+		require.ErrorContains(t, rsp.Responses["B"].Error, "not allowed by the data source query policies")
+	})
+
+	t.Run("QueryData of requests without user is subject to the policies without teams", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		shared := prom
+		shared.DataSourceInstanceSettings = &backend.DataSourceInstanceSettings{UID: "shared"}
+		rsp, err := cdt.MiddlewareHandler.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: shared,
+			Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(`{"expr": "secret"}`)}},
+		})
+		require.NoError(t, err)
+		require.Nil(t, cdt.QueryDataReq)
+		require.Equal(t, backend.StatusForbidden, rsp.Responses["A"].Status)
+	})
+
+	t.Run("QueryData of users without policies is not changed", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		_, err := cdt.MiddlewareHandler.QueryData(otherTeam, &backend.QueryDataRequest{
+			PluginContext: prom,
+			Queries:       []backend.DataQuery{{RefID: "A", JSON: []byte(`{"expr": "secret"}`)}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, `{"expr": "secret"}`, string(cdt.QueryDataReq.Queries[0].JSON))
+	})
+
+	t.Run("CallResource is denied for restricted data sources", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		var sent *backend.CallResourceResponse
+		err := cdt.MiddlewareHandler.CallResource(teamA, &backend.CallResourceRequest{
+			PluginContext: prom,
+			Path:          "api/v1/label/__name__/values",
+		}, backend.CallResourceResponseSenderFunc(func(res *backend.CallResourceResponse) error {
+			sent = res
+			return nil
+		}))
+		require.NoError(t, err)
+		require.Nil(t, cdt.CallResourceReq)
+		require.Equal(t, http.StatusForbidden, sent.Status)
+	})
+
+	t.Run("CallResource is allowed for other data sources", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		loki := backend.PluginContext{
+			OrgID:                      1,
+			PluginID:                   datasources.DS_LOKI,
+			DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{UID: "loki"},
+		}
+		err := cdt.MiddlewareHandler.CallResource(teamA, &backend.CallResourceRequest{PluginContext: loki}, nopCallResourceSender)
+		require.NoError(t, err)
+		require.NotNil(t, cdt.CallResourceReq)
+	})
+
+	t.Run("SubscribeStream is denied for restricted data sources", func(t *testing.T) {
+		cdt := handlertest.NewHandlerMiddlewareTest(t, handlertest.WithMiddlewares(NewQueryPolicyMiddleware(dsGuardian)))
+		rsp, err := cdt.MiddlewareHandler.SubscribeStream(teamA, &backend.SubscribeStreamRequest{PluginContext: prom, Path: "tail"})
+		require.NoError(t, err)
+		require.Nil(t, cdt.SubscribeStreamReq)
+		require.Equal(t, backend.SubscribeStreamStatusPermissionDenied, rsp.Status)
+	})
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/grafana/grafana/pkg/plugins/pluginscdn"
 	"github.com/grafana/grafana/pkg/plugins/repo"
 	"github.com/grafana/grafana/pkg/services/caching"
+	"github.com/grafana/grafana/pkg/services/datasources/guardian"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
 	"github.com/grafana/grafana/pkg/services/oauthtoken"
 	"github.com/grafana/grafana/pkg/services/pluginsintegration/advisor"
@@ -169,22 +170,23 @@ func ProvideClientWithMiddlewares(
 	cachingServiceClient *caching.CachingServiceClient,
 	features featuremgmt.FeatureToggles,
 	promRegisterer prometheus.Registerer,
+	dsGuardian guardian.DatasourceGuardianProvider,
 ) (*backend.MiddlewareHandler, error) {
-	return NewMiddlewareHandler(cfg, pluginRegistry, oAuthTokenService, tracer, cachingServiceClient, features, promRegisterer, pluginRegistry)
+	return NewMiddlewareHandler(cfg, pluginRegistry, oAuthTokenService, tracer, cachingServiceClient, features, promRegisterer, pluginRegistry, dsGuardian)
 }
 
 func NewMiddlewareHandler(
 	cfg *setting.Cfg,
 	pluginRegistry registry.Service, oAuthTokenService oauthtoken.OAuthTokenService,
 	tracer tracing.Tracer, cachingServiceClient *caching.CachingServiceClient, features featuremgmt.FeatureToggles,
-	promRegisterer prometheus.Registerer, registry registry.Service,
+	promRegisterer prometheus.Registerer, registry registry.Service, dsGuardian guardian.DatasourceGuardianProvider,
 ) (*backend.MiddlewareHandler, error) {
 	c := client.ProvideService(pluginRegistry)
-	middlewares := CreateMiddlewares(cfg, oAuthTokenService, tracer, cachingServiceClient, features, promRegisterer, registry)
+	middlewares := CreateMiddlewares(cfg, oAuthTokenService, tracer, cachingServiceClient, features, promRegisterer, registry, dsGuardian)
 	return backend.HandlerFromMiddlewares(c, middlewares...)
 }
 
-func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, tracer tracing.Tracer, cachingServiceClient *caching.CachingServiceClient, features featuremgmt.FeatureToggles, promRegisterer prometheus.Registerer, registry registry.Service) []backend.HandlerMiddleware {
+func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthTokenService, tracer tracing.Tracer, cachingServiceClient *caching.CachingServiceClient, features featuremgmt.FeatureToggles, promRegisterer prometheus.Registerer, registry registry.Service, dsGuardian guardian.DatasourceGuardianProvider) []backend.HandlerMiddleware {
 	middlewares := []backend.HandlerMiddleware{
 		clientmiddleware.NewTracingMiddleware(tracer),
 		clientmiddleware.NewMetricsMiddleware(promRegisterer, registry),
@@ -202,7 +204,8 @@ func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthToken
 		clientmiddleware.NewClearAuthHeadersMiddleware(&cfg.JWTAuth, &cfg.AuthProxy),
 		clientmiddleware.NewOAuthTokenMiddleware(oAuthTokenService),
 		clientmiddleware.NewCookiesMiddleware(skipCookiesNames),
-		clientmiddleware.NewCachingMiddleware(cachingServiceClient),
+		// The query policies are enforced before the cache is looked up, as they can rewrite queries.
+		clientmiddleware.NewQueryPolicyMiddleware(dsGuardian),
 		clientmiddleware.NewForwardIDMiddleware(),
 		clientmiddleware.NewUseAlertHeadersMiddleware(),
 	)
@@ -211,6 +214,10 @@ func CreateMiddlewares(cfg *setting.Cfg, oAuthTokenService oauthtoken.OAuthToken
 		middlewares = append(middlewares, clientmiddleware.NewUserHeaderMiddleware())
 	}
 
+	// The cache is looked up once all the headers that identify the user are set, so that
+	// the responses of the data sources that receive them are cached per user.
+	middlewares = append(middlewares, clientmiddleware.NewCachingMiddleware(cachingServiceClient))
+
 	if cfg.IPRangeACEnabled {
 		middlewares = append(middlewares, clientmiddleware.NewHostedGrafanaACHeaderMiddleware(cfg))
 	}
@@ -0,0 +1,96 @@
+package pluginsintegration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/backend/handlertest"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/infra/tracing"
+	"github.com/grafana/grafana/pkg/plugins/manager/registry"
+	"github.com/grafana/grafana/pkg/services/caching"
+	"github.com/grafana/grafana/pkg/services/contexthandler/ctxkey"
+	contextmodel "github.com/grafana/grafana/pkg/services/contexthandler/model"
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/oauthtoken/oauthtokentest"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+	"github.com/grafana/grafana/pkg/util/proxyutil"
+	"github.com/grafana/grafana/pkg/web"
+)
+
+func TestCreateMiddlewares_QueryCaching(t *testing.T) {
+	cfg := setting.NewCfg()
+	cfg.SendUserHeader = true
+	cfg.QueryCaching = setting.QueryCachingSettings{
+		Enabled:  true,
+		Backend:  setting.QueryCachingBackendMemory,
+		TTL:      time.Minute,
+		MaxItems: 10,
+	}
+	cachingServiceClient := caching.ProvideCachingServiceClient(caching.ProvideCachingService(cfg), nil)
+	middlewares := CreateMiddlewares(cfg, &oauthtokentest.Service{}, tracing.InitializeTracerForTest(), cachingServiceClient, featuremgmt.WithFeatures(), prometheus.NewRegistry(), registry.NewInMemory(), nil)
+
+	queried := make([]string, 0)
+	handler, err := backend.HandlerFromMiddlewares(handlertest.Handler{
+		QueryDataFunc: func(ctx context.Context, req *backend.QueryDataRequest) (*backend.QueryDataResponse, error) {
+			login := req.GetHTTPHeader(proxyutil.UserHeaderName)
+			queried = append(queried, login)
+			return &backend.QueryDataResponse{Responses: backend.Responses{
+				"A": {Frames: data.Frames{data.NewFrame(login)}},
+			}}, nil
+		},
+	}, middlewares...)
+	require.NoError(t, err)
+
+	now := time.Now()
+	query := func(t *testing.T, login string) *backend.QueryDataResponse {
+		t.Helper()
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/ds/query", nil)
+		reqCtx := &contextmodel.ReqContext{
+			Context: &web.Context{
+				Req:  httpReq,
+				Resp: web.NewResponseWriter(httpReq.Method, httptest.NewRecorder()),
+			},
+			SignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1, Login: login},
+		}
+		ctx := ctxkey.Set(httpReq.Context(), reqCtx)
+		resp, err := handler.QueryData(ctx, &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{
+				OrgID:    1,
+				PluginID: "prometheus",
+				User:     &backend.User{Login: login},
+				DataSourceInstanceSettings: &backend.DataSourceInstanceSettings{
+					UID:      "ds",
+					JSONData: []byte("{}"),
+				},
+			},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				TimeRange: backend.TimeRange{From: now.Add(-time.Hour), To: now},
+				JSON:      []byte(`{"expr":"up"}`),
+			}},
+		})
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := query(t, "alice")
+	require.Equal(t, "alice", resp.Responses["A"].Frames[0].Name)
+
+	// the response of the data source that receives the user header is not shared with other users
+	resp = query(t, "bob")
+	require.Equal(t, "bob", resp.Responses["A"].Frames[0].Name)
+	require.Equal(t, []string{"alice", "bob"}, queried)
+
+	resp = query(t, "alice")
+	require.Equal(t, "alice", resp.Responses["A"].Frames[0].Name)
+	require.Equal(t, []string{"alice", "bob"}, queried)
+}
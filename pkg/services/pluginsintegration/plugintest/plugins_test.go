@@ -137,7 +137,7 @@ func TestIntegrationPluginManager(t *testing.T) {
 	cw := cloudwatch.ProvideService()
 	grap := graphite.ProvideService(hcp, tracer)
 	td := testdatasource.ProvideService()
-	graf := grafanads.ProvideService(nil, features)
+	graf := grafanads.ProvideService(nil, features, nil)
 	coreRegistry := coreplugin.ProvideCoreRegistry(tracing.InitializeTracerForTest(), am, cw, grap, td, graf)
 
 	testCtx := pluginsintegration.CreateIntegrationTestCtx(t, cfg, coreRegistry)
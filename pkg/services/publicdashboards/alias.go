@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/service"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/validation"
 	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
 	"github.com/grafana/grafana/pkg/setting"
 )
 
@@ -91,8 +92,10 @@ func ProvideService(
 	serviceWrapper ServiceWrapper,
 	dashboardService dashboards.DashboardService,
 	license licensing.Licensing,
+	userService user.Service,
+	acService accesscontrol.Service,
 ) Service {
-	return service.ProvideService(cfg, features, store, qds, anno, ac, serviceWrapper, dashboardService, license)
+	return service.ProvideService(cfg, features, store, qds, anno, ac, serviceWrapper, dashboardService, license, userService, acService)
 }
 
 func ProvideServiceWrapper(store Store) ServiceWrapper {
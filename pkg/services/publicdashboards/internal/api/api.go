@@ -105,6 +105,11 @@ func (api *Api) RegisterAPIEndpoints() {
 	api.routeRegister.Delete("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid",
 		auth(accesscontrol.EvalPermission(publicdashboards.ActionDashboardsPublicWrite, uidScope)),
 		routing.Wrap(api.DeletePublicDashboard))
+
+	// Rotate Public Dashboard access token
+	api.routeRegister.Post("/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/rotate-access-token",
+		auth(accesscontrol.EvalPermission(publicdashboards.ActionDashboardsPublicWrite, uidScope)),
+		routing.Wrap(api.RotatePublicDashboardAccessToken))
 }
 
 // swagger:route GET /dashboards/public-dashboards dashboards dashboard_public listPublicDashboards
@@ -306,6 +311,39 @@ func (api *Api) DeletePublicDashboard(c *contextmodel.ReqContext) response.Respo
 	return response.Empty(http.StatusOK)
 }
 
+// swagger:route POST /dashboards/uid/{dashboardUid}/public-dashboards/{uid}/rotate-access-token dashboards dashboard_public rotatePublicDashboardAccessToken
+//
+//	Rotate the access token of a public dashboard. The previous access token stops working.
+//
+// Produces:
+// - application/json
+//
+// Responses:
+// 200: rotatePublicDashboardAccessTokenResponse
+// 400: badRequestPublicError
+// 401: unauthorisedPublicError
+// 403: forbiddenPublicError
+// 404: notFoundPublicError
+// 500: internalServerPublicError
+func (api *Api) RotatePublicDashboardAccessToken(c *contextmodel.ReqContext) response.Response {
+	dashboardUid := web.Params(c.Req)[":dashboardUid"]
+	if !validation.IsValidShortUID(dashboardUid) {
+		return response.Err(models.ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid dashboard Uid %s", dashboardUid))
+	}
+
+	uid := web.Params(c.Req)[":uid"]
+	if !validation.IsValidShortUID(uid) {
+		return response.Err(models.ErrInvalidUid.Errorf("RotatePublicDashboardAccessToken: invalid Uid %s", uid))
+	}
+
+	pd, err := api.PublicDashboardService.RotateAccessToken(c.Req.Context(), c.SignedInUser, dashboardUid, uid)
+	if err != nil {
+		return response.Err(err)
+	}
+
+	return response.JSON(http.StatusOK, pd)
+}
+
 // Copied from pkg/api/metrics.go
 func toJsonStreamingResponse(ctx context.Context, features featuremgmt.FeatureToggles, qdr *backend.QueryDataResponse) response.Response {
 	statusCode := http.StatusOK
@@ -381,3 +419,19 @@ type DeletePublicDashboardParams struct {
 	// required:true
 	Uid string `json:"uid"`
 }
+
+// swagger:parameters rotatePublicDashboardAccessToken
+type RotatePublicDashboardAccessTokenParams struct {
+	// in:path
+	// required:true
+	DashboardUid string `json:"dashboardUid"`
+	// in:path
+	// required:true
+	Uid string `json:"uid"`
+}
+
+// swagger:response rotatePublicDashboardAccessTokenResponse
+type RotatePublicDashboardAccessTokenResponse struct {
+	// in: body
+	Body models.PublicDashboard `json:"body"`
+}
@@ -280,6 +280,90 @@ func TestAPIDeletePublicDashboard(t *testing.T) {
 	}
 }
 
+func TestAPIRotatePublicDashboardAccessToken(t *testing.T) {
+	dashboardUid := "abc1234"
+	publicDashboardUid := "1234asdfasdf"
+	userEditorPublicDashboard := &user.SignedInUser{UserID: 4, OrgID: 1, OrgRole: org.RoleEditor, Login: "testEditorUser", Permissions: map[int64]map[string][]string{1: {publicdashboards.ActionDashboardsPublicWrite: {fmt.Sprintf("dashboards:uid:%s", dashboardUid)}}}}
+	userEditorAnotherPublicDashboard := &user.SignedInUser{UserID: 4, OrgID: 1, OrgRole: org.RoleEditor, Login: "testEditorUser", Permissions: map[int64]map[string][]string{1: {publicdashboards.ActionDashboardsPublicWrite: {"another-uid"}}}}
+
+	testCases := []struct {
+		Name                 string
+		User                 *user.SignedInUser
+		PublicDashboardUid   string
+		ResponseErr          error
+		ExpectedHttpResponse int
+		ShouldCallService    bool
+	}{
+		{
+			Name:                 "User viewer cannot rotate the access token",
+			User:                 userViewer,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusForbidden,
+			ShouldCallService:    false,
+		},
+		{
+			Name:                 "User editor without specific dashboard access cannot rotate the access token",
+			User:                 userEditorAnotherPublicDashboard,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusForbidden,
+			ShouldCallService:    false,
+		},
+		{
+			Name:                 "User editor with dashboard access can rotate the access token",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   publicDashboardUid,
+			ExpectedHttpResponse: http.StatusOK,
+			ShouldCallService:    true,
+		},
+		{
+			Name:                 "Invalid publicDashboardUid throws an error",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   "inv@lid-publicd@shboard-uid!",
+			ExpectedHttpResponse: http.StatusBadRequest,
+			ShouldCallService:    false,
+		},
+		{
+			Name:                 "Public dashboard uid does not exist",
+			User:                 userEditorPublicDashboard,
+			PublicDashboardUid:   "UIDDOESNOTEXIST",
+			ResponseErr:          models.ErrPublicDashboardNotFound.Errorf(""),
+			ExpectedHttpResponse: http.StatusNotFound,
+			ShouldCallService:    true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.Name, func(t *testing.T) {
+			service := publicdashboards.NewFakePublicDashboardService(t)
+
+			pubdash := &models.PublicDashboard{Uid: test.PublicDashboardUid, DashboardUid: dashboardUid, AccessToken: "newAccessToken"}
+			if test.ResponseErr != nil {
+				pubdash = nil
+			}
+			if test.ShouldCallService {
+				service.On("RotateAccessToken", mock.Anything, mock.Anything, dashboardUid, test.PublicDashboardUid).
+					Return(pubdash, test.ResponseErr)
+			}
+
+			testServer := setupTestServer(t, nil, service, test.User)
+
+			response := callAPI(testServer, http.MethodPost, fmt.Sprintf("/api/dashboards/uid/%s/public-dashboards/%s/rotate-access-token", dashboardUid, test.PublicDashboardUid), nil, t)
+			assert.Equal(t, test.ExpectedHttpResponse, response.Code)
+
+			if test.ExpectedHttpResponse == http.StatusOK {
+				var rotated models.PublicDashboard
+				err := json.Unmarshal(response.Body.Bytes(), &rotated)
+				require.NoError(t, err)
+				assert.Equal(t, "newAccessToken", rotated.AccessToken)
+			}
+
+			if !test.ShouldCallService {
+				service.AssertNotCalled(t, "RotateAccessToken")
+			}
+		})
+	}
+}
+
 func TestAPIGetPublicDashboard(t *testing.T) {
 	pubdash := &models.PublicDashboard{IsEnabled: true}
 
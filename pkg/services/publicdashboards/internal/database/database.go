@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/grafana/grafana/pkg/infra/db"
 	"github.com/grafana/grafana/pkg/infra/log"
@@ -53,7 +54,7 @@ func (d *PublicDashboardStoreImpl) FindAll(ctx context.Context, query *models.Pu
 	}
 
 	pubdashBuilder := db.NewSqlBuilder(d.cfg, d.features, d.sqlStore.GetDialect(), recursiveQueriesAreSupported)
-	pubdashBuilder.Write("SELECT uid, access_token, dashboard_uid, is_enabled")
+	pubdashBuilder.Write("SELECT uid, access_token, dashboard_uid, is_enabled, expires_at, rate_limit")
 	pubdashBuilder.Write(" FROM dashboard_public")
 	pubdashBuilder.Write(` WHERE org_id = ?`, query.OrgID)
 
@@ -260,12 +261,42 @@ func (d *PublicDashboardStoreImpl) Update(ctx context.Context, cmd models.SavePu
 			return err
 		}
 
-		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, annotations_enabled = ?, time_selection_enabled = ?, share = ?, time_settings = ?, updated_by = ?, updated_at = ? WHERE uid = ? AND org_id = ?",
+		var expiresAt any
+		if cmd.PublicDashboard.ExpiresAt != nil {
+			expiresAt = cmd.PublicDashboard.ExpiresAt.UTC()
+		}
+
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, annotations_enabled = ?, time_selection_enabled = ?, share = ?, time_settings = ?, expires_at = ?, rate_limit = ?, updated_by = ?, updated_at = ? WHERE uid = ? AND org_id = ?",
 			cmd.PublicDashboard.IsEnabled,
 			cmd.PublicDashboard.AnnotationsEnabled,
 			cmd.PublicDashboard.TimeSelectionEnabled,
 			cmd.PublicDashboard.Share,
 			string(timeSettingsJSON),
+			expiresAt,
+			cmd.PublicDashboard.RateLimit,
+			cmd.PublicDashboard.UpdatedBy,
+			cmd.PublicDashboard.UpdatedAt.UTC(),
+			cmd.PublicDashboard.Uid,
+			cmd.PublicDashboard.OrgId)
+
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// RotateAccessToken replaces the access token of an existing public dashboard
+func (d *PublicDashboardStoreImpl) RotateAccessToken(ctx context.Context, cmd models.SavePublicDashboardCommand) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET access_token = ?, updated_by = ?, updated_at = ? WHERE uid = ? AND org_id = ?",
+			cmd.PublicDashboard.AccessToken,
 			cmd.PublicDashboard.UpdatedBy,
 			cmd.PublicDashboard.UpdatedAt.UTC(),
 			cmd.PublicDashboard.Uid,
@@ -283,6 +314,49 @@ func (d *PublicDashboardStoreImpl) Update(ctx context.Context, cmd models.SavePu
 	return affectedRows, err
 }
 
+// DisableExpired disables the enabled public dashboards expired at the given time
+func (d *PublicDashboardStoreImpl) DisableExpired(ctx context.Context, now time.Time) (int64, error) {
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		sqlResult, err := sess.Exec("UPDATE dashboard_public SET is_enabled = ?, updated_at = ? WHERE expires_at <= ? AND is_enabled = ?",
+			false, now.UTC(), now.UTC(), true)
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+
+		return err
+	})
+
+	return affectedRows, err
+}
+
+// ChargeRateLimit counts a request to a public dashboard in the rate limit window of the current minute. It returns false,
+// without counting the request, when the requests of the window reached the rate limit of the public dashboard. The counter
+// is kept in the database, so that the limit is shared by all the Grafana instances.
+func (d *PublicDashboardStoreImpl) ChargeRateLimit(ctx context.Context, orgId int64, uid string, now time.Time) (bool, error) {
+	window := now.Unix() / 60
+	var affectedRows int64
+	err := d.sqlStore.WithDbSession(ctx, func(sess *db.Session) error {
+		// rate_limit_count is assigned before rate_limit_window, as MySQL evaluates the assignments in order
+		sqlResult, err := sess.Exec(`UPDATE dashboard_public SET
+				rate_limit_count = CASE WHEN rate_limit_window = ? THEN rate_limit_count + 1 ELSE 1 END,
+				rate_limit_window = ?
+			WHERE uid = ? AND org_id = ? AND (rate_limit = 0 OR rate_limit_window <> ? OR rate_limit_count < rate_limit)`,
+			window, window, uid, orgId, window)
+		if err != nil {
+			return err
+		}
+
+		affectedRows, err = sqlResult.RowsAffected()
+
+		return err
+	})
+
+	return affectedRows > 0, err
+}
+
 // Delete deletes a public dashboard
 func (d *PublicDashboardStoreImpl) Delete(ctx context.Context, orgId int64, uid string) (int64, error) {
 	var affectedRows int64
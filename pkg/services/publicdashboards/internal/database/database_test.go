@@ -798,6 +798,151 @@ func TestIntegrationDeleteByDashboardUIDs(t *testing.T) {
 	})
 }
 
+func TestIntegrationAccessSettings(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	var sqlStore db.DB
+	var cfg *setting.Cfg
+	var publicdashboardStore *PublicDashboardStoreImpl
+	var savedPublicDashboard *models.PublicDashboard
+
+	setup := func() {
+		sqlStore, cfg = db.InitTestDBWithCfg(t) //nolint:staticcheck // legacy shared-DB test setup; migrate to NewTestStore
+		publicdashboardStore = ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+		savedDashboard := createTestDashboard("testDashie", 1, "", true)
+		savedPublicDashboard = insertPublicDashboard(t, publicdashboardStore, savedDashboard.UID, savedDashboard.OrgID, true, models.PublicShareType)
+	}
+
+	update := func(t *testing.T, expiresAt *time.Time, rateLimit int64) {
+		pubdash := *savedPublicDashboard
+		pubdash.ExpiresAt = expiresAt
+		pubdash.RateLimit = rateLimit
+		pubdash.UpdatedAt = time.Now()
+
+		affectedRows, err := publicdashboardStore.Update(context.Background(), models.SavePublicDashboardCommand{PublicDashboard: pubdash})
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+	}
+
+	t.Run("saves and removes the access settings", func(t *testing.T) {
+		setup()
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		update(t, &expiresAt, 60)
+
+		pubdash, err := publicdashboardStore.FindByAccessToken(context.Background(), savedPublicDashboard.AccessToken)
+		require.NoError(t, err)
+		require.NotNil(t, pubdash.ExpiresAt)
+		assert.True(t, expiresAt.Equal(*pubdash.ExpiresAt))
+		assert.EqualValues(t, 60, pubdash.RateLimit)
+
+		update(t, nil, 0)
+
+		pubdash, err = publicdashboardStore.FindByAccessToken(context.Background(), savedPublicDashboard.AccessToken)
+		require.NoError(t, err)
+		assert.Nil(t, pubdash.ExpiresAt)
+		assert.EqualValues(t, 0, pubdash.RateLimit)
+	})
+
+	t.Run("lists the access settings", func(t *testing.T) {
+		setup()
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		update(t, &expiresAt, 60)
+
+		resp, err := publicdashboardStore.FindAll(context.Background(), &models.PublicDashboardListQuery{OrgID: savedPublicDashboard.OrgId})
+		require.NoError(t, err)
+		require.Len(t, resp.PublicDashboards, 1)
+		require.NotNil(t, resp.PublicDashboards[0].ExpiresAt)
+		assert.True(t, expiresAt.Equal(*resp.PublicDashboards[0].ExpiresAt))
+		assert.EqualValues(t, 60, resp.PublicDashboards[0].RateLimit)
+	})
+
+	t.Run("counts the loads in the rate limit window of the current minute", func(t *testing.T) {
+		setup()
+		update(t, nil, 2)
+		now := time.Now().Truncate(time.Minute)
+		charge := func(now time.Time) bool {
+			allowed, err := publicdashboardStore.ChargeRateLimit(context.Background(), savedPublicDashboard.OrgId, savedPublicDashboard.Uid, now)
+			require.NoError(t, err)
+			return allowed
+		}
+
+		assert.True(t, charge(now))
+		assert.True(t, charge(now.Add(time.Second)))
+		assert.False(t, charge(now.Add(2*time.Second)))
+		assert.True(t, charge(now.Add(time.Minute)))
+
+		update(t, nil, 0)
+		for range 3 {
+			assert.True(t, charge(now.Add(time.Minute)))
+		}
+	})
+
+	t.Run("disables expired public dashboards only", func(t *testing.T) {
+		setup()
+		expiresAt := time.Now().Add(time.Hour)
+		update(t, &expiresAt, 0)
+
+		affectedRows, err := publicdashboardStore.DisableExpired(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, affectedRows)
+
+		affectedRows, err = publicdashboardStore.DisableExpired(context.Background(), expiresAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+
+		pubdash, err := publicdashboardStore.Find(context.Background(), savedPublicDashboard.Uid)
+		require.NoError(t, err)
+		assert.False(t, pubdash.IsEnabled)
+	})
+}
+
+func TestIntegrationRotateAccessToken(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	sqlStore, cfg := db.InitTestDBWithCfg(t) //nolint:staticcheck // legacy shared-DB test setup; migrate to NewTestStore
+	publicdashboardStore := ProvideStore(sqlStore, cfg, featuremgmt.WithFeatures())
+	savedDashboard := createTestDashboard("testDashie", 1, "", true)
+	savedPublicDashboard := insertPublicDashboard(t, publicdashboardStore, savedDashboard.UID, savedDashboard.OrgID, true, models.PublicShareType)
+
+	accessToken, err := service.GenerateAccessToken()
+	require.NoError(t, err)
+	cmd := models.SavePublicDashboardCommand{
+		PublicDashboard: models.PublicDashboard{
+			Uid:         savedPublicDashboard.Uid,
+			OrgId:       savedPublicDashboard.OrgId,
+			AccessToken: accessToken,
+			UpdatedBy:   2,
+			UpdatedAt:   time.Now(),
+		},
+	}
+
+	t.Run("does not rotate the access token of a public dashboard from another org", func(t *testing.T) {
+		otherOrgCmd := cmd
+		otherOrgCmd.PublicDashboard.OrgId = savedPublicDashboard.OrgId + 1
+
+		affectedRows, err := publicdashboardStore.RotateAccessToken(context.Background(), otherOrgCmd)
+		require.NoError(t, err)
+		assert.EqualValues(t, 0, affectedRows)
+	})
+
+	t.Run("replaces the access token and keeps the uid", func(t *testing.T) {
+		affectedRows, err := publicdashboardStore.RotateAccessToken(context.Background(), cmd)
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, affectedRows)
+
+		pubdash, err := publicdashboardStore.FindByAccessToken(context.Background(), savedPublicDashboard.AccessToken)
+		require.NoError(t, err)
+		assert.Nil(t, pubdash)
+
+		pubdash, err = publicdashboardStore.FindByAccessToken(context.Background(), accessToken)
+		require.NoError(t, err)
+		require.NotNil(t, pubdash)
+		assert.Equal(t, savedPublicDashboard.Uid, pubdash.Uid)
+		assert.True(t, pubdash.IsEnabled)
+		assert.EqualValues(t, 2, pubdash.UpdatedBy)
+	})
+}
+
 func TestIntegrationGetMetrics(t *testing.T) {
 	testutil.SkipIntegrationTestInShortMode(t)
 
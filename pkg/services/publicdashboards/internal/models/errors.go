@@ -23,7 +23,13 @@ var (
 	ErrDashboardIsPublic                   = errutil.BadRequest("publicdashboards.dashboardIsPublic", errutil.WithPublicMessage("Dashboard is already public"))
 	ErrPublicDashboardUidExists            = errutil.BadRequest("publicdashboards.uidExists", errutil.WithPublicMessage("Dashboard Uid already exists"))
 	ErrPublicDashboardAccessTokenExists    = errutil.BadRequest("publicdashboards.accessTokenExists", errutil.WithPublicMessage("Dashboard Access Token already exists"))
+	ErrInvalidExpiry                       = errutil.BadRequest("publicdashboards.invalidExpiry", errutil.WithPublicMessage("Expiry date should be in the future"))
+	ErrInvalidRateLimit                    = errutil.BadRequest("publicdashboards.invalidRateLimit", errutil.WithPublicMessage("rateLimit should be greater than or equal to 0"))
+	ErrInvalidVariable                     = errutil.BadRequest("publicdashboards.invalidVariable", errutil.WithPublicMessage("Invalid template variable value"))
 
 	ErrPublicDashboardNotEnabled = errutil.Forbidden("publicdashboards.notEnabled", errutil.WithPublicMessage("Dashboard paused"))
 	ErrPublicDashboardWrongOrg   = errutil.Forbidden("publicdashboards.wrongOrg", errutil.WithPublicMessage("Public dashboard does not belong to this organization"))
+	ErrPublicDashboardExpired    = errutil.Forbidden("publicdashboards.expired", errutil.WithPublicMessage("Dashboard expired"))
+
+	ErrPublicDashboardRateLimited = errutil.TooManyRequests("publicdashboards.rateLimited", errutil.WithPublicMessage("Too many requests to this dashboard, try again later"))
 )
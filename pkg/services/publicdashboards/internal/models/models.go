@@ -51,6 +51,13 @@ type PublicDashboard struct {
 	AnnotationsEnabled   bool          `json:"annotationsEnabled" xorm:"annotations_enabled"`
 	Share                ShareType     `json:"share" xorm:"share"`
 	Recipients           []EmailDTO    `json:"recipients,omitempty" xorm:"-"`
+	ExpiresAt            *time.Time    `json:"expiresAt,omitempty" xorm:"expires_at"`
+	RateLimit            int64         `json:"rateLimit" xorm:"rate_limit"` // requests per minute, 0 means no limit
+}
+
+// IsExpired returns true when the public dashboard has an expiry date in the past.
+func (pd PublicDashboard) IsExpired(now time.Time) bool {
+	return pd.ExpiresAt != nil && !pd.ExpiresAt.After(now)
 }
 
 type PublicDashboardDTO struct {
@@ -60,6 +67,10 @@ type PublicDashboardDTO struct {
 	IsEnabled            *bool     `json:"isEnabled"`
 	AnnotationsEnabled   *bool     `json:"annotationsEnabled"`
 	Share                ShareType `json:"share"`
+	// ExpiresAt sets when the public dashboard gets disabled. A zero time removes the expiry.
+	ExpiresAt *time.Time `json:"expiresAt"`
+	// RateLimit sets the number of requests per minute allowed, including the panel queries and annotations, 0 means no limit.
+	RateLimit *int64 `json:"rateLimit"`
 }
 
 type EmailDTO struct {
@@ -111,12 +122,14 @@ type PublicDashboardListResponseWithPagination struct {
 }
 
 type PublicDashboardListResponse struct {
-	Uid          string `json:"uid" xorm:"uid"`
-	AccessToken  string `json:"accessToken" xorm:"access_token"`
-	Title        string `json:"title" xorm:"title"`
-	DashboardUid string `json:"dashboardUid" xorm:"dashboard_uid"`
-	IsEnabled    bool   `json:"isEnabled" xorm:"is_enabled"`
-	Slug         string `json:"slug" xorm:"slug"`
+	Uid          string     `json:"uid" xorm:"uid"`
+	AccessToken  string     `json:"accessToken" xorm:"access_token"`
+	Title        string     `json:"title" xorm:"title"`
+	DashboardUid string     `json:"dashboardUid" xorm:"dashboard_uid"`
+	IsEnabled    bool       `json:"isEnabled" xorm:"is_enabled"`
+	Slug         string     `json:"slug" xorm:"slug"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty" xorm:"expires_at"`
+	RateLimit    int64      `json:"rateLimit" xorm:"rate_limit"`
 }
 
 type TimeSettings struct {
@@ -152,6 +165,8 @@ type PublicDashboardQueryDTO struct {
 	MaxDataPoints   int64
 	QueryCachingTTL int64
 	TimeRange       TimeRangeDTO
+	// Variables are the values selected by the viewer for the template variables of the dashboard
+	Variables map[string][]string
 }
 
 type AnnotationsQueryDTO struct {
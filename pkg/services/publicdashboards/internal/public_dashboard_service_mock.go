@@ -556,6 +556,36 @@ func (_m *FakePublicDashboardService) NewPublicDashboardUid(ctx context.Context)
 	return r0, r1
 }
 
+// RotateAccessToken provides a mock function with given fields: ctx, u, dashboardUid, uid
+func (_m *FakePublicDashboardService) RotateAccessToken(ctx context.Context, u *user.SignedInUser, dashboardUid string, uid string) (*models.PublicDashboard, error) {
+	ret := _m.Called(ctx, u, dashboardUid, uid)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateAccessToken")
+	}
+
+	var r0 *models.PublicDashboard
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) (*models.PublicDashboard, error)); ok {
+		return rf(ctx, u, dashboardUid, uid)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *user.SignedInUser, string, string) *models.PublicDashboard); ok {
+		r0 = rf(ctx, u, dashboardUid, uid)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.PublicDashboard)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *user.SignedInUser, string, string) error); ok {
+		r1 = rf(ctx, u, dashboardUid, uid)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, u, dto
 func (_m *FakePublicDashboardService) Update(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, error) {
 	ret := _m.Called(ctx, u, dto)
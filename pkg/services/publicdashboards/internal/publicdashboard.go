@@ -2,6 +2,7 @@ package publicdashboards
 
 import (
 	"context"
+	"time"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana/pkg/api/dtos"
@@ -34,6 +35,7 @@ type Service interface {
 	Create(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, error)
 	Update(ctx context.Context, u *user.SignedInUser, dto *models.SavePublicDashboardDTO) (*models.PublicDashboard, error)
 	Delete(ctx context.Context, orgId int64, uid string, dashboardUid string) error
+	RotateAccessToken(ctx context.Context, u *user.SignedInUser, dashboardUid string, uid string) (*models.PublicDashboard, error)
 
 	GetMetricRequest(ctx context.Context, dashboard *dashboards.Dashboard, publicDashboard *models.PublicDashboard, panelId int64, reqDTO models.PublicDashboardQueryDTO) (dtos.MetricRequest, error)
 	GetQueryDataResponse(ctx context.Context, skipDSCache bool, reqDTO models.PublicDashboardQueryDTO, panelId int64, accessToken string) (*backend.QueryDataResponse, error)
@@ -67,6 +69,9 @@ type Store interface {
 	Update(ctx context.Context, cmd models.SavePublicDashboardCommand) (int64, error)
 	Delete(ctx context.Context, orgId int64, uid string) (int64, error)
 	DeleteByDashboardUIDs(ctx context.Context, orgId int64, dashboardUIDs []string) error
+	RotateAccessToken(ctx context.Context, cmd models.SavePublicDashboardCommand) (int64, error)
+	DisableExpired(ctx context.Context, now time.Time) (int64, error)
+	ChargeRateLimit(ctx context.Context, orgId int64, uid string, now time.Time) (bool, error)
 
 	GetOrgIdByAccessToken(ctx context.Context, accessToken string) (int64, error)
 	ExistsEnabledByAccessToken(ctx context.Context, accessToken string) (bool, error)
@@ -14,6 +14,7 @@ import (
 	"github.com/grafana/grafana/pkg/expr"
 	"github.com/grafana/grafana/pkg/services/annotations"
 	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/dashboardvariables"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/models"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/validation"
 	"github.com/grafana/grafana/pkg/tsdb/grafanads"
@@ -26,6 +27,11 @@ func (pd *PublicDashboardServiceImpl) FindAnnotations(ctx context.Context, reqDT
 		return nil, err
 	}
 
+	if err := pd.chargeRateLimit(ctx, pub, "resource", "annotations"); err != nil {
+		return nil, err
+	}
+	pd.logAccess(ctx, pub, accessAllowed, "resource", "annotations")
+
 	if !pub.AnnotationsEnabled {
 		return []models.AnnotationEvent{}, nil
 	}
@@ -126,6 +132,14 @@ func (pd *PublicDashboardServiceImpl) GetMetricRequest(ctx context.Context, dash
 		return dtos.MetricRequest{}, err
 	}
 
+	variables, err := selectVariables(pd.resolveVariables(ctx, publicDashboard, dashboard), queryDto.Variables)
+	if err != nil {
+		return dtos.MetricRequest{}, err
+	}
+	for _, query := range metricReqDTO.Queries {
+		dashboardvariables.Interpolate(query, variables)
+	}
+
 	return metricReqDTO, nil
 }
 
@@ -136,6 +150,11 @@ func (pd *PublicDashboardServiceImpl) GetQueryDataResponse(ctx context.Context,
 		return nil, err
 	}
 
+	if err := pd.chargeRateLimit(ctx, publicDashboard, "resource", "query", "panelId", panelId); err != nil {
+		return nil, err
+	}
+	pd.logAccess(ctx, publicDashboard, accessAllowed, "resource", "query", "panelId", panelId)
+
 	metricReq, err := pd.GetMetricRequest(ctx, dashboard, publicDashboard, panelId, queryDto)
 	if err != nil {
 		return nil, err
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
@@ -11,6 +12,7 @@ import (
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	dashboard2 "github.com/grafana/grafana/pkg/kinds/dashboard"
@@ -358,6 +360,57 @@ func TestIntegrationGetQueryDataResponse(t *testing.T) {
 	})
 }
 
+func TestIntegrationGetQueryDataResponseRateLimit(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	fakeDashboardService := &dashboards.FakeDashboardService{}
+	service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, nil, fakeDashboardService, nil)
+	fakeQueryService := &query.FakeQueryService{}
+	fakeQueryService.On("QueryData", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&backend.QueryDataResponse{}, nil)
+	service.QueryDataService = fakeQueryService
+
+	customPanels := []any{
+		map[string]any{
+			"id":         1,
+			"datasource": map[string]any{"uid": "ds1"},
+			"targets": []any{map[string]any{
+				"datasource": map[string]any{"name": "Expression", "type": "__expr__", "uid": "__expr__"},
+				"refId":      "A",
+			}},
+		}}
+	dashboard := createTestDashboard(t, "testDashWithRateLimit", 1, "", true, []map[string]any{}, customPanels)
+	fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(dashboard, nil)
+
+	isEnabled := true
+	rateLimit := int64(2)
+	pubdashDto, err := service.Create(context.Background(), SignedInUser, &models.SavePublicDashboardDTO{
+		DashboardUid: dashboard.UID,
+		UserId:       7,
+		OrgID:        dashboard.OrgID,
+		PublicDashboard: &models.PublicDashboardDTO{
+			IsEnabled: &isEnabled,
+			RateLimit: &rateLimit,
+		},
+	})
+	require.NoError(t, err)
+
+	queryDTO := models.PublicDashboardQueryDTO{IntervalMs: 1, MaxDataPoints: 1}
+	for range rateLimit {
+		_, err := service.GetQueryDataResponse(context.Background(), true, queryDTO, 1, pubdashDto.AccessToken)
+		require.NoError(t, err)
+	}
+
+	_, err = service.GetQueryDataResponse(context.Background(), true, queryDTO, 1, pubdashDto.AccessToken)
+	require.ErrorIs(t, err, models.ErrPublicDashboardRateLimited)
+	var grafanaErr errutil.Error
+	require.ErrorAs(t, err, &grafanaErr)
+	require.Equal(t, http.StatusTooManyRequests, grafanaErr.Public().StatusCode)
+
+	// The annotations share the rate limit of the queries
+	_, err = service.FindAnnotations(context.Background(), models.AnnotationsQueryDTO{From: 1, To: 2}, pubdashDto.AccessToken)
+	require.ErrorIs(t, err, models.ErrPublicDashboardRateLimited)
+}
+
 func TestIntegrationFindAnnotations(t *testing.T) {
 	testutil.SkipIntegrationTestInShortMode(t)
 
@@ -15,10 +15,12 @@ import (
 	"github.com/grafana/grafana/pkg/api/dtos"
 	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	queryV0 "github.com/grafana/grafana/pkg/apis/datasource/v0alpha1"
+	"github.com/grafana/grafana/pkg/infra/localcache"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/infra/metrics"
 	"github.com/grafana/grafana/pkg/services/accesscontrol"
 	"github.com/grafana/grafana/pkg/services/annotations"
+	"github.com/grafana/grafana/pkg/services/contexthandler"
 	"github.com/grafana/grafana/pkg/services/dashboards"
 	"github.com/grafana/grafana/pkg/services/dashboards/dashboardaccess"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
@@ -48,9 +50,14 @@ type PublicDashboardServiceImpl struct {
 	serviceWrapper     publicdashboards.ServiceWrapper
 	dashboardService   dashboards.DashboardService
 	license            licensing.Licensing
+	accessLog          log.Logger
+	userService        user.Service
+	acService          accesscontrol.Service
+	variableCache      *localcache.CacheService
 }
 
 var LogPrefix = "publicdashboards.service"
+var AccessLogPrefix = "publicdashboards.access"
 var tracer = otel.Tracer("github.com/grafana/grafana/pkg/services/publicdashboards/service")
 
 // Gives us compile time error if the service does not adhere to the contract of
@@ -69,6 +76,8 @@ func ProvideService(
 	serviceWrapper publicdashboards.ServiceWrapper,
 	dashboardService dashboards.DashboardService,
 	license licensing.Licensing,
+	userService user.Service,
+	acService accesscontrol.Service,
 ) *PublicDashboardServiceImpl {
 	return &PublicDashboardServiceImpl{
 		log:                log.New(LogPrefix),
@@ -82,6 +91,10 @@ func ProvideService(
 		serviceWrapper:     serviceWrapper,
 		dashboardService:   dashboardService,
 		license:            license,
+		accessLog:          log.New(AccessLogPrefix),
+		userService:        userService,
+		acService:          acService,
+		variableCache:      localcache.New(variableValuesCacheTTL, 2*variableValuesCacheTTL),
 	}
 }
 
@@ -94,6 +107,10 @@ func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Cont
 		return nil, err
 	}
 
+	if err := pd.chargeRateLimit(ctx, pubdash, "resource", "dashboard"); err != nil {
+		return nil, err
+	}
+
 	metrics.MFolderIDsServiceCount.WithLabelValues(metrics.PublicDashboards).Inc()
 	meta := dtos.DashboardMeta{
 		Slug:                   dash.Slug,
@@ -111,6 +128,7 @@ func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Cont
 		FolderUid:              dash.FolderUID,
 		PublicDashboardEnabled: pubdash.IsEnabled,
 	}
+	applyVariables(pd.resolveVariables(ctx, pubdash, dash))
 	if isDashboardV2(dash) {
 		sanitizeDataV2(dash.Data)
 	} else {
@@ -118,6 +136,8 @@ func (pd *PublicDashboardServiceImpl) GetPublicDashboardForView(ctx context.Cont
 		sanitizeData(dash.Data)
 	}
 
+	pd.logAccess(ctx, pubdash, accessAllowed, "resource", "dashboard")
+
 	return &dtos.DashboardFullWithMeta{Meta: meta, Dashboard: dash.Data}, nil
 }
 
@@ -195,6 +215,11 @@ func (pd *PublicDashboardServiceImpl) FindEnabledPublicDashboardAndDashboardByAc
 		return nil, nil, models.ErrPublicDashboardNotFound.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Dashboard not found accessToken: %s", accessToken)
 	}
 
+	if pubdash.IsExpired(time.Now()) {
+		pd.logAccess(ctx, pubdash, accessExpired)
+		return nil, nil, models.ErrPublicDashboardExpired.Errorf("FindEnabledPublicDashboardAndDashboardByAccessToken: Public dashboard %s expired at %s", pubdash.Uid, pubdash.ExpiresAt)
+	}
+
 	return pubdash, dash, err
 }
 
@@ -336,6 +361,58 @@ func (pd *PublicDashboardServiceImpl) Update(ctx context.Context, u *user.Signed
 	return newPubdash, nil
 }
 
+// RotateAccessToken replaces the access token of an existing public dashboard. The public dashboard keeps its uid and
+// settings, and the previous access token stops working immediately.
+func (pd *PublicDashboardServiceImpl) RotateAccessToken(ctx context.Context, u *user.SignedInUser, dashboardUid string, uid string) (*models.PublicDashboard, error) {
+	ctx, span := tracer.Start(ctx, "publicdashboards.RotateAccessToken")
+	defer span.End()
+
+	existingPubdash, err := pd.store.FindByOrgAndUid(ctx, u.OrgID, uid)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s and orgId: %d: %w", uid, u.OrgID, err)
+	} else if existingPubdash == nil {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("RotateAccessToken: public dashboard not found by uid: %s", uid)
+	}
+
+	// validate the public dashboard belongs to the dashboard
+	if existingPubdash.DashboardUid != dashboardUid {
+		return nil, models.ErrInvalidUid.Errorf("RotateAccessToken: the public dashboard does not belong to the dashboard")
+	}
+
+	accessToken, err := pd.NewPublicDashboardAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := models.SavePublicDashboardCommand{
+		PublicDashboard: models.PublicDashboard{
+			Uid:         existingPubdash.Uid,
+			OrgId:       existingPubdash.OrgId,
+			AccessToken: accessToken,
+			UpdatedBy:   u.UserID,
+			UpdatedAt:   time.Now(),
+		},
+	}
+
+	affectedRows, err := pd.store.RotateAccessToken(ctx, cmd)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("RotateAccessToken: failed to rotate the access token of public dashboard %s: %w", uid, err)
+	}
+
+	if affectedRows == 0 {
+		return nil, models.ErrPublicDashboardNotFound.Errorf("RotateAccessToken: failed to rotate the access token, public dashboard not found by uid: %s", uid)
+	}
+
+	newPubdash, err := pd.store.FindByOrgAndUid(ctx, existingPubdash.OrgId, existingPubdash.Uid)
+	if err != nil {
+		return nil, models.ErrInternalServerError.Errorf("RotateAccessToken: failed to find public dashboard by uid: %s and orgId: %d: %w", existingPubdash.Uid, existingPubdash.OrgId, err)
+	}
+
+	pd.log.Info("Public dashboard access token rotated", "publicDashboardUid", existingPubdash.Uid, "dashboardUid", existingPubdash.DashboardUid, "user", u.Login)
+
+	return newPubdash, nil
+}
+
 // NewPublicDashboardUid Generates a unique uid to create a public dashboard. Will make 3 attempts and fail if it cannot find an unused uid
 func (pd *PublicDashboardServiceImpl) NewPublicDashboardUid(ctx context.Context) (string, error) {
 	ctx, span := tracer.Start(ctx, "publicdashboards.NewPublicDashboardUid")
@@ -523,6 +600,40 @@ func (pd *PublicDashboardServiceImpl) logIsEnabledChanged(existingPubdash *model
 	}
 }
 
+// Statuses of the accesses to public dashboards in the access log
+const (
+	accessAllowed     = "allowed"
+	accessExpired     = "expired"
+	accessRateLimited = "rateLimited"
+)
+
+// Count a request to a public dashboard in its rate limit. The loads of the dashboard, the queries of its panels and its
+// annotations share the limit, so that the data sources can't be queried more often than the limit allows
+func (pd *PublicDashboardServiceImpl) chargeRateLimit(ctx context.Context, pubdash *models.PublicDashboard, fields ...any) error {
+	if pubdash.RateLimit <= 0 {
+		return nil
+	}
+	allowed, err := pd.store.ChargeRateLimit(ctx, pubdash.OrgId, pubdash.Uid, time.Now())
+	if err != nil {
+		return models.ErrInternalServerError.Errorf("chargeRateLimit: failed to charge the rate limit of public dashboard %s: %w", pubdash.Uid, err)
+	}
+	if !allowed {
+		pd.logAccess(ctx, pubdash, accessRateLimited, fields...)
+		return models.ErrPublicDashboardRateLimited.Errorf("chargeRateLimit: Public dashboard %s exceeded its rate limit of %d requests per minute", pubdash.Uid, pubdash.RateLimit)
+	}
+	return nil
+}
+
+// Log an access to a public dashboard, with the client and whether the access was allowed. The access token is never
+// logged, as it grants access to the dashboard
+func (pd *PublicDashboardServiceImpl) logAccess(ctx context.Context, pubdash *models.PublicDashboard, status string, fields ...any) {
+	fields = append([]any{"publicDashboardUid", pubdash.Uid, "dashboardUid", pubdash.DashboardUid, "orgId", pubdash.OrgId, "status", status}, fields...)
+	if reqCtx := contexthandler.FromContext(ctx); reqCtx != nil && reqCtx.Context != nil {
+		fields = append(fields, "remoteAddr", reqCtx.RemoteAddr(), "userAgent", reqCtx.Req.UserAgent())
+	}
+	pd.accessLog.Info("Public dashboard accessed", fields...)
+}
+
 func (pd *PublicDashboardServiceImpl) GetSQLSchemas(ctx context.Context, user identity.Requester, reqDTO dtos.MetricRequest) (queryV0.SQLSchemas, error) {
 	return nil, fmt.Errorf("sql schema endpoint not supported with public dashboards")
 }
@@ -603,6 +714,8 @@ func (pd *PublicDashboardServiceImpl) newCreatePublicDashboard(ctx context.Conte
 		UpdatedBy:            dto.UserId,
 		UpdatedAt:            now,
 		AccessToken:          accessToken,
+		ExpiresAt:            expiresAtOrDefault(dto.PublicDashboard.ExpiresAt, nil),
+		RateLimit:            returnValueOrDefault(dto.PublicDashboard.RateLimit, 0),
 	}, nil
 }
 
@@ -627,10 +740,24 @@ func newUpdatePublicDashboard(dto *models.SavePublicDashboardDTO, pd *models.Pub
 		Share:                share,
 		UpdatedBy:            dto.UserId,
 		UpdatedAt:            time.Now(),
+		ExpiresAt:            expiresAtOrDefault(pubdashDTO.ExpiresAt, pd.ExpiresAt),
+		RateLimit:            returnValueOrDefault(pubdashDTO.RateLimit, pd.RateLimit),
+	}
+}
+
+// expiresAtOrDefault returns the default expiry when none is given, and no expiry when the zero time is given
+func expiresAtOrDefault(value *time.Time, defaultValue *time.Time) *time.Time {
+	if value == nil {
+		return defaultValue
+	}
+	if value.IsZero() {
+		return nil
 	}
+
+	return value
 }
 
-func returnValueOrDefault(value *bool, defaultValue bool) bool {
+func returnValueOrDefault[T any](value *T, defaultValue T) T {
 	if value != nil {
 		return *value
 	}
@@ -678,6 +678,17 @@ func TestIntegrationGetEnabledPublicDashboard(t *testing.T) {
 			ErrResp:  models.ErrPublicDashboardNotFound,
 			DashResp: nil,
 		},
+		{
+			Name:        "returns models.ErrPublicDashboardExpired when expiry is in the past",
+			AccessToken: "abc123",
+			StoreResp: &storeResp{
+				pd:  &models.PublicDashboard{AccessToken: "abcdToken", IsEnabled: true, ExpiresAt: new(time.Now().Add(-time.Hour))},
+				d:   &dashboards.Dashboard{UID: "mydashboard"},
+				err: nil,
+			},
+			ErrResp:  models.ErrPublicDashboardExpired,
+			DashResp: nil,
+		},
 	}
 
 	for _, test := range testCases {
@@ -1401,6 +1412,89 @@ func TestIntegrationDeletePublicDashboard(t *testing.T) {
 	}
 }
 
+func TestIntegrationGetPublicDashboardForViewRateLimit(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	newService := func(t *testing.T, pubdash *models.PublicDashboard) (*PublicDashboardServiceImpl, *publicdashboards.FakePublicDashboardStore) {
+		fakeStore := publicdashboards.NewFakePublicDashboardStore(t)
+		fakeStore.On("FindByAccessToken", mock.Anything, mock.Anything).Return(pubdash, nil)
+		fakeDashboardService := &dashboards.FakeDashboardService{}
+		fakeDashboardService.On("GetDashboard", mock.Anything, mock.Anything, mock.Anything).Return(&dashboards.Dashboard{UID: "mydashboard", Data: simplejson.New()}, nil)
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, fakeStore, fakeDashboardService, nil)
+		return service, fakeStore
+	}
+
+	t.Run("charges the rate limit once per load", func(t *testing.T) {
+		service, fakeStore := newService(t, &models.PublicDashboard{Uid: "pubdashUID", OrgId: 1, AccessToken: "abcdToken", IsEnabled: true, RateLimit: 2})
+		fakeStore.On("ChargeRateLimit", mock.Anything, int64(1), "pubdashUID", mock.Anything).Return(true, nil).Once()
+
+		_, err := service.GetPublicDashboardForView(context.Background(), "abcdToken")
+		require.NoError(t, err)
+	})
+
+	t.Run("charges the rate limit for the annotations", func(t *testing.T) {
+		service, fakeStore := newService(t, &models.PublicDashboard{Uid: "pubdashUID", OrgId: 1, AccessToken: "abcdToken", IsEnabled: true, RateLimit: 2})
+		fakeStore.On("ChargeRateLimit", mock.Anything, int64(1), "pubdashUID", mock.Anything).Return(false, nil).Once()
+
+		_, err := service.FindAnnotations(context.Background(), models.AnnotationsQueryDTO{From: 1, To: 2}, "abcdToken")
+		require.ErrorIs(t, err, models.ErrPublicDashboardRateLimited)
+	})
+
+	t.Run("returns models.ErrPublicDashboardRateLimited when the limit is reached", func(t *testing.T) {
+		service, fakeStore := newService(t, &models.PublicDashboard{Uid: "pubdashUID", OrgId: 1, AccessToken: "abcdToken", IsEnabled: true, RateLimit: 2})
+		fakeStore.On("ChargeRateLimit", mock.Anything, int64(1), "pubdashUID", mock.Anything).Return(false, nil)
+
+		_, err := service.GetPublicDashboardForView(context.Background(), "abcdToken")
+		require.ErrorIs(t, err, models.ErrPublicDashboardRateLimited)
+	})
+
+	t.Run("does not charge public dashboards without rate limit", func(t *testing.T) {
+		service, _ := newService(t, &models.PublicDashboard{Uid: "pubdashUID", OrgId: 1, AccessToken: "abcdToken", IsEnabled: true})
+
+		_, err := service.GetPublicDashboardForView(context.Background(), "abcdToken")
+		require.NoError(t, err)
+	})
+}
+
+func TestIntegrationRotatePublicDashboardAccessToken(t *testing.T) {
+	testutil.SkipIntegrationTestInShortMode(t)
+
+	pubdash := &models.PublicDashboard{Uid: "pubdashUID", OrgId: 1, DashboardUid: "uid", AccessToken: "oldToken"}
+	u := &user.SignedInUser{OrgID: 1, UserID: 2, Login: "admin"}
+
+	t.Run("replaces the access token", func(t *testing.T) {
+		store := publicdashboards.NewFakePublicDashboardStore(t)
+		store.On("FindByOrgAndUid", mock.Anything, int64(1), "pubdashUID").Return(pubdash, nil)
+		store.On("FindByAccessToken", mock.Anything, mock.Anything).Return(nil, nil)
+		store.On("RotateAccessToken", mock.Anything, mock.MatchedBy(func(cmd models.SavePublicDashboardCommand) bool {
+			return cmd.PublicDashboard.Uid == "pubdashUID" && cmd.PublicDashboard.OrgId == 1 &&
+				cmd.PublicDashboard.UpdatedBy == 2 && validation.IsValidAccessToken(cmd.PublicDashboard.AccessToken)
+		})).Return(int64(1), nil)
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, store, nil, nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid", "pubdashUID")
+		require.NoError(t, err)
+	})
+
+	t.Run("returns an error when the public dashboard does not belong to the dashboard", func(t *testing.T) {
+		store := publicdashboards.NewFakePublicDashboardStore(t)
+		store.On("FindByOrgAndUid", mock.Anything, int64(1), "pubdashUID").Return(pubdash, nil)
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, store, nil, nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "wrong", "pubdashUID")
+		require.ErrorIs(t, err, models.ErrInvalidUid)
+	})
+
+	t.Run("returns not found when the public dashboard does not exist", func(t *testing.T) {
+		store := publicdashboards.NewFakePublicDashboardStore(t)
+		store.On("FindByOrgAndUid", mock.Anything, int64(1), "pubdashUID").Return(nil, nil)
+		service, _, _ := newPublicDashboardServiceImpl(t, nil, nil, store, nil, nil)
+
+		_, err := service.RotateAccessToken(context.Background(), u, "uid", "pubdashUID")
+		require.ErrorIs(t, err, models.ErrPublicDashboardNotFound)
+	})
+}
+
 func TestPublicDashboardServiceImpl_getSafeIntervalAndMaxDataPoints(t *testing.T) {
 	type args struct {
 		reqDTO models.PublicDashboardQueryDTO
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/services/accesscontrol"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/dashboards/dashboardvariables"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/models"
+	"github.com/grafana/grafana/pkg/services/user"
+)
+
+// Template variables viewers of a public dashboard can change. Their values are restricted to the values defined in the
+// dashboard, or returned by the variable query for query variables, so viewers can't inject arbitrary queries.
+const (
+	variableTypeCustom   = "custom"
+	variableTypeConstant = "constant"
+	variableTypeQuery    = "query"
+
+	variableAllValue   = dashboardvariables.AllValue
+	variableQueryRefID = "PublicDashboardVariable"
+
+	// query variables are resolved at most once per minute and public dashboard version
+	variableValuesCacheTTL = time.Minute
+)
+
+// v2 dashboard variable kinds of the supported variable types
+var variableKinds = map[string]string{
+	"CustomVariable":   variableTypeCustom,
+	"ConstantVariable": variableTypeConstant,
+	"QueryVariable":    variableTypeQuery,
+}
+
+// publicVariable is a template variable of a public dashboard with the values viewers can select
+type publicVariable struct {
+	Name       string
+	Type       string
+	Multi      bool
+	IncludeAll bool
+	AllValue   string
+	// Current are the values selected in the dashboard
+	Current []string
+	// Values are the values viewers can select
+	Values []string
+
+	// model is the variable in the dashboard, spec is its spec for v2 dashboards and the model itself otherwise
+	model *simplejson.Json
+	spec  *simplejson.Json
+}
+
+// resolveVariables returns the template variables of a public dashboard viewers can change. Query variables are
+// resolved with the permissions of the user who created the public dashboard, falling back to the options saved in
+// the dashboard when they can't be resolved.
+func (pd *PublicDashboardServiceImpl) resolveVariables(ctx context.Context, pubdash *models.PublicDashboard, dash *dashboards.Dashboard) []*publicVariable {
+	var variables []*publicVariable
+	for _, v := range dashboardVariables(dash) {
+		switch v.Type {
+		case variableTypeConstant:
+			v.Values = []string{v.spec.Get("query").MustString()}
+		case variableTypeCustom:
+			v.Values = parseCustomVariableQuery(v.spec.Get("query").MustString())
+			if len(v.Values) == 0 {
+				v.Values = optionValues(v.spec)
+			}
+		case variableTypeQuery:
+			values, err := pd.queryVariableValues(ctx, pubdash, dash, v)
+			if err != nil {
+				pd.log.Warn("Failed to resolve public dashboard variable, using its saved options", "publicDashboardUid", pubdash.Uid, "variable", v.Name, "error", err)
+			}
+			if len(values) == 0 {
+				values = optionValues(v.spec)
+			}
+			v.Values = values
+		}
+
+		v.Current = v.currentValues()
+
+		variables = append(variables, v)
+	}
+
+	return variables
+}
+
+// dashboardVariables returns the supported template variables of a dashboard, without their values
+func dashboardVariables(dash *dashboards.Dashboard) []*publicVariable {
+	var variables []*publicVariable
+
+	if dash.Data.Get("elements").Interface() != nil {
+		for _, obj := range dash.Data.Get("variables").MustArray() {
+			model := simplejson.NewFromAny(obj)
+			varType, ok := variableKinds[model.Get("kind").MustString()]
+			if !ok {
+				continue
+			}
+			variables = append(variables, newPublicVariable(varType, model, model.Get("spec")))
+		}
+		return variables
+	}
+
+	for _, obj := range dash.Data.Get("templating").Get("list").MustArray() {
+		model := simplejson.NewFromAny(obj)
+		varType := model.Get("type").MustString()
+		if varType != variableTypeCustom && varType != variableTypeConstant && varType != variableTypeQuery {
+			continue
+		}
+		variables = append(variables, newPublicVariable(varType, model, model))
+	}
+
+	return variables
+}
+
+func newPublicVariable(varType string, model *simplejson.Json, spec *simplejson.Json) *publicVariable {
+	return &publicVariable{
+		Name:       spec.Get("name").MustString(),
+		Type:       varType,
+		Multi:      spec.Get("multi").MustBool(),
+		IncludeAll: spec.Get("includeAll").MustBool(),
+		AllValue:   spec.Get("allValue").MustString(),
+		Current:    dashboardvariables.StringValues(spec.Get("current").Get("value")),
+		model:      model,
+		spec:       spec,
+	}
+}
+
+// currentValues returns the current values of a variable viewers can select. Current values that aren't allowed
+// anymore, like a value the variable query no longer returns, fall back to all values when the variable includes all,
+// or to its first value otherwise
+func (v *publicVariable) currentValues() []string {
+	current := make([]string, 0, len(v.Current))
+	for _, value := range v.Current {
+		if (value == variableAllValue && v.IncludeAll) || slices.Contains(v.Values, value) {
+			current = append(current, value)
+		}
+	}
+
+	switch {
+	case len(current) > 0:
+		return current
+	case v.IncludeAll:
+		return []string{variableAllValue}
+	case len(v.Values) > 0:
+		return v.Values[:1]
+	}
+	return nil
+}
+
+// queryVariableValues returns the values of a query variable. Values are cached, failures included, so the variable
+// query doesn't run for every request made to the public dashboard.
+func (pd *PublicDashboardServiceImpl) queryVariableValues(ctx context.Context, pubdash *models.PublicDashboard, dash *dashboards.Dashboard, v *publicVariable) ([]string, error) {
+	cacheKey := fmt.Sprintf("%s/%d/%s", pubdash.Uid, dash.Version, v.Name)
+	if cached, ok := pd.variableCache.Get(cacheKey); ok {
+		return slices.Clone(cached.([]string)), nil
+	}
+
+	values, err := pd.runVariableQuery(ctx, pubdash, dash, v)
+	pd.variableCache.Set(cacheKey, values, variableValuesCacheTTL)
+
+	return slices.Clone(values), err
+}
+
+// runVariableQuery runs the query of a query variable with the permissions of the public dashboard creator. Only
+// variables using a data query can be resolved, legacy string queries are resolved by the frontend.
+func (pd *PublicDashboardServiceImpl) runVariableQuery(ctx context.Context, pubdash *models.PublicDashboard, dash *dashboards.Dashboard, v *publicVariable) ([]string, error) {
+	query, ts, ok := variableDataQuery(dash, v)
+	if !ok {
+		return nil, nil
+	}
+
+	owner, err := pd.publicDashboardCreator(ctx, pubdash, dash.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := pd.QueryDataService.QueryData(ctx, owner, false, dtos.MetricRequest{
+		From:    ts.From,
+		To:      ts.To,
+		Queries: []*simplejson.Json{query},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, ok := res.Responses[variableQueryRefID]
+	if !ok {
+		return nil, nil
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+
+	values, err := filterVariableValues(valuesFromFrames(resp.Frames), v.spec.Get("regex").MustString())
+	if err != nil {
+		return nil, err
+	}
+	sortVariableValues(values, v.spec.Get("sort").Interface())
+
+	return values, nil
+}
+
+// variableDataQuery returns the data query of a query variable, and the dashboard time range to run it with
+func variableDataQuery(dash *dashboards.Dashboard, v *publicVariable) (*simplejson.Json, models.TimeSettings, bool) {
+	var query map[string]any
+	var datasource map[string]any
+	var ts models.TimeSettings
+
+	if v.model != v.spec {
+		// v2 dashboards: spec.query is a DataQuery kind, with the datasource type as group
+		dataQuery := v.spec.Get("query")
+		spec, err := dataQuery.Get("spec").Map()
+		if err != nil {
+			return nil, ts, false
+		}
+		query = spec
+		datasource = map[string]any{"type": dataQuery.Get("group").MustString(), "uid": getDataSourceUidFromJsonSchemaV2(dataQuery)}
+		ts = buildTimeSettingsV2(dash, models.PublicDashboardQueryDTO{}, &models.PublicDashboard{}, 0)
+	} else {
+		spec, err := v.spec.Get("query").Map()
+		if err != nil {
+			return nil, ts, false
+		}
+		query = spec
+		datasource, err = v.spec.Get("datasource").Map()
+		if err != nil {
+			return nil, ts, false
+		}
+		ts = buildTimeSettings(dash, models.PublicDashboardQueryDTO{}, &models.PublicDashboard{}, 0)
+	}
+
+	// copy the query so the dashboard isn't changed
+	copied := make(map[string]any, len(query)+2)
+	for k, val := range query {
+		copied[k] = val
+	}
+	copied["refId"] = variableQueryRefID
+	copied["datasource"] = datasource
+
+	return simplejson.NewFromAny(copied), ts, true
+}
+
+// publicDashboardCreator returns the user who created the public dashboard, with its permissions
+func (pd *PublicDashboardServiceImpl) publicDashboardCreator(ctx context.Context, pubdash *models.PublicDashboard, orgID int64) (*user.SignedInUser, error) {
+	if pd.userService == nil || pd.acService == nil {
+		return nil, fmt.Errorf("public dashboard creator can't be resolved")
+	}
+
+	creator, err := pd.userService.GetSignedInUser(ctx, &user.GetSignedInUserQuery{UserID: pubdash.CreatedBy, OrgID: orgID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the public dashboard creator: %w", err)
+	}
+	if creator.IsDisabled {
+		return nil, fmt.Errorf("public dashboard creator is disabled")
+	}
+
+	permissions, err := pd.acService.GetUserPermissions(ctx, creator, accesscontrol.Options{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the permissions of the public dashboard creator: %w", err)
+	}
+	creator.Permissions = map[int64]map[string][]string{
+		creator.OrgID: accesscontrol.GroupScopesByActionContext(ctx, permissions),
+	}
+
+	return creator, nil
+}
+
+// valuesFromFrames returns the values of a variable query response, like the frontend does: the __value or value
+// field when present, or the first field otherwise
+func valuesFromFrames(frames data.Frames) []string {
+	var values []string
+	for _, frame := range frames {
+		if len(frame.Fields) == 0 {
+			continue
+		}
+
+		field := frame.Fields[0]
+		for _, name := range []string{"__value", "value", "__text", "text"} {
+			if f, _ := frame.FieldByName(name); f != nil {
+				field = f
+				break
+			}
+		}
+
+		for i := 0; i < field.Len(); i++ {
+			value, ok := field.ConcreteAt(i)
+			if !ok {
+				continue
+			}
+			s := fmt.Sprint(value)
+			if !slices.Contains(values, s) {
+				values = append(values, s)
+			}
+		}
+	}
+
+	return values
+}
+
+// filterVariableValues applies the regex of a variable, written like /regex/flags, to its values. The value named
+// group, or the first group, of the regex is used as value when present.
+func filterVariableValues(values []string, regex string) ([]string, error) {
+	if regex == "" {
+		return values, nil
+	}
+
+	pattern := regex
+	if end := strings.LastIndex(regex, "/"); strings.HasPrefix(regex, "/") && end > 0 {
+		pattern = regex[1:end]
+		if strings.Contains(regex[end+1:], "i") {
+			pattern = "(?i)" + pattern
+		}
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid variable regex %s: %w", regex, err)
+	}
+
+	filtered := make([]string, 0, len(values))
+	for _, value := range values {
+		matches := re.FindStringSubmatch(value)
+		if matches == nil {
+			continue
+		}
+
+		switch i := re.SubexpIndex("value"); {
+		case i > 0 && matches[i] != "":
+			value = matches[i]
+		case len(matches) > 1 && matches[1] != "":
+			value = matches[1]
+		}
+
+		if !slices.Contains(filtered, value) {
+			filtered = append(filtered, value)
+		}
+	}
+
+	return filtered, nil
+}
+
+// sortVariableValues sorts the values of a query variable, sort being the number used by v1 dashboards or the name
+// used by v2 dashboards
+func sortVariableValues(values []string, sortOrder any) {
+	switch sortOrder {
+	case json.Number("1"), float64(1), "alphabeticalAsc":
+		sort.Strings(values)
+	case json.Number("2"), float64(2), "alphabeticalDesc":
+		sort.Sort(sort.Reverse(sort.StringSlice(values)))
+	case json.Number("3"), float64(3), "numericalAsc":
+		sort.SliceStable(values, func(i, j int) bool { return leadingNumber(values[i]) < leadingNumber(values[j]) })
+	case json.Number("4"), float64(4), "numericalDesc":
+		sort.SliceStable(values, func(i, j int) bool { return leadingNumber(values[i]) > leadingNumber(values[j]) })
+	case json.Number("5"), float64(5), "alphabeticalCaseInsensitiveAsc":
+		sort.SliceStable(values, func(i, j int) bool { return strings.ToLower(values[i]) < strings.ToLower(values[j]) })
+	case json.Number("6"), float64(6), "alphabeticalCaseInsensitiveDesc":
+		sort.SliceStable(values, func(i, j int) bool { return strings.ToLower(values[i]) > strings.ToLower(values[j]) })
+	}
+}
+
+var leadingNumberRegexp = regexp.MustCompile(`\d+`)
+
+// leadingNumber returns the first number of a value, or -1 when it has none
+func leadingNumber(value string) int64 {
+	n, err := strconv.ParseInt(leadingNumberRegexp.FindString(value), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// parseCustomVariableQuery returns the values of a custom variable query, a comma separated list of values or
+// `text : value` pairs where commas can be escaped with a backslash
+func parseCustomVariableQuery(query string) []string {
+	var values []string
+	var current strings.Builder
+	appendValue := func() {
+		value := strings.TrimSpace(current.String())
+		current.Reset()
+		if i := strings.Index(value, " : "); i >= 0 {
+			value = strings.TrimSpace(value[i+3:])
+		}
+		if value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+
+	for i := 0; i < len(query); i++ {
+		switch {
+		case query[i] == '\\' && i+1 < len(query) && query[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case query[i] == ',':
+			appendValue()
+		default:
+			current.WriteByte(query[i])
+		}
+	}
+	appendValue()
+
+	return values
+}
+
+// optionValues returns the values of the options saved with a variable
+func optionValues(spec *simplejson.Json) []string {
+	var values []string
+	for _, obj := range spec.Get("options").MustArray() {
+		for _, value := range dashboardvariables.StringValues(simplejson.NewFromAny(obj).Get("value")) {
+			if value != variableAllValue && !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// selectVariables validates the variable values requested by a viewer, and returns the values to interpolate the
+// queries with. Variables without requested values use their current values, see currentValues.
+func selectVariables(variables []*publicVariable, requested map[string][]string) (map[string]*dashboardvariables.Variable, error) {
+	for name := range requested {
+		if !slices.ContainsFunc(variables, func(v *publicVariable) bool { return v.Name == name }) {
+			return nil, models.ErrInvalidVariable.Errorf("selectVariables: variable %s can't be changed", name)
+		}
+	}
+
+	selected := make(map[string]*dashboardvariables.Variable, len(variables))
+	for _, v := range variables {
+		values, ok := requested[v.Name]
+		if !ok || len(values) == 0 {
+			values = v.currentValues()
+		}
+
+		if len(values) > 1 && !v.Multi {
+			return nil, models.ErrInvalidVariable.Errorf("selectVariables: variable %s doesn't allow multiple values", v.Name)
+		}
+
+		s := &dashboardvariables.Variable{Name: v.Name, Multi: v.Multi, IncludeAll: v.IncludeAll, AllValue: v.AllValue, Values: values}
+		for _, value := range values {
+			switch {
+			case value == variableAllValue && v.IncludeAll:
+				s.All = true
+			case !slices.Contains(v.Values, value):
+				return nil, models.ErrInvalidVariable.Errorf("selectVariables: value %q isn't allowed for variable %s", value, v.Name)
+			}
+		}
+		if s.All {
+			s.Values = v.Values
+		}
+
+		selected[v.Name] = s
+	}
+
+	return selected, nil
+}
+
+// applyVariables turns the supported variables of a public dashboard into custom variables with the values viewers can
+// select, so the frontend doesn't run variable queries and doesn't see them
+func applyVariables(variables []*publicVariable) {
+	for _, v := range variables {
+		if v.Type == variableTypeConstant {
+			continue
+		}
+
+		if v.model != v.spec {
+			v.model.Set("kind", "CustomVariable")
+		} else {
+			v.model.Set("type", variableTypeCustom)
+		}
+
+		options := make([]any, 0, len(v.Values)+1)
+		if v.IncludeAll {
+			options = append(options, map[string]any{"text": "All", "value": variableAllValue, "selected": slices.Contains(v.Current, variableAllValue)})
+		}
+		escaped := make([]string, len(v.Values))
+		for i, value := range v.Values {
+			escaped[i] = strings.ReplaceAll(value, ",", `\,`)
+			options = append(options, map[string]any{"text": value, "value": value, "selected": slices.Contains(v.Current, value)})
+		}
+
+		v.spec.Set("query", strings.Join(escaped, ","))
+		v.spec.Set("options", options)
+		v.spec.Set("allowCustomValue", false)
+		for _, key := range []string{"datasource", "definition", "regex", "refresh", "sort", "regexApplyTo"} {
+			v.spec.Del(key)
+		}
+	}
+}
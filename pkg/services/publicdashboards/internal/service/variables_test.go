@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/api/dtos"
+	"github.com/grafana/grafana/pkg/components/simplejson"
+	"github.com/grafana/grafana/pkg/infra/localcache"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/services/accesscontrol/actest"
+	"github.com/grafana/grafana/pkg/services/dashboards"
+	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/models"
+	"github.com/grafana/grafana/pkg/services/query"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+)
+
+const dashboardWithVariables = `
+{
+  "time": {"from": "now-6h", "to": "now"},
+  "templating": {
+    "list": [
+      {
+        "name": "env",
+        "type": "custom",
+        "query": "Production : prod,Staging : staging,dev\\,test",
+        "current": {"text": "Production", "value": "prod"}
+      },
+      {
+        "name": "region",
+        "type": "custom",
+        "query": "eu,us,ap",
+        "multi": true,
+        "includeAll": true,
+        "current": {"text": ["eu"], "value": ["eu"]}
+      },
+      {
+        "name": "prefix",
+        "type": "constant",
+        "query": "app"
+      },
+      {
+        "name": "host",
+        "type": "query",
+        "datasource": {"type": "prometheus", "uid": "prom"},
+        "query": {"qryType": 1, "query": "label_values(up, host)", "refId": "PrometheusVariableQueryEditor-VariableQuery"},
+        "definition": "label_values(up, host)",
+        "regex": "/(?<value>.*)\\.local/",
+        "sort": 1,
+        "current": {"text": "b", "value": "b"},
+        "options": [{"text": "b", "value": "b"}]
+      },
+      {
+        "name": "text",
+        "type": "textbox",
+        "query": "anything"
+      }
+    ]
+  }
+}`
+
+func newVariablesTestDashboard(t *testing.T) *dashboards.Dashboard {
+	t.Helper()
+
+	data, err := simplejson.NewJson([]byte(dashboardWithVariables))
+	require.NoError(t, err)
+
+	return &dashboards.Dashboard{UID: "dash", OrgID: 1, Version: 1, Data: data}
+}
+
+func newVariablesTestService(qds query.Service) *PublicDashboardServiceImpl {
+	return &PublicDashboardServiceImpl{
+		log:              log.New("test.logger"),
+		QueryDataService: qds,
+		userService:      &usertest.FakeUserService{ExpectedSignedInUser: &user.SignedInUser{UserID: 1, OrgID: 1}},
+		acService:        actest.FakeService{},
+		variableCache:    localcache.New(variableValuesCacheTTL, 2*variableValuesCacheTTL),
+	}
+}
+
+func TestResolveVariables(t *testing.T) {
+	pubdash := &models.PublicDashboard{Uid: "pubdash", CreatedBy: 1}
+
+	t.Run("resolves the values of supported variables", func(t *testing.T) {
+		fakeQueryService := &query.FakeQueryService{}
+		fakeQueryService.On("QueryData", mock.Anything, mock.Anything, false, mock.MatchedBy(func(req dtos.MetricRequest) bool {
+			return len(req.Queries) == 1 && req.Queries[0].Get("refId").MustString() == variableQueryRefID &&
+				req.Queries[0].Get("datasource").Get("uid").MustString() == "prom"
+		})).Return(&backend.QueryDataResponse{
+			Responses: backend.Responses{
+				variableQueryRefID: backend.DataResponse{
+					Frames: data.Frames{data.NewFrame("", data.NewField("host", nil, []string{"c.local", "a.local", "other", "b.local"}))},
+				},
+			},
+		}, nil).Once()
+		service := newVariablesTestService(fakeQueryService)
+
+		variables := service.resolveVariables(context.Background(), pubdash, newVariablesTestDashboard(t))
+
+		require.Len(t, variables, 4)
+		assert.Equal(t, []string{"prod", "staging", "dev,test"}, variables[0].Values)
+		assert.Equal(t, []string{"eu", "us", "ap"}, variables[1].Values)
+		assert.Equal(t, []string{"app"}, variables[2].Values)
+		assert.Equal(t, []string{"app"}, variables[2].Current)
+		assert.Equal(t, []string{"a", "b", "c"}, variables[3].Values)
+
+		// query variable values are cached
+		variables = service.resolveVariables(context.Background(), pubdash, newVariablesTestDashboard(t))
+		assert.Equal(t, []string{"a", "b", "c"}, variables[3].Values)
+		fakeQueryService.AssertExpectations(t)
+	})
+
+	t.Run("uses the saved options when the variable query fails", func(t *testing.T) {
+		service := newVariablesTestService(&query.FakeQueryService{})
+		service.userService = &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound}
+
+		variables := service.resolveVariables(context.Background(), pubdash, newVariablesTestDashboard(t))
+
+		require.Len(t, variables, 4)
+		assert.Equal(t, []string{"b"}, variables[3].Values)
+	})
+}
+
+func TestSelectVariables(t *testing.T) {
+	service := newVariablesTestService(&query.FakeQueryService{})
+	service.userService = &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound}
+	variables := service.resolveVariables(context.Background(), &models.PublicDashboard{Uid: "pubdash"}, newVariablesTestDashboard(t))
+
+	t.Run("uses the current values by default", func(t *testing.T) {
+		selected, err := selectVariables(variables, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"prod"}, selected["env"].Values)
+		assert.Equal(t, []string{"eu"}, selected["region"].Values)
+		assert.Equal(t, []string{"app"}, selected["prefix"].Values)
+	})
+
+	t.Run("uses the requested values", func(t *testing.T) {
+		selected, err := selectVariables(variables, map[string][]string{"env": {"dev,test"}, "region": {"us", "ap"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"dev,test"}, selected["env"].Values)
+		assert.Equal(t, []string{"us", "ap"}, selected["region"].Values)
+	})
+
+	t.Run("falls back to an allowed value when the current value isn't allowed anymore", func(t *testing.T) {
+		stale := []*publicVariable{
+			{Name: "host", Type: variableTypeQuery, Values: []string{"a", "b"}, Current: []string{"removed"}},
+			{Name: "region", Type: variableTypeQuery, Multi: true, IncludeAll: true, Values: []string{"eu", "us"}, Current: []string{"removed"}},
+		}
+
+		selected, err := selectVariables(stale, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a"}, selected["host"].Values)
+		assert.True(t, selected["region"].All)
+		assert.Equal(t, []string{"eu", "us"}, selected["region"].Values)
+	})
+
+	t.Run("selects every value for all", func(t *testing.T) {
+		selected, err := selectVariables(variables, map[string][]string{"region": {variableAllValue}})
+		require.NoError(t, err)
+		assert.True(t, selected["region"].All)
+		assert.Equal(t, []string{"eu", "us", "ap"}, selected["region"].Values)
+	})
+
+	testCases := []struct {
+		name      string
+		requested map[string][]string
+	}{
+		{name: "unknown variable", requested: map[string][]string{"text": {"anything"}}},
+		{name: "value not allowed", requested: map[string][]string{"env": {"prod\"} or vector(1)"}}},
+		{name: "multiple values for a single value variable", requested: map[string][]string{"env": {"prod", "staging"}}},
+		{name: "all for a variable without all", requested: map[string][]string{"env": {variableAllValue}}},
+		{name: "constant value changed", requested: map[string][]string{"prefix": {"other"}}},
+	}
+	for _, tc := range testCases {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			_, err := selectVariables(variables, tc.requested)
+			require.ErrorIs(t, err, models.ErrInvalidVariable)
+		})
+	}
+}
+
+func TestApplyVariables(t *testing.T) {
+	service := newVariablesTestService(&query.FakeQueryService{})
+	service.userService = &usertest.FakeUserService{ExpectedError: user.ErrUserNotFound}
+	dashboard := newVariablesTestDashboard(t)
+
+	applyVariables(service.resolveVariables(context.Background(), &models.PublicDashboard{Uid: "pubdash"}, dashboard))
+
+	host := dashboard.Data.Get("templating").Get("list").GetIndex(3)
+	assert.Equal(t, "custom", host.Get("type").MustString())
+	assert.Equal(t, "b", host.Get("query").MustString())
+	assert.False(t, host.Get("allowCustomValue").MustBool(true))
+	_, ok := host.CheckGet("definition")
+	assert.False(t, ok)
+	_, ok = host.CheckGet("datasource")
+	assert.False(t, ok)
+
+	env := dashboard.Data.Get("templating").Get("list").GetIndex(0)
+	assert.Equal(t, `prod,staging,dev\,test`, env.Get("query").MustString())
+
+	text := dashboard.Data.Get("templating").Get("list").GetIndex(4)
+	assert.Equal(t, "textbox", text.Get("type").MustString())
+}
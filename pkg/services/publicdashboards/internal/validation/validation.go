@@ -1,6 +1,8 @@
 package validation
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/gtime"
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/models"
@@ -13,6 +15,15 @@ func ValidatePublicDashboard(dto *models.SavePublicDashboardDTO) error {
 		return models.ErrInvalidShareType.Errorf("ValidateSavePublicDashboard: invalid share type")
 	}
 
+	// a zero expiry removes the expiry of the public dashboard
+	if expiresAt := dto.PublicDashboard.ExpiresAt; expiresAt != nil && !expiresAt.IsZero() && !expiresAt.After(time.Now()) {
+		return models.ErrInvalidExpiry.Errorf("ValidateSavePublicDashboard: expiry date is in the past")
+	}
+
+	if dto.PublicDashboard.RateLimit != nil && *dto.PublicDashboard.RateLimit < 0 {
+		return models.ErrInvalidRateLimit.Errorf("ValidateSavePublicDashboard: rateLimit should be greater than or equal to 0")
+	}
+
 	return nil
 }
 
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"github.com/grafana/grafana/pkg/services/publicdashboards/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +23,30 @@ func TestValidatePublicDashboard(t *testing.T) {
 		err := ValidatePublicDashboard(dto)
 		require.Error(t, err)
 	})
+
+	t.Run("Returns no error when expiry is in the future or removed", func(t *testing.T) {
+		for _, expiresAt := range []time.Time{time.Now().Add(time.Hour), {}} {
+			dto := &models.SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &models.PublicDashboardDTO{ExpiresAt: &expiresAt}}
+
+			err := ValidatePublicDashboard(dto)
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("Returns error when expiry is in the past", func(t *testing.T) {
+		expiresAt := time.Now().Add(-time.Hour)
+		dto := &models.SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &models.PublicDashboardDTO{ExpiresAt: &expiresAt}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, models.ErrInvalidExpiry)
+	})
+
+	t.Run("Returns error when rate limit is negative", func(t *testing.T) {
+		dto := &models.SavePublicDashboardDTO{DashboardUid: "abc123", UserId: 1, PublicDashboard: &models.PublicDashboardDTO{RateLimit: new(int64(-1))}}
+
+		err := ValidatePublicDashboard(dto)
+		require.ErrorIs(t, err, models.ErrInvalidRateLimit)
+	})
 }
 
 func TestValidateQueryPublicDashboardRequest(t *testing.T) {
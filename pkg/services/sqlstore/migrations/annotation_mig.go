@@ -202,6 +202,14 @@ func addAnnotationMig(mg *Migrator) {
 	}))
 
 	mg.AddMigration("Add missing dashboard_uid to annotation table", &SetDashboardUIDMigration{})
+
+	mg.AddMigration("Add import_key column to annotation table", NewAddColumnMigration(table, &Column{
+		Name: "import_key", Type: DB_NVarchar, Length: 190, Nullable: true,
+	}))
+
+	mg.AddMigration("Add unique index for org_id_import_key on annotation table", NewAddIndexMigration(table, &Index{
+		Cols: []string{"org_id", "import_key"}, Type: UniqueIndex,
+	}))
 }
 
 type AddMakeRegionSingleRowMigration struct {
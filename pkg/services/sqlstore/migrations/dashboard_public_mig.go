@@ -96,4 +96,36 @@ func addPublicDashboardMigration(mg *Migrator) {
 	mg.AddMigration("backfill empty share column fields with default of public", NewRawSQLMigration(
 		"UPDATE dashboard_public SET share='public' WHERE share=''",
 	))
+
+	mg.AddMigration("add expires_at column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "expires_at",
+		Type:     DB_DateTime,
+		Nullable: true,
+	}))
+
+	mg.AddMigration("add index dashboard_public.expires_at", NewAddIndexMigration(dashboardPublicCfgV2, &Index{
+		Cols: []string{"expires_at"},
+	}))
+
+	// the rate limit is the number of loads per minute, counted in the window of the current minute
+	mg.AddMigration("add rate_limit column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "rate_limit",
+		Type:     DB_BigInt,
+		Nullable: false,
+		Default:  "0",
+	}))
+
+	mg.AddMigration("add rate_limit_window column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "rate_limit_window",
+		Type:     DB_BigInt,
+		Nullable: false,
+		Default:  "0",
+	}))
+
+	mg.AddMigration("add rate_limit_count column", NewAddColumnMigration(dashboardPublicCfgV2, &Column{
+		Name:     "rate_limit_count",
+		Type:     DB_BigInt,
+		Nullable: false,
+		Default:  "0",
+	}))
 }
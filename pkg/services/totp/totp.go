@@ -0,0 +1,76 @@
+package totp
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+)
+
+var (
+	ErrUnavailable        = errutil.NotFound("totp.unavailable", errutil.WithPublicMessage("Two-factor authentication is not enabled"))
+	ErrRequired           = errutil.Unauthorized("totp.required", errutil.WithPublicMessage("Verification code required"))
+	ErrInvalidCode        = errutil.Unauthorized("totp.invalid", errutil.WithPublicMessage("Invalid verification code"))
+	ErrNotEnrolled        = errutil.BadRequest("totp.not-enrolled", errutil.WithPublicMessage("Two-factor authentication is not set up"))
+	ErrAlreadyEnabled     = errutil.BadRequest("totp.already-enabled", errutil.WithPublicMessage("Two-factor authentication is already enabled"))
+	ErrEnforced           = errutil.Forbidden("totp.enforced", errutil.WithPublicMessage("Two-factor authentication is required for your account"))
+	ErrEnrollmentRequired = errutil.Unauthorized("totp.enrollment-required").MustTemplate(
+		"Two-factor authentication must be set up",
+		errutil.WithPublic("Two-factor authentication is required, add the secret to an authenticator app and enter the verification code"),
+	)
+)
+
+type Service interface {
+	// GetStatus returns the two-factor authentication status of a user.
+	GetStatus(ctx context.Context, userID int64) (*Status, error)
+	// Enroll generates a new secret for a user, which is only used for logging in once it's activated.
+	Enroll(ctx context.Context, userID int64, login string) (*Enrollment, error)
+	// Activate enables two-factor authentication with the enrolled secret if the code is valid, and returns the
+	// recovery codes of the user.
+	Activate(ctx context.Context, userID int64, code string) (*RecoveryCodes, error)
+	// Disable disables two-factor authentication for a user if the code is valid.
+	Disable(ctx context.Context, userID int64, code string) error
+	// Reset disables two-factor authentication for a user without a code, e.g. when an administrator resets a user
+	// that lost both their authenticator and recovery codes.
+	Reset(ctx context.Context, userID int64) error
+	// RegenerateRecoveryCodes replaces the recovery codes of a user if the code is valid.
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*RecoveryCodes, error)
+	// VerifyLogin checks the second factor of a user logging in with a password. The code is either a time-based
+	// one-time password or an unused recovery code. When the login completes an enrollment required by the
+	// enforcement, the recovery codes of the user are returned.
+	VerifyLogin(ctx context.Context, userID int64, login, code string) (*RecoveryCodes, error)
+	// DeleteByUser removes the two-factor authentication state of a deleted user.
+	DeleteByUser(ctx context.Context, userID int64) error
+	// GetOrgEnforcement returns true when two-factor authentication is enforced for all the members of an org.
+	GetOrgEnforcement(ctx context.Context, orgID int64) (bool, error)
+	// SetOrgEnforcement enforces two-factor authentication for all the members of an org, or stops enforcing it.
+	SetOrgEnforcement(ctx context.Context, orgID int64, enforced bool) error
+}
+
+type Status struct {
+	// Available is false when two-factor authentication is disabled on the server
+	Available bool `json:"available"`
+	Enabled   bool `json:"enabled"`
+	// Enforced is true when two-factor authentication is enforced for administrators and the user is one, or it is
+	// enforced by an org of the user
+	Enforced               bool `json:"enforced"`
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+type Enrollment struct {
+	// Secret is the base32 encoded secret to add to an authenticator app
+	Secret string `json:"secret"`
+	// URL is the otpauth:// key URI of the secret, usually shown as a QR code
+	URL string `json:"url"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"codes"`
+}
+
+type CodeCommand struct {
+	Code string `json:"code"`
+}
+
+type OrgEnforcement struct {
+	Enforced bool `json:"enforced"`
+}
@@ -0,0 +1,116 @@
+package totpimpl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // RFC 6238 uses HMAC-SHA1, which is the only algorithm supported by most authenticator apps
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/util"
+)
+
+const (
+	issuer = "Grafana"
+	// period and digits are the defaults of RFC 6238, the only values supported by every authenticator app
+	period = 30
+	digits = 6
+	// skew is the number of periods before and after the current one codes are accepted for, to allow for clock drift
+	skew       = 1
+	secretSize = 20
+
+	recoveryCodeCount    = 10
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghijklmnopqrstuvwxyz234567"
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// generateSecret returns a random base32 encoded secret
+func generateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// generateCode returns the one-time password of a counter as defined by RFC 4226
+func generateCode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+// validateCode checks a time-based one-time password, and returns the counter it was generated for. Codes of counters
+// up to lastCounter were already used and are rejected so that a code can't be replayed.
+func validateCode(secret, code string, now time.Time, lastCounter int64) (int64, bool) {
+	key, err := secretEncoding.DecodeString(secret)
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	current := now.Unix() / period
+	for counter := current - skew; counter <= current+skew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(generateCode(key, uint64(counter))), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// keyURL returns the key URI of a secret used by authenticator apps, see
+// https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func keyURL(login, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(digits))
+	params.Set("period", strconv.Itoa(period))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+login) + "?" + params.Encode()
+}
+
+// generateRecoveryCodes returns new recovery codes formatted for display, along with the hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for range recoveryCodeCount {
+		code, err := util.GetRandomString(recoveryCodeLength, []byte(recoveryCodeAlphabet)...)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring its formatting. Recovery codes are random, so unlike passwords
+// they don't need a salt or a slow hash.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
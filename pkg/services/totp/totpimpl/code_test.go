@@ -0,0 +1,80 @@
+package totpimpl
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCode(t *testing.T) {
+	// the SHA1 test vectors of RFC 6238, truncated to 6 digits
+	secret := secretEncoding.EncodeToString([]byte("12345678901234567890"))
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+	}
+	for _, tc := range testCases {
+		counter, ok := validateCode(secret, tc.code, time.Unix(tc.unix, 0), 0)
+		require.True(t, ok, "code at %d", tc.unix)
+		assert.Equal(t, tc.unix/period, counter)
+	}
+
+	t.Run("accepts codes of the previous and next period", func(t *testing.T) {
+		_, ok := validateCode(secret, "050471", time.Unix(1111111111+period, 0), 0)
+		assert.True(t, ok)
+		_, ok = validateCode(secret, "050471", time.Unix(1111111111-period, 0), 0)
+		assert.True(t, ok)
+		_, ok = validateCode(secret, "050471", time.Unix(1111111111+2*period, 0), 0)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects codes already used", func(t *testing.T) {
+		_, ok := validateCode(secret, "050471", time.Unix(1111111111, 0), 1111111111/period)
+		assert.False(t, ok)
+	})
+
+	t.Run("rejects malformed codes", func(t *testing.T) {
+		_, ok := validateCode(secret, "50471", time.Unix(1111111111, 0), 0)
+		assert.False(t, ok)
+		_, ok = validateCode("not base32!", "050471", time.Unix(1111111111, 0), 0)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := generateSecret()
+	require.NoError(t, err)
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, secretSize)
+
+	u, err := url.Parse(keyURL("admin@example.com", secret))
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Grafana:admin@example.com", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodeCount)
+	require.Len(t, hashes, recoveryCodeCount)
+
+	assert.Regexp(t, "^[a-z2-7]{5}-[a-z2-7]{5}$", codes[0])
+	assert.Equal(t, hashes[0], hashRecoveryCode(codes[0]))
+	// the formatting of the code is ignored
+	assert.Equal(t, hashes[0], hashRecoveryCode(" "+codes[0][:5]+codes[0][6:]+" "))
+}
@@ -0,0 +1,510 @@
+package totpimpl
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/org"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const (
+	// the state of a user is stored encrypted, in the namespace of the user id
+	kvStoreType = "totp"
+	// the enforcement of an org is stored in the org, in the totp namespace
+	orgKVNamespace = "totp"
+	orgEnforcedKey = "enforced"
+	// lockRetries bounds the wait for the lock of a user, held by another request changing their state
+	lockRetries = 20
+)
+
+var lockTimeConfig = serverlock.LockTimeConfig{
+	MaxInterval: 30 * time.Second,
+	MinWait:     100 * time.Millisecond,
+	MaxWait:     500 * time.Millisecond,
+}
+
+var errLockRetriesExhausted = errors.New("retries exhausted waiting for the totp lock of the user")
+
+var _ totp.Service = new(Service)
+
+type serverLocker interface {
+	LockExecuteAndReleaseWithRetries(context.Context, string, serverlock.LockTimeConfig, func(ctx context.Context), ...serverlock.RetryOpt) error
+}
+
+func ProvideService(cfg *setting.Cfg, secretsStore secretskvs.SecretsKVStore, kvStore kvstore.KVStore, serverLock *serverlock.ServerLockService, userService user.Service, orgService org.Service) *Service {
+	return &Service{
+		cfg:          cfg,
+		secretsStore: secretsStore,
+		kvStore:      kvStore,
+		serverLock:   serverLock,
+		userService:  userService,
+		orgService:   orgService,
+		audit:        log.New("totp.audit"),
+		now:          time.Now,
+	}
+}
+
+type Service struct {
+	cfg          *setting.Cfg
+	secretsStore secretskvs.SecretsKVStore
+	kvStore      kvstore.KVStore
+	serverLock   serverLocker
+	userService  user.Service
+	orgService   org.Service
+	// audit logs the changes to the second factor of users, and its use, for security reviews
+	audit log.Logger
+	now   func() time.Time
+}
+
+type userState struct {
+	Enabled bool   `json:"enabled"`
+	Secret  string `json:"secret,omitempty"`
+	// PendingSecret is the secret of an enrollment that hasn't been activated yet
+	PendingSecret string `json:"pendingSecret,omitempty"`
+	// LastCounter is the counter of the last code used, to prevent codes from being replayed
+	LastCounter int64 `json:"lastCounter,omitempty"`
+	// RecoveryCodes are the hashes of the unused recovery codes
+	RecoveryCodes []string `json:"recoveryCodes,omitempty"`
+}
+
+func (s *Service) GetStatus(ctx context.Context, userID int64) (*totp.Status, error) {
+	if !s.cfg.TOTPEnabled {
+		return &totp.Status{}, nil
+	}
+
+	state, err := s.getState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enforced, err := s.isEnforced(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &totp.Status{
+		Available:              true,
+		Enabled:                state.Enabled,
+		Enforced:               enforced,
+		RecoveryCodesRemaining: len(state.RecoveryCodes),
+	}, nil
+}
+
+func (s *Service) Enroll(ctx context.Context, userID int64, login string) (*totp.Enrollment, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	var enrollment *totp.Enrollment
+	err := s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		state, err := s.getState(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if state.Enabled {
+			return totp.ErrAlreadyEnabled.Errorf("user %d already has totp enabled", userID)
+		}
+
+		state.PendingSecret, err = generateSecret()
+		if err != nil {
+			return err
+		}
+		if err := s.setState(ctx, userID, state); err != nil {
+			return err
+		}
+
+		enrollment = &totp.Enrollment{Secret: state.PendingSecret, URL: keyURL(login, state.PendingSecret)}
+		return nil
+	})
+	return enrollment, err
+}
+
+func (s *Service) Activate(ctx context.Context, userID int64, code string) (*totp.RecoveryCodes, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	var recoveryCodes *totp.RecoveryCodes
+	err := s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		state, err := s.getState(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if state.Enabled {
+			return totp.ErrAlreadyEnabled.Errorf("user %d already has totp enabled", userID)
+		}
+		if state.PendingSecret == "" {
+			return totp.ErrNotEnrolled.Errorf("user %d has no pending enrollment", userID)
+		}
+
+		recoveryCodes, err = s.activate(ctx, userID, state, code)
+		if err != nil {
+			return err
+		}
+
+		s.audit.FromContext(ctx).Info("Enabled totp", "userID", userID)
+		return nil
+	})
+	return recoveryCodes, err
+}
+
+func (s *Service) Disable(ctx context.Context, userID int64, code string) error {
+	if !s.cfg.TOTPEnabled {
+		return totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	enforced, err := s.isEnforced(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if enforced {
+		return totp.ErrEnforced.Errorf("totp is enforced for user %d", userID)
+	}
+
+	return s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		state, err := s.getEnabledState(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.verify(ctx, userID, state, code); err != nil {
+			return err
+		}
+
+		if err := s.deleteState(ctx, userID); err != nil {
+			return err
+		}
+
+		s.audit.FromContext(ctx).Info("Disabled totp", "userID", userID)
+		return nil
+	})
+}
+
+func (s *Service) Reset(ctx context.Context, userID int64) error {
+	err := s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		return s.deleteState(ctx, userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	// the second factor of the user can be set up again by anyone with their password, resets are logged as warnings
+	// so that they stand out
+	ctxLogger := s.audit.FromContext(ctx)
+	if requester, err := identity.GetRequester(ctx); err == nil {
+		ctxLogger.Warn("Reset totp", "userID", userID, "by", requester.GetUID())
+	} else {
+		ctxLogger.Warn("Reset totp", "userID", userID)
+	}
+	return nil
+}
+
+func (s *Service) DeleteByUser(ctx context.Context, userID int64) error {
+	return s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		return s.deleteState(ctx, userID)
+	})
+}
+
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*totp.RecoveryCodes, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	var recoveryCodes *totp.RecoveryCodes
+	err := s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		state, err := s.getEnabledState(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.verify(ctx, userID, state, code); err != nil {
+			return err
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			return err
+		}
+		state.RecoveryCodes = hashes
+
+		if err := s.setState(ctx, userID, state); err != nil {
+			return err
+		}
+
+		s.audit.FromContext(ctx).Info("Regenerated totp recovery codes", "userID", userID)
+		recoveryCodes = &totp.RecoveryCodes{Codes: codes}
+		return nil
+	})
+	return recoveryCodes, err
+}
+
+func (s *Service) VerifyLogin(ctx context.Context, userID int64, login, code string) (*totp.RecoveryCodes, error) {
+	if !s.cfg.TOTPEnabled {
+		return nil, nil
+	}
+
+	// most users have no second factor, they log in without waiting for the lock
+	state, err := s.getState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !state.Enabled {
+		enforced, err := s.isEnforced(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !enforced {
+			return nil, nil
+		}
+	}
+
+	var recoveryCodes *totp.RecoveryCodes
+	err = s.withUserLock(ctx, userID, func(ctx context.Context) error {
+		// the state is read again, a concurrent login can have used the code or completed the enrollment
+		state, err := s.getState(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if state.Enabled {
+			if code == "" {
+				return totp.ErrRequired.Errorf("user %d has totp enabled", userID)
+			}
+			if err := s.verify(ctx, userID, state, code); err != nil {
+				return err
+			}
+			return s.setState(ctx, userID, state)
+		}
+
+		enforced, err := s.isEnforced(ctx, userID)
+		if err != nil || !enforced {
+			return err
+		}
+
+		recoveryCodes, err = s.enrollOnLogin(ctx, userID, login, state, code)
+		return err
+	})
+	return recoveryCodes, err
+}
+
+// enrollOnLogin continues the enrollment of a user that has to set up a second factor before logging in. The login is
+// only allowed once the pending secret is activated, and the recovery codes are returned to the login.
+func (s *Service) enrollOnLogin(ctx context.Context, userID int64, login string, state *userState, code string) (*totp.RecoveryCodes, error) {
+	if code == "" || state.PendingSecret == "" {
+		if state.PendingSecret == "" {
+			var err error
+			state.PendingSecret, err = generateSecret()
+			if err != nil {
+				return nil, err
+			}
+			if err := s.setState(ctx, userID, state); err != nil {
+				return nil, err
+			}
+			s.audit.FromContext(ctx).Info("Started totp enrollment on login", "userID", userID)
+		}
+		return nil, totp.ErrEnrollmentRequired.Build(errutil.TemplateData{
+			Public: map[string]any{"secret": state.PendingSecret, "url": keyURL(login, state.PendingSecret)},
+		})
+	}
+
+	recoveryCodes, err := s.activate(ctx, userID, state, code)
+	if err != nil {
+		return nil, err
+	}
+
+	// the enrollment was only protected by the password of the user, it's logged as a warning so that it can be
+	// reviewed, and reverted with a reset if it wasn't done by the user
+	s.audit.FromContext(ctx).Warn("Enabled totp on login", "userID", userID)
+	return recoveryCodes, nil
+}
+
+func (s *Service) GetOrgEnforcement(ctx context.Context, orgID int64) (bool, error) {
+	if !s.cfg.TOTPEnabled {
+		return false, totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	value, ok, err := s.kvStore.Get(ctx, orgID, orgKVNamespace, orgEnforcedKey)
+	if err != nil || !ok {
+		return false, err
+	}
+	return strconv.ParseBool(value)
+}
+
+func (s *Service) SetOrgEnforcement(ctx context.Context, orgID int64, enforced bool) error {
+	if !s.cfg.TOTPEnabled {
+		return totp.ErrUnavailable.Errorf("totp is disabled")
+	}
+
+	var err error
+	if enforced {
+		err = s.kvStore.Set(ctx, orgID, orgKVNamespace, orgEnforcedKey, strconv.FormatBool(true))
+	} else {
+		err = s.kvStore.Del(ctx, orgID, orgKVNamespace, orgEnforcedKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	ctxLogger := s.audit.FromContext(ctx)
+	if requester, err := identity.GetRequester(ctx); err == nil {
+		ctxLogger.Info("Changed totp enforcement of org", "orgID", orgID, "enforced", enforced, "by", requester.GetUID())
+	} else {
+		ctxLogger.Info("Changed totp enforcement of org", "orgID", orgID, "enforced", enforced)
+	}
+	return nil
+}
+
+// activate enables the pending secret of a user if the code was generated with it, and saves the state with new
+// recovery codes
+func (s *Service) activate(ctx context.Context, userID int64, state *userState, code string) (*totp.RecoveryCodes, error) {
+	counter, ok := validateCode(state.PendingSecret, code, s.now(), 0)
+	if !ok {
+		s.audit.FromContext(ctx).Warn("Invalid totp code", "userID", userID, "enrollment", true)
+		return nil, totp.ErrInvalidCode.Errorf("invalid code for pending secret")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	state.Enabled = true
+	state.Secret = state.PendingSecret
+	state.PendingSecret = ""
+	state.LastCounter = counter
+	state.RecoveryCodes = hashes
+	if err := s.setState(ctx, userID, state); err != nil {
+		return nil, err
+	}
+
+	return &totp.RecoveryCodes{Codes: codes}, nil
+}
+
+// verify checks a one-time password or a recovery code, which is removed once used. The state has to be saved if the
+// code is valid.
+func (s *Service) verify(ctx context.Context, userID int64, state *userState, code string) error {
+	code = strings.TrimSpace(code)
+
+	if counter, ok := validateCode(state.Secret, code, s.now(), state.LastCounter); ok {
+		state.LastCounter = counter
+		return nil
+	}
+
+	hash := hashRecoveryCode(code)
+	for i, recoveryCode := range state.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1 {
+			state.RecoveryCodes = append(state.RecoveryCodes[:i], state.RecoveryCodes[i+1:]...)
+			s.audit.FromContext(ctx).Info("Used totp recovery code", "userID", userID, "recoveryCodesRemaining", len(state.RecoveryCodes))
+			return nil
+		}
+	}
+
+	s.audit.FromContext(ctx).Warn("Invalid totp code", "userID", userID)
+	return totp.ErrInvalidCode.Errorf("invalid code")
+}
+
+// isEnforced returns true when totp is enforced for admins and the user is a server admin or an admin of any org, or
+// when any org of the user enforces it for all its members
+func (s *Service) isEnforced(ctx context.Context, userID int64) (bool, error) {
+	if s.cfg.TOTPEnforceForAdmins {
+		usr, err := s.userService.GetByID(ctx, &user.GetUserByIDQuery{ID: userID})
+		if err != nil {
+			return false, err
+		}
+		if usr.IsAdmin {
+			return true, nil
+		}
+	}
+
+	orgs, err := s.orgService.GetUserOrgList(ctx, &org.GetUserOrgListQuery{UserID: userID})
+	if err != nil {
+		return false, err
+	}
+	for _, o := range orgs {
+		if s.cfg.TOTPEnforceForAdmins && o.Role == org.RoleAdmin {
+			return true, nil
+		}
+		enforced, err := s.GetOrgEnforcement(ctx, o.OrgID)
+		if err != nil {
+			return false, err
+		}
+		if enforced {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// withUserLock runs fn while holding the lock of the user, across instances, so that concurrent requests can't use
+// the same code twice or overwrite each other's changes to the state
+func (s *Service) withUserLock(ctx context.Context, userID int64, fn func(ctx context.Context) error) error {
+	retryOpt := func(attempts int) error {
+		if attempts < lockRetries {
+			return nil
+		}
+		return errLockRetriesExhausted
+	}
+
+	var fnErr error
+	lockErr := s.serverLock.LockExecuteAndReleaseWithRetries(ctx, fmt.Sprintf("totp-%d", userID), lockTimeConfig, func(ctx context.Context) {
+		fnErr = fn(ctx)
+	}, retryOpt)
+	if lockErr != nil {
+		return lockErr
+	}
+	return fnErr
+}
+
+func (s *Service) getEnabledState(ctx context.Context, userID int64) (*userState, error) {
+	state, err := s.getState(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !state.Enabled {
+		return nil, totp.ErrNotEnrolled.Errorf("user %d doesn't have totp enabled", userID)
+	}
+	return state, nil
+}
+
+func (s *Service) getState(ctx context.Context, userID int64) (*userState, error) {
+	value, ok, err := s.secretsStore.Get(ctx, 0, strconv.FormatInt(userID, 10), kvStoreType)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &userState{}
+	if !ok {
+		return state, nil
+	}
+	if err := json.Unmarshal([]byte(value), state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *Service) setState(ctx context.Context, userID int64, state *userState) error {
+	value, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.secretsStore.Set(ctx, 0, strconv.FormatInt(userID, 10), kvStoreType, string(value))
+}
+
+func (s *Service) deleteState(ctx context.Context, userID int64) error {
+	return s.secretsStore.Del(ctx, 0, strconv.FormatInt(userID, 10), kvStoreType)
+}
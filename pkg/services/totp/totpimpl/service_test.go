@@ -0,0 +1,320 @@
+package totpimpl
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/apimachinery/errutil"
+	"github.com/grafana/grafana/pkg/infra/kvstore"
+	"github.com/grafana/grafana/pkg/infra/log"
+	"github.com/grafana/grafana/pkg/infra/serverlock"
+	"github.com/grafana/grafana/pkg/services/org"
+	"github.com/grafana/grafana/pkg/services/org/orgtest"
+	secretskvs "github.com/grafana/grafana/pkg/services/secrets/kvstore"
+	"github.com/grafana/grafana/pkg/services/totp"
+	"github.com/grafana/grafana/pkg/services/user"
+	"github.com/grafana/grafana/pkg/services/user/usertest"
+	"github.com/grafana/grafana/pkg/setting"
+)
+
+const testUserID = 1
+
+// fakeServerLock runs the functions one at a time, like the server lock does for the same action
+type fakeServerLock struct {
+	mu sync.Mutex
+}
+
+func (f *fakeServerLock) LockExecuteAndReleaseWithRetries(ctx context.Context, actionName string, timeConfig serverlock.LockTimeConfig, fn func(ctx context.Context), retryOpts ...serverlock.RetryOpt) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn(ctx)
+	return nil
+}
+
+// syncSecretsStore makes the fake store safe to use from concurrent logins
+type syncSecretsStore struct {
+	secretskvs.SecretsKVStore
+	mu sync.Mutex
+}
+
+func (f *syncSecretsStore) Get(ctx context.Context, orgId int64, namespace string, typ string) (string, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.SecretsKVStore.Get(ctx, orgId, namespace, typ)
+}
+
+func (f *syncSecretsStore) Set(ctx context.Context, orgId int64, namespace string, typ string, value string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.SecretsKVStore.Set(ctx, orgId, namespace, typ, value)
+}
+
+func newTestService(t *testing.T, cfg *setting.Cfg, usr *user.User, orgs ...*org.UserOrgDTO) *Service {
+	t.Helper()
+
+	now := time.Unix(1700000000, 0)
+	return &Service{
+		cfg:          cfg,
+		secretsStore: &syncSecretsStore{SecretsKVStore: secretskvs.NewFakeSecretsKVStore()},
+		kvStore:      kvstore.NewFakeKVStore(),
+		serverLock:   &fakeServerLock{},
+		userService:  &usertest.FakeUserService{ExpectedUser: usr},
+		orgService:   &orgtest.FakeOrgService{ExpectedUserOrgDTO: orgs},
+		audit:        log.NewNopLogger(),
+		now:          func() time.Time { return now },
+	}
+}
+
+func verifyLogin(s *Service, code string) error {
+	_, err := s.VerifyLogin(context.Background(), testUserID, "user", code)
+	return err
+}
+
+// code returns the code of a secret for the current period of the service, or of a later period
+func code(t *testing.T, s *Service, secret string, periods int64) string {
+	t.Helper()
+
+	key, err := secretEncoding.DecodeString(secret)
+	require.NoError(t, err)
+	return generateCode(key, uint64(s.now().Unix()/period+periods))
+}
+
+func enable(t *testing.T, s *Service) (string, []string) {
+	t.Helper()
+
+	enrollment, err := s.Enroll(context.Background(), testUserID, "user")
+	require.NoError(t, err)
+	recoveryCodes, err := s.Activate(context.Background(), testUserID, code(t, s, enrollment.Secret, 0))
+	require.NoError(t, err)
+	return enrollment.Secret, recoveryCodes.Codes
+}
+
+func TestService_Enrollment(t *testing.T) {
+	cfg := &setting.Cfg{TOTPEnabled: true}
+
+	t.Run("activates the secret with a valid code", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+
+		enrollment, err := s.Enroll(context.Background(), testUserID, "user")
+		require.NoError(t, err)
+		assert.Contains(t, enrollment.URL, enrollment.Secret)
+
+		_, err = s.Activate(context.Background(), testUserID, "000000")
+		require.ErrorIs(t, err, totp.ErrInvalidCode)
+
+		recoveryCodes, err := s.Activate(context.Background(), testUserID, code(t, s, enrollment.Secret, 0))
+		require.NoError(t, err)
+		assert.Len(t, recoveryCodes.Codes, recoveryCodeCount)
+
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.Equal(t, &totp.Status{Available: true, Enabled: true, RecoveryCodesRemaining: recoveryCodeCount}, status)
+
+		_, err = s.Enroll(context.Background(), testUserID, "user")
+		require.ErrorIs(t, err, totp.ErrAlreadyEnabled)
+	})
+
+	t.Run("fails to activate without enrollment", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+
+		_, err := s.Activate(context.Background(), testUserID, "000000")
+		require.ErrorIs(t, err, totp.ErrNotEnrolled)
+	})
+
+	t.Run("fails when totp is disabled", func(t *testing.T) {
+		s := newTestService(t, &setting.Cfg{}, &user.User{ID: testUserID})
+
+		_, err := s.Enroll(context.Background(), testUserID, "user")
+		require.ErrorIs(t, err, totp.ErrUnavailable)
+
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.False(t, status.Available)
+	})
+}
+
+func TestService_VerifyLogin(t *testing.T) {
+	cfg := &setting.Cfg{TOTPEnabled: true}
+
+	t.Run("allows users without totp", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+
+		require.NoError(t, verifyLogin(s, ""))
+	})
+
+	t.Run("requires a valid code once enabled", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		secret, _ := enable(t, s)
+
+		require.ErrorIs(t, verifyLogin(s, ""), totp.ErrRequired)
+		require.ErrorIs(t, verifyLogin(s, "000000"), totp.ErrInvalidCode)
+		// the code used to activate can't be used again
+		require.ErrorIs(t, verifyLogin(s, code(t, s, secret, 0)), totp.ErrInvalidCode)
+
+		validCode := code(t, s, secret, 1)
+		require.NoError(t, verifyLogin(s, validCode))
+		require.ErrorIs(t, verifyLogin(s, validCode), totp.ErrInvalidCode)
+	})
+
+	t.Run("accepts each recovery code once", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		_, recoveryCodes := enable(t, s)
+
+		require.NoError(t, verifyLogin(s, recoveryCodes[3]))
+		require.ErrorIs(t, verifyLogin(s, recoveryCodes[3]), totp.ErrInvalidCode)
+
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesRemaining)
+	})
+
+	t.Run("accepts a code once across concurrent logins", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		secret, _ := enable(t, s)
+		validCode := code(t, s, secret, 1)
+
+		var wg sync.WaitGroup
+		var accepted atomic.Int32
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if verifyLogin(s, validCode) == nil {
+					accepted.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), accepted.Load())
+	})
+
+	t.Run("is skipped when totp is disabled on the server", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		enable(t, s)
+		s.cfg = &setting.Cfg{}
+
+		require.NoError(t, verifyLogin(s, ""))
+	})
+}
+
+func TestService_Enforcement(t *testing.T) {
+	cfg := &setting.Cfg{TOTPEnabled: true, TOTPEnforceForAdmins: true}
+
+	t.Run("allows users that aren't admins", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID}, &org.UserOrgDTO{OrgID: 1, Role: org.RoleEditor})
+
+		require.NoError(t, verifyLogin(s, ""))
+	})
+
+	t.Run("enrolls admins on login", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID}, &org.UserOrgDTO{OrgID: 1, Role: org.RoleViewer}, &org.UserOrgDTO{OrgID: 2, Role: org.RoleAdmin})
+
+		err := verifyLogin(s, "")
+		require.ErrorIs(t, err, totp.ErrEnrollmentRequired)
+
+		var enrollmentErr errutil.Error
+		require.ErrorAs(t, err, &enrollmentErr)
+		secret, ok := enrollmentErr.PublicPayload["secret"].(string)
+		require.True(t, ok)
+
+		// the same secret is used until the enrollment is completed
+		err = verifyLogin(s, "")
+		require.ErrorAs(t, err, &enrollmentErr)
+		assert.Equal(t, secret, enrollmentErr.PublicPayload["secret"])
+
+		require.ErrorIs(t, verifyLogin(s, "000000"), totp.ErrInvalidCode)
+		recoveryCodes, err := s.VerifyLogin(context.Background(), testUserID, "user", code(t, s, secret, 0))
+		require.NoError(t, err)
+		require.Len(t, recoveryCodes.Codes, recoveryCodeCount)
+
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.True(t, status.Enabled)
+		assert.True(t, status.Enforced)
+		assert.Equal(t, recoveryCodeCount, status.RecoveryCodesRemaining)
+
+		// the recovery codes returned by the login can be used
+		recoveryCodes, err = s.VerifyLogin(context.Background(), testUserID, "user", recoveryCodes.Codes[0])
+		require.NoError(t, err)
+		assert.Nil(t, recoveryCodes)
+	})
+
+	t.Run("enforces totp for the members of an org enforcing it", func(t *testing.T) {
+		s := newTestService(t, &setting.Cfg{TOTPEnabled: true}, &user.User{ID: testUserID}, &org.UserOrgDTO{OrgID: 1, Role: org.RoleViewer}, &org.UserOrgDTO{OrgID: 2, Role: org.RoleViewer})
+		require.NoError(t, verifyLogin(s, ""))
+
+		require.NoError(t, s.SetOrgEnforcement(context.Background(), 2, true))
+		enforced, err := s.GetOrgEnforcement(context.Background(), 2)
+		require.NoError(t, err)
+		assert.True(t, enforced)
+		require.ErrorIs(t, verifyLogin(s, ""), totp.ErrEnrollmentRequired)
+
+		require.NoError(t, s.SetOrgEnforcement(context.Background(), 2, false))
+		enforced, err = s.GetOrgEnforcement(context.Background(), 2)
+		require.NoError(t, err)
+		assert.False(t, enforced)
+		require.NoError(t, verifyLogin(s, ""))
+	})
+
+	t.Run("prevents admins from disabling totp", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID, IsAdmin: true})
+		secret, _ := enable(t, s)
+
+		require.ErrorIs(t, s.Disable(context.Background(), testUserID, code(t, s, secret, 1)), totp.ErrEnforced)
+
+		require.NoError(t, s.Reset(context.Background(), testUserID))
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+	})
+}
+
+func TestService_ManageEnabled(t *testing.T) {
+	cfg := &setting.Cfg{TOTPEnabled: true}
+
+	t.Run("disables totp with a valid code", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		secret, _ := enable(t, s)
+
+		require.ErrorIs(t, s.Disable(context.Background(), testUserID, "000000"), totp.ErrInvalidCode)
+		require.NoError(t, s.Disable(context.Background(), testUserID, code(t, s, secret, 1)))
+		require.NoError(t, verifyLogin(s, ""))
+	})
+
+	t.Run("regenerates recovery codes", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		secret, recoveryCodes := enable(t, s)
+
+		newCodes, err := s.RegenerateRecoveryCodes(context.Background(), testUserID, code(t, s, secret, 1))
+		require.NoError(t, err)
+		assert.Len(t, newCodes.Codes, recoveryCodeCount)
+
+		require.ErrorIs(t, verifyLogin(s, recoveryCodes[0]), totp.ErrInvalidCode)
+		require.NoError(t, verifyLogin(s, newCodes.Codes[0]))
+	})
+
+	t.Run("deletes the state of deleted users", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+		enable(t, s)
+
+		require.NoError(t, s.DeleteByUser(context.Background(), testUserID))
+		status, err := s.GetStatus(context.Background(), testUserID)
+		require.NoError(t, err)
+		assert.False(t, status.Enabled)
+	})
+
+	t.Run("fails without totp enabled", func(t *testing.T) {
+		s := newTestService(t, cfg, &user.User{ID: testUserID})
+
+		require.ErrorIs(t, s.Disable(context.Background(), testUserID, "000000"), totp.ErrNotEnrolled)
+		_, err := s.RegenerateRecoveryCodes(context.Background(), testUserID, "000000")
+		require.ErrorIs(t, err, totp.ErrNotEnrolled)
+	})
+}
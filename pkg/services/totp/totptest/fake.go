@@ -0,0 +1,57 @@
+package totptest
+
+import (
+	"context"
+
+	"github.com/grafana/grafana/pkg/services/totp"
+)
+
+var _ totp.Service = new(FakeService)
+
+type FakeService struct {
+	ExpectedStatus         *totp.Status
+	ExpectedEnrollment     *totp.Enrollment
+	ExpectedRecoveryCodes  *totp.RecoveryCodes
+	ExpectedOrgEnforcement bool
+	ExpectedErr            error
+}
+
+func (f *FakeService) GetStatus(ctx context.Context, userID int64) (*totp.Status, error) {
+	return f.ExpectedStatus, f.ExpectedErr
+}
+
+func (f *FakeService) Enroll(ctx context.Context, userID int64, login string) (*totp.Enrollment, error) {
+	return f.ExpectedEnrollment, f.ExpectedErr
+}
+
+func (f *FakeService) Activate(ctx context.Context, userID int64, code string) (*totp.RecoveryCodes, error) {
+	return f.ExpectedRecoveryCodes, f.ExpectedErr
+}
+
+func (f *FakeService) Disable(ctx context.Context, userID int64, code string) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) Reset(ctx context.Context, userID int64) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) (*totp.RecoveryCodes, error) {
+	return f.ExpectedRecoveryCodes, f.ExpectedErr
+}
+
+func (f *FakeService) VerifyLogin(ctx context.Context, userID int64, login, code string) (*totp.RecoveryCodes, error) {
+	return f.ExpectedRecoveryCodes, f.ExpectedErr
+}
+
+func (f *FakeService) DeleteByUser(ctx context.Context, userID int64) error {
+	return f.ExpectedErr
+}
+
+func (f *FakeService) GetOrgEnforcement(ctx context.Context, orgID int64) (bool, error) {
+	return f.ExpectedOrgEnforcement, f.ExpectedErr
+}
+
+func (f *FakeService) SetOrgEnforcement(ctx context.Context, orgID int64, enforced bool) error {
+	return f.ExpectedErr
+}
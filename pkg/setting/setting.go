@@ -356,6 +356,10 @@ type Cfg struct {
 	ManagedServiceAccountsEnabled     bool
 	IDUseExternalGroupsForGroupsClaim bool
 
+	// TOTP second factor for users logging in with their Grafana password
+	TOTPEnabled          bool
+	TOTPEnforceForAdmins bool
+
 	// AWS Plugin Auth
 	AWSAllowedAuthProviders          []string
 	AWSAssumeRoleEnabled             bool
@@ -404,6 +408,9 @@ type Cfg struct {
 	// DistributedCache
 	RemoteCacheOptions *RemoteCacheSettings
 
+	// Query caching
+	QueryCaching QueryCachingSettings
+
 	// Deprecated: no longer used
 	ViewersCanEdit bool
 
@@ -449,6 +456,9 @@ type Cfg struct {
 	// Default behavior for the "Allow as recording rules target" toggle when configuring a data source.
 	// It only works if the data source's `jsonData.allowAsRecordingRulesTarget` prop does not contain a previously configured value.
 	DefaultAllowRecordingRulesTargetAlertsUIToggle bool
+	// DataSourceQueryPoliciesFile is the path of the file with the policies
+	// restricting what users can query from data sources.
+	DataSourceQueryPoliciesFile string
 
 	// IP range access control
 	IPRangeACEnabled     bool
@@ -582,6 +592,29 @@ type Cfg struct {
 	// LiveClientQueueMaxSize is the maximum size in bytes of the client queue
 	// for Live connections. Defaults to 4MB.
 	LiveClientQueueMaxSize int
+	// LiveHistoryMaxPoints is the number of points kept per managed stream
+	// channel, returned to new subscribers. 0 disables the history unless
+	// LiveHistoryMaxAge is set.
+	LiveHistoryMaxPoints int
+	// LiveHistoryMaxAge is the maximum age of the points kept per managed
+	// stream channel. 0 means no age limit.
+	LiveHistoryMaxAge time.Duration
+	// LiveMQTTBrokerURL is the URL of an MQTT broker Live subscribes to.
+	// Empty disables MQTT ingestion.
+	LiveMQTTBrokerURL string
+	// LiveMQTTClientID is the client ID used to connect to the MQTT broker.
+	// Empty generates a client ID unique per instance.
+	LiveMQTTClientID string
+	LiveMQTTUsername string
+	LiveMQTTPassword string
+	// LiveMQTTTopics are the topic filters Live subscribes to.
+	LiveMQTTTopics []string
+	// LiveMQTTStreamID is the stream ID of the channels MQTT topics are mapped to.
+	LiveMQTTStreamID string
+	// LiveMQTTConverter converts MQTT payloads for channels without a pipeline rule.
+	LiveMQTTConverter string
+	// LiveMQTTOrgID is the organization MQTT messages are pushed to.
+	LiveMQTTOrgID int64
 
 	// Grafana.com URL, used for OAuth redirect.
 	GrafanaComURL string
@@ -1835,6 +1868,7 @@ func (cfg *Cfg) parseINIFile(iniFile *ini.File) error {
 	cfg.GeomapEnableCustomBaseLayers = geomapSection.Key("enable_custom_baselayers").MustBool(true)
 
 	cfg.readRemoteCacheSettings()
+	cfg.readQueryCachingSettings()
 	cfg.readDateFormats()
 	cfg.readGrafanaJavascriptAgentConfig()
 
@@ -2173,6 +2207,11 @@ func readAuthSettings(iniFile *ini.File, cfg *Cfg) (err error) {
 	cfg.BasicAuthEnabled = authBasic.Key("enabled").MustBool(true)
 	cfg.BasicAuthStrongPasswordPolicy = authBasic.Key("password_policy").MustBool(false)
 
+	// totp
+	authTOTP := iniFile.Section("auth.totp")
+	cfg.TOTPEnabled = authTOTP.Key("enabled").MustBool(false)
+	cfg.TOTPEnforceForAdmins = authTOTP.Key("enforce_for_admins").MustBool(false)
+
 	// SSO Settings
 	ssoSettings := iniFile.Section("sso_settings")
 	cfg.SSOSettingsReloadInterval = ssoSettings.Key("reload_interval").MustDuration(1 * time.Minute)
@@ -2427,6 +2466,9 @@ func (cfg *Cfg) readDataSourcesSettings() {
 	cfg.ConcurrentQueryCount = datasources.Key("concurrent_query_count").MustInt(10)
 	cfg.DefaultDatasourceManageAlertsUIToggle = datasources.Key("default_manage_alerts_ui_toggle").MustBool(true)
 	cfg.DefaultAllowRecordingRulesTargetAlertsUIToggle = datasources.Key("default_allow_recording_rules_target_alerts_ui_toggle").MustBool(true)
+	if policiesFile := datasources.Key("query_policies_file").MustString(""); policiesFile != "" {
+		cfg.DataSourceQueryPoliciesFile = makeAbsolute(policiesFile, cfg.HomePath)
+	}
 }
 
 func (cfg *Cfg) readDataSourceSecuritySettings() {
@@ -2483,6 +2525,29 @@ func (cfg *Cfg) readLiveSettings(iniFile *ini.File) error {
 		return fmt.Errorf("unexpected value %d for [live] client_queue_max_size", cfg.LiveMaxConnections)
 	}
 
+	cfg.LiveHistoryMaxPoints = section.Key("history_max_points").MustInt(0)
+	if cfg.LiveHistoryMaxPoints < 0 {
+		return fmt.Errorf("unexpected value %d for [live] history_max_points", cfg.LiveHistoryMaxPoints)
+	}
+	cfg.LiveHistoryMaxAge = section.Key("history_max_age").MustDuration(0)
+	if cfg.LiveHistoryMaxAge < 0 {
+		return fmt.Errorf("unexpected value %s for [live] history_max_age", cfg.LiveHistoryMaxAge)
+	}
+
+	cfg.LiveMQTTBrokerURL = section.Key("mqtt_broker_url").MustString("")
+	cfg.LiveMQTTClientID = section.Key("mqtt_client_id").MustString("")
+	cfg.LiveMQTTUsername = section.Key("mqtt_username").MustString("")
+	cfg.LiveMQTTPassword = section.Key("mqtt_password").MustString("")
+	cfg.LiveMQTTTopics = util.SplitString(section.Key("mqtt_topics").MustString("#"))
+	cfg.LiveMQTTStreamID = section.Key("mqtt_stream_id").MustString("mqtt")
+	cfg.LiveMQTTConverter = section.Key("mqtt_converter").MustString("jsonAuto")
+	switch cfg.LiveMQTTConverter {
+	case "jsonAuto", "influxAuto", "jsonFrame":
+	default:
+		return fmt.Errorf("unsupported [live] mqtt_converter: %s", cfg.LiveMQTTConverter)
+	}
+	cfg.LiveMQTTOrgID = section.Key("mqtt_org_id").MustInt64(1)
+
 	cfg.LiveHAEngine = section.Key("ha_engine").MustString("")
 	switch cfg.LiveHAEngine {
 	case "", "redis":
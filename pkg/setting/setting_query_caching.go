@@ -0,0 +1,47 @@
+package setting
+
+import (
+	"time"
+)
+
+const (
+	QueryCachingBackendMemory = "memory"
+	QueryCachingBackendRedis  = "redis"
+)
+
+type QueryCachingSettings struct {
+	Enabled bool
+	// Backend is where the query responses are stored, either "memory" or "redis".
+	Backend string
+	// TTL is how long a query response is cached, unless the data source sets its own TTL.
+	TTL time.Duration
+	// MaxItems is the maximum number of query responses kept by the memory backend.
+	MaxItems int
+	// MaxValueSize is the maximum size in bytes of a cached query response. Larger responses are not cached.
+	MaxValueSize int
+	// RedisURL is the redis:// or rediss:// connection URL used by the redis backend.
+	RedisURL string
+	// RedisPrefix is prepended to all the keys written to Redis.
+	RedisPrefix string
+	// TimeBucketSize is the size of the time buckets the data of time series responses is cached in, so that
+	// requests with an overlapping time range only query the missing part. Zero disables time buckets.
+	TimeBucketSize time.Duration
+	// TimeBucketTTL is how long the data of a time bucket is cached.
+	TimeBucketTTL time.Duration
+}
+
+func (cfg *Cfg) readQueryCachingSettings() {
+	section := cfg.Raw.Section("query_caching")
+
+	cfg.QueryCaching = QueryCachingSettings{
+		Enabled:        section.Key("enabled").MustBool(false),
+		Backend:        valueAsString(section, "backend", QueryCachingBackendMemory),
+		TTL:            section.Key("ttl").MustDuration(time.Minute),
+		MaxItems:       section.Key("max_items").MustInt(1000),
+		MaxValueSize:   section.Key("max_value_size").MustInt(10 * 1024 * 1024),
+		RedisURL:       valueAsString(section, "redis_url", ""),
+		RedisPrefix:    valueAsString(section, "redis_prefix", "grafana:query_caching:"),
+		TimeBucketSize: section.Key("time_bucket_size").MustDuration(10 * time.Minute),
+		TimeBucketTTL:  section.Key("time_bucket_ttl").MustDuration(time.Hour),
+	}
+}
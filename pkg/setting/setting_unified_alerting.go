@@ -2,6 +2,7 @@ package setting
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -64,6 +65,7 @@ const (
 	DefaultRuleEvaluationInterval          = SchedulerBaseInterval * 6 // == 60 seconds
 	stateHistoryDefaultEnabled             = true
 	stateHistoryBackendLoki                = "loki"
+	stateHistoryBackendParquet             = "parquet"
 	stateHistoryBackendMultiple            = "multiple"
 	notificationHistoryDefaultEnabled      = false
 	lokiDefaultMaxQueryLength              = 721 * time.Hour // 30d1h, matches the default value in Loki
@@ -71,6 +73,8 @@ const (
 	lokiDefaultMaxQuerySize                = 65536 // 64kb
 	defaultHistorianPrometheusWriteTimeout = 10 * time.Second
 	defaultHistorianPrometheusMetricName   = "GRAFANA_ALERTS"
+	defaultHistorianParquetRetention       = 30 * 24 * time.Hour
+	defaultHistorianParquetFlushInterval   = time.Minute
 )
 
 var (
@@ -239,6 +243,9 @@ type UnifiedAlertingStateHistorySettings struct {
 	PrometheusMetricName          string
 	PrometheusTargetDatasourceUID string
 	PrometheusWriteTimeout        time.Duration
+	ParquetPath                   string
+	ParquetRetention              time.Duration
+	ParquetFlushInterval          time.Duration
 	MultiPrimary                  string
 	MultiSecondaries              []string
 	ExternalLabels                map[string]string
@@ -255,17 +262,18 @@ func (u *UnifiedAlertingSettings) IsEnabled() bool {
 	return u.Enabled == nil || *u.Enabled
 }
 
-// QueriesServedByLoki returns true if state history read queries are served by Loki, either as the
-// only backend or as the primary of the "multiple" backend. Loki is the only backend that can answer
-// queries which are not scoped to a single alert rule.
-func (u *UnifiedAlertingStateHistorySettings) QueriesServedByLoki() bool {
+// QueriesNotScopedToRule returns true if state history read queries are served by Loki or Parquet files,
+// either as the only backend or as the primary of the "multiple" backend. These are the only backends
+// that can answer queries which are not scoped to a single alert rule.
+func (u *UnifiedAlertingStateHistorySettings) QueriesNotScopedToRule() bool {
 	if !u.Enabled {
 		return false
 	}
-	if isStateHistoryBackend(u.Backend, stateHistoryBackendMultiple) {
-		return isStateHistoryBackend(u.MultiPrimary, stateHistoryBackendLoki)
+	backend := u.Backend
+	if isStateHistoryBackend(backend, stateHistoryBackendMultiple) {
+		backend = u.MultiPrimary
 	}
-	return isStateHistoryBackend(u.Backend, stateHistoryBackendLoki)
+	return isStateHistoryBackend(backend, stateHistoryBackendLoki) || isStateHistoryBackend(backend, stateHistoryBackendParquet)
 }
 
 // isStateHistoryBackend normalizes the configured value the same way historian.ParseBackendType does.
@@ -571,6 +579,9 @@ func (cfg *Cfg) ReadUnifiedAlertingSettings(iniFile *ini.File) error {
 		PrometheusMetricName:          stateHistory.Key("prometheus_metric_name").MustString(defaultHistorianPrometheusMetricName),
 		PrometheusTargetDatasourceUID: stateHistory.Key("prometheus_target_datasource_uid").MustString(""),
 		PrometheusWriteTimeout:        stateHistory.Key("prometheus_write_timeout").MustDuration(defaultHistorianPrometheusWriteTimeout),
+		ParquetPath:                   stateHistory.Key("parquet_path").MustString(filepath.Join(cfg.DataPath, "alerting", "state-history")),
+		ParquetRetention:              stateHistory.Key("parquet_retention").MustDuration(defaultHistorianParquetRetention),
+		ParquetFlushInterval:          stateHistory.Key("parquet_flush_interval").MustDuration(defaultHistorianParquetFlushInterval),
 		ExternalLabels:                stateHistoryLabels.KeysHash(),
 	}
 	uaCfg.StateHistory = uaCfgStateHistory
@@ -470,7 +470,7 @@ func TestReadAllowedIntegrations(t *testing.T) {
 	}
 }
 
-func TestQueriesServedByLoki(t *testing.T) {
+func TestQueriesNotScopedToRule(t *testing.T) {
 	testCases := []struct {
 		name         string
 		stateHistory UnifiedAlertingStateHistorySettings
@@ -486,6 +486,11 @@ func TestQueriesServedByLoki(t *testing.T) {
 			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "loki"},
 			want:         true,
 		},
+		{
+			name:         "parquet backend",
+			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "parquet"},
+			want:         true,
+		},
 		{
 			name:         "non-loki backend",
 			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "annotations"},
@@ -501,6 +506,11 @@ func TestQueriesServedByLoki(t *testing.T) {
 			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: " Multiple ", MultiPrimary: " Loki "},
 			want:         true,
 		},
+		{
+			name:         "multiple backend with parquet primary",
+			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "parquet", MultiSecondaries: []string{"annotations"}},
+			want:         true,
+		},
 		{
 			name:         "multiple backend with loki as a secondary only",
 			stateHistory: UnifiedAlertingStateHistorySettings{Enabled: true, Backend: "multiple", MultiPrimary: "annotations", MultiSecondaries: []string{"loki"}},
@@ -510,7 +520,7 @@ func TestQueriesServedByLoki(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.want, tc.stateHistory.QueriesServedByLoki())
+			require.Equal(t, tc.want, tc.stateHistory.QueriesNotScopedToRule())
 		})
 	}
 }
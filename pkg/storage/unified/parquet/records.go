@@ -0,0 +1,122 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+)
+
+// RecordWriter writes rows with an arbitrary schema into a parquet file.
+// Rows are appended to the builder and written as a row group on Flush or Close.
+type RecordWriter struct {
+	builder *array.RecordBuilder
+	writer  *pqarrow.FileWriter
+}
+
+// NewRecordWriter creates a writer for rows with the schema. Closing the writer closes f if it is an io.Closer.
+func NewRecordWriter(f io.Writer, schema *arrow.Schema) (*RecordWriter, error) {
+	props := parquet.NewWriterProperties(
+		parquet.WithCompression(compress.Codecs.Zstd),
+	)
+	writer, err := pqarrow.NewFileWriter(schema, f, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		return nil, err
+	}
+	return &RecordWriter{
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		writer:  writer,
+	}, nil
+}
+
+// Builder returns the builder the rows are appended to, with one field builder per column of the schema.
+func (w *RecordWriter) Builder() *array.RecordBuilder {
+	return w.builder
+}
+
+// Flush writes the rows appended since the last flush.
+func (w *RecordWriter) Flush() error {
+	rec := w.builder.NewRecordBatch()
+	defer rec.Release()
+	if rec.NumRows() == 0 {
+		return nil
+	}
+	return w.writer.Write(rec)
+}
+
+// Close flushes the remaining rows, and releases the builder.
+func (w *RecordWriter) Close() error {
+	defer w.builder.Release()
+	if err := w.Flush(); err != nil {
+		_ = w.writer.Close()
+		return err
+	}
+	return w.writer.Close()
+}
+
+// ReadRecords reads the parquet file in batches of rows. The columns of the file must match the schema,
+// so the columns of each batch are in the order of the schema. The batches are released once fn returns.
+func ReadRecords(ctx context.Context, r parquet.ReaderAtSeeker, schema *arrow.Schema, fn func(rec arrow.RecordBatch) error) error {
+	rdr, err := file.NewParquetReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rdr.Close() }()
+
+	pool := memory.DefaultAllocator
+	fr, err := pqarrow.NewFileReader(rdr, pqarrow.ArrowReadProperties{BatchSize: 1024}, pool)
+	if err != nil {
+		return err
+	}
+	fileSchema, err := fr.Schema()
+	if err != nil {
+		return err
+	}
+
+	columns := make([]int, 0, len(schema.Fields()))
+	for _, field := range schema.Fields() {
+		indices := fileSchema.FieldIndices(field.Name)
+		if len(indices) == 0 {
+			return fmt.Errorf("missing column: %s", field.Name)
+		}
+		if t := fileSchema.Field(indices[0]).Type; !arrow.TypeEqual(t, field.Type) {
+			return fmt.Errorf("unexpected type of column %s: %s", field.Name, t)
+		}
+		columns = append(columns, indices[0])
+	}
+
+	if rdr.NumRowGroups() == 0 {
+		return nil
+	}
+	rr, err := fr.GetRecordReader(ctx, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+
+	cols := make([]arrow.Array, len(columns))
+	for rr.Next() {
+		batch := rr.RecordBatch()
+		for i, c := range columns {
+			cols[i] = batch.Column(c)
+		}
+		rec := array.NewRecordBatch(schema, cols, batch.NumRows())
+		err := fn(rec)
+		rec.Release()
+		if err != nil {
+			return err
+		}
+	}
+	if err := rr.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package parquet
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordsWriteThenRead(t *testing.T) {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String},
+	}, nil)
+
+	file, err := os.CreateTemp(t.TempDir(), "temp-*.parquet")
+	require.NoError(t, err)
+	writer, err := NewRecordWriter(file, schema)
+	require.NoError(t, err)
+
+	b := writer.Builder()
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{1, 2}, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues([]string{"a", "b"}, nil)
+	require.NoError(t, writer.Flush())
+	b.Field(0).(*array.Int64Builder).Append(3)
+	b.Field(1).(*array.StringBuilder).Append("c")
+	require.NoError(t, writer.Close())
+
+	t.Run("columns are read in the order of the schema", func(t *testing.T) {
+		f, err := os.Open(file.Name())
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+
+		reversed := arrow.NewSchema([]arrow.Field{schema.Field(1), schema.Field(0)}, nil)
+		var names []string
+		var ids []int64
+		err = ReadRecords(context.Background(), f, reversed, func(rec arrow.RecordBatch) error {
+			for i := range int(rec.NumRows()) {
+				names = append(names, rec.Column(0).(*array.String).Value(i))
+				ids = append(ids, rec.Column(1).(*array.Int64).Value(i))
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b", "c"}, names)
+		require.Equal(t, []int64{1, 2, 3}, ids)
+	})
+
+	t.Run("missing columns are an error", func(t *testing.T) {
+		f, err := os.Open(file.Name())
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+
+		other := arrow.NewSchema([]arrow.Field{{Name: "other", Type: arrow.BinaryTypes.String}}, nil)
+		err = ReadRecords(context.Background(), f, other, func(arrow.RecordBatch) error { return nil })
+		require.ErrorContains(t, err, "missing column: other")
+	})
+}
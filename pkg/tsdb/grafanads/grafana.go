@@ -4,14 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
+	"github.com/grafana/authlib/types"
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/data"
 	"github.com/grafana/grafana/apps/dashboard/pkg/apis/dashboard"
+	"github.com/grafana/grafana/pkg/apimachinery/identity"
 	"github.com/grafana/grafana/pkg/components/simplejson"
 	"github.com/grafana/grafana/pkg/infra/log"
 	"github.com/grafana/grafana/pkg/services/datasources"
 	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
 	"github.com/grafana/grafana/pkg/services/store"
 	testdatasource "github.com/grafana/grafana/pkg/tsdb/grafana-testdata-datasource"
 )
@@ -37,16 +41,17 @@ var (
 )
 
 // nolint:staticcheck
-func ProvideService(store store.StorageService, features featuremgmt.FeatureToggles) *Service {
-	return newService(store, features)
+func ProvideService(store store.StorageService, features featuremgmt.FeatureToggles, history *managedstream.History) *Service {
+	return newService(store, features, history)
 }
 
 // nolint:staticcheck
-func newService(store store.StorageService, features featuremgmt.FeatureToggles) *Service {
+func newService(store store.StorageService, features featuremgmt.FeatureToggles, history *managedstream.History) *Service {
 	s := &Service{
 		store:    store,
 		log:      log.New("grafanads"),
 		features: features,
+		history:  history,
 	}
 
 	return s
@@ -57,6 +62,7 @@ type Service struct {
 	store    store.StorageService // nolint:staticcheck
 	log      log.Logger
 	features featuremgmt.FeatureToggles
+	history  *managedstream.History
 }
 
 func DataSourceModel(orgId int64) *datasources.DataSource {
@@ -80,6 +86,8 @@ func (s *Service) QueryData(ctx context.Context, req *backend.QueryDataRequest)
 			response.Responses[q.RefID] = s.doRandomWalk(q)
 		case queryTypeList:
 			response.Responses[q.RefID] = s.doListQuery(ctx, q)
+		case queryTypeLiveHistory:
+			response.Responses[q.RefID] = s.doLiveHistoryQuery(ctx, req.PluginContext, q)
 		default:
 			response.Responses[q.RefID] = backend.DataResponse{
 				Error: fmt.Errorf("unknown query type"),
@@ -116,6 +124,63 @@ func (s *Service) doListQuery(ctx context.Context, query backend.DataQuery) back
 	return response
 }
 
+func (s *Service) doLiveHistoryQuery(ctx context.Context, pCtx backend.PluginContext, query backend.DataQuery) backend.DataResponse {
+	q := &liveHistoryQueryModel{}
+	response := backend.DataResponse{}
+	err := json.Unmarshal(query.JSON, &q)
+	if err != nil {
+		response.Error = err
+		return response
+	}
+	if q.Channel == "" {
+		response.Error = fmt.Errorf("missing channel")
+		return response
+	}
+	if !s.history.Enabled() {
+		response.Error = fmt.Errorf("live history is not enabled")
+		return response
+	}
+
+	ns := types.OrgNamespaceFormatter(pCtx.OrgID)
+	if requester, err := identity.GetRequester(ctx); err == nil {
+		ns = requester.GetNamespace()
+	}
+	frame, ok, err := s.history.Get(ctx, ns, q.Channel, query.TimeRange.From)
+	if err != nil || !ok {
+		response.Error = err
+		return response
+	}
+	response.Frames = data.Frames{filterTimeRange(frame, query.TimeRange)}
+	return response
+}
+
+// filterTimeRange returns the rows of the frame within the time range.
+func filterTimeRange(frame *data.Frame, timeRange backend.TimeRange) *data.Frame {
+	timeIndex := -1
+	for i, field := range frame.Fields {
+		if field.Type().Time() {
+			timeIndex = i
+			break
+		}
+	}
+	if timeIndex < 0 || timeRange.From.IsZero() || timeRange.To.IsZero() {
+		return frame
+	}
+	filtered := frame.EmptyCopy()
+	for i := range frame.Rows() {
+		v, ok := frame.Fields[timeIndex].ConcreteAt(i)
+		if !ok {
+			continue
+		}
+		t := v.(time.Time)
+		if t.Before(timeRange.From) || t.After(timeRange.To) {
+			continue
+		}
+		filtered.AppendRow(frame.RowCopy(i)...)
+	}
+	return filtered
+}
+
 func (s *Service) doRandomWalk(query backend.DataQuery) backend.DataResponse {
 	response := backend.DataResponse{}
 
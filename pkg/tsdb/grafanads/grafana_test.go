@@ -0,0 +1,63 @@
+package grafanads
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/grafana/grafana-plugin-sdk-go/backend"
+	"github.com/grafana/grafana-plugin-sdk-go/data"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/grafana/pkg/services/featuremgmt"
+	"github.com/grafana/grafana/pkg/services/live/managedstream"
+)
+
+func TestLiveHistoryQuery(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	history := managedstream.NewHistory(10, 0)
+	require.NoError(t, history.Add(context.Background(), "default", "stream/test/cpu", data.NewFrame("cpu",
+		data.NewField("time", nil, []time.Time{now.Add(-time.Hour), now.Add(-time.Minute), now}),
+		data.NewField("value", nil, []float64{1, 2, 3}),
+	)))
+
+	query := func(s *Service, json string, timeRange backend.TimeRange) backend.DataResponse {
+		rsp, err := s.QueryData(context.Background(), &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{OrgID: 1},
+			Queries: []backend.DataQuery{{
+				RefID:     "A",
+				QueryType: queryTypeLiveHistory,
+				JSON:      []byte(json),
+				TimeRange: timeRange,
+			}},
+		})
+		require.NoError(t, err)
+		return rsp.Responses["A"]
+	}
+	s := newService(nil, featuremgmt.WithFeatures(), history)
+
+	t.Run("returns the points of the channel within the time range", func(t *testing.T) {
+		rsp := query(s, `{"channel":"stream/test/cpu"}`, backend.TimeRange{From: now.Add(-10 * time.Minute), To: now.Add(-time.Second)})
+		require.NoError(t, rsp.Error)
+		require.Len(t, rsp.Frames, 1)
+		require.Equal(t, 1, rsp.Frames[0].Rows())
+		require.Equal(t, 2.0, rsp.Frames[0].Fields[1].At(0))
+	})
+
+	t.Run("returns no frames for a channel without history", func(t *testing.T) {
+		rsp := query(s, `{"channel":"stream/test/other"}`, backend.TimeRange{From: now.Add(-time.Hour), To: now})
+		require.NoError(t, rsp.Error)
+		require.Empty(t, rsp.Frames)
+	})
+
+	t.Run("requires a channel", func(t *testing.T) {
+		rsp := query(s, `{}`, backend.TimeRange{From: now.Add(-time.Hour), To: now})
+		require.ErrorContains(t, rsp.Error, "missing channel")
+	})
+
+	t.Run("requires the history to be enabled", func(t *testing.T) {
+		s := newService(nil, featuremgmt.WithFeatures(), managedstream.NewHistory(0, 0))
+		rsp := query(s, `{"channel":"stream/test/cpu"}`, backend.TimeRange{From: now.Add(-time.Hour), To: now})
+		require.ErrorContains(t, rsp.Error, "live history is not enabled")
+	})
+}
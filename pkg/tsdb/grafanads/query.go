@@ -6,8 +6,15 @@ const (
 
 	// QueryTypeList will list the files in a folder
 	queryTypeList = "list"
+
+	// queryTypeLiveHistory returns the history of a Live managed stream channel
+	queryTypeLiveHistory = "liveHistory"
 )
 
 type listQueryModel struct {
 	Path string `json:"path"`
 }
+
+type liveHistoryQueryModel struct {
+	Channel string `json:"channel"`
+}
@@ -2,6 +2,7 @@ package graphite
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -44,10 +45,46 @@ func ProvideService(httpClientProvider *httpclient.Provider, tracer trace.Tracer
 	return s
 }
 
+// Formats of the /render responses. msgpack and pickle are cheaper to decode
+// than JSON for large responses.
+const (
+	renderFormatJSON    = "json"
+	renderFormatMsgpack = "msgpack"
+	renderFormatPickle  = "pickle"
+)
+
+// defaultMaxConcurrentQueries is the number of /render requests a query runs
+// in parallel when the data source doesn't configure it.
+const defaultMaxConcurrentQueries = 10
+
 type datasourceInfo struct {
 	HTTPClient *http.Client
 	URL        string
 	Id         int64
+	// RenderFormat is the format of the /render responses, json when empty.
+	RenderFormat string
+	// MaxConcurrentQueries bounds the /render requests run in parallel,
+	// defaultMaxConcurrentQueries when not positive.
+	MaxConcurrentQueries int
+}
+
+type datasourceJSONData struct {
+	RenderFormat         string `json:"renderFormat"`
+	MaxConcurrentQueries int    `json:"maxConcurrentQueries"`
+}
+
+func (dsInfo *datasourceInfo) renderFormat() string {
+	if dsInfo.RenderFormat == "" {
+		return renderFormatJSON
+	}
+	return dsInfo.RenderFormat
+}
+
+func (dsInfo *datasourceInfo) maxConcurrentQueries() int {
+	if dsInfo.MaxConcurrentQueries > 0 {
+		return dsInfo.MaxConcurrentQueries
+	}
+	return defaultMaxConcurrentQueries
 }
 
 func newInstanceSettings(httpClientProvider *httpclient.Provider) datasource.InstanceFactoryFunc {
@@ -62,10 +99,24 @@ func newInstanceSettings(httpClientProvider *httpclient.Provider) datasource.Ins
 			return nil, err
 		}
 
+		jsonData := datasourceJSONData{}
+		if len(settings.JSONData) > 0 {
+			if err := json.Unmarshal(settings.JSONData, &jsonData); err != nil {
+				return nil, fmt.Errorf("failed to parse the Graphite data source settings: %w", err)
+			}
+		}
+		switch jsonData.RenderFormat {
+		case "", renderFormatJSON, renderFormatMsgpack, renderFormatPickle:
+		default:
+			return nil, fmt.Errorf("unsupported Graphite render format %q", jsonData.RenderFormat)
+		}
+
 		model := datasourceInfo{
-			HTTPClient: client,
-			URL:        settings.URL,
-			Id:         settings.ID,
+			HTTPClient:           client,
+			URL:                  settings.URL,
+			Id:                   settings.ID,
+			RenderFormat:         jsonData.RenderFormat,
+			MaxConcurrentQueries: jsonData.MaxConcurrentQueries,
 		}
 
 		return model, nil
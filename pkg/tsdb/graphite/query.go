@@ -11,11 +11,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/net/html"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/grafana/grafana-plugin-sdk-go/backend"
 	"github.com/grafana/grafana-plugin-sdk-go/backend/tracing"
@@ -37,7 +39,7 @@ func (s *Service) RunQuery(ctx context.Context, req *backend.QueryDataRequest, d
 		graphiteReq, formData, emptyQuery, target, err := s.createGraphiteRequest(ctx, query, dsInfo)
 		if err != nil {
 			result.Responses[query.RefID] = backend.ErrorResponseWithErrorSource(err)
-			return result, nil
+			continue
 		}
 
 		if emptyQuery != nil {
@@ -67,63 +69,61 @@ func (s *Service) RunQuery(ctx context.Context, req *backend.QueryDataRequest, d
 		}
 	}
 
-	frames := data.Frames{}
-
+	// Queries run in parallel and fail independently: the error of a query is
+	// returned in its response, along with the results of the other queries.
+	var mu sync.Mutex
+	g := errgroup.Group{}
+	g.SetLimit(dsInfo.maxConcurrentQueries())
 	for refId, graphiteReq := range graphiteQueries {
-		_, span := tracing.DefaultTracer().Start(ctx, "graphite query")
-		targetStr := strings.Join(graphiteReq.formData["target"], ",")
-		span.SetAttributes(
-			attribute.String("refId", refId),
-			attribute.String("target", targetStr),
-			attribute.String("from", graphiteReq.formData["from"][0]),
-			attribute.String("until", graphiteReq.formData["until"][0]),
-			attribute.Int64("datasource_id", dsInfo.Id),
-			attribute.Int64("org_id", req.PluginContext.OrgID), // nolint:staticcheck
-		)
-		res, err := dsInfo.HTTPClient.Do(graphiteReq.req)
-		if res != nil {
-			span.SetAttributes(attribute.Int("graphite.response.code", res.StatusCode))
-		}
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-			span.End()
-			result.Responses[refId] = backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
-			return result, nil
-		}
-
-		defer func() {
-			err := res.Body.Close()
-			if err != nil {
-				s.logger.Warn("Failed to close response body", "error", err)
+		g.Go(func() error {
+			resp := s.runGraphiteQuery(ctx, refId, graphiteReq, dsInfo, req.PluginContext.OrgID) // nolint:staticcheck
+			mu.Lock()
+			defer mu.Unlock()
+			// Queries returning no series have no response.
+			if resp.Error != nil || len(resp.Frames) > 0 {
+				result.Responses[refId] = resp
 			}
-		}()
+			return nil
+		})
+	}
+	_ = g.Wait()
 
-		queryFrames, err := s.toDataFrames(res, refId)
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-			span.End()
-			result.Responses[refId] = backend.ErrorResponseWithErrorSource(err)
-			return result, nil
-		}
+	return result, nil
+}
 
-		frames = append(frames, queryFrames...)
-		span.End()
+// runGraphiteQuery sends the /render request of a query and converts the response to data frames.
+func (s *Service) runGraphiteQuery(ctx context.Context, refId string, graphiteReq queryModel, dsInfo *datasourceInfo, orgID int64) backend.DataResponse {
+	_, span := tracing.DefaultTracer().Start(ctx, "graphite query")
+	defer span.End()
+	targetStr := strings.Join(graphiteReq.formData["target"], ",")
+	span.SetAttributes(
+		attribute.String("refId", refId),
+		attribute.String("target", targetStr),
+		attribute.String("from", graphiteReq.formData["from"][0]),
+		attribute.String("until", graphiteReq.formData["until"][0]),
+		attribute.String("format", graphiteReq.formData.Get("format")),
+		attribute.Int64("datasource_id", dsInfo.Id),
+		attribute.Int64("org_id", orgID),
+	)
+	res, err := dsInfo.HTTPClient.Do(graphiteReq.req)
+	if res != nil {
+		span.SetAttributes(attribute.Int("graphite.response.code", res.StatusCode))
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return backend.ErrorResponseWithErrorSource(backend.DownstreamError(err))
 	}
 
-	for _, f := range frames {
-		if resp, ok := result.Responses[f.RefID]; ok {
-			resp.Frames = append(resp.Frames, f)
-			result.Responses[f.RefID] = resp
-		} else {
-			result.Responses[f.RefID] = backend.DataResponse{
-				Frames: data.Frames{f},
-			}
-		}
+	// toDataFrames closes the response body.
+	frames, err := s.toDataFrames(res, refId)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return backend.ErrorResponseWithErrorSource(err)
 	}
 
-	return result, nil
+	return backend.DataResponse{Frames: frames}
 }
 
 // processQuery converts a Graphite data source query to a Graphite query target. It returns the target,
@@ -158,7 +158,7 @@ func (s *Service) createGraphiteRequest(ctx context.Context, query backend.DataQ
 	formData := url.Values{
 		"from":          []string{from},
 		"until":         []string{until},
-		"format":        []string{"json"},
+		"format":        []string{dsInfo.renderFormat()},
 		"maxDataPoints": []string{fmt.Sprintf("%d", query.MaxDataPoints)},
 		"target":        []string{},
 	}
@@ -279,6 +279,24 @@ func (s *Service) parseResponse(res *http.Response) ([]TargetResponseDTO, error)
 		return nil, backend.PluginError(err)
 	}
 
+	// Graphite answers with JSON when it doesn't support the requested format.
+	switch contentType := res.Header.Get("Content-Type"); {
+	case strings.HasPrefix(contentType, msgpackContentType):
+		data, err := decodeMsgpackResponse(body)
+		if err != nil {
+			s.logger.Info("Failed to decode msgpack graphite response", "error", err, "status", res.Status)
+			return nil, backend.PluginError(err)
+		}
+		return data, nil
+	case strings.HasPrefix(contentType, pickleContentType):
+		data, err := decodePickleResponse(body)
+		if err != nil {
+			s.logger.Info("Failed to decode pickle graphite response", "error", err, "status", res.Status)
+			return nil, backend.PluginError(err)
+		}
+		return data, nil
+	}
+
 	var data []TargetResponseDTO
 	err = json.Unmarshal(body, &data)
 	if err != nil {
@@ -2,6 +2,7 @@ package graphite
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -747,6 +749,109 @@ func TestRunQueryE2E(t *testing.T) {
 	}
 }
 
+func TestRunQueryConcurrency(t *testing.T) {
+	newQuery := func(refId, target string) backend.DataQuery {
+		return backend.DataQuery{
+			RefID: refId,
+			TimeRange: backend.TimeRange{
+				From: time.Unix(1609459200, 0),
+				To:   time.Unix(1609459260, 0),
+			},
+			MaxDataPoints: 1000,
+			JSON:          []byte(fmt.Sprintf(`{"target": %q}`, target)),
+		}
+	}
+	newRequest := func(queries ...backend.DataQuery) *backend.QueryDataRequest {
+		return &backend.QueryDataRequest{
+			PluginContext: backend.PluginContext{OrgID: 1},
+			Queries:       queries,
+		}
+	}
+	service := &Service{
+		logger: backend.Logger,
+	}
+
+	t.Run("Failing queries don't fail the other queries", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.FormValue("target") == "failing" {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("failing target"))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `[{"target": %q, "datapoints": [[1, 1609459200]]}]`, r.FormValue("target"))
+		}))
+		defer server.Close()
+
+		dsInfo := &datasourceInfo{Id: 1, URL: server.URL, HTTPClient: &http.Client{}}
+		result, err := service.RunQuery(context.Background(), newRequest(
+			newQuery("A", "a.b"),
+			newQuery("B", "failing"),
+			backend.DataQuery{RefID: "C", JSON: []byte(`{invalid json}`)},
+			newQuery("D", "c.d"),
+		), dsInfo)
+		require.NoError(t, err)
+
+		require.Len(t, result.Responses, 4)
+		for _, refId := range []string{"A", "D"} {
+			require.NoError(t, result.Responses[refId].Error)
+			require.Len(t, result.Responses[refId].Frames, 1)
+			assert.Equal(t, refId, result.Responses[refId].Frames[0].RefID)
+		}
+		require.ErrorContains(t, result.Responses["B"].Error, "failing target")
+		require.ErrorContains(t, result.Responses["C"].Error, "failed to decode the Graphite query")
+	})
+
+	t.Run("Bounds the number of concurrent requests", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				m := maxInFlight.Load()
+				if n <= m || maxInFlight.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			_, _ = w.Write([]byte(`[]`))
+		}))
+		defer server.Close()
+
+		queries := make([]backend.DataQuery, 0, 6)
+		for i := range 6 {
+			queries = append(queries, newQuery(fmt.Sprintf("Q%d", i), fmt.Sprintf("series.%d", i)))
+		}
+		dsInfo := &datasourceInfo{Id: 1, URL: server.URL, HTTPClient: &http.Client{}, MaxConcurrentQueries: 2}
+		_, err := service.RunQuery(context.Background(), newRequest(queries...), dsInfo)
+		require.NoError(t, err)
+
+		assert.LessOrEqual(t, maxInFlight.Load(), int32(2))
+		assert.Positive(t, maxInFlight.Load())
+	})
+
+	t.Run("Requests the configured render format", func(t *testing.T) {
+		body, err := hex.DecodeString(msgpackSeries)
+		require.NoError(t, err)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, renderFormatMsgpack, r.FormValue("format"))
+			w.Header().Set("Content-Type", msgpackContentType)
+			_, _ = w.Write(body)
+		}))
+		defer server.Close()
+
+		dsInfo := &datasourceInfo{Id: 1, URL: server.URL, HTTPClient: &http.Client{}, RenderFormat: renderFormatMsgpack}
+		result, err := service.RunQuery(context.Background(), newRequest(newQuery("A", "a.b")), dsInfo)
+		require.NoError(t, err)
+
+		resp := result.Responses["A"]
+		require.NoError(t, resp.Error)
+		require.Len(t, resp.Frames, 1)
+		assert.Equal(t, "a.b", resp.Frames[0].Fields[1].Config.DisplayNameFromDS)
+		assert.Equal(t, data.Labels{"name": "a.b", "port": "8080"}, resp.Frames[0].Fields[1].Labels)
+		assert.Equal(t, 3, resp.Frames[0].Rows())
+	})
+}
+
 func TestAliasMatching(t *testing.T) {
 	service := &Service{
 		logger: backend.Logger,
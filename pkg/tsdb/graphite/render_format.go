@@ -0,0 +1,565 @@
+package graphite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Content types of the msgpack and pickle /render responses.
+const (
+	msgpackContentType = "application/x-msgpack"
+	pickleContentType  = "application/pickle"
+)
+
+// maxRenderDepth bounds the nesting of msgpack and pickle responses. Series are
+// nested three levels deep; anything deeper is not a /render response.
+const maxRenderDepth = 16
+
+// seriesFromInfo converts the series of msgpack and pickle responses to the
+// series of JSON responses. Both formats encode a list of series as maps with a
+// name, the start and step of the series in seconds, and the values, null when
+// missing. Graphite backends supporting tags also include the tags of the series.
+func seriesFromInfo(v any) ([]TargetResponseDTO, error) {
+	list, ok := asList(v)
+	if !ok {
+		return nil, errors.New("expected a list of series")
+	}
+
+	series := make([]TargetResponseDTO, 0, len(list))
+	for _, item := range list {
+		info, ok := item.(map[string]any)
+		if !ok {
+			return nil, errors.New("expected a series")
+		}
+		name, _ := info["name"].(string)
+		start, hasStart := info["start"].(float64)
+		step, hasStep := info["step"].(float64)
+		values, hasValues := asList(info["values"])
+		if !hasStart || !hasStep || !hasValues {
+			return nil, fmt.Errorf("series %q has no start, step or values", name)
+		}
+
+		points := make(DataTimeSeriesPoints, 0, len(values))
+		for i, value := range values {
+			point := DataTimePoint{{}, FloatFrom(start + float64(i)*step)}
+			if f, ok := value.(float64); ok && !math.IsNaN(f) {
+				point[0] = FloatFrom(f)
+			}
+			points = append(points, point)
+		}
+
+		dto := TargetResponseDTO{Target: name, DataPoints: points}
+		if tags, ok := info["tags"].(map[string]any); ok {
+			dto.Tags = tags
+		}
+		series = append(series, dto)
+	}
+	return series, nil
+}
+
+func asList(v any) ([]any, bool) {
+	switch v := v.(type) {
+	case []any:
+		return v, true
+	case *[]any:
+		return *v, true
+	}
+	return nil, false
+}
+
+// decodeMsgpackResponse decodes a msgpack /render response. Integers are decoded
+// as float64, binary data as strings, and extension types aren't supported.
+func decodeMsgpackResponse(body []byte) ([]TargetResponseDTO, error) {
+	d := &msgpackDecoder{buf: body}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode msgpack response: %w", err)
+	}
+	return seriesFromInfo(v)
+}
+
+type msgpackDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.buf)-d.pos {
+		return nil, errors.New("unexpected end of data")
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) uint(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+func (d *msgpackDecoder) length(n int) (int, error) {
+	v, err := d.uint(n)
+	if err != nil {
+		return 0, err
+	}
+	// Every element takes at least one byte, which bounds the allocations.
+	if v > uint64(len(d.buf)-d.pos) {
+		return 0, errors.New("unexpected end of data")
+	}
+	return int(v), nil
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxRenderDepth {
+		return nil, errors.New("maximum nesting depth exceeded")
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return float64(c), nil
+	case c >= 0xe0:
+		return float64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.mapOf(int(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return d.arrayOf(int(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		return d.str(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		return d.strWithLength(1)
+	case 0xc5, 0xda:
+		return d.strWithLength(2)
+	case 0xc6, 0xdb:
+		return d.strWithLength(4)
+	case 0xca:
+		v, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.uint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.uint(1 << (c - 0xcc))
+		return float64(v), err
+	case 0xd0:
+		v, err := d.uint(1)
+		return float64(int8(v)), err
+	case 0xd1:
+		v, err := d.uint(2)
+		return float64(int16(v)), err
+	case 0xd2:
+		v, err := d.uint(4)
+		return float64(int32(v)), err
+	case 0xd3:
+		v, err := d.uint(8)
+		return float64(int64(v)), err
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("unsupported msgpack type 0x%x", c)
+}
+
+func (d *msgpackDecoder) str(n int) (string, error) {
+	b, err := d.next(n)
+	return string(b), err
+}
+
+func (d *msgpackDecoder) strWithLength(n int) (string, error) {
+	length, err := d.length(n)
+	if err != nil {
+		return "", err
+	}
+	return d.str(length)
+}
+
+func (d *msgpackDecoder) arrayOf(n int, depth int) ([]any, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errors.New("unexpected end of data")
+	}
+	array := make([]any, 0, n)
+	for range n {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, v)
+	}
+	return array, nil
+}
+
+func (d *msgpackDecoder) mapOf(n int, depth int) (map[string]any, error) {
+	if n > len(d.buf)-d.pos {
+		return nil, errors.New("unexpected end of data")
+	}
+	m := make(map[string]any, n)
+	for range n {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
+
+// decodePickleResponse decodes a pickle /render response. Only the opcodes
+// needed for lists, dicts, tuples, strings, numbers and None are supported:
+// objects and globals are never loaded.
+func decodePickleResponse(body []byte) ([]TargetResponseDTO, error) {
+	u := &unpickler{buf: body, memo: map[uint64]any{}}
+	v, err := u.load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pickle response: %w", err)
+	}
+	return seriesFromInfo(v)
+}
+
+// Pickle opcodes, see Lib/pickletools.py.
+const (
+	pickleMark            = '('
+	pickleStop            = '.'
+	pickleNone            = 'N'
+	pickleBinInt          = 'J'
+	pickleBinInt1         = 'K'
+	pickleBinInt2         = 'M'
+	pickleBinFloat        = 'G'
+	pickleBinString       = 'T'
+	pickleShortBinString  = 'U'
+	pickleBinUnicode      = 'X'
+	pickleBinBytes        = 'B'
+	pickleShortBinBytes   = 'C'
+	pickleAppend          = 'a'
+	pickleAppends         = 'e'
+	pickleEmptyList       = ']'
+	pickleList            = 'l'
+	pickleEmptyDict       = '}'
+	pickleDict            = 'd'
+	pickleSetItem         = 's'
+	pickleSetItems        = 'u'
+	pickleEmptyTuple      = ')'
+	pickleTuple           = 't'
+	pickleBinGet          = 'h'
+	pickleLongBinGet      = 'j'
+	pickleBinPut          = 'q'
+	pickleLongBinPut      = 'r'
+	pickleProto           = 0x80
+	pickleTuple1          = 0x85
+	pickleTuple2          = 0x86
+	pickleTuple3          = 0x87
+	pickleNewTrue         = 0x88
+	pickleNewFalse        = 0x89
+	pickleLong1           = 0x8a
+	pickleShortBinUnicode = 0x8c
+	pickleBinUnicode8     = 0x8d
+	pickleMemoize         = 0x94
+	pickleFrame           = 0x95
+)
+
+type unpickler struct {
+	buf   []byte
+	pos   int
+	stack []any
+	marks []int
+	memo  map[uint64]any
+}
+
+func (u *unpickler) next(n uint64) ([]byte, error) {
+	if n > uint64(len(u.buf)-u.pos) {
+		return nil, errors.New("unexpected end of data")
+	}
+	b := u.buf[u.pos : u.pos+int(n)]
+	u.pos += int(n)
+	return b, nil
+}
+
+func (u *unpickler) uint(n int) (uint64, error) {
+	b, err := u.next(uint64(n))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v, nil
+}
+
+func (u *unpickler) str(lengthBytes int) (string, error) {
+	n, err := u.uint(lengthBytes)
+	if err != nil {
+		return "", err
+	}
+	b, err := u.next(n)
+	return string(b), err
+}
+
+func (u *unpickler) push(v any) {
+	u.stack = append(u.stack, v)
+}
+
+func (u *unpickler) pop() (any, error) {
+	if len(u.stack) == 0 || (len(u.marks) > 0 && len(u.stack) <= u.marks[len(u.marks)-1]) {
+		return nil, errors.New("stack underflow")
+	}
+	v := u.stack[len(u.stack)-1]
+	u.stack = u.stack[:len(u.stack)-1]
+	return v, nil
+}
+
+func (u *unpickler) top() (any, error) {
+	if len(u.stack) == 0 {
+		return nil, errors.New("stack underflow")
+	}
+	return u.stack[len(u.stack)-1], nil
+}
+
+// popMark returns the items pushed since the last mark.
+func (u *unpickler) popMark() ([]any, error) {
+	if len(u.marks) == 0 {
+		return nil, errors.New("missing mark")
+	}
+	mark := u.marks[len(u.marks)-1]
+	u.marks = u.marks[:len(u.marks)-1]
+	items := append([]any{}, u.stack[mark:]...)
+	u.stack = u.stack[:mark]
+	return items, nil
+}
+
+func (u *unpickler) appendTo(items []any) error {
+	target, err := u.top()
+	if err != nil {
+		return err
+	}
+	list, ok := target.(*[]any)
+	if !ok {
+		return errors.New("append to a non-list")
+	}
+	*list = append(*list, items...)
+	return nil
+}
+
+func (u *unpickler) setItems(items []any) error {
+	if len(items)%2 != 0 {
+		return errors.New("odd number of dict items")
+	}
+	target, err := u.top()
+	if err != nil {
+		return err
+	}
+	dict, ok := target.(map[string]any)
+	if !ok {
+		return errors.New("set item of a non-dict")
+	}
+	for i := 0; i < len(items); i += 2 {
+		dict[fmt.Sprint(items[i])] = items[i+1]
+	}
+	return nil
+}
+
+func (u *unpickler) load() (any, error) {
+	for {
+		b, err := u.next(1)
+		if err != nil {
+			return nil, err
+		}
+		if len(u.marks) > maxRenderDepth {
+			return nil, errors.New("maximum nesting depth exceeded")
+		}
+
+		switch op := b[0]; op {
+		case pickleProto:
+			_, err = u.next(1)
+		case pickleFrame:
+			_, err = u.next(8)
+		case pickleStop:
+			v, err := u.pop()
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		case pickleMark:
+			u.marks = append(u.marks, len(u.stack))
+		case pickleNone:
+			u.push(nil)
+		case pickleNewTrue:
+			u.push(true)
+		case pickleNewFalse:
+			u.push(false)
+		case pickleBinInt:
+			var v uint64
+			v, err = u.uint(4)
+			u.push(float64(int32(v)))
+		case pickleBinInt1:
+			var v uint64
+			v, err = u.uint(1)
+			u.push(float64(v))
+		case pickleBinInt2:
+			var v uint64
+			v, err = u.uint(2)
+			u.push(float64(v))
+		case pickleLong1:
+			var n uint64
+			var data []byte
+			if n, err = u.uint(1); err == nil {
+				data, err = u.next(n)
+				u.push(decodePickleLong(data))
+			}
+		case pickleBinFloat:
+			var data []byte
+			if data, err = u.next(8); err == nil {
+				u.push(math.Float64frombits(binary.BigEndian.Uint64(data)))
+			}
+		case pickleShortBinString, pickleShortBinBytes, pickleShortBinUnicode:
+			var s string
+			s, err = u.str(1)
+			u.push(s)
+		case pickleBinString, pickleBinBytes, pickleBinUnicode:
+			var s string
+			s, err = u.str(4)
+			u.push(s)
+		case pickleBinUnicode8:
+			var s string
+			s, err = u.str(8)
+			u.push(s)
+		case pickleEmptyList:
+			u.push(&[]any{})
+		case pickleList:
+			var items []any
+			if items, err = u.popMark(); err == nil {
+				u.push(&items)
+			}
+		case pickleEmptyDict:
+			u.push(map[string]any{})
+		case pickleDict:
+			var items []any
+			if items, err = u.popMark(); err == nil {
+				u.push(map[string]any{})
+				err = u.setItems(items)
+			}
+		case pickleEmptyTuple:
+			u.push([]any{})
+		case pickleTuple:
+			var items []any
+			if items, err = u.popMark(); err == nil {
+				u.push(items)
+			}
+		case pickleTuple1, pickleTuple2, pickleTuple3:
+			n := int(op-pickleTuple1) + 1
+			items := make([]any, n)
+			for i := n - 1; i >= 0 && err == nil; i-- {
+				items[i], err = u.pop()
+			}
+			u.push(items)
+		case pickleAppend:
+			var v any
+			if v, err = u.pop(); err == nil {
+				err = u.appendTo([]any{v})
+			}
+		case pickleAppends:
+			var items []any
+			if items, err = u.popMark(); err == nil {
+				err = u.appendTo(items)
+			}
+		case pickleSetItem:
+			var k, v any
+			if v, err = u.pop(); err == nil {
+				if k, err = u.pop(); err == nil {
+					err = u.setItems([]any{k, v})
+				}
+			}
+		case pickleSetItems:
+			var items []any
+			if items, err = u.popMark(); err == nil {
+				err = u.setItems(items)
+			}
+		case pickleMemoize:
+			var v any
+			if v, err = u.top(); err == nil {
+				u.memo[uint64(len(u.memo))] = v
+			}
+		case pickleBinPut, pickleLongBinPut:
+			var key uint64
+			var v any
+			if key, err = u.uint(memoKeyBytes(op)); err == nil {
+				if v, err = u.top(); err == nil {
+					u.memo[key] = v
+				}
+			}
+		case pickleBinGet, pickleLongBinGet:
+			var key uint64
+			if key, err = u.uint(memoKeyBytes(op)); err == nil {
+				v, ok := u.memo[key]
+				if !ok {
+					err = fmt.Errorf("missing memo entry %d", key)
+				}
+				u.push(v)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported pickle opcode 0x%x", op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// memoKeyBytes returns the size of the memo key of the PUT and GET opcodes.
+func memoKeyBytes(op byte) int {
+	if op == pickleLongBinPut || op == pickleLongBinGet {
+		return 4
+	}
+	return 1
+}
+
+// decodePickleLong decodes the little-endian two's complement integers of LONG1.
+func decodePickleLong(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+	be := make([]byte, len(b))
+	for i, c := range b {
+		be[len(b)-1-i] = c
+	}
+	v := new(big.Int).SetBytes(be)
+	if b[len(b)-1]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	f, _ := new(big.Float).SetInt(v).Float64()
+	return f
+}
@@ -0,0 +1,85 @@
+package graphite
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The fixtures encode [{'name': 'a.b', 'start': 100, 'step': 10, 'values': [1.5, None, 3], 'tags': {'name': 'a.b', 'port': 8080}}].
+const (
+	msgpackSeries = "9185a46e616d65a3612e62a5737461727464a4737465700aa676616c75657393cb3ff8000000000000c003a47461677382a46e616d65a3612e62a4706f7274cd1f90"
+	// pickle.dumps(series, protocol=2), as sent by graphite-web on Python 2.
+	pickleSeriesProtocol2 = "80025d71007d71012858040000006e616d6571025803000000612e6271035805000000737461727471044b645803000000656e6471054b8258040000007374657071064b0a580600000076616c75657371075d710828473ff80000000000004e4b036558040000007461677371097d710a28680268035804000000706f7274710b4d901f7575612e"
+	// pickle.dumps(series, protocol=4), as sent by graphite-web on Python 3.
+	pickleSeriesProtocol4 = "80049562000000000000005d947d94288c046e616d65948c03612e62948c057374617274944b648c03656e64944b828c0473746570944b0a8c0676616c756573945d9428473ff80000000000004e4b03658c0474616773947d9428680268038c04706f7274944d901f7575612e"
+)
+
+func TestDecodeRenderFormats(t *testing.T) {
+	expected := []TargetResponseDTO{
+		{
+			Target: "a.b",
+			DataPoints: DataTimeSeriesPoints{
+				{FloatFrom(1.5), FloatFrom(100)},
+				{Float{}, FloatFrom(110)},
+				{FloatFrom(3), FloatFrom(120)},
+			},
+			Tags: map[string]any{"name": "a.b", "port": float64(8080)},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		decode func([]byte) ([]TargetResponseDTO, error)
+	}{
+		{name: "msgpack", body: msgpackSeries, decode: decodeMsgpackResponse},
+		{name: "pickle protocol 2", body: pickleSeriesProtocol2, decode: decodePickleResponse},
+		{name: "pickle protocol 4", body: pickleSeriesProtocol4, decode: decodePickleResponse},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := hex.DecodeString(tt.body)
+			require.NoError(t, err)
+
+			series, err := tt.decode(body)
+			require.NoError(t, err)
+			assert.Equal(t, expected, series)
+		})
+		t.Run(tt.name+" truncated", func(t *testing.T) {
+			body, err := hex.DecodeString(tt.body)
+			require.NoError(t, err)
+
+			_, err = tt.decode(body[:len(body)/2])
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("empty msgpack response", func(t *testing.T) {
+		series, err := decodeMsgpackResponse([]byte{0x90})
+		require.NoError(t, err)
+		assert.Empty(t, series)
+	})
+
+	t.Run("msgpack array longer than the response", func(t *testing.T) {
+		_, err := decodeMsgpackResponse([]byte{0xdd, 0xff, 0xff, 0xff, 0xff})
+		require.Error(t, err)
+	})
+
+	t.Run("pickle globals are not loaded", func(t *testing.T) {
+		// A pickle loading os.system.
+		body, err := hex.DecodeString("8002636f730a73797374656d0a71002e")
+		require.NoError(t, err)
+
+		_, err = decodePickleResponse(body)
+		require.ErrorContains(t, err, "unsupported pickle opcode")
+	})
+
+	t.Run("series without values", func(t *testing.T) {
+		// [{'name': 'a.b'}]
+		_, err := decodeMsgpackResponse([]byte{0x91, 0x81, 0xa4, 'n', 'a', 'm', 'e', 0xa3, 'a', '.', 'b'})
+		require.ErrorContains(t, err, `series "a.b" has no start, step or values`)
+	})
+}
@@ -4,7 +4,7 @@ import { t } from '@grafana/i18n';
 import { type FetchError, getBackendSrv, isFetchError } from '@grafana/runtime';
 import config from 'app/core/config';
 
-import { type LoginDTO } from './types';
+import { type LoginDTO, type TOTPChallenge, type TOTPEnrollment } from './types';
 
 const isOauthEnabled = () => {
   return !!config.oauth && Object.keys(config.oauth).length > 0;
@@ -14,8 +14,11 @@ export interface FormModel {
   user: string;
   password: string;
   email: string;
+  totpCode?: string;
 }
 
+type LoginErrorData = { messageId?: string; message?: string; extra?: TOTPEnrollment };
+
 interface Props {
   resetCode?: string;
 
@@ -32,6 +35,8 @@ interface Props {
     passwordHint: string;
     showDefaultPasswordWarning: boolean;
     loginErrorMessage: string | undefined;
+    totpChallenge: TOTPChallenge | undefined;
+    totpRecoveryCodes: string[] | undefined;
   }) => JSX.Element;
 }
 
@@ -40,6 +45,8 @@ const LoginCtrl = memo(({ resetCode, children }: Props) => {
   const [isLoggingIn, setIsLoggingIn] = useState(false);
   const [isChangingPassword, setIsChangingPassword] = useState(false);
   const [showDefaultPasswordWarning, setShowDefaultPasswordWarning] = useState(false);
+  const [totpChallenge, setTotpChallenge] = useState<TOTPChallenge | undefined>();
+  const [totpRecoveryCodes, setTotpRecoveryCodes] = useState<string[] | undefined>();
   // oAuth unauthorized sets the redirect error message in the bootdata, hence we need to check the key here
   const [loginErrorMessage, setLoginErrorMessage] = useState<string | undefined>(
     getBootDataErrMessage(config.loginError)
@@ -108,6 +115,11 @@ const LoginCtrl = memo(({ resetCode, children }: Props) => {
         .post<LoginDTO>('/login', formModel, { showErrorAlert: false })
         .then((result) => {
           setResult(result);
+          // the recovery codes are only returned once, they are shown before leaving the login page
+          if (result.totpRecoveryCodes?.length) {
+            setTotpRecoveryCodes(result.totpRecoveryCodes);
+            return;
+          }
           if (formModel.password !== 'admin' || config.ldapEnabled || config.authProxyEnabled) {
             toGrafana();
             return;
@@ -116,8 +128,17 @@ const LoginCtrl = memo(({ resetCode, children }: Props) => {
           }
         })
         .catch((err) => {
-          const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
           setIsLoggingIn(false);
+          // the second factor is asked for after a valid password
+          if (isFetchError<LoginErrorData>(err) && err.data?.messageId === 'totp.required') {
+            setTotpChallenge({});
+            return;
+          }
+          if (isFetchError<LoginErrorData>(err) && err.data?.messageId === 'totp.enrollment-required') {
+            setTotpChallenge({ enrollment: err.data.extra });
+            return;
+          }
+          const fetchErrorMessage = isFetchError(err) ? getErrorMessage(err) : undefined;
           setLoginErrorMessage(fetchErrorMessage || t('login.error.unknown', 'Unknown error occurred'));
         });
     },
@@ -141,6 +162,8 @@ const LoginCtrl = memo(({ resetCode, children }: Props) => {
         isChangingPassword,
         showDefaultPasswordWarning,
         loginErrorMessage,
+        totpChallenge,
+        totpRecoveryCodes,
       })}
     </>
   );
@@ -150,7 +173,7 @@ LoginCtrl.displayName = 'LoginCtrl';
 
 export default LoginCtrl;
 
-function getErrorMessage(err: FetchError<undefined | { messageId?: string; message?: string }>): string | undefined {
+function getErrorMessage(err: FetchError<undefined | LoginErrorData>): string | undefined {
   switch (err.data?.messageId) {
     case 'password-auth.empty':
     case 'password-auth.failed':
@@ -161,6 +184,8 @@ function getErrorMessage(err: FetchError<undefined | { messageId?: string; messa
         'login.error.blocked',
         'You have exceeded the number of login attempts for this user. Please try again later.'
       );
+    case 'totp.invalid':
+      return t('login.error.invalid-totp-code', 'Invalid verification code');
     default:
       return err.data?.message;
   }
@@ -4,12 +4,13 @@ import { useForm } from 'react-hook-form';
 
 import { type GrafanaTheme2 } from '@grafana/data';
 import { selectors } from '@grafana/e2e-selectors';
-import { t } from '@grafana/i18n';
-import { Button, Input, Field, useStyles2 } from '@grafana/ui';
+import { Trans, t } from '@grafana/i18n';
+import { Alert, Button, Input, Field, useStyles2 } from '@grafana/ui';
 
 import { PasswordField } from '../PasswordField/PasswordField';
 
 import { type FormModel } from './LoginCtrl';
+import { type TOTPChallenge } from './types';
 
 interface Props {
   children: ReactElement;
@@ -17,12 +18,14 @@ interface Props {
   isLoggingIn: boolean;
   passwordHint: string;
   loginHint: string;
+  totpChallenge?: TOTPChallenge;
 }
 
-export const LoginForm = ({ children, onSubmit, isLoggingIn, passwordHint, loginHint }: Props) => {
+export const LoginForm = ({ children, onSubmit, isLoggingIn, passwordHint, loginHint, totpChallenge }: Props) => {
   const styles = useStyles2(getStyles);
   const usernameId = useId();
   const passwordId = useId();
+  const totpCodeId = useId();
   const {
     handleSubmit,
     register,
@@ -58,6 +61,33 @@ export const LoginForm = ({ children, onSubmit, isLoggingIn, passwordHint, login
             placeholder={passwordHint || t('login.form.password-placeholder', 'password')}
           />
         </Field>
+        {totpChallenge?.enrollment && (
+          <Alert severity="info" title={t('login.totp.enrollment-title', 'Set up two-factor authentication')}>
+            <Trans i18nKey="login.totp.enrollment-description">
+              Two-factor authentication is required for your account. Add this secret to your authenticator app, then
+              enter the verification code it generates:
+            </Trans>
+            <div className={styles.totpSecret}>{totpChallenge.enrollment.secret}</div>
+          </Alert>
+        )}
+        {totpChallenge && (
+          <Field
+            label={t('login.form.totp-code-label', 'Verification code')}
+            description={t(
+              'login.form.totp-code-description',
+              'Enter the code from your authenticator app, or one of your recovery codes'
+            )}
+            invalid={!!errors.totpCode}
+            error={errors.totpCode?.message}
+          >
+            <Input
+              {...register('totpCode', { required: t('login.form.totp-code-required', 'Verification code is required') })}
+              id={totpCodeId}
+              autoFocus
+              autoComplete="one-time-code"
+            />
+          </Field>
+        )}
         <Button
           type="submit"
           data-testid={selectors.pages.Login.submit}
@@ -87,5 +117,11 @@ export const getStyles = (theme: GrafanaTheme2) => {
     skipButton: css({
       alignSelf: 'flex-start',
     }),
+
+    totpSecret: css({
+      fontFamily: theme.typography.fontFamilyMonospace,
+      marginTop: theme.spacing(1),
+      wordBreak: 'break-all',
+    }),
   };
 };
@@ -153,4 +153,78 @@ describe('Login Page', () => {
       'You have exceeded the number of login attempts for this user. Please try again later.'
     );
   });
+
+  it('asks for the verification code of users with two-factor authentication', async () => {
+    server.use(
+      customLoginHandler(async ({ request }) => {
+        const body = await request.json();
+        if (body && typeof body === 'object' && 'totpCode' in body && body.totpCode === '123456') {
+          return HttpResponse.json({ message: 'Logged in' });
+        }
+        return HttpResponse.json(
+          { message: 'Verification code required', messageId: 'totp.required' },
+          { status: 401 }
+        );
+      })
+    );
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    const totpCode = await screen.findByRole('textbox', { name: /Verification code/ });
+    expect(screen.queryByRole('alert', { name: 'Login failed' })).not.toBeInTheDocument();
+
+    await userEvent.type(totpCode, '123456');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    await waitFor(() => expect(mockLocationAssign).toHaveBeenCalledWith('/'));
+  });
+
+  it('shows the secret when two-factor authentication has to be set up', async () => {
+    server.use(
+      customLoginHandler(() =>
+        HttpResponse.json(
+          {
+            message: 'Two-factor authentication is required',
+            messageId: 'totp.enrollment-required',
+            extra: { secret: 'JBSWY3DPEHPK3PXP', url: 'otpauth://totp/Grafana:admin?secret=JBSWY3DPEHPK3PXP' },
+          },
+          { status: 401 }
+        )
+      )
+    );
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    expect(await screen.findByText('JBSWY3DPEHPK3PXP')).toBeInTheDocument();
+    expect(screen.getByRole('textbox', { name: /Verification code/ })).toBeInTheDocument();
+  });
+
+  it('shows the recovery codes of users that set up two-factor authentication while logging in', async () => {
+    server.use(
+      customLoginHandler(() =>
+        HttpResponse.json({ message: 'Logged in', redirectUrl: '/', totpRecoveryCodes: ['abcde-fghij', 'klmno-pqrst'] })
+      )
+    );
+
+    render(<LoginPage />);
+
+    await userEvent.type(screen.getByLabelText('Email or username'), 'admin');
+    await userEvent.type(screen.getByLabelText('Password'), 'test');
+    await userEvent.click(screen.getByRole('button', { name: 'Log in' }));
+
+    expect(await screen.findByText('abcde-fghij')).toBeInTheDocument();
+    expect(screen.getByText('klmno-pqrst')).toBeInTheDocument();
+    expect(mockLocationAssign).not.toHaveBeenCalled();
+
+    await userEvent.click(screen.getByRole('button', { name: 'Continue' }));
+    await waitFor(() => expect(mockLocationAssign).toHaveBeenCalledWith('/'));
+  });
 });
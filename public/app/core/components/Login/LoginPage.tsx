@@ -15,6 +15,7 @@ import LoginCtrl from './LoginCtrl';
 import { LoginForm } from './LoginForm';
 import { LoginLayout, InnerBox } from './LoginLayout';
 import { LoginServiceButtons } from './LoginServiceButtons';
+import { TOTPRecoveryCodes } from './TOTPRecoveryCodes';
 import { UserSignup } from './UserSignup';
 
 const LoginPage = () => {
@@ -37,9 +38,17 @@ const LoginPage = () => {
           isChangingPassword,
           showDefaultPasswordWarning,
           loginErrorMessage,
+          totpChallenge,
+          totpRecoveryCodes,
         }) => (
           <LoginLayout isChangingPassword={isChangingPassword}>
-            {!isChangingPassword && (
+            {totpRecoveryCodes && (
+              <InnerBox>
+                <TOTPRecoveryCodes codes={totpRecoveryCodes} onContinue={() => skipPasswordChange()} />
+              </InnerBox>
+            )}
+
+            {!isChangingPassword && !totpRecoveryCodes && (
               <InnerBox>
                 {loginErrorMessage && (
                   <Alert className={styles.alert} severity="error" title={t('login.error.title', 'Login failed')}>
@@ -53,6 +62,7 @@ const LoginPage = () => {
                     loginHint={loginHint}
                     passwordHint={passwordHint}
                     isLoggingIn={isLoggingIn}
+                    totpChallenge={totpChallenge}
                   >
                     <Stack justifyContent="flex-end">
                       {!config.auth.disableLogin && (
@@ -0,0 +1,50 @@
+import { css } from '@emotion/css';
+
+import { type GrafanaTheme2 } from '@grafana/data';
+import { Trans, t } from '@grafana/i18n';
+import { Alert, Button, useStyles2 } from '@grafana/ui';
+
+interface Props {
+  codes: string[];
+  onContinue: () => void;
+}
+
+// TOTPRecoveryCodes shows the recovery codes of a user that set up two-factor authentication while logging in
+export const TOTPRecoveryCodes = ({ codes, onContinue }: Props) => {
+  const styles = useStyles2(getStyles);
+
+  return (
+    <div className={styles.wrapper}>
+      <Alert severity="warning" title={t('login.totp.recovery-codes-title', 'Save your recovery codes')}>
+        <Trans i18nKey="login.totp.recovery-codes-description">
+          Two-factor authentication is set up. Each recovery code can be used once instead of a verification code, if
+          you lose access to your authenticator app. They won&apos;t be shown again.
+        </Trans>
+        <ul className={styles.codes}>
+          {codes.map((code) => (
+            <li key={code}>{code}</li>
+          ))}
+        </ul>
+      </Alert>
+      <Button className={styles.continueButton} onClick={onContinue}>
+        <Trans i18nKey="login.totp.recovery-codes-continue">Continue</Trans>
+      </Button>
+    </div>
+  );
+};
+
+const getStyles = (theme: GrafanaTheme2) => ({
+  wrapper: css({
+    width: '100%',
+    paddingBottom: theme.spacing(2),
+  }),
+  codes: css({
+    fontFamily: theme.typography.fontFamilyMonospace,
+    listStyle: 'none',
+    marginTop: theme.spacing(1),
+  }),
+  continueButton: css({
+    justifyContent: 'center',
+    width: '100%',
+  }),
+});
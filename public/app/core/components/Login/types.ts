@@ -1,4 +1,16 @@
 export interface LoginDTO {
   message: string;
   redirectUrl: string;
+  // totpRecoveryCodes are set when the user set up two-factor authentication while logging in
+  totpRecoveryCodes?: string[];
+}
+
+export interface TOTPChallenge {
+  // enrollment is set when the user has to set up two-factor authentication to log in
+  enrollment?: TOTPEnrollment;
+}
+
+export interface TOTPEnrollment {
+  secret: string;
+  url: string;
 }
@@ -17,7 +17,7 @@ import {
 import { EvalFunction } from 'app/features/alerting/state/alertDef';
 import { ThresholdSelect } from 'app/features/expressions/components/ThresholdSelect';
 import { ToLabel } from 'app/features/expressions/components/ToLabel';
-import { ExpressionDatasourceUID, reducerTypes, thresholdFunctions } from 'app/features/expressions/types';
+import { ExpressionDatasourceUID, simpleReducerTypes, thresholdFunctions } from 'app/features/expressions/types';
 import { isRangeEvaluator } from 'app/features/expressions/utils/expressionTypes';
 
 import { createSimpleConditionExpressions } from '../rule-editor/formProcessing';
@@ -65,7 +65,7 @@ export function RuleConditionSection({ hideEvaluationGroup = false }: RuleCondit
     [getValues, setValue]
   );
 
-  const reducerOptions: Array<ComboboxOption<string>> = reducerTypes
+  const reducerOptions: Array<ComboboxOption<string>> = simpleReducerTypes
     .filter((o) => typeof o.value === 'string')
     .map((o) => ({ value: o.value ?? '', label: o.label ?? String(o.value) }));
 
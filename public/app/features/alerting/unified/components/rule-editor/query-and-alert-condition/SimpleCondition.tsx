@@ -12,7 +12,7 @@ import { ThresholdSelect } from 'app/features/expressions/components/ThresholdSe
 import {
   type ExpressionQuery,
   ExpressionQueryType,
-  reducerTypes,
+  simpleReducerTypes,
   thresholdFunctions,
 } from 'app/features/expressions/types';
 import { getReducerType, isRangeEvaluator } from 'app/features/expressions/utils/expressionTypes';
@@ -95,8 +95,8 @@ export const SimpleConditionEditor = ({
           {simpleCondition.whenField && (
             <InlineField label={t('alerting.simple-condition-editor.label-when', 'WHEN')}>
               <Select
-                options={reducerTypes}
-                value={reducerTypes.find((o) => o.value === simpleCondition.whenField)}
+                options={simpleReducerTypes}
+                value={simpleReducerTypes.find((o) => o.value === simpleCondition.whenField)}
                 onChange={onReducerTypeChange}
                 width={20}
               />
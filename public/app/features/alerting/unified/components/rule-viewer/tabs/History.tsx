@@ -13,14 +13,15 @@ interface HistoryProps {
 }
 
 const History = ({ rule }: HistoryProps) => {
-  // can be "loki", "multiple" or "annotations"
+  // can be "loki", "parquet", "multiple" or "annotations"
   const stateHistoryBackend = config.unifiedAlerting.stateHistory?.backend;
-  // can be "loki" or "annotations"
+  // can be "loki", "parquet" or "annotations"
   const stateHistoryPrimary = config.unifiedAlerting.stateHistory?.primary;
 
-  // if "loki" is either the backend or the primary, show the new state history implementation
+  // if "loki" or "parquet" is either the backend or the primary, show the new state history implementation
   const usingNewAlertStateHistory = [stateHistoryBackend, stateHistoryPrimary].some(
-    (implementation) => implementation === StateHistoryImplementation.Loki
+    (implementation) =>
+      implementation === StateHistoryImplementation.Loki || implementation === StateHistoryImplementation.Parquet
   );
   const implementation = usingNewAlertStateHistory
     ? StateHistoryImplementation.Loki
@@ -12,6 +12,7 @@ const LokiStateHistory = lazy(() => import('../components/rules/state-history/Lo
 
 export enum StateHistoryImplementation {
   Loki = 'loki',
+  Parquet = 'parquet',
   Annotations = 'annotations',
 }
 
@@ -21,14 +22,15 @@ function useStateHistoryModal() {
 
   const styles = useStyles2(getStyles);
 
-  // can be "loki", "multiple" or "annotations"
+  // can be "loki", "parquet", "multiple" or "annotations"
   const stateHistoryBackend = config.unifiedAlerting.stateHistory?.backend;
-  // can be "loki" or "annotations"
+  // can be "loki", "parquet" or "annotations"
   const stateHistoryPrimary = config.unifiedAlerting.stateHistory?.primary;
 
-  // if "loki" is either the backend or the primary, show the new state history implementation
+  // if "loki" or "parquet" is either the backend or the primary, show the new state history implementation
   const usingNewAlertStateHistory = [stateHistoryBackend, stateHistoryPrimary].some(
-    (implementation) => implementation === StateHistoryImplementation.Loki
+    (implementation) =>
+      implementation === StateHistoryImplementation.Loki || implementation === StateHistoryImplementation.Parquet
   );
   const implementation = usingNewAlertStateHistory
     ? StateHistoryImplementation.Loki
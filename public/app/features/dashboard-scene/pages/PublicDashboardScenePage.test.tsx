@@ -200,6 +200,33 @@ describe('PublicDashboardScenePage', () => {
     expect(screen.queryByTestId(componentsSelector.RefreshPicker.runButtonV2)).not.toBeInTheDocument();
     expect(screen.queryByTestId(componentsSelector.RefreshPicker.intervalButtonV2)).not.toBeInTheDocument();
   });
+
+  it('shows variable controls', async () => {
+    const accessToken = 'variables-pubdash-access-token';
+    config.publicDashboardAccessToken = accessToken;
+    setupLoadDashboardMock({
+      dashboard: {
+        ...simpleDashboard,
+        templating: {
+          list: [
+            {
+              name: 'env',
+              type: 'custom',
+              query: 'prod,staging',
+              current: { text: 'prod', value: 'prod' },
+              options: [],
+            },
+          ],
+        },
+      },
+      meta: {},
+    });
+    setup(accessToken);
+
+    await waitForDashboardGridToRender();
+
+    expect(await screen.findByText('env')).toBeInTheDocument();
+  });
 });
 
 describe('given unavailable public dashboard', () => {
@@ -267,6 +294,38 @@ describe('given unavailable public dashboard', () => {
     expect(screen.queryByTestId(publicDashboardSelector.NotAvailable.pausedDescription)).not.toBeInTheDocument();
     expect(screen.getByTestId(publicDashboardSelector.NotAvailable.title)).toBeInTheDocument();
   });
+
+  it('renders public dashboard not available screen when it is expired', async () => {
+    const accessToken = 'expired-pubdash-access-token';
+    config.publicDashboardAccessToken = accessToken;
+
+    setupLoadDashboardMockReject({
+      status: 403,
+      statusText: 'Forbidden',
+      data: {
+        statusCode: 403,
+        messageId: 'publicdashboards.expired',
+        message: 'Dashboard expired',
+      },
+      config: {
+        method: 'GET',
+        url: 'api/public/dashboards/ce159fe139fc4d238a7d9c3ae33fb82b',
+        retry: 0,
+        hideFromInspector: true,
+        headers: {
+          'X-Grafana-Device-Id': 'da48fad0e58ba327fd7d1e6bd17e9c63',
+        },
+      },
+    });
+
+    setup(accessToken);
+
+    await waitForElementToBeRemoved(screen.getByTestId(publicDashboardSceneSelector.loadingPage));
+
+    expect(screen.queryByTestId(publicDashboardSelector.page)).not.toBeInTheDocument();
+    expect(screen.queryByTestId(publicDashboardSelector.NotAvailable.pausedDescription)).not.toBeInTheDocument();
+    expect(screen.getByTestId(publicDashboardSelector.NotAvailable.title)).toBeInTheDocument();
+  });
 });
 
 interface VizOptions {
@@ -21,6 +21,7 @@ import { AppNotificationSeverity } from 'app/types/appNotifications';
 import { DashboardRoutes } from 'app/types/dashboard';
 
 import { type DashboardScene } from '../scene/DashboardScene';
+import { VariableControls } from '../scene/VariableControls';
 
 import { getDashboardScenePageStateManager, type LoadError } from './DashboardScenePageStateManager';
 
@@ -107,6 +108,9 @@ function PublicDashboardSceneRenderer({ model }: SceneComponentProps<DashboardSc
           )}
           <span className={styles.title}>{title}</span>
         </Stack>
+        <div className={styles.variables}>
+          <VariableControls dashboard={model} />
+        </div>
         {!hideTimeControls && (
           <Stack>
             <timePicker.Component model={timePicker} />
@@ -145,6 +149,16 @@ function getStyles(theme: GrafanaTheme2) {
         alignItems: 'stretch',
       },
     }),
+    variables: css({
+      display: 'flex',
+      flex: 1,
+      flexWrap: 'wrap',
+      gap: theme.spacing(1),
+      padding: theme.spacing(0, 2),
+      [theme.breakpoints.down('sm')]: {
+        padding: 0,
+      },
+    }),
     iconTitle: css({
       display: 'none',
       [theme.breakpoints.up('sm')]: {
@@ -177,11 +191,12 @@ function PublicDashboardScenePageError({ error }: { error: LoadError }) {
   const message = error.message;
 
   const isPublicDashboardPaused = statusCode === 403 && messageId === 'publicdashboards.notEnabled';
+  const isPublicDashboardExpired = statusCode === 403 && messageId === 'publicdashboards.expired';
   const isPublicDashboardNotFound = statusCode === 404 && messageId === 'publicdashboards.notFound';
   const isDashboardNotFound = statusCode === 404 && messageId === 'publicdashboards.dashboardNotFound';
 
   const publicDashboardEnabled = isPublicDashboardNotFound ? undefined : !isPublicDashboardPaused;
-  const dashboardNotFound = isPublicDashboardNotFound || isDashboardNotFound;
+  const dashboardNotFound = isPublicDashboardNotFound || isDashboardNotFound || isPublicDashboardExpired;
 
   if (publicDashboardEnabled === false) {
     return <PublicDashboardNotAvailable paused />;
@@ -6,6 +6,7 @@ import {
   isEmailSharingEnabled,
   type PublicDashboard,
   PublicDashboardShareType,
+  supportedTemplateVariableTypes,
 } from 'app/features/dashboard/components/ShareModal/SharePublicDashboard/SharePublicDashboardUtils';
 import { AccessControlAction } from 'app/types/accessControl';
 
@@ -18,11 +19,15 @@ export default function ShareAlerts({ publicDashboard }: { publicDashboard?: Pub
   const { dashboard } = useShareDrawerContext();
   const hasWritePermissions = contextSrv.hasPermission(AccessControlAction.DashboardsPublicWrite);
   const unsupportedDataSources = useUnsupportedDatasources(dashboard);
-  const hasTemplateVariables = (dashboard.state.$variables?.state.variables.length ?? 0) > 0;
+  const hasUnsupportedTemplateVariables = (dashboard.state.$variables?.state.variables ?? []).some(
+    (variable) => !supportedTemplateVariableTypes.includes(variable.state.type)
+  );
 
   return (
     <>
-      {hasWritePermissions && hasTemplateVariables && <UnsupportedTemplateVariablesAlert showDescription={false} />}
+      {hasWritePermissions && hasUnsupportedTemplateVariables && (
+        <UnsupportedTemplateVariablesAlert showDescription={false} />
+      )}
       {!hasWritePermissions && <NoUpsertPermissionsAlert mode={publicDashboard ? 'edit' : 'create'} />}
       {hasWritePermissions && !!unsupportedDataSources?.length && (
         <UnsupportedDataSourcesAlert unsupportedDataSources={unsupportedDataSources.join(', ')} />
@@ -1,14 +1,29 @@
 import { css, cx } from '@emotion/css';
+import { useState } from 'react';
 import { Controller, useForm } from 'react-hook-form';
 
-import { type GrafanaTheme2 } from '@grafana/data';
+import { type DateTime, dateTime, type GrafanaTheme2 } from '@grafana/data';
 import { selectors as e2eSelectors } from '@grafana/e2e-selectors';
 import { Trans, t } from '@grafana/i18n';
 import { sceneGraph } from '@grafana/scenes';
-import { FieldSet, Icon, Label, Spinner, Stack, Switch, Text, TimeRangeLabel, Tooltip, useStyles2 } from '@grafana/ui';
+import {
+  DateTimePicker,
+  FieldSet,
+  Icon,
+  Input,
+  Label,
+  Spinner,
+  Stack,
+  Switch,
+  Text,
+  TimeRangeLabel,
+  Tooltip,
+  useStyles2,
+} from '@grafana/ui';
 import { contextSrv } from 'app/core/services/context_srv';
 import { publicDashboardApi, useUpdatePublicDashboardMutation } from 'app/features/dashboard/api/publicDashboardApi';
 import { type ConfigPublicDashboardForm } from 'app/features/dashboard/components/ShareModal/SharePublicDashboard/ConfigPublicDashboard/ConfigPublicDashboard';
+import { NO_EXPIRY } from 'app/features/dashboard/components/ShareModal/SharePublicDashboard/SharePublicDashboardUtils';
 import { DashboardInteractions } from 'app/features/dashboard-scene/utils/interactions';
 import { AccessControlAction } from 'app/types/accessControl';
 
@@ -31,6 +46,7 @@ export default function ShareConfiguration() {
   const disableForm = isLoading || !hasWritePermissions;
   const timeRangeState = sceneGraph.getTimeRange(dashboard);
   const timeRange = timeRangeState.useState();
+  const [rateLimit, setRateLimit] = useState(String(publicDashboard?.rateLimit ?? 0));
 
   const { handleSubmit, setValue, control } = useForm<FormInput>({
     defaultValues: {
@@ -57,6 +73,35 @@ export default function ShareConfiguration() {
     });
   };
 
+  const onExpiryChange = (date?: DateTime) => {
+    DashboardInteractions.publicDashboardExpiryChanged({ enabled: date !== undefined });
+    update({
+      dashboard: dashboard,
+      payload: {
+        ...publicDashboard!,
+        expiresAt: date ? date.toISOString() : NO_EXPIRY,
+      },
+    });
+  };
+
+  const onRateLimitBlur = () => {
+    const value = Number(rateLimit);
+    const current = publicDashboard?.rateLimit ?? 0;
+    if (!Number.isInteger(value) || value < 0 || value === current) {
+      setRateLimit(String(current));
+      return;
+    }
+
+    DashboardInteractions.publicDashboardRateLimitChanged({ enabled: value > 0 });
+    update({
+      dashboard: dashboard,
+      payload: {
+        ...publicDashboard!,
+        rateLimit: value,
+      },
+    });
+  };
+
   return (
     <Stack direction="column" gap={2}>
       <Text element="p">
@@ -121,6 +166,47 @@ export default function ShareConfiguration() {
                   <Trans i18nKey="public-dashboard.configuration.display-annotations-label">Display annotations</Trans>
                 </Label>
               </Stack>
+              <Stack gap={1} alignItems="center">
+                <Label
+                  style={{ flex: 1 }}
+                  description={t(
+                    'public-dashboard.configuration.expiry-description',
+                    'Pause access to this dashboard at this date'
+                  )}
+                >
+                  <Trans i18nKey="public-dashboard.configuration.expiry-label">Expiry</Trans>
+                </Label>
+                <div data-testid={selectors.expiryDateTimePicker}>
+                  <DateTimePicker
+                    date={publicDashboard?.expiresAt ? dateTime(publicDashboard.expiresAt) : undefined}
+                    onChange={onExpiryChange}
+                    minDate={new Date()}
+                    clearable
+                  />
+                </div>
+              </Stack>
+              <Stack gap={1} alignItems="center">
+                <Label
+                  style={{ flex: 1 }}
+                  description={t(
+                    'public-dashboard.configuration.rate-limit-description',
+                    'Maximum number of requests to this dashboard per minute, including the queries of its panels, 0 means no limit'
+                  )}
+                >
+                  <Trans i18nKey="public-dashboard.configuration.rate-limit-label">Rate limit</Trans>
+                </Label>
+                <Input
+                  type="number"
+                  min={0}
+                  width={16}
+                  value={rateLimit}
+                  onChange={(e) => setRateLimit(e.currentTarget.value)}
+                  onBlur={onRateLimitBlur}
+                  suffix={t('public-dashboard.configuration.rate-limit-suffix', '/ min')}
+                  aria-label={t('public-dashboard.configuration.rate-limit-label', 'Rate limit')}
+                  data-testid={selectors.rateLimitInput}
+                />
+              </Stack>
               <Stack gap={1} alignItems="flex-start">
                 <div className={styles.timeRange}>
                   <Trans i18nKey="public-dashboard.configuration.time-range-label">Time range</Trans>
@@ -1,4 +1,7 @@
-import { screen, waitForElementToBeRemoved } from '@testing-library/react';
+import { screen, waitFor, waitForElementToBeRemoved } from '@testing-library/react';
+import userEvent from '@testing-library/user-event';
+import { http, HttpResponse } from 'msw';
+import { setupServer } from 'msw/node';
 import { render } from 'test/test-utils';
 
 import { getDefaultTimeRange, LoadingState } from '@grafana/data';
@@ -13,6 +16,11 @@ import {
   VizPanel,
   type VizPanelState,
 } from '@grafana/scenes';
+import { backendSrv } from 'app/core/services/backend_srv';
+import {
+  getExistentPublicDashboardResponse,
+  pubdashResponse,
+} from 'app/features/dashboard/components/ShareModal/SharePublicDashboard/utilsTest';
 import { shareDashboardType } from 'app/features/dashboard/components/ShareModal/utils';
 import { DefaultGridLayoutManager } from 'app/features/dashboard-scene/scene/layout-default/DefaultGridLayoutManager';
 
@@ -26,18 +34,50 @@ import { ShareDrawer } from '../../ShareDrawer/ShareDrawer';
 const selectors = e2eSelectors.pages.ShareDashboardModal.PublicDashboard;
 const shareExternallySelector = e2eSelectors.pages.ShareDashboardDrawer.ShareExternally;
 
+const server = setupServer();
+
+jest.mock('@grafana/runtime', () => ({
+  ...jest.requireActual('@grafana/runtime'),
+  getBackendSrv: () => backendSrv,
+}));
+
 setPluginImportUtils({
   importPanelPlugin: (id: string) => Promise.resolve(getPanelPlugin({})),
   getPanelPluginFromCache: (id: string) => undefined,
 });
 
+const getNonExistentPublicDashboardResponse = () =>
+  http.get('/api/dashboards/uid/:dashboardUid/public-dashboards', () => {
+    return HttpResponse.json(
+      {
+        message: 'Public dashboard not found',
+        messageId: 'publicdashboards.notFound',
+        statusCode: 404,
+        traceID: '',
+      },
+      {
+        status: 404,
+      }
+    );
+  });
+
+beforeAll(() => {
+  server.listen({ onUnhandledRequest: 'bypass' });
+});
+
 beforeEach(() => {
+  server.use(getNonExistentPublicDashboardResponse());
   jest.spyOn(contextSrv, 'hasPermission').mockReturnValue(true);
   jest.spyOn(contextSrv, 'hasRole').mockReturnValue(true);
 });
 
 afterEach(() => {
   jest.restoreAllMocks();
+  server.resetHandlers();
+});
+
+afterAll(() => {
+  server.close();
 });
 
 describe('Alerts', () => {
@@ -53,7 +93,7 @@ describe('Alerts', () => {
     expect(screen.queryByTestId(selectors.NoUpsertPermissionsWarningAlert)).toBeInTheDocument();
   });
   it('when dashboard has template variables, warning is shown', async () => {
-    jest.spyOn(sharePublicDashboardUtils, 'dashboardHasTemplateVariables').mockReturnValue(true);
+    jest.spyOn(sharePublicDashboardUtils, 'dashboardHasUnsupportedTemplateVariables').mockReturnValue(true);
 
     await buildAndRenderScenario({
       overrides: {
@@ -85,6 +125,53 @@ describe('Alerts', () => {
   });
 });
 
+describe('Configuration', () => {
+  it('rotates the link after confirmation', async () => {
+    let rotated = false;
+    server.use(
+      getExistentPublicDashboardResponse(),
+      http.post('/api/dashboards/uid/:dashboardUid/public-dashboards/:uid/rotate-access-token', () => {
+        rotated = true;
+        return HttpResponse.json({ ...pubdashResponse, accessToken: 'a-new-access-token' });
+      })
+    );
+
+    await buildAndRenderScenario({});
+    await userEvent.click(await screen.findByTestId(shareExternallySelector.Configuration.rotateAccessTokenButton));
+    expect(rotated).toBe(false);
+
+    await userEvent.click(screen.getByRole('button', { name: 'Rotate link' }));
+    await waitFor(() => expect(rotated).toBe(true));
+  });
+
+  it('updates the rate limit', async () => {
+    let payload: unknown;
+    server.use(
+      getExistentPublicDashboardResponse({ rateLimit: 0 }),
+      http.patch('/api/dashboards/uid/:dashboardUid/public-dashboards/:uid', async ({ request }) => {
+        payload = await request.json();
+        return HttpResponse.json(payload);
+      })
+    );
+
+    await buildAndRenderScenario({});
+    const input = await screen.findByTestId(shareExternallySelector.Configuration.rateLimitInput);
+    await userEvent.clear(input);
+    await userEvent.type(input, '30');
+    await userEvent.tab();
+
+    await waitFor(() => expect(payload).toMatchObject({ rateLimit: 30 }));
+  });
+
+  it('shows the expiry of the dashboard', async () => {
+    server.use(getExistentPublicDashboardResponse({ expiresAt: '2099-01-01T12:00:00Z' }));
+
+    await buildAndRenderScenario({});
+    const picker = await screen.findByTestId(shareExternallySelector.Configuration.expiryDateTimePicker);
+    expect(picker.querySelector('input')).toHaveValue(expect.stringContaining('2099-01-01'));
+  });
+});
+
 async function buildAndRenderScenario({
   overrides,
   panelOverrides,
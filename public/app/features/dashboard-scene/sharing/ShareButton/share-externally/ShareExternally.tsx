@@ -11,6 +11,7 @@ import {
   useDeletePublicDashboardMutation,
   useGetPublicDashboardQuery,
   usePauseOrResumePublicDashboardMutation,
+  useRotatePublicDashboardAccessTokenMutation,
 } from 'app/features/dashboard/api/publicDashboardApi';
 import { Loader } from 'app/features/dashboard/components/ShareModal/SharePublicDashboard/SharePublicDashboard';
 import {
@@ -51,12 +52,14 @@ export class ShareExternally extends SceneObjectBase<SceneShareTabState> impleme
 
 function ShareExternallyRenderer({ model }: SceneComponentProps<ShareExternally>) {
   const [showRevokeAccess, setShowRevokeAccess] = useState(false);
+  const [showRotateAccessToken, setShowRotateAccessToken] = useState(false);
 
   const styles = useStyles2(getStyles);
   const dashboard = getDashboardSceneFor(model);
 
   const { data: publicDashboard, isLoading } = useGetPublicDashboardQuery(dashboard.state.uid!);
   const [deletePublicDashboard, { isLoading: isDeleteLoading }] = useDeletePublicDashboardMutation();
+  const [rotateAccessToken, { isLoading: isRotateLoading }] = useRotatePublicDashboardAccessTokenMutation();
 
   const onRevokeClick = () => {
     setShowRevokeAccess(true);
@@ -72,6 +75,20 @@ function ShareExternallyRenderer({ model }: SceneComponentProps<ShareExternally>
     setShowRevokeAccess(false);
   };
 
+  const onRotateClick = () => {
+    setShowRotateAccessToken(true);
+  };
+
+  const onRotateConfirmClick = async () => {
+    DashboardInteractions.publicDashboardAccessTokenRotated();
+    await rotateAccessToken({
+      dashboard,
+      uid: publicDashboard!.uid,
+      dashboardUid: dashboard.state.uid!,
+    }).unwrap();
+    setShowRotateAccessToken(false);
+  };
+
   if (isLoading) {
     return <Loader />;
   }
@@ -92,9 +109,29 @@ function ShareExternallyRenderer({ model }: SceneComponentProps<ShareExternally>
     );
   }
 
+  if (showRotateAccessToken) {
+    return (
+      <ShareDrawerConfirmAction
+        title={t('public-dashboard.share-externally.rotate-link-button', 'Rotate link')}
+        confirmButtonLabel={t('public-dashboard.share-externally.rotate-link-button', 'Rotate link')}
+        onConfirm={onRotateConfirmClick}
+        onDismiss={() => setShowRotateAccessToken(false)}
+        description={t(
+          'public-dashboard.share-externally.rotate-link-description',
+          'Are you sure you want to rotate the link? The current link stops working and people need the new link to access the dashboard.'
+        )}
+        isActionLoading={isRotateLoading}
+      />
+    );
+  }
+
   return (
     <div className={styles.container}>
-      <ShareExternallyBase publicDashboard={publicDashboard} onRevokeClick={onRevokeClick} />
+      <ShareExternallyBase
+        publicDashboard={publicDashboard}
+        onRevokeClick={onRevokeClick}
+        onRotateClick={onRotateClick}
+      />
     </div>
   );
 }
@@ -102,9 +139,11 @@ function ShareExternallyRenderer({ model }: SceneComponentProps<ShareExternally>
 function ShareExternallyBase({
   publicDashboard,
   onRevokeClick,
+  onRotateClick,
 }: {
   publicDashboard?: PublicDashboard;
   onRevokeClick: () => void;
+  onRotateClick: () => void;
 }) {
   const options = getShareExternallyOptions();
   const getShareType = useMemo(() => {
@@ -134,13 +173,21 @@ function ShareExternallyBase({
       {publicDashboard && (
         <>
           <Divider spacing={0} />
-          <Actions publicDashboard={publicDashboard} onRevokeClick={onRevokeClick} />
+          <Actions publicDashboard={publicDashboard} onRevokeClick={onRevokeClick} onRotateClick={onRotateClick} />
         </>
       )}
     </Stack>
   );
 }
-function Actions({ publicDashboard, onRevokeClick }: { publicDashboard: PublicDashboard; onRevokeClick: () => void }) {
+function Actions({
+  publicDashboard,
+  onRevokeClick,
+  onRotateClick,
+}: {
+  publicDashboard: PublicDashboard;
+  onRevokeClick: () => void;
+  onRotateClick: () => void;
+}) {
   const { dashboard } = useShareDrawerContext();
   const [update, { isLoading: isUpdateLoading }] = usePauseOrResumePublicDashboardMutation();
   const styles = useStyles2(getStyles);
@@ -178,6 +225,20 @@ function Actions({ publicDashboard, onRevokeClick }: { publicDashboard: PublicDa
           >
             <Trans i18nKey="public-dashboard.share-externally.copy-link-button">Copy external link</Trans>
           </ClipboardButton>
+          <Button
+            icon="sync"
+            variant="secondary"
+            fill="outline"
+            tooltip={t(
+              'public-dashboard.share-externally.rotate-link-tooltip',
+              'Replace the link of this dashboard, the current link stops working'
+            )}
+            disabled={isUpdateLoading || !hasWritePermissions}
+            onClick={onRotateClick}
+            data-testid={selectors.Configuration.rotateAccessTokenButton}
+          >
+            <Trans i18nKey="public-dashboard.share-externally.rotate-link-button">Rotate link</Trans>
+          </Button>
           <Button
             icon="trash-alt"
             variant="destructive"
@@ -328,6 +328,15 @@ export const DashboardInteractions = {
   revokePublicDashboardClicked: (properties?: Record<string, unknown>) => {
     reportSharingInteraction('sharing_public_revoke_clicked', properties);
   },
+  publicDashboardAccessTokenRotated: (properties?: Record<string, unknown>) => {
+    reportSharingInteraction('sharing_public_rotate_link_clicked', properties);
+  },
+  publicDashboardExpiryChanged: (properties?: Record<string, unknown>) => {
+    reportSharingInteraction('sharing_public_expiry_changed', properties);
+  },
+  publicDashboardRateLimitChanged: (properties?: Record<string, unknown>) => {
+    reportSharingInteraction('sharing_public_rate_limit_changed', properties);
+  },
 
   // Empty dashboard state interactions:
   emptyDashboardButtonClicked: (properties?: Record<string, unknown>) => {
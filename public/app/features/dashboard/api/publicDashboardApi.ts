@@ -261,6 +261,32 @@ export const publicDashboardApi = createApi({
         'ActiveUserDashboards',
       ],
     }),
+    rotatePublicDashboardAccessToken: builder.mutation<
+      PublicDashboard,
+      { dashboard: DashboardModel | DashboardScene; dashboardUid: string; uid: string }
+    >({
+      query: (params) => ({
+        url: `/dashboards/uid/${params.dashboardUid}/public-dashboards/${params.uid}/rotate-access-token`,
+        method: 'POST',
+      }),
+      async onQueryStarted(_, { dispatch, queryFulfilled }) {
+        await queryFulfilled;
+        dispatch(
+          notifyApp(
+            createSuccessNotification(
+              t(
+                'public-dashboard.share.success-rotate-access-token',
+                'The previous link no longer works, copy the new link to share the dashboard'
+              )
+            )
+          )
+        );
+      },
+      invalidatesTags: (result, error, { dashboardUid }) => [
+        { type: 'PublicDashboard', id: dashboardUid },
+        'AuditTablePublicDashboard',
+      ],
+    }),
     revokeAllAccess: builder.mutation<void, { email: string }>({
       query: () => ({
         url: '',
@@ -283,4 +309,5 @@ export const {
   useRevokeAllAccessMutation,
   usePauseOrResumePublicDashboardMutation,
   useUpdatePublicDashboardAccessMutation,
+  useRotatePublicDashboardAccessTokenMutation,
 } = publicDashboardApi;
@@ -27,7 +27,7 @@ import { SaveDashboardChangesAlert } from '../ModalAlerts/SaveDashboardChangesAl
 import { UnsupportedDataSourcesAlert } from '../ModalAlerts/UnsupportedDataSourcesAlert';
 import { UnsupportedTemplateVariablesAlert } from '../ModalAlerts/UnsupportedTemplateVariablesAlert';
 import {
-  dashboardHasTemplateVariables,
+  dashboardHasUnsupportedTemplateVariables,
   generatePublicDashboardUrl,
   isEmailSharingEnabled,
   type PublicDashboard,
@@ -230,7 +230,7 @@ export function ConfigPublicDashboard({ publicDashboard, unsupportedDatasources
   const dashboard = dashboardState.getModel()!;
   const timeRange = getTimeRange(dashboard.getDefaultTime(), dashboard);
   const hasWritePermissions = contextSrv.hasPermission(AccessControlAction.DashboardsPublicWrite);
-  const hasTemplateVariables = dashboardHasTemplateVariables(dashboard.getVariables());
+  const hasTemplateVariables = dashboardHasUnsupportedTemplateVariables(dashboard.getVariables());
   const [deletePublicDashboard] = useDeletePublicDashboardMutation();
   const onDeletePublicDashboardClick = (onDelete: () => void) => {
     deletePublicDashboard({
@@ -16,7 +16,7 @@ import { useSelector } from 'app/types/store';
 import { NoUpsertPermissionsAlert } from '../ModalAlerts/NoUpsertPermissionsAlert';
 import { UnsupportedDataSourcesAlert } from '../ModalAlerts/UnsupportedDataSourcesAlert';
 import { UnsupportedTemplateVariablesAlert } from '../ModalAlerts/UnsupportedTemplateVariablesAlert';
-import { dashboardHasTemplateVariables } from '../SharePublicDashboardUtils';
+import { dashboardHasUnsupportedTemplateVariables } from '../SharePublicDashboardUtils';
 import { useGetUnsupportedDataSources } from '../useGetUnsupportedDataSources';
 
 import { AcknowledgeCheckboxes } from './AcknowledgeCheckboxes';
@@ -97,7 +97,7 @@ export function CreatePublicDashboard({ hasError }: { hasError?: boolean }) {
   const dashboardState = useSelector((store) => store.dashboard);
   const dashboard = dashboardState.getModel()!;
   const { unsupportedDataSources } = useGetUnsupportedDataSources(dashboard);
-  const hasTemplateVariables = dashboardHasTemplateVariables(dashboard.getVariables());
+  const hasTemplateVariables = dashboardHasUnsupportedTemplateVariables(dashboard.getVariables());
 
   return (
     <CreatePublicDashboardBase
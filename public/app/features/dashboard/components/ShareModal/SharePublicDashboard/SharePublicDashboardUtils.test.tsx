@@ -7,7 +7,7 @@ import { type PanelModel } from 'app/features/dashboard/state/PanelModel';
 
 import {
   type PublicDashboard,
-  dashboardHasTemplateVariables,
+  dashboardHasUnsupportedTemplateVariables,
   publicDashboardPersisted,
   generatePublicDashboardUrl,
   getUnsupportedDashboardDatasources,
@@ -36,16 +36,28 @@ beforeEach(() => {
   );
 });
 
-describe('dashboardHasTemplateVariables', () => {
+describe('dashboardHasUnsupportedTemplateVariables', () => {
   it('false', () => {
     let variables: TypedVariableModel[] = [];
-    expect(dashboardHasTemplateVariables(variables)).toBe(false);
+    expect(dashboardHasUnsupportedTemplateVariables(variables)).toBe(false);
   });
 
   it('true', () => {
     //@ts-ignore
     let variables: TypedVariableModel[] = ['a'];
-    expect(dashboardHasTemplateVariables(variables)).toBe(true);
+    expect(dashboardHasUnsupportedTemplateVariables(variables)).toBe(true);
+  });
+
+  it('false for supported variables', () => {
+    //@ts-ignore
+    let variables: TypedVariableModel[] = [{ type: 'custom' }, { type: 'constant' }, { type: 'query' }];
+    expect(dashboardHasUnsupportedTemplateVariables(variables)).toBe(false);
+  });
+
+  it('true for unsupported variables', () => {
+    //@ts-ignore
+    let variables: TypedVariableModel[] = [{ type: 'custom' }, { type: 'textbox' }];
+    expect(dashboardHasUnsupportedTemplateVariables(variables)).toBe(true);
   });
 });
 
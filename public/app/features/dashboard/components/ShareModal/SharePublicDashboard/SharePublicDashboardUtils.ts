@@ -26,8 +26,13 @@ export interface PublicDashboard extends PublicDashboardSettings {
   dashboardUid: string;
   timeSettings?: object;
   recipients?: Array<{ uid: string; recipient: string }>;
+  expiresAt?: string;
+  rateLimit?: number;
 }
 
+// Sending the zero time as expiry removes the expiry of a public dashboard
+export const NO_EXPIRY = '0001-01-01T00:00:00Z';
+
 export interface SessionDashboard {
   dashboardTitle: string;
   dashboardUid: string;
@@ -43,8 +48,11 @@ export interface SessionUser {
 }
 
 // Instance methods
-export const dashboardHasTemplateVariables = (variables: TypedVariableModel[]): boolean => {
-  return variables.length > 0;
+// Template variables viewers can use in public dashboards, their values are checked by the server
+export const supportedTemplateVariableTypes: string[] = ['custom', 'constant', 'query'];
+
+export const dashboardHasUnsupportedTemplateVariables = (variables: TypedVariableModel[]): boolean => {
+  return variables.some((variable) => !supportedTemplateVariableTypes.includes(variable.type));
 };
 
 export const publicDashboardPersisted = (publicDashboard?: PublicDashboard): boolean => {
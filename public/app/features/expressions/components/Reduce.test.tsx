@@ -0,0 +1,57 @@
+import { selectOptionInTest } from 'test/helpers/selectOptionInTest';
+import { fireEvent, render, screen } from 'test/test-utils';
+
+import { type ExpressionQuery, ExpressionQueryType } from '../types';
+
+import { Reduce } from './Reduce';
+
+const baseQuery: ExpressionQuery = {
+  refId: 'B',
+  type: ExpressionQueryType.reduce,
+  expression: 'A',
+  reducer: 'mean',
+};
+
+const refIds = [{ value: 'A', label: 'A' }];
+
+describe('Reduce', () => {
+  it('should not show the percentile field for reducers without an argument', () => {
+    render(<Reduce refIds={refIds} query={baseQuery} onChange={jest.fn()} />);
+
+    expect(screen.queryByRole('spinbutton')).not.toBeInTheDocument();
+  });
+
+  it('should set the default percentile when selecting the percentile reducer', async () => {
+    const onChange = jest.fn();
+    render(<Reduce refIds={refIds} query={baseQuery} onChange={onChange} />);
+
+    const [, functionSelect] = screen.getAllByRole('combobox');
+    await selectOptionInTest(functionSelect, 'Percentile');
+
+    expect(onChange).toHaveBeenCalledWith({ ...baseQuery, reducer: 'percentile', percentile: 95 });
+  });
+
+  it('should clear the percentile when selecting another reducer', async () => {
+    const onChange = jest.fn();
+    const query = { ...baseQuery, reducer: 'percentile', percentile: 90 };
+    render(<Reduce refIds={refIds} query={query} onChange={onChange} />);
+
+    const [, functionSelect] = screen.getAllByRole('combobox');
+    await selectOptionInTest(functionSelect, 'Standard deviation');
+
+    expect(onChange).toHaveBeenCalledWith({ ...baseQuery, reducer: 'stddev', percentile: undefined });
+  });
+
+  it('should update the percentile', () => {
+    const onChange = jest.fn();
+    const query = { ...baseQuery, reducer: 'percentile', percentile: 95 };
+    render(<Reduce refIds={refIds} query={query} onChange={onChange} />);
+
+    const percentile = screen.getByRole('spinbutton');
+    expect(percentile).toHaveValue(95);
+
+    fireEvent.change(percentile, { target: { value: '99.5' } });
+
+    expect(onChange).toHaveBeenCalledWith({ ...query, percentile: 99.5 });
+  });
+});
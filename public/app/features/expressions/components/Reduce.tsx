@@ -4,7 +4,16 @@ import { CoreApp, type SelectableValue } from '@grafana/data';
 import { Trans, t } from '@grafana/i18n';
 import { Alert, InlineField, InlineFieldRow, Input, Select, TextLink } from '@grafana/ui';
 
-import { type ExpressionQuery, type ExpressionQuerySettings, ReducerMode, reducerModes, reducerTypes } from '../types';
+import {
+  type ExpressionQuery,
+  type ExpressionQuerySettings,
+  PercentileReducer,
+  ReducerMode,
+  reducerModes,
+  reducerTypes,
+} from '../types';
+
+const defaultPercentile = 95;
 
 interface Props {
   app?: CoreApp;
@@ -22,7 +31,16 @@ export const Reduce = ({ labelWidth = 'auto', onChange, app, refIds, query }: Pr
   };
 
   const onSelectReducer = (value: SelectableValue<string>) => {
-    onChange({ ...query, reducer: value.value });
+    if (value.value === PercentileReducer) {
+      onChange({ ...query, reducer: value.value, percentile: query.percentile ?? defaultPercentile });
+      return;
+    }
+    onChange({ ...query, reducer: value.value, percentile: undefined });
+  };
+
+  const onPercentileChanged = (e: React.FormEvent<HTMLInputElement>) => {
+    const value = e.currentTarget.valueAsNumber;
+    onChange({ ...query, percentile: Number.isNaN(value) ? undefined : value });
   };
 
   const onSettingsChanged = (settings: ExpressionQuerySettings) => {
@@ -62,6 +80,29 @@ export const Reduce = ({ labelWidth = 'auto', onChange, app, refIds, query }: Pr
 
   const mode = query.settings?.mode ?? ReducerMode.Strict;
 
+  const percentileField = () => {
+    if (query.reducer !== PercentileReducer) {
+      return;
+    }
+    return (
+      <InlineField
+        label={t('expressions.reduce.percentile.label', 'Percentile')}
+        labelWidth={labelWidth}
+        tooltip={t('expressions.reduce.percentile.tooltip', 'A number between 0 and 100')}
+        invalid={query.percentile === undefined || query.percentile < 0 || query.percentile > 100}
+      >
+        <Input
+          type="number"
+          min={0}
+          max={100}
+          width={10}
+          onChange={onPercentileChanged}
+          value={query.percentile ?? ''}
+        />
+      </InlineField>
+    );
+  };
+
   const replaceWithNumber = () => {
     if (mode !== ReducerMode.ReplaceNonNumbers) {
       return;
@@ -108,6 +149,7 @@ export const Reduce = ({ labelWidth = 'auto', onChange, app, refIds, query }: Pr
         <InlineField label={t('expressions.reduce.label-function', 'Function')} labelWidth={labelWidth}>
           <Select options={reducerTypes} value={reducer} onChange={onSelectReducer} width={20} />
         </InlineField>
+        {percentileField()}
         <InlineField label={t('expressions.reduce.label-mode', 'Mode')} labelWidth={labelWidth}>
           <Select onChange={onModeChanged} options={reducerModes} value={mode} width={25} />
         </InlineField>
@@ -29,6 +29,11 @@ export function isReducerType(value: string): value is ReducerType {
     'count',
     'last',
     'median',
+    'first',
+    'stddev',
+    'variance',
+    'range',
+    'percentile',
     'diff',
     'diff_abs',
     'percent_diff',
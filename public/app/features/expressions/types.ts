@@ -75,6 +75,11 @@ export const expressionTypes: Array<SelectableValue<ExpressionQueryType>> = [
   return true;
 });
 
+/**
+ * The percentile reducer needs the percentile to calculate, set in the percentile field of the expression
+ */
+export const PercentileReducer = 'percentile';
+
 export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.min, label: 'Min', description: 'Get the minimum value' },
   { value: ReducerID.max, label: 'Max', description: 'Get the maximum value' },
@@ -83,8 +88,24 @@ export const reducerTypes: Array<SelectableValue<string>> = [
   { value: ReducerID.sum, label: 'Sum', description: 'Get the sum of all values' },
   { value: ReducerID.count, label: 'Count', description: 'Get the number of values' },
   { value: ReducerID.last, label: 'Last', description: 'Get the last value' },
+  { value: ReducerID.first, label: 'First', description: 'Get the first value' },
+  { value: 'stddev', label: 'Standard deviation', description: 'Get the standard deviation of all values' },
+  { value: ReducerID.variance, label: 'Variance', description: 'Get the variance of all values' },
+  { value: ReducerID.range, label: 'Range', description: 'Get the difference between the maximum and minimum values' },
+  { value: ReducerID.diff, label: 'Difference', description: 'Get the difference between the last and first values' },
+  {
+    value: 'percent_diff',
+    label: 'Difference percent',
+    description: 'Get the difference between the last and first values, in percent of the first value',
+  },
+  { value: PercentileReducer, label: 'Percentile', description: 'Get the value at a percentile of all values' },
 ];
 
+/**
+ * The reducers that don't need an argument, for the editors that can't set one
+ */
+export const simpleReducerTypes = reducerTypes.filter((reducer) => reducer.value !== PercentileReducer);
+
 export enum ReducerMode {
   Strict = '', // backend API wants an empty string to support "strict" mode
   ReplaceNonNumbers = 'replaceNN',
@@ -143,6 +164,8 @@ export const thresholdFunctions: Array<SelectableValue<EvalFunction>> = [
 export interface ExpressionQuery extends DataQuery {
   type: ExpressionQueryType;
   reducer?: string;
+  /** The percentile to calculate, between 0 and 100, when the reducer is percentile */
+  percentile?: number;
   expression?: string;
   window?: string;
   downsampler?: string;
@@ -194,6 +217,11 @@ export type ReducerType =
   | 'count'
   | 'last'
   | 'median'
+  | 'first'
+  | 'stddev'
+  | 'variance'
+  | 'range'
+  | 'percentile'
   | 'diff'
   | 'diff_abs'
   | 'percent_diff'
@@ -29,6 +29,8 @@ export interface PublicDashboardListResponse {
   title: string;
   slug: string;
   isEnabled: boolean;
+  expiresAt?: string;
+  rateLimit?: number;
 }
 
 export interface PublicDashboardListWithPagination extends PublicDashboardListWithPaginationResponse {
@@ -14,6 +14,7 @@ import { type StoreState } from 'app/types/store';
 import { SharedPreferences } from '../../core/components/SharedPreferences/SharedPreferences';
 
 import OrgProfile from './OrgProfile';
+import OrgTOTP from './OrgTOTP';
 import { loadOrganization, updateOrganization } from './state/actions';
 import { setOrganizationName } from './state/reducers';
 
@@ -52,6 +53,7 @@ export const OrgDetailsPage = memo(function OrgDetailsPage({
 
   const isLoading = Object.keys(organization).length === 0;
   const canReadOrg = contextSrv.hasPermission(AccessControlAction.OrgsRead);
+  const canWriteOrg = contextSrv.hasPermission(AccessControlAction.OrgsWrite);
   const canReadPreferences = contextSrv.hasPermission(AccessControlAction.OrgsPreferencesRead);
   const canWritePreferences = contextSrv.hasPermission(AccessControlAction.OrgsPreferencesWrite);
 
@@ -63,6 +65,7 @@ export const OrgDetailsPage = memo(function OrgDetailsPage({
         {!isLoading && (
           <Stack direction="column" gap={3}>
             {canReadOrg && <OrgProfile onSubmit={onUpdateOrganization} orgName={organization.name} />}
+            {canReadOrg && <OrgTOTP disabled={!canWriteOrg} />}
             {canReadPreferences && (
               <SharedPreferences
                 resourceUri={orgResourceUri}
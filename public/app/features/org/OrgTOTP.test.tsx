@@ -0,0 +1,49 @@
+import { screen, waitFor } from '@testing-library/react';
+import userEvent from '@testing-library/user-event';
+import { HttpResponse, http } from 'msw';
+import { render } from 'test/test-utils';
+
+import { setBackendSrv } from '@grafana/runtime';
+import server, { setupMockServer } from '@grafana/test-utils/server';
+import { backendSrv } from 'app/core/services/backend_srv';
+
+import OrgTOTP from './OrgTOTP';
+
+setBackendSrv(backendSrv);
+setupMockServer();
+
+describe('OrgTOTP', () => {
+  it('renders nothing when two-factor authentication is not available', async () => {
+    server.use(
+      http.get('/api/org/totp', () =>
+        HttpResponse.json({ message: 'Two-factor authentication is not enabled' }, { status: 404 })
+      )
+    );
+
+    render(<OrgTOTP disabled={false} />);
+
+    await new Promise((resolve) => setTimeout(resolve, 0));
+    expect(screen.queryByRole('switch', { name: 'Require two-factor authentication' })).not.toBeInTheDocument();
+  });
+
+  it('enforces two-factor authentication for the org', async () => {
+    let enforced = false;
+    server.use(
+      http.get('/api/org/totp', () => HttpResponse.json({ enforced })),
+      http.put('/api/org/totp', async ({ request }) => {
+        const body = await request.json();
+        enforced = !!body && typeof body === 'object' && 'enforced' in body && body.enforced === true;
+        return HttpResponse.json({ message: 'Two-factor authentication enforcement updated' });
+      })
+    );
+
+    render(<OrgTOTP disabled={false} />);
+
+    const enforce = await screen.findByRole('switch', { name: 'Require two-factor authentication' });
+    expect(enforce).not.toBeChecked();
+
+    await userEvent.click(enforce);
+    await waitFor(() => expect(enforced).toBe(true));
+    expect(enforce).toBeChecked();
+  });
+});
@@ -0,0 +1,63 @@
+import { useEffect, useId, useState } from 'react';
+
+import { t } from '@grafana/i18n';
+import { getBackendSrv } from '@grafana/runtime';
+import { Field, FieldSet, Switch } from '@grafana/ui';
+
+export interface Props {
+  disabled: boolean;
+}
+
+interface OrgTOTPEnforcement {
+  enforced: boolean;
+}
+
+// OrgTOTP enforces two-factor authentication for the members of the current org, it's hidden when two-factor
+// authentication isn't enabled on the server
+const OrgTOTP = ({ disabled }: Props) => {
+  const switchId = useId();
+  const [enforced, setEnforced] = useState<boolean>();
+  const [isUpdating, setIsUpdating] = useState(false);
+
+  useEffect(() => {
+    getBackendSrv()
+      .get<OrgTOTPEnforcement>('/api/org/totp', undefined, undefined, { showErrorAlert: false })
+      .then((result) => setEnforced(result.enforced))
+      .catch(() => setEnforced(undefined));
+  }, []);
+
+  if (enforced === undefined) {
+    return null;
+  }
+
+  const onChange = async (value: boolean) => {
+    setIsUpdating(true);
+    try {
+      await getBackendSrv().put('/api/org/totp', { enforced: value });
+      setEnforced(value);
+    } finally {
+      setIsUpdating(false);
+    }
+  };
+
+  return (
+    <FieldSet label={t('org.org-totp.label', 'Two-factor authentication')} disabled={disabled}>
+      <Field
+        label={t('org.org-totp.enforce-label', 'Require two-factor authentication')}
+        description={t(
+          'org.org-totp.enforce-description',
+          "Members logging in with their Grafana password have to set up two-factor authentication on their next login, and can't disable it"
+        )}
+      >
+        <Switch
+          id={switchId}
+          value={enforced}
+          disabled={disabled || isUpdating}
+          onChange={(event) => onChange(event.currentTarget.checked)}
+        />
+      </Field>
+    </FieldSet>
+  );
+};
+
+export default OrgTOTP;
@@ -13,6 +13,7 @@ import UserOrganizations from './UserOrganizations';
 import UserProfileEditForm from './UserProfileEditForm';
 import { UserProfileEditTabs } from './UserProfileEditTabs';
 import UserSessions from './UserSessions';
+import { UserTOTP } from './UserTOTP';
 import { UserTeams } from './UserTeams';
 import { changeUserOrg, initUserProfilePage, revokeUserSession, updateUserProfile } from './state/actions';
 
@@ -75,6 +76,7 @@ export function UserProfileEditPage({
               <UserTeams isLoading={teamsAreLoading} teams={teams} />
               <UserOrganizations isLoading={orgsAreLoading} setUserOrg={changeUserOrg} orgs={orgs} user={user} />
               <UserSessions isLoading={sessionsAreLoading} revokeUserSession={revokeUserSession} sessions={sessions} />
+              <UserTOTP />
             </Stack>
           </Stack>
         </UserProfileEditTabs>
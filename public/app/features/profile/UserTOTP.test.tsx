@@ -0,0 +1,58 @@
+import { screen } from '@testing-library/react';
+import userEvent from '@testing-library/user-event';
+import { HttpResponse, http } from 'msw';
+import { render } from 'test/test-utils';
+
+import { setBackendSrv } from '@grafana/runtime';
+import { getUserTOTPStatusHandler } from '@grafana/test-utils/handlers';
+import server, { setupMockServer } from '@grafana/test-utils/server';
+import { backendSrv } from 'app/core/services/backend_srv';
+
+import { UserTOTP } from './UserTOTP';
+
+setBackendSrv(backendSrv);
+setupMockServer();
+
+describe('UserTOTP', () => {
+  it('renders nothing when two-factor authentication is not available', async () => {
+    render(<UserTOTP />);
+
+    await new Promise((resolve) => setTimeout(resolve, 0));
+    expect(screen.queryByRole('heading', { name: 'Two-factor authentication' })).not.toBeInTheDocument();
+  });
+
+  it('sets up two-factor authentication', async () => {
+    server.use(
+      getUserTOTPStatusHandler({ available: true, enabled: false, enforced: false, recoveryCodesRemaining: 0 }),
+      http.post('/api/user/totp/enroll', () =>
+        HttpResponse.json({ secret: 'JBSWY3DPEHPK3PXP', url: 'otpauth://totp/Grafana:admin?secret=JBSWY3DPEHPK3PXP' })
+      ),
+      http.post('/api/user/totp/activate', () => {
+        server.use(
+          getUserTOTPStatusHandler({ available: true, enabled: true, enforced: false, recoveryCodesRemaining: 1 })
+        );
+        return HttpResponse.json({ codes: ['abcde-fghij'] });
+      })
+    );
+
+    render(<UserTOTP />);
+
+    await userEvent.click(await screen.findByRole('button', { name: 'Set up' }));
+    expect(await screen.findByText('JBSWY3DPEHPK3PXP')).toBeInTheDocument();
+
+    await userEvent.type(screen.getByRole('textbox', { name: /Verification code/ }), '123456');
+    await userEvent.click(screen.getByRole('button', { name: 'Enable' }));
+
+    expect(await screen.findByText('abcde-fghij')).toBeInTheDocument();
+    expect(await screen.findByText(/you have 1 recovery codes left/)).toBeInTheDocument();
+  });
+
+  it('does not allow disabling two-factor authentication when it is enforced', async () => {
+    server.use(getUserTOTPStatusHandler({ available: true, enabled: true, enforced: true, recoveryCodesRemaining: 10 }));
+
+    render(<UserTOTP />);
+
+    expect(await screen.findByRole('button', { name: 'Regenerate recovery codes' })).toBeInTheDocument();
+    expect(screen.queryByRole('button', { name: 'Disable' })).not.toBeInTheDocument();
+  });
+});
@@ -0,0 +1,165 @@
+import { css } from '@emotion/css';
+import { useCallback, useEffect, useId, useState } from 'react';
+
+import { type GrafanaTheme2 } from '@grafana/data';
+import { Trans, t } from '@grafana/i18n';
+import { Alert, Button, Field, Input, Stack, Text, useStyles2 } from '@grafana/ui';
+import { type TOTPEnrollment } from 'app/core/components/Login/types';
+
+import { api } from './api';
+import { type TOTPStatus } from './types';
+
+export const UserTOTP = () => {
+  const styles = useStyles2(getStyles);
+  const codeId = useId();
+  const [status, setStatus] = useState<TOTPStatus>();
+  const [enrollment, setEnrollment] = useState<TOTPEnrollment>();
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>();
+  const [code, setCode] = useState('');
+  const [isUpdating, setIsUpdating] = useState(false);
+
+  const loadStatus = useCallback(async () => {
+    setStatus(await api.loadTOTPStatus());
+  }, []);
+
+  useEffect(() => {
+    loadStatus();
+  }, [loadStatus]);
+
+  const update = async (action: () => Promise<void>) => {
+    setIsUpdating(true);
+    try {
+      await action();
+      setCode('');
+      await loadStatus();
+    } catch (err) {
+      // the error is shown by the backend service, the code is kept so it can be corrected
+      console.error(err);
+    } finally {
+      setIsUpdating(false);
+    }
+  };
+
+  const onEnroll = () => update(async () => setEnrollment(await api.enrollTOTP()));
+  const onActivate = () =>
+    update(async () => {
+      const result = await api.activateTOTP(code);
+      setEnrollment(undefined);
+      setRecoveryCodes(result.codes);
+    });
+  const onRegenerateRecoveryCodes = () =>
+    update(async () => setRecoveryCodes((await api.regenerateTOTPRecoveryCodes(code)).codes));
+  const onDisable = () => update(() => api.disableTOTP(code));
+
+  if (!status?.available) {
+    return null;
+  }
+
+  const codeField = (
+    <Field
+      label={t('profile.user-totp.code-label', 'Verification code')}
+      description={t(
+        'profile.user-totp.code-description',
+        'Enter the code from your authenticator app, or one of your recovery codes'
+      )}
+    >
+      <Input
+        id={codeId}
+        width={30}
+        autoComplete="one-time-code"
+        value={code}
+        onChange={(event) => setCode(event.currentTarget.value)}
+      />
+    </Field>
+  );
+
+  return (
+    <div>
+      <div className="page-sub-heading">
+        <Text variant="h3" element="h2">
+          <Trans i18nKey="profile.user-totp.title">Two-factor authentication</Trans>
+        </Text>
+      </div>
+
+      {recoveryCodes && (
+        <Alert
+          severity="warning"
+          title={t('profile.user-totp.recovery-codes-title', 'Save your recovery codes')}
+          onRemove={() => setRecoveryCodes(undefined)}
+        >
+          <Trans i18nKey="profile.user-totp.recovery-codes-description">
+            Each recovery code can be used once instead of a verification code, if you lose access to your
+            authenticator app. They won&apos;t be shown again.
+          </Trans>
+          <ul className={styles.codes}>
+            {recoveryCodes.map((recoveryCode) => (
+              <li key={recoveryCode}>{recoveryCode}</li>
+            ))}
+          </ul>
+        </Alert>
+      )}
+
+      {status.enabled && (
+        <Stack direction="column" alignItems="flex-start">
+          <Text>
+            <Trans i18nKey="profile.user-totp.enabled" values={{ recoveryCodesRemaining: status.recoveryCodesRemaining }}>
+              Two-factor authentication is enabled, you have {'{{recoveryCodesRemaining}}'} recovery codes left.
+            </Trans>
+          </Text>
+          {codeField}
+          <Stack>
+            <Button variant="secondary" onClick={onRegenerateRecoveryCodes} disabled={!code || isUpdating}>
+              <Trans i18nKey="profile.user-totp.regenerate-recovery-codes">Regenerate recovery codes</Trans>
+            </Button>
+            {!status.enforced && (
+              <Button variant="destructive" onClick={onDisable} disabled={!code || isUpdating}>
+                <Trans i18nKey="profile.user-totp.disable">Disable</Trans>
+              </Button>
+            )}
+          </Stack>
+        </Stack>
+      )}
+
+      {!status.enabled && enrollment && (
+        <Stack direction="column" alignItems="flex-start">
+          <Text>
+            <Trans i18nKey="profile.user-totp.enrollment-description">
+              Add this secret to your authenticator app, then enter the verification code it generates:
+            </Trans>
+          </Text>
+          <div className={styles.secret}>{enrollment.secret}</div>
+          {codeField}
+          <Button onClick={onActivate} disabled={!code || isUpdating}>
+            <Trans i18nKey="profile.user-totp.enable">Enable</Trans>
+          </Button>
+        </Stack>
+      )}
+
+      {!status.enabled && !enrollment && (
+        <Stack direction="column" alignItems="flex-start">
+          <Text>
+            <Trans i18nKey="profile.user-totp.description">
+              Protect your account with a verification code from an authenticator app when logging in with your
+              password.
+            </Trans>
+          </Text>
+          <Button onClick={onEnroll} disabled={isUpdating}>
+            <Trans i18nKey="profile.user-totp.set-up">Set up</Trans>
+          </Button>
+        </Stack>
+      )}
+    </div>
+  );
+};
+
+const getStyles = (theme: GrafanaTheme2) => ({
+  secret: css({
+    fontFamily: theme.typography.fontFamilyMonospace,
+    wordBreak: 'break-all',
+  }),
+  codes: css({
+    fontFamily: theme.typography.fontFamilyMonospace,
+    listStyle: 'none',
+    marginTop: theme.spacing(1),
+  }),
+});
@@ -1,8 +1,9 @@
 import { getBackendSrv } from '@grafana/runtime';
+import { type TOTPEnrollment } from 'app/core/components/Login/types';
 import { type Team } from 'app/types/teams';
 import { type UserDTO, type UserOrg, type UserSession } from 'app/types/user';
 
-import { type ChangePasswordFields, type ProfileUpdateFields } from './types';
+import { type ChangePasswordFields, type ProfileUpdateFields, type TOTPRecoveryCodes, type TOTPStatus } from './types';
 
 async function changePassword(payload: ChangePasswordFields): Promise<void> {
   try {
@@ -46,6 +47,26 @@ async function updateUserProfile(payload: ProfileUpdateFields): Promise<void> {
   }
 }
 
+function loadTOTPStatus(): Promise<TOTPStatus> {
+  return getBackendSrv().get('/api/user/totp');
+}
+
+function enrollTOTP(): Promise<TOTPEnrollment> {
+  return getBackendSrv().post('/api/user/totp/enroll');
+}
+
+function activateTOTP(code: string): Promise<TOTPRecoveryCodes> {
+  return getBackendSrv().post('/api/user/totp/activate', { code });
+}
+
+async function disableTOTP(code: string): Promise<void> {
+  await getBackendSrv().post('/api/user/totp/disable', { code });
+}
+
+function regenerateTOTPRecoveryCodes(code: string): Promise<TOTPRecoveryCodes> {
+  return getBackendSrv().post('/api/user/totp/recovery-codes', { code });
+}
+
 export const api = {
   changePassword,
   revokeUserSession,
@@ -55,4 +76,9 @@ export const api = {
   loadTeams,
   setUserOrg,
   updateUserProfile,
+  loadTOTPStatus,
+  enrollTOTP,
+  activateTOTP,
+  disableTOTP,
+  regenerateTOTPRecoveryCodes,
 };
@@ -9,3 +9,14 @@ export interface ProfileUpdateFields {
   email: string;
   login: string;
 }
+
+export interface TOTPStatus {
+  available: boolean;
+  enabled: boolean;
+  enforced: boolean;
+  recoveryCodesRemaining: number;
+}
+
+export interface TOTPRecoveryCodes {
+  codes: string[];
+}
@@ -1,4 +1,4 @@
-import { render, waitFor } from '@testing-library/react';
+import { render, screen, waitFor } from '@testing-library/react';
 
 import { config } from '@grafana/runtime';
 
@@ -70,4 +70,23 @@ describe('QueryEditor', () => {
       });
     });
   });
+
+  describe('Live history', () => {
+    it('should render the channel field for live history queries', async () => {
+      const query: GrafanaQuery = {
+        refId: 'A',
+        queryType: GrafanaQueryType.LiveHistory,
+        channel: 'stream/telegraf/cpu',
+      };
+
+      render(
+        <QueryEditor datasource={mockDatasource} query={query} onChange={mockOnChange} onRunQuery={mockOnRunQuery} />
+      );
+
+      expect(await screen.findByText('Live History')).toBeInTheDocument();
+      expect(screen.getByText('Channel')).toBeInTheDocument();
+      expect(screen.getByText('stream/telegraf/cpu')).toBeInTheDocument();
+      expect(screen.getByText('Grafana Live - History')).toBeInTheDocument();
+    });
+  });
 });
@@ -32,6 +32,11 @@ const queryTypes: Array<SelectableValue<GrafanaQueryType>> = [
     value: GrafanaQueryType.LiveMeasurements,
     description: 'Stream real-time measurements from Grafana',
   },
+  {
+    label: 'Live History',
+    value: GrafanaQueryType.LiveHistory,
+    description: 'Points kept in the history of a live measurements channel',
+  },
   {
     label: 'List public files',
     value: GrafanaQueryType.List,
@@ -170,9 +175,9 @@ export const QueryEditor = memo(function QueryEditor(props: Props) {
     onRunQuery();
   };
 
-  const renderMeasurementsQuery = () => {
-    let { channel, filter, buffer } = query;
-    let { channels, channelFields } = channelInfo;
+  const renderChannelField = () => {
+    const { channel } = query;
+    let { channels } = channelInfo;
     let currentChannel = channels.find((c) => c.value === channel);
     if (channel && !currentChannel) {
       currentChannel = {
@@ -183,6 +188,27 @@ export const QueryEditor = memo(function QueryEditor(props: Props) {
       channels = [currentChannel, ...channels];
     }
 
+    return (
+      <InlineField label="Channel" grow={true} labelWidth={labelWidth}>
+        <Select
+          options={channels}
+          value={currentChannel || ''}
+          onChange={onChannelChange}
+          allowCustomValue={true}
+          backspaceRemovesValue={true}
+          placeholder="Select measurements channel"
+          isClearable={true}
+          noOptionsMessage="Enter channel name"
+          formatCreateLabel={(input: string) => `Connect to: ${input}`}
+        />
+      </InlineField>
+    );
+  };
+
+  const renderMeasurementsQuery = () => {
+    let { channel, filter, buffer } = query;
+    const { channelFields } = channelInfo;
+
     const distinctFields = new Set<string>();
     const fields: Array<SelectableValue<string>> = channel ? (channelFields[channel] ?? []) : [];
 
@@ -206,19 +232,7 @@ export const QueryEditor = memo(function QueryEditor(props: Props) {
 
     return (
       <>
-        <InlineField label="Channel" grow={true} labelWidth={labelWidth}>
-          <Select
-            options={channels}
-            value={currentChannel || ''}
-            onChange={onChannelChange}
-            allowCustomValue={true}
-            backspaceRemovesValue={true}
-            placeholder="Select measurements channel"
-            isClearable={true}
-            noOptionsMessage="Enter channel name"
-            formatCreateLabel={(input: string) => `Connect to: ${input}`}
-          />
-        </InlineField>
+        {renderChannelField()}
 
         {channel && (
           <Stack direction="row" gap={0}>
@@ -258,6 +272,18 @@ export const QueryEditor = memo(function QueryEditor(props: Props) {
     );
   };
 
+  const renderLiveHistoryQuery = () => {
+    return (
+      <>
+        {renderChannelField()}
+        <Alert title="Grafana Live - History" severity="info">
+          Returns the points of the channel within the time range that are kept in the history. The history must be
+          enabled with the history_max_points or history_max_age settings of the [live] section.
+        </Alert>
+      </>
+    );
+  };
+
   const renderListPublicFiles = () => {
     const { path } = query;
     const folderList = folders ?? [];
@@ -332,6 +358,7 @@ export const QueryEditor = memo(function QueryEditor(props: Props) {
       </InlineFieldRow>
       {queryType === GrafanaQueryType.RandomWalk && config.featureToggles.dashboardTemplates && renderRandomWalkQuery()}
       {queryType === GrafanaQueryType.LiveMeasurements && renderMeasurementsQuery()}
+      {queryType === GrafanaQueryType.LiveHistory && renderLiveHistoryQuery()}
       {queryType === GrafanaQueryType.List && renderListPublicFiles()}
       {queryType === GrafanaQueryType.Snapshot && renderSnapshotQuery()}
     </>
@@ -1,9 +1,12 @@
+import { of } from 'rxjs';
+
 import {
   type AnnotationQueryRequest,
   type DataQueryRequest,
   type DataSourceInstanceSettings,
   dateTime,
 } from '@grafana/data';
+import { DataSourceWithBackend } from '@grafana/runtime';
 import { backendSrv } from 'app/core/services/backend_srv'; // will use the version in __mocks__
 
 import { GrafanaDatasource } from './datasource';
@@ -58,6 +61,28 @@ describe('RandomWalk query', () => {
   });
 });
 
+describe('Live history query', () => {
+  it('sends the query to the backend with the channel interpolated', () => {
+    const ds = new GrafanaDatasource({} as DataSourceInstanceSettings);
+    const backendQuery = jest.spyOn(DataSourceWithBackend.prototype, 'query').mockReturnValue(of({ data: [] }));
+    const request = {
+      targets: [
+        { refId: 'A', queryType: GrafanaQueryType.LiveHistory, channel: 'stream/$var/cpu' },
+        { refId: 'B', queryType: GrafanaQueryType.LiveHistory },
+      ],
+      scopedVars: {},
+    } as unknown as DataQueryRequest<GrafanaQuery>;
+
+    ds.query(request).subscribe();
+
+    expect(backendQuery).toHaveBeenCalledTimes(1);
+    expect(backendQuery.mock.calls[0][0].targets).toEqual([
+      { refId: 'A', queryType: GrafanaQueryType.LiveHistory, channel: 'stream/replaced/cpu' },
+    ]);
+    backendQuery.mockRestore();
+  });
+});
+
 describe('grafana data source', () => {
   const getMock = jest.spyOn(backendSrv, 'get');
 
@@ -142,6 +142,10 @@ export class GrafanaDatasource extends DataSourceWithBackend<GrafanaQuery> {
             buffer,
           })
         );
+      } else if (target.queryType === GrafanaQueryType.LiveHistory) {
+        if (target.channel) {
+          targets.push({ ...target, channel: templateSrv.replace(target.channel, request.scopedVars) });
+        }
       } else if (target.queryType === GrafanaQueryType.RandomWalk || !target.queryType) {
         results.push(
           of({
@@ -17,11 +17,12 @@ export enum GrafanaQueryType {
   // backend
   RandomWalk = 'randomWalk',
   List = 'list',
+  LiveHistory = 'liveHistory',
 }
 
 export interface GrafanaQuery extends DataQuery {
   queryType: GrafanaQueryType; // RandomWalk by default
-  channel?: string;
+  channel?: string; // for live measurements and live history
   filter?: LiveDataFilter;
   buffer?: number;
   path?: string; // for list
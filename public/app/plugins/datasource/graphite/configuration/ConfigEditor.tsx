@@ -8,9 +8,9 @@ import {
   store,
 } from '@grafana/data';
 import { config } from '@grafana/runtime';
-import { Alert, DataSourceHttpSettings, Field, FieldSet, Select, Switch } from '@grafana/ui';
+import { Alert, DataSourceHttpSettings, Field, FieldSet, Input, Select, Switch } from '@grafana/ui';
 
-import { type GraphiteOptions, GraphiteType } from '../types';
+import { type GraphiteOptions, GraphiteRenderFormat, GraphiteType } from '../types';
 import { DEFAULT_GRAPHITE_VERSION, GRAPHITE_VERSIONS } from '../versions';
 
 import { MappingsConfiguration } from './MappingsConfiguration';
@@ -25,6 +25,11 @@ const graphiteTypes = Object.entries(GraphiteType).map(([label, value]) => ({
   value,
 }));
 
+const renderFormats = Object.entries(GraphiteRenderFormat).map(([label, value]) => ({
+  label,
+  value,
+}));
+
 export type Props = DataSourcePluginOptionsEditorProps<GraphiteOptions>;
 
 type State = {
@@ -103,6 +108,39 @@ export class ConfigEditor extends PureComponent<Props, State> {
               />
             </Field>
           )}
+          <Field
+            label="Render format"
+            description="Format of the Graphite render responses. Msgpack and pickle are faster to decode than JSON for large responses. Only used with server access mode."
+          >
+            <Select
+              id="render-format"
+              options={renderFormats}
+              value={renderFormats.find((format) => format.value === this.currentRenderFormat)}
+              width={16}
+              onChange={onUpdateDatasourceJsonDataOptionSelect(this.props, 'renderFormat')}
+            />
+          </Field>
+          <Field
+            label="Max concurrent queries"
+            description="Number of queries of a request sent to Graphite in parallel. Defaults to 10."
+          >
+            <Input
+              id="max-concurrent-queries"
+              type="number"
+              min={1}
+              placeholder="10"
+              width={16}
+              value={options.jsonData.maxConcurrentQueries ?? ''}
+              onChange={(event) => {
+                const value = parseInt(event.currentTarget.value, 10);
+                updateDatasourcePluginJsonDataOption(
+                  this.props,
+                  'maxConcurrentQueries',
+                  Number.isNaN(value) ? undefined : value
+                );
+              }}
+            />
+          </Field>
         </FieldSet>
         <MappingsConfiguration
           mappings={(options.jsonData.importConfiguration?.loki?.mappings || []).map(toString)}
@@ -134,6 +172,10 @@ export class ConfigEditor extends PureComponent<Props, State> {
     );
   }
 
+  private get currentRenderFormat() {
+    return this.props.options.jsonData.renderFormat || GraphiteRenderFormat.JSON;
+  }
+
   private get currentGraphiteVersion() {
     return this.props.options.jsonData.graphiteVersion || DEFAULT_GRAPHITE_VERSION;
   }
@@ -25,6 +25,14 @@ export interface GraphiteOptions extends DataSourceJsonData {
   graphiteType: GraphiteType;
   rollupIndicatorEnabled?: boolean;
   importConfiguration: GraphiteQueryImportConfiguration;
+  renderFormat?: GraphiteRenderFormat;
+  maxConcurrentQueries?: number;
+}
+
+export enum GraphiteRenderFormat {
+  JSON = 'json',
+  Msgpack = 'msgpack',
+  Pickle = 'pickle',
 }
 
 export enum GraphiteType {